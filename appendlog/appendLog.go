@@ -0,0 +1,231 @@
+package appendlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-core-go/hashing"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// ErrNilStorageUnit signals that a nil storage unit has been provided
+var ErrNilStorageUnit = errors.New("nil storage unit")
+
+// ErrNilHasher signals that a nil hasher has been provided
+var ErrNilHasher = errors.New("nil hasher")
+
+// ErrInvalidEntry signals that a stored log entry could not be decoded
+var ErrInvalidEntry = errors.New("invalid log entry")
+
+// headKey stores the number of entries followed by the hash of the last entry. Its length differs from the one of
+// the entry keys, so it never collides with them
+var headKey = []byte("appendLogHead")
+
+const seqLength = 8
+
+// appendLog is a tamper-evident log stored in a storage unit. Each entry is stored under its big endian sequence
+// number as the hash of the previous entry followed by the data, and the hash of an entry covers its sequence
+// number, the previous hash and the data. Changing any historical entry breaks the link recorded by its successor,
+// or by the head for the last entry. The storage unit should be dedicated to the log
+type appendLog struct {
+	mut      sync.Mutex
+	unit     *storageUnit.Unit
+	hasher   hashing.Hasher
+	nextSeq  uint64
+	lastHash []byte
+}
+
+// NewAppendLog creates the append log over the provided storage unit, resuming from the stored head if any
+func NewAppendLog(unit *storageUnit.Unit, hasher hashing.Hasher) (*appendLog, error) {
+	if unit == nil {
+		return nil, ErrNilStorageUnit
+	}
+	if check.IfNil(hasher) {
+		return nil, ErrNilHasher
+	}
+
+	al := &appendLog{
+		unit:     unit,
+		hasher:   hasher,
+		lastHash: make([]byte, hasher.Size()),
+	}
+
+	head, err := unit.Get(headKey)
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return al, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(head) != seqLength+hasher.Size() {
+		return nil, fmt.Errorf("%w: invalid head length %d", ErrInvalidEntry, len(head))
+	}
+
+	al.nextSeq = binary.BigEndian.Uint64(head[:seqLength])
+	al.lastHash = head[seqLength:]
+
+	return al, nil
+}
+
+// Append stores the data chained to the previous entry and returns its sequence number, starting from 0
+func (al *appendLog) Append(data []byte) (uint64, error) {
+	al.mut.Lock()
+	defer al.mut.Unlock()
+
+	seq := al.nextSeq
+	storedValue := make([]byte, 0, len(al.lastHash)+len(data))
+	storedValue = append(storedValue, al.lastHash...)
+	storedValue = append(storedValue, data...)
+	entryHash := al.computeHash(seq, storedValue)
+
+	head := make([]byte, seqLength, seqLength+len(entryHash))
+	binary.BigEndian.PutUint64(head, seq+1)
+	head = append(head, entryHash...)
+
+	err := al.write(seqKey(seq), storedValue, head)
+	if err != nil {
+		return 0, err
+	}
+
+	al.nextSeq = seq + 1
+	al.lastHash = entryHash
+
+	return seq, nil
+}
+
+// write stores the entry and the head in the same batch when the persister allows it, otherwise the entry is
+// written first, so an interrupted append never leaves a head referencing a missing entry
+func (al *appendLog) write(entryKey []byte, storedValue []byte, head []byte) error {
+	err := al.unit.ApplyBatch([]types.Operation{
+		{Type: types.PutOperation, Key: entryKey, Value: storedValue},
+		{Type: types.PutOperation, Key: headKey, Value: head},
+	})
+	if !errors.Is(err, common.ErrBatchNotSupported) {
+		return err
+	}
+
+	err = al.unit.Put(entryKey, storedValue)
+	if err != nil {
+		return err
+	}
+
+	return al.unit.Put(headKey, head)
+}
+
+// Get returns the data of the entry with the provided sequence number, without verifying the chain
+func (al *appendLog) Get(seq uint64) ([]byte, error) {
+	storedValue, err := al.unit.Get(seqKey(seq))
+	if err != nil {
+		return nil, err
+	}
+	if len(storedValue) < al.hasher.Size() {
+		return nil, fmt.Errorf("%w: sequence %d", ErrInvalidEntry, seq)
+	}
+
+	return storedValue[al.hasher.Size():], nil
+}
+
+// Len returns the number of entries in the log
+func (al *appendLog) Len() uint64 {
+	al.mut.Lock()
+	defer al.mut.Unlock()
+
+	return al.nextSeq
+}
+
+// VerifyChain walks the whole log, as stored in the persister of the unit, and checks that each entry hashes to the
+// value recorded by its successor, or by the head for the last entry. It returns true if the chain is intact,
+// otherwise false and the sequence number of the first entry which was altered or removed. The errors other than a
+// missing entry are returned as such
+func (al *appendLog) VerifyChain() (bool, uint64, error) {
+	al.mut.Lock()
+	defer al.mut.Unlock()
+
+	prevHash := make([]byte, al.hasher.Size())
+	for seq := uint64(0); seq < al.nextSeq; seq++ {
+		storedValue, err := al.readEntry(seq)
+		if err != nil {
+			return false, seq, err
+		}
+		if storedValue == nil {
+			return false, seq, nil
+		}
+
+		if !bytes.Equal(storedValue[:al.hasher.Size()], prevHash) {
+			return false, al.brokenLinkEntry(seq, storedValue), nil
+		}
+
+		prevHash = al.computeHash(seq, storedValue)
+	}
+
+	if al.nextSeq > 0 && !bytes.Equal(prevHash, al.lastHash) {
+		return false, al.nextSeq - 1, nil
+	}
+
+	return true, 0, nil
+}
+
+// readEntry reads the stored value of the entry from the persister, returning nil if it is missing or too short
+func (al *appendLog) readEntry(seq uint64) ([]byte, error) {
+	storedValue, err := al.unit.GetFromPersister(seqKey(seq))
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(storedValue) < al.hasher.Size() {
+		return nil, nil
+	}
+
+	return storedValue, nil
+}
+
+// brokenLinkEntry tells which entry was altered when the link of the provided entry to its predecessor fails: the
+// entry itself, if it no longer hashes to the value recorded by its successor, otherwise its predecessor
+func (al *appendLog) brokenLinkEntry(seq uint64, storedValue []byte) uint64 {
+	if seq == 0 {
+		return seq
+	}
+
+	recordedHash := al.lastHash
+	if seq+1 < al.nextSeq {
+		successor, err := al.readEntry(seq + 1)
+		if err != nil || successor == nil {
+			return seq
+		}
+		recordedHash = successor[:al.hasher.Size()]
+	}
+
+	if !bytes.Equal(al.computeHash(seq, storedValue), recordedHash) {
+		return seq
+	}
+
+	return seq - 1
+}
+
+func (al *appendLog) computeHash(seq uint64, storedValue []byte) []byte {
+	buff := make([]byte, 0, seqLength+len(storedValue))
+	buff = append(buff, seqKey(seq)...)
+	buff = append(buff, storedValue...)
+
+	return al.hasher.Compute(string(buff))
+}
+
+func seqKey(seq uint64) []byte {
+	key := make([]byte, seqLength)
+	binary.BigEndian.PutUint64(key, seq)
+
+	return key
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (al *appendLog) IsInterfaceNil() bool {
+	return al == nil
+}
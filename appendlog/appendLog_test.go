@@ -0,0 +1,200 @@
+package appendlog_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-core-go/hashing/blake2b"
+	"github.com/DharitriOne/drt-chain-storage-go/appendlog"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func createUnit(t *testing.T, persister types.Persister) *storageUnit.Unit {
+	cache, _ := lrucache.NewCache(100)
+	unit, err := storageUnit.NewStorageUnit(cache, persister)
+	require.Nil(t, err)
+
+	return unit
+}
+
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+
+	return key
+}
+
+func appendEntries(t *testing.T, numEntries int) (*storageUnit.Unit, types.Persister) {
+	persister := memorydb.New()
+	unit := createUnit(t, persister)
+	al, err := appendlog.NewAppendLog(unit, blake2b.NewBlake2b())
+	require.Nil(t, err)
+
+	for i := 0; i < numEntries; i++ {
+		seq, errAppend := al.Append([]byte(fmt.Sprintf("entry %d", i)))
+		require.Nil(t, errAppend)
+		require.Equal(t, uint64(i), seq)
+	}
+
+	return unit, persister
+}
+
+// tamper rewrites an entry directly in the persister, then reopens the log over a fresh cache
+func tamper(t *testing.T, persister types.Persister, seq uint64, change func(storedValue []byte) []byte) *storageUnit.Unit {
+	storedValue, err := persister.Get(seqKey(seq))
+	require.Nil(t, err)
+	require.Nil(t, persister.Put(seqKey(seq), change(storedValue)))
+
+	return createUnit(t, persister)
+}
+
+func TestNewAppendLog(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		al, err := appendlog.NewAppendLog(nil, blake2b.NewBlake2b())
+		require.True(t, check.IfNil(al))
+		require.Equal(t, appendlog.ErrNilStorageUnit, err)
+	})
+	t.Run("nil hasher should error", func(t *testing.T) {
+		t.Parallel()
+
+		al, err := appendlog.NewAppendLog(createUnit(t, memorydb.New()), nil)
+		require.True(t, check.IfNil(al))
+		require.Equal(t, appendlog.ErrNilHasher, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		al, err := appendlog.NewAppendLog(createUnit(t, memorydb.New()), blake2b.NewBlake2b())
+		require.False(t, check.IfNil(al))
+		require.Nil(t, err)
+		require.Equal(t, uint64(0), al.Len())
+
+		ok, _, err := al.VerifyChain()
+		require.True(t, ok)
+		require.Nil(t, err)
+	})
+}
+
+func TestAppendLog_AppendAndReopen(t *testing.T) {
+	t.Parallel()
+
+	unit, _ := appendEntries(t, 10)
+
+	al, err := appendlog.NewAppendLog(unit, blake2b.NewBlake2b())
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), al.Len())
+
+	data, err := al.Get(3)
+	require.Nil(t, err)
+	require.Equal(t, []byte("entry 3"), data)
+
+	seq, err := al.Append([]byte("entry 10"))
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), seq)
+
+	ok, _, err := al.VerifyChain()
+	require.True(t, ok)
+	require.Nil(t, err)
+}
+
+func TestAppendLog_VerifyChainShouldDetectTampering(t *testing.T) {
+	t.Parallel()
+
+	const numEntries = 10
+
+	t.Run("altered data of a historical entry", func(t *testing.T) {
+		t.Parallel()
+
+		_, persister := appendEntries(t, numEntries)
+		unit := tamper(t, persister, 4, func(storedValue []byte) []byte {
+			storedValue[len(storedValue)-1] ^= 0xFF
+			return storedValue
+		})
+
+		al, _ := appendlog.NewAppendLog(unit, blake2b.NewBlake2b())
+		ok, seq, err := al.VerifyChain()
+		require.False(t, ok)
+		require.Equal(t, uint64(4), seq)
+		require.Nil(t, err)
+	})
+	t.Run("altered link of a historical entry", func(t *testing.T) {
+		t.Parallel()
+
+		_, persister := appendEntries(t, numEntries)
+		unit := tamper(t, persister, 5, func(storedValue []byte) []byte {
+			storedValue[0] ^= 0xFF
+			return storedValue
+		})
+
+		al, _ := appendlog.NewAppendLog(unit, blake2b.NewBlake2b())
+		ok, seq, err := al.VerifyChain()
+		require.False(t, ok)
+		require.Equal(t, uint64(5), seq)
+		require.Nil(t, err)
+	})
+	t.Run("altered entry still cached by the unit", func(t *testing.T) {
+		t.Parallel()
+
+		unit, persister := appendEntries(t, numEntries)
+		storedValue, _ := persister.Get(seqKey(4))
+		storedValue[len(storedValue)-1] ^= 0xFF
+		require.Nil(t, persister.Put(seqKey(4), storedValue))
+
+		al, _ := appendlog.NewAppendLog(unit, blake2b.NewBlake2b())
+		ok, seq, err := al.VerifyChain()
+		require.False(t, ok)
+		require.Equal(t, uint64(4), seq)
+		require.Nil(t, err)
+	})
+	t.Run("altered first entry", func(t *testing.T) {
+		t.Parallel()
+
+		_, persister := appendEntries(t, numEntries)
+		unit := tamper(t, persister, 0, func(storedValue []byte) []byte {
+			return append(storedValue, 'x')
+		})
+
+		al, _ := appendlog.NewAppendLog(unit, blake2b.NewBlake2b())
+		ok, seq, err := al.VerifyChain()
+		require.False(t, ok)
+		require.Equal(t, uint64(0), seq)
+		require.Nil(t, err)
+	})
+	t.Run("altered last entry", func(t *testing.T) {
+		t.Parallel()
+
+		_, persister := appendEntries(t, numEntries)
+		unit := tamper(t, persister, numEntries-1, func(storedValue []byte) []byte {
+			storedValue[len(storedValue)-1] ^= 0xFF
+			return storedValue
+		})
+
+		al, _ := appendlog.NewAppendLog(unit, blake2b.NewBlake2b())
+		ok, seq, err := al.VerifyChain()
+		require.False(t, ok)
+		require.Equal(t, uint64(numEntries-1), seq)
+		require.Nil(t, err)
+	})
+	t.Run("removed entry", func(t *testing.T) {
+		t.Parallel()
+
+		_, persister := appendEntries(t, numEntries)
+		require.Nil(t, persister.Remove(seqKey(6)))
+
+		al, _ := appendlog.NewAppendLog(createUnit(t, persister), blake2b.NewBlake2b())
+		ok, seq, err := al.VerifyChain()
+		require.False(t, ok)
+		require.Equal(t, uint64(6), seq)
+		require.Nil(t, err)
+	})
+}
@@ -0,0 +1,485 @@
+package blobpersister
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*blobPersister)(nil)
+
+var log = logger.GetOrCreate("storage/blobpersister")
+
+// read + write + execute for owner only
+const rwxOwner = 0700
+
+// read + write for owner only
+const rwOwner = 0600
+
+const blobFileExtension = ".blob"
+
+// ErrInvalidBlobDirectory signals that an invalid blob directory was provided
+var ErrInvalidBlobDirectory = errors.New("invalid blob directory")
+
+// ErrInvalidInlineThreshold signals that an invalid inline threshold was provided
+var ErrInvalidInlineThreshold = errors.New("invalid inline threshold")
+
+// ErrInvalidStoredValue signals that the value stored in the inner persister could not be decoded
+var ErrInvalidStoredValue = errors.New("invalid stored value")
+
+// ErrMissingBlobFile signals that a blob pointer references a blob file that does not exist
+var ErrMissingBlobFile = errors.New("missing blob file")
+
+// blobPersister stores the values larger than a threshold in append-only blob files, keeping in the
+// inner persister only a (file, offset, length) pointer to them. Smaller values are stored inline.
+type blobPersister struct {
+	mut             sync.RWMutex
+	inner           types.Persister
+	blobDir         string
+	inlineThreshold int
+	files           map[uint32]*os.File
+	activeFileID    uint32
+	activeFileSize  uint64
+	obsoleteFileIDs []uint32
+	index           map[string]blobPointer
+	closed          bool
+}
+
+// NewBlobPersister creates a persister wrapper that stores the values larger than inlineThreshold
+// in append-only blob files located in blobDir
+func NewBlobPersister(inner types.Persister, blobDir string, inlineThreshold int) (*blobPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	if len(blobDir) == 0 {
+		return nil, ErrInvalidBlobDirectory
+	}
+	if inlineThreshold < 0 {
+		return nil, ErrInvalidInlineThreshold
+	}
+
+	err := os.MkdirAll(blobDir, rwxOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	bp := &blobPersister{
+		inner:           inner,
+		blobDir:         blobDir,
+		inlineThreshold: inlineThreshold,
+		files:           make(map[uint32]*os.File),
+		index:           make(map[string]blobPointer),
+	}
+
+	err = bp.openBlobFiles()
+	if err != nil {
+		bp.closeBlobFiles()
+		return nil, err
+	}
+
+	bp.buildIndex()
+	bp.removeUnreferencedBlobFiles()
+
+	return bp, nil
+}
+
+func (bp *blobPersister) openBlobFiles() error {
+	entries, err := os.ReadDir(bp.blobDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		fileID, ok := parseBlobFileName(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		err = bp.openBlobFile(fileID)
+		if err != nil {
+			return err
+		}
+		if fileID > bp.activeFileID {
+			bp.activeFileID = fileID
+		}
+	}
+
+	_, exists := bp.files[bp.activeFileID]
+	if !exists {
+		err = bp.openBlobFile(bp.activeFileID)
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := bp.files[bp.activeFileID].Stat()
+	if err != nil {
+		return err
+	}
+	bp.activeFileSize = uint64(info.Size())
+
+	return nil
+}
+
+func (bp *blobPersister) openBlobFile(fileID uint32) error {
+	file, err := os.OpenFile(bp.blobFilePath(fileID), os.O_RDWR|os.O_CREATE|os.O_APPEND, rwOwner)
+	if err != nil {
+		return err
+	}
+
+	bp.files[fileID] = file
+
+	return nil
+}
+
+func (bp *blobPersister) blobFilePath(fileID uint32) string {
+	return filepath.Join(bp.blobDir, fmt.Sprintf("%06d%s", fileID, blobFileExtension))
+}
+
+func parseBlobFileName(name string) (uint32, bool) {
+	if !strings.HasSuffix(name, blobFileExtension) {
+		return 0, false
+	}
+
+	fileID, err := strconv.ParseUint(strings.TrimSuffix(name, blobFileExtension), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(fileID), true
+}
+
+func (bp *blobPersister) buildIndex() {
+	bp.inner.RangeKeys(func(key []byte, storedValue []byte) bool {
+		_, pointer, err := decodeStoredValue(storedValue)
+		if err != nil {
+			log.Warn("blobPersister.buildIndex", "key", key, "error", err)
+			return true
+		}
+		if pointer != nil {
+			bp.index[string(key)] = *pointer
+		}
+
+		return true
+	})
+}
+
+// removeUnreferencedBlobFiles deletes the blob files left behind by a compaction that was not followed by a clean close
+func (bp *blobPersister) removeUnreferencedBlobFiles() {
+	referenced := make(map[uint32]struct{})
+	for _, pointer := range bp.index {
+		referenced[pointer.fileID] = struct{}{}
+	}
+
+	for fileID := range bp.files {
+		_, isReferenced := referenced[fileID]
+		if isReferenced || fileID == bp.activeFileID {
+			continue
+		}
+
+		bp.obsoleteFileIDs = append(bp.obsoleteFileIDs, fileID)
+	}
+
+	bp.removeObsoleteFiles()
+}
+
+func (bp *blobPersister) removeObsoleteFiles() {
+	for _, fileID := range bp.obsoleteFileIDs {
+		file, exists := bp.files[fileID]
+		if exists {
+			_ = file.Close()
+			delete(bp.files, fileID)
+		}
+
+		err := os.Remove(bp.blobFilePath(fileID))
+		if err != nil && !os.IsNotExist(err) {
+			log.Warn("blobPersister.removeObsoleteFiles", "file ID", fileID, "error", err)
+		}
+	}
+
+	bp.obsoleteFileIDs = nil
+}
+
+// Put adds the value to the (key, val) persistence medium. Values larger than the inline threshold
+// are appended to the active blob file and only a pointer to them is stored in the inner persister
+func (bp *blobPersister) Put(key, val []byte) error {
+	bp.mut.Lock()
+	defer bp.mut.Unlock()
+
+	if bp.closed {
+		return common.ErrDBIsClosed
+	}
+
+	if len(val) <= bp.inlineThreshold {
+		err := bp.inner.Put(key, encodeInlineValue(val))
+		if err != nil {
+			return err
+		}
+
+		delete(bp.index, string(key))
+		return nil
+	}
+
+	pointer, err := bp.appendBlob(val)
+	if err != nil {
+		return err
+	}
+
+	err = bp.inner.Put(key, pointer.encode())
+	if err != nil {
+		return err
+	}
+
+	bp.index[string(key)] = *pointer
+
+	return nil
+}
+
+func (bp *blobPersister) appendBlob(val []byte) (*blobPointer, error) {
+	n, err := bp.files[bp.activeFileID].Write(val)
+	if err != nil {
+		return nil, err
+	}
+
+	pointer := &blobPointer{
+		fileID: bp.activeFileID,
+		offset: bp.activeFileSize,
+		length: uint32(n),
+	}
+	bp.activeFileSize += uint64(n)
+
+	return pointer, nil
+}
+
+func (bp *blobPersister) readBlob(pointer *blobPointer) ([]byte, error) {
+	file, exists := bp.files[pointer.fileID]
+	if !exists {
+		return nil, fmt.Errorf("%w, file ID %d", ErrMissingBlobFile, pointer.fileID)
+	}
+
+	buff := make([]byte, pointer.length)
+	_, err := file.ReadAt(buff, int64(pointer.offset))
+	if err != nil {
+		return nil, err
+	}
+
+	return buff, nil
+}
+
+func (bp *blobPersister) resolveStoredValue(storedValue []byte) ([]byte, error) {
+	val, pointer, err := decodeStoredValue(storedValue)
+	if err != nil {
+		return nil, err
+	}
+	if pointer == nil {
+		return val, nil
+	}
+
+	return bp.readBlob(pointer)
+}
+
+// Get gets the value associated to the key, reading it from the blob file if needed
+func (bp *blobPersister) Get(key []byte) ([]byte, error) {
+	bp.mut.RLock()
+	defer bp.mut.RUnlock()
+
+	if bp.closed {
+		return nil, common.ErrDBIsClosed
+	}
+
+	storedValue, err := bp.inner.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return bp.resolveStoredValue(storedValue)
+}
+
+// Has returns nil if the given key is present in the persistence medium
+func (bp *blobPersister) Has(key []byte) error {
+	return bp.inner.Has(key)
+}
+
+// Remove removes the data associated to the given key. The space used in the blob file
+// is reclaimed on the next compaction
+func (bp *blobPersister) Remove(key []byte) error {
+	bp.mut.Lock()
+	defer bp.mut.Unlock()
+
+	err := bp.inner.Remove(key)
+	if err != nil {
+		return err
+	}
+
+	delete(bp.index, string(key))
+
+	return nil
+}
+
+// Compact moves all the live blobs in a new blob file, reclaiming the space used by the
+// removed or overwritten values. The old blob files are deleted only after the inner persister
+// is closed, so a crash during compaction can not leave dangling pointers. It returns the number
+// of bytes that will be reclaimed. All operations are blocked during the compaction
+func (bp *blobPersister) Compact() (uint64, error) {
+	bp.mut.Lock()
+	defer bp.mut.Unlock()
+
+	if bp.closed {
+		return 0, common.ErrDBIsClosed
+	}
+
+	oldFileIDs := make([]uint32, 0, len(bp.files))
+	oldFilesSize := uint64(0)
+	for fileID, file := range bp.files {
+		info, err := file.Stat()
+		if err != nil {
+			return 0, err
+		}
+
+		oldFileIDs = append(oldFileIDs, fileID)
+		oldFilesSize += uint64(info.Size())
+	}
+
+	newFileID := bp.activeFileID + 1
+	err := bp.openBlobFile(newFileID)
+	if err != nil {
+		return 0, err
+	}
+	bp.activeFileID = newFileID
+	bp.activeFileSize = 0
+
+	for key, pointer := range bp.index {
+		err = bp.moveBlob(key, pointer)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, fileID := range oldFileIDs {
+		_ = bp.files[fileID].Close()
+		delete(bp.files, fileID)
+	}
+	bp.obsoleteFileIDs = append(bp.obsoleteFileIDs, oldFileIDs...)
+
+	reclaimed := uint64(0)
+	if oldFilesSize > bp.activeFileSize {
+		reclaimed = oldFilesSize - bp.activeFileSize
+	}
+
+	log.Debug("blobPersister.Compact", "blob dir", bp.blobDir, "num blobs", len(bp.index), "reclaimed bytes", reclaimed)
+
+	return reclaimed, nil
+}
+
+func (bp *blobPersister) moveBlob(key string, pointer blobPointer) error {
+	val, err := bp.readBlob(&pointer)
+	if err != nil {
+		return err
+	}
+
+	newPointer, err := bp.appendBlob(val)
+	if err != nil {
+		return err
+	}
+
+	err = bp.inner.Put([]byte(key), newPointer.encode())
+	if err != nil {
+		return err
+	}
+
+	bp.index[key] = *newPointer
+
+	return nil
+}
+
+func (bp *blobPersister) closeBlobFiles() {
+	for fileID, file := range bp.files {
+		err := file.Sync()
+		if err != nil {
+			log.Warn("blobPersister.closeBlobFiles sync", "file ID", fileID, "error", err)
+		}
+
+		_ = file.Close()
+	}
+
+	bp.files = make(map[uint32]*os.File)
+}
+
+// Close closes the blob files and the inner persister
+func (bp *blobPersister) Close() error {
+	bp.mut.Lock()
+	defer bp.mut.Unlock()
+
+	bp.closed = true
+	bp.closeBlobFiles()
+
+	err := bp.inner.Close()
+	if err != nil {
+		return err
+	}
+
+	bp.removeObsoleteFiles()
+
+	return nil
+}
+
+// Destroy removes the inner persister stored data and all the blob files
+func (bp *blobPersister) Destroy() error {
+	bp.mut.Lock()
+	defer bp.mut.Unlock()
+
+	bp.closed = true
+	bp.closeBlobFiles()
+
+	err := bp.inner.Destroy()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(bp.blobDir)
+}
+
+// DestroyClosed removes the already closed inner persister stored data and all the blob files
+func (bp *blobPersister) DestroyClosed() error {
+	err := bp.inner.DestroyClosed()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(bp.blobDir)
+}
+
+// RangeKeys will iterate over all contained (key, value) pairs calling the provided handler.
+// The values stored in blob files are transparently resolved
+func (bp *blobPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	bp.mut.RLock()
+	defer bp.mut.RUnlock()
+
+	bp.inner.RangeKeys(func(key []byte, storedValue []byte) bool {
+		val, err := bp.resolveStoredValue(storedValue)
+		if err != nil {
+			log.Warn("blobPersister.RangeKeys", "key", key, "error", err)
+			return true
+		}
+
+		return handler(key, val)
+	})
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (bp *blobPersister) IsInterfaceNil() bool {
+	return bp == nil
+}
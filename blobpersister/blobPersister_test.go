@@ -0,0 +1,191 @@
+package blobpersister_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/blobpersister"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/stretchr/testify/require"
+)
+
+const inlineThreshold = 8
+
+func countBlobFiles(t *testing.T, blobDir string) int {
+	entries, err := os.ReadDir(blobDir)
+	require.Nil(t, err)
+
+	return len(entries)
+}
+
+func blobFilesSize(t *testing.T, blobDir string) int64 {
+	entries, err := os.ReadDir(blobDir)
+	require.Nil(t, err)
+
+	size := int64(0)
+	for _, entry := range entries {
+		info, errInfo := os.Stat(filepath.Join(blobDir, entry.Name()))
+		require.Nil(t, errInfo)
+		size += info.Size()
+	}
+
+	return size
+}
+
+func TestNewBlobPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		bp, err := blobpersister.NewBlobPersister(nil, t.TempDir(), inlineThreshold)
+		require.True(t, check.IfNil(bp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("empty blob directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		bp, err := blobpersister.NewBlobPersister(&testscommon.PersisterStub{}, "", inlineThreshold)
+		require.True(t, check.IfNil(bp))
+		require.Equal(t, blobpersister.ErrInvalidBlobDirectory, err)
+	})
+	t.Run("negative inline threshold should error", func(t *testing.T) {
+		t.Parallel()
+
+		bp, err := blobpersister.NewBlobPersister(&testscommon.PersisterStub{}, t.TempDir(), -1)
+		require.True(t, check.IfNil(bp))
+		require.Equal(t, blobpersister.ErrInvalidInlineThreshold, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		blobDir := filepath.Join(t.TempDir(), "blobs")
+		bp, err := blobpersister.NewBlobPersister(memorydb.New(), blobDir, inlineThreshold)
+		require.False(t, check.IfNil(bp))
+		require.Nil(t, err)
+		require.Equal(t, 1, countBlobFiles(t, blobDir))
+	})
+}
+
+func TestBlobPersister_PutGetShouldStoreLargeValuesOutOfLine(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	blobDir := t.TempDir()
+	bp, _ := blobpersister.NewBlobPersister(inner, blobDir, inlineThreshold)
+
+	smallValue := []byte("small")
+	largeValue := bytes.Repeat([]byte("a"), 100)
+	require.Nil(t, bp.Put([]byte("small"), smallValue))
+	require.Nil(t, bp.Put([]byte("large"), largeValue))
+
+	storedLarge, _ := inner.Get([]byte("large"))
+	require.Less(t, len(storedLarge), len(largeValue))
+	require.Equal(t, int64(len(largeValue)), blobFilesSize(t, blobDir))
+
+	val, err := bp.Get([]byte("small"))
+	require.Nil(t, err)
+	require.Equal(t, smallValue, val)
+
+	val, err = bp.Get([]byte("large"))
+	require.Nil(t, err)
+	require.Equal(t, largeValue, val)
+
+	require.Nil(t, bp.Has([]byte("large")))
+	require.Nil(t, bp.Remove([]byte("large")))
+	_, err = bp.Get([]byte("large"))
+	require.NotNil(t, err)
+
+	recovered := make(map[string][]byte)
+	bp.RangeKeys(func(key []byte, val []byte) bool {
+		recovered[string(key)] = val
+		return true
+	})
+	require.Equal(t, map[string][]byte{"small": smallValue}, recovered)
+}
+
+func TestBlobPersister_CompactShouldReclaimSpace(t *testing.T) {
+	t.Parallel()
+
+	blobDir := t.TempDir()
+	bp, _ := blobpersister.NewBlobPersister(memorydb.New(), blobDir, inlineThreshold)
+
+	liveValue := bytes.Repeat([]byte("l"), 100)
+	require.Nil(t, bp.Put([]byte("live"), liveValue))
+	require.Nil(t, bp.Put([]byte("overwritten"), bytes.Repeat([]byte("o"), 100)))
+	require.Nil(t, bp.Put([]byte("overwritten"), []byte("inline")))
+	require.Nil(t, bp.Put([]byte("removed"), bytes.Repeat([]byte("r"), 100)))
+	require.Nil(t, bp.Remove([]byte("removed")))
+
+	reclaimed, err := bp.Compact()
+	require.Nil(t, err)
+	require.Equal(t, uint64(200), reclaimed)
+
+	val, err := bp.Get([]byte("live"))
+	require.Nil(t, err)
+	require.Equal(t, liveValue, val)
+
+	val, err = bp.Get([]byte("overwritten"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("inline"), val)
+
+	require.Nil(t, bp.Close())
+	require.Equal(t, 1, countBlobFiles(t, blobDir))
+	require.Equal(t, int64(len(liveValue)), blobFilesSize(t, blobDir))
+}
+
+func TestBlobPersister_ReopenWithLevelDB(t *testing.T) {
+	t.Parallel()
+
+	dbDir := t.TempDir()
+	blobDir := t.TempDir()
+	inner, err := leveldb.NewDB(dbDir, 1, 100, 10)
+	require.Nil(t, err)
+
+	bp, _ := blobpersister.NewBlobPersister(inner, blobDir, inlineThreshold)
+	largeValue := bytes.Repeat([]byte("a"), 100)
+	require.Nil(t, bp.Put([]byte("large"), largeValue))
+	require.Nil(t, bp.Put([]byte("small"), []byte("small")))
+	_, err = bp.Compact()
+	require.Nil(t, err)
+	require.Nil(t, bp.Close())
+
+	inner, err = leveldb.NewDB(dbDir, 1, 100, 10)
+	require.Nil(t, err)
+	bp, err = blobpersister.NewBlobPersister(inner, blobDir, inlineThreshold)
+	require.Nil(t, err)
+
+	val, err := bp.Get([]byte("large"))
+	require.Nil(t, err)
+	require.Equal(t, largeValue, val)
+
+	val, err = bp.Get([]byte("small"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("small"), val)
+
+	_, err = bp.Get([]byte("missing"))
+	require.NotNil(t, err)
+
+	require.Nil(t, bp.Destroy())
+	_, err = os.Stat(blobDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestBlobPersister_OperationsOnClosedPersisterShouldError(t *testing.T) {
+	t.Parallel()
+
+	bp, _ := blobpersister.NewBlobPersister(memorydb.New(), t.TempDir(), inlineThreshold)
+	require.Nil(t, bp.Close())
+
+	require.Equal(t, common.ErrDBIsClosed, bp.Put([]byte("key"), []byte("value")))
+	_, err := bp.Get([]byte("key"))
+	require.Equal(t, common.ErrDBIsClosed, err)
+	_, err = bp.Compact()
+	require.Equal(t, common.ErrDBIsClosed, err)
+}
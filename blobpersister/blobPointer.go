@@ -0,0 +1,59 @@
+package blobpersister
+
+import (
+	"encoding/binary"
+)
+
+const inlineValueMarker = byte(0)
+const blobPointerMarker = byte(1)
+
+// marker + file ID + offset + length
+const blobPointerLength = 1 + 4 + 8 + 4
+
+type blobPointer struct {
+	fileID uint32
+	offset uint64
+	length uint32
+}
+
+func encodeInlineValue(val []byte) []byte {
+	buff := make([]byte, 1+len(val))
+	buff[0] = inlineValueMarker
+	copy(buff[1:], val)
+
+	return buff
+}
+
+func (bp *blobPointer) encode() []byte {
+	buff := make([]byte, blobPointerLength)
+	buff[0] = blobPointerMarker
+	binary.BigEndian.PutUint32(buff[1:5], bp.fileID)
+	binary.BigEndian.PutUint64(buff[5:13], bp.offset)
+	binary.BigEndian.PutUint32(buff[13:17], bp.length)
+
+	return buff
+}
+
+// decodeStoredValue returns either the inline value or the blob pointer contained in the stored value
+func decodeStoredValue(storedValue []byte) ([]byte, *blobPointer, error) {
+	if len(storedValue) == 0 {
+		return nil, nil, ErrInvalidStoredValue
+	}
+
+	switch storedValue[0] {
+	case inlineValueMarker:
+		return storedValue[1:], nil, nil
+	case blobPointerMarker:
+		if len(storedValue) != blobPointerLength {
+			return nil, nil, ErrInvalidStoredValue
+		}
+
+		return nil, &blobPointer{
+			fileID: binary.BigEndian.Uint32(storedValue[1:5]),
+			offset: binary.BigEndian.Uint64(storedValue[5:13]),
+			length: binary.BigEndian.Uint32(storedValue[13:17]),
+		}, nil
+	default:
+		return nil, nil, ErrInvalidStoredValue
+	}
+}
@@ -0,0 +1,163 @@
+package boundedkeycache
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*boundedKeyCache)(nil)
+
+var log = logger.GetOrCreate("storage/boundedkeycache")
+
+// ErrInvalidMaxKeyLength signals that a non-positive maximum key length was provided
+var ErrInvalidMaxKeyLength = errors.New("invalid maximum key length")
+
+// boundedKeyCache protects the inner cache against the oversized keys, as the ones coming from untrusted input:
+// the writes of the keys longer than the maximum length are skipped and their reads are answered as misses,
+// without reaching the inner cache. The number of rejected keys is reported by RejectedKeys
+type boundedKeyCache struct {
+	inner           types.Cacher
+	maxKeyLen       int
+	numRejectedKeys uint64
+}
+
+// NewBoundedKeyCache creates a cache forwarding to the inner cache only the keys of at most maxKeyLen bytes.
+// The inner cache should not be used directly afterwards
+func NewBoundedKeyCache(inner types.Cacher, maxKeyLen int) (*boundedKeyCache, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilCacher
+	}
+	if maxKeyLen < 1 {
+		return nil, ErrInvalidMaxKeyLength
+	}
+
+	return &boundedKeyCache{
+		inner:     inner,
+		maxKeyLen: maxKeyLen,
+	}, nil
+}
+
+// isRejected returns true if the key is too long, counting it as rejected
+func (c *boundedKeyCache) isRejected(key []byte) bool {
+	if len(key) <= c.maxKeyLen {
+		return false
+	}
+
+	atomic.AddUint64(&c.numRejectedKeys, 1)
+	log.Trace("bounded key cache rejected key", "key length", len(key), "max key length", c.maxKeyLen)
+
+	return true
+}
+
+// RejectedKeys returns the number of operations rejected for their key being too long
+func (c *boundedKeyCache) RejectedKeys() uint64 {
+	return atomic.LoadUint64(&c.numRejectedKeys)
+}
+
+// Clear is used to completely clear the cache.
+func (c *boundedKeyCache) Clear() {
+	c.inner.Clear()
+}
+
+// Put adds a value to the cache, skipping the too long keys. Returns true if an eviction occurred.
+func (c *boundedKeyCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	if c.isRejected(key) {
+		return false
+	}
+
+	return c.inner.Put(key, value, sizeInBytes)
+}
+
+// Get looks up a key's value from the cache, the too long keys being reported as missing.
+func (c *boundedKeyCache) Get(key []byte) (value interface{}, ok bool) {
+	if c.isRejected(key) {
+		return nil, false
+	}
+
+	return c.inner.Get(key)
+}
+
+// Has checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (c *boundedKeyCache) Has(key []byte) bool {
+	if c.isRejected(key) {
+		return false
+	}
+
+	return c.inner.Has(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *boundedKeyCache) Peek(key []byte) (value interface{}, ok bool) {
+	if c.isRejected(key) {
+		return nil, false
+	}
+
+	return c.inner.Peek(key)
+}
+
+// HasOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not adds the value.
+// A too long key is neither found nor added.
+func (c *boundedKeyCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	if c.isRejected(key) {
+		return false, false
+	}
+
+	return c.inner.HasOrAdd(key, value, sizeInBytes)
+}
+
+// Remove removes the provided key from the cache.
+func (c *boundedKeyCache) Remove(key []byte) {
+	if len(key) > c.maxKeyLen {
+		return
+	}
+
+	c.inner.Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *boundedKeyCache) Keys() [][]byte {
+	return c.inner.Keys()
+}
+
+// Len returns the number of items in the cache.
+func (c *boundedKeyCache) Len() int {
+	return c.inner.Len()
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (c *boundedKeyCache) SizeInBytesContained() uint64 {
+	return c.inner.SizeInBytesContained()
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (c *boundedKeyCache) MaxSize() int {
+	return c.inner.MaxSize()
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (c *boundedKeyCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	c.inner.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandler removes the handler from the list
+func (c *boundedKeyCache) UnRegisterHandler(id string) {
+	c.inner.UnRegisterHandler(id)
+}
+
+// Close closes the inner cache
+func (c *boundedKeyCache) Close() error {
+	return c.inner.Close()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *boundedKeyCache) IsInterfaceNil() bool {
+	return c == nil
+}
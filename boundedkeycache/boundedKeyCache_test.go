@@ -0,0 +1,89 @@
+package boundedkeycache_test
+
+import (
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/boundedkeycache"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/stretchr/testify/require"
+)
+
+const maxKeyLen = 8
+
+func TestNewBoundedKeyCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil inner cache should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := boundedkeycache.NewBoundedKeyCache(nil, maxKeyLen)
+		require.True(t, check.IfNil(c))
+		require.Equal(t, common.ErrNilCacher, err)
+	})
+	t.Run("invalid max key length should error", func(t *testing.T) {
+		t.Parallel()
+
+		inner, _ := lrucache.NewCache(10)
+		c, err := boundedkeycache.NewBoundedKeyCache(inner, 0)
+		require.True(t, check.IfNil(c))
+		require.Equal(t, boundedkeycache.ErrInvalidMaxKeyLength, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		inner, _ := lrucache.NewCache(10)
+		c, err := boundedkeycache.NewBoundedKeyCache(inner, maxKeyLen)
+		require.False(t, check.IfNil(c))
+		require.Nil(t, err)
+	})
+}
+
+func TestBoundedKeyCache_ShouldForwardTheBoundedKeys(t *testing.T) {
+	t.Parallel()
+
+	inner, _ := lrucache.NewCache(10)
+	c, _ := boundedkeycache.NewBoundedKeyCache(inner, maxKeyLen)
+	key := []byte("12345678")
+
+	c.Put(key, "value", 5)
+	require.True(t, inner.Has(key))
+	value, ok := c.Get(key)
+	require.True(t, ok)
+	require.Equal(t, "value", value)
+	require.True(t, c.Has(key))
+
+	has, added := c.HasOrAdd([]byte("other"), "value", 5)
+	require.False(t, has)
+	require.True(t, added)
+
+	c.Remove(key)
+	require.False(t, inner.Has(key))
+	require.Equal(t, 1, c.Len())
+	require.Equal(t, uint64(0), c.RejectedKeys())
+}
+
+func TestBoundedKeyCache_ShouldRejectTheOversizedKeys(t *testing.T) {
+	t.Parallel()
+
+	inner, _ := lrucache.NewCache(10)
+	c, _ := boundedkeycache.NewBoundedKeyCache(inner, maxKeyLen)
+	key := []byte("123456789")
+	inner.Put(key, "value", 5)
+
+	require.False(t, c.Put(key, "other value", 11))
+	value, ok := c.Get(key)
+	require.False(t, ok)
+	require.Nil(t, value)
+	_, ok = c.Peek(key)
+	require.False(t, ok)
+	require.False(t, c.Has(key))
+	has, added := c.HasOrAdd(key, "other value", 11)
+	require.False(t, has)
+	require.False(t, added)
+
+	innerValue, _ := inner.Get(key)
+	require.Equal(t, "value", innerValue)
+	require.Equal(t, uint64(5), c.RejectedKeys())
+}
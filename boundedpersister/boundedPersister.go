@@ -0,0 +1,386 @@
+package boundedpersister
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*boundedPersister)(nil)
+var _ types.BatchApplier = (*boundedPersister)(nil)
+var _ types.PrefixCounter = (*boundedPersister)(nil)
+var _ types.PrefixRanger = (*boundedPersister)(nil)
+var _ types.PrefixDiskSizer = (*boundedPersister)(nil)
+var _ types.EntryStatsProvider = (*boundedPersister)(nil)
+var _ types.Truncater = (*boundedPersister)(nil)
+var _ types.Snapshotter = (*boundedPersister)(nil)
+var _ types.MultiGetter = (*boundedPersister)(nil)
+var _ types.ContextPersister = (*boundedPersister)(nil)
+
+var log = logger.GetOrCreate("storage/boundedpersister")
+
+// ErrInvalidMaxConcurrentOps signals that an invalid maximum number of concurrent operations was provided
+var ErrInvalidMaxConcurrentOps = errors.New("invalid max concurrent operations")
+
+// boundedPersister limits the number of operations simultaneously executed on the inner persister. The optional
+// persister interfaces are forwarded under the same limit and report the inner persister not supporting them with
+// the usual not supported errors. Close, Destroy and DestroyClosed are never limited.
+type boundedPersister struct {
+	persister types.Persister
+	slots     chan struct{}
+	failFast  bool
+}
+
+// NewBoundedPersister creates a persister wrapper allowing at most maxConcurrentOps simultaneous operations on the
+// inner persister. The operations exceeding the limit wait for a free slot or, if failFast is set, return
+// common.ErrTooManyConcurrentOps
+func NewBoundedPersister(inner types.Persister, maxConcurrentOps int, failFast bool) (*boundedPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	if maxConcurrentOps < 1 {
+		return nil, ErrInvalidMaxConcurrentOps
+	}
+
+	return &boundedPersister{
+		persister: inner,
+		slots:     make(chan struct{}, maxConcurrentOps),
+		failFast:  failFast,
+	}, nil
+}
+
+func (bp *boundedPersister) acquire() error {
+	if !bp.failFast {
+		bp.slots <- struct{}{}
+		return nil
+	}
+
+	select {
+	case bp.slots <- struct{}{}:
+		return nil
+	default:
+		return common.ErrTooManyConcurrentOps
+	}
+}
+
+func (bp *boundedPersister) acquireCtx(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	if bp.failFast {
+		return bp.acquire()
+	}
+
+	select {
+	case bp.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (bp *boundedPersister) release() {
+	<-bp.slots
+}
+
+// Put adds the value to the inner persister
+func (bp *boundedPersister) Put(key, val []byte) error {
+	err := bp.acquire()
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	return bp.persister.Put(key, val)
+}
+
+// Get returns the value from the inner persister
+func (bp *boundedPersister) Get(key []byte) ([]byte, error) {
+	err := bp.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer bp.release()
+
+	return bp.persister.Get(key)
+}
+
+// Has returns nil if the key is found in the inner persister
+func (bp *boundedPersister) Has(key []byte) error {
+	err := bp.acquire()
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	return bp.persister.Has(key)
+}
+
+// Remove removes the key from the inner persister
+func (bp *boundedPersister) Remove(key []byte) error {
+	err := bp.acquire()
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	return bp.persister.Remove(key)
+}
+
+// RangeKeys iterates over the inner persister pairs, holding a single slot for the whole iteration.
+// In fail fast mode, the iteration is skipped if no slot is available
+func (bp *boundedPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	err := bp.acquire()
+	if err != nil {
+		log.Debug("boundedPersister.RangeKeys: iteration skipped", "error", err)
+		return
+	}
+	defer bp.release()
+
+	bp.persister.RangeKeys(handler)
+}
+
+// ApplyBatch atomically applies the operations on the inner persister. An empty batch is a no-op if the inner
+// persister can not apply batches, otherwise ErrBatchNotSupported is returned
+func (bp *boundedPersister) ApplyBatch(ops []types.Operation) error {
+	batchApplier, ok := bp.persister.(types.BatchApplier)
+	if !ok {
+		if len(ops) == 0 {
+			return nil
+		}
+		return common.ErrBatchNotSupported
+	}
+
+	err := bp.acquire()
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	return batchApplier.ApplyBatch(ops)
+}
+
+// CountPrefix returns the number of inner persister keys starting with the provided prefix
+func (bp *boundedPersister) CountPrefix(prefix []byte) (uint64, error) {
+	prefixCounter, ok := bp.persister.(types.PrefixCounter)
+	if !ok {
+		return 0, common.ErrPrefixCountNotSupported
+	}
+
+	err := bp.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer bp.release()
+
+	return prefixCounter.CountPrefix(prefix)
+}
+
+// RangePrefix iterates over the inner persister pairs whose keys start with the provided prefix, holding a single
+// slot for the whole iteration. The inner persisters not able to range over a prefix are fully iterated
+func (bp *boundedPersister) RangePrefix(prefix []byte, handler func(key []byte, value []byte) bool) {
+	err := bp.acquire()
+	if err != nil {
+		log.Debug("boundedPersister.RangePrefix: iteration skipped", "error", err)
+		return
+	}
+	defer bp.release()
+
+	prefixRanger, ok := bp.persister.(types.PrefixRanger)
+	if ok {
+		prefixRanger.RangePrefix(prefix, handler)
+		return
+	}
+
+	bp.persister.RangeKeys(func(key []byte, value []byte) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return true
+		}
+
+		return handler(key, value)
+	})
+}
+
+// PrefixDiskSize returns the estimated size on disk of the inner persister keys starting with the provided prefix
+func (bp *boundedPersister) PrefixDiskSize(prefix []byte) (uint64, error) {
+	prefixDiskSizer, ok := bp.persister.(types.PrefixDiskSizer)
+	if !ok {
+		return 0, common.ErrPrefixDiskSizeNotSupported
+	}
+
+	err := bp.acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer bp.release()
+
+	return prefixDiskSizer.PrefixDiskSize(prefix)
+}
+
+// EntryStats returns the number of live keys and the estimated number of deleted entries of the inner persister
+func (bp *boundedPersister) EntryStats() (uint64, uint64, error) {
+	entryStatsProvider, ok := bp.persister.(types.EntryStatsProvider)
+	if !ok {
+		return 0, 0, common.ErrEntryStatsNotSupported
+	}
+
+	err := bp.acquire()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer bp.release()
+
+	return entryStatsProvider.EntryStats()
+}
+
+// Truncate deletes all the keys of the inner persister
+func (bp *boundedPersister) Truncate() error {
+	truncater, ok := bp.persister.(types.Truncater)
+	if !ok {
+		return common.ErrTruncateNotSupported
+	}
+
+	err := bp.acquire()
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	return truncater.Truncate()
+}
+
+// Snapshot creates a snapshot of the inner persister. Only the snapshot creation is limited, not its reads
+func (bp *boundedPersister) Snapshot() (types.Snapshot, error) {
+	snapshotter, ok := bp.persister.(types.Snapshotter)
+	if !ok {
+		return nil, common.ErrSnapshotNotSupported
+	}
+
+	err := bp.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer bp.release()
+
+	return snapshotter.Snapshot()
+}
+
+// GetExisting returns the values of the provided keys found in the inner persister, holding a single slot for all
+// the keys. The inner persisters not able to read multiple keys are read one key at a time
+func (bp *boundedPersister) GetExisting(keys [][]byte) (map[string][]byte, error) {
+	err := bp.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer bp.release()
+
+	multiGetter, ok := bp.persister.(types.MultiGetter)
+	if ok {
+		return multiGetter.GetExisting(keys)
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		buff, errGet := bp.persister.Get(key)
+		if errors.Is(errGet, common.ErrKeyNotFound) {
+			continue
+		}
+		if errGet != nil {
+			return nil, errGet
+		}
+
+		values[string(key)] = buff
+	}
+
+	return values, nil
+}
+
+// PutCtx adds the value to the inner persister, giving up waiting for a slot when the context is done
+func (bp *boundedPersister) PutCtx(ctx context.Context, key, val []byte) error {
+	err := bp.acquireCtx(ctx)
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	ctxPersister, ok := bp.persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.PutCtx(ctx, key, val)
+	}
+
+	return bp.persister.Put(key, val)
+}
+
+// GetCtx returns the value from the inner persister, giving up waiting for a slot when the context is done
+func (bp *boundedPersister) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	err := bp.acquireCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer bp.release()
+
+	ctxPersister, ok := bp.persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.GetCtx(ctx, key)
+	}
+
+	return bp.persister.Get(key)
+}
+
+// HasCtx checks the key in the inner persister, giving up waiting for a slot when the context is done
+func (bp *boundedPersister) HasCtx(ctx context.Context, key []byte) error {
+	err := bp.acquireCtx(ctx)
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	ctxPersister, ok := bp.persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.HasCtx(ctx, key)
+	}
+
+	return bp.persister.Has(key)
+}
+
+// RemoveCtx removes the key from the inner persister, giving up waiting for a slot when the context is done
+func (bp *boundedPersister) RemoveCtx(ctx context.Context, key []byte) error {
+	err := bp.acquireCtx(ctx)
+	if err != nil {
+		return err
+	}
+	defer bp.release()
+
+	ctxPersister, ok := bp.persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.RemoveCtx(ctx, key)
+	}
+
+	return bp.persister.Remove(key)
+}
+
+// Close closes the inner persister
+func (bp *boundedPersister) Close() error {
+	return bp.persister.Close()
+}
+
+// Destroy removes the inner persister data
+func (bp *boundedPersister) Destroy() error {
+	return bp.persister.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister data
+func (bp *boundedPersister) DestroyClosed() error {
+	return bp.persister.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (bp *boundedPersister) IsInterfaceNil() bool {
+	return bp == nil
+}
@@ -0,0 +1,234 @@
+package boundedpersister_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/boundedpersister"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBoundedPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		bp, err := boundedpersister.NewBoundedPersister(nil, 1, false)
+		require.True(t, check.IfNil(bp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("invalid max concurrent ops should error", func(t *testing.T) {
+		t.Parallel()
+
+		bp, err := boundedpersister.NewBoundedPersister(memorydb.New(), 0, false)
+		require.True(t, check.IfNil(bp))
+		require.Equal(t, boundedpersister.ErrInvalidMaxConcurrentOps, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		bp, err := boundedpersister.NewBoundedPersister(memorydb.New(), 1, false)
+		require.False(t, check.IfNil(bp))
+		require.Nil(t, err)
+	})
+}
+
+func TestBoundedPersister_OperationsShouldReachInnerPersister(t *testing.T) {
+	t.Parallel()
+
+	bp, _ := boundedpersister.NewBoundedPersister(memorydb.New(), 2, false)
+	require.Nil(t, bp.Put([]byte("key"), []byte("value")))
+	require.Nil(t, bp.Has([]byte("key")))
+
+	val, err := bp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), val)
+
+	numPairs := 0
+	bp.RangeKeys(func(key []byte, val []byte) bool {
+		numPairs++
+		return true
+	})
+	require.Equal(t, 1, numPairs)
+
+	require.Nil(t, bp.Remove([]byte("key")))
+	require.NotNil(t, bp.Has([]byte("key")))
+}
+
+func TestBoundedPersister_ShouldBoundConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	maxConcurrentOps := 3
+	current := int32(0)
+	maxObserved := int32(0)
+	inner := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			value := atomic.AddInt32(&current, 1)
+			for {
+				observed := atomic.LoadInt32(&maxObserved)
+				if value <= observed || atomic.CompareAndSwapInt32(&maxObserved, observed, value) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond * 5)
+			atomic.AddInt32(&current, -1)
+
+			return nil, nil
+		},
+	}
+	bp, _ := boundedpersister.NewBoundedPersister(inner, maxConcurrentOps, false)
+
+	wg := sync.WaitGroup{}
+	numOps := 30
+	wg.Add(numOps)
+	for i := 0; i < numOps; i++ {
+		go func() {
+			_, err := bp.Get([]byte("key"))
+			require.Nil(t, err)
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, atomic.LoadInt32(&maxObserved), int32(maxConcurrentOps))
+}
+
+func TestBoundedPersister_FailFastShouldError(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	inner := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			close(started)
+			<-unblock
+			return nil
+		},
+	}
+	bp, _ := boundedpersister.NewBoundedPersister(inner, 1, true)
+
+	done := make(chan error)
+	go func() {
+		done <- bp.Put([]byte("key"), []byte("value"))
+	}()
+	<-started
+
+	_, err := bp.Get([]byte("key"))
+	require.Equal(t, common.ErrTooManyConcurrentOps, err)
+	require.Equal(t, common.ErrTooManyConcurrentOps, bp.Has([]byte("key")))
+	require.Equal(t, common.ErrTooManyConcurrentOps, bp.Remove([]byte("key")))
+
+	close(unblock)
+	require.Nil(t, <-done)
+
+	_, err = bp.Get([]byte("key"))
+	require.Nil(t, err)
+}
+
+func TestBoundedPersister_ShouldForwardTheOptionalInterfaces(t *testing.T) {
+	t.Parallel()
+
+	t.Run("supporting inner persister", func(t *testing.T) {
+		t.Parallel()
+
+		bp, _ := boundedpersister.NewBoundedPersister(memorydb.New(), 2, true)
+		err := bp.ApplyBatch([]types.Operation{
+			{Type: types.PutOperation, Key: []byte("a1"), Value: []byte("v1")},
+			{Type: types.PutOperation, Key: []byte("a2"), Value: []byte("v2")},
+			{Type: types.PutOperation, Key: []byte("b1"), Value: []byte("v3")},
+		})
+		require.Nil(t, err)
+
+		numKeys, err := bp.CountPrefix([]byte("a"))
+		require.Nil(t, err)
+		require.Equal(t, uint64(2), numKeys)
+
+		values, err := bp.GetExisting([][]byte{[]byte("a1"), []byte("missing")})
+		require.Nil(t, err)
+		require.Equal(t, map[string][]byte{"a1": []byte("v1")}, values)
+
+		snapshot, err := bp.Snapshot()
+		require.Nil(t, err)
+		snapshot.Release()
+
+		require.Nil(t, bp.PutCtx(context.Background(), []byte("c1"), []byte("v4")))
+		require.Nil(t, bp.Truncate())
+		require.NotNil(t, bp.Has([]byte("a1")))
+	})
+	t.Run("not supporting inner persister", func(t *testing.T) {
+		t.Parallel()
+
+		inner := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				return nil, common.ErrKeyNotFound
+			},
+			RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+				_ = handler([]byte("a1"), []byte("v1")) && handler([]byte("b1"), []byte("v2"))
+			},
+		}
+		bp, _ := boundedpersister.NewBoundedPersister(inner, 1, true)
+
+		require.Nil(t, bp.ApplyBatch(nil))
+		require.Equal(t, common.ErrBatchNotSupported, bp.ApplyBatch([]types.Operation{
+			{Type: types.RemoveOperation, Key: []byte("a1")},
+		}))
+		_, err := bp.CountPrefix([]byte("a"))
+		require.Equal(t, common.ErrPrefixCountNotSupported, err)
+		_, err = bp.PrefixDiskSize([]byte("a"))
+		require.Equal(t, common.ErrPrefixDiskSizeNotSupported, err)
+		_, _, err = bp.EntryStats()
+		require.Equal(t, common.ErrEntryStatsNotSupported, err)
+		_, err = bp.Snapshot()
+		require.Equal(t, common.ErrSnapshotNotSupported, err)
+		require.Equal(t, common.ErrTruncateNotSupported, bp.Truncate())
+
+		values, err := bp.GetExisting([][]byte{[]byte("a1")})
+		require.Nil(t, err)
+		require.Empty(t, values)
+
+		rangedKeys := make([]string, 0)
+		bp.RangePrefix([]byte("a"), func(key []byte, value []byte) bool {
+			rangedKeys = append(rangedKeys, string(key))
+			return true
+		})
+		require.Equal(t, []string{"a1"}, rangedKeys)
+	})
+}
+
+func TestBoundedPersister_ContextOperationsShouldStopWaitingWhenTheContextIsDone(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	inner := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			close(started)
+			<-unblock
+			return nil
+		},
+	}
+	bp, _ := boundedpersister.NewBoundedPersister(inner, 1, false)
+
+	done := make(chan error)
+	go func() {
+		done <- bp.Put([]byte("key"), []byte("value"))
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := bp.GetCtx(ctx, []byte("key"))
+	require.Equal(t, context.Canceled, err)
+
+	close(unblock)
+	require.Nil(t, <-done)
+}
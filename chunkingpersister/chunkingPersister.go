@@ -0,0 +1,258 @@
+package chunkingpersister
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*chunkingPersister)(nil)
+
+var log = logger.GetOrCreate("storage/chunkingpersister")
+
+// ErrInvalidChunkSize signals that an invalid chunk size was provided
+var ErrInvalidChunkSize = errors.New("invalid chunk size")
+
+// ErrInvalidStoredValue signals that the value stored in the inner persister could not be decoded
+var ErrInvalidStoredValue = errors.New("invalid stored value")
+
+// ErrMissingChunk signals that a chunk referenced by a manifest is missing or has an unexpected length
+var ErrMissingChunk = errors.New("missing chunk")
+
+// chunkingPersister splits the values that do not fit in chunkSize bytes in chunks of chunkSize bytes stored under
+// separate keys, recording in the entry of the key a manifest with the number of chunks. No value written in the
+// inner persister exceeds chunkSize bytes, so backends capping the value size can store arbitrarily large values.
+// The chunks are written before the manifest and the replaced chunks are removed after it, so an interrupted write
+// never leaves a manifest referencing missing chunks. The keys of the inner persister are prefixed by this wrapper,
+// so the inner persister should not be shared with other writers
+type chunkingPersister struct {
+	mutWrite  sync.Mutex
+	inner     types.Persister
+	chunkSize int
+}
+
+// NewChunkingPersister creates a persister wrapper splitting the large values in chunks. The chunk size should
+// allow storing a manifest, of manifestLength bytes
+func NewChunkingPersister(inner types.Persister, chunkSize int) (*chunkingPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	if chunkSize < manifestLength {
+		return nil, fmt.Errorf("%w: minimum is %d", ErrInvalidChunkSize, manifestLength)
+	}
+
+	return &chunkingPersister{
+		inner:     inner,
+		chunkSize: chunkSize,
+	}, nil
+}
+
+// Put stores the value inline if it fits in a chunk, otherwise splits it in chunks
+func (cp *chunkingPersister) Put(key, val []byte) error {
+	cp.mutWrite.Lock()
+	defer cp.mutWrite.Unlock()
+
+	oldManifest, err := cp.getManifest(key)
+	if err != nil {
+		return err
+	}
+
+	if len(val)+1 <= cp.chunkSize {
+		err = cp.inner.Put(logicalKey(key), encodeInlineValue(val))
+	} else {
+		err = cp.putChunked(key, val, oldManifest)
+	}
+	if err != nil {
+		return err
+	}
+
+	cp.removeChunks(key, oldManifest)
+
+	return nil
+}
+
+func (cp *chunkingPersister) putChunked(key []byte, val []byte, oldManifest *manifest) error {
+	m := &manifest{
+		numChunks:   uint32((len(val) + cp.chunkSize - 1) / cp.chunkSize),
+		totalLength: uint64(len(val)),
+	}
+	if oldManifest != nil {
+		m.generation = oldManifest.generation + 1
+	}
+
+	for i := uint32(0); i < m.numChunks; i++ {
+		start := int(i) * cp.chunkSize
+		end := start + cp.chunkSize
+		if end > len(val) {
+			end = len(val)
+		}
+
+		err := cp.inner.Put(chunkKey(key, m.generation, i), val[start:end])
+		if err != nil {
+			cp.removeChunks(key, &manifest{generation: m.generation, numChunks: i})
+			return err
+		}
+	}
+
+	err := cp.inner.Put(logicalKey(key), m.encode())
+	if err != nil {
+		cp.removeChunks(key, m)
+		return err
+	}
+
+	return nil
+}
+
+// removeChunks removes the chunks of the provided manifest, if any. The failures are only logged, as the chunks are
+// no longer referenced
+func (cp *chunkingPersister) removeChunks(key []byte, m *manifest) {
+	if m == nil {
+		return
+	}
+
+	for i := uint32(0); i < m.numChunks; i++ {
+		err := cp.inner.Remove(chunkKey(key, m.generation, i))
+		if err != nil {
+			log.Warn("chunkingPersister: cannot remove chunk", "key", key, "index", i, "error", err)
+		}
+	}
+}
+
+// getManifest returns the manifest stored for the key, nil if the key is missing or stored inline
+func (cp *chunkingPersister) getManifest(key []byte) (*manifest, error) {
+	storedValue, err := cp.inner.Get(logicalKey(key))
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, m, err := decodeStoredValue(storedValue)
+
+	return m, err
+}
+
+// Get returns the value of the key, reassembling it from its chunks if needed
+func (cp *chunkingPersister) Get(key []byte) ([]byte, error) {
+	storedValue, err := cp.inner.Get(logicalKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.decode(key, storedValue)
+}
+
+func (cp *chunkingPersister) decode(key []byte, storedValue []byte) ([]byte, error) {
+	val, m, err := decodeStoredValue(storedValue)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return val, nil
+	}
+
+	val = make([]byte, 0, m.totalLength)
+	for i := uint32(0); i < m.numChunks; i++ {
+		chunk, errGet := cp.inner.Get(chunkKey(key, m.generation, i))
+		if errGet != nil {
+			return nil, fmt.Errorf("%w: index %d, %v", ErrMissingChunk, i, errGet)
+		}
+
+		val = append(val, chunk...)
+	}
+	if uint64(len(val)) != m.totalLength {
+		return nil, fmt.Errorf("%w: expected length %d, got %d", ErrMissingChunk, m.totalLength, len(val))
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the key is present
+func (cp *chunkingPersister) Has(key []byte) error {
+	return cp.inner.Has(logicalKey(key))
+}
+
+// Remove deletes the key and all its chunks
+func (cp *chunkingPersister) Remove(key []byte) error {
+	cp.mutWrite.Lock()
+	defer cp.mutWrite.Unlock()
+
+	m, err := cp.getManifest(key)
+	if err != nil {
+		return err
+	}
+
+	err = cp.inner.Remove(logicalKey(key))
+	if err != nil {
+		return err
+	}
+
+	cp.removeChunks(key, m)
+
+	return nil
+}
+
+// RangeKeys iterates over the logical keys with their reassembled values, hiding the chunk keys. The logical
+// entries are collected before calling the handler, so the chunks are read outside the inner iteration
+func (cp *chunkingPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	type storedEntry struct {
+		key         []byte
+		storedValue []byte
+	}
+
+	entries := make([]storedEntry, 0)
+	cp.inner.RangeKeys(func(key []byte, val []byte) bool {
+		if len(key) == 0 || key[0] != logicalKeyPrefix {
+			return true
+		}
+
+		entries = append(entries, storedEntry{
+			key:         append([]byte{}, key[1:]...),
+			storedValue: append([]byte{}, val...),
+		})
+
+		return true
+	})
+
+	for _, e := range entries {
+		val, err := cp.decode(e.key, e.storedValue)
+		if err != nil {
+			log.Warn("chunkingPersister.RangeKeys: cannot read value", "key", e.key, "error", err)
+			continue
+		}
+
+		if !handler(e.key, val) {
+			return
+		}
+	}
+}
+
+// Close closes the inner persister
+func (cp *chunkingPersister) Close() error {
+	return cp.inner.Close()
+}
+
+// Destroy removes the inner persister data
+func (cp *chunkingPersister) Destroy() error {
+	return cp.inner.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister data
+func (cp *chunkingPersister) DestroyClosed() error {
+	return cp.inner.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cp *chunkingPersister) IsInterfaceNil() bool {
+	return cp == nil
+}
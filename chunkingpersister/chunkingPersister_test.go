@@ -0,0 +1,275 @@
+package chunkingpersister_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/chunkingpersister"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+const chunkSize = 32
+
+func countInnerKeys(inner types.Persister) int {
+	numKeys := 0
+	inner.RangeKeys(func(_ []byte, _ []byte) bool {
+		numKeys++
+		return true
+	})
+
+	return numKeys
+}
+
+func maxInnerValueLength(inner types.Persister) int {
+	maxLength := 0
+	inner.RangeKeys(func(_ []byte, val []byte) bool {
+		if len(val) > maxLength {
+			maxLength = len(val)
+		}
+		return true
+	})
+
+	return maxLength
+}
+
+func TestNewChunkingPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		cp, err := chunkingpersister.NewChunkingPersister(nil, chunkSize)
+		require.True(t, check.IfNil(cp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("chunk size too small should error", func(t *testing.T) {
+		t.Parallel()
+
+		cp, err := chunkingpersister.NewChunkingPersister(&testscommon.PersisterStub{}, 8)
+		require.True(t, check.IfNil(cp))
+		require.True(t, errors.Is(err, chunkingpersister.ErrInvalidChunkSize))
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		cp, err := chunkingpersister.NewChunkingPersister(&testscommon.PersisterStub{}, chunkSize)
+		require.False(t, check.IfNil(cp))
+		require.Nil(t, err)
+	})
+}
+
+func TestChunkingPersister_PutGetBoundarySizes(t *testing.T) {
+	t.Parallel()
+
+	sizes := []int{0, 1, chunkSize - 2, chunkSize - 1, chunkSize, chunkSize + 1, 2 * chunkSize, 2*chunkSize + 1, 10 * chunkSize}
+	for _, size := range sizes {
+		size := size
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			t.Parallel()
+
+			inner := memorydb.New()
+			cp, _ := chunkingpersister.NewChunkingPersister(inner, chunkSize)
+
+			val := make([]byte, size)
+			for i := range val {
+				val[i] = byte(i)
+			}
+
+			err := cp.Put([]byte("key"), val)
+			require.Nil(t, err)
+
+			recovered, err := cp.Get([]byte("key"))
+			require.Nil(t, err)
+			require.Equal(t, len(val), len(recovered))
+			require.True(t, bytes.Equal(val, recovered))
+			require.Nil(t, cp.Has([]byte("key")))
+			require.LessOrEqual(t, maxInnerValueLength(inner), chunkSize)
+
+			expectedInnerKeys := 1
+			if size+1 > chunkSize {
+				expectedInnerKeys += (size + chunkSize - 1) / chunkSize
+			}
+			require.Equal(t, expectedInnerKeys, countInnerKeys(inner))
+		})
+	}
+}
+
+func TestChunkingPersister_OverwriteShouldRemoveOldChunks(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	cp, _ := chunkingpersister.NewChunkingPersister(inner, chunkSize)
+
+	large := bytes.Repeat([]byte("a"), 5*chunkSize)
+	err := cp.Put([]byte("key"), large)
+	require.Nil(t, err)
+	require.Equal(t, 6, countInnerKeys(inner))
+
+	smaller := bytes.Repeat([]byte("b"), 2*chunkSize)
+	err = cp.Put([]byte("key"), smaller)
+	require.Nil(t, err)
+	require.Equal(t, 3, countInnerKeys(inner))
+
+	recovered, err := cp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, smaller, recovered)
+
+	err = cp.Put([]byte("key"), []byte("inline"))
+	require.Nil(t, err)
+	require.Equal(t, 1, countInnerKeys(inner))
+
+	recovered, err = cp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("inline"), recovered)
+}
+
+func TestChunkingPersister_RemoveShouldDeleteAllChunks(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	cp, _ := chunkingpersister.NewChunkingPersister(inner, chunkSize)
+
+	_ = cp.Put([]byte("key"), bytes.Repeat([]byte("a"), 3*chunkSize+5))
+	_ = cp.Put([]byte("other"), bytes.Repeat([]byte("b"), 2*chunkSize))
+	require.Equal(t, 8, countInnerKeys(inner))
+
+	err := cp.Remove([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, 3, countInnerKeys(inner))
+
+	_, err = cp.Get([]byte("key"))
+	require.NotNil(t, err)
+	require.NotNil(t, cp.Has([]byte("key")))
+
+	recovered, err := cp.Get([]byte("other"))
+	require.Nil(t, err)
+	require.Equal(t, bytes.Repeat([]byte("b"), 2*chunkSize), recovered)
+}
+
+func TestChunkingPersister_RangeKeysShouldHideChunkKeys(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	cp, _ := chunkingpersister.NewChunkingPersister(inner, chunkSize)
+
+	expected := map[string][]byte{
+		"small": []byte("value"),
+		"large": bytes.Repeat([]byte("l"), 4*chunkSize),
+		// a logical key looking like a chunk key of another one
+		"large#0": bytes.Repeat([]byte("x"), chunkSize+3),
+	}
+	for key, val := range expected {
+		err := cp.Put([]byte(key), val)
+		require.Nil(t, err)
+	}
+
+	recovered := make(map[string][]byte)
+	cp.RangeKeys(func(key []byte, val []byte) bool {
+		recovered[string(key)] = val
+		return true
+	})
+	require.Equal(t, expected, recovered)
+
+	numVisited := 0
+	cp.RangeKeys(func(_ []byte, _ []byte) bool {
+		numVisited++
+		return false
+	})
+	require.Equal(t, 1, numVisited)
+
+	require.NotPanics(t, func() {
+		cp.RangeKeys(nil)
+	})
+}
+
+func TestChunkingPersister_FailedPutShouldCleanPartialChunks(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+
+	t.Run("failing chunk write", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		numPuts := 0
+		stub := &testscommon.PersisterStub{
+			PutCalled: func(key, val []byte) error {
+				numPuts++
+				if numPuts == 3 {
+					return expectedErr
+				}
+				return inner.Put(key, val)
+			},
+			GetCalled:       inner.Get,
+			RemoveCalled:    inner.Remove,
+			RangeKeysCalled: inner.RangeKeys,
+		}
+		cp, _ := chunkingpersister.NewChunkingPersister(stub, chunkSize)
+
+		err := cp.Put([]byte("key"), bytes.Repeat([]byte("a"), 5*chunkSize))
+		require.Equal(t, expectedErr, err)
+		require.Equal(t, 0, countInnerKeys(inner))
+	})
+	t.Run("failing manifest write should keep the previous value", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		failPuts := false
+		stub := &testscommon.PersisterStub{
+			PutCalled: func(key, val []byte) error {
+				if failPuts && len(val) > 0 && val[0] == 1 && len(val) < chunkSize {
+					return expectedErr
+				}
+				return inner.Put(key, val)
+			},
+			GetCalled:       inner.Get,
+			RemoveCalled:    inner.Remove,
+			RangeKeysCalled: inner.RangeKeys,
+		}
+		cp, _ := chunkingpersister.NewChunkingPersister(stub, chunkSize)
+
+		previous := bytes.Repeat([]byte("p"), 2*chunkSize)
+		err := cp.Put([]byte("key"), previous)
+		require.Nil(t, err)
+		require.Equal(t, 3, countInnerKeys(inner))
+
+		failPuts = true
+		err = cp.Put([]byte("key"), bytes.Repeat([]byte("n"), 4*chunkSize))
+		require.Equal(t, expectedErr, err)
+		require.Equal(t, 3, countInnerKeys(inner))
+
+		recovered, err := cp.Get([]byte("key"))
+		require.Nil(t, err)
+		require.Equal(t, previous, recovered)
+	})
+}
+
+func TestChunkingPersister_GetWithMissingChunkShouldError(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	cp, _ := chunkingpersister.NewChunkingPersister(inner, chunkSize)
+
+	_ = cp.Put([]byte("key"), bytes.Repeat([]byte("a"), 3*chunkSize))
+
+	var chunkKey []byte
+	inner.RangeKeys(func(key []byte, _ []byte) bool {
+		if key[0] == 'c' {
+			chunkKey = append([]byte{}, key...)
+			return false
+		}
+		return true
+	})
+	require.NotNil(t, chunkKey)
+	_ = inner.Remove(chunkKey)
+
+	_, err := cp.Get([]byte("key"))
+	require.True(t, errors.Is(err, chunkingpersister.ErrMissingChunk))
+}
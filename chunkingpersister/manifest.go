@@ -0,0 +1,85 @@
+package chunkingpersister
+
+import (
+	"encoding/binary"
+)
+
+const inlineValueMarker = byte(0)
+const manifestMarker = byte(1)
+
+// marker + generation + number of chunks + total length
+const manifestLength = 1 + 8 + 4 + 8
+
+const logicalKeyPrefix = byte('v')
+const chunkKeyPrefix = byte('c')
+
+// generation + chunk index
+const chunkKeySuffixLength = 8 + 4
+
+// manifest describes a value split in chunks. The chunk keys include the generation of the value, so overwriting
+// a chunked value writes new chunks instead of modifying the ones referenced by the stored manifest
+type manifest struct {
+	generation  uint64
+	numChunks   uint32
+	totalLength uint64
+}
+
+func encodeInlineValue(val []byte) []byte {
+	buff := make([]byte, 1+len(val))
+	buff[0] = inlineValueMarker
+	copy(buff[1:], val)
+
+	return buff
+}
+
+func (m *manifest) encode() []byte {
+	buff := make([]byte, manifestLength)
+	buff[0] = manifestMarker
+	binary.BigEndian.PutUint64(buff[1:9], m.generation)
+	binary.BigEndian.PutUint32(buff[9:13], m.numChunks)
+	binary.BigEndian.PutUint64(buff[13:21], m.totalLength)
+
+	return buff
+}
+
+// decodeStoredValue returns either the inline value or the manifest contained in the stored value
+func decodeStoredValue(storedValue []byte) ([]byte, *manifest, error) {
+	if len(storedValue) == 0 {
+		return nil, nil, ErrInvalidStoredValue
+	}
+
+	switch storedValue[0] {
+	case inlineValueMarker:
+		return storedValue[1:], nil, nil
+	case manifestMarker:
+		if len(storedValue) != manifestLength {
+			return nil, nil, ErrInvalidStoredValue
+		}
+
+		return nil, &manifest{
+			generation:  binary.BigEndian.Uint64(storedValue[1:9]),
+			numChunks:   binary.BigEndian.Uint32(storedValue[9:13]),
+			totalLength: binary.BigEndian.Uint64(storedValue[13:21]),
+		}, nil
+	default:
+		return nil, nil, ErrInvalidStoredValue
+	}
+}
+
+func logicalKey(key []byte) []byte {
+	buff := make([]byte, 0, 1+len(key))
+	buff = append(buff, logicalKeyPrefix)
+
+	return append(buff, key...)
+}
+
+// chunkKey builds the key of a chunk. As the suffix has a fixed length, the chunk keys of different keys never collide
+func chunkKey(key []byte, generation uint64, index uint32) []byte {
+	buff := make([]byte, 1+len(key)+chunkKeySuffixLength)
+	buff[0] = chunkKeyPrefix
+	copy(buff[1:], key)
+	binary.BigEndian.PutUint64(buff[1+len(key):], generation)
+	binary.BigEndian.PutUint32(buff[1+len(key)+8:], index)
+
+	return buff
+}
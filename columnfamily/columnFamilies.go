@@ -0,0 +1,117 @@
+package columnfamily
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// columnFamilies splits one persister in logically separated column families. Each family stores its keys under
+// a prefix made of the varint encoded length of the family name followed by the name, so no family prefix can be
+// the prefix of another one. The persister should be dedicated to the column families, as keys written directly
+// in it might be observed by the families.
+// If the persister implements types.PrefixRanger, as the leveldb persisters do, the iteration over a family only
+// visits its own keys, otherwise all the persisted keys are visited and filtered
+type columnFamilies struct {
+	persister types.Persister
+}
+
+// NewColumnFamilies creates the column families over the provided persister
+func NewColumnFamilies(persister types.Persister) (*columnFamilies, error) {
+	if check.IfNil(persister) {
+		return nil, common.ErrNilPersister
+	}
+
+	return &columnFamilies{
+		persister: persister,
+	}, nil
+}
+
+// ColumnFamily returns the persister view of the column family with the provided name. Closing the view does not
+// close the underlying persister and destroying it only drops the family keys
+func (cf *columnFamilies) ColumnFamily(name string) types.Persister {
+	return &columnFamily{
+		parent: cf,
+		prefix: computePrefix(name),
+	}
+}
+
+// DropColumnFamily removes all the keys of the column family with the provided name
+func (cf *columnFamilies) DropColumnFamily(name string) error {
+	return cf.dropPrefix(computePrefix(name))
+}
+
+func (cf *columnFamilies) dropPrefix(prefix []byte) error {
+	batchApplier, ok := cf.persister.(types.BatchApplier)
+	if ok {
+		// applying an empty batch writes the pending operations of the batching persisters,
+		// otherwise the keys not yet written would not be iterated and would survive the drop
+		err := batchApplier.ApplyBatch(nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	keys := make([][]byte, 0)
+	cf.rangePrefix(prefix, func(key []byte, _ []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	if ok {
+		ops := make([]types.Operation, 0, len(keys))
+		for _, key := range keys {
+			ops = append(ops, types.Operation{
+				Type: types.RemoveOperation,
+				Key:  key,
+			})
+		}
+
+		return batchApplier.ApplyBatch(ops)
+	}
+
+	for _, key := range keys {
+		err := cf.persister.Remove(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cf *columnFamilies) rangePrefix(prefix []byte, handler func(key []byte, value []byte) bool) {
+	prefixRanger, ok := cf.persister.(types.PrefixRanger)
+	if ok {
+		prefixRanger.RangePrefix(prefix, handler)
+		return
+	}
+
+	cf.persister.RangeKeys(func(key []byte, value []byte) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return true
+		}
+
+		return handler(key, value)
+	})
+}
+
+// Close closes the underlying persister, after which all the column families are unusable
+func (cf *columnFamilies) Close() error {
+	return cf.persister.Close()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cf *columnFamilies) IsInterfaceNil() bool {
+	return cf == nil
+}
+
+func computePrefix(name string) []byte {
+	prefix := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(name))
+	n := binary.PutUvarint(prefix, uint64(len(name)))
+
+	return append(prefix[:n], name...)
+}
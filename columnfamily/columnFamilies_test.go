@@ -0,0 +1,127 @@
+package columnfamily_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/columnfamily"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rangeKeys(persister types.Persister) []string {
+	keys := make([]string, 0)
+	persister.RangeKeys(func(key []byte, _ []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	sort.Strings(keys)
+
+	return keys
+}
+
+func TestNewColumnFamilies(t *testing.T) {
+	t.Parallel()
+
+	cf, err := columnfamily.NewColumnFamilies(nil)
+	assert.True(t, check.IfNil(cf))
+	assert.Equal(t, common.ErrNilPersister, err)
+
+	cf, err = columnfamily.NewColumnFamilies(memorydb.New())
+	assert.False(t, check.IfNil(cf))
+	assert.Nil(t, err)
+}
+
+func TestColumnFamilies_FamiliesShouldBeIsolated(t *testing.T) {
+	t.Parallel()
+
+	persisters := map[string]func(t *testing.T) types.Persister{
+		"leveldb": func(t *testing.T) types.Persister {
+			ldb, err := leveldb.NewDB(t.TempDir(), 10, 1, 10)
+			require.Nil(t, err)
+			return ldb
+		},
+		"memorydb": func(t *testing.T) types.Persister {
+			return memorydb.New()
+		},
+		"without prefix ranger": func(t *testing.T) types.Persister {
+			return testscommon.NewMemDbMock()
+		},
+	}
+
+	for name, createPersister := range persisters {
+		createPersister := createPersister
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cf, _ := columnfamily.NewColumnFamilies(createPersister(t))
+			defer func() {
+				_ = cf.Close()
+			}()
+
+			blocks := cf.ColumnFamily("a")
+			receipts := cf.ColumnFamily("ab")
+			require.Nil(t, blocks.Put([]byte("bkey"), []byte("block")))
+			require.Nil(t, blocks.Put([]byte("key"), []byte("block")))
+			require.Nil(t, receipts.Put([]byte("key"), []byte("receipt")))
+
+			val, err := blocks.Get([]byte("key"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("block"), val)
+			val, err = receipts.Get([]byte("key"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("receipt"), val)
+			assert.NotNil(t, receipts.Has([]byte("bkey")))
+
+			assert.Equal(t, []string{"bkey", "key"}, rangeKeys(blocks))
+			assert.Equal(t, []string{"key"}, rangeKeys(receipts))
+
+			require.Nil(t, blocks.Close())
+			require.Nil(t, cf.DropColumnFamily("a"))
+			assert.Empty(t, rangeKeys(blocks))
+			assert.NotNil(t, blocks.Has([]byte("key")))
+			assert.Nil(t, receipts.Has([]byte("key")))
+
+			require.Nil(t, receipts.Remove([]byte("key")))
+			assert.NotNil(t, receipts.Has([]byte("key")))
+		})
+	}
+}
+
+func TestColumnFamily_DestroyShouldOnlyDropTheFamily(t *testing.T) {
+	t.Parallel()
+
+	cf, _ := columnfamily.NewColumnFamilies(memorydb.New())
+	metadata := cf.ColumnFamily("metadata")
+	blocks := cf.ColumnFamily("blocks")
+	_ = metadata.Put([]byte("key"), []byte("value"))
+	_ = blocks.Put([]byte("key"), []byte("value"))
+
+	assert.Nil(t, metadata.Destroy())
+	assert.Empty(t, rangeKeys(metadata))
+	assert.Equal(t, []string{"key"}, rangeKeys(blocks))
+}
+
+func TestColumnFamilies_DropShouldRemoveTheKeysPendingInBatch(t *testing.T) {
+	t.Parallel()
+
+	ldb, err := leveldb.NewDB(t.TempDir(), 10, 100, 10)
+	require.Nil(t, err)
+	cf, _ := columnfamily.NewColumnFamilies(ldb)
+	defer func() {
+		_ = cf.Close()
+	}()
+
+	blocks := cf.ColumnFamily("blocks")
+	_ = blocks.Put([]byte("key"), []byte("value"))
+	require.Nil(t, blocks.Has([]byte("key")))
+
+	require.Nil(t, cf.DropColumnFamily("blocks"))
+	assert.ErrorIs(t, blocks.Has([]byte("key")), common.ErrKeyNotFound)
+}
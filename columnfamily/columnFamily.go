@@ -0,0 +1,73 @@
+package columnfamily
+
+import (
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*columnFamily)(nil)
+
+// columnFamily is the persister view of one column family, scoping all the operations to the family prefix
+type columnFamily struct {
+	parent *columnFamilies
+	prefix []byte
+}
+
+func (c *columnFamily) prefixedKey(key []byte) []byte {
+	prefixed := make([]byte, 0, len(c.prefix)+len(key))
+	prefixed = append(prefixed, c.prefix...)
+
+	return append(prefixed, key...)
+}
+
+// Put adds the value to the column family
+func (c *columnFamily) Put(key, val []byte) error {
+	return c.parent.persister.Put(c.prefixedKey(key), val)
+}
+
+// Get gets the value associated to the key in the column family
+func (c *columnFamily) Get(key []byte) ([]byte, error) {
+	return c.parent.persister.Get(c.prefixedKey(key))
+}
+
+// Has returns nil if the given key is present in the column family
+func (c *columnFamily) Has(key []byte) error {
+	return c.parent.persister.Has(c.prefixedKey(key))
+}
+
+// Remove removes the data associated to the given key from the column family
+func (c *columnFamily) Remove(key []byte) error {
+	return c.parent.persister.Remove(c.prefixedKey(key))
+}
+
+// RangeKeys calls the handler for each (key, value) pair of the column family, the keys being provided without
+// the family prefix. If the handler returns true, the iteration will continue, otherwise will stop
+func (c *columnFamily) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	c.parent.rangePrefix(c.prefix, func(key []byte, val []byte) bool {
+		return handler(key[len(c.prefix):], val)
+	})
+}
+
+// Close does nothing, as the underlying persister is shared by all the column families
+func (c *columnFamily) Close() error {
+	return nil
+}
+
+// Destroy removes all the keys of the column family
+func (c *columnFamily) Destroy() error {
+	return c.parent.dropPrefix(c.prefix)
+}
+
+// DestroyClosed removes all the keys of the column family, as closing a column family does not close the
+// underlying persister
+func (c *columnFamily) DestroyClosed() error {
+	return c.parent.dropPrefix(c.prefix)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *columnFamily) IsInterfaceNil() bool {
+	return c == nil
+}
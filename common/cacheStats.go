@@ -0,0 +1,75 @@
+package common
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+)
+
+const cacheStatsVersion = byte(1)
+
+// version + hits + misses
+const exportedCacheStatsLength = 1 + 8 + 8
+
+// CacheStats holds the lifetime hit and miss counters of a cache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRatio returns the fraction of the lookups which were hits, or 0 if there were no lookups
+func (cs CacheStats) HitRatio() float64 {
+	total := cs.Hits + cs.Misses
+	if total == 0 {
+		return 0
+	}
+
+	return float64(cs.Hits) / float64(total)
+}
+
+// CacheStatsCounter counts the hits and the misses of a cache lookups. The counters can be exported and imported
+// back, so they survive the restarts of the node instead of starting again from zero
+type CacheStatsCounter struct {
+	hits   uint64
+	misses uint64
+}
+
+// Record counts a lookup as a hit or as a miss
+func (csc *CacheStatsCounter) Record(hit bool) {
+	if hit {
+		atomic.AddUint64(&csc.hits, 1)
+		return
+	}
+
+	atomic.AddUint64(&csc.misses, 1)
+}
+
+// Stats returns the current counters
+func (csc *CacheStatsCounter) Stats() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&csc.hits),
+		Misses: atomic.LoadUint64(&csc.misses),
+	}
+}
+
+// Export encodes the current counters
+func (csc *CacheStatsCounter) Export() []byte {
+	stats := csc.Stats()
+	buff := make([]byte, exportedCacheStatsLength)
+	buff[0] = cacheStatsVersion
+	binary.BigEndian.PutUint64(buff[1:9], stats.Hits)
+	binary.BigEndian.PutUint64(buff[9:], stats.Misses)
+
+	return buff
+}
+
+// Import adds the counters encoded by Export to the current ones, so the lookups done since the start are kept
+func (csc *CacheStatsCounter) Import(exported []byte) error {
+	if len(exported) != exportedCacheStatsLength || exported[0] != cacheStatsVersion {
+		return ErrInvalidCacheStats
+	}
+
+	atomic.AddUint64(&csc.hits, binary.BigEndian.Uint64(exported[1:9]))
+	atomic.AddUint64(&csc.misses, binary.BigEndian.Uint64(exported[9:]))
+
+	return nil
+}
@@ -86,3 +86,148 @@ var ErrInvalidCacheExpiry = errors.New("invalid cache expiry")
 
 // ErrDBIsClosed is raised when the DB is closed
 var ErrDBIsClosed = core.ErrDBIsClosed
+
+// ErrBufferTooSmall signals that the provided buffer can not hold the requested value
+var ErrBufferTooSmall = errors.New("buffer too small")
+
+// ErrCacheShardsInvalid signals that the number of cache shards is less than 1
+var ErrCacheShardsInvalid = errors.New("number of cache shards is less than 1")
+
+// ErrInvalidOperationType signals that an operation with an unknown type was provided
+var ErrInvalidOperationType = errors.New("invalid operation type")
+
+// ErrBatchNotSupported signals that the persister can not atomically apply a batch of operations
+var ErrBatchNotSupported = errors.New("persister does not support atomic batches")
+
+// ErrInvalidObjectDestination signals that the provided destination is not a non-nil pointer
+var ErrInvalidObjectDestination = errors.New("invalid object destination")
+
+// ErrPrefixCountNotSupported signals that the persister can not count the keys starting with a prefix
+var ErrPrefixCountNotSupported = errors.New("persister does not support prefix counting")
+
+// ErrTruncateNotSupported signals that the persister can not be truncated
+var ErrTruncateNotSupported = errors.New("persister does not support truncation")
+
+// ErrNotSupportedEvictionPolicy signals that an unknown cache eviction policy was provided
+var ErrNotSupportedEvictionPolicy = errors.New("not supported eviction policy")
+
+// ErrIncompatibleEvictionPolicy signals that the eviction policy can not be used with the provided cache type
+var ErrIncompatibleEvictionPolicy = errors.New("eviction policy incompatible with the cache type")
+
+// ErrSnapshotNotSupported signals that the persister can not create snapshots
+var ErrSnapshotNotSupported = errors.New("persister does not support snapshots")
+
+// ErrNilIndexFunc signals that a nil index function has been provided
+var ErrNilIndexFunc = errors.New("nil index function")
+
+// ErrTooManyConcurrentOps signals that the maximum number of concurrent persister operations has been reached
+var ErrTooManyConcurrentOps = errors.New("too many concurrent persister operations")
+
+// ErrInvalidPerEntryOverhead signals that a negative per entry overhead was provided
+var ErrInvalidPerEntryOverhead = errors.New("invalid per entry overhead")
+
+// ErrReadOnlyPersister signals that a write operation was attempted on a read only persister
+var ErrReadOnlyPersister = errors.New("read only persister")
+
+// ErrInvalidBatchDelayBounds signals that invalid adaptive batch delay bounds were provided
+var ErrInvalidBatchDelayBounds = errors.New("invalid batch delay bounds")
+
+// ErrNilSnapshot signals that a nil snapshot has been provided
+var ErrNilSnapshot = errors.New("nil snapshot")
+
+// ErrInvalidSamplingInterval signals that an invalid sampling interval has been provided
+var ErrInvalidSamplingInterval = errors.New("invalid sampling interval")
+
+// ErrNilCondition signals that a nil condition function has been provided
+var ErrNilCondition = errors.New("nil condition")
+
+// ErrNotSupportedShardIDProviderType is raised when an unsupported shard id provider type is provided
+var ErrNotSupportedShardIDProviderType = errors.New("not supported shard id provider type")
+
+// ErrInvalidCompactionWindow signals that an invalid compaction window has been provided
+var ErrInvalidCompactionWindow = errors.New("invalid compaction window")
+
+// ErrPrefixRangeNotSupported signals that the persister does not support iterating over a prefixed range
+var ErrPrefixRangeNotSupported = errors.New("persister does not support prefix ranges")
+
+// ErrResizeNotSupported signals that the cache can not be resized
+var ErrResizeNotSupported = errors.New("cache does not support resizing")
+
+// ErrGetAndRemoveNotSupported signals that the cache does not support atomically getting and removing a key
+var ErrGetAndRemoveNotSupported = errors.New("cache does not support get and remove")
+
+// ErrInvalidCacheStats signals that the exported cache statistics could not be decoded
+var ErrInvalidCacheStats = errors.New("invalid cache statistics")
+
+// ErrEmptyDBPath signals that an empty path was configured for a disk backed database
+var ErrEmptyDBPath = errors.New("empty database path")
+
+// ErrInvalidTimeCacheSnapshot signals that the saved time cache data could not be decoded
+var ErrInvalidTimeCacheSnapshot = errors.New("invalid time cache snapshot")
+
+// ErrTimeCachePersistenceNotSupported signals that the time cache can not be saved and loaded back
+var ErrTimeCachePersistenceNotSupported = errors.New("time cache does not support persistence")
+
+// ErrVersionKeyInBatch signals that the operations committed conditionally on a version key also write the version key
+var ErrVersionKeyInBatch = errors.New("the batch must not contain the version key")
+
+// ErrInvalidVersion signals that the version key holds a value which is not a version counter
+var ErrInvalidVersion = errors.New("invalid version")
+
+// ErrEntryStatsNotSupported signals that the persister can not report its live and deleted entries
+var ErrEntryStatsNotSupported = errors.New("persister does not support entry statistics")
+
+// ErrOpenTimeout signals that the database could not be opened in the allotted time
+var ErrOpenTimeout = errors.New("timeout opening the database")
+
+// ErrInvalidReadAhead signals that a non-positive number of entries to read ahead was provided
+var ErrInvalidReadAhead = errors.New("invalid read ahead")
+
+// ErrInvalidNumShards signals that the number of shards is less than 1
+var ErrInvalidNumShards = errors.New("number of shards is less than 1")
+
+// ErrUnitClosed signals that an operation was called on a closed storage unit
+var ErrUnitClosed = errors.New("storage unit is closed")
+
+// ErrInvalidTrace signals that a cache access trace could not be decoded
+var ErrInvalidTrace = errors.New("invalid cache access trace")
+
+// ErrTraceClosed signals that the cache access trace was already closed
+var ErrTraceClosed = errors.New("cache access trace closed")
+
+// ErrPrefixDiskSizeNotSupported signals that the persister can not estimate the size on disk of a prefix
+var ErrPrefixDiskSizeNotSupported = errors.New("persister does not support prefix disk size estimation")
+
+// ErrNilHasher signals that a nil hasher has been provided
+var ErrNilHasher = errors.New("nil hasher")
+
+// ErrInvalidSampleRate signals that a sample rate outside the (0, 1] interval has been provided
+var ErrInvalidSampleRate = errors.New("invalid sample rate")
+
+// ErrChangeLogNotEnabled signals that the storage unit was not created with a change log
+var ErrChangeLogNotEnabled = errors.New("change log not enabled")
+
+// ErrChangeLogAppendFailed signals that a mutation was applied, but its record could not be appended to the
+// change log
+var ErrChangeLogAppendFailed = errors.New("change log append failed")
+
+// ErrInvalidChangeRecord signals that a stored change log record could not be decoded
+var ErrInvalidChangeRecord = errors.New("invalid change record")
+
+// ErrInvalidChangeSequence signals that an invalid change log sequence has been acknowledged
+var ErrInvalidChangeSequence = errors.New("invalid change sequence")
+
+// ErrChangesTruncated signals that the requested change records were already removed from the change log
+var ErrChangesTruncated = errors.New("change records truncated")
+
+// ErrNilChangeHandler signals that a nil change handler has been provided
+var ErrNilChangeHandler = errors.New("nil change handler")
+
+// ErrCacheInvariantViolated signals that the internal structures of a cache are inconsistent
+var ErrCacheInvariantViolated = errors.New("cache invariant violated")
+
+// ErrInvalidSyncInterval signals that a negative journal sync interval has been provided
+var ErrInvalidSyncInterval = errors.New("invalid sync interval")
+
+// ErrInvalidOpenTimeout signals that a negative database open timeout has been provided
+var ErrInvalidOpenTimeout = errors.New("invalid open timeout")
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// NeverFull is the time to full reported when the number of entries is not growing
+const NeverFull = time.Duration(math.MaxInt64)
+
+// fillRateWindow is the sliding window over which the fill rate is computed
+const fillRateWindow = time.Minute
+
+// fillRateSlots is the number of samples kept over the sliding window, one per window/fillRateSlots
+const fillRateSlots = 12
+
+type fillSample struct {
+	timestamp  time.Time
+	numEntries int
+}
+
+// FillRateTracker samples the number of entries of a cache as the values are added, so the rate at which the cache
+// fills can be computed over a sliding window. At most one sample is taken for each window/fillRateSlots
+// interval, so the tracker is cheap to call on every write. The zero value is ready to use
+type FillRateTracker struct {
+	mut      sync.Mutex
+	samples  [fillRateSlots]fillSample
+	lastSlot int64
+}
+
+// Record takes a sample of the number of entries if none was taken yet in the current interval. The number of
+// entries is only computed when a sample is taken
+func (frt *FillRateTracker) Record(now time.Time, numEntries func() int) {
+	slot := now.UnixNano() / int64(fillRateWindow/fillRateSlots)
+
+	frt.mut.Lock()
+	defer frt.mut.Unlock()
+
+	if slot == frt.lastSlot {
+		return
+	}
+
+	frt.lastSlot = slot
+	frt.samples[slot%fillRateSlots] = fillSample{
+		timestamp:  now,
+		numEntries: numEntries(),
+	}
+}
+
+// FillRate returns the growth of the number of entries in entries per second, measured from the oldest sample of
+// the sliding window, and the time left until the provided maximum number of entries is reached at this rate.
+// The time to full is 0 if the cache is already full and NeverFull if the number of entries is not growing
+func (frt *FillRateTracker) FillRate(now time.Time, numEntries int, maxEntries int) (float64, time.Duration) {
+	entriesPerSec := frt.entriesPerSecond(now, numEntries)
+	if numEntries >= maxEntries {
+		return entriesPerSec, 0
+	}
+	if entriesPerSec <= 0 {
+		return entriesPerSec, NeverFull
+	}
+
+	secondsToFull := float64(maxEntries-numEntries) / entriesPerSec
+	if secondsToFull >= NeverFull.Seconds() {
+		return entriesPerSec, NeverFull
+	}
+
+	return entriesPerSec, time.Duration(secondsToFull * float64(time.Second))
+}
+
+func (frt *FillRateTracker) entriesPerSecond(now time.Time, numEntries int) float64 {
+	frt.mut.Lock()
+	defer frt.mut.Unlock()
+
+	var oldest *fillSample
+	for i := range frt.samples {
+		sample := &frt.samples[i]
+		if sample.timestamp.IsZero() || now.Sub(sample.timestamp) > fillRateWindow {
+			continue
+		}
+		if oldest == nil || sample.timestamp.Before(oldest.timestamp) {
+			oldest = sample
+		}
+	}
+	if oldest == nil {
+		return 0
+	}
+
+	elapsed := now.Sub(oldest.timestamp).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(numEntries-oldest.numEntries) / elapsed
+}
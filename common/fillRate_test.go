@@ -0,0 +1,73 @@
+package common_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/stretchr/testify/require"
+)
+
+func constantEntries(numEntries int) func() int {
+	return func() int {
+		return numEntries
+	}
+}
+
+func TestFillRateTracker_NoSamplesShouldReportNotGrowing(t *testing.T) {
+	t.Parallel()
+
+	tracker := common.FillRateTracker{}
+	entriesPerSec, timeToFull := tracker.FillRate(time.Now(), 10, 100)
+	require.Equal(t, float64(0), entriesPerSec)
+	require.Equal(t, common.NeverFull, timeToFull)
+}
+
+func TestFillRateTracker_ShouldComputeOverTheSlidingWindow(t *testing.T) {
+	t.Parallel()
+
+	tracker := common.FillRateTracker{}
+	start := time.Unix(1000, 0)
+	for i := 0; i <= 90; i++ {
+		now := start.Add(time.Duration(i) * time.Second)
+		tracker.Record(now, constantEntries(i*10))
+	}
+
+	// one sample is kept every 5 seconds over the last minute, the oldest one being taken at 35 seconds
+	now := start.Add(90 * time.Second)
+	entriesPerSec, timeToFull := tracker.FillRate(now, 900, 1500)
+	require.InDelta(t, 10, entriesPerSec, 0.001)
+	require.Equal(t, time.Minute, timeToFull.Round(time.Millisecond))
+}
+
+func TestFillRateTracker_ShouldSampleOncePerInterval(t *testing.T) {
+	t.Parallel()
+
+	tracker := common.FillRateTracker{}
+	start := time.Unix(1000, 0)
+	numCalls := 0
+	for i := 0; i < 5; i++ {
+		tracker.Record(start.Add(time.Duration(i)*time.Second), func() int {
+			numCalls++
+			return 0
+		})
+	}
+
+	require.Equal(t, 1, numCalls)
+}
+
+func TestFillRateTracker_ShrinkingOrFullCache(t *testing.T) {
+	t.Parallel()
+
+	tracker := common.FillRateTracker{}
+	start := time.Unix(1000, 0)
+	tracker.Record(start, constantEntries(100))
+
+	entriesPerSec, timeToFull := tracker.FillRate(start.Add(10*time.Second), 50, 200)
+	require.InDelta(t, -5, entriesPerSec, 0.001)
+	require.Equal(t, common.NeverFull, timeToFull)
+
+	entriesPerSec, timeToFull = tracker.FillRate(start.Add(10*time.Second), 200, 200)
+	require.InDelta(t, 10, entriesPerSec, 0.001)
+	require.Equal(t, time.Duration(0), timeToFull)
+}
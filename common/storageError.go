@@ -0,0 +1,51 @@
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// OpPut is the operation name of a Put call
+	OpPut = "Put"
+	// OpGet is the operation name of a Get call
+	OpGet = "Get"
+	// OpHas is the operation name of a Has call
+	OpHas = "Has"
+	// OpRemove is the operation name of a Remove call
+	OpRemove = "Remove"
+)
+
+// StorageError is returned by the persisters to provide the context of a failed operation: the operation name,
+// the key it was called with and the backend that failed. The wrapped error can be checked with errors.Is, for
+// example against ErrKeyNotFound, and the StorageError itself can be extracted with errors.As
+type StorageError struct {
+	Op      string
+	Key     []byte
+	Backend string
+	Err     error
+}
+
+// NewStorageError wraps the provided error in a StorageError. It returns nil if the provided error is nil
+func NewStorageError(op string, key []byte, backend string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &StorageError{
+		Op:      op,
+		Key:     key,
+		Backend: backend,
+		Err:     err,
+	}
+}
+
+// Error returns the error message, containing the hex encoded key
+func (e *StorageError) Error() string {
+	return fmt.Sprintf("%s %s, key: %s: %v", e.Backend, e.Op, hex.EncodeToString(e.Key), e.Err)
+}
+
+// Unwrap returns the wrapped error
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
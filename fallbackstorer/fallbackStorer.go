@@ -0,0 +1,262 @@
+package fallbackstorer
+
+import (
+	"errors"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-core-go/data"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Storer = (*fallbackStorer)(nil)
+
+var log = logger.GetOrCreate("storage/fallbackstorer")
+
+// ErrNilPrimaryStorer signals that a nil primary storer was provided
+var ErrNilPrimaryStorer = errors.New("nil primary storer")
+
+// ErrNilSecondaryStorer signals that a nil secondary storer was provided
+var ErrNilSecondaryStorer = errors.New("nil secondary storer")
+
+// fallbackStorer is a read-through tier over two storers: the reads missing from the fast primary storer are served
+// by the authoritative secondary storer, the found values being written back in the primary one. The writes go to
+// the primary storer and, if configured, to the secondary one as well. Otherwise the secondary storer is only read,
+// so a key removed from the primary storer is read back from the secondary storer if it holds it
+type fallbackStorer struct {
+	primary          types.Storer
+	secondary        types.Storer
+	writeToSecondary bool
+}
+
+// NewFallbackStorer creates a storer reading from the secondary storer the keys missing from the primary one.
+// If writeToSecondary is set, the writes and the removals are applied on both storers
+func NewFallbackStorer(primary types.Storer, secondary types.Storer, writeToSecondary bool) (*fallbackStorer, error) {
+	if check.IfNil(primary) {
+		return nil, ErrNilPrimaryStorer
+	}
+	if check.IfNil(secondary) {
+		return nil, ErrNilSecondaryStorer
+	}
+
+	return &fallbackStorer{
+		primary:          primary,
+		secondary:        secondary,
+		writeToSecondary: writeToSecondary,
+	}, nil
+}
+
+// Put writes the data in the primary storer and, if configured, in the secondary one
+func (fs *fallbackStorer) Put(key, data []byte) error {
+	err := fs.primary.Put(key, data)
+	if err != nil || !fs.writeToSecondary {
+		return err
+	}
+
+	return fs.secondary.Put(key, data)
+}
+
+// PutInEpoch writes the data in the provided epoch of the primary storer and, if configured, of the secondary one
+func (fs *fallbackStorer) PutInEpoch(key, data []byte, epoch uint32) error {
+	err := fs.primary.PutInEpoch(key, data, epoch)
+	if err != nil || !fs.writeToSecondary {
+		return err
+	}
+
+	return fs.secondary.PutInEpoch(key, data, epoch)
+}
+
+// Get returns the value of the key from the primary storer, falling back to the secondary storer on a miss.
+// A value found in the secondary storer is written back in the primary one
+func (fs *fallbackStorer) Get(key []byte) ([]byte, error) {
+	value, err := fs.primary.Get(key)
+	if !errors.Is(err, common.ErrKeyNotFound) {
+		return value, err
+	}
+
+	value, err = fs.secondary.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	logBackfillError(key, fs.primary.Put(key, value))
+
+	return value, nil
+}
+
+// GetFromEpoch returns the value of the key from the provided epoch of the primary storer, falling back to the
+// secondary storer on a miss. A value found in the secondary storer is written back in the primary one
+func (fs *fallbackStorer) GetFromEpoch(key []byte, epoch uint32) ([]byte, error) {
+	value, err := fs.primary.GetFromEpoch(key, epoch)
+	if !errors.Is(err, common.ErrKeyNotFound) {
+		return value, err
+	}
+
+	value, err = fs.secondary.GetFromEpoch(key, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	logBackfillError(key, fs.primary.PutInEpoch(key, value, epoch))
+
+	return value, nil
+}
+
+// SearchFirst returns the first value found for the key in the primary storer, falling back to the secondary
+// storer on a miss. A value found in the secondary storer is written back in the primary one
+func (fs *fallbackStorer) SearchFirst(key []byte) ([]byte, error) {
+	value, err := fs.primary.SearchFirst(key)
+	if !errors.Is(err, common.ErrKeyNotFound) {
+		return value, err
+	}
+
+	value, err = fs.secondary.SearchFirst(key)
+	if err != nil {
+		return nil, err
+	}
+
+	logBackfillError(key, fs.primary.Put(key, value))
+
+	return value, nil
+}
+
+// logBackfillError logs the failure of writing back a value in the primary storer, which does not fail the read
+func logBackfillError(key []byte, err error) {
+	if err != nil {
+		log.Warn("cannot write back the value read from the secondary storer", "key", key, "error", err)
+	}
+}
+
+// GetBulkFromEpoch returns the values of the keys found in the provided epoch of the primary storer, the missing
+// ones being searched in the secondary storer and written back in the primary one
+func (fs *fallbackStorer) GetBulkFromEpoch(keys [][]byte, epoch uint32) ([]data.KeyValuePair, error) {
+	results, err := fs.primary.GetBulkFromEpoch(keys, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]struct{}, len(results))
+	for _, result := range results {
+		found[string(result.Key)] = struct{}{}
+	}
+
+	missingKeys := make([][]byte, 0, len(keys)-len(results))
+	for _, key := range keys {
+		_, ok := found[string(key)]
+		if !ok {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	if len(missingKeys) == 0 {
+		return results, nil
+	}
+
+	secondaryResults, err := fs.secondary.GetBulkFromEpoch(missingKeys, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range secondaryResults {
+		logBackfillError(result.Key, fs.primary.PutInEpoch(result.Key, result.Value, epoch))
+	}
+
+	return append(results, secondaryResults...), nil
+}
+
+// Has returns nil if the key is present in the primary storer or, on a miss, in the secondary one
+func (fs *fallbackStorer) Has(key []byte) error {
+	err := fs.primary.Has(key)
+	if !errors.Is(err, common.ErrKeyNotFound) {
+		return err
+	}
+
+	return fs.secondary.Has(key)
+}
+
+// RemoveFromCurrentEpoch removes the key from the current epoch of the primary storer and, if configured, of the
+// secondary one
+func (fs *fallbackStorer) RemoveFromCurrentEpoch(key []byte) error {
+	err := fs.primary.RemoveFromCurrentEpoch(key)
+	if err != nil || !fs.writeToSecondary {
+		return err
+	}
+
+	return fs.secondary.RemoveFromCurrentEpoch(key)
+}
+
+// Remove removes the key from the primary storer and, if configured, from the secondary one
+func (fs *fallbackStorer) Remove(key []byte) error {
+	err := fs.primary.Remove(key)
+	if err != nil || !fs.writeToSecondary {
+		return err
+	}
+
+	return fs.secondary.Remove(key)
+}
+
+// ClearCache clears the caches of both storers
+func (fs *fallbackStorer) ClearCache() {
+	fs.primary.ClearCache()
+	fs.secondary.ClearCache()
+}
+
+// DestroyUnit destroys the primary storer and, if configured, the secondary one
+func (fs *fallbackStorer) DestroyUnit() error {
+	err := fs.primary.DestroyUnit()
+	if err != nil || !fs.writeToSecondary {
+		return err
+	}
+
+	return fs.secondary.DestroyUnit()
+}
+
+// GetOldestEpoch returns the oldest epoch of the primary storer
+func (fs *fallbackStorer) GetOldestEpoch() (uint32, error) {
+	return fs.primary.GetOldestEpoch()
+}
+
+// RangeKeys iterates over the (key, value) pairs of the primary storer, then over the pairs of the secondary
+// storer whose keys were not met in the primary one. If the handler returns true, the iteration will continue,
+// otherwise will stop
+func (fs *fallbackStorer) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	seenKeys := make(map[string]struct{})
+	shouldContinue := true
+	fs.primary.RangeKeys(func(key []byte, val []byte) bool {
+		seenKeys[string(key)] = struct{}{}
+		shouldContinue = handler(key, val)
+		return shouldContinue
+	})
+	if !shouldContinue {
+		return
+	}
+
+	fs.secondary.RangeKeys(func(key []byte, val []byte) bool {
+		_, seen := seenKeys[string(key)]
+		if seen {
+			return true
+		}
+
+		return handler(key, val)
+	})
+}
+
+// Close closes both storers
+func (fs *fallbackStorer) Close() error {
+	errPrimary := fs.primary.Close()
+	errSecondary := fs.secondary.Close()
+	if errPrimary != nil {
+		return errPrimary
+	}
+
+	return errSecondary
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (fs *fallbackStorer) IsInterfaceNil() bool {
+	return fs == nil
+}
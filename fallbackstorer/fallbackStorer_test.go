@@ -0,0 +1,147 @@
+package fallbackstorer_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/fallbackstorer"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
+	"github.com/stretchr/testify/require"
+)
+
+func createUnit(t *testing.T) *storageUnit.Unit {
+	cache, err := lrucache.NewCache(10)
+	require.Nil(t, err)
+	unit, err := storageUnit.NewStorageUnit(cache, memorydb.New())
+	require.Nil(t, err)
+
+	return unit
+}
+
+func TestNewFallbackStorer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil primary should error", func(t *testing.T) {
+		t.Parallel()
+
+		fs, err := fallbackstorer.NewFallbackStorer(nil, createUnit(t), false)
+		require.True(t, check.IfNil(fs))
+		require.Equal(t, fallbackstorer.ErrNilPrimaryStorer, err)
+	})
+	t.Run("nil secondary should error", func(t *testing.T) {
+		t.Parallel()
+
+		fs, err := fallbackstorer.NewFallbackStorer(createUnit(t), nil, false)
+		require.True(t, check.IfNil(fs))
+		require.Equal(t, fallbackstorer.ErrNilSecondaryStorer, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		fs, err := fallbackstorer.NewFallbackStorer(createUnit(t), createUnit(t), false)
+		require.False(t, check.IfNil(fs))
+		require.Nil(t, err)
+	})
+}
+
+func TestFallbackStorer_GetShouldFallBackAndBackfill(t *testing.T) {
+	t.Parallel()
+
+	primary, secondary := createUnit(t), createUnit(t)
+	fs, _ := fallbackstorer.NewFallbackStorer(primary, secondary, false)
+	_ = primary.Put([]byte("primary"), []byte("primary value"))
+	_ = secondary.Put([]byte("secondary"), []byte("secondary value"))
+
+	value, err := fs.Get([]byte("primary"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("primary value"), value)
+
+	require.NotNil(t, primary.Has([]byte("secondary")))
+	require.Nil(t, fs.Has([]byte("secondary")))
+	value, err = fs.Get([]byte("secondary"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("secondary value"), value)
+	require.Nil(t, primary.Has([]byte("secondary")))
+
+	_, err = fs.Get([]byte("missing"))
+	require.True(t, errors.Is(err, common.ErrKeyNotFound))
+	require.True(t, errors.Is(fs.Has([]byte("missing")), common.ErrKeyNotFound))
+}
+
+func TestFallbackStorer_GetBulkFromEpochShouldFallBackForTheMissingKeys(t *testing.T) {
+	t.Parallel()
+
+	primary, secondary := createUnit(t), createUnit(t)
+	fs, _ := fallbackstorer.NewFallbackStorer(primary, secondary, false)
+	_ = primary.Put([]byte("key1"), []byte("value1"))
+	_ = secondary.Put([]byte("key2"), []byte("value2"))
+
+	results, err := fs.GetBulkFromEpoch([][]byte{[]byte("key1"), []byte("key2"), []byte("key3")}, 0)
+	require.Nil(t, err)
+	require.Equal(t, 2, len(results))
+	require.Equal(t, []byte("value1"), results[0].Value)
+	require.Equal(t, []byte("value2"), results[1].Value)
+	require.Nil(t, primary.Has([]byte("key2")))
+}
+
+func TestFallbackStorer_Writes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("writes should go to the primary only", func(t *testing.T) {
+		t.Parallel()
+
+		primary, secondary := createUnit(t), createUnit(t)
+		fs, _ := fallbackstorer.NewFallbackStorer(primary, secondary, false)
+
+		require.Nil(t, fs.Put([]byte("key"), []byte("value")))
+		require.Nil(t, primary.Has([]byte("key")))
+		require.NotNil(t, secondary.Has([]byte("key")))
+
+		_ = secondary.Put([]byte("key"), []byte("secondary value"))
+		require.Nil(t, fs.Remove([]byte("key")))
+		require.NotNil(t, primary.Has([]byte("key")))
+		require.Nil(t, secondary.Has([]byte("key")))
+	})
+	t.Run("writes should go to both", func(t *testing.T) {
+		t.Parallel()
+
+		primary, secondary := createUnit(t), createUnit(t)
+		fs, _ := fallbackstorer.NewFallbackStorer(primary, secondary, true)
+
+		require.Nil(t, fs.Put([]byte("key"), []byte("value")))
+		require.Nil(t, primary.Has([]byte("key")))
+		require.Nil(t, secondary.Has([]byte("key")))
+
+		require.Nil(t, fs.Remove([]byte("key")))
+		require.NotNil(t, primary.Has([]byte("key")))
+		require.NotNil(t, secondary.Has([]byte("key")))
+	})
+}
+
+func TestFallbackStorer_RangeKeysShouldVisitEachKeyOnce(t *testing.T) {
+	t.Parallel()
+
+	primary, secondary := createUnit(t), createUnit(t)
+	fs, _ := fallbackstorer.NewFallbackStorer(primary, secondary, false)
+	_ = primary.Put([]byte("key1"), []byte("primary value1"))
+	_ = secondary.Put([]byte("key1"), []byte("secondary value1"))
+	_ = secondary.Put([]byte("key2"), []byte("value2"))
+
+	visited := make(map[string]string)
+	fs.RangeKeys(func(key []byte, val []byte) bool {
+		visited[string(key)] = string(val)
+		return true
+	})
+	require.Equal(t, map[string]string{"key1": "primary value1", "key2": "value2"}, visited)
+
+	numVisited := 0
+	fs.RangeKeys(func(_ []byte, _ []byte) bool {
+		numVisited++
+		return false
+	})
+	require.Equal(t, 1, numVisited)
+}
@@ -2,20 +2,32 @@ package fifocache
 
 import (
 	"sync"
+	"time"
 
 	cmap "github.com/DharitriOne/concurrent-map"
 	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/types"
 )
 
 var _ types.Cacher = (*FIFOShardedCache)(nil)
+var _ types.GetAndRemover = (*FIFOShardedCache)(nil)
+var _ types.CacheStatsExporter = (*FIFOShardedCache)(nil)
+var _ types.FillRateProvider = (*FIFOShardedCache)(nil)
+var _ types.CacheRanger = (*FIFOShardedCache)(nil)
+var _ types.CacheBatchGetter = (*FIFOShardedCache)(nil)
 
 var log = logger.GetOrCreate("storage/fifocache")
 
 // FIFOShardedCache implements a First In First Out eviction cache
 type FIFOShardedCache struct {
-	cache   *cmap.ConcurrentMap
-	maxsize int
+	cache         *cmap.ConcurrentMap
+	maxsize       int
+	stats         common.CacheStatsCounter
+	fillRate      common.FillRateTracker
+	numShards     int
+	shardCapacity int
+	shardsStats   []common.CacheStatsCounter
 
 	mutAddedDataHandlers sync.RWMutex
 	mapDataHandlers      map[string]func(key []byte, value interface{})
@@ -27,6 +39,9 @@ func NewShardedCache(size int, shards int) (*FIFOShardedCache, error) {
 	fifoShardedCache := &FIFOShardedCache{
 		cache:                cache,
 		maxsize:              size,
+		numShards:            shards,
+		shardCapacity:        computeShardCapacity(size, shards),
+		shardsStats:          make([]common.CacheStatsCounter, shards),
 		mutAddedDataHandlers: sync.RWMutex{},
 		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
 	}
@@ -46,6 +61,7 @@ func (c *FIFOShardedCache) Clear() {
 // the int parameter for size is not used as, for now, fifo sharded cache can not count for its contained data size
 func (c *FIFOShardedCache) Put(key []byte, value interface{}, _ int) (evicted bool) {
 	c.cache.Set(string(key), value)
+	c.fillRate.Record(time.Now(), c.Len)
 	c.callAddedDataHandlers(key, value)
 
 	return true
@@ -70,9 +86,43 @@ func (c *FIFOShardedCache) UnRegisterHandler(id string) {
 	c.mutAddedDataHandlers.Unlock()
 }
 
-// Get looks up a key's value from the cache.
+// Get looks up a key's value from the cache, counting the lookup as a hit or as a miss.
 func (c *FIFOShardedCache) Get(key []byte) (value interface{}, ok bool) {
-	return c.cache.Get(string(key))
+	value, ok = c.cache.Get(string(key))
+	c.stats.Record(ok)
+	c.shardsStats[c.shardIndex(string(key))].Record(ok)
+
+	return value, ok
+}
+
+// GetMulti looks up all the provided keys, returning the values of the found ones. As the eviction order does not
+// depend on the lookups, each key is looked up under the read lock of its shard only, like in Get. Each key counts
+// as a hit or as a miss
+func (c *FIFOShardedCache) GetMulti(keys [][]byte) map[string]interface{} {
+	found := make(map[string]interface{})
+	for _, key := range keys {
+		value, ok := c.Get(key)
+		if ok {
+			found[string(key)] = value
+		}
+	}
+
+	return found
+}
+
+// Stats returns the lifetime hit and miss counters of the Get calls
+func (c *FIFOShardedCache) Stats() common.CacheStats {
+	return c.stats.Stats()
+}
+
+// ExportStats encodes the hit and miss counters, to be imported back with ImportStats after a restart
+func (c *FIFOShardedCache) ExportStats() []byte {
+	return c.stats.Export()
+}
+
+// ImportStats adds the counters encoded by ExportStats to the current ones
+func (c *FIFOShardedCache) ImportStats(exported []byte) error {
+	return c.stats.Import(exported)
 }
 
 // Has checks if a key is in the cache, without updating the
@@ -94,6 +144,7 @@ func (c *FIFOShardedCache) HasOrAdd(key []byte, value interface{}, _ int) (has,
 	added = c.cache.SetIfAbsent(string(key), value)
 
 	if added {
+		c.fillRate.Record(time.Now(), c.Len)
 		c.callAddedDataHandlers(key, value)
 	}
 
@@ -113,6 +164,12 @@ func (c *FIFOShardedCache) Remove(key []byte) {
 	c.cache.Remove(string(key))
 }
 
+// GetAndRemove atomically returns the value of the key and removes it from the cache, so when several callers pop
+// the same key only one of them gets the value. Returns false if the key is missing
+func (c *FIFOShardedCache) GetAndRemove(key []byte) (value interface{}, ok bool) {
+	return c.cache.Pop(string(key))
+}
+
 // Keys returns a slice of the keys in the cache, from oldest to newest.
 func (c *FIFOShardedCache) Keys() [][]byte {
 	res := c.cache.Keys()
@@ -125,6 +182,22 @@ func (c *FIFOShardedCache) Keys() [][]byte {
 	return r
 }
 
+// Range calls the handler for each entry, shard by shard, in no particular order. The iteration stops when the
+// handler returns false. The read lock of the iterated shard is held while calling the handler, so the handler
+// must not write in the cache
+func (c *FIFOShardedCache) Range(handler func(key []byte, value interface{}) bool) {
+	if handler == nil {
+		return
+	}
+
+	shouldContinue := true
+	c.cache.IterCb(func(key string, value interface{}) {
+		if shouldContinue {
+			shouldContinue = handler([]byte(key), value)
+		}
+	})
+}
+
 // Len returns the number of items in the cache.
 func (c *FIFOShardedCache) Len() int {
 	return c.cache.Count()
@@ -140,6 +213,12 @@ func (c *FIFOShardedCache) MaxSize() int {
 	return c.maxsize
 }
 
+// FillRate returns how fast the cache filled during the last minute, in entries per second, and the predicted time
+// left until all the shards hold their effective capacity at this rate
+func (c *FIFOShardedCache) FillRate() (entriesPerSec float64, timeToFull time.Duration) {
+	return c.fillRate.FillRate(time.Now(), c.Len(), c.numShards*c.shardCapacity)
+}
+
 // Close does nothing for this cacher implementation
 func (c *FIFOShardedCache) Close() error {
 	return nil
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/fifocache"
 	"github.com/stretchr/testify/assert"
 )
@@ -423,3 +424,201 @@ func TestFifoShardedCache_ConcurrentOperation(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestFIFOShardedCache_GetAndRemoveShouldPopOnlyOnce(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 2)
+	key := []byte("key")
+
+	value, ok := c.GetAndRemove(key)
+	assert.False(t, ok)
+	assert.Nil(t, value)
+
+	c.Put(key, "value", 0)
+
+	numPopped := uint32(0)
+	wg := sync.WaitGroup{}
+	wg.Add(10)
+	mut := sync.Mutex{}
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+
+			popped, found := c.GetAndRemove(key)
+			if found {
+				assert.Equal(t, "value", popped)
+				mut.Lock()
+				numPopped++
+				mut.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint32(1), numPopped)
+	assert.False(t, c.Has(key))
+}
+
+func TestFIFOShardedCache_StatsShouldSurviveExportAndImport(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(10, 2)
+	c.Put([]byte("key"), "value", 0)
+	_, _ = c.Get([]byte("key"))
+	_, _ = c.Get([]byte("missing"))
+	assert.Equal(t, common.CacheStats{Hits: 1, Misses: 1}, c.Stats())
+
+	restarted, _ := fifocache.NewShardedCache(10, 2)
+	assert.Equal(t, common.ErrInvalidCacheStats, restarted.ImportStats(nil))
+	assert.Nil(t, restarted.ImportStats(c.ExportStats()))
+	_, _ = restarted.Get([]byte("key"))
+	assert.Equal(t, common.CacheStats{Hits: 1, Misses: 2}, restarted.Stats())
+}
+
+func TestFIFOShardedCache_FillRate(t *testing.T) {
+	t.Parallel()
+
+	// a shard of 3 slots holds at most 2 entries
+	c, _ := fifocache.NewShardedCache(3, 1)
+	entriesPerSec, timeToFull := c.FillRate()
+	assert.Equal(t, float64(0), entriesPerSec)
+	assert.Equal(t, common.NeverFull, timeToFull)
+
+	c.Put([]byte("key1"), "value", 0)
+	_, _ = c.HasOrAdd([]byte("key2"), "value", 0)
+	_, _ = c.HasOrAdd([]byte("key3"), "value", 0)
+	_, timeToFull = c.FillRate()
+	assert.Equal(t, time.Duration(0), timeToFull)
+}
+
+func TestFIFOShardedCache_Range(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 4)
+	for i := 0; i < 20; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+
+	ranged := make(map[string]interface{})
+	c.Range(func(key []byte, value interface{}) bool {
+		ranged[string(key)] = value
+		return true
+	})
+	assert.Equal(t, 20, len(ranged))
+	assert.Equal(t, 7, ranged["key7"])
+
+	numCalls := 0
+	c.Range(func(key []byte, value interface{}) bool {
+		numCalls++
+		return numCalls < 5
+	})
+	assert.Equal(t, 5, numCalls)
+}
+
+func TestFIFOShardedCache_ShardStats(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should account each shard", func(t *testing.T) {
+		t.Parallel()
+
+		numShards := 4
+		c, _ := fifocache.NewShardedCache(400, numShards)
+		for i := 0; i < 50; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+		for i := 0; i < 100; i++ {
+			_, _ = c.Get([]byte(fmt.Sprintf("key%d", i)))
+		}
+
+		stats := c.ShardStats()
+		assert.Equal(t, numShards, len(stats))
+
+		totalLen := 0
+		totalCounters := common.CacheStats{}
+		for _, stat := range stats {
+			assert.Equal(t, 99, stat.Capacity)
+			totalLen += stat.Len
+			totalCounters.Hits += stat.Hits
+			totalCounters.Misses += stat.Misses
+		}
+		assert.Equal(t, c.Len(), totalLen)
+		assert.Equal(t, c.Stats(), totalCounters)
+		assert.Equal(t, common.CacheStats{Hits: 50, Misses: 50}, totalCounters)
+	})
+	t.Run("full shards should report their capacity", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := fifocache.NewShardedCache(40, 4)
+		for i := 0; i < 1000; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+
+		for _, stat := range c.ShardStats() {
+			assert.Equal(t, stat.Capacity, stat.Len)
+		}
+	})
+	t.Run("hot key should skew one shard", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := fifocache.NewShardedCache(100, 4)
+		c.Put([]byte("hot"), "value", 0)
+		for i := 0; i < 100; i++ {
+			_, _ = c.Get([]byte("hot"))
+		}
+
+		numHotShards := 0
+		for _, stat := range c.ShardStats() {
+			if stat.Hits == 100 {
+				numHotShards++
+				assert.Equal(t, 1, stat.Len)
+				continue
+			}
+			assert.Equal(t, uint64(0), stat.Hits)
+			assert.Equal(t, 0, stat.Len)
+		}
+		assert.Equal(t, 1, numHotShards)
+	})
+}
+
+func TestFIFOShardedCache_CheckInvariants(t *testing.T) {
+	t.Parallel()
+
+	t.Run("consistent cache should not error", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := fifocache.NewShardedCache(40, 4)
+		for i := 0; i < 200; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i%60)), i, 0)
+			if i%5 == 0 {
+				c.Remove([]byte(fmt.Sprintf("key%d", i%13)))
+			}
+		}
+
+		assert.Nil(t, c.CheckInvariants())
+	})
+	t.Run("full shards should not error", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := fifocache.NewShardedCache(40, 4)
+		for i := 0; i < 1000; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+
+		assert.Nil(t, c.CheckInvariants())
+	})
+}
+
+func TestFIFOShardedCache_GetMulti(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 4)
+	for i := 0; i < 10; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+	}
+
+	found := c.GetMulti([][]byte{[]byte("key1"), []byte("key7"), []byte("missing")})
+	assert.Equal(t, map[string]interface{}{"key1": 1, "key7": 7}, found)
+	assert.Equal(t, common.CacheStats{Hits: 2, Misses: 1}, c.Stats())
+	assert.Empty(t, c.GetMulti(nil))
+}
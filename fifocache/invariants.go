@@ -0,0 +1,53 @@
+package fifocache
+
+import (
+	"fmt"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+// CheckInvariants returns an error describing the first inconsistency found between the insertion order rings
+// and the entries maps of the shards: a key listed more than once or missing from the entries, an entry missing
+// from the insertion order, so it would never be evicted, or a shard holding more entries than its capacity.
+// The shards are visited one after the other, so the cache should not be written during the check. It is meant
+// for tests and debugging, as it visits all the entries
+func (c *FIFOShardedCache) CheckInvariants() error {
+	entries := make(map[string]struct{})
+	shardLens := make([]int, c.numShards)
+	c.cache.IterCb(func(key string, _ interface{}) {
+		entries[key] = struct{}{}
+		shardLens[c.shardIndex(key)]++
+	})
+
+	for i, shardLen := range shardLens {
+		if shardLen > c.shardCapacity {
+			return fmt.Errorf("%w: shard %d holds %d entries over its capacity of %d",
+				common.ErrCacheInvariantViolated, i, shardLen, c.shardCapacity)
+		}
+	}
+
+	ordered := make(map[string]struct{}, len(entries))
+	for _, key := range c.cache.Keys() {
+		_, found := ordered[key]
+		if found {
+			return fmt.Errorf("%w: key %x listed more than once in the insertion order", common.ErrCacheInvariantViolated, key)
+		}
+		ordered[key] = struct{}{}
+
+		_, found = entries[key]
+		if !found {
+			return fmt.Errorf("%w: orphaned insertion order key %x", common.ErrCacheInvariantViolated, key)
+		}
+	}
+
+	if len(ordered) != len(entries) {
+		for key := range entries {
+			_, found := ordered[key]
+			if !found {
+				return fmt.Errorf("%w: key %x missing from the insertion order", common.ErrCacheInvariantViolated, key)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package fifocache
+
+// ShardStat holds the statistics of one shard of the FIFO sharded cache
+type ShardStat struct {
+	Len      int
+	Capacity int
+	Hits     uint64
+	Misses   uint64
+}
+
+// ShardStats returns the statistics of each shard, in shard order. The shards are visited one after the other,
+// each one being locked only while its own entries are counted, so the result is not an atomic view of the whole
+// cache. Counting the entries visits all of them, the method being meant for diagnostics, not for hot paths.
+// The hits and the misses are the ones recorded since the cache creation, the counters added with ImportStats
+// having no shard
+func (c *FIFOShardedCache) ShardStats() []ShardStat {
+	stats := make([]ShardStat, c.numShards)
+	for i := range stats {
+		shardCounters := c.shardsStats[i].Stats()
+		stats[i] = ShardStat{
+			Capacity: c.shardCapacity,
+			Hits:     shardCounters.Hits,
+			Misses:   shardCounters.Misses,
+		}
+	}
+
+	// the callback is called while holding only the lock of the shard being iterated
+	c.cache.IterCb(func(key string, _ interface{}) {
+		stats[c.shardIndex(key)].Len++
+	})
+
+	return stats
+}
+
+// shardIndex mirrors the shard selection of the concurrent map
+func (c *FIFOShardedCache) shardIndex(key string) int {
+	return int(uint(fnv32(key)) % uint(c.numShards))
+}
+
+func fnv32(key string) uint32 {
+	hash := uint32(2166136261)
+	const prime32 = uint32(16777619)
+	for i := 0; i < len(key); i++ {
+		hash *= prime32
+		hash ^= uint32(key[i])
+	}
+
+	return hash
+}
+
+// computeShardCapacity mirrors the shard sizing of the concurrent map. A shard keeps its keys in a ring where the
+// slot following the last added key is always freed, so it holds one entry less than its size
+func computeShardCapacity(size int, shards int) int {
+	shardSize := size / shards
+	if shardSize == 0 {
+		shardSize = 1
+	}
+	if size%shards != 0 {
+		shardSize++
+	}
+
+	return shardSize - 1
+}
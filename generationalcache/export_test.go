@@ -0,0 +1,9 @@
+package generationalcache
+
+// NumHeldEntries returns the number of entries held in memory, including the stale ones not yet swept
+func (c *generationalCache) NumHeldEntries() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.order.Len()
+}
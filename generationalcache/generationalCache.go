@@ -0,0 +1,360 @@
+package generationalcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*generationalCache)(nil)
+
+var log = logger.GetOrCreate("storage/generationalcache")
+
+// sweepChunkSize is the maximum number of stale entries removed while holding the lock during a background sweep
+const sweepChunkSize = 1000
+
+type entry struct {
+	key        string
+	value      interface{}
+	size       int
+	generation uint64
+}
+
+// generationalCache implements a cache whose entries expire when the generation they were inserted in is left,
+// instead of after a time span. Bumping the generation invalidates all the contained entries in O(1): the stale
+// entries are no longer returned, they are dropped when accessed and proactively swept in the background.
+// The entries are kept in insertion order, so when the maximum size is reached the stale entries are evicted first,
+// then the oldest inserted ones
+type generationalCache struct {
+	mut             sync.Mutex
+	maxSize         int
+	generation      uint64
+	entries         map[string]*list.Element
+	order           *list.List
+	numLive         int
+	liveSizeInBytes uint64
+	chSweep         chan struct{}
+	cancelFunc      func()
+
+	mutAddedDataHandlers sync.RWMutex
+	mapDataHandlers      map[string]func(key []byte, value interface{})
+}
+
+// NewGenerationalCache creates a new generational cache holding at most the provided number of elements
+func NewGenerationalCache(capacity int) (*generationalCache, error) {
+	if capacity < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+
+	c := &generationalCache{
+		maxSize:         capacity,
+		entries:         make(map[string]*list.Element, capacity),
+		order:           list.New(),
+		chSweep:         make(chan struct{}, 1),
+		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
+	}
+
+	var ctx context.Context
+	ctx, c.cancelFunc = context.WithCancel(context.Background())
+	go c.startSweeping(ctx)
+
+	return c, nil
+}
+
+// startSweeping removes the stale entries each time the generation is bumped
+func (c *generationalCache) startSweeping(ctx context.Context) {
+	for {
+		select {
+		case <-c.chSweep:
+			c.sweep()
+		case <-ctx.Done():
+			log.Debug("closing generational cache's sweep go routine...")
+			return
+		}
+	}
+}
+
+// sweep removes the stale entries in chunks, so the other operations are not blocked for the whole sweep
+func (c *generationalCache) sweep() {
+	for {
+		c.mut.Lock()
+		numRemoved := c.removeStaleEntries(sweepChunkSize)
+		c.mut.Unlock()
+
+		if numRemoved < sweepChunkSize {
+			return
+		}
+	}
+}
+
+// removeStaleEntries removes at most maxEntries stale entries. As the entries are kept ordered by generation,
+// the stale ones are all at the front
+func (c *generationalCache) removeStaleEntries(maxEntries int) int {
+	numRemoved := 0
+	for numRemoved < maxEntries {
+		front := c.order.Front()
+		if front == nil || !c.isStale(front) {
+			return numRemoved
+		}
+
+		c.removeElement(front)
+		numRemoved++
+	}
+
+	return numRemoved
+}
+
+// Generation returns the current generation
+func (c *generationalCache) Generation() uint64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.generation
+}
+
+// BumpGeneration starts a new generation, invalidating all the entries inserted in the prior generations.
+// The stale entries are released asynchronously
+func (c *generationalCache) BumpGeneration() {
+	c.mut.Lock()
+	c.generation++
+	c.numLive = 0
+	c.liveSizeInBytes = 0
+	c.mut.Unlock()
+
+	select {
+	case c.chSweep <- struct{}{}:
+	default:
+	}
+}
+
+// Clear is used to completely clear the cache.
+func (c *generationalCache) Clear() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.entries = make(map[string]*list.Element, c.maxSize)
+	c.order.Init()
+	c.numLive = 0
+	c.liveSizeInBytes = 0
+}
+
+// Put adds a value to the cache in the current generation. Returns true if a live entry was evicted.
+func (c *generationalCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	if sizeInBytes < 0 {
+		log.Error("generational cache put error",
+			"key", key,
+			"error", common.ErrNegativeSizeInBytes,
+		)
+
+		return false
+	}
+
+	c.mut.Lock()
+	evicted = c.put(string(key), value, sizeInBytes)
+	c.mut.Unlock()
+
+	c.callAddedDataHandlers(key, value)
+
+	return evicted
+}
+
+func (c *generationalCache) put(key string, value interface{}, sizeInBytes int) bool {
+	element, ok := c.entries[key]
+	if ok {
+		existing := element.Value.(*entry)
+		if c.isStale(element) {
+			c.numLive++
+		} else {
+			c.liveSizeInBytes -= uint64(existing.size)
+		}
+		c.liveSizeInBytes += uint64(sizeInBytes)
+
+		existing.value = value
+		existing.size = sizeInBytes
+		existing.generation = c.generation
+		c.order.MoveToBack(element)
+
+		return false
+	}
+
+	evicted := false
+	for c.order.Len() >= c.maxSize {
+		front := c.order.Front()
+		evicted = evicted || !c.isStale(front)
+		c.removeElement(front)
+	}
+
+	c.entries[key] = c.order.PushBack(&entry{
+		key:        key,
+		value:      value,
+		size:       sizeInBytes,
+		generation: c.generation,
+	})
+	c.numLive++
+	c.liveSizeInBytes += uint64(sizeInBytes)
+
+	return evicted
+}
+
+func (c *generationalCache) isStale(element *list.Element) bool {
+	return element.Value.(*entry).generation < c.generation
+}
+
+func (c *generationalCache) removeElement(element *list.Element) {
+	e := element.Value.(*entry)
+	if !c.isStale(element) {
+		c.numLive--
+		c.liveSizeInBytes -= uint64(e.size)
+	}
+
+	c.order.Remove(element)
+	delete(c.entries, e.key)
+}
+
+// Get looks up a key's value from the cache. A stale entry is dropped and reported as missing.
+func (c *generationalCache) Get(key []byte) (value interface{}, ok bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	element, ok := c.entries[string(key)]
+	if !ok {
+		return nil, false
+	}
+	if c.isStale(element) {
+		c.removeElement(element)
+		return nil, false
+	}
+
+	return element.Value.(*entry).value, true
+}
+
+// Has checks if a key of the current generation is in the cache, without deleting it for being stale.
+func (c *generationalCache) Has(key []byte) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	element, ok := c.entries[string(key)]
+
+	return ok && !c.isStale(element)
+}
+
+// Peek returns the key value (or undefined if not found). It behaves as Get since the cache does not track usage.
+func (c *generationalCache) Peek(key []byte) (value interface{}, ok bool) {
+	return c.Get(key)
+}
+
+// HasOrAdd checks if a key of the current generation is in the cache and if not, adds the value.
+// Returns whether found and whether the value was added.
+func (c *generationalCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	if sizeInBytes < 0 {
+		return c.Has(key), false
+	}
+
+	c.mut.Lock()
+	element, ok := c.entries[string(key)]
+	if ok && !c.isStale(element) {
+		c.mut.Unlock()
+		return true, false
+	}
+
+	c.put(string(key), value, sizeInBytes)
+	c.mut.Unlock()
+
+	c.callAddedDataHandlers(key, value)
+
+	return false, true
+}
+
+// Remove removes the provided key from the cache.
+func (c *generationalCache) Remove(key []byte) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	element, ok := c.entries[string(key)]
+	if !ok {
+		return
+	}
+
+	c.removeElement(element)
+}
+
+// Keys returns a slice of the keys of the current generation, from oldest to newest.
+func (c *generationalCache) Keys() [][]byte {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	keys := make([][]byte, 0, c.numLive)
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		if c.isStale(element) {
+			continue
+		}
+
+		keys = append(keys, []byte(element.Value.(*entry).key))
+	}
+
+	return keys
+}
+
+// Len returns the number of items of the current generation in the cache.
+func (c *generationalCache) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.numLive
+}
+
+// SizeInBytesContained returns the size in bytes of all the elements of the current generation
+func (c *generationalCache) SizeInBytesContained() uint64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.liveSizeInBytes
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (c *generationalCache) MaxSize() int {
+	return c.maxSize
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (c *generationalCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	c.mutAddedDataHandlers.Lock()
+	c.mapDataHandlers[id] = handler
+	c.mutAddedDataHandlers.Unlock()
+}
+
+// UnRegisterHandler removes the handler from the list
+func (c *generationalCache) UnRegisterHandler(id string) {
+	c.mutAddedDataHandlers.Lock()
+	delete(c.mapDataHandlers, id)
+	c.mutAddedDataHandlers.Unlock()
+}
+
+func (c *generationalCache) callAddedDataHandlers(key []byte, value interface{}) {
+	c.mutAddedDataHandlers.RLock()
+	for _, handler := range c.mapDataHandlers {
+		go handler(key, value)
+	}
+	c.mutAddedDataHandlers.RUnlock()
+}
+
+// Close stops the background sweeping
+func (c *generationalCache) Close() error {
+	c.cancelFunc()
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *generationalCache) IsInterfaceNil() bool {
+	return c == nil
+}
@@ -0,0 +1,216 @@
+package generationalcache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/generationalcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGenerationalCache(t *testing.T) {
+	t.Parallel()
+
+	c, err := generationalcache.NewGenerationalCache(0)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	c, err = generationalcache.NewGenerationalCache(10)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, c.MaxSize())
+	assert.Equal(t, uint64(0), c.Generation())
+	_ = c.Close()
+}
+
+func TestGenerationalCache_PutGetRemove(t *testing.T) {
+	t.Parallel()
+
+	c, _ := generationalcache.NewGenerationalCache(10)
+	defer func() {
+		_ = c.Close()
+	}()
+	key := []byte("key")
+
+	evicted := c.Put(key, "value", 5)
+	assert.False(t, evicted)
+	assert.True(t, c.Has(key))
+	val, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, "value", val)
+	assert.Equal(t, uint64(5), c.SizeInBytesContained())
+
+	c.Put(key, "new value", 9)
+	val, _ = c.Peek(key)
+	assert.Equal(t, "new value", val)
+	assert.Equal(t, uint64(9), c.SizeInBytesContained())
+	assert.Equal(t, 1, c.Len())
+
+	has, added := c.HasOrAdd(key, "other", 1)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	c.Remove(key)
+	assert.False(t, c.Has(key))
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+	assert.Equal(t, 0, c.Len())
+	_, ok = c.Get(key)
+	assert.False(t, ok)
+}
+
+func TestGenerationalCache_BumpGenerationShouldInvalidateEntries(t *testing.T) {
+	t.Parallel()
+
+	c, _ := generationalcache.NewGenerationalCache(10)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	c.Put([]byte("old1"), "value", 1)
+	c.Put([]byte("old2"), "value", 1)
+	c.BumpGeneration()
+	assert.Equal(t, uint64(1), c.Generation())
+	c.Put([]byte("new"), "value", 3)
+
+	assert.False(t, c.Has([]byte("old1")))
+	_, ok := c.Get([]byte("old2"))
+	assert.False(t, ok)
+	assert.True(t, c.Has([]byte("new")))
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(3), c.SizeInBytesContained())
+	assert.Equal(t, [][]byte{[]byte("new")}, c.Keys())
+
+	has, added := c.HasOrAdd([]byte("old1"), "refreshed", 2)
+	assert.False(t, has)
+	assert.True(t, added)
+	val, ok := c.Get([]byte("old1"))
+	assert.True(t, ok)
+	assert.Equal(t, "refreshed", val)
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, uint64(5), c.SizeInBytesContained())
+}
+
+func TestGenerationalCache_StaleEntriesShouldBeSweptInBackground(t *testing.T) {
+	t.Parallel()
+
+	c, _ := generationalcache.NewGenerationalCache(5000)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	for i := 0; i < 2500; i++ {
+		c.Put([]byte(fmt.Sprintf("key%d", i)), i, 1)
+	}
+	c.BumpGeneration()
+	c.Put([]byte("new"), "value", 1)
+
+	assert.Eventually(t, func() bool {
+		return c.NumHeldEntries() == 1
+	}, time.Second, time.Millisecond*10)
+	assert.True(t, c.Has([]byte("new")))
+}
+
+func TestGenerationalCache_PutShouldEvictStaleEntriesFirst(t *testing.T) {
+	t.Parallel()
+
+	c, _ := generationalcache.NewGenerationalCache(2)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	c.Put([]byte("key1"), "value", 1)
+	c.Put([]byte("key2"), "value", 1)
+	evicted := c.Put([]byte("key3"), "value", 1)
+	assert.True(t, evicted)
+	assert.False(t, c.Has([]byte("key1")))
+	assert.Equal(t, [][]byte{[]byte("key2"), []byte("key3")}, c.Keys())
+
+	c.BumpGeneration()
+	evicted = c.Put([]byte("key4"), "value", 1)
+	assert.False(t, evicted)
+	evicted = c.Put([]byte("key5"), "value", 1)
+	assert.False(t, evicted)
+	assert.Equal(t, [][]byte{[]byte("key4"), []byte("key5")}, c.Keys())
+}
+
+func TestGenerationalCache_ClearShouldRemoveAllEntries(t *testing.T) {
+	t.Parallel()
+
+	c, _ := generationalcache.NewGenerationalCache(10)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	c.Put([]byte("key1"), "value", 1)
+	c.BumpGeneration()
+	c.Put([]byte("key2"), "value", 1)
+	c.Clear()
+
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, 0, c.NumHeldEntries())
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+}
+
+func TestGenerationalCache_RegisterHandlerShouldBeCalledOnAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := generationalcache.NewGenerationalCache(10)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	chCalled := make(chan []byte, 1)
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		chCalled <- key
+	}, "id")
+
+	c.Put([]byte("key"), "value", 1)
+	select {
+	case key := <-chCalled:
+		assert.Equal(t, []byte("key"), key)
+	case <-time.After(time.Second):
+		assert.Fail(t, "handler not called")
+	}
+}
+
+func TestGenerationalCache_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := generationalcache.NewGenerationalCache(100)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	numOperations := 1000
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key%d", idx%150))
+			switch idx % 6 {
+			case 0:
+				c.Put(key, idx, 1)
+			case 1:
+				_, _ = c.Get(key)
+			case 2:
+				_ = c.Has(key)
+			case 3:
+				_, _ = c.HasOrAdd(key, idx, 1)
+			case 4:
+				c.Remove(key)
+			case 5:
+				c.BumpGeneration()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.NumHeldEntries(), 100)
+	assert.LessOrEqual(t, c.Len(), c.NumHeldEntries())
+}
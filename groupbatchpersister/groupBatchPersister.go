@@ -0,0 +1,355 @@
+package groupbatchpersister
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*groupBatchPersister)(nil)
+
+var log = logger.GetOrCreate("storage/groupbatchpersister")
+
+// ErrInvalidBatchSize signals that a batch group was configured with a non positive maximum number of operations
+var ErrInvalidBatchSize = errors.New("invalid batch size")
+
+// ErrInvalidBatchSizeInBytes signals that a batch group was configured with a negative maximum size in bytes
+var ErrInvalidBatchSizeInBytes = errors.New("invalid batch size in bytes")
+
+// GroupConfig defines the batching policy of a batch group
+type GroupConfig struct {
+	// MaxBatchSize is the number of pending operations flushing the group, 1 writing each operation right away
+	MaxBatchSize int
+	// MaxBatchSizeInBytes is the size of the pending keys and values flushing the group, 0 meaning unbounded
+	MaxBatchSizeInBytes int
+}
+
+func (config GroupConfig) check() error {
+	if config.MaxBatchSize < 1 {
+		return ErrInvalidBatchSize
+	}
+	if config.MaxBatchSizeInBytes < 0 {
+		return ErrInvalidBatchSizeInBytes
+	}
+
+	return nil
+}
+
+type batchGroup struct {
+	config      GroupConfig
+	ops         []types.Operation
+	sizeInBytes int
+}
+
+type pendingValue struct {
+	group string
+	value []byte
+}
+
+// groupBatchPersister accumulates the writes done with PutInGroup in independent per group batches, each group
+// having its own batching policy, so a latency sensitive group can flush right away while a bulk group batches
+// aggressively in the same persister. A group is flushed as one atomic batch when its size limits are reached or
+// on FlushGroup, there is no time based flush. The pending values are visible to the reads. Writing a key pending
+// in another group, or writing it directly with Put or Remove, first flushes that group, so the writes of a key are
+// persisted in order
+type groupBatchPersister struct {
+	persister     types.Persister
+	batchApplier  types.BatchApplier
+	defaultConfig GroupConfig
+
+	mut     sync.RWMutex
+	groups  map[string]*batchGroup
+	pending map[string]pendingValue
+}
+
+// NewGroupBatchPersister creates a persister wrapper batching the writes per group, the groups not configured with
+// SetGroupConfig using the provided default policy. It returns ErrBatchNotSupported if the inner persister can not
+// apply batches atomically
+func NewGroupBatchPersister(inner types.Persister, defaultConfig GroupConfig) (*groupBatchPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	batchApplier, ok := inner.(types.BatchApplier)
+	if !ok {
+		return nil, common.ErrBatchNotSupported
+	}
+	err := defaultConfig.check()
+	if err != nil {
+		return nil, err
+	}
+
+	return &groupBatchPersister{
+		persister:     inner,
+		batchApplier:  batchApplier,
+		defaultConfig: defaultConfig,
+		groups:        make(map[string]*batchGroup),
+		pending:       make(map[string]pendingValue),
+	}, nil
+}
+
+// SetGroupConfig sets the batching policy of the group, flushing it if the pending operations already reach the
+// new limits
+func (gbp *groupBatchPersister) SetGroupConfig(group string, config GroupConfig) error {
+	err := config.check()
+	if err != nil {
+		return err
+	}
+
+	gbp.mut.Lock()
+	defer gbp.mut.Unlock()
+
+	bg := gbp.getOrCreateGroup(group)
+	bg.config = config
+
+	return gbp.flushIfFull(group, bg)
+}
+
+// PutInGroup adds the write to the batch of the group, flushing the group if its limits are reached
+func (gbp *groupBatchPersister) PutInGroup(group string, key, data []byte) error {
+	gbp.mut.Lock()
+	defer gbp.mut.Unlock()
+
+	pv, ok := gbp.pending[string(key)]
+	if ok && pv.group != group {
+		err := gbp.flushGroup(pv.group)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the pending operations outlive the call, so they must not alias the caller buffers
+	keyCopy := copyBytes(key)
+	dataCopy := copyBytes(data)
+	bg := gbp.getOrCreateGroup(group)
+	bg.ops = append(bg.ops, types.Operation{
+		Type:  types.PutOperation,
+		Key:   keyCopy,
+		Value: dataCopy,
+	})
+	bg.sizeInBytes += len(key) + len(data)
+	gbp.pending[string(key)] = pendingValue{
+		group: group,
+		value: dataCopy,
+	}
+
+	return gbp.flushIfFull(group, bg)
+}
+
+// FlushGroup writes the pending operations of the group as one atomic batch
+func (gbp *groupBatchPersister) FlushGroup(group string) error {
+	gbp.mut.Lock()
+	defer gbp.mut.Unlock()
+
+	return gbp.flushGroup(group)
+}
+
+// Flush writes the pending operations of all the groups, each group as one atomic batch
+func (gbp *groupBatchPersister) Flush() error {
+	gbp.mut.Lock()
+	defer gbp.mut.Unlock()
+
+	return gbp.flushAll()
+}
+
+// PendingGroupLen returns the number of operations of the group not yet written in the inner persister
+func (gbp *groupBatchPersister) PendingGroupLen(group string) int {
+	gbp.mut.RLock()
+	defer gbp.mut.RUnlock()
+
+	bg, ok := gbp.groups[group]
+	if !ok {
+		return 0
+	}
+
+	return len(bg.ops)
+}
+
+func (gbp *groupBatchPersister) getOrCreateGroup(group string) *batchGroup {
+	bg, ok := gbp.groups[group]
+	if !ok {
+		bg = &batchGroup{
+			config: gbp.defaultConfig,
+		}
+		gbp.groups[group] = bg
+	}
+
+	return bg
+}
+
+func (gbp *groupBatchPersister) flushIfFull(group string, bg *batchGroup) error {
+	isFull := len(bg.ops) >= bg.config.MaxBatchSize ||
+		(bg.config.MaxBatchSizeInBytes > 0 && bg.sizeInBytes >= bg.config.MaxBatchSizeInBytes)
+	if !isFull {
+		return nil
+	}
+
+	return gbp.flushGroup(group)
+}
+
+// flushGroupHolding flushes the group holding a pending write of the key, if any
+func (gbp *groupBatchPersister) flushGroupHolding(key []byte) error {
+	pv, ok := gbp.pending[string(key)]
+	if !ok {
+		return nil
+	}
+
+	return gbp.flushGroup(pv.group)
+}
+
+func (gbp *groupBatchPersister) flushGroup(group string) error {
+	bg, ok := gbp.groups[group]
+	if !ok || len(bg.ops) == 0 {
+		return nil
+	}
+
+	err := gbp.batchApplier.ApplyBatch(bg.ops)
+	if err != nil {
+		return fmt.Errorf("%w while flushing batch group %s", err, group)
+	}
+
+	for _, op := range bg.ops {
+		pv, found := gbp.pending[string(op.Key)]
+		if found && pv.group == group {
+			delete(gbp.pending, string(op.Key))
+		}
+	}
+	bg.ops = nil
+	bg.sizeInBytes = 0
+
+	return nil
+}
+
+func (gbp *groupBatchPersister) flushAll() error {
+	for group := range gbp.groups {
+		err := gbp.flushGroup(group)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Put writes the value right away in the inner persister, after flushing the group holding a pending write of
+// the key
+func (gbp *groupBatchPersister) Put(key, val []byte) error {
+	gbp.mut.Lock()
+	defer gbp.mut.Unlock()
+
+	err := gbp.flushGroupHolding(key)
+	if err != nil {
+		return err
+	}
+
+	return gbp.persister.Put(key, val)
+}
+
+// Get returns the pending value of the key if any, otherwise the value from the inner persister
+func (gbp *groupBatchPersister) Get(key []byte) ([]byte, error) {
+	gbp.mut.RLock()
+	defer gbp.mut.RUnlock()
+
+	pv, ok := gbp.pending[string(key)]
+	if ok {
+		return pv.value, nil
+	}
+
+	return gbp.persister.Get(key)
+}
+
+// Has returns nil if the key has a pending write or is present in the inner persister
+func (gbp *groupBatchPersister) Has(key []byte) error {
+	gbp.mut.RLock()
+	defer gbp.mut.RUnlock()
+
+	_, ok := gbp.pending[string(key)]
+	if ok {
+		return nil
+	}
+
+	return gbp.persister.Has(key)
+}
+
+// Remove removes the key right away from the inner persister, after flushing the group holding a pending write of
+// the key
+func (gbp *groupBatchPersister) Remove(key []byte) error {
+	gbp.mut.Lock()
+	defer gbp.mut.Unlock()
+
+	err := gbp.flushGroupHolding(key)
+	if err != nil {
+		return err
+	}
+
+	return gbp.persister.Remove(key)
+}
+
+// RangeKeys flushes all the groups and iterates over the inner persister
+func (gbp *groupBatchPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	gbp.mut.Lock()
+	err := gbp.flushAll()
+	gbp.mut.Unlock()
+	if err != nil {
+		log.Warn("groupBatchPersister.RangeKeys: pending writes not flushed", "error", err)
+	}
+
+	gbp.persister.RangeKeys(handler)
+}
+
+// Close flushes all the groups and closes the inner persister
+func (gbp *groupBatchPersister) Close() error {
+	gbp.mut.Lock()
+	err := gbp.flushAll()
+	gbp.mut.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return gbp.persister.Close()
+}
+
+// Destroy drops the pending writes and destroys the inner persister
+func (gbp *groupBatchPersister) Destroy() error {
+	gbp.dropPending()
+
+	return gbp.persister.Destroy()
+}
+
+// DestroyClosed drops the pending writes and destroys the closed inner persister
+func (gbp *groupBatchPersister) DestroyClosed() error {
+	gbp.dropPending()
+
+	return gbp.persister.DestroyClosed()
+}
+
+func (gbp *groupBatchPersister) dropPending() {
+	gbp.mut.Lock()
+	defer gbp.mut.Unlock()
+
+	for _, bg := range gbp.groups {
+		bg.ops = nil
+		bg.sizeInBytes = 0
+	}
+	gbp.pending = make(map[string]pendingValue)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (gbp *groupBatchPersister) IsInterfaceNil() bool {
+	return gbp == nil
+}
+
+func copyBytes(buff []byte) []byte {
+	if buff == nil {
+		return nil
+	}
+
+	buffCopy := make([]byte, len(buff))
+	copy(buffCopy, buff)
+
+	return buffCopy
+}
@@ -0,0 +1,207 @@
+package groupbatchpersister_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/groupbatchpersister"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+var bulkConfig = groupbatchpersister.GroupConfig{
+	MaxBatchSize: 100,
+}
+
+func TestNewGroupBatchPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		gbp, err := groupbatchpersister.NewGroupBatchPersister(nil, bulkConfig)
+		require.True(t, check.IfNil(gbp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("persister without batches should error", func(t *testing.T) {
+		t.Parallel()
+
+		gbp, err := groupbatchpersister.NewGroupBatchPersister(&testscommon.PersisterStub{}, bulkConfig)
+		require.True(t, check.IfNil(gbp))
+		require.Equal(t, common.ErrBatchNotSupported, err)
+	})
+	t.Run("invalid config should error", func(t *testing.T) {
+		t.Parallel()
+
+		gbp, err := groupbatchpersister.NewGroupBatchPersister(memorydb.New(), groupbatchpersister.GroupConfig{})
+		require.True(t, check.IfNil(gbp))
+		require.Equal(t, groupbatchpersister.ErrInvalidBatchSize, err)
+
+		config := groupbatchpersister.GroupConfig{MaxBatchSize: 1, MaxBatchSizeInBytes: -1}
+		gbp, err = groupbatchpersister.NewGroupBatchPersister(memorydb.New(), config)
+		require.True(t, check.IfNil(gbp))
+		require.Equal(t, groupbatchpersister.ErrInvalidBatchSizeInBytes, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		gbp, err := groupbatchpersister.NewGroupBatchPersister(memorydb.New(), bulkConfig)
+		require.False(t, check.IfNil(gbp))
+		require.Nil(t, err)
+	})
+}
+
+func TestGroupBatchPersister_GroupsShouldFlushIndependently(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	gbp, _ := groupbatchpersister.NewGroupBatchPersister(db, bulkConfig)
+	err := gbp.SetGroupConfig("block", groupbatchpersister.GroupConfig{MaxBatchSize: 1})
+	require.Nil(t, err)
+
+	require.Nil(t, gbp.PutInGroup("bulk", []byte("b1"), []byte("v1")))
+	require.Nil(t, gbp.PutInGroup("block", []byte("k1"), []byte("v2")))
+
+	require.Nil(t, db.Has([]byte("k1")))
+	require.NotNil(t, db.Has([]byte("b1")))
+	require.Equal(t, 1, gbp.PendingGroupLen("bulk"))
+	require.Equal(t, 0, gbp.PendingGroupLen("block"))
+
+	value, err := gbp.Get([]byte("b1"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("v1"), value)
+	require.Nil(t, gbp.Has([]byte("b1")))
+
+	require.Nil(t, gbp.FlushGroup("bulk"))
+	require.Nil(t, db.Has([]byte("b1")))
+	require.Equal(t, 0, gbp.PendingGroupLen("bulk"))
+}
+
+func TestGroupBatchPersister_SizeInBytesShouldFlush(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	config := groupbatchpersister.GroupConfig{MaxBatchSize: 100, MaxBatchSizeInBytes: 10}
+	gbp, _ := groupbatchpersister.NewGroupBatchPersister(db, config)
+
+	require.Nil(t, gbp.PutInGroup("group", []byte("k1"), []byte("abc")))
+	require.NotNil(t, db.Has([]byte("k1")))
+	require.Nil(t, gbp.PutInGroup("group", []byte("k2"), []byte("abc")))
+	require.Nil(t, db.Has([]byte("k1")))
+	require.Nil(t, db.Has([]byte("k2")))
+}
+
+func TestGroupBatchPersister_GroupShouldBeFlushedAsOneBatch(t *testing.T) {
+	t.Parallel()
+
+	batches := make([][]types.Operation, 0)
+	stub := &testscommon.BatchPersisterStub{
+		ApplyBatchCalled: func(ops []types.Operation) error {
+			batches = append(batches, ops)
+			return nil
+		},
+	}
+	gbp, _ := groupbatchpersister.NewGroupBatchPersister(stub, groupbatchpersister.GroupConfig{MaxBatchSize: 3})
+
+	_ = gbp.PutInGroup("group", []byte("k1"), []byte("v1"))
+	_ = gbp.PutInGroup("group", []byte("k2"), []byte("v2"))
+	require.Empty(t, batches)
+	_ = gbp.PutInGroup("group", []byte("k3"), []byte("v3"))
+	require.Equal(t, 1, len(batches))
+	require.Equal(t, 3, len(batches[0]))
+}
+
+func TestGroupBatchPersister_ReusedBuffersShouldNotChangeThePendingWrites(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	gbp, _ := groupbatchpersister.NewGroupBatchPersister(db, groupbatchpersister.GroupConfig{MaxBatchSize: 10})
+
+	key := []byte("k1")
+	data := []byte("v1")
+	_ = gbp.PutInGroup("group", key, data)
+	copy(key, "k2")
+	copy(data, "v2")
+
+	value, err := gbp.Get([]byte("k1"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("v1"), value)
+
+	require.Nil(t, gbp.Flush())
+	value, err = db.Get([]byte("k1"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("v1"), value)
+	require.NotNil(t, db.Has([]byte("k2")))
+}
+
+func TestGroupBatchPersister_WritesOfAKeyShouldStayOrdered(t *testing.T) {
+	t.Parallel()
+
+	t.Run("put in another group", func(t *testing.T) {
+		t.Parallel()
+
+		db := memorydb.New()
+		gbp, _ := groupbatchpersister.NewGroupBatchPersister(db, bulkConfig)
+
+		_ = gbp.PutInGroup("first", []byte("key"), []byte("old"))
+		_ = gbp.PutInGroup("second", []byte("key"), []byte("new"))
+		require.Equal(t, 0, gbp.PendingGroupLen("first"))
+
+		_ = gbp.FlushGroup("second")
+		value, _ := db.Get([]byte("key"))
+		require.Equal(t, []byte("new"), value)
+	})
+	t.Run("direct remove", func(t *testing.T) {
+		t.Parallel()
+
+		db := memorydb.New()
+		gbp, _ := groupbatchpersister.NewGroupBatchPersister(db, bulkConfig)
+
+		_ = gbp.PutInGroup("", []byte("key"), []byte("value"))
+		require.Nil(t, gbp.Remove([]byte("key")))
+		require.Equal(t, 0, gbp.PendingGroupLen(""))
+
+		_ = gbp.Flush()
+		require.NotNil(t, gbp.Has([]byte("key")))
+		require.NotNil(t, db.Has([]byte("key")))
+	})
+}
+
+func TestGroupBatchPersister_FailedFlushShouldKeepThePendingWrites(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+	stub := &testscommon.BatchPersisterStub{
+		ApplyBatchCalled: func(ops []types.Operation) error {
+			return expectedErr
+		},
+	}
+	gbp, _ := groupbatchpersister.NewGroupBatchPersister(stub, bulkConfig)
+
+	_ = gbp.PutInGroup("group", []byte("key"), []byte("value"))
+	err := gbp.FlushGroup("group")
+	require.True(t, errors.Is(err, expectedErr))
+	require.Equal(t, 1, gbp.PendingGroupLen("group"))
+
+	value, err := gbp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), value)
+}
+
+func TestGroupBatchPersister_CloseShouldFlush(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	gbp, _ := groupbatchpersister.NewGroupBatchPersister(db, bulkConfig)
+
+	_ = gbp.PutInGroup("a", []byte("k1"), []byte("v1"))
+	_ = gbp.PutInGroup("b", []byte("k2"), []byte("v2"))
+	require.Nil(t, gbp.Close())
+
+	require.Nil(t, db.Has([]byte("k1")))
+	require.Nil(t, db.Has([]byte("k2")))
+}
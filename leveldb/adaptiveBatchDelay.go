@@ -0,0 +1,71 @@
+package leveldb
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// rateSmoothingFactor is the weight of the last measured write rate in the exponentially weighted moving average
+const rateSmoothingFactor = 0.5
+
+// adaptiveBatchDelay computes the delay between two timed batch writes from the recent write rate: under low load
+// the delay approaches the minimum, reducing the time the writes wait in the pending batch, while under high load it
+// approaches the maximum, letting the batch size limits trigger the writes and keeping the batches large.
+// A nil adaptiveBatchDelay always returns the fixed delay
+type adaptiveBatchDelay struct {
+	minDelay     time.Duration
+	maxDelay     time.Duration
+	maxBatchSize int
+	numWrites    uint64
+	// writeRate is only accessed by the timed batch goroutine
+	writeRate float64
+}
+
+func newAdaptiveBatchDelay(minDelay time.Duration, maxDelay time.Duration, maxBatchSize int) *adaptiveBatchDelay {
+	if maxBatchSize < 1 {
+		maxBatchSize = 1
+	}
+
+	return &adaptiveBatchDelay{
+		minDelay:     minDelay,
+		maxDelay:     maxDelay,
+		maxBatchSize: maxBatchSize,
+	}
+}
+
+func (abd *adaptiveBatchDelay) recordWrite() {
+	if abd == nil {
+		return
+	}
+
+	atomic.AddUint64(&abd.numWrites, 1)
+}
+
+func (abd *adaptiveBatchDelay) initialDelay(fixedDelay time.Duration) time.Duration {
+	if abd == nil {
+		return fixedDelay
+	}
+
+	return abd.minDelay
+}
+
+// nextDelay updates the write rate with the writes recorded during the elapsed interval and returns the next delay
+func (abd *adaptiveBatchDelay) nextDelay(elapsed time.Duration, fixedDelay time.Duration) time.Duration {
+	if abd == nil {
+		return fixedDelay
+	}
+
+	numWrites := atomic.SwapUint64(&abd.numWrites, 0)
+	if elapsed > 0 {
+		currentRate := float64(numWrites) / elapsed.Seconds()
+		abd.writeRate = rateSmoothingFactor*currentRate + (1-rateSmoothingFactor)*abd.writeRate
+	}
+
+	// load is the fraction of a full batch that would be accumulated while waiting the maximum delay
+	load := abd.writeRate * abd.maxDelay.Seconds() / float64(abd.maxBatchSize)
+	if load > 1 {
+		load = 1
+	}
+
+	return abd.minDelay + time.Duration(load*float64(abd.maxDelay-abd.minDelay))
+}
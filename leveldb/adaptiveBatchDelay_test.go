@@ -0,0 +1,49 @@
+package leveldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveBatchDelay_NilShouldReturnTheFixedDelay(t *testing.T) {
+	t.Parallel()
+
+	var abd *adaptiveBatchDelay
+	abd.recordWrite()
+
+	assert.Equal(t, time.Second, abd.initialDelay(time.Second))
+	assert.Equal(t, time.Second, abd.nextDelay(time.Millisecond, time.Second))
+}
+
+func TestAdaptiveBatchDelay_ShouldFollowTheWriteRate(t *testing.T) {
+	t.Parallel()
+
+	minDelay := time.Millisecond * 10
+	maxDelay := time.Second
+	abd := newAdaptiveBatchDelay(minDelay, maxDelay, 100)
+	assert.Equal(t, minDelay, abd.initialDelay(time.Second))
+
+	// idle
+	assert.Equal(t, minDelay, abd.nextDelay(minDelay, time.Second))
+
+	// 1000 writes per second would fill the batch 10 times during the maximum delay
+	for i := 0; i < 100; i++ {
+		abd.recordWrite()
+	}
+	delay := abd.nextDelay(time.Millisecond*100, time.Second)
+	assert.Equal(t, maxDelay, delay)
+
+	// the load decreases gradually
+	delay = abd.nextDelay(time.Second, time.Second)
+	assert.Equal(t, maxDelay, delay)
+	previousDelay := delay
+	for i := 0; i < 10; i++ {
+		delay = abd.nextDelay(time.Second, time.Second)
+		assert.LessOrEqual(t, delay, previousDelay)
+		previousDelay = delay
+	}
+	assert.Less(t, delay, maxDelay/10)
+	assert.GreaterOrEqual(t, delay, minDelay)
+}
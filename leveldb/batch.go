@@ -3,6 +3,7 @@ package leveldb
 import (
 	"sync"
 
+	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/types"
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -13,6 +14,7 @@ type batch struct {
 	batch       *leveldb.Batch
 	cachedData  map[string][]byte
 	removedData map[string]struct{}
+	sizeInBytes int
 	mutBatch    sync.RWMutex
 }
 
@@ -32,6 +34,7 @@ func (b *batch) Put(key []byte, val []byte) error {
 	b.batch.Put(key, val)
 	b.cachedData[string(key)] = val
 	delete(b.removedData, string(key))
+	b.sizeInBytes += len(key) + len(val)
 	b.mutBatch.Unlock()
 	return nil
 }
@@ -42,6 +45,7 @@ func (b *batch) Delete(key []byte) error {
 	b.batch.Delete(key)
 	b.removedData[string(key)] = struct{}{}
 	delete(b.cachedData, string(key))
+	b.sizeInBytes += len(key)
 	b.mutBatch.Unlock()
 	return nil
 }
@@ -52,9 +56,18 @@ func (b *batch) Reset() {
 	b.batch.Reset()
 	b.cachedData = make(map[string][]byte)
 	b.removedData = make(map[string]struct{})
+	b.sizeInBytes = 0
 	b.mutBatch.Unlock()
 }
 
+// getSizeInBytes returns the accumulated size of the keys and values recorded in the batch
+func (b *batch) getSizeInBytes() int {
+	b.mutBatch.RLock()
+	defer b.mutBatch.RUnlock()
+
+	return b.sizeInBytes
+}
+
 // Get returns the value
 func (b *batch) Get(key []byte) []byte {
 	b.mutBatch.RLock()
@@ -73,6 +86,45 @@ func (b *batch) IsRemoved(key []byte) bool {
 	return found
 }
 
+// clone returns a new batch holding the same entries as this one
+func (b *batch) clone() *batch {
+	b.mutBatch.RLock()
+	defer b.mutBatch.RUnlock()
+
+	newBatch := NewBatch()
+	_ = b.batch.Replay(newBatch.batch)
+	for key, val := range b.cachedData {
+		newBatch.cachedData[key] = val
+	}
+	for key := range b.removedData {
+		newBatch.removedData[key] = struct{}{}
+	}
+	newBatch.sizeInBytes = b.sizeInBytes
+
+	return newBatch
+}
+
+// applyOperations records all the provided operations in the batch. No operation is
+// recorded if one of them has an invalid type
+func (b *batch) applyOperations(ops []types.Operation) error {
+	for _, op := range ops {
+		if op.Type != types.PutOperation && op.Type != types.RemoveOperation {
+			return common.ErrInvalidOperationType
+		}
+	}
+
+	for _, op := range ops {
+		if op.Type == types.PutOperation {
+			_ = b.Put(op.Key, op.Value)
+			continue
+		}
+
+		_ = b.Delete(op.Key)
+	}
+
+	return nil
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (b *batch) IsInterfaceNil() bool {
 	return b == nil
@@ -0,0 +1,182 @@
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+const maxCheckpointAttempts = 5
+
+// read + write for owner only
+const rwOwner = 0600
+
+// ErrCheckpointDirNotEmpty signals that the checkpoint destination directory already contains files
+var ErrCheckpointDirNotEmpty = errors.New("checkpoint destination directory is not empty")
+
+// createCheckpoint copies the database files from srcPath into destDir without blocking the writer.
+// The immutable table files are hard linked (or copied if linking is not possible), then the journals and, last,
+// the manifest are copied. Copying the manifest last guarantees that every table it references was already
+// captured unless a compaction or a memtable flush happened in the meantime, case in which the checkpoint fails
+// the validation and is retried.
+func createCheckpoint(srcPath string, destDir string) error {
+	err := checkCheckpointDestination(destDir)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; attempt <= maxCheckpointAttempts; attempt++ {
+		err = tryCreateCheckpoint(srcPath, destDir)
+		if err == nil {
+			return nil
+		}
+
+		log.Debug("checkpoint attempt failed", "source", srcPath, "destination", destDir, "attempt", attempt, "error", err)
+		errRemove := removeDirContent(destDir)
+		if errRemove != nil {
+			return errRemove
+		}
+	}
+
+	return fmt.Errorf("%w, checkpoint failed after %d attempts", err, maxCheckpointAttempts)
+}
+
+func checkCheckpointDestination(destDir string) error {
+	err := os.MkdirAll(destDir, rwxOwner)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return ErrCheckpointDirNotEmpty
+	}
+
+	return nil
+}
+
+func tryCreateCheckpoint(srcPath string, destDir string) error {
+	src := &readOnlyStorage{path: srcPath}
+
+	tables, err := src.List(storage.TypeTable)
+	if err != nil {
+		return err
+	}
+	for _, fd := range tables {
+		err = linkOrCopyTable(srcPath, destDir, fd)
+		if err != nil {
+			return err
+		}
+	}
+
+	journals, err := src.List(storage.TypeJournal)
+	if err != nil {
+		return err
+	}
+	for _, fd := range journals {
+		err = copyFile(filepath.Join(srcPath, generateFileName(fd)), filepath.Join(destDir, generateFileName(fd)))
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest, err := src.GetMeta()
+	if err != nil {
+		return err
+	}
+	manifestName := generateFileName(manifest)
+	err = copyFile(filepath.Join(srcPath, manifestName), filepath.Join(destDir, manifestName))
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(destDir, currentFileName), []byte(manifestName+"\n"), rwOwner)
+	if err != nil {
+		return err
+	}
+
+	return validateCheckpoint(destDir)
+}
+
+// validateCheckpoint opens the checkpoint, which fails if any table referenced by the manifest is missing
+func validateCheckpoint(destDir string) error {
+	db, err := leveldb.OpenFile(destDir, &opt.Options{ErrorIfMissing: true})
+	if err != nil {
+		return err
+	}
+
+	return db.Close()
+}
+
+func linkOrCopyTable(srcPath string, destDir string, fd storage.FileDesc) error {
+	name := generateFileName(fd)
+	srcFile := filepath.Join(srcPath, name)
+	if _, err := os.Stat(srcFile); os.IsNotExist(err) {
+		// older databases might contain tables with the legacy extension
+		name = fmt.Sprintf("%06d.sst", fd.Num)
+		srcFile = filepath.Join(srcPath, name)
+	}
+
+	destFile := filepath.Join(destDir, name)
+	err := os.Link(srcFile, destFile)
+	if err == nil {
+		return nil
+	}
+
+	log.Trace("cannot hard link table file, copying it", "file", srcFile, "error", err)
+
+	return copyFile(srcFile, destFile)
+}
+
+func copyFile(srcFile string, destFile string) error {
+	src, err := os.Open(srcFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dest, err := os.OpenFile(destFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, rwOwner)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dest, src)
+	if err != nil {
+		_ = dest.Close()
+		return err
+	}
+
+	err = dest.Sync()
+	if err != nil {
+		_ = dest.Close()
+		return err
+	}
+
+	return dest.Close()
+}
+
+func removeDirContent(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err = os.RemoveAll(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
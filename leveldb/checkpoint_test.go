@@ -0,0 +1,133 @@
+package leveldb_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDB_CheckpointShouldCaptureTheCurrentState(t *testing.T) {
+	t.Parallel()
+
+	db, err := leveldb.NewDB(t.TempDir(), 10, 100, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	for i := 0; i < 50; i++ {
+		require.Nil(t, db.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i))))
+	}
+
+	checkpointDir := filepath.Join(t.TempDir(), "checkpoint")
+	require.Nil(t, db.Checkpoint(checkpointDir))
+	require.Equal(t, 0, db.PendingBatchLen())
+
+	require.Nil(t, db.Put([]byte("after"), []byte("checkpoint")))
+
+	replica, err := leveldb.NewReadReplica(checkpointDir)
+	require.Nil(t, err)
+	for i := 0; i < 50; i++ {
+		val, errGet := replica.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.Nil(t, errGet)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+	require.ErrorIs(t, replica.Has([]byte("after")), common.ErrKeyNotFound)
+	require.Nil(t, replica.Close())
+
+	restored, err := leveldb.NewDB(checkpointDir, 10, 1, 10)
+	require.Nil(t, err)
+	val, err := restored.Get([]byte("key7"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value7"), val)
+	require.Nil(t, restored.Close())
+}
+
+func TestDB_CheckpointWithNotEmptyDestinationShouldError(t *testing.T) {
+	t.Parallel()
+
+	db, err := leveldb.NewDB(t.TempDir(), 10, 1, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	checkpointDir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(checkpointDir, "file"), []byte("content"), 0600))
+
+	err = db.Checkpoint(checkpointDir)
+	require.Equal(t, leveldb.ErrCheckpointDirNotEmpty, err)
+}
+
+func TestDB_CheckpointOnClosedDBShouldError(t *testing.T) {
+	t.Parallel()
+
+	db, err := leveldb.NewDB(t.TempDir(), 10, 1, 10)
+	require.Nil(t, err)
+	require.Nil(t, db.Close())
+
+	require.ErrorIs(t, db.Checkpoint(t.TempDir()), common.ErrDBIsClosed)
+}
+
+func TestDB_CheckpointShouldNotBlockConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	db, err := leveldb.NewDB(t.TempDir(), 10, 10, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	value := make([]byte, 1024)
+	for i := 0; i < 1000; i++ {
+		require.Nil(t, db.Put([]byte(fmt.Sprintf("initial%d", i)), value))
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = db.Put([]byte(fmt.Sprintf("concurrent%d", i)), value)
+		}
+	}()
+
+	checkpointDir := filepath.Join(t.TempDir(), "checkpoint")
+	require.Nil(t, db.Checkpoint(checkpointDir))
+	wg.Wait()
+
+	replica, err := leveldb.NewReadReplica(checkpointDir)
+	require.Nil(t, err)
+	count, err := replica.CountPrefix([]byte("initial"))
+	require.Nil(t, err)
+	require.Equal(t, uint64(1000), count)
+	require.Nil(t, replica.Close())
+}
+
+func TestSerialDB_CheckpointShouldCaptureTheCurrentState(t *testing.T) {
+	t.Parallel()
+
+	db, err := leveldb.NewSerialDB(t.TempDir(), 10, 100, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	require.Nil(t, db.Put([]byte("key"), []byte("value")))
+
+	checkpointDir := filepath.Join(t.TempDir(), "checkpoint")
+	require.Nil(t, db.Checkpoint(checkpointDir))
+
+	replica, err := leveldb.NewReadReplica(checkpointDir)
+	require.Nil(t, err)
+	val, err := replica.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), val)
+	require.Nil(t, replica.Close())
+}
@@ -1,24 +1,92 @@
 package leveldb
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const resourceUnavailable = "resource temporarily unavailable"
 const maxRetries = 10
 const timeBetweenRetries = time.Second
+const persisterSizeReportInterval = time.Minute
+const noEntriesLimit = 0
+
+// nonStrictReadOptions makes the iterators skip the corrupted table blocks instead of stopping on them
+var nonStrictReadOptions = &opt.ReadOptions{
+	Strict: opt.StrictOverride,
+}
 
 // loggingDBCounter this variable should be used only used in logging prints
 var loggingDBCounter = uint32(0)
 
 func openLevelDB(path string, options *opt.Options) (*leveldb.DB, error) {
+	return openLevelDBWithContext(context.Background(), path, options)
+}
+
+type openResult struct {
+	db  *leveldb.DB
+	err error
+}
+
+// openLevelDBWithTimeout opens the database, retries included, in at most the provided timeout. The opening is done
+// on a separate go routine, as a hung disk blocks the file system calls which can not be interrupted
+func openLevelDBWithTimeout(path string, options *opt.Options, timeout time.Duration) (*leveldb.DB, error) {
+	if timeout <= 0 {
+		return openLevelDB(path, options)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	chResult := make(chan openResult, 1)
+	go func() {
+		db, err := openLevelDBWithContext(ctx, path, options)
+		chResult <- openResult{
+			db:  db,
+			err: err,
+		}
+	}()
+
+	select {
+	case result := <-chResult:
+		if result.err != nil && ctx.Err() != nil {
+			return nil, common.ErrOpenTimeout
+		}
+
+		return result.db, result.err
+	case <-ctx.Done():
+		go closeAbandonedDb(path, chResult)
+		return nil, common.ErrOpenTimeout
+	}
+}
+
+// closeAbandonedDb closes the database opened after the timeout, so its file lock does not outlive the failed open
+func closeAbandonedDb(path string, chResult chan openResult) {
+	result := <-chResult
+	if result.err != nil {
+		return
+	}
+
+	log.Warn("closing the database opened after the open timeout", "path", path)
+	err := result.db.Close()
+	if err != nil {
+		log.Warn("error closing the database opened after the open timeout", "path", path, "error", err)
+	}
+}
+
+func openLevelDBWithContext(ctx context.Context, path string, options *opt.Options) (*leveldb.DB, error) {
 	retries := 0
 	for {
 		db, err := openOneTime(path, options)
@@ -35,7 +103,11 @@ func openLevelDB(path string, options *opt.Options) (*leveldb.DB, error) {
 			"retry", retries,
 		)
 
-		time.Sleep(timeBetweenRetries)
+		select {
+		case <-time.After(timeBetweenRetries):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w, retried %d number of times", ctx.Err(), retries)
+		}
 		retries++
 		if retries > maxRetries {
 			return nil, fmt.Errorf("%w, retried %d number of times", err, maxRetries)
@@ -74,9 +146,41 @@ func openOneTime(path string, options *opt.Options) (*leveldb.DB, error) {
 }
 
 type baseLevelDb struct {
-	mutDb sync.RWMutex
-	path  string
-	db    *leveldb.DB
+	mutDb                 sync.RWMutex
+	path                  string
+	db                    *leveldb.DB
+	onCorruption          OnCorruptionHandler
+	numSkippedCorruptions uint64
+	journalSyncer         *journalSyncer
+	// chSizeReportsDone is closed when the periodic size reports stop, nil if they were never started
+	chSizeReportsDone chan struct{}
+}
+
+// writeOptions returns the options of the batch writes, which are synced unless the journal is periodically synced
+func (bldb *baseLevelDb) writeOptions() *opt.WriteOptions {
+	return &opt.WriteOptions{
+		Sync: bldb.journalSyncer == nil,
+	}
+}
+
+// closeDb closes the database and syncs the journals the periodic syncer did not sync yet
+func (bldb *baseLevelDb) closeDb(db *leveldb.DB) error {
+	err := db.Close()
+	if err != nil {
+		return err
+	}
+
+	return bldb.journalSyncer.sync()
+}
+
+// compactAll compacts the whole key range of the database
+func (bldb *baseLevelDb) compactAll() error {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return common.ErrDBIsClosed
+	}
+
+	return db.CompactRange(util.Range{})
 }
 
 func (bldb *baseLevelDb) getDbPointer() *leveldb.DB {
@@ -103,6 +207,8 @@ func (bldb *baseLevelDb) makeDbPointerNilReturningLast() *leveldb.DB {
 
 // RangeKeys will call the handler function for each (key, value) pair
 // If the handler returns true, the iteration will continue, otherwise will stop
+// When a corrupted entry is met, the iteration stops unless an OnCorruption handler was provided and returns true,
+// case in which the corrupted entries are skipped and the iteration continues with the next readable entry
 func (bldb *baseLevelDb) RangeKeys(handler func(key []byte, value []byte) bool) {
 	if handler == nil {
 		return
@@ -113,12 +219,150 @@ func (bldb *baseLevelDb) RangeKeys(handler func(key []byte, value []byte) bool)
 		return
 	}
 
+	var lastKey []byte
+	for {
+		var err error
+		var limitReached bool
+		lastKey, _, err = rangeKeysAfter(db, lastKey, nil, noEntriesLimit, handler)
+		if err == nil {
+			return
+		}
+		if !bldb.shouldSkipCorruption(lastKey, err) {
+			return
+		}
+
+		// the corrupted entries are passed over by reading the next readable entry without the strict checks,
+		// the strict iteration is then resumed after it so any further corruption gets reported as well
+		lastKey, limitReached, err = rangeKeysAfter(db, lastKey, nonStrictReadOptions, 1, handler)
+		if err != nil {
+			log.Warn("leveldb RangeKeys stopped after skipping a corruption", "path", bldb.path, "error", err)
+			return
+		}
+		if !limitReached {
+			return
+		}
+	}
+}
+
+// rangeKeysAfter calls the handler for the (key, value) pairs whose keys are greater than startAfter, a nil
+// startAfter meaning from the first key, and stops after maxEntries pairs if maxEntries is positive.
+// It returns the last handled key, whether maxEntries pairs were handled and the iteration error, if any
+func rangeKeysAfter(
+	db *leveldb.DB,
+	startAfter []byte,
+	readOptions *opt.ReadOptions,
+	maxEntries int,
+	handler func(key []byte, value []byte) bool,
+) ([]byte, bool, error) {
+	var slice *util.Range
+	if startAfter != nil {
+		// appending a zero byte builds the smallest key greater than startAfter
+		start := make([]byte, len(startAfter), len(startAfter)+1)
+		copy(start, startAfter)
+		slice = &util.Range{Start: append(start, 0)}
+	}
+
+	lastKey := startAfter
+	numHandled := 0
+	iterator := db.NewIterator(slice, readOptions)
+	defer iterator.Release()
+
+	for iterator.Next() {
+		key := iterator.Key()
+		clonedKey := make([]byte, len(key))
+		copy(clonedKey, key)
+
+		val := iterator.Value()
+		clonedVal := make([]byte, len(val))
+		copy(clonedVal, val)
+
+		lastKey = clonedKey
+		numHandled++
+		shouldContinue := handler(clonedKey, clonedVal)
+		if !shouldContinue {
+			return lastKey, false, nil
+		}
+		if maxEntries > noEntriesLimit && numHandled >= maxEntries {
+			return lastKey, true, nil
+		}
+	}
+
+	return lastKey, false, iterator.Error()
+}
+
+func (bldb *baseLevelDb) shouldSkipCorruption(lastKey []byte, err error) bool {
+	if bldb.onCorruption == nil || !errors.IsCorrupted(err) {
+		log.Warn("leveldb RangeKeys stopped", "path", bldb.path, "error", err)
+		return false
+	}
+	if !bldb.onCorruption(lastKey, err) {
+		return false
+	}
+
+	atomic.AddUint64(&bldb.numSkippedCorruptions, 1)
+	log.Debug("leveldb RangeKeys skipped a corruption", "path", bldb.path, "after key", lastKey, "error", err)
+
+	return true
+}
+
+// SkippedCorruptions returns the number of corruptions skipped by RangeKeys since the persister was opened.
+// All the entries of a corrupted table block are skipped together, so they are counted once
+func (bldb *baseLevelDb) SkippedCorruptions() uint64 {
+	return atomic.LoadUint64(&bldb.numSkippedCorruptions)
+}
+
+// FilterKeys will call the handler function for each key whose value satisfies the provided predicate
+// The predicate is evaluated during iteration and receives a value that must not be retained after the call.
+// If the handler returns true, the iteration will continue, otherwise will stop
+func (bldb *baseLevelDb) FilterKeys(predicate func(value []byte) bool, handler func(key []byte) bool) {
+	if predicate == nil || handler == nil {
+		return
+	}
+
+	db := bldb.getDbPointer()
+	if db == nil {
+		return
+	}
+
 	iterator := db.NewIterator(nil, nil)
 	for {
 		if !iterator.Next() {
 			break
 		}
 
+		if !predicate(iterator.Value()) {
+			continue
+		}
+
+		key := iterator.Key()
+		clonedKey := make([]byte, len(key))
+		copy(clonedKey, key)
+
+		shouldContinue := handler(clonedKey)
+		if !shouldContinue {
+			break
+		}
+	}
+
+	iterator.Release()
+}
+
+// RangePrefix will call the handler function for each (key, value) pair whose key starts with the provided prefix
+// If the handler returns true, the iteration will continue, otherwise will stop
+func (bldb *baseLevelDb) RangePrefix(prefix []byte, handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	db := bldb.getDbPointer()
+	if db == nil {
+		return
+	}
+
+	iterator := db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iterator.Release()
+
+	for iterator.Next() {
 		key := iterator.Key()
 		clonedKey := make([]byte, len(key))
 		copy(clonedKey, key)
@@ -129,9 +373,153 @@ func (bldb *baseLevelDb) RangeKeys(handler func(key []byte, value []byte) bool)
 
 		shouldContinue := handler(clonedKey, clonedVal)
 		if !shouldContinue {
-			break
+			return
 		}
 	}
+}
+
+// CountPrefix returns the number of persisted keys starting with the provided prefix
+// The values are never copied during iteration, making it cheaper than a RangeKeys call
+func (bldb *baseLevelDb) CountPrefix(prefix []byte) (uint64, error) {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return 0, common.ErrDBIsClosed
+	}
+
+	iterator := db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iterator.Release()
+
+	count := uint64(0)
+	for iterator.Next() {
+		count++
+	}
+
+	return count, iterator.Error()
+}
 
+// PrefixDiskSize returns the approximate size on disk of the keys starting with the provided prefix, overhead and
+// compression included, as estimated from the table files covering the prefixed range. The writes still held in
+// the journal are not in the table files yet, so they are only accounted after being flushed
+func (bldb *baseLevelDb) PrefixDiskSize(prefix []byte) (uint64, error) {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return 0, common.ErrDBIsClosed
+	}
+
+	prefixRange := util.BytesPrefix(prefix)
+	if prefixRange.Limit != nil {
+		sizes, err := db.SizeOf([]util.Range{*prefixRange})
+		if err != nil {
+			return 0, err
+		}
+
+		return uint64(sizes.Sum()), nil
+	}
+
+	// the range is not bounded above, as for the empty prefix, so it spans from its start to the end of the tables
+	stats := &leveldb.DBStats{}
+	err := db.Stats(stats)
+	if err != nil {
+		return 0, err
+	}
+	sizesBefore, err := db.SizeOf([]util.Range{{Limit: prefixRange.Start}})
+	if err != nil {
+		return 0, err
+	}
+
+	totalSize := stats.LevelSizes.Sum()
+	if sizesBefore.Sum() >= totalSize {
+		return 0, nil
+	}
+
+	return uint64(totalSize - sizesBefore.Sum()), nil
+}
+
+// removeAllKeys deletes all the persisted keys by writing a single full-range delete batch,
+// keeping the database opened
+func (bldb *baseLevelDb) removeAllKeys() error {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return common.ErrDBIsClosed
+	}
+
+	dbBatch := new(leveldb.Batch)
+	iterator := db.NewIterator(nil, nil)
+	for iterator.Next() {
+		dbBatch.Delete(iterator.Key())
+	}
 	iterator.Release()
+
+	err := iterator.Error()
+	if err != nil {
+		return err
+	}
+
+	wopt := &opt.WriteOptions{
+		Sync: true,
+	}
+
+	return db.Write(dbBatch, wopt)
+}
+
+// reportSizeHandle periodically reports the on-disk size of the database to the monitoring package
+func (bldb *baseLevelDb) reportSizeHandle(ctx context.Context) {
+	defer close(bldb.chSizeReportsDone)
+
+	bldb.reportSize()
+
+	ticker := time.NewTicker(persisterSizeReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			bldb.reportSize()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// clearReportedSize waits for the periodic size reports to stop, their context being already cancelled, and removes
+// the size of the database from the monitored total, so a closed database does not count anymore
+func (bldb *baseLevelDb) clearReportedSize() {
+	if bldb.chSizeReportsDone != nil {
+		<-bldb.chSizeReportsDone
+	}
+
+	monitoring.MonitorPersisterSize(bldb.path, 0)
+}
+
+func (bldb *baseLevelDb) reportSize() {
+	size, err := directorySize(bldb.path)
+	if err != nil {
+		log.Debug("cannot compute the persister size", "path", bldb.path, "error", err)
+		return
+	}
+
+	monitoring.MonitorPersisterSize(bldb.path, size)
+}
+
+func directorySize(path string) (uint64, error) {
+	size := uint64(0)
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		size += uint64(info.Size())
+
+		return nil
+	})
+
+	return size, err
 }
@@ -0,0 +1,150 @@
+package leveldb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+// compactionScheduleCheckInterval is how often the compaction scheduler checks whether a window was entered
+const compactionScheduleCheckInterval = time.Minute
+
+const compactionWindowTimeLayout = "15:04"
+
+// CompactionWindow is a daily time window, in local time, in which the full compaction of the database is allowed.
+// Start and End are the offsets from midnight, a window whose end precedes its start spanning over midnight
+type CompactionWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseCompactionSchedule parses compaction windows written as "HH:MM-HH:MM", such as "03:00-05:00" or
+// "23:30-01:00"
+func ParseCompactionSchedule(schedule []string) ([]CompactionWindow, error) {
+	windows := make([]CompactionWindow, 0, len(schedule))
+	for _, window := range schedule {
+		compactionWindow, err := parseCompactionWindow(window)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, compactionWindow)
+	}
+
+	return windows, nil
+}
+
+func parseCompactionWindow(window string) (CompactionWindow, error) {
+	bounds := strings.Split(window, "-")
+	if len(bounds) != 2 {
+		return CompactionWindow{}, fmt.Errorf("%w: %s", common.ErrInvalidCompactionWindow, window)
+	}
+
+	start, err := parseTimeOfDay(bounds[0])
+	if err != nil {
+		return CompactionWindow{}, fmt.Errorf("%w: %s", common.ErrInvalidCompactionWindow, window)
+	}
+	end, err := parseTimeOfDay(bounds[1])
+	if err != nil || start == end {
+		return CompactionWindow{}, fmt.Errorf("%w: %s", common.ErrInvalidCompactionWindow, window)
+	}
+
+	return CompactionWindow{
+		Start: start,
+		End:   end,
+	}, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse(compactionWindowTimeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (cw CompactionWindow) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if cw.Start < cw.End {
+		return offset >= cw.Start && offset < cw.End
+	}
+
+	return offset >= cw.Start || offset < cw.End
+}
+
+// compactionScheduler runs a full compaction each time one of the allowed windows is entered. A nil
+// compactionScheduler does nothing
+type compactionScheduler struct {
+	path    string
+	windows []CompactionWindow
+	compact func() error
+	// inWindow is only accessed by the scheduler goroutine
+	inWindow bool
+}
+
+func newCompactionScheduler(path string, windows []CompactionWindow, compact func() error) *compactionScheduler {
+	if len(windows) == 0 {
+		return nil
+	}
+
+	return &compactionScheduler{
+		path:    path,
+		windows: windows,
+		compact: compact,
+	}
+}
+
+func (cs *compactionScheduler) run(ctx context.Context) {
+	if cs == nil {
+		return
+	}
+
+	ticker := time.NewTicker(compactionScheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		cs.check(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Debug("closing the compaction scheduler", "path", cs.path)
+			return
+		}
+	}
+}
+
+// check compacts the database if a window was entered since the previous check. Only one compaction runs per
+// window, even if it ends before the window does
+func (cs *compactionScheduler) check(now time.Time) {
+	inWindow := cs.isInWindow(now)
+	entered := inWindow && !cs.inWindow
+	cs.inWindow = inWindow
+	if !entered {
+		return
+	}
+
+	log.Debug("scheduled leveldb compaction started", "path", cs.path)
+	startTime := time.Now()
+	err := cs.compact()
+	if err != nil {
+		log.Warn("scheduled leveldb compaction failed", "path", cs.path, "error", err)
+		return
+	}
+
+	log.Debug("scheduled leveldb compaction finished", "path", cs.path, "duration", time.Since(startTime))
+}
+
+func (cs *compactionScheduler) isInWindow(now time.Time) bool {
+	for _, window := range cs.windows {
+		if window.contains(now) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package leveldb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func timeOfDay(hour int, minute int) time.Time {
+	return time.Date(2024, 1, 1, hour, minute, 0, 0, time.Local)
+}
+
+func TestParseCompactionSchedule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid windows should error", func(t *testing.T) {
+		t.Parallel()
+
+		for _, window := range []string{"", "03:00", "03:00-", "3am-5am", "03:00-05:00-07:00", "25:00-05:00", "03:00-03:00"} {
+			windows, err := ParseCompactionSchedule([]string{window})
+			assert.Nil(t, windows)
+			assert.True(t, errors.Is(err, common.ErrInvalidCompactionWindow), window)
+		}
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		windows, err := ParseCompactionSchedule([]string{"03:00-05:00", " 23:30 - 01:00 "})
+		assert.Nil(t, err)
+		assert.Equal(t, []CompactionWindow{
+			{Start: 3 * time.Hour, End: 5 * time.Hour},
+			{Start: 23*time.Hour + 30*time.Minute, End: time.Hour},
+		}, windows)
+	})
+}
+
+func TestCompactionWindow_Contains(t *testing.T) {
+	t.Parallel()
+
+	window := CompactionWindow{Start: 3 * time.Hour, End: 5 * time.Hour}
+	assert.False(t, window.contains(timeOfDay(2, 59)))
+	assert.True(t, window.contains(timeOfDay(3, 0)))
+	assert.True(t, window.contains(timeOfDay(4, 59)))
+	assert.False(t, window.contains(timeOfDay(5, 0)))
+
+	overMidnight := CompactionWindow{Start: 23 * time.Hour, End: time.Hour}
+	assert.True(t, overMidnight.contains(timeOfDay(23, 30)))
+	assert.True(t, overMidnight.contains(timeOfDay(0, 30)))
+	assert.False(t, overMidnight.contains(timeOfDay(1, 0)))
+	assert.False(t, overMidnight.contains(timeOfDay(12, 0)))
+}
+
+func TestCompactionScheduler_ShouldCompactOncePerWindow(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newCompactionScheduler("path", nil, nil))
+
+	numCompactions := 0
+	cs := newCompactionScheduler("path", []CompactionWindow{{Start: 3 * time.Hour, End: 5 * time.Hour}}, func() error {
+		numCompactions++
+		return nil
+	})
+
+	cs.check(timeOfDay(2, 0))
+	assert.Equal(t, 0, numCompactions)
+	cs.check(timeOfDay(3, 1))
+	assert.Equal(t, 1, numCompactions)
+	cs.check(timeOfDay(4, 0))
+	assert.Equal(t, 1, numCompactions)
+	cs.check(timeOfDay(5, 0))
+	assert.Equal(t, 1, numCompactions)
+	cs.check(timeOfDay(3, 0))
+	assert.Equal(t, 2, numCompactions)
+}
+
+func TestWithCompactionSchedule_ShouldDeferTheAutomaticCompactions(t *testing.T) {
+	t.Parallel()
+
+	opts := createOptions(10, WithCompactionSchedule(nil))
+	assert.Nil(t, opts.compactionWindows)
+	assert.False(t, opts.levelDBOptions.DisableSeeksCompaction)
+
+	windows := []CompactionWindow{{Start: 3 * time.Hour, End: 5 * time.Hour}}
+	opts = createOptions(10, WithCompactionSchedule(windows))
+	assert.Equal(t, windows, opts.compactionWindows)
+	assert.True(t, opts.levelDBOptions.DisableSeeksCompaction)
+	assert.Equal(t, deferredCompactionL0Trigger, opts.levelDBOptions.CompactionL0Trigger)
+}
@@ -0,0 +1,104 @@
+package leveldb
+
+import (
+	"context"
+)
+
+// runWithContext runs the operation in a separate go routine and returns as soon as either the operation completes
+// or the context is done. As the goleveldb calls can not be interrupted, an operation whose context expired keeps
+// running in the background and its outcome is discarded
+func runWithContext(ctx context.Context, operation func() error) error {
+	if ctx.Done() == nil {
+		// the context can never be done, no need to spend a go routine
+		return operation()
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	chDone := make(chan error, 1)
+	go func() {
+		chDone <- operation()
+	}()
+
+	select {
+	case err = <-chDone:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func getWithContext(ctx context.Context, get func(key []byte) ([]byte, error), key []byte) ([]byte, error) {
+	chValue := make(chan []byte, 1)
+	err := runWithContext(ctx, func() error {
+		val, errGet := get(key)
+		chValue <- val
+		return errGet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return <-chValue, nil
+}
+
+// PutCtx adds the value to the (key, val) storage medium, returning the context error if the context is done first.
+// In that case the value might still be written afterwards
+func (s *DB) PutCtx(ctx context.Context, key, val []byte) error {
+	return runWithContext(ctx, func() error {
+		return s.Put(key, val)
+	})
+}
+
+// GetCtx returns the value associated to the key, returning the context error if the context is done first
+func (s *DB) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	return getWithContext(ctx, s.Get, key)
+}
+
+// HasCtx returns nil if the given key is present in the persistence medium, returning the context error if the
+// context is done first
+func (s *DB) HasCtx(ctx context.Context, key []byte) error {
+	return runWithContext(ctx, func() error {
+		return s.Has(key)
+	})
+}
+
+// RemoveCtx removes the data associated to the given key, returning the context error if the context is done
+// first. In that case the key might still be removed afterwards
+func (s *DB) RemoveCtx(ctx context.Context, key []byte) error {
+	return runWithContext(ctx, func() error {
+		return s.Remove(key)
+	})
+}
+
+// PutCtx adds the value to the (key, val) storage medium, returning the context error if the context is done first.
+// In that case the value might still be written afterwards
+func (s *SerialDB) PutCtx(ctx context.Context, key, val []byte) error {
+	return runWithContext(ctx, func() error {
+		return s.Put(key, val)
+	})
+}
+
+// GetCtx returns the value associated to the key, returning the context error if the context is done first
+func (s *SerialDB) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	return getWithContext(ctx, s.Get, key)
+}
+
+// HasCtx returns nil if the given key is present in the persistence medium, returning the context error if the
+// context is done first
+func (s *SerialDB) HasCtx(ctx context.Context, key []byte) error {
+	return runWithContext(ctx, func() error {
+		return s.Has(key)
+	})
+}
+
+// RemoveCtx removes the data associated to the given key, returning the context error if the context is done
+// first. In that case the key might still be removed afterwards
+func (s *SerialDB) RemoveCtx(ctx context.Context, key []byte) error {
+	return runWithContext(ctx, func() error {
+		return s.Remove(key)
+	})
+}
@@ -0,0 +1,121 @@
+package leveldb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/table"
+)
+
+const sstablesProperty = "leveldb.sstables"
+
+// EntryStats returns the number of live persisted keys and an estimation of the number of obsolete entries held by
+// the table files, that is the deletion markers and the overwritten values a compaction would drop. The obsolete
+// entries are estimated as the difference between all the entries of the tables listed by the database and the live
+// keys, so the keys written since the last memtable flush make it an underestimation. Both the live keys and the
+// tables are fully scanned, so it should only be called by maintenance jobs
+func (bldb *baseLevelDb) EntryStats() (uint64, uint64, error) {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return 0, 0, common.ErrDBIsClosed
+	}
+
+	tables, err := db.GetProperty(sstablesProperty)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	live, err := bldb.CountPrefix(nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tableEntries := uint64(0)
+	for _, tableNum := range parseTableNumbers(tables) {
+		numEntries, errCount := bldb.countTableEntries(tableNum)
+		if errCount != nil {
+			return 0, 0, errCount
+		}
+
+		tableEntries += numEntries
+	}
+
+	if tableEntries < live {
+		return live, 0, nil
+	}
+
+	return live, tableEntries - live, nil
+}
+
+// parseTableNumbers extracts the table file numbers from the sstables property, made of one "--- level N ---"
+// header per level followed by one "num:size[min .. max]" line per table
+func parseTableNumbers(property string) []int64 {
+	tableNums := make([]int64, 0)
+	scanner := bufio.NewScanner(strings.NewReader(property))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") {
+			continue
+		}
+
+		var tableNum int64
+		_, err := fmt.Sscanf(line, "%d:", &tableNum)
+		if err != nil {
+			continue
+		}
+
+		tableNums = append(tableNums, tableNum)
+	}
+
+	return tableNums
+}
+
+// countTableEntries counts all the internal entries of a table file, including the deletion markers. A table
+// removed by a concurrent compaction is counted as empty
+func (bldb *baseLevelDb) countTableEntries(tableNum int64) (uint64, error) {
+	fd := storage.FileDesc{
+		Type: storage.TypeTable,
+		Num:  tableNum,
+	}
+
+	file, err := os.Open(filepath.Join(bldb.path, generateFileName(fd)))
+	if os.IsNotExist(err) {
+		// the tables written by older versions use the sst extension
+		file, err = os.Open(filepath.Join(bldb.path, fmt.Sprintf("%06d.sst", tableNum)))
+	}
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	reader, err := table.NewReader(file, info.Size(), fd, nil, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Release()
+
+	iterator := reader.NewIterator(nil, nil)
+	defer iterator.Release()
+
+	numEntries := uint64(0)
+	for iterator.Next() {
+		numEntries++
+	}
+
+	return numEntries, iterator.Error()
+}
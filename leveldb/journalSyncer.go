@@ -0,0 +1,93 @@
+package leveldb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// journalSyncer periodically flushes to disk the journal files of a database whose writes are not synced, bounding
+// the data lost on a machine crash to the writes of the last interval. A nil journalSyncer does nothing
+type journalSyncer struct {
+	path     string
+	interval time.Duration
+}
+
+func newJournalSyncer(path string, interval time.Duration) *journalSyncer {
+	if interval <= 0 {
+		return nil
+	}
+
+	return &journalSyncer{
+		path:     path,
+		interval: interval,
+	}
+}
+
+func (js *journalSyncer) run(ctx context.Context) {
+	if js == nil {
+		return
+	}
+
+	ticker := time.NewTicker(js.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := js.sync()
+			if err != nil {
+				log.Warn("periodic leveldb journal sync failed", "path", js.path, "error", err)
+			}
+		case <-ctx.Done():
+			log.Debug("closing the journal syncer", "path", js.path)
+			return
+		}
+	}
+}
+
+// sync flushes all the journal files of the database. The journals are written by goleveldb through its own file
+// handles, but syncing a file flushes its modified data regardless of the handle used to write it. The previous
+// journals are synced as well, as goleveldb does not sync a journal when rotating it
+func (js *journalSyncer) sync() error {
+	if js == nil {
+		return nil
+	}
+
+	entries, err := os.ReadDir(js.path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		fd, ok := parseFileName(entry.Name())
+		if !ok || fd.Type != storage.TypeJournal {
+			continue
+		}
+
+		err = syncFile(filepath.Join(js.path, entry.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func syncFile(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	errSync := file.Sync()
+	errClose := file.Close()
+	if errSync != nil {
+		return errSync
+	}
+
+	return errClose
+}
@@ -0,0 +1,92 @@
+package leveldb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJournalSyncer(t *testing.T) {
+	t.Parallel()
+
+	js := newJournalSyncer(t.TempDir(), 0)
+	assert.Nil(t, js)
+	assert.Nil(t, js.sync())
+	assert.NotPanics(t, func() {
+		js.run(context.Background())
+	})
+
+	js = newJournalSyncer(t.TempDir(), time.Second)
+	assert.NotNil(t, js)
+}
+
+func TestJournalSyncer_Sync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		js := newJournalSyncer(filepath.Join(t.TempDir(), "missing"), time.Second)
+		assert.NotNil(t, js.sync())
+	})
+	t.Run("should sync the journals only", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "000001.log"), []byte("journal"), 0600))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "000002.log"), []byte("journal"), 0600))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "000003.ldb"), []byte("table"), 0600))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, "LOG"), []byte("log"), 0600))
+
+		js := newJournalSyncer(dir, time.Second)
+		assert.Nil(t, js.sync())
+	})
+}
+
+func TestDB_WithSyncEveryIntervalShouldWriteUnsynced(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir()
+	ldb, err := NewDB(path, 10, 1, 10, WithSyncEveryInterval(time.Millisecond*10))
+	require.Nil(t, err)
+	assert.False(t, ldb.writeOptions().Sync)
+
+	assert.Nil(t, ldb.Put([]byte("key"), []byte("value")))
+	time.Sleep(time.Millisecond * 50)
+	assert.Nil(t, ldb.Close())
+
+	ldb, err = NewDB(path, 10, 1, 10)
+	require.Nil(t, err)
+	assert.True(t, ldb.writeOptions().Sync)
+
+	value, err := ldb.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+	assert.Nil(t, ldb.Close())
+}
+
+func TestSerialDB_WithSyncEveryIntervalShouldWriteUnsynced(t *testing.T) {
+	t.Parallel()
+
+	path := t.TempDir()
+	ldb, err := NewSerialDB(path, 10, 1, 10, WithSyncEveryInterval(time.Millisecond*10))
+	require.Nil(t, err)
+	assert.False(t, ldb.writeOptions().Sync)
+
+	assert.Nil(t, ldb.Put([]byte("key"), []byte("value")))
+	time.Sleep(time.Millisecond * 50)
+	assert.Nil(t, ldb.Close())
+
+	ldb, err = NewSerialDB(path, 10, 1, 10)
+	require.Nil(t, err)
+
+	value, err := ldb.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+	assert.Nil(t, ldb.Close())
+}
@@ -12,34 +12,55 @@ import (
 	"github.com/DharitriOne/drt-chain-core-go/core"
 	logger "github.com/DharitriOne/drt-chain-logger-go"
 	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
 	"github.com/DharitriOne/drt-chain-storage-go/types"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 var _ types.Persister = (*DB)(nil)
+var _ types.BatchApplier = (*DB)(nil)
+var _ types.PrefixCounter = (*DB)(nil)
+var _ types.PrefixDiskSizer = (*DB)(nil)
+var _ types.PrefixRanger = (*DB)(nil)
+var _ types.Truncater = (*DB)(nil)
+var _ types.Snapshotter = (*DB)(nil)
+var _ types.Checkpointer = (*DB)(nil)
+var _ types.MultiGetter = (*DB)(nil)
+var _ types.ContextPersister = (*DB)(nil)
 
 // read + write + execute for owner only
 const rwxOwner = 0700
 const mkdirAllFunction = "mkdirAll"
 const openLevelDBFunction = "openLevelDB"
+const backendName = "leveldb"
 
 var log = logger.GetOrCreate("storage/leveldb")
 
 // DB holds a pointer to the leveldb database and the path to where it is stored.
 type DB struct {
 	*baseLevelDb
-	maxBatchSize      int
-	batchDelaySeconds int
-	sizeBatch         int
-	batch             types.Batcher
-	mutBatch          sync.RWMutex
-	cancel            context.CancelFunc
+	maxBatchSize        int
+	maxBatchSizeInBytes int
+	batchDelaySeconds   int
+	adaptiveDelay       *adaptiveBatchDelay
+	sizeBatch           int
+	batch               types.Batcher
+	mutBatch            sync.RWMutex
+	cancel              context.CancelFunc
+	levelDBOptions      *opt.Options
+	bulkLoadMode        bool
 }
 
 // NewDB is a constructor for the leveldb persister
 // It creates the files in the location given as parameter
-func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (s *DB, err error) {
+func NewDB(
+	path string,
+	batchDelaySeconds int,
+	maxBatchSize int,
+	maxOpenFiles int,
+	options ...Option,
+) (s *DB, err error) {
 	constructorName := "NewDB"
 
 	sw := core.NewStopWatch()
@@ -56,36 +77,45 @@ func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles in
 		return nil, common.ErrInvalidNumOpenFiles
 	}
 
-	options := &opt.Options{
-		// disable internal cache
-		BlockCacheCapacity:     -1,
-		OpenFilesCacheCapacity: maxOpenFiles,
+	dbOptions := createOptions(maxOpenFiles, options...)
+	adaptiveDelay, err := createAdaptiveBatchDelay(dbOptions, maxBatchSize)
+	if err != nil {
+		return nil, err
 	}
 
 	sw.Start(openLevelDBFunction)
-	db, err := openLevelDB(path, options)
+	db, err := openLevelDBWithTimeout(path, dbOptions.levelDBOptions, dbOptions.openTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("%w for path %s", err, path)
 	}
 	sw.Stop(openLevelDBFunction)
 
 	bldb := &baseLevelDb{
-		db:   db,
-		path: path,
+		db:                db,
+		path:              path,
+		onCorruption:      dbOptions.onCorruption,
+		journalSyncer:     newJournalSyncer(path, dbOptions.syncInterval),
+		chSizeReportsDone: make(chan struct{}),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	dbStore := &DB{
-		baseLevelDb:       bldb,
-		maxBatchSize:      maxBatchSize,
-		batchDelaySeconds: batchDelaySeconds,
-		sizeBatch:         0,
-		cancel:            cancel,
+		baseLevelDb:         bldb,
+		maxBatchSize:        maxBatchSize,
+		maxBatchSizeInBytes: dbOptions.maxBatchSizeInBytes,
+		batchDelaySeconds:   batchDelaySeconds,
+		adaptiveDelay:       adaptiveDelay,
+		sizeBatch:           0,
+		cancel:              cancel,
+		levelDBOptions:      dbOptions.levelDBOptions,
 	}
 
 	dbStore.batch = dbStore.createBatch()
 
 	go dbStore.batchTimeoutHandle(ctx)
+	go dbStore.reportSizeHandle(ctx)
+	go newCompactionScheduler(path, dbOptions.compactionWindows, bldb.compactAll).run(ctx)
+	go bldb.journalSyncer.run(ctx)
 
 	runtime.SetFinalizer(dbStore, func(db *DB) {
 		_ = db.Close()
@@ -101,8 +131,24 @@ func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles in
 	return dbStore, nil
 }
 
+// NewDBWithTimeout is a constructor for the leveldb persister bounding the whole opening, retries included, to the
+// provided timeout. It returns ErrOpenTimeout if the database could not be opened in time, as on a hung disk
+func NewDBWithTimeout(
+	path string,
+	timeout time.Duration,
+	batchDelaySeconds int,
+	maxBatchSize int,
+	maxOpenFiles int,
+	options ...Option,
+) (*DB, error) {
+	options = append(options, WithOpenTimeout(timeout))
+
+	return NewDB(path, batchDelaySeconds, maxBatchSize, maxOpenFiles, options...)
+}
+
 func (s *DB) batchTimeoutHandle(ctx context.Context) {
-	interval := time.Duration(s.batchDelaySeconds) * time.Second
+	fixedInterval := time.Duration(s.batchDelaySeconds) * time.Second
+	interval := s.adaptiveDelay.initialDelay(fixedInterval)
 	timer := time.NewTimer(interval)
 	defer timer.Stop()
 
@@ -111,6 +157,7 @@ func (s *DB) batchTimeoutHandle(ctx context.Context) {
 
 		select {
 		case <-timer.C:
+			interval = s.adaptiveDelay.nextDelay(interval, fixedInterval)
 			s.mutBatch.Lock()
 			err := s.putBatch(s.batch)
 			if err != nil {
@@ -134,7 +181,8 @@ func (s *DB) updateBatchWithIncrement() error {
 	defer s.mutBatch.Unlock()
 
 	s.sizeBatch++
-	if s.sizeBatch < s.maxBatchSize {
+	s.adaptiveDelay.recordWrite()
+	if s.sizeBatch < s.maxBatchSize && !isBatchSizeInBytesReached(s.batch, s.maxBatchSizeInBytes) {
 		return nil
 	}
 
@@ -156,22 +204,52 @@ func (s *DB) Put(key, val []byte) error {
 	err := s.batch.Put(key, val)
 	s.mutBatch.RUnlock()
 
+	if err != nil {
+		return common.NewStorageError(common.OpPut, key, backendName, err)
+	}
+
+	err = s.updateBatchWithIncrement()
+
+	return common.NewStorageError(common.OpPut, key, backendName, err)
+}
+
+// ApplyBatch atomically writes the provided Put and Remove operations, together with the
+// pending batch, in the database. If the write fails, the pending batch is left untouched
+func (s *DB) ApplyBatch(ops []types.Operation) error {
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	pendingBatch, ok := s.batch.(*batch)
+	if !ok {
+		return common.ErrInvalidBatch
+	}
+
+	newBatch := pendingBatch.clone()
+	err := newBatch.applyOperations(ops)
+	if err != nil {
+		return err
+	}
+
+	err = s.putBatch(newBatch)
 	if err != nil {
 		return err
 	}
 
-	return s.updateBatchWithIncrement()
+	s.batch.Reset()
+	s.sizeBatch = 0
+
+	return nil
 }
 
 // Get returns the value associated to the key
 func (s *DB) Get(key []byte) ([]byte, error) {
 	db := s.getDbPointer()
 	if db == nil {
-		return nil, common.ErrDBIsClosed
+		return nil, common.NewStorageError(common.OpGet, key, backendName, common.ErrDBIsClosed)
 	}
 
 	if s.batch.IsRemoved(key) {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewStorageError(common.OpGet, key, backendName, common.ErrKeyNotFound)
 	}
 
 	data := s.batch.Get(key)
@@ -181,24 +259,171 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 
 	data, err := db.Get(key, nil)
 	if err == leveldb.ErrNotFound {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewStorageError(common.OpGet, key, backendName, common.ErrKeyNotFound)
 	}
 	if err != nil {
-		return nil, err
+		return nil, common.NewStorageError(common.OpGet, key, backendName, err)
 	}
 
 	return data, nil
 }
 
+// GetExisting returns the values of the provided keys which are present, searching each key in the pending batch
+// and then in the database. The missing keys are omitted
+func (s *DB) GetExisting(keys [][]byte) (map[string][]byte, error) {
+	db := s.getDbPointer()
+	if db == nil {
+		return nil, common.NewStorageError(common.OpGet, nil, backendName, common.ErrDBIsClosed)
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if s.batch.IsRemoved(key) {
+			continue
+		}
+
+		data := s.batch.Get(key)
+		if data != nil {
+			values[string(key)] = data
+			continue
+		}
+
+		data, err := db.Get(key, nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, common.NewStorageError(common.OpGet, key, backendName, err)
+		}
+
+		values[string(key)] = data
+	}
+
+	return values, nil
+}
+
+// Snapshot writes the pending batch and returns a consistent read-only view of the database
+func (s *DB) Snapshot() (types.Snapshot, error) {
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	err := s.putBatch(s.batch)
+	if err != nil {
+		return nil, err
+	}
+
+	s.batch.Reset()
+	s.sizeBatch = 0
+
+	return newSnapshot(s.getDbPointer())
+}
+
+// Checkpoint writes the pending batch and creates in destDir, which must be empty or missing, a consistent copy
+// of the database that can be opened with NewDB or, read only, with NewReadReplica. The table files are hard
+// linked when possible, so the checkpoint is cheap and does not block the ongoing writes
+func (s *DB) Checkpoint(destDir string) error {
+	s.mutBatch.Lock()
+	err := s.putBatch(s.batch)
+	if err != nil {
+		s.mutBatch.Unlock()
+		return err
+	}
+	s.batch.Reset()
+	s.sizeBatch = 0
+	s.mutBatch.Unlock()
+
+	return createCheckpoint(s.path, destDir)
+}
+
+// SetBulkLoadMode switches the bulk load mode on or off. The mode speeds up the ingestion of large amounts of data,
+// as when restoring a backup in a fresh database: the database uses larger write buffers and table files and defers
+// the compactions, so the writes are not slowed down while the levels fill.
+// Durability: the writes done in bulk load mode are not synced to disk. A process crash does not lose them, but on a
+// machine crash the data not yet flushed by the operating system is lost and the load should be restarted from the
+// beginning. The reads are also slower while the mode is on, as the level 0 table files accumulate.
+// Disabling the mode compacts the whole database, rewriting the data with synced writes in a read optimized shape,
+// which can take a while for large databases. Switching the mode reopens the database, so it is not meant to be
+// done often
+func (s *DB) SetBulkLoadMode(on bool) error {
+	s.mutBatch.Lock()
+	if s.bulkLoadMode == on {
+		s.mutBatch.Unlock()
+		return nil
+	}
+
+	err := s.putBatch(s.batch)
+	if err != nil {
+		s.mutBatch.Unlock()
+		return err
+	}
+	s.batch.Reset()
+	s.sizeBatch = 0
+
+	options := s.levelDBOptions
+	if on {
+		options = createBulkLoadOptions(s.levelDBOptions)
+	}
+	err = s.reopen(options)
+	if err != nil {
+		s.mutBatch.Unlock()
+		return err
+	}
+	s.bulkLoadMode = on
+	s.mutBatch.Unlock()
+
+	log.Debug("leveldb bulk load mode switched", "path", s.path, "on", on)
+	if on {
+		return nil
+	}
+
+	return s.compactAll()
+}
+
+// reopen closes the database and opens it again with the provided options. The readers wait for the new database
+// instead of observing it as closed
+func (s *DB) reopen(options *opt.Options) error {
+	s.mutDb.Lock()
+	defer s.mutDb.Unlock()
+
+	if s.db == nil {
+		return common.ErrDBIsClosed
+	}
+
+	err := s.db.Close()
+	if err != nil {
+		return err
+	}
+
+	db, err := openLevelDB(s.path, options)
+	if err != nil {
+		crtCounter := atomic.AddUint32(&loggingDBCounter, ^uint32(0)) // subtract 1
+		log.Warn("leveldb reopen failed", "path", s.path, "error", err, "global db counter", crtCounter)
+		s.db = nil
+		return err
+	}
+
+	s.db = db
+
+	return nil
+}
+
+// PendingBatchLen returns the number of operations recorded in the batch that was not yet written in the database
+func (s *DB) PendingBatchLen() int {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	return s.sizeBatch
+}
+
 // Has returns nil if the given key is present in the persistence medium
 func (s *DB) Has(key []byte) error {
 	db := s.getDbPointer()
 	if db == nil {
-		return common.ErrDBIsClosed
+		return common.NewStorageError(common.OpHas, key, backendName, common.ErrDBIsClosed)
 	}
 
 	if s.batch.IsRemoved(key) {
-		return common.ErrKeyNotFound
+		return common.NewStorageError(common.OpHas, key, backendName, common.ErrKeyNotFound)
 	}
 
 	data := s.batch.Get(key)
@@ -208,14 +433,14 @@ func (s *DB) Has(key []byte) error {
 
 	has, err := db.Has(key, nil)
 	if err != nil {
-		return err
+		return common.NewStorageError(common.OpHas, key, backendName, err)
 	}
 
 	if has {
 		return nil
 	}
 
-	return common.ErrKeyNotFound
+	return common.NewStorageError(common.OpHas, key, backendName, common.ErrKeyNotFound)
 }
 
 // CreateBatch returns a batcher to be used for batch writing data to the database
@@ -230,16 +455,12 @@ func (s *DB) putBatch(b types.Batcher) error {
 		return common.ErrInvalidBatch
 	}
 
-	wopt := &opt.WriteOptions{
-		Sync: true,
-	}
-
 	db := s.getDbPointer()
 	if db == nil {
 		return common.ErrDBIsClosed
 	}
 
-	return db.Write(dbBatch.batch, wopt)
+	return db.Write(dbBatch.batch, s.writeOptions())
 }
 
 // Close closes the files/resources associated to the storage medium
@@ -250,9 +471,10 @@ func (s *DB) Close() error {
 	s.mutBatch.Unlock()
 
 	s.cancel()
+	s.clearReportedSize()
 	db := s.makeDbPointerNilReturningLast()
 	if db != nil {
-		return db.Close()
+		return s.closeDb(db)
 	}
 
 	return nil
@@ -264,7 +486,9 @@ func (s *DB) Remove(key []byte) error {
 	_ = s.batch.Delete(key)
 	s.mutBatch.Unlock()
 
-	return s.updateBatchWithIncrement()
+	err := s.updateBatchWithIncrement()
+
+	return common.NewStorageError(common.OpRemove, key, backendName, err)
 }
 
 // Destroy removes the storage medium stored data
@@ -275,6 +499,7 @@ func (s *DB) Destroy() error {
 	s.mutBatch.Unlock()
 
 	s.cancel()
+	s.clearReportedSize()
 	db := s.makeDbPointerNilReturningLast()
 	if db != nil {
 		err := db.Close()
@@ -286,8 +511,22 @@ func (s *DB) Destroy() error {
 	return os.RemoveAll(s.path)
 }
 
+// Truncate discards the pending batch and deletes all the persisted keys without closing
+// the database or removing its directory
+func (s *DB) Truncate() error {
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	s.batch.Reset()
+	s.sizeBatch = 0
+
+	return s.removeAllKeys()
+}
+
 // DestroyClosed removes the already closed storage medium stored data
 func (s *DB) DestroyClosed() error {
+	monitoring.MonitorPersisterSize(s.path, 0)
+
 	return os.RemoveAll(s.path)
 }
 
@@ -12,29 +12,49 @@ import (
 	"github.com/DharitriOne/drt-chain-core-go/core"
 	"github.com/DharitriOne/drt-chain-core-go/core/closing"
 	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
 	"github.com/DharitriOne/drt-chain-storage-go/types"
 	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 var _ types.Persister = (*SerialDB)(nil)
+var _ types.BatchApplier = (*SerialDB)(nil)
+var _ types.PrefixCounter = (*SerialDB)(nil)
+var _ types.PrefixDiskSizer = (*SerialDB)(nil)
+var _ types.PrefixRanger = (*SerialDB)(nil)
+var _ types.Truncater = (*SerialDB)(nil)
+var _ types.Snapshotter = (*SerialDB)(nil)
+var _ types.Checkpointer = (*SerialDB)(nil)
+var _ types.MultiGetter = (*SerialDB)(nil)
+var _ types.ContextPersister = (*SerialDB)(nil)
+
+const serialBackendName = "leveldbSerial"
 
 // SerialDB holds a pointer to the leveldb database and the path to where it is stored.
 type SerialDB struct {
 	*baseLevelDb
-	maxBatchSize      int
-	batchDelaySeconds int
-	sizeBatch         int
-	batch             types.Batcher
-	mutBatch          sync.RWMutex
-	dbAccess          chan serialQueryer
-	cancel            context.CancelFunc
-	closer            core.SafeCloser
+	maxBatchSize        int
+	maxBatchSizeInBytes int
+	batchDelaySeconds   int
+	adaptiveDelay       *adaptiveBatchDelay
+	sizeBatch           int
+	batch               types.Batcher
+	flushingBatches     []*batch
+	mutBatch            sync.RWMutex
+	dbAccess            chan serialQueryer
+	cancel              context.CancelFunc
+	closer              core.SafeCloser
 }
 
 // NewSerialDB is a constructor for the leveldb persister
 // It creates the files in the location given as parameter
-func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (s *SerialDB, err error) {
+func NewSerialDB(
+	path string,
+	batchDelaySeconds int,
+	maxBatchSize int,
+	maxOpenFiles int,
+	options ...Option,
+) (s *SerialDB, err error) {
 	constructorName := "NewSerialDB"
 
 	sw := core.NewStopWatch()
@@ -51,38 +71,46 @@ func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFi
 		return nil, common.ErrInvalidNumOpenFiles
 	}
 
-	options := &opt.Options{
-		// disable internal cache
-		BlockCacheCapacity:     -1,
-		OpenFilesCacheCapacity: maxOpenFiles,
+	dbOptions := createOptions(maxOpenFiles, options...)
+	adaptiveDelay, err := createAdaptiveBatchDelay(dbOptions, maxBatchSize)
+	if err != nil {
+		return nil, err
 	}
 
 	sw.Start(openLevelDBFunction)
-	db, err := openLevelDB(path, options)
+	db, err := openLevelDBWithTimeout(path, dbOptions.levelDBOptions, dbOptions.openTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("%w for path %s", err, path)
 	}
 	sw.Stop(openLevelDBFunction)
 
 	bldb := &baseLevelDb{
-		db:   db,
-		path: path,
+		db:                db,
+		path:              path,
+		onCorruption:      dbOptions.onCorruption,
+		journalSyncer:     newJournalSyncer(path, dbOptions.syncInterval),
+		chSizeReportsDone: make(chan struct{}),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	dbStore := &SerialDB{
-		baseLevelDb:       bldb,
-		maxBatchSize:      maxBatchSize,
-		batchDelaySeconds: batchDelaySeconds,
-		sizeBatch:         0,
-		dbAccess:          make(chan serialQueryer),
-		cancel:            cancel,
-		closer:            closing.NewSafeChanCloser(),
+		baseLevelDb:         bldb,
+		maxBatchSize:        maxBatchSize,
+		maxBatchSizeInBytes: dbOptions.maxBatchSizeInBytes,
+		batchDelaySeconds:   batchDelaySeconds,
+		adaptiveDelay:       adaptiveDelay,
+		sizeBatch:           0,
+		dbAccess:            make(chan serialQueryer),
+		cancel:              cancel,
+		closer:              closing.NewSafeChanCloser(),
 	}
 
 	dbStore.batch = NewBatch()
 
 	go dbStore.batchTimeoutHandle(ctx)
+	go dbStore.reportSizeHandle(ctx)
+	go newCompactionScheduler(path, dbOptions.compactionWindows, bldb.compactAll).run(ctx)
+	go bldb.journalSyncer.run(ctx)
 	go dbStore.processLoop(ctx)
 
 	runtime.SetFinalizer(dbStore, func(db *SerialDB) {
@@ -100,7 +128,8 @@ func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFi
 }
 
 func (s *SerialDB) batchTimeoutHandle(ctx context.Context) {
-	interval := time.Duration(s.batchDelaySeconds) * time.Second
+	fixedInterval := time.Duration(s.batchDelaySeconds) * time.Second
+	interval := s.adaptiveDelay.initialDelay(fixedInterval)
 	timer := time.NewTimer(interval)
 	defer timer.Stop()
 
@@ -109,6 +138,7 @@ func (s *SerialDB) batchTimeoutHandle(ctx context.Context) {
 
 		select {
 		case <-timer.C:
+			interval = s.adaptiveDelay.nextDelay(interval, fixedInterval)
 			err := s.putBatch()
 			if err != nil {
 				log.Warn("leveldb serial putBatch", "error", err.Error())
@@ -124,7 +154,8 @@ func (s *SerialDB) batchTimeoutHandle(ctx context.Context) {
 func (s *SerialDB) updateBatchWithIncrement() error {
 	s.mutBatch.Lock()
 	s.sizeBatch++
-	if s.sizeBatch < s.maxBatchSize {
+	s.adaptiveDelay.recordWrite()
+	if s.sizeBatch < s.maxBatchSize && !isBatchSizeInBytesReached(s.batch, s.maxBatchSizeInBytes) {
 		s.mutBatch.Unlock()
 		return nil
 	}
@@ -138,34 +169,75 @@ func (s *SerialDB) updateBatchWithIncrement() error {
 // Put adds the value to the (key, val) storage medium
 func (s *SerialDB) Put(key, val []byte) error {
 	if s.isClosed() {
-		return common.ErrDBIsClosed
+		return common.NewStorageError(common.OpPut, key, serialBackendName, common.ErrDBIsClosed)
 	}
 
 	s.mutBatch.RLock()
 	err := s.batch.Put(key, val)
 	s.mutBatch.RUnlock()
+	if err != nil {
+		return common.NewStorageError(common.OpPut, key, serialBackendName, err)
+	}
+
+	err = s.updateBatchWithIncrement()
+
+	return common.NewStorageError(common.OpPut, key, serialBackendName, err)
+}
+
+// ApplyBatch atomically writes the provided Put and Remove operations, together with the
+// pending batch, in the database. If the write fails, the pending batch is left untouched
+func (s *SerialDB) ApplyBatch(ops []types.Operation) error {
+	if s.isClosed() {
+		return common.ErrDBIsClosed
+	}
+
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	pendingBatch, ok := s.batch.(*batch)
+	if !ok {
+		return common.ErrInvalidBatch
+	}
+
+	newBatch := pendingBatch.clone()
+	err := newBatch.applyOperations(ops)
 	if err != nil {
 		return err
 	}
 
-	return s.updateBatchWithIncrement()
+	ch := make(chan error)
+	req := &putBatchAct{
+		batch:   newBatch,
+		resChan: ch,
+	}
+
+	err = s.tryWriteInDbAccessChan(req)
+	if err != nil {
+		return err
+	}
+	result := <-ch
+	close(ch)
+
+	if result != nil {
+		return result
+	}
+
+	s.batch = NewBatch()
+	s.sizeBatch = 0
+
+	return nil
 }
 
 // Get returns the value associated to the key
 func (s *SerialDB) Get(key []byte) ([]byte, error) {
 	if s.isClosed() {
-		return nil, common.ErrDBIsClosed
+		return nil, common.NewStorageError(common.OpGet, key, serialBackendName, common.ErrDBIsClosed)
 	}
 
-	s.mutBatch.RLock()
-	if s.batch.IsRemoved(key) {
-		s.mutBatch.RUnlock()
-		return nil, common.ErrKeyNotFound
+	data, isRemoved := s.getFromPendingBatches(key)
+	if isRemoved {
+		return nil, common.NewStorageError(common.OpGet, key, serialBackendName, common.ErrKeyNotFound)
 	}
-
-	data := s.batch.Get(key)
-	s.mutBatch.RUnlock()
-
 	if data != nil {
 		return data, nil
 	}
@@ -178,36 +250,80 @@ func (s *SerialDB) Get(key []byte) ([]byte, error) {
 
 	err := s.tryWriteInDbAccessChan(req)
 	if err != nil {
-		return nil, err
+		return nil, common.NewStorageError(common.OpGet, key, serialBackendName, err)
 	}
 	result := <-ch
 	close(ch)
 
 	if result.err == leveldb.ErrNotFound {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewStorageError(common.OpGet, key, serialBackendName, common.ErrKeyNotFound)
 	}
 	if result.err != nil {
-		return nil, result.err
+		return nil, common.NewStorageError(common.OpGet, key, serialBackendName, result.err)
 	}
 
 	return result.value, nil
 }
 
-// Has returns nil if the given key is present in the persistence medium
-func (s *SerialDB) Has(key []byte) error {
+// GetExisting returns the values of the provided keys which are present. The keys not found in the pending batches
+// are read from the database in a single request to the database access go routine. The missing keys are omitted
+func (s *SerialDB) GetExisting(keys [][]byte) (map[string][]byte, error) {
 	if s.isClosed() {
-		return common.ErrDBIsClosed
+		return nil, common.NewStorageError(common.OpGet, nil, serialBackendName, common.ErrDBIsClosed)
 	}
 
-	s.mutBatch.RLock()
-	if s.batch.IsRemoved(key) {
-		s.mutBatch.RUnlock()
-		return common.ErrKeyNotFound
+	values := make(map[string][]byte, len(keys))
+	keysToRead := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		data, isRemoved := s.getFromPendingBatches(key)
+		if isRemoved {
+			continue
+		}
+		if data != nil {
+			values[string(key)] = data
+			continue
+		}
+
+		keysToRead = append(keysToRead, key)
+	}
+	if len(keysToRead) == 0 {
+		return values, nil
 	}
 
-	data := s.batch.Get(key)
-	s.mutBatch.RUnlock()
+	ch := make(chan *multiGetResult)
+	req := &multiGetAct{
+		keys:    keysToRead,
+		resChan: ch,
+	}
 
+	err := s.tryWriteInDbAccessChan(req)
+	if err != nil {
+		return nil, common.NewStorageError(common.OpGet, nil, serialBackendName, err)
+	}
+	result := <-ch
+	close(ch)
+
+	if result.err != nil {
+		return nil, common.NewStorageError(common.OpGet, nil, serialBackendName, result.err)
+	}
+
+	for key, data := range result.values {
+		values[key] = data
+	}
+
+	return values, nil
+}
+
+// Has returns nil if the given key is present in the persistence medium
+func (s *SerialDB) Has(key []byte) error {
+	if s.isClosed() {
+		return common.NewStorageError(common.OpHas, key, serialBackendName, common.ErrDBIsClosed)
+	}
+
+	data, isRemoved := s.getFromPendingBatches(key)
+	if isRemoved {
+		return common.NewStorageError(common.OpHas, key, serialBackendName, common.ErrKeyNotFound)
+	}
 	if data != nil {
 		return nil
 	}
@@ -220,12 +336,77 @@ func (s *SerialDB) Has(key []byte) error {
 
 	err := s.tryWriteInDbAccessChan(req)
 	if err != nil {
-		return err
+		return common.NewStorageError(common.OpHas, key, serialBackendName, err)
 	}
 	result := <-ch
 	close(ch)
 
-	return result
+	return common.NewStorageError(common.OpHas, key, serialBackendName, result)
+}
+
+// getFromPendingBatches searches the key in the current batch and then in the batches that are
+// being written in the database, from the newest to the oldest one
+func (s *SerialDB) getFromPendingBatches(key []byte) ([]byte, bool) {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	if s.batch.IsRemoved(key) {
+		return nil, true
+	}
+	data := s.batch.Get(key)
+	if data != nil {
+		return data, false
+	}
+
+	for i := len(s.flushingBatches) - 1; i >= 0; i-- {
+		if s.flushingBatches[i].IsRemoved(key) {
+			return nil, true
+		}
+		data = s.flushingBatches[i].Get(key)
+		if data != nil {
+			return data, false
+		}
+	}
+
+	return nil, false
+}
+
+// Snapshot writes the pending batch and returns a consistent read-only view of the database
+func (s *SerialDB) Snapshot() (types.Snapshot, error) {
+	if s.isClosed() {
+		return nil, common.ErrDBIsClosed
+	}
+
+	err := s.putBatch()
+	if err != nil {
+		return nil, err
+	}
+
+	return newSnapshot(s.getDbPointer())
+}
+
+// Checkpoint writes the pending batch and creates in destDir, which must be empty or missing, a consistent copy
+// of the database that can be opened with NewSerialDB or, read only, with NewReadReplica. The table files are
+// hard linked when possible, so the checkpoint is cheap and does not block the ongoing writes
+func (s *SerialDB) Checkpoint(destDir string) error {
+	if s.isClosed() {
+		return common.ErrDBIsClosed
+	}
+
+	err := s.putBatch()
+	if err != nil {
+		return err
+	}
+
+	return createCheckpoint(s.path, destDir)
+}
+
+// PendingBatchLen returns the number of operations recorded in the batch that was not yet written in the database
+func (s *SerialDB) PendingBatchLen() int {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	return s.sizeBatch
 }
 
 func (s *SerialDB) tryWriteInDbAccessChan(req serialQueryer) error {
@@ -247,8 +428,12 @@ func (s *SerialDB) putBatch() error {
 	}
 	s.sizeBatch = 0
 	s.batch = NewBatch()
+	// the batch remains visible to readers until it is written in the database
+	s.flushingBatches = append(s.flushingBatches, dbBatch)
 	s.mutBatch.Unlock()
 
+	defer s.removeFlushingBatch(dbBatch)
+
 	ch := make(chan error)
 	req := &putBatchAct{
 		batch:   dbBatch,
@@ -265,6 +450,18 @@ func (s *SerialDB) putBatch() error {
 	return result
 }
 
+func (s *SerialDB) removeFlushingBatch(b *batch) {
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	for i := range s.flushingBatches {
+		if s.flushingBatches[i] == b {
+			s.flushingBatches = append(s.flushingBatches[:i], s.flushingBatches[i+1:]...)
+			return
+		}
+	}
+}
+
 func (s *SerialDB) isClosed() bool {
 	db := s.getDbPointer()
 
@@ -283,14 +480,16 @@ func (s *SerialDB) Close() error {
 // Remove removes the data associated to the given key
 func (s *SerialDB) Remove(key []byte) error {
 	if s.isClosed() {
-		return common.ErrDBIsClosed
+		return common.NewStorageError(common.OpRemove, key, serialBackendName, common.ErrDBIsClosed)
 	}
 
 	s.mutBatch.Lock()
 	_ = s.batch.Delete(key)
 	s.mutBatch.Unlock()
 
-	return s.updateBatchWithIncrement()
+	err := s.updateBatchWithIncrement()
+
+	return common.NewStorageError(common.OpRemove, key, serialBackendName, err)
 }
 
 // Destroy removes the storage medium stored data
@@ -309,8 +508,37 @@ func (s *SerialDB) Destroy() error {
 	return err
 }
 
+// Truncate discards the pending batch and deletes all the persisted keys without closing
+// the database or removing its directory. The deletion is serialized after the already queued batch writes
+func (s *SerialDB) Truncate() error {
+	if s.isClosed() {
+		return common.ErrDBIsClosed
+	}
+
+	s.mutBatch.Lock()
+	s.batch.Reset()
+	s.sizeBatch = 0
+	s.mutBatch.Unlock()
+
+	ch := make(chan error)
+	req := &truncateAct{
+		resChan: ch,
+	}
+
+	err := s.tryWriteInDbAccessChan(req)
+	if err != nil {
+		return err
+	}
+	result := <-ch
+	close(ch)
+
+	return result
+}
+
 // DestroyClosed removes the already closed storage medium stored data
 func (s *SerialDB) DestroyClosed() error {
+	monitoring.MonitorPersisterSize(s.path, 0)
+
 	err := os.RemoveAll(s.path)
 	if err != nil {
 		log.Error("error destroy closed", "error", err, "path", s.path)
@@ -324,10 +552,11 @@ func (s *SerialDB) DestroyClosed() error {
 func (s *SerialDB) doClose() error {
 	_ = s.putBatch()
 	s.cancel()
+	s.clearReportedSize()
 
 	db := s.makeDbPointerNilReturningLast()
 	if db != nil {
-		return db.Close()
+		return s.closeDb(db)
 	}
 
 	return nil
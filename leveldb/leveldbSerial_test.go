@@ -1,6 +1,8 @@
 package leveldb_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
@@ -9,6 +11,8 @@ import (
 
 	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -81,16 +85,16 @@ func testSerialDbAllMethodsShouldNotPanic(t *testing.T, closeHandler func(db *le
 	closeHandler(ldb)
 
 	_, err := ldb.Get([]byte("key1"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
 
 	err = ldb.Has([]byte("key2"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
 
 	err = ldb.Remove([]byte("key3"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
 
 	err = ldb.Put([]byte("key4"), []byte("val"))
-	assert.Equal(t, common.ErrDBIsClosed, err)
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
 
 	ldb.RangeKeys(func(key []byte, value []byte) bool {
 		require.Fail(t, "should have not called range")
@@ -120,7 +124,7 @@ func TestSerialDB_RemoveBeforeTimeoutOK(t *testing.T) {
 	v, err := ldb.Get(key)
 
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestSerialDB_RemoveAfterTimeoutOK(t *testing.T) {
@@ -133,7 +137,7 @@ func TestSerialDB_RemoveAfterTimeoutOK(t *testing.T) {
 	v, err := ldb.Get(key)
 
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestSerialDB_GetPresent(t *testing.T) {
@@ -173,7 +177,7 @@ func TestSerialDB_HasNotPresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestSerialDB_RemovePresent(t *testing.T) {
@@ -185,7 +189,7 @@ func TestSerialDB_RemovePresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestSerialDB_RemoveNotPresent(t *testing.T) {
@@ -245,7 +249,7 @@ func TestSerialDB_SpecialValueTest(t *testing.T) {
 		require.Nil(t, err)
 
 		recovered, err := ldb.Get(key)
-		assert.Equal(t, common.ErrKeyNotFound, err)
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
 		assert.Nil(t, recovered)
 	})
 	t.Run("operations: put -> remove -> put -> get of 'removed' value", func(t *testing.T) {
@@ -358,3 +362,288 @@ func TestSerialDB_ConcurrentOperations(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestSerialDB_ApplyBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid operation type should error", func(t *testing.T) {
+		t.Parallel()
+
+		ldb := createSerialLevelDb(t, 10, 100, 10)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		ops := []types.Operation{
+			{Type: types.PutOperation, Key: []byte("key1"), Value: []byte("value1")},
+			{Type: 100, Key: []byte("key2")},
+		}
+		err := ldb.ApplyBatch(ops)
+		assert.Equal(t, common.ErrInvalidOperationType, err)
+
+		_, err = ldb.Get([]byte("key1"))
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	})
+	t.Run("should write the operations and the pending batch", func(t *testing.T) {
+		t.Parallel()
+
+		ldb := createSerialLevelDb(t, 10, 100, 10)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		_ = ldb.Put([]byte("pending"), []byte("pending value"))
+		_ = ldb.Put([]byte("removed"), []byte("removed value"))
+
+		ops := []types.Operation{
+			{Type: types.PutOperation, Key: []byte("key1"), Value: []byte("value1")},
+			{Type: types.RemoveOperation, Key: []byte("removed")},
+			{Type: types.PutOperation, Key: []byte("key2"), Value: []byte("value2")},
+		}
+		err := ldb.ApplyBatch(ops)
+		assert.Nil(t, err)
+
+		// RangeKeys only sees the data written in the database
+		recovered := make(map[string][]byte)
+		ldb.RangeKeys(func(key []byte, value []byte) bool {
+			recovered[string(key)] = value
+			return true
+		})
+
+		expected := map[string][]byte{
+			"pending": []byte("pending value"),
+			"key1":    []byte("value1"),
+			"key2":    []byte("value2"),
+		}
+		assert.Equal(t, expected, recovered)
+	})
+}
+
+func TestSerialDB_Truncate(t *testing.T) {
+	t.Parallel()
+
+	ldb := createSerialLevelDb(t, 100, 3, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	// first 3 puts are written in the database, the last one stays in the pending batch
+	for i := 0; i < 4; i++ {
+		_ = ldb.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+	}
+	assert.Equal(t, 1, ldb.PendingBatchLen())
+
+	err := ldb.Truncate()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+	for i := 0; i < 4; i++ {
+		assert.ErrorIs(t, ldb.Has([]byte(fmt.Sprintf("key%d", i))), common.ErrKeyNotFound)
+	}
+
+	numKeys := 0
+	ldb.RangeKeys(func(key []byte, value []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 0, numKeys)
+
+	// the database should be usable after truncation
+	_ = ldb.Put([]byte("key"), []byte("value"))
+	recovered, err := ldb.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), recovered)
+
+	_ = ldb.Close()
+	assert.ErrorIs(t, ldb.Truncate(), common.ErrDBIsClosed)
+}
+
+func TestSerialDB_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	ldb := createSerialLevelDb(t, 100, 10, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("key1"), []byte("value1"))
+	_ = ldb.Put([]byte("key2"), []byte("value2"))
+
+	snapshot, err := ldb.Snapshot()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+
+	_ = ldb.Put([]byte("key1"), []byte("changed"))
+	_ = ldb.Remove([]byte("key2"))
+	_ = ldb.Put([]byte("key3"), []byte("value3"))
+
+	val, err := snapshot.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	assert.Nil(t, snapshot.Has([]byte("key2")))
+	_, err = snapshot.Get([]byte("key3"))
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, snapshot.Has([]byte("key3")), common.ErrKeyNotFound)
+
+	recovered := make(map[string]string)
+	snapshot.RangeKeys(func(key []byte, value []byte) bool {
+		recovered[string(key)] = string(value)
+		return true
+	})
+	assert.Equal(t, map[string]string{"key1": "value1", "key2": "value2"}, recovered)
+	snapshot.Release()
+
+	val, err = ldb.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("changed"), val)
+}
+
+func TestSerialDB_WithMaxBatchSizeInBytesShouldFlush(t *testing.T) {
+	t.Parallel()
+
+	ldb, err := leveldb.NewSerialDB(t.TempDir(), 100, 100, 10, leveldb.WithMaxBatchSizeInBytes(20))
+	assert.Nil(t, err)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	// key + value = 10 bytes
+	_ = ldb.Put([]byte("key1"), []byte("value1"))
+	assert.Equal(t, 1, ldb.PendingBatchLen())
+	_ = ldb.Remove([]byte("key0"))
+	assert.Equal(t, 2, ldb.PendingBatchLen())
+
+	_ = ldb.Put([]byte("key2"), []byte("value2"))
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+
+	// a single value larger than the limit is written right away
+	_ = ldb.Put([]byte("key3"), make([]byte, 100))
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+
+	val, err := ldb.Get([]byte("key2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value2"), val)
+}
+
+func TestSerialDB_ReadAfterWriteWithinBatchWindow(t *testing.T) {
+	t.Parallel()
+
+	ldb := createSerialLevelDb(t, 100, 3, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	key, val := []byte("key"), []byte("value")
+	_ = ldb.Put(key, val)
+	assert.Equal(t, 1, ldb.PendingBatchLen())
+
+	recovered, err := ldb.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, val, recovered)
+	assert.Nil(t, ldb.Has(key))
+
+	_ = ldb.Remove(key)
+	assert.Equal(t, 2, ldb.PendingBatchLen())
+	_, err = ldb.Get(key)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, ldb.Has(key), common.ErrKeyNotFound)
+
+	_ = ldb.Put([]byte("key2"), val)
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+}
+
+func TestSerialDB_ConcurrentReadAfterWriteShouldBeConsistent(t *testing.T) {
+	t.Parallel()
+
+	ldb := createSerialLevelDb(t, 1, 2, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	numGoroutines := 100
+	wg := sync.WaitGroup{}
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key%d", idx))
+			_ = ldb.Put(key, key)
+			recovered, err := ldb.Get(key)
+			assert.Nil(t, err)
+			assert.Equal(t, key, recovered)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSerialDB_ContextOperations(t *testing.T) {
+	t.Parallel()
+
+	ldb := createSerialLevelDb(t, 10, 1, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+	key, val := []byte("key"), []byte("value")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	require.Nil(t, ldb.PutCtx(ctx, key, val))
+	recovered, err := ldb.GetCtx(ctx, key)
+	require.Nil(t, err)
+	require.Equal(t, val, recovered)
+	require.Nil(t, ldb.HasCtx(ctx, key))
+
+	cancel()
+	require.Equal(t, context.Canceled, ldb.RemoveCtx(ctx, key))
+	require.Nil(t, ldb.Has(key))
+}
+
+func TestSerialDB_GetExisting(t *testing.T) {
+	t.Parallel()
+
+	ldb := createSerialLevelDb(t, 10, 100, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("persisted"), []byte("persisted value"))
+	_ = ldb.Put([]byte("removed"), []byte("removed value"))
+	err := ldb.ApplyBatch(nil)
+	require.Nil(t, err)
+
+	// pending in the batch
+	_ = ldb.Put([]byte("pending"), []byte("pending value"))
+	_ = ldb.Remove([]byte("removed"))
+
+	values, err := ldb.GetExisting([][]byte{
+		[]byte("persisted"),
+		[]byte("pending"),
+		[]byte("removed"),
+		[]byte("missing"),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, map[string][]byte{
+		"persisted": []byte("persisted value"),
+		"pending":   []byte("pending value"),
+	}, values)
+
+	_ = ldb.Close()
+	_, err = ldb.GetExisting([][]byte{[]byte("persisted")})
+	assert.True(t, errors.Is(err, common.ErrDBIsClosed))
+}
+
+func TestSerialDB_CloseShouldClearThePersistedSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ldb, err := leveldb.NewSerialDB(dir, 10, 1, 10)
+	require.Nil(t, err)
+	_ = ldb.Put([]byte("key"), []byte("value"))
+
+	assert.Eventually(t, func() bool {
+		return monitoring.PersisterSize(dir) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.Nil(t, ldb.Close())
+	assert.Equal(t, uint64(0), monitoring.PersisterSize(dir))
+}
@@ -1,16 +1,21 @@
 package leveldb_test
 
 import (
+	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +67,42 @@ func TestDB_DoubleOpenShouldError(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestNewDBWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("locked database should time out", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		lvdb1, err := leveldb.NewDB(dir, 10, 1, 10)
+		require.Nil(t, err)
+		defer func() {
+			_ = lvdb1.Close()
+		}()
+
+		start := time.Now()
+		lvdb2, err := leveldb.NewDBWithTimeout(dir, time.Millisecond*200, 10, 1, 10)
+		assert.Nil(t, lvdb2)
+		assert.ErrorIs(t, err, common.ErrOpenTimeout)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+	t.Run("database released in time should open", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		lvdb1, err := leveldb.NewDB(dir, 10, 1, 10)
+		require.Nil(t, err)
+		go func() {
+			time.Sleep(time.Millisecond * 500)
+			_ = lvdb1.Close()
+		}()
+
+		lvdb2, err := leveldb.NewDBWithTimeout(dir, time.Second*5, 10, 1, 10)
+		require.Nil(t, err)
+		assert.Nil(t, lvdb2.Close())
+	})
+}
+
 func TestDB_DoubleOpenButClosedInTimeShouldWork(t *testing.T) {
 	dir := t.TempDir()
 	lvdb1, err := leveldb.NewDB(dir, 10, 1, 10)
@@ -141,7 +182,7 @@ func TestDB_RemoveBeforeTimeoutOK(t *testing.T) {
 
 	v, err := ldb.Get(key)
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestDB_RemoveAfterTimeoutOK(t *testing.T) {
@@ -156,7 +197,7 @@ func TestDB_RemoveAfterTimeoutOK(t *testing.T) {
 
 	v, err := ldb.Get(key)
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestDB_GetPresent(t *testing.T) {
@@ -202,7 +243,7 @@ func TestDB_HasNotPresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestDB_RemovePresent(t *testing.T) {
@@ -220,7 +261,7 @@ func TestDB_RemovePresent(t *testing.T) {
 	err = ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestDB_RemoveNotPresent(t *testing.T) {
@@ -248,6 +289,48 @@ func TestDB_Destroy(t *testing.T) {
 	assert.Nil(t, err, "no error expected but got %s", err)
 }
 
+func TestDB_ContextOperations(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 10, 1, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+	key, val := []byte("key"), []byte("value")
+
+	t.Run("live context should work", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		require.Nil(t, ldb.PutCtx(ctx, key, val))
+		recovered, err := ldb.GetCtx(ctx, key)
+		require.Nil(t, err)
+		require.Equal(t, val, recovered)
+		require.Nil(t, ldb.HasCtx(ctx, key))
+		require.Nil(t, ldb.RemoveCtx(ctx, key))
+		require.NotNil(t, ldb.HasCtx(ctx, key))
+	})
+	t.Run("cancelled context should not reach the db", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.Equal(t, context.Canceled, ldb.PutCtx(ctx, key, val))
+		recovered, err := ldb.GetCtx(ctx, key)
+		require.Nil(t, recovered)
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, context.Canceled, ldb.HasCtx(ctx, key))
+		require.Equal(t, context.Canceled, ldb.RemoveCtx(ctx, key))
+		require.NotNil(t, ldb.Has(key))
+	})
+	t.Run("expired deadline should error", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+		defer cancel()
+
+		_, err := ldb.GetCtx(ctx, key)
+		require.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
 func TestDB_RangeKeys(t *testing.T) {
 	ldb := createLevelDb(t, 1, 1, 10)
 	defer func() {
@@ -282,6 +365,600 @@ func TestDB_RangeKeys(t *testing.T) {
 	assert.Equal(t, keysVals, recovered)
 }
 
+func TestDB_FilterKeys(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 1, 1, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	keysVals := map[string][]byte{
+		"key1": {0, 1},
+		"key2": {1, 2},
+		"key3": {0, 3},
+		"key4": {1, 4},
+	}
+	for key, val := range keysVals {
+		_ = ldb.Put([]byte(key), val)
+	}
+
+	predicate := func(value []byte) bool {
+		return len(value) > 0 && value[0] == 0
+	}
+
+	recovered := make(map[string]struct{})
+	ldb.FilterKeys(predicate, func(key []byte) bool {
+		recovered[string(key)] = struct{}{}
+		return true
+	})
+	assert.Equal(t, map[string]struct{}{"key1": {}, "key3": {}}, recovered)
+
+	numCalls := 0
+	ldb.FilterKeys(predicate, func(key []byte) bool {
+		numCalls++
+		return false
+	})
+	assert.Equal(t, 1, numCalls)
+}
+
+func TestDB_CountPrefix(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 1, 1, 10)
+
+	for _, key := range []string{"acc1_a", "acc1_b", "acc2_a", "acc1"} {
+		_ = ldb.Put([]byte(key), []byte("value"))
+	}
+
+	count, err := ldb.CountPrefix([]byte("acc1_"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	count, err = ldb.CountPrefix([]byte("acc3"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	count, err = ldb.CountPrefix(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), count)
+
+	_ = ldb.Close()
+	_, err = ldb.CountPrefix([]byte("acc1_"))
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
+}
+
+func TestDB_RangePrefix(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 1, 1, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	for _, key := range []string{"acc1_a", "acc1_b", "acc2_a", "acc1"} {
+		_ = ldb.Put([]byte(key), []byte("value"))
+	}
+
+	keys := make([]string, 0)
+	ldb.RangePrefix([]byte("acc1_"), func(key []byte, value []byte) bool {
+		keys = append(keys, string(key))
+		assert.Equal(t, []byte("value"), value)
+		return true
+	})
+	assert.Equal(t, []string{"acc1_a", "acc1_b"}, keys)
+
+	numCalls := 0
+	ldb.RangePrefix(nil, func(_ []byte, _ []byte) bool {
+		numCalls++
+		return false
+	})
+	assert.Equal(t, 1, numCalls)
+}
+
+func TestDB_Truncate(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 100, 3, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	// first 3 puts are written in the database, the last one stays in the pending batch
+	for i := 0; i < 4; i++ {
+		_ = ldb.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+	}
+	assert.Equal(t, 1, ldb.PendingBatchLen())
+
+	err := ldb.Truncate()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+	for i := 0; i < 4; i++ {
+		assert.ErrorIs(t, ldb.Has([]byte(fmt.Sprintf("key%d", i))), common.ErrKeyNotFound)
+	}
+
+	numKeys := 0
+	ldb.RangeKeys(func(key []byte, value []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 0, numKeys)
+
+	// the database should be usable after truncation
+	_ = ldb.Put([]byte("key"), []byte("value"))
+	recovered, err := ldb.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), recovered)
+
+	_ = ldb.Close()
+	assert.ErrorIs(t, ldb.Truncate(), common.ErrDBIsClosed)
+}
+
+func TestDB_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 100, 10, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("key1"), []byte("value1"))
+	_ = ldb.Put([]byte("key2"), []byte("value2"))
+
+	snapshot, err := ldb.Snapshot()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+
+	_ = ldb.Put([]byte("key1"), []byte("changed"))
+	_ = ldb.Remove([]byte("key2"))
+	_ = ldb.Put([]byte("key3"), []byte("value3"))
+
+	val, err := snapshot.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	assert.Nil(t, snapshot.Has([]byte("key2")))
+	_, err = snapshot.Get([]byte("key3"))
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, snapshot.Has([]byte("key3")), common.ErrKeyNotFound)
+
+	recovered := make(map[string]string)
+	snapshot.RangeKeys(func(key []byte, value []byte) bool {
+		recovered[string(key)] = string(value)
+		return true
+	})
+	assert.Equal(t, map[string]string{"key1": "value1", "key2": "value2"}, recovered)
+	snapshot.Release()
+
+	val, err = ldb.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("changed"), val)
+}
+
+func TestDB_WithMaxBatchSizeInBytesShouldFlush(t *testing.T) {
+	t.Parallel()
+
+	ldb, err := leveldb.NewDB(t.TempDir(), 100, 100, 10, leveldb.WithMaxBatchSizeInBytes(20))
+	assert.Nil(t, err)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	// key + value = 10 bytes
+	_ = ldb.Put([]byte("key1"), []byte("value1"))
+	assert.Equal(t, 1, ldb.PendingBatchLen())
+	_ = ldb.Remove([]byte("key0"))
+	assert.Equal(t, 2, ldb.PendingBatchLen())
+
+	_ = ldb.Put([]byte("key2"), []byte("value2"))
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+
+	// a single value larger than the limit is written right away
+	_ = ldb.Put([]byte("key3"), make([]byte, 100))
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+
+	val, err := ldb.Get([]byte("key2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value2"), val)
+}
+
+func TestDB_WithAdaptiveBatching(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid bounds should error", func(t *testing.T) {
+		t.Parallel()
+
+		ldb, err := leveldb.NewDB(t.TempDir(), 1, 100, 10, leveldb.WithAdaptiveBatching(0, time.Second))
+		assert.Nil(t, ldb)
+		assert.Equal(t, common.ErrInvalidBatchDelayBounds, err)
+
+		ldb, err = leveldb.NewDB(t.TempDir(), 1, 100, 10, leveldb.WithAdaptiveBatching(time.Second, time.Millisecond))
+		assert.Nil(t, ldb)
+		assert.Equal(t, common.ErrInvalidBatchDelayBounds, err)
+	})
+	t.Run("low load should write the batch after the minimum delay", func(t *testing.T) {
+		t.Parallel()
+
+		ldb, err := leveldb.NewDB(t.TempDir(), 100, 100, 10, leveldb.WithAdaptiveBatching(time.Millisecond*10, time.Second))
+		assert.Nil(t, err)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		_ = ldb.Put([]byte("key"), []byte("value"))
+		assert.Eventually(t, func() bool {
+			return ldb.PendingBatchLen() == 0
+		}, time.Second, time.Millisecond*5)
+	})
+}
+
+func waitBatchWritten(ldb interface{ PendingBatchLen() int }) {
+	for ldb.PendingBatchLen() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// BenchmarkDB_LowLoadWriteLatency measures the time a single write waits before being written in the database
+func BenchmarkDB_LowLoadWriteLatency(b *testing.B) {
+	benchmarks := map[string][]leveldb.Option{
+		"fixed delay":    nil,
+		"adaptive delay": {leveldb.WithAdaptiveBatching(time.Millisecond*10, time.Second)},
+	}
+
+	for name, options := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			ldb, err := leveldb.NewDB(b.TempDir(), 1, 1000, 10, options...)
+			require.Nil(b, err)
+			defer func() {
+				_ = ldb.Close()
+			}()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = ldb.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+				waitBatchWritten(ldb)
+			}
+		})
+	}
+}
+
+// BenchmarkDB_HighLoadWriteThroughput measures the write throughput when the batches are filled by the writes
+func BenchmarkDB_HighLoadWriteThroughput(b *testing.B) {
+	benchmarks := map[string][]leveldb.Option{
+		"fixed delay":    nil,
+		"adaptive delay": {leveldb.WithAdaptiveBatching(time.Millisecond*10, time.Second)},
+	}
+
+	value := make([]byte, 32)
+	for name, options := range benchmarks {
+		b.Run(name, func(b *testing.B) {
+			ldb, err := leveldb.NewDB(b.TempDir(), 1, 1000, 10, options...)
+			require.Nil(b, err)
+			defer func() {
+				_ = ldb.Close()
+			}()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = ldb.Put([]byte(fmt.Sprintf("key%d", i)), value)
+			}
+		})
+	}
+}
+
+func createCorruptedDb(t *testing.T, numKeys int) string {
+	dir := t.TempDir()
+	db, err := leveldb.NewDB(dir, 10, numKeys+1, 10)
+	require.Nil(t, err)
+
+	for i := 0; i < numKeys; i++ {
+		value := make([]byte, 1000)
+		_, _ = rand.Read(value)
+		err = db.Put([]byte(fmt.Sprintf("key_%03d", i)), value)
+		require.Nil(t, err)
+	}
+	require.Nil(t, db.Close())
+
+	// reopening flushes the journal in a table file
+	db, err = leveldb.NewDB(dir, 10, numKeys+1, 10)
+	require.Nil(t, err)
+	require.Nil(t, db.Close())
+
+	tableFiles, err := filepath.Glob(filepath.Join(dir, "*.ldb"))
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tableFiles))
+
+	contents, err := os.ReadFile(tableFiles[0])
+	require.Nil(t, err)
+	middle := len(contents) / 2
+	for i := middle; i < middle+10; i++ {
+		contents[i] ^= 0xFF
+	}
+	require.Nil(t, os.WriteFile(tableFiles[0], contents, 0600))
+
+	return dir
+}
+
+func TestDB_RangeKeysWithCorruption(t *testing.T) {
+	t.Parallel()
+
+	numKeys := 100
+	rangeKeys := func(db *leveldb.DB) [][]byte {
+		keys := make([][]byte, 0)
+		db.RangeKeys(func(key []byte, _ []byte) bool {
+			keys = append(keys, key)
+			return true
+		})
+
+		return keys
+	}
+
+	t.Run("without handler should stop on corruption", func(t *testing.T) {
+		t.Parallel()
+
+		db, err := leveldb.NewDB(createCorruptedDb(t, numKeys), 10, 10, 10)
+		require.Nil(t, err)
+		defer func() {
+			_ = db.Close()
+		}()
+
+		keys := rangeKeys(db)
+		assert.Greater(t, len(keys), 0)
+		assert.Less(t, len(keys), numKeys/2+10)
+		assert.Equal(t, uint64(0), db.SkippedCorruptions())
+	})
+	t.Run("handler returning false should stop on corruption", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		handler := func(key []byte, err error) bool {
+			numCalls++
+			return false
+		}
+		db, err := leveldb.NewDB(createCorruptedDb(t, numKeys), 10, 10, 10, leveldb.WithOnCorruption(handler))
+		require.Nil(t, err)
+		defer func() {
+			_ = db.Close()
+		}()
+
+		keys := rangeKeys(db)
+		assert.Less(t, len(keys), numKeys/2+10)
+		assert.Equal(t, 1, numCalls)
+		assert.Equal(t, uint64(0), db.SkippedCorruptions())
+	})
+	t.Run("handler returning true should skip the corrupted entries", func(t *testing.T) {
+		t.Parallel()
+
+		var lastKeyBeforeCorruption []byte
+		handler := func(key []byte, err error) bool {
+			lastKeyBeforeCorruption = key
+			assert.NotNil(t, err)
+			return true
+		}
+		db, err := leveldb.NewDB(createCorruptedDb(t, numKeys), 10, 10, 10, leveldb.WithOnCorruption(handler))
+		require.Nil(t, err)
+		defer func() {
+			_ = db.Close()
+		}()
+
+		keys := rangeKeys(db)
+		assert.Less(t, len(keys), numKeys)
+		assert.Greater(t, len(keys), numKeys-10)
+		assert.Equal(t, []byte(fmt.Sprintf("key_%03d", numKeys-1)), keys[len(keys)-1])
+		assert.Contains(t, keys, lastKeyBeforeCorruption)
+		assert.Equal(t, uint64(1), db.SkippedCorruptions())
+
+		for i := 1; i < len(keys); i++ {
+			assert.Less(t, string(keys[i-1]), string(keys[i]))
+		}
+	})
+}
+
+func TestDB_SwapDirectory(t *testing.T) {
+	t.Parallel()
+
+	createDbWithKey := func(t *testing.T, dir string, key string) *leveldb.DB {
+		ldb, err := leveldb.NewDB(dir, 10, 10, 10)
+		require.Nil(t, err)
+		require.Nil(t, ldb.Put([]byte(key), []byte(key+" value")))
+
+		return ldb
+	}
+
+	t.Run("invalid directory should error", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "db")
+		ldb := createDbWithKey(t, dir, "old")
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		err := ldb.SwapDirectory(filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorIs(t, err, leveldb.ErrInvalidSwapDirectory)
+
+		file := filepath.Join(t.TempDir(), "file")
+		require.Nil(t, os.WriteFile(file, []byte("content"), 0600))
+		err = ldb.SwapDirectory(file)
+		assert.ErrorIs(t, err, leveldb.ErrInvalidSwapDirectory)
+
+		err = ldb.SwapDirectory(dir + string(filepath.Separator))
+		assert.ErrorIs(t, err, leveldb.ErrInvalidSwapDirectory)
+
+		require.Nil(t, os.Mkdir(dir+".swap-backup", 0700))
+		err = ldb.SwapDirectory(t.TempDir())
+		assert.ErrorIs(t, err, leveldb.ErrSwapBackupExists)
+
+		val, err := ldb.Get([]byte("old"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("old value"), val)
+	})
+	t.Run("closed db should error", func(t *testing.T) {
+		t.Parallel()
+
+		ldb := createLevelDb(t, 10, 10, 10)
+		_ = ldb.Close()
+
+		err := ldb.SwapDirectory(t.TempDir())
+		assert.ErrorIs(t, err, common.ErrDBIsClosed)
+	})
+	t.Run("should swap in the new database", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "db")
+		stagingDir := filepath.Join(t.TempDir(), "staging")
+		staging := createDbWithKey(t, stagingDir, "new")
+		require.Nil(t, staging.Close())
+
+		ldb := createDbWithKey(t, dir, "old")
+		require.Nil(t, ldb.SwapDirectory(stagingDir))
+
+		val, err := ldb.Get([]byte("new"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("new value"), val)
+		_, err = ldb.Get([]byte("old"))
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
+
+		_, err = os.Stat(stagingDir)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(dir + ".swap-backup")
+		assert.True(t, os.IsNotExist(err))
+
+		require.Nil(t, ldb.Put([]byte("after"), []byte("after value")))
+		require.Nil(t, ldb.Close())
+
+		ldb, err = leveldb.NewDB(dir, 10, 10, 10)
+		require.Nil(t, err)
+		val, err = ldb.Get([]byte("after"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("after value"), val)
+		_ = ldb.Close()
+	})
+	t.Run("failed open should roll back", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "db")
+		stagingDir := filepath.Join(t.TempDir(), "staging")
+		// the lock file can not be created, so the staging database can not be opened
+		require.Nil(t, os.MkdirAll(filepath.Join(stagingDir, "LOCK"), 0700))
+
+		ldb := createDbWithKey(t, dir, "old")
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		err := ldb.SwapDirectory(stagingDir)
+		assert.NotNil(t, err)
+
+		val, err := ldb.Get([]byte("old"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("old value"), val)
+
+		_, err = os.Stat(filepath.Join(stagingDir, "LOCK"))
+		assert.Nil(t, err)
+		_, err = os.Stat(dir + ".swap-backup")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestDB_SetBulkLoadMode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("closed db should error", func(t *testing.T) {
+		t.Parallel()
+
+		ldb := createLevelDb(t, 10, 10, 10)
+		_ = ldb.Close()
+
+		err := ldb.SetBulkLoadMode(true)
+		assert.ErrorIs(t, err, common.ErrDBIsClosed)
+	})
+	t.Run("data written before, during and after bulk load should be kept", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		ldb, err := leveldb.NewDB(dir, 10, 100, 10)
+		require.Nil(t, err)
+
+		numKeys := 1000
+		putRange := func(start int, end int) {
+			for i := start; i < end; i++ {
+				errPut := ldb.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+				require.Nil(t, errPut)
+			}
+		}
+
+		putRange(0, 10)
+		require.Nil(t, ldb.SetBulkLoadMode(true))
+		require.Nil(t, ldb.SetBulkLoadMode(true))
+		putRange(10, numKeys-10)
+
+		val, err := ldb.Get([]byte("key5"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value5"), val)
+
+		require.Nil(t, ldb.SetBulkLoadMode(false))
+		putRange(numKeys-10, numKeys)
+		require.Nil(t, ldb.Close())
+
+		ldb, err = leveldb.NewDB(dir, 10, 100, 10)
+		require.Nil(t, err)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		for i := 0; i < numKeys; i++ {
+			val, err = ldb.Get([]byte(fmt.Sprintf("key%d", i)))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+	})
+}
+
+// BenchmarkDB_BulkLoad measures the write throughput of a restore like load, with and without the bulk load mode
+func BenchmarkDB_BulkLoad(b *testing.B) {
+	value := make([]byte, 1024)
+	_, _ = rand.Read(value)
+
+	for _, bulkLoad := range []bool{false, true} {
+		b.Run(fmt.Sprintf("bulk load %v", bulkLoad), func(b *testing.B) {
+			ldb, err := leveldb.NewDB(b.TempDir(), 1, 1000, 10)
+			require.Nil(b, err)
+			defer func() {
+				_ = ldb.Close()
+			}()
+			require.Nil(b, ldb.SetBulkLoadMode(bulkLoad))
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = ldb.Put([]byte(fmt.Sprintf("key%d", i)), value)
+			}
+			require.Nil(b, ldb.SetBulkLoadMode(false))
+		})
+	}
+}
+
+func TestDB_ErrorsShouldCarryTheKeyAndOperation(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 10, 1, 10)
+	key := []byte("key")
+
+	err := ldb.Has(key)
+	storageErr := &common.StorageError{}
+	require.True(t, errors.As(err, &storageErr))
+	assert.Equal(t, common.OpHas, storageErr.Op)
+	assert.Equal(t, key, storageErr.Key)
+	assert.Equal(t, "leveldb", storageErr.Backend)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+
+	_ = ldb.Close()
+	err = ldb.Put(key, []byte("value"))
+	require.True(t, errors.As(err, &storageErr))
+	assert.Equal(t, common.OpPut, storageErr.Op)
+	assert.Equal(t, key, storageErr.Key)
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
+}
+
 func TestDB_PutGetLargeValue(t *testing.T) {
 	t.Parallel()
 
@@ -336,13 +1013,13 @@ func testDbAllMethodsShouldNotPanic(t *testing.T, closeHandler func(db *leveldb.
 	closeHandler(ldb)
 
 	err := ldb.Put([]byte("key1"), []byte("val1"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.ErrorIs(t, err, common.ErrDBIsClosed)
 
 	_, err = ldb.Get([]byte("key2"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.ErrorIs(t, err, common.ErrDBIsClosed)
 
 	err = ldb.Has([]byte("key3"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.ErrorIs(t, err, common.ErrDBIsClosed)
 
 	ldb.RangeKeys(func(key []byte, value []byte) bool {
 		require.Fail(t, "should have not called range")
@@ -350,7 +1027,7 @@ func testDbAllMethodsShouldNotPanic(t *testing.T, closeHandler func(db *leveldb.
 	})
 
 	err = ldb.Remove([]byte("key4"))
-	require.Equal(t, common.ErrDBIsClosed, err)
+	require.ErrorIs(t, err, common.ErrDBIsClosed)
 }
 
 func TestDB_SpecialValueTest(t *testing.T) {
@@ -376,7 +1053,7 @@ func TestDB_SpecialValueTest(t *testing.T) {
 		require.Nil(t, err)
 
 		recovered, err := ldb.Get(key)
-		assert.Equal(t, common.ErrKeyNotFound, err)
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
 		assert.Nil(t, recovered)
 	})
 	t.Run("operations: put -> remove -> put -> get of 'removed' value", func(t *testing.T) {
@@ -452,3 +1129,420 @@ func TestDB_ConcurrentOperations(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestDB_ApplyBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid operation type should error", func(t *testing.T) {
+		t.Parallel()
+
+		ldb := createLevelDb(t, 10, 100, 10)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		ops := []types.Operation{
+			{Type: types.PutOperation, Key: []byte("key1"), Value: []byte("value1")},
+			{Type: 100, Key: []byte("key2")},
+		}
+		err := ldb.ApplyBatch(ops)
+		assert.Equal(t, common.ErrInvalidOperationType, err)
+
+		_, err = ldb.Get([]byte("key1"))
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	})
+	t.Run("should write the operations and the pending batch", func(t *testing.T) {
+		t.Parallel()
+
+		ldb := createLevelDb(t, 10, 100, 10)
+		defer func() {
+			_ = ldb.Close()
+		}()
+
+		_ = ldb.Put([]byte("pending"), []byte("pending value"))
+		_ = ldb.Put([]byte("removed"), []byte("removed value"))
+
+		ops := []types.Operation{
+			{Type: types.PutOperation, Key: []byte("key1"), Value: []byte("value1")},
+			{Type: types.RemoveOperation, Key: []byte("removed")},
+			{Type: types.PutOperation, Key: []byte("key2"), Value: []byte("value2")},
+		}
+		err := ldb.ApplyBatch(ops)
+		assert.Nil(t, err)
+
+		// RangeKeys only sees the data written in the database
+		recovered := make(map[string][]byte)
+		ldb.RangeKeys(func(key []byte, value []byte) bool {
+			recovered[string(key)] = value
+			return true
+		})
+
+		expected := map[string][]byte{
+			"pending": []byte("pending value"),
+			"key1":    []byte("value1"),
+			"key2":    []byte("value2"),
+		}
+		assert.Equal(t, expected, recovered)
+	})
+}
+
+func TestDB_ReadAfterWriteWithinBatchWindow(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 100, 3, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	key, val := []byte("key"), []byte("value")
+	_ = ldb.Put(key, val)
+	assert.Equal(t, 1, ldb.PendingBatchLen())
+
+	recovered, err := ldb.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, val, recovered)
+	assert.Nil(t, ldb.Has(key))
+
+	_ = ldb.Remove(key)
+	assert.Equal(t, 2, ldb.PendingBatchLen())
+	_, err = ldb.Get(key)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, ldb.Has(key), common.ErrKeyNotFound)
+
+	_ = ldb.Put([]byte("key2"), val)
+	assert.Equal(t, 0, ldb.PendingBatchLen())
+}
+
+func TestDB_ConcurrentReadAfterWriteShouldBeConsistent(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 1, 2, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	numGoroutines := 100
+	wg := sync.WaitGroup{}
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key%d", idx))
+			_ = ldb.Put(key, key)
+			recovered, err := ldb.Get(key)
+			assert.Nil(t, err)
+			assert.Equal(t, key, recovered)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNewSharedBlockCache(t *testing.T) {
+	t.Parallel()
+
+	sharedBlockCache, err := leveldb.NewSharedBlockCache(0)
+	assert.Nil(t, sharedBlockCache)
+	assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+
+	sharedBlockCache, err = leveldb.NewSharedBlockCache(1024)
+	assert.Nil(t, err)
+	assert.Equal(t, 1024, sharedBlockCache.Capacity())
+}
+
+func TestDB_WithSharedBlockCacheShouldWork(t *testing.T) {
+	t.Parallel()
+
+	sharedBlockCache, _ := leveldb.NewSharedBlockCache(1024 * 1024)
+	dirs := []string{t.TempDir(), t.TempDir()}
+	numKeys := 100
+
+	for _, dir := range dirs {
+		ldb, err := leveldb.NewDB(dir, 10, numKeys, 10, leveldb.WithSharedBlockCache(sharedBlockCache))
+		assert.Nil(t, err)
+		for i := 0; i < numKeys; i++ {
+			_ = ldb.Put([]byte(fmt.Sprintf("key%d", i)), []byte(dir))
+		}
+		_ = ldb.Close()
+	}
+
+	// reopen the persisters so the values are read from the sorted tables, through the shared block cache
+	first, err := leveldb.NewDB(dirs[0], 10, numKeys, 10, leveldb.WithSharedBlockCache(sharedBlockCache))
+	assert.Nil(t, err)
+	second, err := leveldb.NewSerialDB(dirs[1], 10, numKeys, 10, leveldb.WithSharedBlockCache(sharedBlockCache))
+	assert.Nil(t, err)
+
+	for i := 0; i < numKeys; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		val, errGet := first.Get(key)
+		assert.Nil(t, errGet)
+		assert.Equal(t, []byte(dirs[0]), val)
+
+		val, errGet = second.Get(key)
+		assert.Nil(t, errGet)
+		assert.Equal(t, []byte(dirs[1]), val)
+	}
+
+	// closing one persister should not affect the other one using the same cache
+	_ = first.Close()
+	val, err := second.Get([]byte("key0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(dirs[1]), val)
+	_ = second.Close()
+}
+
+func TestDB_ShouldReportPersistedSize(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 10, 1, 10)
+	_ = ldb.Put([]byte("key"), []byte("value"))
+
+	assert.Eventually(t, func() bool {
+		return monitoring.TotalPersistedBytes() > 0
+	}, time.Second, 10*time.Millisecond)
+
+	_ = ldb.Destroy()
+}
+
+func TestDB_CloseShouldClearThePersistedSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ldb, err := leveldb.NewDB(dir, 10, 1, 10)
+	require.Nil(t, err)
+	_ = ldb.Put([]byte("key"), []byte("value"))
+
+	assert.Eventually(t, func() bool {
+		return monitoring.PersisterSize(dir) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	require.Nil(t, ldb.Close())
+	assert.Equal(t, uint64(0), monitoring.PersisterSize(dir))
+}
+
+func TestDB_GetExisting(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 10, 100, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("persisted"), []byte("persisted value"))
+	_ = ldb.Put([]byte("removed"), []byte("removed value"))
+	err := ldb.ApplyBatch(nil)
+	require.Nil(t, err)
+
+	// pending in the batch
+	_ = ldb.Put([]byte("pending"), []byte("pending value"))
+	_ = ldb.Remove([]byte("removed"))
+
+	values, err := ldb.GetExisting([][]byte{
+		[]byte("persisted"),
+		[]byte("pending"),
+		[]byte("removed"),
+		[]byte("missing"),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, map[string][]byte{
+		"persisted": []byte("persisted value"),
+		"pending":   []byte("pending value"),
+	}, values)
+}
+
+func TestDB_PrefixDiskSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ldb, err := leveldb.NewDB(dir, 1, 1, 10)
+	require.Nil(t, err)
+
+	putRandomValues := func(prefix string, numValues int) {
+		for i := 0; i < numValues; i++ {
+			value := make([]byte, 1024)
+			_, _ = rand.Read(value)
+			_ = ldb.Put([]byte(fmt.Sprintf("%s%04d", prefix, i)), value)
+		}
+	}
+	putRandomValues("tenant1_", 200)
+	putRandomValues("tenant2_", 20)
+
+	// the values still in the journal are not accounted
+	size, err := ldb.PrefixDiskSize([]byte("tenant1_"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), size)
+
+	// reopening flushes the journal in a table file
+	_ = ldb.Close()
+	ldb, err = leveldb.NewDB(dir, 1, 1, 10)
+	require.Nil(t, err)
+
+	sizeTenant1, err := ldb.PrefixDiskSize([]byte("tenant1_"))
+	assert.Nil(t, err)
+	sizeTenant2, err := ldb.PrefixDiskSize([]byte("tenant2_"))
+	assert.Nil(t, err)
+	sizeTenant3, err := ldb.PrefixDiskSize([]byte("tenant3_"))
+	assert.Nil(t, err)
+	sizeAll, err := ldb.PrefixDiskSize(nil)
+	assert.Nil(t, err)
+
+	// the random values can not be compressed, so the estimations are close to the written bytes
+	assert.Greater(t, sizeTenant1, uint64(190*1024))
+	assert.Greater(t, sizeTenant2, uint64(10*1024))
+	assert.Less(t, sizeTenant2, sizeTenant1)
+	assert.Equal(t, uint64(0), sizeTenant3)
+	assert.GreaterOrEqual(t, sizeAll, sizeTenant1+sizeTenant2)
+
+	_ = ldb.Close()
+	_, err = ldb.PrefixDiskSize([]byte("tenant1_"))
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
+}
+
+func TestDB_EntryStats(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	reopen := func(ldb *leveldb.DB) *leveldb.DB {
+		// reopening flushes the journal in a table file
+		_ = ldb.Close()
+		reopened, err := leveldb.NewDB(dir, 1, 1, 10)
+		require.Nil(t, err)
+
+		return reopened
+	}
+
+	ldb, err := leveldb.NewDB(dir, 1, 1, 10)
+	require.Nil(t, err)
+	live, deleted, err := ldb.EntryStats()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), live)
+	assert.Equal(t, uint64(0), deleted)
+
+	for i := 0; i < 10; i++ {
+		_ = ldb.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+	}
+	ldb = reopen(ldb)
+
+	live, deleted, err = ldb.EntryStats()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10), live)
+	assert.Equal(t, uint64(0), deleted)
+
+	for i := 0; i < 4; i++ {
+		_ = ldb.Remove([]byte(fmt.Sprintf("key%d", i)))
+	}
+	ldb = reopen(ldb)
+
+	// each removed key leaves its deletion marker and its shadowed value
+	live, deleted, err = ldb.EntryStats()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(6), live)
+	assert.Equal(t, uint64(8), deleted)
+
+	_ = ldb.Close()
+	_, _, err = ldb.EntryStats()
+	assert.ErrorIs(t, err, common.ErrDBIsClosed)
+}
+
+func TestDB_NewPrefetchIterator(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 10, 1, 10)
+	t.Cleanup(func() {
+		_ = ldb.Close()
+	})
+	for i := 0; i < 20; i++ {
+		_ = ldb.Put([]byte(fmt.Sprintf("key%02d", i)), []byte(fmt.Sprintf("value%02d", i)))
+	}
+	waitBatchWritten(ldb)
+
+	t.Run("invalid read ahead should error", func(t *testing.T) {
+		t.Parallel()
+
+		it, err := ldb.NewPrefetchIterator(nil, nil, 0)
+		assert.Nil(t, it)
+		assert.Equal(t, common.ErrInvalidReadAhead, err)
+	})
+	t.Run("should iterate the range in order", func(t *testing.T) {
+		t.Parallel()
+
+		it, err := ldb.NewPrefetchIterator([]byte("key05"), []byte("key15"), 3)
+		require.Nil(t, err)
+		defer it.Release()
+
+		i := 5
+		for it.Next() {
+			assert.Equal(t, []byte(fmt.Sprintf("key%02d", i)), it.Key())
+			assert.Equal(t, []byte(fmt.Sprintf("value%02d", i)), it.Value())
+			i++
+		}
+		assert.Equal(t, 15, i)
+		assert.Nil(t, it.Error())
+		assert.False(t, it.Next())
+	})
+	t.Run("released iterator should stop", func(t *testing.T) {
+		t.Parallel()
+
+		it, err := ldb.NewPrefetchIterator(nil, nil, 1)
+		require.Nil(t, err)
+
+		assert.True(t, it.Next())
+		assert.Equal(t, []byte("key00"), it.Key())
+		it.Release()
+		it.Release()
+		assert.False(t, it.Next())
+		assert.Nil(t, it.Error())
+	})
+}
+
+func TestDB_NewPrefetchIteratorOnClosedDBShouldError(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 10, 1, 10)
+	_ = ldb.Close()
+
+	it, err := ldb.NewPrefetchIterator(nil, nil, 10)
+	assert.Nil(t, it)
+	assert.Equal(t, common.ErrDBIsClosed, err)
+}
+
+// BenchmarkDB_SequentialScanThenRead measures a scan reading each value of a key range in order, as separate
+// lookups of the scanned keys and through the prefetch iterator
+func BenchmarkDB_SequentialScanThenRead(b *testing.B) {
+	numEntries := 10000
+	ldb, err := leveldb.NewDB(b.TempDir(), 1, numEntries, 10)
+	require.Nil(b, err)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	value := make([]byte, 256)
+	_, _ = rand.Read(value)
+	for i := 0; i < numEntries; i++ {
+		_ = ldb.Put([]byte(fmt.Sprintf("key%06d", i)), value)
+	}
+	waitBatchWritten(ldb)
+
+	b.Run("scan keys then get", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			keys := make([][]byte, 0, numEntries)
+			ldb.RangePrefix([]byte("key"), func(key []byte, _ []byte) bool {
+				keys = append(keys, append([]byte{}, key...))
+				return true
+			})
+			for _, key := range keys {
+				_, _ = ldb.Get(key)
+			}
+		}
+	})
+	b.Run("prefetch iterator", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			it, errIterator := ldb.NewPrefetchIterator(nil, nil, 256)
+			require.Nil(b, errIterator)
+			for it.Next() {
+				_ = it.Value()
+			}
+			it.Release()
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package leveldb
+
+import (
+	"time"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/syndtr/goleveldb/leveldb/cache"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+const (
+	bulkLoadWriteBuffer         = 64 * opt.MiB
+	bulkLoadCompactionTableSize = 8 * opt.MiB
+	deferredCompactionL0Trigger = 64
+	deferredL0SlowdownTrigger   = 128
+	deferredL0PauseTrigger      = 256
+)
+
+// Option defines an optional setting applied when opening a persister
+type Option func(options *dbOptions)
+
+// OnCorruptionHandler is called when RangeKeys meets a corrupted entry. As the key of a corrupted entry can not be
+// read, it receives the last readable key preceding the corruption, nil if there is none. Returning true skips the
+// corrupted entries and continues the iteration, returning false stops it
+type OnCorruptionHandler func(key []byte, err error) bool
+
+type dbOptions struct {
+	levelDBOptions      *opt.Options
+	maxBatchSizeInBytes int
+	adaptiveBatching    bool
+	minBatchDelay       time.Duration
+	maxBatchDelay       time.Duration
+	onCorruption        OnCorruptionHandler
+	compactionWindows   []CompactionWindow
+	syncInterval        time.Duration
+	openTimeout         time.Duration
+}
+
+// SharedBlockCache is a leveldb block cache, bounded in bytes, that can be shared by multiple persisters.
+// Each persister keeps its own cache namespace while the eviction and the capacity are handled globally,
+// so inactive persisters do not hold a private block cache each.
+// The shared cache must outlive all the persisters using it, so it should be created before opening the first
+// persister and released only after all of them are closed
+type SharedBlockCache struct {
+	cacher cache.Cacher
+}
+
+// NewSharedBlockCache creates a new block cache with the provided capacity in bytes
+func NewSharedBlockCache(capacityInBytes int) (*SharedBlockCache, error) {
+	if capacityInBytes < 1 {
+		return nil, common.ErrCacheCapacityInvalid
+	}
+
+	return &SharedBlockCache{
+		cacher: cache.NewLRU(capacityInBytes),
+	}, nil
+}
+
+// Capacity returns the capacity in bytes of the shared block cache
+func (sbc *SharedBlockCache) Capacity() int {
+	return sbc.cacher.Capacity()
+}
+
+// WithSharedBlockCache makes the persister use the provided shared block cache instead of a private one.
+// A nil cache is ignored
+func WithSharedBlockCache(sharedBlockCache *SharedBlockCache) Option {
+	return func(options *dbOptions) {
+		if sharedBlockCache == nil {
+			return
+		}
+
+		options.levelDBOptions.BlockCacher = opt.PassthroughCacher(sharedBlockCache.cacher)
+		// the capacity is only checked to be positive, the shared cacher enforces its own capacity
+		options.levelDBOptions.BlockCacheCapacity = sharedBlockCache.Capacity()
+	}
+}
+
+// WithMaxBatchSizeInBytes makes the persister write the pending batch as soon as the accumulated size of its keys
+// and values reaches the provided value, besides the entries count and the time limits. Non-positive values disable it
+func WithMaxBatchSizeInBytes(maxBatchSizeInBytes int) Option {
+	return func(options *dbOptions) {
+		options.maxBatchSizeInBytes = maxBatchSizeInBytes
+	}
+}
+
+// WithAdaptiveBatching replaces the fixed batch delay with one adapted to the recent write rate, between the provided
+// bounds: the delay is shortened under low load to reduce the write latency and lengthened under high load
+func WithAdaptiveBatching(minBatchDelay time.Duration, maxBatchDelay time.Duration) Option {
+	return func(options *dbOptions) {
+		options.adaptiveBatching = true
+		options.minBatchDelay = minBatchDelay
+		options.maxBatchDelay = maxBatchDelay
+	}
+}
+
+// WithOnCorruption sets the handler deciding whether RangeKeys skips the corrupted entries it meets instead of
+// stopping, so the readable data of a partially corrupted database can still be salvaged.
+// The number of skipped corruptions is reported by SkippedCorruptions
+func WithOnCorruption(handler OnCorruptionHandler) Option {
+	return func(options *dbOptions) {
+		options.onCorruption = handler
+	}
+}
+
+// WithCompactionSchedule restricts the expensive compaction IO to the provided daily windows, such as the low traffic
+// hours: a full compaction is run each time a window is entered, while outside the windows the automatic level 0
+// and seek triggered compactions are deferred by raising their thresholds. The writes are still slowed down if the
+// level 0 table files pile up past the raised thresholds. No windows leave the automatic compactions unchanged
+func WithCompactionSchedule(windows []CompactionWindow) Option {
+	return func(options *dbOptions) {
+		if len(windows) == 0 {
+			return
+		}
+
+		options.compactionWindows = windows
+		deferCompactions(options.levelDBOptions)
+	}
+}
+
+// WithSyncEveryInterval makes the batch writes return without waiting for the data to reach the disk, the journal
+// being instead synced in the background every interval and when closing. A machine crash can then lose the writes
+// of the last interval, while a process crash loses nothing. A zero interval keeps each write synced
+func WithSyncEveryInterval(interval time.Duration) Option {
+	return func(options *dbOptions) {
+		options.syncInterval = interval
+	}
+}
+
+// WithOpenTimeout bounds the whole opening of the database, retries included, to the provided duration, the
+// constructor returning ErrOpenTimeout once it is exceeded. A database opened after the timeout, as a hung disk
+// recovers, is closed in the background. A zero timeout keeps the opening unbounded
+func WithOpenTimeout(timeout time.Duration) Option {
+	return func(options *dbOptions) {
+		options.openTimeout = timeout
+	}
+}
+
+func createOptions(maxOpenFiles int, options ...Option) *dbOptions {
+	opts := &dbOptions{
+		levelDBOptions: &opt.Options{
+			// disable internal cache
+			BlockCacheCapacity:     -1,
+			OpenFilesCacheCapacity: maxOpenFiles,
+		},
+	}
+
+	for _, option := range options {
+		option(opts)
+	}
+
+	return opts
+}
+
+// createBulkLoadOptions returns a copy of the provided options tuned for ingest throughput: larger write buffer and
+// table files, level 0 compactions deferred and writes not synced
+func createBulkLoadOptions(options *opt.Options) *opt.Options {
+	bulkLoadOptions := *options
+	bulkLoadOptions.WriteBuffer = bulkLoadWriteBuffer
+	bulkLoadOptions.CompactionTableSize = bulkLoadCompactionTableSize
+	bulkLoadOptions.NoSync = true
+	deferCompactions(&bulkLoadOptions)
+
+	return &bulkLoadOptions
+}
+
+func deferCompactions(options *opt.Options) {
+	options.CompactionL0Trigger = deferredCompactionL0Trigger
+	options.WriteL0SlowdownTrigger = deferredL0SlowdownTrigger
+	options.WriteL0PauseTrigger = deferredL0PauseTrigger
+	options.DisableSeeksCompaction = true
+}
+
+func isBatchSizeInBytesReached(b types.Batcher, maxBatchSizeInBytes int) bool {
+	if maxBatchSizeInBytes <= 0 {
+		return false
+	}
+
+	dbBatch, ok := b.(*batch)
+	if !ok {
+		return false
+	}
+
+	return dbBatch.getSizeInBytes() >= maxBatchSizeInBytes
+}
+
+func createAdaptiveBatchDelay(options *dbOptions, maxBatchSize int) (*adaptiveBatchDelay, error) {
+	if !options.adaptiveBatching {
+		return nil, nil
+	}
+	if options.minBatchDelay <= 0 || options.maxBatchDelay < options.minBatchDelay {
+		return nil, common.ErrInvalidBatchDelayBounds
+	}
+
+	return newAdaptiveBatchDelay(options.minBatchDelay, options.maxBatchDelay, maxBatchSize), nil
+}
@@ -0,0 +1,120 @@
+package leveldb
+
+import (
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+type prefetchEntry struct {
+	key   []byte
+	value []byte
+}
+
+// PrefetchIterator iterates over the persisted (key, value) pairs of a key range in ascending key order, while a
+// background go routine reads ahead the next entries in a bounded buffer, so the sequential scans overlap the
+// database reads with the processing of the returned entries. The iterator is not safe for concurrent use and must
+// be released after use
+type PrefetchIterator struct {
+	chEntries     chan prefetchEntry
+	chRelease     chan struct{}
+	chReleased    chan struct{}
+	releaseOnce   sync.Once
+	isReleased    bool
+	current       prefetchEntry
+	err           error
+	producerError error
+}
+
+// NewPrefetchIterator creates an iterator over the persisted keys in the [start, limit) range, reading ahead at most
+// readAhead entries. A nil start means the first key and a nil limit means past the last key. The writes not yet
+// flushed from the pending batch are not visible
+func (bldb *baseLevelDb) NewPrefetchIterator(start, limit []byte, readAhead int) (*PrefetchIterator, error) {
+	if readAhead < 1 {
+		return nil, common.ErrInvalidReadAhead
+	}
+
+	db := bldb.getDbPointer()
+	if db == nil {
+		return nil, common.ErrDBIsClosed
+	}
+
+	it := &PrefetchIterator{
+		chEntries:  make(chan prefetchEntry, readAhead),
+		chRelease:  make(chan struct{}),
+		chReleased: make(chan struct{}),
+	}
+
+	go it.prefetch(db.NewIterator(&util.Range{Start: start, Limit: limit}, nil))
+
+	return it, nil
+}
+
+func (it *PrefetchIterator) prefetch(dbIterator iterator.Iterator) {
+	defer close(it.chReleased)
+	defer dbIterator.Release()
+	// the producer error is written before closing the channel, so it is visible to the consumer reading the close
+	defer close(it.chEntries)
+
+	for dbIterator.Next() {
+		// the iterator reuses its buffers, so the key and the value are copied
+		entry := prefetchEntry{
+			key:   append([]byte{}, dbIterator.Key()...),
+			value: append([]byte{}, dbIterator.Value()...),
+		}
+
+		select {
+		case it.chEntries <- entry:
+		case <-it.chRelease:
+			return
+		}
+	}
+
+	it.producerError = dbIterator.Error()
+}
+
+// Next moves to the next entry, returning false when the range is exhausted, when an error occurred or after
+// the iterator was released
+func (it *PrefetchIterator) Next() bool {
+	if it.isReleased {
+		return false
+	}
+
+	entry, ok := <-it.chEntries
+	if !ok {
+		it.err = it.producerError
+		it.current = prefetchEntry{}
+		return false
+	}
+
+	it.current = entry
+
+	return true
+}
+
+// Key returns the key of the current entry. The returned slice is owned by the caller
+func (it *PrefetchIterator) Key() []byte {
+	return it.current.key
+}
+
+// Value returns the value of the current entry. The returned slice is owned by the caller
+func (it *PrefetchIterator) Value() []byte {
+	return it.current.value
+}
+
+// Error returns the error which stopped the iteration, if any
+func (it *PrefetchIterator) Error() error {
+	return it.err
+}
+
+// Release stops the read ahead and releases the underlying database iterator, waiting for the background go
+// routine to end. It can be called multiple times
+func (it *PrefetchIterator) Release() {
+	it.releaseOnce.Do(func() {
+		it.isReleased = true
+		close(it.chRelease)
+		<-it.chReleased
+	})
+}
@@ -0,0 +1,161 @@
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+const currentFileName = "CURRENT"
+
+var errReadOnlyStorage = errors.New("read only storage")
+var errInvalidCurrentFile = errors.New("invalid CURRENT file")
+
+// readOnlyStorage is a goleveldb storage that reads the database files without acquiring the directory lock,
+// so it can be used while another process holds the database opened for writing. It never writes any file
+type readOnlyStorage struct {
+	path string
+}
+
+type noopLocker struct{}
+
+// Unlock does nothing
+func (nl *noopLocker) Unlock() {}
+
+// Lock returns a locker that does not lock anything, as the directory lock is owned by the writer
+func (ros *readOnlyStorage) Lock() (storage.Locker, error) {
+	return &noopLocker{}, nil
+}
+
+// Log does nothing, the LOG file belongs to the writer
+func (ros *readOnlyStorage) Log(_ string) {}
+
+// SetMeta returns an error as the storage is read only
+func (ros *readOnlyStorage) SetMeta(_ storage.FileDesc) error {
+	return errReadOnlyStorage
+}
+
+// GetMeta returns the manifest file referenced by the CURRENT file
+func (ros *readOnlyStorage) GetMeta() (storage.FileDesc, error) {
+	content, err := os.ReadFile(filepath.Join(ros.path, currentFileName))
+	if err != nil {
+		return storage.FileDesc{}, err
+	}
+
+	name := string(content)
+	fd, ok := parseFileName(strings.TrimSuffix(name, "\n"))
+	if !strings.HasSuffix(name, "\n") || !ok || fd.Type != storage.TypeManifest {
+		return storage.FileDesc{}, &storage.ErrCorrupted{Err: errInvalidCurrentFile}
+	}
+
+	_, err = os.Stat(filepath.Join(ros.path, generateFileName(fd)))
+	if err != nil {
+		return storage.FileDesc{}, err
+	}
+
+	return fd, nil
+}
+
+// List returns the database files of the provided types
+func (ros *readOnlyStorage) List(ft storage.FileType) ([]storage.FileDesc, error) {
+	entries, err := os.ReadDir(ros.path)
+	if err != nil {
+		return nil, err
+	}
+
+	fds := make([]storage.FileDesc, 0, len(entries))
+	for _, entry := range entries {
+		fd, ok := parseFileName(entry.Name())
+		if ok && fd.Type&ft != 0 {
+			fds = append(fds, fd)
+		}
+	}
+
+	return fds, nil
+}
+
+// Open opens the provided database file for reading
+func (ros *readOnlyStorage) Open(fd storage.FileDesc) (storage.Reader, error) {
+	file, err := os.Open(filepath.Join(ros.path, generateFileName(fd)))
+	if err == nil {
+		return file, nil
+	}
+	if fd.Type != storage.TypeTable || !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// older databases might contain tables with the legacy extension
+	file, err = os.Open(filepath.Join(ros.path, fmt.Sprintf("%06d.sst", fd.Num)))
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Create returns an error as the storage is read only
+func (ros *readOnlyStorage) Create(_ storage.FileDesc) (storage.Writer, error) {
+	return nil, errReadOnlyStorage
+}
+
+// Remove returns an error as the storage is read only
+func (ros *readOnlyStorage) Remove(_ storage.FileDesc) error {
+	return errReadOnlyStorage
+}
+
+// Rename returns an error as the storage is read only
+func (ros *readOnlyStorage) Rename(_, _ storage.FileDesc) error {
+	return errReadOnlyStorage
+}
+
+// Close does nothing
+func (ros *readOnlyStorage) Close() error {
+	return nil
+}
+
+// generateFileName mirrors the file naming used by the goleveldb file storage
+func generateFileName(fd storage.FileDesc) string {
+	switch fd.Type {
+	case storage.TypeManifest:
+		return fmt.Sprintf("MANIFEST-%06d", fd.Num)
+	case storage.TypeJournal:
+		return fmt.Sprintf("%06d.log", fd.Num)
+	case storage.TypeTable:
+		return fmt.Sprintf("%06d.ldb", fd.Num)
+	default:
+		return fmt.Sprintf("%06d.tmp", fd.Num)
+	}
+}
+
+// parseFileName mirrors the file name parsing used by the goleveldb file storage
+func parseFileName(name string) (storage.FileDesc, bool) {
+	fd := storage.FileDesc{}
+	var tail string
+	_, err := fmt.Sscanf(name, "%d.%s", &fd.Num, &tail)
+	if err == nil {
+		switch tail {
+		case "log":
+			fd.Type = storage.TypeJournal
+		case "ldb", "sst":
+			fd.Type = storage.TypeTable
+		case "tmp":
+			fd.Type = storage.TypeTemp
+		default:
+			return fd, false
+		}
+
+		return fd, true
+	}
+
+	n, _ := fmt.Sscanf(name, "MANIFEST-%d%s", &fd.Num, &tail)
+	if n == 1 {
+		fd.Type = storage.TypeManifest
+		return fd, true
+	}
+
+	return fd, false
+}
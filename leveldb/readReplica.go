@@ -0,0 +1,153 @@
+package leveldb
+
+import (
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+var _ types.Persister = (*ReadReplica)(nil)
+var _ types.PrefixCounter = (*ReadReplica)(nil)
+var _ types.PrefixDiskSizer = (*ReadReplica)(nil)
+
+const readReplicaBackendName = "leveldbReadReplica"
+
+// ReadReplica is a read only view over a leveldb database that can be concurrently opened for writing by another
+// DB or process.
+//
+// The replica is eventually consistent: it observes the state of the database at the moment it was opened or last
+// refreshed, including the writes already present in the writer journal. Writes done afterwards, including the
+// pending batches of the writer, become visible only after calling Refresh. Since the writer compacts and deletes
+// old table files, a stale replica might return errors for the files removed in the meantime; calling Refresh
+// solves these as well. All write operations return common.ErrReadOnlyPersister and Destroy never removes any file.
+type ReadReplica struct {
+	*baseLevelDb
+}
+
+// NewReadReplica opens the leveldb database located at the provided path in read only mode, without acquiring
+// the lock held by the writer
+func NewReadReplica(path string) (*ReadReplica, error) {
+	db, err := openReadReplica(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReadReplica{
+		baseLevelDb: &baseLevelDb{
+			path: path,
+			db:   db,
+		},
+	}, nil
+}
+
+func openReadReplica(path string) (*leveldb.DB, error) {
+	options := &opt.Options{
+		ReadOnly:       true,
+		ErrorIfMissing: true,
+	}
+
+	return leveldb.Open(&readOnlyStorage{path: path}, options)
+}
+
+// Refresh reopens the database in order to observe the writes done since the replica was opened or last refreshed.
+// A closed replica stays closed, common.ErrDBIsClosed being returned
+func (rr *ReadReplica) Refresh() error {
+	if rr.getDbPointer() == nil {
+		return common.ErrDBIsClosed
+	}
+
+	db, err := openReadReplica(rr.path)
+	if err != nil {
+		return err
+	}
+
+	rr.mutDb.Lock()
+	oldDb := rr.db
+	if oldDb == nil {
+		// closed while the database was being reopened
+		rr.mutDb.Unlock()
+		_ = db.Close()
+
+		return common.ErrDBIsClosed
+	}
+	rr.db = db
+	rr.mutDb.Unlock()
+
+	return oldDb.Close()
+}
+
+// Put returns common.ErrReadOnlyPersister
+func (rr *ReadReplica) Put(key, _ []byte) error {
+	return common.NewStorageError(common.OpPut, key, readReplicaBackendName, common.ErrReadOnlyPersister)
+}
+
+// Get returns the value associated to the key
+func (rr *ReadReplica) Get(key []byte) ([]byte, error) {
+	db := rr.getDbPointer()
+	if db == nil {
+		return nil, common.NewStorageError(common.OpGet, key, readReplicaBackendName, common.ErrDBIsClosed)
+	}
+
+	data, err := db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, common.NewStorageError(common.OpGet, key, readReplicaBackendName, common.ErrKeyNotFound)
+	}
+	if err != nil {
+		return nil, common.NewStorageError(common.OpGet, key, readReplicaBackendName, err)
+	}
+
+	return data, nil
+}
+
+// Has returns nil if the given key is present in the replica
+func (rr *ReadReplica) Has(key []byte) error {
+	db := rr.getDbPointer()
+	if db == nil {
+		return common.NewStorageError(common.OpHas, key, readReplicaBackendName, common.ErrDBIsClosed)
+	}
+
+	has, err := db.Has(key, nil)
+	if err != nil {
+		return common.NewStorageError(common.OpHas, key, readReplicaBackendName, err)
+	}
+	if has {
+		return nil
+	}
+
+	return common.NewStorageError(common.OpHas, key, readReplicaBackendName, common.ErrKeyNotFound)
+}
+
+// Remove returns common.ErrReadOnlyPersister
+func (rr *ReadReplica) Remove(key []byte) error {
+	return common.NewStorageError(common.OpRemove, key, readReplicaBackendName, common.ErrReadOnlyPersister)
+}
+
+// Close closes the replica, the database files are left untouched
+func (rr *ReadReplica) Close() error {
+	rr.mutDb.Lock()
+	db := rr.db
+	rr.db = nil
+	rr.mutDb.Unlock()
+
+	if db != nil {
+		return db.Close()
+	}
+
+	return nil
+}
+
+// Destroy only closes the replica, as the database files belong to the writer
+func (rr *ReadReplica) Destroy() error {
+	return rr.Close()
+}
+
+// DestroyClosed does nothing, as the database files belong to the writer
+func (rr *ReadReplica) DestroyClosed() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (rr *ReadReplica) IsInterfaceNil() bool {
+	return rr == nil
+}
@@ -0,0 +1,130 @@
+package leveldb_test
+
+import (
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReadReplica(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing database should error", func(t *testing.T) {
+		t.Parallel()
+
+		replica, err := leveldb.NewReadReplica(t.TempDir())
+		require.NotNil(t, err)
+		require.True(t, check.IfNil(replica))
+	})
+	t.Run("should open a database locked by the writer", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writer, err := leveldb.NewDB(dir, 10, 1, 10)
+		require.Nil(t, err)
+		defer func() {
+			_ = writer.Close()
+		}()
+
+		require.Nil(t, writer.Put([]byte("key"), []byte("value")))
+
+		replica, err := leveldb.NewReadReplica(dir)
+		require.Nil(t, err)
+		require.False(t, check.IfNil(replica))
+		defer func() {
+			_ = replica.Close()
+		}()
+
+		val, err := replica.Get([]byte("key"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("value"), val)
+		require.Nil(t, replica.Has([]byte("key")))
+	})
+}
+
+func TestReadReplica_RefreshShouldObserveNewWrites(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer, err := leveldb.NewDB(dir, 10, 1, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = writer.Close()
+	}()
+	require.Nil(t, writer.Put([]byte("key1"), []byte("value1")))
+
+	replica, err := leveldb.NewReadReplica(dir)
+	require.Nil(t, err)
+	defer func() {
+		_ = replica.Close()
+	}()
+
+	require.Nil(t, writer.Put([]byte("key2"), []byte("value2")))
+	require.Nil(t, writer.Remove([]byte("key1")))
+
+	_, err = replica.Get([]byte("key2"))
+	require.ErrorIs(t, err, common.ErrKeyNotFound)
+	require.Nil(t, replica.Has([]byte("key1")))
+
+	require.Nil(t, replica.Refresh())
+
+	val, err := replica.Get([]byte("key2"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value2"), val)
+	require.ErrorIs(t, replica.Has([]byte("key1")), common.ErrKeyNotFound)
+
+	count, err := replica.CountPrefix([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, uint64(1), count)
+}
+
+func TestReadReplica_RefreshAfterCloseShouldError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer, err := leveldb.NewDB(dir, 10, 1, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = writer.Close()
+	}()
+	require.Nil(t, writer.Put([]byte("key"), []byte("value")))
+
+	replica, err := leveldb.NewReadReplica(dir)
+	require.Nil(t, err)
+	require.Nil(t, replica.Close())
+
+	require.ErrorIs(t, replica.Refresh(), common.ErrDBIsClosed)
+	_, err = replica.Get([]byte("key"))
+	require.ErrorIs(t, err, common.ErrDBIsClosed)
+}
+
+func TestReadReplica_WritesShouldError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer, err := leveldb.NewDB(dir, 10, 1, 10)
+	require.Nil(t, err)
+	require.Nil(t, writer.Put([]byte("key"), []byte("value")))
+	require.Nil(t, writer.Close())
+
+	replica, err := leveldb.NewReadReplica(dir)
+	require.Nil(t, err)
+
+	require.ErrorIs(t, replica.Put([]byte("key"), []byte("other")), common.ErrReadOnlyPersister)
+	require.ErrorIs(t, replica.Remove([]byte("key")), common.ErrReadOnlyPersister)
+
+	require.Nil(t, replica.Destroy())
+	_, err = replica.Get([]byte("key"))
+	require.ErrorIs(t, err, common.ErrDBIsClosed)
+
+	// the database files are left untouched
+	writer, err = leveldb.NewDB(dir, 10, 1, 10)
+	require.Nil(t, err)
+	val, err := writer.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), val)
+	require.Nil(t, writer.Close())
+}
@@ -2,7 +2,7 @@ package leveldb
 
 import (
 	"github.com/DharitriOne/drt-chain-storage-go/common"
-	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 type putBatchAct struct {
@@ -10,6 +10,10 @@ type putBatchAct struct {
 	resChan chan<- error
 }
 
+type truncateAct struct {
+	resChan chan<- error
+}
+
 type pairResult struct {
 	value []byte
 	err   error
@@ -24,6 +28,16 @@ type getAct struct {
 	resChan chan<- *pairResult
 }
 
+type multiGetResult struct {
+	values map[string][]byte
+	err    error
+}
+
+type multiGetAct struct {
+	keys    [][]byte
+	resChan chan<- *multiGetResult
+}
+
 type hasAct struct {
 	key     []byte
 	resChan chan<- error
@@ -39,11 +53,7 @@ func (p *putBatchAct) doPutRequest(s *SerialDB) error {
 		return common.ErrDBIsClosed
 	}
 
-	wopt := &opt.WriteOptions{
-		Sync: true,
-	}
-
-	return db.Write(p.batch.batch, wopt)
+	return db.Write(p.batch.batch, s.writeOptions())
 }
 
 func (g *getAct) request(s *SerialDB) {
@@ -65,6 +75,37 @@ func (g *getAct) doGetRequest(s *SerialDB) ([]byte, error) {
 	return db.Get(g.key, nil)
 }
 
+func (m *multiGetAct) request(s *SerialDB) {
+	values, err := m.doMultiGetRequest(s)
+
+	m.resChan <- &multiGetResult{
+		values: values,
+		err:    err,
+	}
+}
+
+func (m *multiGetAct) doMultiGetRequest(s *SerialDB) (map[string][]byte, error) {
+	db := s.getDbPointer()
+	if db == nil {
+		return nil, common.ErrDBIsClosed
+	}
+
+	values := make(map[string][]byte, len(m.keys))
+	for _, key := range m.keys {
+		data, err := db.Get(key, nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values[string(key)] = data
+	}
+
+	return values, nil
+}
+
 func (h *hasAct) request(s *SerialDB) {
 	has, err := h.doHasRequest(s)
 
@@ -89,3 +130,7 @@ func (h *hasAct) doHasRequest(s *SerialDB) (bool, error) {
 
 	return db.Has(h.key, nil)
 }
+
+func (t *truncateAct) request(s *SerialDB) {
+	t.resChan <- s.removeAllKeys()
+}
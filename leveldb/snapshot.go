@@ -0,0 +1,92 @@
+package leveldb
+
+import (
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+var _ types.Snapshot = (*snapshot)(nil)
+
+// snapshot wraps a leveldb snapshot handle, seeing the database as it was at the snapshot creation
+type snapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func newSnapshot(db *leveldb.DB) (*snapshot, error) {
+	if db == nil {
+		return nil, common.ErrDBIsClosed
+	}
+
+	snap, err := db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot{
+		snap: snap,
+	}, nil
+}
+
+// Get returns the value associated to the key, as it was at the snapshot creation
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	data, err := s.snap.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, common.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Has returns nil if the given key was present at the snapshot creation
+func (s *snapshot) Has(key []byte) error {
+	has, err := s.snap.Has(key, nil)
+	if err != nil {
+		return err
+	}
+
+	if has {
+		return nil
+	}
+
+	return common.ErrKeyNotFound
+}
+
+// RangeKeys will call the handler function for each (key, value) pair contained at the snapshot creation
+// If the handler returns true, the iteration will continue, otherwise will stop
+func (s *snapshot) RangeKeys(handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	iterator := s.snap.NewIterator(nil, nil)
+	for iterator.Next() {
+		key := iterator.Key()
+		clonedKey := make([]byte, len(key))
+		copy(clonedKey, key)
+
+		val := iterator.Value()
+		clonedVal := make([]byte, len(val))
+		copy(clonedVal, val)
+
+		shouldContinue := handler(clonedKey, clonedVal)
+		if !shouldContinue {
+			break
+		}
+	}
+
+	iterator.Release()
+}
+
+// Release releases the snapshot handle. The snapshot should not be used afterwards
+func (s *snapshot) Release() {
+	s.snap.Release()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (s *snapshot) IsInterfaceNil() bool {
+	return s == nil
+}
@@ -0,0 +1,147 @@
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+const swapBackupSuffix = ".swap-backup"
+
+// ErrInvalidSwapDirectory signals that the directory to swap in is not a directory distinct from the database one
+var ErrInvalidSwapDirectory = errors.New("invalid swap directory")
+
+// ErrSwapBackupExists signals that the backup directory of a previous swap still exists, as after a crash during
+// the swap, and has to be inspected before swapping again
+var ErrSwapBackupExists = errors.New("swap backup directory already exists")
+
+// SwapDirectory replaces the database with the one stored in newPath, as built in a staging directory. The pending
+// batch is written in the current database, which is then closed and moved aside, newPath is renamed to the
+// database path and the database is reopened from it. Each rename is atomic, the database path only being missing
+// between them. The operations are blocked during the swap. On failure the original database is moved back and
+// reopened, newPath being restored as well. On success the original database is removed
+func (s *DB) SwapDirectory(newPath string) error {
+	err := s.checkSwapDirectory(newPath)
+	if err != nil {
+		return err
+	}
+
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	err = s.putBatch(s.batch)
+	if err != nil {
+		return err
+	}
+	s.batch.Reset()
+	s.sizeBatch = 0
+
+	options := s.levelDBOptions
+	if s.bulkLoadMode {
+		options = createBulkLoadOptions(s.levelDBOptions)
+	}
+
+	err = s.swapDirectory(newPath, options)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("leveldb directory swapped", "path", s.path, "swapped in", newPath)
+
+	return nil
+}
+
+func (s *DB) checkSwapDirectory(newPath string) error {
+	info, err := os.Stat(newPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSwapDirectory, err.Error())
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrInvalidSwapDirectory, newPath)
+	}
+	if filepath.Clean(newPath) == filepath.Clean(s.path) {
+		return fmt.Errorf("%w: %s is the database directory", ErrInvalidSwapDirectory, newPath)
+	}
+
+	_, err = os.Stat(s.path + swapBackupSuffix)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrSwapBackupExists, s.path+swapBackupSuffix)
+	}
+
+	return nil
+}
+
+func (s *DB) swapDirectory(newPath string, options *opt.Options) error {
+	s.mutDb.Lock()
+	defer s.mutDb.Unlock()
+
+	if s.db == nil {
+		return common.ErrDBIsClosed
+	}
+
+	err := s.db.Close()
+	if err != nil {
+		return err
+	}
+
+	backupPath := s.path + swapBackupSuffix
+	err = os.Rename(s.path, backupPath)
+	if err != nil {
+		return s.rollbackSwap(err, options, "")
+	}
+
+	err = os.Rename(newPath, s.path)
+	if err != nil {
+		return s.rollbackSwap(err, options, backupPath)
+	}
+
+	db, err := openLevelDB(s.path, options)
+	if err != nil {
+		errRestore := os.Rename(s.path, newPath)
+		if errRestore != nil {
+			log.Error("leveldb swap could not restore the swapped directory", "path", newPath, "error", errRestore)
+		}
+
+		return s.rollbackSwap(err, options, backupPath)
+	}
+	s.db = db
+
+	err = os.RemoveAll(backupPath)
+	if err != nil {
+		log.Warn("leveldb swap could not remove the original database", "path", backupPath, "error", err)
+	}
+
+	return nil
+}
+
+// rollbackSwap moves the original database back from the backup path, if not empty, and reopens it. It returns
+// the error that caused the rollback, along with the rollback error if any, in which case the database is closed
+func (s *DB) rollbackSwap(cause error, options *opt.Options, backupPath string) error {
+	if len(backupPath) > 0 {
+		err := os.Rename(backupPath, s.path)
+		if err != nil {
+			s.markClosedAfterFailedSwap()
+			return fmt.Errorf("%w, rollback failed moving back %s: %s", cause, backupPath, err.Error())
+		}
+	}
+
+	db, err := openLevelDB(s.path, options)
+	if err != nil {
+		s.markClosedAfterFailedSwap()
+		return fmt.Errorf("%w, rollback failed reopening %s: %s", cause, s.path, err.Error())
+	}
+	s.db = db
+
+	return cause
+}
+
+func (s *DB) markClosedAfterFailedSwap() {
+	crtCounter := atomic.AddUint32(&loggingDBCounter, ^uint32(0)) // subtract 1
+	log.Error("leveldb swap rollback failed, the database is closed", "path", s.path, "global db counter", crtCounter)
+	s.db = nil
+}
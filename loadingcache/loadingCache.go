@@ -0,0 +1,323 @@
+package loadingcache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*loadingCache)(nil)
+
+var log = logger.GetOrCreate("storage/loadingcache")
+
+// ErrNilLoader signals that a nil loader function was provided
+var ErrNilLoader = errors.New("nil loader")
+
+// ErrLoaderPanicked signals that the loader panicked while loading a key
+var ErrLoaderPanicked = errors.New("loader panicked")
+
+// Option configures a loading cache
+type Option func(c *loadingCache)
+
+// WithNegativeCaching makes the cache remember the loader errors for the provided duration, the misses of the
+// key being answered with the same error until it expires, without calling the loader again
+func WithNegativeCaching(ttl time.Duration) Option {
+	return func(c *loadingCache) {
+		c.negativeTTL = ttl
+	}
+}
+
+type loadCall struct {
+	wg          sync.WaitGroup
+	value       []byte
+	err         error
+	invalidated bool
+}
+
+type negativeEntry struct {
+	err    error
+	expiry time.Time
+}
+
+// loadingCache is an LRU cache populating itself on a miss from the provided loader. The concurrent misses of the
+// same key share a single loader call. A Put, Remove or Clear done while a key is being loaded takes precedence:
+// the loaded value is still returned to the waiting callers, but it is not cached over the newer write
+type loadingCache struct {
+	cache       types.Cacher
+	negatives   types.Cacher
+	negativeTTL time.Duration
+	loader      func(key []byte) ([]byte, error)
+
+	mutCalls sync.Mutex
+	calls    map[string]*loadCall
+}
+
+// NewLoadingCache creates a loading cache holding at most the provided number of elements, the missing values
+// being fetched through the provided loader. The loader errors are not cached, unless WithNegativeCaching is used
+func NewLoadingCache(capacity int, loader func(key []byte) ([]byte, error), options ...Option) (*loadingCache, error) {
+	if capacity < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+	if loader == nil {
+		return nil, ErrNilLoader
+	}
+
+	cache, err := lrucache.NewCache(capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &loadingCache{
+		cache:  cache,
+		loader: loader,
+		calls:  make(map[string]*loadCall),
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.negativeTTL > 0 {
+		c.negatives, err = lrucache.NewCache(capacity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// GetOrLoad returns the cached value of the key, loading it on a miss. The loader error is returned as is
+func (c *loadingCache) GetOrLoad(key []byte) (interface{}, error) {
+	value, ok := c.cache.Get(key)
+	if ok {
+		return value, nil
+	}
+
+	err := c.getNegative(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.load(key)
+}
+
+func (c *loadingCache) load(key []byte) (interface{}, error) {
+	c.mutCalls.Lock()
+	call, inFlight := c.calls[string(key)]
+	if inFlight {
+		c.mutCalls.Unlock()
+		call.wg.Wait()
+
+		return call.result()
+	}
+
+	// the value might have been loaded or written since the first lookup
+	value, ok := c.cache.Peek(key)
+	if ok {
+		c.mutCalls.Unlock()
+		return value, nil
+	}
+
+	call = &loadCall{}
+	call.wg.Add(1)
+	c.calls[string(key)] = call
+	c.mutCalls.Unlock()
+
+	c.callLoader(key, call)
+
+	return call.result()
+}
+
+// callLoader runs the loader and completes the call even if the loader panics, the panic being returned as an
+// error to the caller and to the waiting callers, so the later loads of the key are not blocked
+func (c *loadingCache) callLoader(key []byte, call *loadCall) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			log.Error("loading cache loader panicked", "key", key, "panic", r)
+			call.value = nil
+			call.err = fmt.Errorf("%w: %v", ErrLoaderPanicked, r)
+		}
+
+		c.mutCalls.Lock()
+		if !call.invalidated {
+			c.storeLoaded(key, call)
+		}
+		delete(c.calls, string(key))
+		c.mutCalls.Unlock()
+
+		call.wg.Done()
+	}()
+
+	call.value, call.err = c.loader(key)
+}
+
+func (call *loadCall) result() (interface{}, error) {
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	return call.value, nil
+}
+
+func (c *loadingCache) storeLoaded(key []byte, call *loadCall) {
+	if call.err == nil {
+		c.cache.Put(key, call.value, len(call.value))
+		return
+	}
+
+	log.Trace("loading cache loader error", "key", key, "error", call.err)
+	if c.negatives != nil {
+		c.negatives.Put(key, &negativeEntry{
+			err:    call.err,
+			expiry: time.Now().Add(c.negativeTTL),
+		}, 0)
+	}
+}
+
+func (c *loadingCache) getNegative(key []byte) error {
+	if c.negatives == nil {
+		return nil
+	}
+
+	value, ok := c.negatives.Get(key)
+	if !ok {
+		return nil
+	}
+
+	entry := value.(*negativeEntry)
+	if time.Now().After(entry.expiry) {
+		c.negatives.Remove(key)
+		return nil
+	}
+
+	return entry.err
+}
+
+// invalidate drops the negative entry of the key and prevents the ongoing load, if any, from being cached
+func (c *loadingCache) invalidate(key []byte) {
+	call, inFlight := c.calls[string(key)]
+	if inFlight {
+		call.invalidated = true
+	}
+
+	if c.negatives != nil {
+		c.negatives.Remove(key)
+	}
+}
+
+// Clear is used to completely clear the cache.
+func (c *loadingCache) Clear() {
+	c.mutCalls.Lock()
+	defer c.mutCalls.Unlock()
+
+	for _, call := range c.calls {
+		call.invalidated = true
+	}
+	if c.negatives != nil {
+		c.negatives.Clear()
+	}
+	c.cache.Clear()
+}
+
+// Put adds a value to the cache. Returns true if an eviction occurred.
+func (c *loadingCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	c.mutCalls.Lock()
+	defer c.mutCalls.Unlock()
+
+	c.invalidate(key)
+
+	return c.cache.Put(key, value, sizeInBytes)
+}
+
+// Get looks up a key's value from the cache, loading it on a miss. A loader error is reported as a miss,
+// GetOrLoad should be used to get the error.
+func (c *loadingCache) Get(key []byte) (value interface{}, ok bool) {
+	value, err := c.GetOrLoad(key)
+	if err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// Has checks if a key is in the cache, without loading it, updating the
+// recent-ness or deleting it for being stale.
+func (c *loadingCache) Has(key []byte) bool {
+	return c.cache.Has(key)
+}
+
+// Peek returns the key value (or undefined if not found) without loading it or updating
+// the "recently used"-ness of the key.
+func (c *loadingCache) Peek(key []byte) (value interface{}, ok bool) {
+	return c.cache.Peek(key)
+}
+
+// HasOrAdd checks if a key is in the cache without loading it, updating the
+// recent-ness or deleting it for being stale, and if not adds the value.
+func (c *loadingCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	c.mutCalls.Lock()
+	defer c.mutCalls.Unlock()
+
+	has, added = c.cache.HasOrAdd(key, value, sizeInBytes)
+	if added {
+		c.invalidate(key)
+	}
+
+	return has, added
+}
+
+// Remove removes the provided key from the cache.
+func (c *loadingCache) Remove(key []byte) {
+	c.mutCalls.Lock()
+	defer c.mutCalls.Unlock()
+
+	c.invalidate(key)
+	c.cache.Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *loadingCache) Keys() [][]byte {
+	return c.cache.Keys()
+}
+
+// Len returns the number of items in the cache.
+func (c *loadingCache) Len() int {
+	return c.cache.Len()
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (c *loadingCache) SizeInBytesContained() uint64 {
+	return c.cache.SizeInBytesContained()
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (c *loadingCache) MaxSize() int {
+	return c.cache.MaxSize()
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added, including the loaded data
+func (c *loadingCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	c.cache.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandler removes the handler from the list
+func (c *loadingCache) UnRegisterHandler(id string) {
+	c.cache.UnRegisterHandler(id)
+}
+
+// Close closes the underlying cache
+func (c *loadingCache) Close() error {
+	return c.cache.Close()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *loadingCache) IsInterfaceNil() bool {
+	return c == nil
+}
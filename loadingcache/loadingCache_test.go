@@ -0,0 +1,239 @@
+package loadingcache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/loadingcache"
+	"github.com/stretchr/testify/require"
+)
+
+var errLoad = errors.New("load error")
+
+func TestNewLoadingCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil loader should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := loadingcache.NewLoadingCache(10, nil)
+		require.True(t, check.IfNil(c))
+		require.Equal(t, loadingcache.ErrNilLoader, err)
+	})
+	t.Run("invalid capacity should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := loadingcache.NewLoadingCache(0, func(key []byte) ([]byte, error) {
+			return key, nil
+		})
+		require.True(t, check.IfNil(c))
+		require.Equal(t, common.ErrCacheSizeInvalid, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := loadingcache.NewLoadingCache(10, func(key []byte) ([]byte, error) {
+			return key, nil
+		})
+		require.False(t, check.IfNil(c))
+		require.Nil(t, err)
+	})
+}
+
+func TestLoadingCache_GetShouldLoadTheMissingValues(t *testing.T) {
+	t.Parallel()
+
+	numLoads := uint32(0)
+	c, _ := loadingcache.NewLoadingCache(10, func(key []byte) ([]byte, error) {
+		atomic.AddUint32(&numLoads, 1)
+		return append([]byte("value-"), key...), nil
+	})
+
+	require.False(t, c.Has([]byte("key")))
+	_, ok := c.Peek([]byte("key"))
+	require.False(t, ok)
+
+	value, ok := c.Get([]byte("key"))
+	require.True(t, ok)
+	require.Equal(t, []byte("value-key"), value)
+	require.True(t, c.Has([]byte("key")))
+
+	value, ok = c.Get([]byte("key"))
+	require.True(t, ok)
+	require.Equal(t, []byte("value-key"), value)
+	require.Equal(t, uint32(1), atomic.LoadUint32(&numLoads))
+
+	c.Put([]byte("put"), "put value", 9)
+	value, err := c.GetOrLoad([]byte("put"))
+	require.Nil(t, err)
+	require.Equal(t, "put value", value)
+	require.Equal(t, uint32(1), atomic.LoadUint32(&numLoads))
+}
+
+func TestLoadingCache_LoaderErrorsShouldNotBeCached(t *testing.T) {
+	t.Parallel()
+
+	numLoads := uint32(0)
+	c, _ := loadingcache.NewLoadingCache(10, func(key []byte) ([]byte, error) {
+		atomic.AddUint32(&numLoads, 1)
+		return nil, errLoad
+	})
+
+	value, ok := c.Get([]byte("key"))
+	require.False(t, ok)
+	require.Nil(t, value)
+
+	value, err := c.GetOrLoad([]byte("key"))
+	require.Equal(t, errLoad, err)
+	require.Nil(t, value)
+	require.Equal(t, uint32(2), atomic.LoadUint32(&numLoads))
+	require.Equal(t, 0, c.Len())
+}
+
+func TestLoadingCache_NegativeCaching(t *testing.T) {
+	t.Parallel()
+
+	numLoads := uint32(0)
+	c, _ := loadingcache.NewLoadingCache(10, func(key []byte) ([]byte, error) {
+		atomic.AddUint32(&numLoads, 1)
+		return nil, errLoad
+	}, loadingcache.WithNegativeCaching(time.Millisecond*100))
+
+	_, err := c.GetOrLoad([]byte("key"))
+	require.Equal(t, errLoad, err)
+	_, err = c.GetOrLoad([]byte("key"))
+	require.Equal(t, errLoad, err)
+	require.Equal(t, uint32(1), atomic.LoadUint32(&numLoads))
+
+	c.Put([]byte("key"), []byte("value"), 5)
+	value, err := c.GetOrLoad([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), value)
+
+	c.Remove([]byte("key"))
+	_, err = c.GetOrLoad([]byte("key"))
+	require.Equal(t, errLoad, err)
+	require.Equal(t, uint32(2), atomic.LoadUint32(&numLoads))
+
+	time.Sleep(time.Millisecond * 150)
+	_, err = c.GetOrLoad([]byte("key"))
+	require.Equal(t, errLoad, err)
+	require.Equal(t, uint32(3), atomic.LoadUint32(&numLoads))
+}
+
+func TestLoadingCache_ConcurrentMissesShouldLoadOnce(t *testing.T) {
+	t.Parallel()
+
+	numLoads := uint32(0)
+	chRelease := make(chan struct{})
+	c, _ := loadingcache.NewLoadingCache(10, func(key []byte) ([]byte, error) {
+		atomic.AddUint32(&numLoads, 1)
+		<-chRelease
+		return []byte("value"), nil
+	})
+
+	numGets := 20
+	wg := sync.WaitGroup{}
+	wg.Add(numGets)
+	for i := 0; i < numGets; i++ {
+		go func() {
+			defer wg.Done()
+
+			value, ok := c.Get([]byte("key"))
+			require.True(t, ok)
+			require.Equal(t, []byte("value"), value)
+		}()
+	}
+
+	time.Sleep(time.Millisecond * 50)
+	close(chRelease)
+	wg.Wait()
+
+	require.Equal(t, uint32(1), atomic.LoadUint32(&numLoads))
+}
+
+func TestLoadingCache_WriteDuringLoadShouldTakePrecedence(t *testing.T) {
+	t.Parallel()
+
+	chLoading := make(chan struct{})
+	chRelease := make(chan struct{})
+	c, _ := loadingcache.NewLoadingCache(10, func(key []byte) ([]byte, error) {
+		close(chLoading)
+		<-chRelease
+		return []byte("loaded"), nil
+	})
+
+	chDone := make(chan struct{})
+	go func() {
+		value, ok := c.Get([]byte("key"))
+		require.True(t, ok)
+		require.Equal(t, []byte("loaded"), value)
+		close(chDone)
+	}()
+
+	<-chLoading
+	c.Put([]byte("key"), []byte("written"), 7)
+	close(chRelease)
+	<-chDone
+
+	value, ok := c.Peek([]byte("key"))
+	require.True(t, ok)
+	require.Equal(t, []byte("written"), value)
+}
+
+func TestLoadingCache_PanickingLoaderShouldNotBlockTheLaterLoads(t *testing.T) {
+	t.Parallel()
+
+	panicking := uint32(1)
+	numLoads := uint32(0)
+	chLoading := make(chan struct{})
+	chRelease := make(chan struct{})
+	c, _ := loadingcache.NewLoadingCache(10, func(key []byte) ([]byte, error) {
+		if atomic.AddUint32(&numLoads, 1) == 1 {
+			close(chLoading)
+			<-chRelease
+		}
+		if atomic.LoadUint32(&panicking) == 1 {
+			panic("loader failure")
+		}
+		return []byte("value"), nil
+	})
+
+	errs := make(chan error, 2)
+	getOrLoad := func() {
+		_, err := c.GetOrLoad([]byte("key"))
+		errs <- err
+	}
+
+	go getOrLoad()
+	<-chLoading
+	// the second caller either waits for the panicking load or, if it arrives after it, panics on its own load
+	go getOrLoad()
+	close(chRelease)
+
+	require.True(t, errors.Is(<-errs, loadingcache.ErrLoaderPanicked))
+	require.True(t, errors.Is(<-errs, loadingcache.ErrLoaderPanicked))
+	require.Equal(t, 0, c.Len())
+
+	atomic.StoreUint32(&panicking, 0)
+	numLoadsBefore := atomic.LoadUint32(&numLoads)
+	chDone := make(chan struct{})
+	go func() {
+		value, err := c.GetOrLoad([]byte("key"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("value"), value)
+		close(chDone)
+	}()
+
+	select {
+	case <-chDone:
+	case <-time.After(time.Second):
+		require.Fail(t, "the load after the panic should not block")
+	}
+	require.Equal(t, numLoadsBefore+1, atomic.LoadUint32(&numLoads))
+}
@@ -17,6 +17,10 @@ type capacityLRU struct {
 	size                   int
 	maxCapacityInBytes     int64
 	currentCapacityInBytes int64
+	accountOverhead        bool
+	perEntryOverhead       int64
+	accountedOverhead      int64
+	maxEntrySizeInBytes    int64
 	//TODO investigate if we can replace this list with a binary tree. Check also the other implementation lruCache
 	evictList *list.List
 	items     map[interface{}]*list.Element
@@ -24,13 +28,25 @@ type capacityLRU struct {
 
 // entry is used to hold a value in the evictList
 type entry struct {
-	key   interface{}
-	value interface{}
-	size  int64
+	key      interface{}
+	value    interface{}
+	size     int64
+	overhead int64
+}
+
+// Option defines an optional setting of the CapacityLRU
+type Option func(c *capacityLRU)
+
+// WithMaxEntrySizeInBytes makes the cache skip the values larger than the provided size, so a single large value
+// can not evict all the other entries. A value lower than 1 disables the limit
+func WithMaxEntrySizeInBytes(maxEntrySizeInBytes int64) Option {
+	return func(c *capacityLRU) {
+		c.maxEntrySizeInBytes = maxEntrySizeInBytes
+	}
 }
 
 // NewCapacityLRU constructs an CapacityLRU of the given size with a byte size capacity
-func NewCapacityLRU(size int, byteCapacity int64) (*capacityLRU, error) {
+func NewCapacityLRU(size int, byteCapacity int64, options ...Option) (*capacityLRU, error) {
 	if size < 1 {
 		return nil, common.ErrCacheSizeInvalid
 	}
@@ -43,6 +59,28 @@ func NewCapacityLRU(size int, byteCapacity int64) (*capacityLRU, error) {
 		evictList:          list.New(),
 		items:              make(map[interface{}]*list.Element),
 	}
+	for _, option := range options {
+		option(c)
+	}
+
+	return c, nil
+}
+
+// NewCapacityLRUWithOverhead constructs an CapacityLRU of the given size with a byte size capacity that also
+// accounts, for each entry, the key length and the provided per entry overhead in its byte budget
+func NewCapacityLRUWithOverhead(size int, byteCapacity int64, perEntryOverheadBytes int, options ...Option) (*capacityLRU, error) {
+	if perEntryOverheadBytes < 0 {
+		return nil, common.ErrInvalidPerEntryOverhead
+	}
+
+	c, err := NewCapacityLRU(size, byteCapacity, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.accountOverhead = true
+	c.perEntryOverhead = int64(perEntryOverheadBytes)
+
 	return c, nil
 }
 
@@ -54,6 +92,7 @@ func (c *capacityLRU) Purge() {
 	c.items = make(map[interface{}]*list.Element)
 	c.evictList.Init()
 	c.currentCapacityInBytes = 0
+	c.accountedOverhead = 0
 }
 
 // AddSized adds a value to the cache.  Returns true if an eviction occurred.
@@ -76,15 +115,41 @@ func (c *capacityLRU) addSized(key interface{}, value interface{}, sizeInBytes i
 
 		return
 	}
+	if c.isOversized(sizeInBytes) {
+		// the previously cached value of the key would be stale
+		c.removeKey(key)
+		return
+	}
 
 	// Check for existing item
 	if ent, ok := c.items[key]; ok {
-		c.update(key, value, sizeInBytes, ent)
+		c.update(value, sizeInBytes, ent)
 	} else {
 		c.addNew(key, value, sizeInBytes)
 	}
 }
 
+// BatchEntry is a (key, value) pair added by AddSizedBatch
+type BatchEntry struct {
+	Key         interface{}
+	Value       interface{}
+	SizeInBytes int64
+}
+
+// AddSizedBatch adds all the provided entries under a single lock acquisition, in order, and runs the eviction
+// once after the last one, so the cache is brought back within its limits only at the end of the batch.
+// Returns true if an eviction occurred.
+func (c *capacityLRU) AddSizedBatch(entries []BatchEntry) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, e := range entries {
+		c.addSized(e.Key, e.Value, e.SizeInBytes)
+	}
+
+	return c.evictIfNeeded()
+}
+
 // AddSizedAndReturnEvicted adds the given key-value pair to the cache, and returns the evicted values
 func (c *capacityLRU) AddSizedAndReturnEvicted(key, value interface{}, sizeInBytes int64) map[interface{}]interface{} {
 	c.lock.Lock()
@@ -113,25 +178,51 @@ func (c *capacityLRU) AddSizedAndReturnEvicted(key, value interface{}, sizeInByt
 
 func (c *capacityLRU) addNew(key interface{}, value interface{}, sizeInBytes int64) {
 	ent := &entry{
-		key:   key,
-		value: value,
-		size:  sizeInBytes,
+		key:      key,
+		value:    value,
+		size:     sizeInBytes,
+		overhead: c.computeOverhead(key),
 	}
 	e := c.evictList.PushFront(ent)
 	c.items[key] = e
-	c.currentCapacityInBytes += sizeInBytes
+	c.currentCapacityInBytes += sizeInBytes + ent.overhead
+	c.accountedOverhead += ent.overhead
+}
+
+func (c *capacityLRU) isOversized(sizeInBytes int64) bool {
+	return c.maxEntrySizeInBytes > 0 && sizeInBytes > c.maxEntrySizeInBytes
 }
 
-func (c *capacityLRU) update(key interface{}, value interface{}, sizeInBytes int64, ent *list.Element) {
+func (c *capacityLRU) removeKey(key interface{}) {
+	ent, ok := c.items[key]
+	if ok {
+		c.removeElement(ent)
+	}
+}
+
+func (c *capacityLRU) computeOverhead(key interface{}) int64 {
+	if !c.accountOverhead {
+		return 0
+	}
+
+	keyString, ok := key.(string)
+	if !ok {
+		return c.perEntryOverhead
+	}
+
+	return int64(len(keyString)) + c.perEntryOverhead
+}
+
+// update replaces the value and the size of an existing entry, subtracting the old size and adding the new one
+// in the same critical section. The eviction is left to the callers so the evicted values can be reported
+func (c *capacityLRU) update(value interface{}, sizeInBytes int64, ent *list.Element) {
 	c.evictList.MoveToFront(ent)
 
 	e := ent.Value.(*entry)
-	sizeDiff := sizeInBytes - e.size
+	c.currentCapacityInBytes -= e.size
+	c.currentCapacityInBytes += sizeInBytes
 	e.value = value
 	e.size = sizeInBytes
-	c.currentCapacityInBytes += sizeDiff
-
-	c.adjustSize(key, sizeInBytes)
 }
 
 // Get looks up a key's value from the cache.
@@ -151,6 +242,26 @@ func (c *capacityLRU) Get(key interface{}) (interface{}, bool) {
 	return nil, false
 }
 
+// GetBatch looks up all the provided keys under a single lock acquisition, marking each found key as the most
+// recently used one, in order. Returns the values of the found keys only
+func (c *capacityLRU) GetBatch(keys []interface{}) map[interface{}]interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	found := make(map[interface{}]interface{})
+	for _, key := range keys {
+		ent, ok := c.items[key]
+		if !ok {
+			continue
+		}
+
+		c.evictList.MoveToFront(ent)
+		found[key] = ent.Value.(*entry).value
+	}
+
+	return found
+}
+
 // Contains checks if a key is in the cache, without updating the recent-ness
 // or deleting it for being stale.
 func (c *capacityLRU) Contains(key interface{}) bool {
@@ -183,6 +294,9 @@ func (c *capacityLRU) AddSizedIfMissing(key, value interface{}, sizeInBytes int6
 	if ok {
 		return true, false
 	}
+	if c.isOversized(sizeInBytes) {
+		return false, false
+	}
 	c.addNew(key, value, sizeInBytes)
 	evicted := c.evictIfNeeded()
 
@@ -215,6 +329,22 @@ func (c *capacityLRU) Remove(key interface{}) bool {
 	return false
 }
 
+// Resize changes the maximum number of items of the cache, evicting the oldest ones exceeding it.
+// Returns the number of evicted items
+func (c *capacityLRU) Resize(size int) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.size = size
+	numEvicted := 0
+	for c.evictList.Len() > size {
+		c.removeOldest()
+		numEvicted++
+	}
+
+	return numEvicted
+}
+
 // Keys returns a slice of the keys in the cache, from oldest to newest.
 func (c *capacityLRU) Keys() []interface{} {
 	c.lock.Lock()
@@ -229,6 +359,46 @@ func (c *capacityLRU) Keys() []interface{} {
 	return keys
 }
 
+// EntryInfo describes one cache entry, as reported by Entries
+type EntryInfo struct {
+	Key      interface{}
+	Size     int64
+	Overhead int64
+}
+
+// Entries returns a snapshot of the entries in the cache, from oldest to newest, which is the eviction order
+func (c *capacityLRU) Entries() []EntryInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entries := make([]EntryInfo, 0, len(c.items))
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		kv := ent.Value.(*entry)
+		entries = append(entries, EntryInfo{
+			Key:      kv.key,
+			Size:     kv.size,
+			Overhead: kv.overhead,
+		})
+	}
+
+	return entries
+}
+
+// Range calls the handler for each entry, from oldest to newest, without changing the eviction order. The
+// iteration stops when the handler returns false. The cache lock is held during the whole iteration, so the
+// handler must not access the cache
+func (c *capacityLRU) Range(handler func(key interface{}, value interface{}) bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
+		kv := ent.Value.(*entry)
+		if !handler(kv.key, kv.value) {
+			return
+		}
+	}
+}
+
 // Len returns the number of items in the cache.
 func (c *capacityLRU) Len() int {
 	c.lock.Lock()
@@ -237,7 +407,7 @@ func (c *capacityLRU) Len() int {
 	return c.evictList.Len()
 }
 
-// SizeInBytesContained returns the size in bytes of all contained elements
+// SizeInBytesContained returns the size in bytes of all contained elements, including the accounted overhead
 func (c *capacityLRU) SizeInBytesContained() uint64 {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -245,6 +415,14 @@ func (c *capacityLRU) SizeInBytesContained() uint64 {
 	return uint64(c.currentCapacityInBytes)
 }
 
+// AccountedOverhead returns the bytes accounted for the keys and the per entry overhead of all contained elements
+func (c *capacityLRU) AccountedOverhead() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.accountedOverhead
+}
+
 // removeOldest removes the oldest item from the cache.
 func (c *capacityLRU) removeOldest() {
 	ent := c.evictList.Back()
@@ -258,21 +436,8 @@ func (c *capacityLRU) removeElement(e *list.Element) {
 	c.evictList.Remove(e)
 	kv := e.Value.(*entry)
 	delete(c.items, kv.key)
-	c.currentCapacityInBytes -= kv.size
-}
-
-func (c *capacityLRU) adjustSize(key interface{}, sizeInBytes int64) {
-	element := c.items[key]
-	if element == nil || element.Value == nil || element.Value.(*entry) == nil {
-		return
-	}
-
-	v := element.Value.(*entry)
-	c.currentCapacityInBytes -= v.size
-	v.size = sizeInBytes
-	element.Value = v
-	c.currentCapacityInBytes += sizeInBytes
-	c.evictIfNeeded()
+	c.currentCapacityInBytes -= kv.size + kv.overhead
+	c.accountedOverhead -= kv.overhead
 }
 
 func (c *capacityLRU) shouldEvict() bool {
@@ -298,3 +463,19 @@ func (c *capacityLRU) evictIfNeeded() bool {
 func (c *capacityLRU) IsInterfaceNil() bool {
 	return c == nil
 }
+
+// EvictBytes removes the oldest entries until at least the provided number of bytes, overhead included, is freed
+// or the cache is empty. Returns the number of freed bytes
+func (c *capacityLRU) EvictBytes(numBytes uint64) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	freed := uint64(0)
+	for freed < numBytes && c.evictList.Len() > 0 {
+		before := c.currentCapacityInBytes
+		c.removeOldest()
+		freed += uint64(before - c.currentCapacityInBytes)
+	}
+
+	return freed
+}
@@ -1,6 +1,9 @@
 package capacity
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/DharitriOne/drt-chain-core-go/core/check"
@@ -51,6 +54,34 @@ func TestNewCapacityLRU(t *testing.T) {
 	assert.NotNil(t, cache.items)
 }
 
+func TestNewCapacityLRUWithOverhead(t *testing.T) {
+	t.Parallel()
+
+	t.Run("negative overhead should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := NewCapacityLRUWithOverhead(1, 5, -1)
+		assert.True(t, check.IfNil(cache))
+		assert.Equal(t, common.ErrInvalidPerEntryOverhead, err)
+	})
+	t.Run("invalid capacity should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := NewCapacityLRUWithOverhead(1, 0, 10)
+		assert.True(t, check.IfNil(cache))
+		assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := NewCapacityLRUWithOverhead(1, 5, 10)
+		assert.False(t, check.IfNil(cache))
+		assert.Nil(t, err)
+		assert.True(t, cache.accountOverhead)
+		assert.Equal(t, int64(10), cache.perEntryOverhead)
+	})
+}
+
 //------- AddSized
 
 func TestCapacityLRUCache_AddSizedNegativeSizeInBytesShouldReturn(t *testing.T) {
@@ -497,3 +528,411 @@ func TestCapacityLRUCache_AddSizedAndReturnEvictedEvictionBySizeInBytesOneLargeE
 	assert.True(t, c.Contains(keys[1]))
 	assert.True(t, c.Contains(keys[2]))
 }
+
+func TestCapacityLRUCache_AddSizedOverwriteShouldKeepExactSizeInBytes(t *testing.T) {
+	t.Parallel()
+
+	c, _ := NewCapacityLRU(100, 100000)
+	c.AddSized("other", "other", 7)
+
+	sizes := []int64{1000, 10, 5000, 0, 1, 3000, 2999, 50}
+	for i := 0; i < 10; i++ {
+		for _, size := range sizes {
+			c.AddSized("key", size, size)
+			assert.Equal(t, uint64(7+size), c.SizeInBytesContained())
+			assert.Equal(t, 2, c.Len())
+		}
+	}
+
+	for _, size := range sizes {
+		evicted := c.AddSizedAndReturnEvicted("key", size, size)
+		assert.Equal(t, 0, len(evicted))
+		assert.Equal(t, uint64(7+size), c.SizeInBytesContained())
+	}
+
+	c.Remove("key")
+	assert.Equal(t, uint64(7), c.SizeInBytesContained())
+}
+
+func TestCapacityLRUCache_ConcurrentOverwritesShouldKeepExactSizeInBytes(t *testing.T) {
+	t.Parallel()
+
+	c, _ := NewCapacityLRU(100, 100000)
+
+	numKeys := 5
+	numOverwrites := 1000
+	wg := sync.WaitGroup{}
+	wg.Add(numKeys)
+	for i := 0; i < numKeys; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := fmt.Sprintf("key%d", idx)
+			for j := 0; j < numOverwrites; j++ {
+				size := int64((j * 37) % 1000)
+				if j%2 == 0 {
+					c.AddSized(key, j, size)
+					continue
+				}
+				_ = c.AddSizedAndReturnEvicted(key, j, size)
+			}
+			c.AddSized(key, idx, int64(idx+1))
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(1+2+3+4+5), c.SizeInBytesContained())
+}
+
+func TestCapacityLRUCache_AddSizedAndReturnEvictedGrowingExistingElementShouldReturnEvicted(t *testing.T) {
+	t.Parallel()
+
+	c, _ := NewCapacityLRU(100, 1000)
+
+	_ = c.AddSizedAndReturnEvicted("key1", "val1", 400)
+	_ = c.AddSizedAndReturnEvicted("key2", "val2", 400)
+
+	evicted := c.AddSizedAndReturnEvicted("key2", "large val2", 900)
+	assert.Equal(t, map[interface{}]interface{}{"key1": "val1"}, evicted)
+	assert.Equal(t, uint64(900), c.SizeInBytesContained())
+	assert.Equal(t, 1, c.Len())
+}
+
+//------- overhead accounting
+
+func TestCapacityLRUCache_OverheadShouldBeAccounted(t *testing.T) {
+	t.Parallel()
+
+	perEntryOverhead := 10
+	cache, _ := NewCapacityLRUWithOverhead(100, 1000, perEntryOverhead)
+
+	cache.AddSized("key1", []byte("value"), 5)
+	cache.AddSized("key22", []byte("value"), 5)
+	assert.Equal(t, int64(4+10+5+10), cache.AccountedOverhead())
+	assert.Equal(t, uint64(4+10+5+10+5+5), cache.SizeInBytesContained())
+
+	// updating an existing key changes only the value size
+	cache.AddSized("key1", []byte("longer value"), 12)
+	assert.Equal(t, int64(4+10+5+10), cache.AccountedOverhead())
+	assert.Equal(t, uint64(4+10+5+10+12+5), cache.SizeInBytesContained())
+
+	cache.Remove("key1")
+	assert.Equal(t, int64(5+10), cache.AccountedOverhead())
+	assert.Equal(t, uint64(5+10+5), cache.SizeInBytesContained())
+
+	cache.Purge()
+	assert.Equal(t, int64(0), cache.AccountedOverhead())
+	assert.Equal(t, uint64(0), cache.SizeInBytesContained())
+}
+
+func TestCapacityLRUCache_OverheadShouldTriggerEviction(t *testing.T) {
+	t.Parallel()
+
+	// each entry accounts 4 bytes of key + 10 bytes of overhead + 5 bytes of value
+	cache, _ := NewCapacityLRUWithOverhead(100, 40, 10)
+	cache.AddSized("key1", []byte("value"), 5)
+	cache.AddSized("key2", []byte("value"), 5)
+	assert.Equal(t, 2, cache.Len())
+
+	evicted := cache.AddSized("key3", []byte("value"), 5)
+	assert.True(t, evicted)
+	assert.Equal(t, 2, cache.Len())
+	assert.False(t, cache.Contains("key1"))
+	assert.Equal(t, uint64(38), cache.SizeInBytesContained())
+}
+
+func TestCapacityLRUCache_WithoutOverheadShouldNotAccount(t *testing.T) {
+	t.Parallel()
+
+	cache := createDefaultCache()
+	cache.AddSized("key1", []byte("value"), 5)
+
+	assert.Equal(t, int64(0), cache.AccountedOverhead())
+	assert.Equal(t, uint64(5), cache.SizeInBytesContained())
+}
+
+//------- max entry size
+
+func TestCapacityLRUCache_OversizedEntriesShouldNotBeCached(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityLRU(100, 100, WithMaxEntrySizeInBytes(20))
+	cache.AddSized("hot1", []byte("value"), 10)
+	cache.AddSized("hot2", []byte("value"), 10)
+
+	evicted := cache.AddSized("large", []byte("large value"), 90)
+	assert.False(t, evicted)
+	assert.False(t, cache.Contains("large"))
+	assert.True(t, cache.Contains("hot1"))
+	assert.True(t, cache.Contains("hot2"))
+	assert.Equal(t, uint64(20), cache.SizeInBytesContained())
+
+	has, evicted := cache.AddSizedIfMissing("large", []byte("large value"), 90)
+	assert.False(t, has)
+	assert.False(t, evicted)
+	assert.False(t, cache.Contains("large"))
+
+	evictedValues := cache.AddSizedAndReturnEvicted("large", []byte("large value"), 90)
+	assert.Equal(t, 0, len(evictedValues))
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestCapacityLRUCache_OversizedUpdateShouldRemoveTheStaleValue(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityLRU(100, 100, WithMaxEntrySizeInBytes(20))
+	cache.AddSized("key", []byte("value"), 10)
+	cache.AddSized("key", []byte("large value"), 30)
+
+	assert.False(t, cache.Contains("key"))
+	assert.Equal(t, uint64(0), cache.SizeInBytesContained())
+}
+
+func TestCapacityLRUCache_ResizeShouldEvictTheOldest(t *testing.T) {
+	t.Parallel()
+
+	cache := createDefaultCache()
+	for i := 0; i < 5; i++ {
+		cache.AddSized(i, i, 1)
+	}
+
+	assert.Equal(t, 0, cache.Resize(10))
+	assert.Equal(t, 3, cache.Resize(2))
+	assert.Equal(t, []interface{}{3, 4}, cache.Keys())
+	assert.Equal(t, uint64(2), cache.SizeInBytesContained())
+
+	cache.AddSized(5, 5, 1)
+	assert.Equal(t, []interface{}{4, 5}, cache.Keys())
+}
+
+func TestCapacityLRUCache_EntriesShouldFollowTheEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityLRUWithOverhead(100, 1000, 2)
+	cache.AddSized("a", []byte("a"), 3)
+	cache.AddSized("bb", []byte("bb"), 4)
+	_, _ = cache.Get("a")
+
+	expected := []EntryInfo{
+		{Key: "bb", Size: 4, Overhead: 4},
+		{Key: "a", Size: 3, Overhead: 3},
+	}
+	assert.Equal(t, expected, cache.Entries())
+}
+
+func TestCapacityLRUCache_RangeShouldNotChangeTheEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityLRU(3, 1000)
+	cache.AddSized("a", "va", 1)
+	cache.AddSized("b", "vb", 1)
+	cache.AddSized("c", "vc", 1)
+
+	keys := make([]interface{}, 0)
+	values := make([]interface{}, 0)
+	cache.Range(func(key interface{}, value interface{}) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	assert.Equal(t, []interface{}{"a", "b", "c"}, keys)
+	assert.Equal(t, []interface{}{"va", "vb", "vc"}, values)
+
+	numCalls := 0
+	cache.Range(func(key interface{}, value interface{}) bool {
+		numCalls++
+		return false
+	})
+	assert.Equal(t, 1, numCalls)
+
+	cache.AddSized("d", "vd", 1)
+	assert.False(t, cache.Contains("a"))
+}
+
+func TestCapacityLRUCache_AddSizedBatchShouldEvictOnceAtTheEnd(t *testing.T) {
+	t.Parallel()
+
+	t.Run("count limit", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := NewCapacityLRU(3, 1000)
+		cache.AddSized("old", "old", 1)
+
+		evicted := cache.AddSizedBatch([]BatchEntry{
+			{Key: "a", Value: "va", SizeInBytes: 1},
+			{Key: "b", Value: "vb", SizeInBytes: 1},
+			{Key: "old", Value: "new", SizeInBytes: 1},
+			{Key: "c", Value: "vc", SizeInBytes: 1},
+		})
+		assert.True(t, evicted)
+		assert.Equal(t, []interface{}{"b", "old", "c"}, cache.Keys())
+		value, _ := cache.Peek("old")
+		assert.Equal(t, "new", value)
+	})
+	t.Run("byte limit", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := NewCapacityLRU(100, 10)
+		evicted := cache.AddSizedBatch([]BatchEntry{
+			{Key: "a", Value: "va", SizeInBytes: 4},
+			{Key: "b", Value: "vb", SizeInBytes: 4},
+			{Key: "c", Value: "vc", SizeInBytes: 4},
+			{Key: "d", Value: "vd", SizeInBytes: 2},
+		})
+		assert.True(t, evicted)
+		assert.Equal(t, []interface{}{"b", "c", "d"}, cache.Keys())
+		assert.Equal(t, uint64(10), cache.SizeInBytesContained())
+	})
+	t.Run("within limits should not evict", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := NewCapacityLRU(3, 1000)
+		evicted := cache.AddSizedBatch([]BatchEntry{
+			{Key: "a", Value: "va", SizeInBytes: 1},
+			{Key: "b", Value: "vb", SizeInBytes: 1},
+		})
+		assert.False(t, evicted)
+		assert.Equal(t, 2, cache.Len())
+	})
+}
+
+func TestCapacityLRUCache_CheckInvariants(t *testing.T) {
+	t.Parallel()
+
+	createPopulatedCache := func() *capacityLRU {
+		cache, _ := NewCapacityLRUWithOverhead(10, 200, 3)
+		for i := 0; i < 30; i++ {
+			key := fmt.Sprintf("key%d", i%15)
+			cache.AddSized(key, i, int64(i))
+			if i%4 == 0 {
+				cache.Remove(fmt.Sprintf("key%d", i%7))
+			}
+		}
+		cache.Resize(5)
+
+		return cache
+	}
+
+	t.Run("consistent cache should not error", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, createPopulatedCache().CheckInvariants())
+	})
+	t.Run("wrong byte total should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := createPopulatedCache()
+		cache.currentCapacityInBytes++
+
+		err := cache.CheckInvariants()
+		assert.True(t, errors.Is(err, common.ErrCacheInvariantViolated))
+		assert.Contains(t, err.Error(), "byte total")
+	})
+	t.Run("orphaned list element should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := createPopulatedCache()
+		cache.evictList.PushBack(&entry{key: "orphan"})
+
+		err := cache.CheckInvariants()
+		assert.True(t, errors.Is(err, common.ErrCacheInvariantViolated))
+		assert.Contains(t, err.Error(), "items map has")
+	})
+	t.Run("map pointing to another element should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := createPopulatedCache()
+		front := cache.evictList.Front()
+		back := cache.evictList.Back()
+		cache.items[front.Value.(*entry).key] = back
+
+		err := cache.CheckInvariants()
+		assert.True(t, errors.Is(err, common.ErrCacheInvariantViolated))
+		assert.Contains(t, err.Error(), "another element")
+	})
+	t.Run("cache over its limits should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache := createPopulatedCache()
+		cache.size = 1
+
+		err := cache.CheckInvariants()
+		assert.True(t, errors.Is(err, common.ErrCacheInvariantViolated))
+		assert.Contains(t, err.Error(), "exceed the limits")
+	})
+	t.Run("sharded cache should check the key placement", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := NewShardedSizeLRU(10, 1000, 4)
+		for i := 0; i < 20; i++ {
+			cache.AddSized(fmt.Sprintf("key%d", i), i, 10)
+		}
+		assert.Nil(t, cache.CheckInvariants())
+
+		misplacedKey := "key0"
+		owner := cache.getShard(misplacedKey)
+		for _, shard := range cache.shards {
+			if shard != owner {
+				shard.AddSized(misplacedKey, 0, 10)
+				break
+			}
+		}
+
+		err := cache.CheckInvariants()
+		assert.True(t, errors.Is(err, common.ErrCacheInvariantViolated))
+		assert.Contains(t, err.Error(), "wrong shard")
+	})
+}
+
+func TestCapacityLRUCache_EvictBytes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should evict the oldest entries", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := NewCapacityLRU(10, 1000)
+		for i := 0; i < 5; i++ {
+			cache.AddSized(fmt.Sprintf("key%d", i), i, 10)
+		}
+
+		assert.Equal(t, uint64(20), cache.EvictBytes(15))
+		assert.Equal(t, []interface{}{"key2", "key3", "key4"}, cache.Keys())
+		assert.Equal(t, uint64(30), cache.EvictBytes(1000))
+		assert.Equal(t, 0, cache.Len())
+		assert.Equal(t, uint64(0), cache.EvictBytes(10))
+	})
+	t.Run("sharded cache should evict from each shard", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := NewShardedSizeLRU(100, 10000, 4)
+		for i := 0; i < 100; i++ {
+			cache.AddSized(fmt.Sprintf("key%d", i), i, 10)
+		}
+
+		lensBefore := make([]int, len(cache.shards))
+		for i, shard := range cache.shards {
+			lensBefore[i] = shard.Len()
+		}
+
+		freed := cache.EvictBytes(500)
+		assert.GreaterOrEqual(t, freed, uint64(500))
+		assert.Equal(t, uint64(1000)-freed, cache.SizeInBytesContained())
+		for i, shard := range cache.shards {
+			assert.Less(t, shard.Len(), lensBefore[i])
+		}
+	})
+}
+
+func TestCapacityLRUCache_GetBatchShouldUpdateTheEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityLRU(3, 1000)
+	cache.AddSized("a", "va", 1)
+	cache.AddSized("b", "vb", 1)
+	cache.AddSized("c", "vc", 1)
+
+	found := cache.GetBatch([]interface{}{"b", "missing", "a"})
+	assert.Equal(t, map[interface{}]interface{}{"a": "va", "b": "vb"}, found)
+	assert.Equal(t, []interface{}{"c", "b", "a"}, cache.Keys())
+	assert.Empty(t, cache.GetBatch(nil))
+}
@@ -0,0 +1,84 @@
+package capacity
+
+import (
+	"fmt"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+// CheckInvariants walks the internal structures of the cache and returns an error describing the first
+// inconsistency found: the eviction list and the items map holding different entries, the byte total or the
+// accounted overhead differing from the sum over the entries, or the cache being over its limits. It is meant
+// for tests and debugging, as it visits all the entries while holding the cache lock
+func (c *capacityLRU) CheckInvariants() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.evictList.Len() != len(c.items) {
+		return fmt.Errorf("%w: the eviction list has %d entries while the items map has %d",
+			common.ErrCacheInvariantViolated, c.evictList.Len(), len(c.items))
+	}
+
+	sizeInBytes := int64(0)
+	overhead := int64(0)
+	for element := c.evictList.Front(); element != nil; element = element.Next() {
+		e, ok := element.Value.(*entry)
+		if !ok {
+			return fmt.Errorf("%w: invalid eviction list element %T", common.ErrCacheInvariantViolated, element.Value)
+		}
+
+		mapped, found := c.items[e.key]
+		if !found {
+			return fmt.Errorf("%w: orphaned eviction list element for key %v", common.ErrCacheInvariantViolated, e.key)
+		}
+		if mapped != element {
+			return fmt.Errorf("%w: the items map points to another element for key %v", common.ErrCacheInvariantViolated, e.key)
+		}
+		if e.size < 0 {
+			return fmt.Errorf("%w: negative size %d for key %v", common.ErrCacheInvariantViolated, e.size, e.key)
+		}
+
+		expectedOverhead := c.computeOverhead(e.key)
+		if e.overhead != expectedOverhead {
+			return fmt.Errorf("%w: overhead %d instead of %d for key %v",
+				common.ErrCacheInvariantViolated, e.overhead, expectedOverhead, e.key)
+		}
+
+		sizeInBytes += e.size + e.overhead
+		overhead += e.overhead
+	}
+
+	if sizeInBytes != c.currentCapacityInBytes {
+		return fmt.Errorf("%w: the byte total is %d while the entries sum up to %d",
+			common.ErrCacheInvariantViolated, c.currentCapacityInBytes, sizeInBytes)
+	}
+	if overhead != c.accountedOverhead {
+		return fmt.Errorf("%w: the accounted overhead is %d while the entries sum up to %d",
+			common.ErrCacheInvariantViolated, c.accountedOverhead, overhead)
+	}
+	if c.shouldEvict() {
+		return fmt.Errorf("%w: %d entries holding %d bytes exceed the limits of %d entries and %d bytes",
+			common.ErrCacheInvariantViolated, c.evictList.Len(), c.currentCapacityInBytes, c.size, c.maxCapacityInBytes)
+	}
+
+	return nil
+}
+
+// CheckInvariants checks the invariants of each shard and that each key is held by the shard it belongs to. It
+// is meant for tests and debugging, the shards being checked one after the other
+func (c *shardedCapacityLRU) CheckInvariants() error {
+	for i, shard := range c.shards {
+		err := shard.CheckInvariants()
+		if err != nil {
+			return fmt.Errorf("%w in shard %d", err, i)
+		}
+
+		for _, key := range shard.Keys() {
+			if c.getShard(key) != shard {
+				return fmt.Errorf("%w: key %v held by the wrong shard %d", common.ErrCacheInvariantViolated, key, i)
+			}
+		}
+	}
+
+	return nil
+}
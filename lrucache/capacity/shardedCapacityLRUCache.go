@@ -0,0 +1,268 @@
+package capacity
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.AdaptedSizedLRUCache = (*shardedCapacityLRU)(nil)
+
+const prime32 = uint32(16777619)
+const offset32 = uint32(2166136261)
+
+// shardedCapacityLRU partitions the keys across independently locked capacityLRU instances
+type shardedCapacityLRU struct {
+	shards []*capacityLRU
+}
+
+// NewShardedSizeLRU constructs a sharded CapacityLRU. Both the size and the byte capacity are applied per shard
+func NewShardedSizeLRU(capacityPerShard int, maxBytesPerShard int64, shards int, options ...Option) (*shardedCapacityLRU, error) {
+	return newShardedSizeLRU(shards, func() (*capacityLRU, error) {
+		return NewCapacityLRU(capacityPerShard, maxBytesPerShard, options...)
+	})
+}
+
+// NewShardedSizeLRUWithOverhead constructs a sharded CapacityLRU whose shards also account the key length and the
+// provided per entry overhead in their byte budget. Both the size and the byte capacity are applied per shard
+func NewShardedSizeLRUWithOverhead(
+	capacityPerShard int,
+	maxBytesPerShard int64,
+	shards int,
+	perEntryOverheadBytes int,
+	options ...Option,
+) (*shardedCapacityLRU, error) {
+	return newShardedSizeLRU(shards, func() (*capacityLRU, error) {
+		return NewCapacityLRUWithOverhead(capacityPerShard, maxBytesPerShard, perEntryOverheadBytes, options...)
+	})
+}
+
+func newShardedSizeLRU(shards int, createShard func() (*capacityLRU, error)) (*shardedCapacityLRU, error) {
+	if shards < 1 {
+		return nil, common.ErrCacheShardsInvalid
+	}
+
+	c := &shardedCapacityLRU{
+		shards: make([]*capacityLRU, shards),
+	}
+	for i := 0; i < shards; i++ {
+		shard, err := createShard()
+		if err != nil {
+			return nil, err
+		}
+
+		c.shards[i] = shard
+	}
+
+	return c, nil
+}
+
+func (c *shardedCapacityLRU) getShard(key interface{}) *capacityLRU {
+	keyString, ok := key.(string)
+	if !ok {
+		keyString = fmt.Sprintf("%v", key)
+	}
+
+	return c.shards[fnv32(keyString)%uint32(len(c.shards))]
+}
+
+func fnv32(key string) uint32 {
+	hash := offset32
+	for i := 0; i < len(key); i++ {
+		hash *= prime32
+		hash ^= uint32(key[i])
+	}
+
+	return hash
+}
+
+// Purge is used to completely clear all shards
+func (c *shardedCapacityLRU) Purge() {
+	for _, shard := range c.shards {
+		shard.Purge()
+	}
+}
+
+// AddSized adds a value to the owning shard. Returns true if an eviction occurred.
+func (c *shardedCapacityLRU) AddSized(key, value interface{}, sizeInBytes int64) bool {
+	return c.getShard(key).AddSized(key, value, sizeInBytes)
+}
+
+// AddSizedBatch groups the entries by their owning shard and adds each group as a single batch of the shard.
+// Returns true if an eviction occurred in any shard.
+func (c *shardedCapacityLRU) AddSizedBatch(entries []BatchEntry) bool {
+	entriesByShard := make(map[*capacityLRU][]BatchEntry, len(c.shards))
+	for _, e := range entries {
+		shard := c.getShard(e.Key)
+		entriesByShard[shard] = append(entriesByShard[shard], e)
+	}
+
+	evicted := false
+	for shard, shardEntries := range entriesByShard {
+		evicted = shard.AddSizedBatch(shardEntries) || evicted
+	}
+
+	return evicted
+}
+
+// AddSizedAndReturnEvicted adds the given key-value pair to the owning shard, and returns the evicted values
+func (c *shardedCapacityLRU) AddSizedAndReturnEvicted(key, value interface{}, sizeInBytes int64) map[interface{}]interface{} {
+	return c.getShard(key).AddSizedAndReturnEvicted(key, value, sizeInBytes)
+}
+
+// EvictBytes makes each shard evict the oldest entries for its share of the provided number of bytes,
+// proportional to its contained size. Returns the number of freed bytes
+func (c *shardedCapacityLRU) EvictBytes(numBytes uint64) uint64 {
+	sizes := make([]uint64, len(c.shards))
+	total := uint64(0)
+	for i, shard := range c.shards {
+		sizes[i] = shard.SizeInBytesContained()
+		total += sizes[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	if numBytes >= total {
+		numBytes = total
+	}
+
+	freed := uint64(0)
+	for i, shard := range c.shards {
+		if sizes[i] == 0 {
+			continue
+		}
+
+		hi, lo := bits.Mul64(numBytes, sizes[i])
+		share, rest := bits.Div64(hi, lo, total)
+		if rest > 0 {
+			share++
+		}
+		freed += shard.EvictBytes(share)
+	}
+
+	return freed
+}
+
+// Get looks up a key's value from the owning shard.
+func (c *shardedCapacityLRU) Get(key interface{}) (interface{}, bool) {
+	return c.getShard(key).Get(key)
+}
+
+// GetBatch groups the keys by their owning shard and looks up each group as a single batch of the shard.
+// Returns the values of the found keys only
+func (c *shardedCapacityLRU) GetBatch(keys []interface{}) map[interface{}]interface{} {
+	keysByShard := make(map[*capacityLRU][]interface{}, len(c.shards))
+	for _, key := range keys {
+		shard := c.getShard(key)
+		keysByShard[shard] = append(keysByShard[shard], key)
+	}
+
+	found := make(map[interface{}]interface{})
+	for shard, shardKeys := range keysByShard {
+		for key, value := range shard.GetBatch(shardKeys) {
+			found[key] = value
+		}
+	}
+
+	return found
+}
+
+// Contains checks if a key is in the cache, without updating the recent-ness
+// or deleting it for being stale.
+func (c *shardedCapacityLRU) Contains(key interface{}) bool {
+	return c.getShard(key).Contains(key)
+}
+
+// AddSizedIfMissing checks if a key is in the owning shard without updating the
+// recent-ness or deleting it for being stale, and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (c *shardedCapacityLRU) AddSizedIfMissing(key, value interface{}, sizeInBytes int64) (bool, bool) {
+	return c.getShard(key).AddSizedIfMissing(key, value, sizeInBytes)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *shardedCapacityLRU) Peek(key interface{}) (interface{}, bool) {
+	return c.getShard(key).Peek(key)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *shardedCapacityLRU) Remove(key interface{}) bool {
+	return c.getShard(key).Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache. The keys are ordered from oldest to newest
+// only within the same shard
+func (c *shardedCapacityLRU) Keys() []interface{} {
+	keys := make([]interface{}, 0, c.Len())
+	for _, shard := range c.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+
+	return keys
+}
+
+// Entries returns a snapshot of the entries in the cache, shard by shard. The entries are ordered from oldest
+// to newest only within the same shard, each shard evicting independently
+func (c *shardedCapacityLRU) Entries() []EntryInfo {
+	entries := make([]EntryInfo, 0, c.Len())
+	for _, shard := range c.shards {
+		entries = append(entries, shard.Entries()...)
+	}
+
+	return entries
+}
+
+// Range calls the handler for each entry, shard by shard, without changing the eviction order. The entries are
+// ordered from oldest to newest only within the same shard. The iteration stops when the handler returns false.
+// The lock of the iterated shard is held while calling the handler, so the handler must not access the cache
+func (c *shardedCapacityLRU) Range(handler func(key interface{}, value interface{}) bool) {
+	for _, shard := range c.shards {
+		shouldContinue := true
+		shard.Range(func(key interface{}, value interface{}) bool {
+			shouldContinue = handler(key, value)
+			return shouldContinue
+		})
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+// Len returns the number of items in all shards.
+func (c *shardedCapacityLRU) Len() int {
+	numItems := 0
+	for _, shard := range c.shards {
+		numItems += shard.Len()
+	}
+
+	return numItems
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements, in all shards
+func (c *shardedCapacityLRU) SizeInBytesContained() uint64 {
+	size := uint64(0)
+	for _, shard := range c.shards {
+		size += shard.SizeInBytesContained()
+	}
+
+	return size
+}
+
+// AccountedOverhead returns the bytes accounted for the keys and the per entry overhead, in all shards
+func (c *shardedCapacityLRU) AccountedOverhead() int64 {
+	overhead := int64(0)
+	for _, shard := range c.shards {
+		overhead += shard.AccountedOverhead()
+	}
+
+	return overhead
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *shardedCapacityLRU) IsInterfaceNil() bool {
+	return c == nil
+}
@@ -0,0 +1,244 @@
+package capacity
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShardedSizeLRU(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid number of shards should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := NewShardedSizeLRU(10, 100, 0)
+		assert.True(t, check.IfNil(cache))
+		assert.Equal(t, common.ErrCacheShardsInvalid, err)
+	})
+	t.Run("invalid size should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := NewShardedSizeLRU(0, 100, 4)
+		assert.True(t, check.IfNil(cache))
+		assert.Equal(t, common.ErrCacheSizeInvalid, err)
+	})
+	t.Run("invalid capacity should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := NewShardedSizeLRU(10, 0, 4)
+		assert.True(t, check.IfNil(cache))
+		assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		cache, err := NewShardedSizeLRU(10, 100, 4)
+		assert.False(t, check.IfNil(cache))
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(cache.shards))
+	})
+}
+
+func TestShardedCapacityLRU_OperationsShouldAggregateShards(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewShardedSizeLRU(100, 1000, 4)
+	numKeys := 50
+	for i := 0; i < numKeys; i++ {
+		cache.AddSized(fmt.Sprintf("key%d", i), []byte("value"), 5)
+	}
+
+	assert.Equal(t, numKeys, cache.Len())
+	assert.Equal(t, numKeys, len(cache.Keys()))
+	assert.Equal(t, uint64(numKeys*5), cache.SizeInBytesContained())
+
+	val, ok := cache.Get("key7")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), val)
+	assert.True(t, cache.Contains("key7"))
+
+	val, ok = cache.Peek("key8")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), val)
+
+	has, evicted := cache.AddSizedIfMissing("key9", []byte("other"), 5)
+	assert.True(t, has)
+	assert.False(t, evicted)
+
+	assert.True(t, cache.Remove("key9"))
+	assert.False(t, cache.Contains("key9"))
+	assert.Equal(t, uint64((numKeys-1)*5), cache.SizeInBytesContained())
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, uint64(0), cache.SizeInBytesContained())
+}
+
+func TestShardedCapacityLRU_OverheadShouldAggregateShards(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewShardedSizeLRUWithOverhead(100, 1000, 4, 10)
+	require.Nil(t, err)
+
+	numKeys := 20
+	for i := 0; i < numKeys; i++ {
+		cache.AddSized(fmt.Sprintf("key%02d", i), []byte("value"), 5)
+	}
+
+	assert.Equal(t, int64(numKeys*(5+10)), cache.AccountedOverhead())
+	assert.Equal(t, uint64(numKeys*(5+10+5)), cache.SizeInBytesContained())
+
+	_, err = NewShardedSizeLRUWithOverhead(100, 1000, 4, -1)
+	assert.Equal(t, common.ErrInvalidPerEntryOverhead, err)
+}
+
+func TestShardedCapacityLRU_ByteBudgetIsPerShard(t *testing.T) {
+	t.Parallel()
+
+	numShards := 4
+	maxBytesPerShard := int64(20)
+	cache, _ := NewShardedSizeLRU(100, maxBytesPerShard, numShards)
+	for i := 0; i < 100; i++ {
+		cache.AddSized(fmt.Sprintf("key%d", i), []byte("value"), 5)
+	}
+
+	for _, shard := range cache.shards {
+		assert.LessOrEqual(t, shard.currentCapacityInBytes, maxBytesPerShard)
+	}
+	assert.LessOrEqual(t, cache.SizeInBytesContained(), uint64(int64(numShards)*maxBytesPerShard))
+}
+
+func TestShardedCapacityLRU_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewShardedSizeLRU(100, 1000, 8)
+	numOperations := 1000
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := fmt.Sprintf("key%d", idx%100)
+			switch idx % 3 {
+			case 0:
+				cache.AddSized(key, idx, int64(idx%10))
+			case 1:
+				_, _ = cache.Get(key)
+			default:
+				_ = cache.Remove(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	sum := int64(0)
+	for _, shard := range cache.shards {
+		for e := shard.evictList.Front(); e != nil; e = e.Next() {
+			sum += e.Value.(*entry).size
+		}
+	}
+	require.Equal(t, uint64(sum), cache.SizeInBytesContained())
+}
+
+func benchmarkConcurrentAddAndGet(b *testing.B, cache interface {
+	AddSized(key, value interface{}, sizeInBytes int64) bool
+	Get(key interface{}) (interface{}, bool)
+}) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		idx := 0
+		for pb.Next() {
+			key := keys[idx%len(keys)]
+			if idx%2 == 0 {
+				cache.AddSized(key, idx, 8)
+			} else {
+				_, _ = cache.Get(key)
+			}
+			idx++
+		}
+	})
+}
+
+func BenchmarkCapacityLRU_ConcurrentAddAndGet(b *testing.B) {
+	cache, _ := NewCapacityLRU(1024*16, 1024*1024)
+	benchmarkConcurrentAddAndGet(b, cache)
+}
+
+func BenchmarkShardedCapacityLRU_ConcurrentAddAndGet(b *testing.B) {
+	cache, _ := NewShardedSizeLRU(1024, 1024*64, 16)
+	benchmarkConcurrentAddAndGet(b, cache)
+}
+
+func TestShardedCapacityLRU_RangeShouldVisitAllShards(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewShardedSizeLRU(100, 1000, 4)
+	for i := 0; i < 20; i++ {
+		cache.AddSized(fmt.Sprintf("key%d", i), i, 1)
+	}
+
+	visited := make(map[interface{}]interface{})
+	cache.Range(func(key interface{}, value interface{}) bool {
+		visited[key] = value
+		return true
+	})
+	require.Equal(t, 20, len(visited))
+	require.Equal(t, 7, visited["key7"])
+
+	numCalls := 0
+	cache.Range(func(key interface{}, value interface{}) bool {
+		numCalls++
+		return numCalls < 5
+	})
+	require.Equal(t, 5, numCalls)
+}
+
+func TestShardedCapacityLRU_AddSizedBatchShouldRouteToTheShards(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewShardedSizeLRU(100, 1000, 4)
+	entries := make([]BatchEntry, 0, 20)
+	for i := 0; i < 20; i++ {
+		entries = append(entries, BatchEntry{Key: fmt.Sprintf("key%d", i), Value: i, SizeInBytes: 1})
+	}
+
+	evicted := cache.AddSizedBatch(entries)
+	require.False(t, evicted)
+	require.Equal(t, 20, cache.Len())
+	for i := 0; i < 20; i++ {
+		value, ok := cache.getShard(fmt.Sprintf("key%d", i)).Peek(fmt.Sprintf("key%d", i))
+		require.True(t, ok)
+		require.Equal(t, i, value)
+	}
+}
+
+func TestShardedCapacityLRU_GetBatchShouldRouteToTheShards(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewShardedSizeLRU(100, 1000, 4)
+	keys := make([]interface{}, 0, 30)
+	for i := 0; i < 20; i++ {
+		cache.AddSized(fmt.Sprintf("key%d", i), i, 1)
+	}
+	for i := 10; i < 30; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+
+	found := cache.GetBatch(keys)
+	require.Equal(t, 10, len(found))
+	for i := 10; i < 20; i++ {
+		require.Equal(t, i, found[fmt.Sprintf("key%d", i)])
+	}
+}
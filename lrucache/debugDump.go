@@ -0,0 +1,52 @@
+package lrucache
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache/capacity"
+)
+
+type entriesLister interface {
+	Entries() []capacity.EntryInfo
+}
+
+// DebugDump writes a human-readable representation of the cache to the provided writer: the entries in
+// eviction order (oldest first), each one with its hex encoded key, its size and its accounted overhead.
+// The caches not tracking the sizes report them as n/a. For the sharded caches the order is kept only within
+// the same shard. The entries are snapshot under the cache lock and written afterwards, so a slow writer does
+// not block the cache
+func (c *lruCache) DebugDump(w io.Writer) {
+	entries, sizesKnown := c.snapshotEntries()
+
+	builder := strings.Builder{}
+	_, _ = fmt.Fprintf(&builder, "lru cache: len=%d maxSize=%d sizeInBytes=%d\n",
+		len(entries), c.MaxSize(), c.SizeInBytesContained())
+	for i, e := range entries {
+		key := []byte(fmt.Sprintf("%v", e.Key))
+		if !sizesKnown {
+			_, _ = fmt.Fprintf(&builder, "%d: key=%x size=n/a overhead=n/a\n", i, key)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(&builder, "%d: key=%x size=%d overhead=%d\n", i, key, e.Size, e.Overhead)
+	}
+
+	_, _ = io.WriteString(w, builder.String())
+}
+
+func (c *lruCache) snapshotEntries() ([]capacity.EntryInfo, bool) {
+	lister, ok := c.cache.(entriesLister)
+	if ok {
+		return lister.Entries(), true
+	}
+
+	keys := c.cache.Keys()
+	entries := make([]capacity.EntryInfo, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, capacity.EntryInfo{Key: key})
+	}
+
+	return entries, false
+}
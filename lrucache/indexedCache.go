@@ -0,0 +1,300 @@
+package lrucache
+
+import (
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+var _ types.Cacher = (*indexedCache)(nil)
+
+type indexedEntry struct {
+	value     interface{}
+	size      int
+	indexKey  string
+	isIndexed bool
+}
+
+// indexedCache implements a Least Recently Used eviction cache that also maintains a secondary index,
+// mapping the index keys derived from the stored pairs to the set of primary keys
+type indexedCache struct {
+	mut         sync.Mutex
+	cache       *simplelru.LRU
+	maxSize     int
+	sizeInBytes uint64
+	indexFunc   func(key, value []byte) []byte
+	index       map[string]map[string]struct{}
+
+	mutAddedDataHandlers sync.RWMutex
+	mapDataHandlers      map[string]func(key []byte, value interface{})
+}
+
+// NewIndexedCache creates a new LRU cache that indexes its entries by the key returned by the provided function.
+// The function receives the value only if it is a byte slice, and a nil index key means the entry is not indexed.
+// The index is kept consistent on updates, removals and evictions
+func NewIndexedCache(capacity int, indexFunc func(key, value []byte) []byte) (*indexedCache, error) {
+	if indexFunc == nil {
+		return nil, common.ErrNilIndexFunc
+	}
+
+	ic := &indexedCache{
+		maxSize:         capacity,
+		indexFunc:       indexFunc,
+		index:           make(map[string]map[string]struct{}),
+		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
+	}
+
+	cache, err := simplelru.NewLRU(capacity, ic.onRemoved)
+	if err != nil {
+		return nil, err
+	}
+	ic.cache = cache
+
+	return ic, nil
+}
+
+// onRemoved is called by the inner LRU, under the cache mutex, each time an entry is removed or evicted
+func (ic *indexedCache) onRemoved(key interface{}, value interface{}) {
+	entry, ok := value.(*indexedEntry)
+	if !ok {
+		return
+	}
+
+	ic.sizeInBytes -= uint64(entry.size)
+	ic.unindex(key.(string), entry)
+}
+
+func (ic *indexedCache) unindex(key string, entry *indexedEntry) {
+	if !entry.isIndexed {
+		return
+	}
+
+	primaryKeys := ic.index[entry.indexKey]
+	delete(primaryKeys, key)
+	if len(primaryKeys) == 0 {
+		delete(ic.index, entry.indexKey)
+	}
+}
+
+func (ic *indexedCache) newEntry(key []byte, value interface{}, sizeInBytes int) *indexedEntry {
+	buff, _ := value.([]byte)
+	indexKey := ic.indexFunc(key, buff)
+
+	return &indexedEntry{
+		value:     value,
+		size:      sizeInBytes,
+		indexKey:  string(indexKey),
+		isIndexed: indexKey != nil,
+	}
+}
+
+func (ic *indexedCache) add(key []byte, entry *indexedEntry) bool {
+	existing, found := ic.cache.Peek(string(key))
+	if found {
+		existingEntry := existing.(*indexedEntry)
+		ic.sizeInBytes -= uint64(existingEntry.size)
+		ic.unindex(string(key), existingEntry)
+	}
+
+	ic.sizeInBytes += uint64(entry.size)
+	if entry.isIndexed {
+		primaryKeys, ok := ic.index[entry.indexKey]
+		if !ok {
+			primaryKeys = make(map[string]struct{})
+			ic.index[entry.indexKey] = primaryKeys
+		}
+		primaryKeys[string(key)] = struct{}{}
+	}
+
+	return ic.cache.Add(string(key), entry)
+}
+
+// Clear is used to completely clear the cache.
+func (ic *indexedCache) Clear() {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	ic.cache.Purge()
+}
+
+// Put adds a value to the cache. Returns true if an eviction occurred.
+func (ic *indexedCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	entry := ic.newEntry(key, value, sizeInBytes)
+
+	ic.mut.Lock()
+	evicted = ic.add(key, entry)
+	ic.mut.Unlock()
+
+	ic.callAddedDataHandlers(key, value)
+
+	return evicted
+}
+
+// Get looks up a key's value from the cache.
+func (ic *indexedCache) Get(key []byte) (value interface{}, ok bool) {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	v, ok := ic.cache.Get(string(key))
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*indexedEntry).value, true
+}
+
+// Has checks if a key is in the cache, without updating the recent-ness.
+func (ic *indexedCache) Has(key []byte) bool {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	return ic.cache.Contains(string(key))
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (ic *indexedCache) Peek(key []byte) (value interface{}, ok bool) {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	v, ok := ic.cache.Peek(string(key))
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*indexedEntry).value, true
+}
+
+// HasOrAdd checks if a key is in the cache without updating the
+// recent-ness, and if not, adds the value.
+// Returns whether found and whether the value was added.
+func (ic *indexedCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	entry := ic.newEntry(key, value, sizeInBytes)
+
+	ic.mut.Lock()
+	has = ic.cache.Contains(string(key))
+	if !has {
+		ic.add(key, entry)
+	}
+	ic.mut.Unlock()
+
+	if !has {
+		ic.callAddedDataHandlers(key, value)
+	}
+
+	return has, !has
+}
+
+// Remove removes the provided key from the cache.
+func (ic *indexedCache) Remove(key []byte) {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	ic.cache.Remove(string(key))
+}
+
+// GetByIndex returns the primary keys of the entries indexed by the provided index key
+func (ic *indexedCache) GetByIndex(indexKey []byte) [][]byte {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	primaryKeys := ic.index[string(indexKey)]
+	keys := make([][]byte, 0, len(primaryKeys))
+	for key := range primaryKeys {
+		keys = append(keys, []byte(key))
+	}
+
+	return keys
+}
+
+// RemoveByIndex removes all the entries indexed by the provided index key, returning the number of removed entries
+func (ic *indexedCache) RemoveByIndex(indexKey []byte) int {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	primaryKeys := ic.index[string(indexKey)]
+	keys := make([]string, 0, len(primaryKeys))
+	for key := range primaryKeys {
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		ic.cache.Remove(key)
+	}
+
+	return len(keys)
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (ic *indexedCache) Keys() [][]byte {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	res := ic.cache.Keys()
+	keys := make([][]byte, len(res))
+	for i := 0; i < len(res); i++ {
+		keys[i] = []byte(res[i].(string))
+	}
+
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (ic *indexedCache) Len() int {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	return ic.cache.Len()
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (ic *indexedCache) SizeInBytesContained() uint64 {
+	ic.mut.Lock()
+	defer ic.mut.Unlock()
+
+	return ic.sizeInBytes
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (ic *indexedCache) MaxSize() int {
+	return ic.maxSize
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (ic *indexedCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	ic.mutAddedDataHandlers.Lock()
+	ic.mapDataHandlers[id] = handler
+	ic.mutAddedDataHandlers.Unlock()
+}
+
+// UnRegisterHandler removes the handler from the list
+func (ic *indexedCache) UnRegisterHandler(id string) {
+	ic.mutAddedDataHandlers.Lock()
+	delete(ic.mapDataHandlers, id)
+	ic.mutAddedDataHandlers.Unlock()
+}
+
+func (ic *indexedCache) callAddedDataHandlers(key []byte, value interface{}) {
+	ic.mutAddedDataHandlers.RLock()
+	for _, handler := range ic.mapDataHandlers {
+		go handler(key, value)
+	}
+	ic.mutAddedDataHandlers.RUnlock()
+}
+
+// Close does nothing for this cacher implementation
+func (ic *indexedCache) Close() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ic *indexedCache) IsInterfaceNil() bool {
+	return ic == nil
+}
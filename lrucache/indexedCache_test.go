@@ -0,0 +1,125 @@
+package lrucache_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/stretchr/testify/assert"
+)
+
+// indexByAccount indexes the keys formatted as <account>_<suffix> by their account
+func indexByAccount(key, _ []byte) []byte {
+	for i, b := range key {
+		if b == '_' {
+			return key[:i]
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(keys [][]byte) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, string(key))
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func TestNewIndexedCache(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewIndexedCache(10, nil)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrNilIndexFunc, err)
+
+	c, err = lrucache.NewIndexedCache(0, indexByAccount)
+	assert.True(t, check.IfNil(c))
+	assert.NotNil(t, err)
+
+	c, err = lrucache.NewIndexedCache(10, indexByAccount)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, c.MaxSize())
+}
+
+func TestIndexedCache_GetByIndexAndRemoveByIndex(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewIndexedCache(10, indexByAccount)
+	c.Put([]byte("alice_1"), []byte("v1"), 2)
+	c.Put([]byte("alice_2"), []byte("v2"), 2)
+	c.Put([]byte("bob_1"), []byte("v3"), 2)
+	c.Put([]byte("unindexed"), []byte("v4"), 2)
+
+	assert.Equal(t, []string{"alice_1", "alice_2"}, sortedKeys(c.GetByIndex([]byte("alice"))))
+	assert.Equal(t, []string{"bob_1"}, sortedKeys(c.GetByIndex([]byte("bob"))))
+	assert.Empty(t, c.GetByIndex([]byte("carol")))
+	assert.Equal(t, uint64(8), c.SizeInBytesContained())
+
+	removed := c.RemoveByIndex([]byte("alice"))
+	assert.Equal(t, 2, removed)
+	assert.False(t, c.Has([]byte("alice_1")))
+	assert.False(t, c.Has([]byte("alice_2")))
+	assert.Empty(t, c.GetByIndex([]byte("alice")))
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, uint64(4), c.SizeInBytesContained())
+
+	assert.Equal(t, 0, c.RemoveByIndex([]byte("alice")))
+}
+
+func TestIndexedCache_IndexShouldStayConsistentOnEvictionsAndRemovals(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewIndexedCache(2, indexByAccount)
+	c.Put([]byte("alice_1"), []byte("v1"), 1)
+	c.Put([]byte("alice_2"), []byte("v2"), 1)
+
+	evicted := c.Put([]byte("bob_1"), []byte("v3"), 1)
+	assert.True(t, evicted)
+	assert.Equal(t, []string{"alice_2"}, sortedKeys(c.GetByIndex([]byte("alice"))))
+	assert.Equal(t, uint64(2), c.SizeInBytesContained())
+
+	c.Remove([]byte("alice_2"))
+	assert.Empty(t, c.GetByIndex([]byte("alice")))
+
+	c.Clear()
+	assert.Empty(t, c.GetByIndex([]byte("bob")))
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+}
+
+func TestIndexedCache_UpdateShouldMoveEntryToNewIndexKey(t *testing.T) {
+	t.Parallel()
+
+	indexByValue := func(_, value []byte) []byte {
+		return value
+	}
+	c, _ := lrucache.NewIndexedCache(10, indexByValue)
+	c.Put([]byte("key"), []byte("group1"), 6)
+	assert.Equal(t, []string{"key"}, sortedKeys(c.GetByIndex([]byte("group1"))))
+
+	c.Put([]byte("key"), []byte("group2"), 10)
+	assert.Empty(t, c.GetByIndex([]byte("group1")))
+	assert.Equal(t, []string{"key"}, sortedKeys(c.GetByIndex([]byte("group2"))))
+	assert.Equal(t, uint64(10), c.SizeInBytesContained())
+
+	has, added := c.HasOrAdd([]byte("key"), []byte("group3"), 1)
+	assert.True(t, has)
+	assert.False(t, added)
+	assert.Empty(t, c.GetByIndex([]byte("group3")))
+
+	has, added = c.HasOrAdd([]byte("other"), []byte("group2"), 1)
+	assert.False(t, has)
+	assert.True(t, added)
+	assert.Equal(t, []string{"key", "other"}, sortedKeys(c.GetByIndex([]byte("group2"))))
+
+	val, ok := c.Get([]byte("key"))
+	assert.True(t, ok)
+	assert.Equal(t, []byte("group2"), val)
+}
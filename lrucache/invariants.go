@@ -0,0 +1,39 @@
+package lrucache
+
+import (
+	"fmt"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+type invariantsChecker interface {
+	CheckInvariants() error
+}
+
+// CheckInvariants returns an error describing the first inconsistency found in the internal structures of the
+// cache. The size aware caches are fully walked, while for the simple LRU cache only the keys list is checked
+// against the number of entries, as its internal structures are not reachable. It is meant for tests and
+// debugging, as it visits all the entries
+func (c *lruCache) CheckInvariants() error {
+	checker, ok := c.cache.(invariantsChecker)
+	if ok {
+		return checker.CheckInvariants()
+	}
+
+	keys := c.cache.Keys()
+	if len(keys) != c.cache.Len() {
+		return fmt.Errorf("%w: the keys list has %d entries while the cache has %d",
+			common.ErrCacheInvariantViolated, len(keys), c.cache.Len())
+	}
+
+	seen := make(map[interface{}]struct{}, len(keys))
+	for _, key := range keys {
+		_, found := seen[key]
+		if found {
+			return fmt.Errorf("%w: duplicated key %v", common.ErrCacheInvariantViolated, key)
+		}
+		seen[key] = struct{}{}
+	}
+
+	return nil
+}
@@ -1,22 +1,50 @@
 package lrucache
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/lrucache/capacity"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
 	"github.com/DharitriOne/drt-chain-storage-go/types"
 	lru "github.com/hashicorp/golang-lru"
 )
 
 var _ types.Cacher = (*lruCache)(nil)
+var _ types.GetAndRemover = (*lruCache)(nil)
+var _ types.CacheStatsExporter = (*lruCache)(nil)
+var _ types.FillRateProvider = (*lruCache)(nil)
+var _ types.CacheRanger = (*lruCache)(nil)
+var _ types.CacheBatchPutter = (*lruCache)(nil)
+var _ types.CacheBatchGetter = (*lruCache)(nil)
+var _ monitoring.CacheMemoryConsumer = (*lruCache)(nil)
 
 var log = logger.GetOrCreate("storage/lrucache")
 
-// LRUCache implements a Least Recently Used eviction cache
+// LRUCache implements a Least Recently Used eviction cache.
+// The eviction order is fully determined by the sequence of operations: Put, HasOrAdd (when adding) and Get mark
+// the key as the most recently used one, while Has, Peek and HasOrAdd (when the key is present) do not change the
+// order. When an eviction is needed, the least recently used key is evicted first. The added data handlers are
+// called on separate goroutines, so handlers accessing the cache may reorder it concurrently; use
+// NewCacheDeterministic when the eviction order has to be reproducible.
 type lruCache struct {
-	cache   types.SizedLRUCacheHandler
-	maxsize int
+	cache types.SizedLRUCacheHandler
+	// maxsize and cancelResize are protected by mutResize
+	mutResize    sync.Mutex
+	maxsize      int
+	cancelResize func()
+	// mutWrites is held in shared mode by the writes and exclusively by GetAndRemove, so no write of the
+	// popped key can happen between reading and removing it
+	mutWrites sync.RWMutex
+	stats     common.CacheStatsCounter
+	fillRate  common.FillRateTracker
+	// synchronousHandlers makes the added data handlers run on the caller goroutine, ordered by their ID
+	synchronousHandlers bool
+	// sized marks the caches able to count against the global cache memory limit
+	sized bool
 
 	mutAddedDataHandlers sync.RWMutex
 	mapDataHandlers      map[string]func(key []byte, value interface{})
@@ -34,6 +62,20 @@ func NewCache(size int) (*lruCache, error) {
 	return c, nil
 }
 
+// NewCacheDeterministic creates a new LRU cache instance that calls the added data handlers synchronously,
+// in the ascending order of their IDs, so the cache accesses done by the handlers can not reorder the cache
+// concurrently with the caller. The eviction order is then reproducible for a given sequence of operations
+func NewCacheDeterministic(size int) (*lruCache, error) {
+	c, err := NewCache(size)
+	if err != nil {
+		return nil, err
+	}
+
+	c.synchronousHandlers = true
+
+	return c, nil
+}
+
 // NewCacheWithEviction creates a new sized LRU cache instance with eviction function
 func NewCacheWithEviction(size int, onEvicted func(key interface{}, value interface{})) (*lruCache, error) {
 	cache, err := lru.NewWithEvict(size, onEvicted)
@@ -58,21 +100,79 @@ func createLRUCache(size int, cache *lru.Cache) *lruCache {
 	return c
 }
 
-// NewCacheWithSizeInBytes creates a new sized LRU cache instance
-func NewCacheWithSizeInBytes(size int, sizeInBytes int64) (*lruCache, error) {
-	cache, err := capacity.NewCapacityLRU(size, sizeInBytes)
-	if err != nil {
-		return nil, err
-	}
-
+// createSizedLRUCache wraps the sized cache
+func createSizedLRUCache(size int, cache types.SizedLRUCacheHandler) *lruCache {
 	c := &lruCache{
 		cache:                cache,
 		maxsize:              size,
+		sized:                true,
 		mutAddedDataHandlers: sync.RWMutex{},
 		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
 	}
 
-	return c, nil
+	return c
+}
+
+// NewCacheWithSizeInBytes creates a new sized LRU cache instance. The sized caches count against the global cache
+// memory limit set with monitoring.SetGlobalCacheMemoryLimit once registered by their owner, as the storage units do
+// for their cacher, until closed
+func NewCacheWithSizeInBytes(size int, sizeInBytes int64, options ...capacity.Option) (*lruCache, error) {
+	cache, err := capacity.NewCapacityLRU(size, sizeInBytes, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return createSizedLRUCache(size, cache), nil
+}
+
+// NewCacheWithSizeInBytesAndOverhead creates a new sized LRU cache instance that also accounts the key length and
+// the provided per entry overhead in its byte budget
+func NewCacheWithSizeInBytesAndOverhead(
+	size int,
+	sizeInBytes int64,
+	perEntryOverheadBytes int,
+	options ...capacity.Option,
+) (*lruCache, error) {
+	cache, err := capacity.NewCapacityLRUWithOverhead(size, sizeInBytes, perEntryOverheadBytes, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return createSizedLRUCache(size, cache), nil
+}
+
+// NewShardedCacheWithSizeInBytes creates a new sized LRU cache instance that partitions the keys across
+// the provided number of shards. The size and the size in bytes are applied per shard
+func NewShardedCacheWithSizeInBytes(
+	sizePerShard int,
+	sizeInBytesPerShard int64,
+	shards int,
+	options ...capacity.Option,
+) (*lruCache, error) {
+	cache, err := capacity.NewShardedSizeLRU(sizePerShard, sizeInBytesPerShard, shards, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return createSizedLRUCache(sizePerShard*shards, cache), nil
+}
+
+// NewShardedCacheWithSizeInBytesAndOverhead creates a new sharded sized LRU cache instance that also accounts the
+// key length and the provided per entry overhead in its byte budget. The size and the size in bytes are applied
+// per shard
+func NewShardedCacheWithSizeInBytesAndOverhead(
+	sizePerShard int,
+	sizeInBytesPerShard int64,
+	shards int,
+	perEntryOverheadBytes int,
+	options ...capacity.Option,
+) (*lruCache, error) {
+	cache, err := capacity.NewShardedSizeLRUWithOverhead(sizePerShard, sizeInBytesPerShard, shards, perEntryOverheadBytes, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return createSizedLRUCache(sizePerShard*shards, cache), nil
 }
 
 // Clear is used to completely clear the cache.
@@ -82,13 +182,62 @@ func (c *lruCache) Clear() {
 
 // Put adds a value to the cache.  Returns true if an eviction occurred.
 func (c *lruCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	c.mutWrites.RLock()
 	evicted = c.cache.AddSized(string(key), value, int64(sizeInBytes))
+	c.mutWrites.RUnlock()
+
+	c.monitorGrowth(sizeInBytes)
+	c.fillRate.Record(time.Now(), c.Len)
 
 	c.callAddedDataHandlers(key, value)
 
 	return evicted
 }
 
+type batchAdder interface {
+	AddSizedBatch(entries []capacity.BatchEntry) bool
+}
+
+// PutBatch adds all the provided entries to the cache. The sized caches insert them under a single lock
+// acquisition and evict once after the last entry, bringing the cache within its capacity and byte limits at the
+// end of the batch, while the other caches add them one by one. The added data handlers are called for each entry.
+// Returns true if an eviction occurred.
+func (c *lruCache) PutBatch(entries []types.CacheEntry) (evicted bool) {
+	if len(entries) == 0 {
+		return false
+	}
+
+	c.mutWrites.RLock()
+	adder, ok := c.cache.(batchAdder)
+	if ok {
+		batch := make([]capacity.BatchEntry, 0, len(entries))
+		for _, e := range entries {
+			batch = append(batch, capacity.BatchEntry{
+				Key:         string(e.Key),
+				Value:       e.Value,
+				SizeInBytes: int64(e.SizeInBytes),
+			})
+		}
+		evicted = adder.AddSizedBatch(batch)
+	} else {
+		for _, e := range entries {
+			evicted = c.cache.AddSized(string(e.Key), e.Value, int64(e.SizeInBytes)) || evicted
+		}
+	}
+	c.mutWrites.RUnlock()
+
+	for _, e := range entries {
+		c.monitorGrowth(e.SizeInBytes)
+	}
+	c.fillRate.Record(time.Now(), c.Len)
+
+	for _, e := range entries {
+		c.callAddedDataHandlers(e.Key, e.Value)
+	}
+
+	return evicted
+}
+
 // RegisterHandler registers a new handler to be called when a new data is added
 func (c *lruCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
 	if handler == nil {
@@ -108,9 +257,63 @@ func (c *lruCache) UnRegisterHandler(id string) {
 	c.mutAddedDataHandlers.Unlock()
 }
 
-// Get looks up a key's value from the cache.
+// Get looks up a key's value from the cache, counting the lookup as a hit or as a miss.
 func (c *lruCache) Get(key []byte) (value interface{}, ok bool) {
-	return c.cache.Get(string(key))
+	value, ok = c.cache.Get(string(key))
+	c.stats.Record(ok)
+
+	return value, ok
+}
+
+type batchGetter interface {
+	GetBatch(keys []interface{}) map[interface{}]interface{}
+}
+
+// GetMulti looks up all the provided keys, returning the values of the found ones and marking them as the most
+// recently used ones, in order. The sized caches look up the keys under a single lock acquisition (one per touched
+// shard for the sharded caches), while the other caches look them up one by one. Each key counts as a hit or as a
+// miss
+func (c *lruCache) GetMulti(keys [][]byte) map[string]interface{} {
+	found := make(map[string]interface{})
+	getter, ok := c.cache.(batchGetter)
+	if ok {
+		batch := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			batch = append(batch, string(key))
+		}
+		for key, value := range getter.GetBatch(batch) {
+			found[key.(string)] = value
+		}
+	} else {
+		for _, key := range keys {
+			value, isFound := c.cache.Get(string(key))
+			if isFound {
+				found[string(key)] = value
+			}
+		}
+	}
+
+	for _, key := range keys {
+		_, isFound := found[string(key)]
+		c.stats.Record(isFound)
+	}
+
+	return found
+}
+
+// Stats returns the lifetime hit and miss counters of the Get calls
+func (c *lruCache) Stats() common.CacheStats {
+	return c.stats.Stats()
+}
+
+// ExportStats encodes the hit and miss counters, to be imported back with ImportStats after a restart
+func (c *lruCache) ExportStats() []byte {
+	return c.stats.Export()
+}
+
+// ImportStats adds the counters encoded by ExportStats to the current ones
+func (c *lruCache) ImportStats(exported []byte) error {
+	return c.stats.Import(exported)
 }
 
 // Has checks if a key is in the cache, without updating the
@@ -135,9 +338,13 @@ func (c *lruCache) Peek(key []byte) (value interface{}, ok bool) {
 // recent-ness or deleting it for being stale,  and if not, adds the value.
 // Returns whether found and whether an eviction occurred.
 func (c *lruCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	c.mutWrites.RLock()
 	has, _ = c.cache.AddSizedIfMissing(string(key), value, int64(sizeInBytes))
+	c.mutWrites.RUnlock()
 
 	if !has {
+		c.monitorGrowth(sizeInBytes)
+		c.fillRate.Record(time.Now(), c.Len)
 		c.callAddedDataHandlers(key, value)
 	}
 
@@ -145,6 +352,11 @@ func (c *lruCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has
 }
 
 func (c *lruCache) callAddedDataHandlers(key []byte, value interface{}) {
+	if c.synchronousHandlers {
+		c.callAddedDataHandlersSynchronously(key, value)
+		return
+	}
+
 	c.mutAddedDataHandlers.RLock()
 	for _, handler := range c.mapDataHandlers {
 		go handler(key, value)
@@ -152,9 +364,44 @@ func (c *lruCache) callAddedDataHandlers(key []byte, value interface{}) {
 	c.mutAddedDataHandlers.RUnlock()
 }
 
+func (c *lruCache) callAddedDataHandlersSynchronously(key []byte, value interface{}) {
+	c.mutAddedDataHandlers.RLock()
+	ids := make([]string, 0, len(c.mapDataHandlers))
+	handlers := make(map[string]func(key []byte, value interface{}), len(c.mapDataHandlers))
+	for id, handler := range c.mapDataHandlers {
+		ids = append(ids, id)
+		handlers[id] = handler
+	}
+	c.mutAddedDataHandlers.RUnlock()
+
+	// the handlers are called outside the lock so they can (un)register handlers themselves
+	sort.Strings(ids)
+	for _, id := range ids {
+		handlers[id](key, value)
+	}
+}
+
 // Remove removes the provided key from the cache.
 func (c *lruCache) Remove(key []byte) {
+	c.mutWrites.RLock()
 	c.cache.Remove(string(key))
+	c.mutWrites.RUnlock()
+}
+
+// GetAndRemove atomically returns the value of the key and removes it from the cache, so when several callers pop
+// the same key only one of them gets the value. Returns false if the key is missing
+func (c *lruCache) GetAndRemove(key []byte) (value interface{}, ok bool) {
+	c.mutWrites.Lock()
+	defer c.mutWrites.Unlock()
+
+	value, ok = c.cache.Peek(string(key))
+	if !ok {
+		return nil, false
+	}
+
+	c.cache.Remove(string(key))
+
+	return value, true
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
@@ -169,6 +416,38 @@ func (c *lruCache) Keys() [][]byte {
 	return r
 }
 
+type entriesRanger interface {
+	Range(handler func(key interface{}, value interface{}) bool)
+}
+
+// Range calls the handler for each entry, from oldest to newest, without changing the eviction order. The
+// iteration stops when the handler returns false. The sized caches hold their lock while calling the handler, so
+// the handler must not access the cache. The other caches iterate over a snapshot of the keys, skipping the
+// entries removed meanwhile. For the sharded caches the order is kept only within the same shard
+func (c *lruCache) Range(handler func(key []byte, value interface{}) bool) {
+	if handler == nil {
+		return
+	}
+
+	ranger, ok := c.cache.(entriesRanger)
+	if ok {
+		ranger.Range(func(key interface{}, value interface{}) bool {
+			return handler([]byte(key.(string)), value)
+		})
+		return
+	}
+
+	for _, key := range c.cache.Keys() {
+		value, found := c.cache.Peek(key)
+		if !found {
+			continue
+		}
+		if !handler([]byte(key.(string)), value) {
+			return
+		}
+	}
+}
+
 // Len returns the number of items in the cache.
 func (c *lruCache) Len() int {
 	return c.cache.Len()
@@ -179,16 +458,67 @@ func (c *lruCache) SizeInBytesContained() uint64 {
 	return c.cache.SizeInBytesContained()
 }
 
+// AccountedOverhead returns the bytes accounted for the keys and the per entry overhead of all contained elements.
+// It returns 0 for the caches not accounting the overhead
+func (c *lruCache) AccountedOverhead() int64 {
+	overheadAccounter, ok := c.cache.(interface{ AccountedOverhead() int64 })
+	if !ok {
+		return 0
+	}
+
+	return overheadAccounter.AccountedOverhead()
+}
+
 // MaxSize returns the maximum number of items which can be stored in cache.
 func (c *lruCache) MaxSize() int {
+	c.mutResize.Lock()
+	defer c.mutResize.Unlock()
+
 	return c.maxsize
 }
 
-// Close does nothing for this cacher implementation
+// FillRate returns how fast the cache filled during the last minute, in entries per second, and the predicted time
+// left until the maximum number of entries is reached at this rate
+func (c *lruCache) FillRate() (entriesPerSec float64, timeToFull time.Duration) {
+	return c.fillRate.FillRate(time.Now(), c.Len(), c.MaxSize())
+}
+
+// Close stops the ongoing asynchronous downgrade, if any, and unregisters the cache from the global cache memory
+// limit
 func (c *lruCache) Close() error {
+	c.mutResize.Lock()
+	c.stopResize()
+	c.mutResize.Unlock()
+
+	if c.sized {
+		monitoring.UnregisterCacheMemoryConsumer(c)
+	}
+
 	return nil
 }
 
+func (c *lruCache) monitorGrowth(sizeInBytes int) {
+	if c.sized {
+		monitoring.MonitorCacheGrowth(sizeInBytes)
+	}
+}
+
+type bytesEvicter interface {
+	EvictBytes(numBytes uint64) uint64
+}
+
+// EvictBytes removes the least recently used entries until at least the provided number of bytes is freed or the
+// cache is empty, as asked by the global cache memory limit. Returns the number of freed bytes, always 0 for the
+// caches not tracking the sizes
+func (c *lruCache) EvictBytes(numBytes uint64) uint64 {
+	evicter, ok := c.cache.(bytesEvicter)
+	if !ok {
+		return 0
+	}
+
+	return evicter.EvictBytes(numBytes)
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (c *lruCache) IsInterfaceNil() bool {
 	return c == nil
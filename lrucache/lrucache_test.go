@@ -36,6 +36,26 @@ func TestNewCache_ShouldWork(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+//------- NewCacheDeterministic
+
+func TestNewCacheDeterministic_BadSizeShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewCacheDeterministic(0)
+
+	assert.True(t, check.IfNil(c))
+	assert.NotNil(t, err)
+}
+
+func TestNewCacheDeterministic_ShouldWork(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewCacheDeterministic(1)
+
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+}
+
 //------- NewCacheWithSizeInBytes
 
 func TestNewCacheWithSizeInBytes_BadSizeShouldErr(t *testing.T) {
@@ -65,6 +85,27 @@ func TestNewCacheWithSizeInBytes_ShouldWork(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+//------- NewShardedCacheWithSizeInBytes
+
+func TestNewShardedCacheWithSizeInBytes_BadShardsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewShardedCacheWithSizeInBytes(1, 100000, 0)
+
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheShardsInvalid, err)
+}
+
+func TestNewShardedCacheWithSizeInBytes_ShouldWork(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewShardedCacheWithSizeInBytes(10, 100000, 4)
+
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+	assert.Equal(t, 40, c.MaxSize())
+}
+
 func TestLRUCache_PutNotPresent(t *testing.T) {
 	t.Parallel()
 
@@ -411,6 +452,88 @@ func TestLRUCache_CacherRegisterHasOrAddAddedDataHandlerNotAddedShouldNotCall(t
 	assert.Equal(t, 1, len(c.AddedDataHandlers()))
 }
 
+func TestLRUCache_DeterministicShouldCallHandlersSynchronouslyInIDOrder(t *testing.T) {
+	t.Parallel()
+
+	calls := make([]string, 0)
+	c, _ := lrucache.NewCacheDeterministic(2)
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		calls = append(calls, "b")
+	}, "b")
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		calls = append(calls, "a")
+		// accessing the cache from the handler marks the key as recently used before Put returns
+		_, _ = c.Get([]byte("key1"))
+	}, "a")
+
+	c.Put([]byte("key1"), "value1", 0)
+	assert.Equal(t, []string{"a", "b"}, calls)
+
+	c.Put([]byte("key2"), "value2", 0)
+	c.Put([]byte("key3"), "value3", 0)
+	assert.Equal(t, []string{"a", "b", "a", "b", "a", "b"}, calls)
+	assert.Equal(t, [][]byte{[]byte("key3"), []byte("key1")}, c.Keys())
+}
+
+func TestLRUCache_EvictionOrderShouldBeStable(t *testing.T) {
+	t.Parallel()
+
+	constructors := map[string]func() types.Cacher{
+		"lru": func() types.Cacher {
+			c, _ := lrucache.NewCache(3)
+			return c
+		},
+		"deterministic lru": func() types.Cacher {
+			c, _ := lrucache.NewCacheDeterministic(3)
+			return c
+		},
+		"size lru": func() types.Cacher {
+			c, _ := lrucache.NewCacheWithSizeInBytes(3, 1000)
+			return c
+		},
+	}
+
+	for name, constructor := range constructors {
+		for run := 0; run < 10; run++ {
+			c := constructor()
+			c.Put([]byte("key1"), "value", 1)
+			c.Put([]byte("key2"), "value", 1)
+			c.Put([]byte("key3"), "value", 1)
+
+			// Get refreshes key1, Has and Peek do not refresh key2
+			_, _ = c.Get([]byte("key1"))
+			_ = c.Has([]byte("key2"))
+			_, _ = c.Peek([]byte("key2"))
+
+			c.Put([]byte("key4"), "value", 1)
+			assert.False(t, c.Has([]byte("key2")), name)
+
+			c.Put([]byte("key5"), "value", 1)
+			assert.False(t, c.Has([]byte("key3")), name)
+
+			expectedKeys := [][]byte{[]byte("key1"), []byte("key4"), []byte("key5")}
+			assert.Equal(t, expectedKeys, c.Keys(), name)
+		}
+	}
+}
+
+func TestLRUCache_AccountedOverhead(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+	c.Put([]byte("key"), "value", 5)
+	assert.Equal(t, int64(0), c.AccountedOverhead())
+
+	c, _ = lrucache.NewCacheWithSizeInBytesAndOverhead(10, 1000, 8)
+	c.Put([]byte("key"), "value", 5)
+	assert.Equal(t, int64(3+8), c.AccountedOverhead())
+	assert.Equal(t, uint64(3+8+5), c.SizeInBytesContained())
+
+	c, _ = lrucache.NewShardedCacheWithSizeInBytesAndOverhead(10, 1000, 2, 8)
+	c.Put([]byte("key"), "value", 5)
+	assert.Equal(t, int64(3+8), c.AccountedOverhead())
+}
+
 func TestLRUCache_CloseShouldNotErr(t *testing.T) {
 	t.Parallel()
 
@@ -459,3 +582,390 @@ func TestLruCache_LenDuringEviction(t *testing.T) {
 		assert.Fail(t, "test failed, deadlock occurred")
 	}
 }
+
+func TestLRUCache_ResizeAsync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid capacity should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(10)
+		assert.Equal(t, common.ErrCacheSizeInvalid, c.ResizeAsync(0))
+		assert.Equal(t, 10, c.MaxSize())
+	})
+	t.Run("sharded cache should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewShardedCacheWithSizeInBytes(10, 1000, 2)
+		assert.Equal(t, common.ErrResizeNotSupported, c.ResizeAsync(5))
+		assert.Equal(t, 20, c.MaxSize())
+	})
+	t.Run("growing should allow more items", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(2, 1000)
+		assert.Nil(t, c.ResizeAsync(3))
+		assert.Equal(t, 3, c.MaxSize())
+		for i := 0; i < 4; i++ {
+			c.Put([]byte{byte(i)}, i, 1)
+		}
+		assert.Equal(t, 3, c.Len())
+	})
+	t.Run("shrinking should evict the oldest items gradually", func(t *testing.T) {
+		t.Parallel()
+
+		numItems := 1000
+		c, _ := lrucache.NewCache(numItems)
+		for i := 0; i < numItems; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+
+		assert.Nil(t, c.ResizeAsync(10))
+		assert.Equal(t, 10, c.MaxSize())
+		assert.Greater(t, c.Len(), 10)
+
+		// the cache stays usable during the downgrade
+		c.Put([]byte("new key"), "value", 0)
+		value, ok := c.Get([]byte("new key"))
+		assert.True(t, ok)
+		assert.Equal(t, "value", value)
+
+		assert.Eventually(t, func() bool {
+			return c.Len() == 10
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.True(t, c.Has([]byte("new key")))
+		assert.True(t, c.Has([]byte(fmt.Sprintf("key%d", numItems-1))))
+		assert.False(t, c.Has([]byte("key0")))
+
+		c.Put([]byte("other key"), "value", 0)
+		assert.Equal(t, 10, c.Len())
+	})
+	t.Run("close should stop the downgrade", func(t *testing.T) {
+		t.Parallel()
+
+		numItems := 1000
+		c, _ := lrucache.NewCache(numItems)
+		for i := 0; i < numItems; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 0)
+		}
+
+		assert.Nil(t, c.ResizeAsync(10))
+		assert.Nil(t, c.Close())
+		numItemsAfterClose := c.Len()
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, numItemsAfterClose, c.Len())
+	})
+}
+
+func TestLRUCache_GetAndRemoveShouldPopOnlyOnce(t *testing.T) {
+	t.Parallel()
+
+	caches := map[string]types.GetAndRemover{}
+	simpleCache, _ := lrucache.NewCache(100)
+	caches["simple"] = simpleCache
+	sizedCache, _ := lrucache.NewCacheWithSizeInBytes(100, 1000)
+	caches["sized"] = sizedCache
+	shardedCache, _ := lrucache.NewShardedCacheWithSizeInBytes(100, 1000, 4)
+	caches["sharded"] = shardedCache
+
+	for name, c := range caches {
+		c := c
+		cacher := c.(types.Cacher)
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			value, ok := c.GetAndRemove([]byte("missing"))
+			assert.False(t, ok)
+			assert.Nil(t, value)
+
+			numKeys := 50
+			for i := 0; i < numKeys; i++ {
+				cacher.Put([]byte(fmt.Sprintf("key%d", i)), i, 1)
+			}
+
+			mut := sync.Mutex{}
+			popped := make(map[int]int)
+			wg := sync.WaitGroup{}
+			wg.Add(4)
+			for worker := 0; worker < 4; worker++ {
+				go func() {
+					defer wg.Done()
+
+					for i := 0; i < numKeys; i++ {
+						v, found := c.GetAndRemove([]byte(fmt.Sprintf("key%d", i)))
+						if !found {
+							continue
+						}
+
+						mut.Lock()
+						popped[v.(int)]++
+						mut.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			assert.Equal(t, numKeys, len(popped))
+			for _, count := range popped {
+				assert.Equal(t, 1, count)
+			}
+			assert.Equal(t, 0, cacher.Len())
+		})
+	}
+}
+
+func TestLRUCache_StatsShouldSurviveExportAndImport(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+	c.Put([]byte("key"), "value", 0)
+	_, _ = c.Get([]byte("key"))
+	_, _ = c.Get([]byte("key"))
+	_, _ = c.Get([]byte("missing"))
+	_, _ = c.Peek([]byte("missing"))
+	assert.Equal(t, common.CacheStats{Hits: 2, Misses: 1}, c.Stats())
+
+	exported := c.ExportStats()
+
+	restarted, _ := lrucache.NewCache(10)
+	_, _ = restarted.Get([]byte("missing"))
+	assert.Equal(t, common.ErrInvalidCacheStats, restarted.ImportStats(exported[1:]))
+	assert.Nil(t, restarted.ImportStats(exported))
+	assert.Equal(t, common.CacheStats{Hits: 2, Misses: 2}, restarted.Stats())
+}
+
+func TestLRUCache_FillRate(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(2)
+	entriesPerSec, timeToFull := c.FillRate()
+	assert.Equal(t, float64(0), entriesPerSec)
+	assert.Equal(t, common.NeverFull, timeToFull)
+
+	c.Put([]byte("key1"), "value", 0)
+	_, _ = c.HasOrAdd([]byte("key2"), "value", 0)
+	_, timeToFull = c.FillRate()
+	assert.Equal(t, time.Duration(0), timeToFull)
+}
+
+func TestLRUCache_RangeShouldNotChangeTheEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	testRange := func(t *testing.T, c types.Cacher) {
+		c.Put([]byte("a"), "va", 1)
+		c.Put([]byte("b"), "vb", 1)
+		c.Put([]byte("c"), "vc", 1)
+
+		ranged := make(map[string]interface{})
+		keys := make([]string, 0)
+		c.(types.CacheRanger).Range(func(key []byte, value interface{}) bool {
+			ranged[string(key)] = value
+			keys = append(keys, string(key))
+			return true
+		})
+		assert.Equal(t, []string{"a", "b", "c"}, keys)
+		assert.Equal(t, "vb", ranged["b"])
+
+		numCalls := 0
+		c.(types.CacheRanger).Range(func(key []byte, value interface{}) bool {
+			numCalls++
+			return false
+		})
+		assert.Equal(t, 1, numCalls)
+
+		c.Put([]byte("d"), "vd", 1)
+		assert.False(t, c.Has([]byte("a")))
+	}
+
+	t.Run("lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(3)
+		testRange(t, c)
+	})
+	t.Run("size lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(3, 1000)
+		testRange(t, c)
+	})
+}
+
+func TestLRUCache_PutBatch(t *testing.T) {
+	t.Parallel()
+
+	testPutBatch := func(t *testing.T, c types.Cacher) {
+		chAdded := make(chan string, 10)
+		c.RegisterHandler(func(key []byte, value interface{}) {
+			chAdded <- string(key)
+		}, "id")
+
+		assert.False(t, c.(types.CacheBatchPutter).PutBatch(nil))
+
+		evicted := c.(types.CacheBatchPutter).PutBatch([]types.CacheEntry{
+			{Key: []byte("a"), Value: "va", SizeInBytes: 1},
+			{Key: []byte("b"), Value: "vb", SizeInBytes: 1},
+			{Key: []byte("c"), Value: "vc", SizeInBytes: 1},
+			{Key: []byte("d"), Value: "vd", SizeInBytes: 1},
+		})
+		assert.True(t, evicted)
+		assert.Equal(t, 3, c.Len())
+		assert.False(t, c.Has([]byte("a")))
+		value, ok := c.Peek([]byte("d"))
+		assert.True(t, ok)
+		assert.Equal(t, "vd", value)
+
+		added := make(map[string]struct{})
+		for i := 0; i < 4; i++ {
+			select {
+			case key := <-chAdded:
+				added[key] = struct{}{}
+			case <-time.After(time.Second):
+				assert.Fail(t, "handler was not called")
+			}
+		}
+		assert.Equal(t, 4, len(added))
+	}
+
+	t.Run("lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(3)
+		testPutBatch(t, c)
+	})
+	t.Run("size lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(3, 1000)
+		testPutBatch(t, c)
+	})
+	t.Run("sharded size lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewShardedCacheWithSizeInBytes(10, 3, 1)
+		testPutBatch(t, c)
+	})
+}
+
+func TestLRUCache_GetMulti(t *testing.T) {
+	t.Parallel()
+
+	testGetMulti := func(t *testing.T, c types.Cacher) {
+		c.Put([]byte("a"), "va", 1)
+		c.Put([]byte("b"), "vb", 1)
+		c.Put([]byte("c"), "vc", 1)
+
+		found := c.(types.CacheBatchGetter).GetMulti([][]byte{[]byte("a"), []byte("missing"), []byte("b")})
+		assert.Equal(t, map[string]interface{}{"a": "va", "b": "vb"}, found)
+		assert.Equal(t, common.CacheStats{Hits: 2, Misses: 1}, c.(interface{ Stats() common.CacheStats }).Stats())
+
+		c.Put([]byte("d"), "vd", 1)
+		assert.False(t, c.Has([]byte("c")))
+		assert.True(t, c.Has([]byte("a")))
+		assert.True(t, c.Has([]byte("b")))
+		assert.Empty(t, c.(types.CacheBatchGetter).GetMulti(nil))
+	}
+
+	t.Run("lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(3)
+		testGetMulti(t, c)
+	})
+	t.Run("size lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(3, 1000)
+		testGetMulti(t, c)
+	})
+	t.Run("sharded size lru cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewShardedCacheWithSizeInBytes(10, 3, 1)
+		testGetMulti(t, c)
+	})
+}
+
+func TestLRUCache_DebugDump(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sized cache should dump the eviction order with the sizes", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(10, 1000)
+		c.Put([]byte("a"), "value", 5)
+		c.Put([]byte("b"), "value", 7)
+		_, _ = c.Get([]byte("a"))
+
+		buff := &bytes.Buffer{}
+		c.DebugDump(buff)
+
+		expected := "lru cache: len=2 maxSize=10 sizeInBytes=12\n" +
+			"0: key=62 size=7 overhead=0\n" +
+			"1: key=61 size=5 overhead=0\n"
+		assert.Equal(t, expected, buff.String())
+	})
+	t.Run("cache with overhead should dump the accounted overhead", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytesAndOverhead(10, 1000, 10)
+		c.Put([]byte("key"), "value", 5)
+
+		buff := &bytes.Buffer{}
+		c.DebugDump(buff)
+
+		expected := "lru cache: len=1 maxSize=10 sizeInBytes=18\n" +
+			"0: key=6b6579 size=5 overhead=13\n"
+		assert.Equal(t, expected, buff.String())
+	})
+	t.Run("simple cache should dump the eviction order without the sizes", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(10)
+		c.Put([]byte("a"), "value", 5)
+		c.Put([]byte("b"), "value", 7)
+
+		buff := &bytes.Buffer{}
+		c.DebugDump(buff)
+
+		expected := "lru cache: len=2 maxSize=10 sizeInBytes=0\n" +
+			"0: key=61 size=n/a overhead=n/a\n" +
+			"1: key=62 size=n/a overhead=n/a\n"
+		assert.Equal(t, expected, buff.String())
+	})
+}
+
+func TestLRUCache_CheckInvariants(t *testing.T) {
+	t.Parallel()
+
+	fill := func(c types.Cacher) {
+		for i := 0; i < 50; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i%20)), i, i)
+			if i%3 == 0 {
+				c.Remove([]byte(fmt.Sprintf("key%d", i%7)))
+			}
+		}
+	}
+
+	t.Run("simple cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(10)
+		fill(c)
+		assert.Nil(t, c.CheckInvariants())
+	})
+	t.Run("sized cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytesAndOverhead(10, 200, 5)
+		fill(c)
+		assert.Nil(t, c.CheckInvariants())
+	})
+	t.Run("sharded sized cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewShardedCacheWithSizeInBytes(10, 200, 4)
+		fill(c)
+		assert.Nil(t, c.CheckInvariants())
+	})
+}
@@ -0,0 +1,99 @@
+package lrucache
+
+import (
+	"context"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+const (
+	resizeEvictionsPerTick = 100
+	resizeTickInterval     = 10 * time.Millisecond
+)
+
+type resizer interface {
+	Resize(size int) (evicted int)
+	Len() int
+}
+
+// ResizeAsync changes the maximum number of items of the cache. MaxSize reports the new capacity immediately, but
+// when the cache shrinks, the excess items are evicted gradually in the background, oldest first, at most
+// resizeEvictionsPerTick at each tick, so no operation waits for the whole eviction. During the downgrade the cache
+// stays usable and holds more items than the new capacity. A new call, or closing the cache, cancels the ongoing
+// downgrade. It returns ErrResizeNotSupported for the sharded caches
+func (c *lruCache) ResizeAsync(newCapacity int) error {
+	if newCapacity < 1 {
+		return common.ErrCacheSizeInvalid
+	}
+
+	cacheResizer, ok := c.cache.(resizer)
+	if !ok {
+		return common.ErrResizeNotSupported
+	}
+
+	c.mutResize.Lock()
+	defer c.mutResize.Unlock()
+
+	c.stopResize()
+	c.maxsize = newCapacity
+	if cacheResizer.Len() <= newCapacity {
+		cacheResizer.Resize(newCapacity)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancelResize = cancel
+	go c.downgrade(ctx, cacheResizer, newCapacity)
+
+	return nil
+}
+
+// downgrade lowers the capacity of the underlying cache step by step, each step evicting at most
+// resizeEvictionsPerTick items
+func (c *lruCache) downgrade(ctx context.Context, cacheResizer resizer, newCapacity int) {
+	ticker := time.NewTicker(resizeTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		if c.downgradeStep(ctx, cacheResizer, newCapacity) {
+			return
+		}
+	}
+}
+
+func (c *lruCache) downgradeStep(ctx context.Context, cacheResizer resizer, newCapacity int) bool {
+	c.mutResize.Lock()
+	defer c.mutResize.Unlock()
+
+	if ctx.Err() != nil {
+		return true
+	}
+
+	stepCapacity := cacheResizer.Len() - resizeEvictionsPerTick
+	if stepCapacity <= newCapacity {
+		cacheResizer.Resize(newCapacity)
+		c.stopResize()
+		return true
+	}
+
+	cacheResizer.Resize(stepCapacity)
+
+	return false
+}
+
+// stopResize cancels the ongoing downgrade. Should be called under mutResize
+func (c *lruCache) stopResize() {
+	if c.cancelResize == nil {
+		return
+	}
+
+	c.cancelResize()
+	c.cancelResize = nil
+}
@@ -8,6 +8,8 @@ import (
 
 var _ types.Persister = (*lruDB)(nil)
 
+const lruBackendName = "lruMemoryDB"
+
 // lruDB represents the memory database storage. It holds a LRU of key value pairs
 // and a mutex to handle concurrent accesses to the map
 type lruDB struct {
@@ -34,12 +36,12 @@ func (l *lruDB) Put(key, val []byte) error {
 func (l *lruDB) Get(key []byte) ([]byte, error) {
 	val, ok := l.cacher.Get(key)
 	if !ok {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewStorageError(common.OpGet, key, lruBackendName, common.ErrKeyNotFound)
 	}
 
 	mrsVal, ok := val.([]byte)
 	if !ok {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewStorageError(common.OpGet, key, lruBackendName, common.ErrKeyNotFound)
 	}
 	return mrsVal, nil
 }
@@ -50,7 +52,7 @@ func (l *lruDB) Has(key []byte) error {
 	if has {
 		return nil
 	}
-	return common.ErrKeyNotFound
+	return common.NewStorageError(common.OpHas, key, lruBackendName, common.ErrKeyNotFound)
 }
 
 // Close closes the files/resources associated to the storage medium
@@ -75,7 +75,7 @@ func TestLruDB_HasNotPresent(t *testing.T) {
 
 	err = mdb.Has(key)
 
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestLruDB_DeletePresent(t *testing.T) {
@@ -94,7 +94,7 @@ func TestLruDB_DeletePresent(t *testing.T) {
 
 	err = mdb.Has(key)
 
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestLruDB_DeleteNotPresent(t *testing.T) {
@@ -0,0 +1,69 @@
+package memorydb
+
+import "github.com/DharitriOne/drt-chain-storage-go/types"
+
+var _ types.Snapshot = (*memorySnapshot)(nil)
+
+// memorySnapshot is a read-only view over a copy of the memory database
+type memorySnapshot struct {
+	db *DB
+}
+
+// Get returns the value associated to the key, as it was at the snapshot creation
+func (ms *memorySnapshot) Get(key []byte) ([]byte, error) {
+	return ms.db.Get(key)
+}
+
+// Has returns nil if the given key was present at the snapshot creation
+func (ms *memorySnapshot) Has(key []byte) error {
+	return ms.db.Has(key)
+}
+
+// RangeKeys will call the handler function for each (key, value) pair contained at the snapshot creation
+// If the handler returns true, the iteration will continue, otherwise will stop
+func (ms *memorySnapshot) RangeKeys(handler func(key []byte, value []byte) bool) {
+	ms.db.RangeKeys(handler)
+}
+
+// Release drops the copied data
+func (ms *memorySnapshot) Release() {
+	_ = ms.db.Destroy()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ms *memorySnapshot) IsInterfaceNil() bool {
+	return ms == nil
+}
+
+var _ types.Snapshot = (*sortedMemorySnapshot)(nil)
+
+// sortedMemorySnapshot is a read-only view over a copy of the sorted memory database
+type sortedMemorySnapshot struct {
+	db *sortedDB
+}
+
+// Get returns the value associated to the key, as it was at the snapshot creation
+func (sms *sortedMemorySnapshot) Get(key []byte) ([]byte, error) {
+	return sms.db.Get(key)
+}
+
+// Has returns nil if the given key was present at the snapshot creation
+func (sms *sortedMemorySnapshot) Has(key []byte) error {
+	return sms.db.Has(key)
+}
+
+// RangeKeys will call the handler function for each (key, value) pair contained at the snapshot creation, in
+// ascending key order. If the handler returns true, the iteration will continue, otherwise will stop
+func (sms *sortedMemorySnapshot) RangeKeys(handler func(key []byte, value []byte) bool) {
+	sms.db.RangeKeys(handler)
+}
+
+// Release drops the copied data
+func (sms *sortedMemorySnapshot) Release() {
+	_ = sms.db.Destroy()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sms *sortedMemorySnapshot) IsInterfaceNil() bool {
+	return sms == nil
+}
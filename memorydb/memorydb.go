@@ -1,50 +1,258 @@
 package memorydb
 
 import (
-	"encoding/base64"
-	"errors"
-	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/types"
 )
 
 var _ types.Persister = (*DB)(nil)
+var _ types.BatchApplier = (*DB)(nil)
+var _ types.PrefixCounter = (*DB)(nil)
+var _ types.PrefixRanger = (*DB)(nil)
+var _ types.Truncater = (*DB)(nil)
+var _ types.Snapshotter = (*DB)(nil)
+var _ types.MultiGetter = (*DB)(nil)
 
-// DB represents the memory database storage. It holds a map of key value pairs
-// and a mutex to handle concurrent accesses to the map
-type DB struct {
+const backendName = "memorydb"
+
+// defaultNumShards is the number of shards of the databases created by New
+const defaultNumShards = 16
+
+const prime32 = uint32(16777619)
+const offset32 = uint32(2166136261)
+
+type shard struct {
 	db   map[string][]byte
 	mutx sync.RWMutex
 }
 
-// New creates a new memorydb object
+// DB represents the memory database storage. The key value pairs are striped over several shards, each one holding
+// a map and a mutex to handle the concurrent accesses to it, so the operations on keys of different shards
+// proceed in parallel. The operations spanning several shards lock them in ascending order. The iterations
+// either lock all the shards for their whole duration, seeing a consistent view of the data, or one shard at a
+// time, seeing the writes done meanwhile in the shards not yet visited
+type DB struct {
+	shards          []*shard
+	consistentRange bool
+}
+
+// New creates a new memorydb object, whose iterations see a consistent view of the data
 func New() *DB {
-	return &DB{
-		db:   make(map[string][]byte),
-		mutx: sync.RWMutex{},
+	db, _ := NewWithShards(defaultNumShards, true)
+
+	return db
+}
+
+// NewWithShards creates a new memorydb object striped over the provided number of shards. If consistentRange is
+// set, the iterations lock all the shards at once, otherwise they lock one shard at a time
+func NewWithShards(numShards int, consistentRange bool) (*DB, error) {
+	if numShards < 1 {
+		return nil, common.ErrInvalidNumShards
+	}
+
+	db := &DB{
+		shards:          make([]*shard, numShards),
+		consistentRange: consistentRange,
+	}
+	for i := range db.shards {
+		db.shards[i] = &shard{
+			db: make(map[string][]byte),
+		}
+	}
+
+	return db, nil
+}
+
+func (s *DB) shardIndex(key string) int {
+	hash := offset32
+	for i := 0; i < len(key); i++ {
+		hash *= prime32
+		hash ^= uint32(key[i])
+	}
+
+	return int(hash % uint32(len(s.shards)))
+}
+
+func (s *DB) getShard(key string) *shard {
+	return s.shards[s.shardIndex(key)]
+}
+
+// shardIndexes returns the sorted indexes of the shards holding the provided keys, so they can be locked in order
+func (s *DB) shardIndexes(keys []string) []int {
+	isIncluded := make(map[int]struct{}, len(s.shards))
+	indexes := make([]int, 0, len(s.shards))
+	for _, key := range keys {
+		index := s.shardIndex(key)
+		_, ok := isIncluded[index]
+		if ok {
+			continue
+		}
+
+		isIncluded[index] = struct{}{}
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+func (s *DB) allShardIndexes() []int {
+	indexes := make([]int, len(s.shards))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	return indexes
+}
+
+func (s *DB) lockShards(indexes []int) func() {
+	for _, index := range indexes {
+		s.shards[index].mutx.Lock()
+	}
+
+	return func() {
+		for _, index := range indexes {
+			s.shards[index].mutx.Unlock()
+		}
+	}
+}
+
+func (s *DB) rLockShards(indexes []int) func() {
+	for _, index := range indexes {
+		s.shards[index].mutx.RLock()
+	}
+
+	return func() {
+		for _, index := range indexes {
+			s.shards[index].mutx.RUnlock()
+		}
 	}
 }
 
 // Put adds the value to the (key, val) storage medium
 func (s *DB) Put(key, val []byte) error {
-	s.mutx.Lock()
-	defer s.mutx.Unlock()
+	sh := s.getShard(string(key))
+	sh.mutx.Lock()
+	defer sh.mutx.Unlock()
+
+	sh.db[string(key)] = val
+
+	return nil
+}
+
+// ApplyBatch atomically applies the provided Put and Remove operations, locking all the shards they touch
+func (s *DB) ApplyBatch(ops []types.Operation) error {
+	keys := make([]string, 0, len(ops))
+	for _, op := range ops {
+		if op.Type != types.PutOperation && op.Type != types.RemoveOperation {
+			return common.ErrInvalidOperationType
+		}
+
+		keys = append(keys, string(op.Key))
+	}
 
-	s.db[string(key)] = val
+	unlock := s.lockShards(s.shardIndexes(keys))
+	defer unlock()
+
+	for _, op := range ops {
+		sh := s.getShard(string(op.Key))
+		if op.Type == types.PutOperation {
+			sh.db[string(op.Key)] = op.Value
+			continue
+		}
+
+		delete(sh.db, string(op.Key))
+	}
+
+	return nil
+}
+
+// Merge copies the entries of the other database in this one. The keys present in both are set to the value
+// returned by onConflict, called with the existing and the incoming values, a nil onConflict keeping the incoming
+// values. The other database is copied first, so it can be concurrently used, while this one is locked during the
+// whole merge, so onConflict must not access it
+func (s *DB) Merge(other *DB, onConflict func(key []byte, existing, incoming []byte) []byte) error {
+	if other == nil {
+		return common.ErrNilPersister
+	}
+	if other == s {
+		return nil
+	}
+
+	incomingEntries := other.copyEntries()
+
+	unlock := s.lockShards(s.allShardIndexes())
+	defer unlock()
+
+	for key, incoming := range incomingEntries {
+		sh := s.getShard(key)
+		existing, ok := sh.db[key]
+		if ok && onConflict != nil {
+			incoming = onConflict([]byte(key), existing, incoming)
+		}
+
+		sh.db[key] = incoming
+	}
 
 	return nil
 }
 
+// copyEntries returns a copy of all the contained entries, taken under the read lock of all the shards
+func (s *DB) copyEntries() map[string][]byte {
+	unlock := s.rLockShards(s.allShardIndexes())
+	defer unlock()
+
+	numEntries := 0
+	for _, sh := range s.shards {
+		numEntries += len(sh.db)
+	}
+
+	entries := make(map[string][]byte, numEntries)
+	for _, sh := range s.shards {
+		for k, v := range sh.db {
+			entries[k] = v
+		}
+	}
+
+	return entries
+}
+
+// GetExisting returns the values of the provided keys which are present, under the read lock of all the shards
+// holding them. The missing keys are omitted
+func (s *DB) GetExisting(keys [][]byte) (map[string][]byte, error) {
+	stringKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		stringKeys = append(stringKeys, string(key))
+	}
+
+	unlock := s.rLockShards(s.shardIndexes(stringKeys))
+	defer unlock()
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range stringKeys {
+		val, ok := s.getShard(key).db[key]
+		if ok {
+			values[key] = val
+		}
+	}
+
+	return values, nil
+}
+
 // Get gets the value associated to the key, or reports an error
 func (s *DB) Get(key []byte) ([]byte, error) {
-	s.mutx.RLock()
-	defer s.mutx.RUnlock()
+	sh := s.getShard(string(key))
+	sh.mutx.RLock()
+	defer sh.mutx.RUnlock()
 
-	val, ok := s.db[string(key)]
+	val, ok := sh.db[string(key)]
 
 	if !ok {
-		return nil, fmt.Errorf("key: %s not found", base64.StdEncoding.EncodeToString(key))
+		return nil, common.NewStorageError(common.OpGet, key, backendName, common.ErrKeyNotFound)
 	}
 
 	return val, nil
@@ -52,13 +260,14 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 
 // Has returns true if the given key is present in the persistence medium, false otherwise
 func (s *DB) Has(key []byte) error {
-	s.mutx.RLock()
-	defer s.mutx.RUnlock()
+	sh := s.getShard(string(key))
+	sh.mutx.RLock()
+	defer sh.mutx.RUnlock()
 
-	_, ok := s.db[string(key)]
+	_, ok := sh.db[string(key)]
 
 	if !ok {
-		return errors.New("key not found")
+		return common.NewStorageError(common.OpHas, key, backendName, common.ErrKeyNotFound)
 	}
 	return nil
 }
@@ -71,39 +280,141 @@ func (s *DB) Close() error {
 
 // Remove removes the data associated to the given key
 func (s *DB) Remove(key []byte) error {
-	s.mutx.Lock()
-	defer s.mutx.Unlock()
+	sh := s.getShard(string(key))
+	sh.mutx.Lock()
+	defer sh.mutx.Unlock()
 
-	delete(s.db, string(key))
+	delete(sh.db, string(key))
 
 	return nil
 }
 
 // Destroy removes the storage medium stored data
 func (s *DB) Destroy() error {
-	s.mutx.Lock()
-	defer s.mutx.Unlock()
-
-	s.db = make(map[string][]byte)
+	s.clear()
 
 	return nil
 }
 
+func (s *DB) clear() {
+	unlock := s.lockShards(s.allShardIndexes())
+	defer unlock()
+
+	for _, sh := range s.shards {
+		sh.db = make(map[string][]byte)
+	}
+}
+
+// rangeEntries calls the handler for the contained (key, value) pairs, either under the read lock of all the
+// shards or under the read lock of the visited shard, until the handler returns false
+func (s *DB) rangeEntries(handler func(key string, value []byte) bool) {
+	if s.consistentRange {
+		unlock := s.rLockShards(s.allShardIndexes())
+		defer unlock()
+
+		for _, sh := range s.shards {
+			if !rangeShard(sh, handler) {
+				return
+			}
+		}
+
+		return
+	}
+
+	for _, sh := range s.shards {
+		sh.mutx.RLock()
+		shouldContinue := rangeShard(sh, handler)
+		sh.mutx.RUnlock()
+
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+func rangeShard(sh *shard, handler func(key string, value []byte) bool) bool {
+	for k, v := range sh.db {
+		if !handler(k, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // RangeKeys will iterate over all contained (key, value) pairs calling the provided handler
 func (s *DB) RangeKeys(handler func(key []byte, value []byte) bool) {
 	if handler == nil {
 		return
 	}
 
-	s.mutx.RLock()
-	defer s.mutx.RUnlock()
+	s.rangeEntries(func(key string, value []byte) bool {
+		return handler([]byte(key), value)
+	})
+}
 
-	for k, v := range s.db {
-		shouldContinue := handler([]byte(k), v)
-		if !shouldContinue {
-			return
+// FilterKeys will call the handler for each contained key whose value satisfies the provided predicate
+func (s *DB) FilterKeys(predicate func(value []byte) bool, handler func(key []byte) bool) {
+	if predicate == nil || handler == nil {
+		return
+	}
+
+	s.rangeEntries(func(key string, value []byte) bool {
+		if !predicate(value) {
+			return true
 		}
+
+		return handler([]byte(key))
+	})
+}
+
+// RangePrefix will iterate over the contained (key, value) pairs whose key starts with the provided prefix
+func (s *DB) RangePrefix(prefix []byte, handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
 	}
+
+	s.rangeEntries(func(key string, value []byte) bool {
+		if !strings.HasPrefix(key, string(prefix)) {
+			return true
+		}
+
+		return handler([]byte(key), value)
+	})
+}
+
+// CountPrefix returns the number of contained keys starting with the provided prefix
+func (s *DB) CountPrefix(prefix []byte) (uint64, error) {
+	count := uint64(0)
+	s.rangeEntries(func(key string, _ []byte) bool {
+		if strings.HasPrefix(key, string(prefix)) {
+			count++
+		}
+
+		return true
+	})
+
+	return count, nil
+}
+
+// Truncate removes all the contained keys
+func (s *DB) Truncate() error {
+	s.clear()
+
+	return nil
+}
+
+// Snapshot returns a read-only view of the contained data, backed by a copy of the current data taken under the
+// read lock of all the shards
+func (s *DB) Snapshot() (types.Snapshot, error) {
+	snapshot, _ := NewWithShards(len(s.shards), s.consistentRange)
+	for k, v := range s.copyEntries() {
+		snapshot.getShard(k).db[k] = v
+	}
+
+	return &memorySnapshot{
+		db: snapshot,
+	}, nil
 }
 
 // DestroyClosed removes the storage medium stored data
@@ -1,9 +1,15 @@
 package memorydb_test
 
 import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 
+	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,6 +39,22 @@ func TestGetNotPresent(t *testing.T) {
 
 	v, err := mdb.Get(key)
 	assert.NotNil(t, err, "error expected but got nil, value %s", v)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+}
+
+func TestGetNotPresentShouldReturnStorageError(t *testing.T) {
+	key := []byte("key2")
+	mdb := memorydb.New()
+
+	_, err := mdb.Get(key)
+
+	storageErr := &common.StorageError{}
+	assert.True(t, errors.As(err, &storageErr))
+	assert.Equal(t, common.OpGet, storageErr.Op)
+	assert.Equal(t, key, storageErr.Key)
+	assert.Equal(t, "memorydb", storageErr.Backend)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.Equal(t, "memorydb Get, key: 6b657932: key not found", err.Error())
 }
 
 func TestHasPresent(t *testing.T) {
@@ -120,3 +142,331 @@ func Test_RangeKeys(t *testing.T) {
 
 	assert.Equal(t, keysVals, recovered)
 }
+
+func Test_FilterKeys(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+
+	keysVals := map[string][]byte{
+		"key1": {0, 1},
+		"key2": {1, 2},
+		"key3": {0, 3},
+		"key4": {1, 4},
+	}
+	for key, val := range keysVals {
+		_ = mdb.Put([]byte(key), val)
+	}
+
+	predicate := func(value []byte) bool {
+		return len(value) > 0 && value[0] == 1
+	}
+
+	recovered := make(map[string]struct{})
+	mdb.FilterKeys(predicate, func(key []byte) bool {
+		recovered[string(key)] = struct{}{}
+		return true
+	})
+	assert.Equal(t, map[string]struct{}{"key2": {}, "key4": {}}, recovered)
+
+	numCalls := 0
+	mdb.FilterKeys(predicate, func(key []byte) bool {
+		numCalls++
+		return false
+	})
+	assert.Equal(t, 1, numCalls)
+}
+
+func Test_CountPrefix(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	for _, key := range []string{"acc1_a", "acc1_b", "acc2_a", "acc1"} {
+		_ = mdb.Put([]byte(key), []byte("value"))
+	}
+
+	count, err := mdb.CountPrefix([]byte("acc1_"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), count)
+
+	count, err = mdb.CountPrefix([]byte("acc3"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	count, err = mdb.CountPrefix(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), count)
+}
+
+func Test_RangePrefix(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	for _, key := range []string{"acc1_a", "acc1_b", "acc2_a", "acc1"} {
+		_ = mdb.Put([]byte(key), []byte("value"))
+	}
+
+	keys := make(map[string]struct{})
+	mdb.RangePrefix([]byte("acc1_"), func(key []byte, value []byte) bool {
+		keys[string(key)] = struct{}{}
+		return true
+	})
+	assert.Equal(t, map[string]struct{}{"acc1_a": {}, "acc1_b": {}}, keys)
+}
+
+func Test_Truncate(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("key2"), []byte("value2"))
+
+	err := mdb.Truncate()
+	assert.Nil(t, err)
+	count, _ := mdb.CountPrefix(nil)
+	assert.Equal(t, uint64(0), count)
+
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	assert.Nil(t, mdb.Has([]byte("key1")))
+	assert.NotNil(t, mdb.Has([]byte("key2")))
+}
+
+func Test_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("key2"), []byte("value2"))
+
+	snapshot, err := mdb.Snapshot()
+	assert.Nil(t, err)
+
+	_ = mdb.Put([]byte("key1"), []byte("changed"))
+	_ = mdb.Remove([]byte("key2"))
+	_ = mdb.Put([]byte("key3"), []byte("value3"))
+
+	val, err := snapshot.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value1"), val)
+	assert.Nil(t, snapshot.Has([]byte("key2")))
+	assert.NotNil(t, snapshot.Has([]byte("key3")))
+
+	numKeys := 0
+	snapshot.RangeKeys(func(key []byte, value []byte) bool {
+		numKeys++
+		return true
+	})
+	assert.Equal(t, 2, numKeys)
+
+	_, isWritable := snapshot.(interface {
+		Put(key, val []byte) error
+	})
+	assert.False(t, isWritable)
+	_, isRemovable := snapshot.(interface {
+		Remove(key []byte) error
+	})
+	assert.False(t, isRemovable)
+
+	snapshot.Release()
+	assert.Nil(t, mdb.Has([]byte("key3")))
+}
+
+func Test_ApplyBatch(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("removed"), []byte("removed value"))
+
+	ops := []types.Operation{
+		{Type: types.PutOperation, Key: []byte("key1"), Value: []byte("value1")},
+		{Type: types.RemoveOperation, Key: []byte("removed")},
+		{Type: 100, Key: []byte("key2")},
+	}
+	err := mdb.ApplyBatch(ops)
+	assert.Equal(t, common.ErrInvalidOperationType, err)
+	assert.NotNil(t, mdb.Has([]byte("key1")))
+	assert.Nil(t, mdb.Has([]byte("removed")))
+
+	err = mdb.ApplyBatch(ops[:2])
+	assert.Nil(t, err)
+	assert.Nil(t, mdb.Has([]byte("key1")))
+	assert.NotNil(t, mdb.Has([]byte("removed")))
+}
+
+func Test_GetExisting(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("key2"), []byte("value2"))
+
+	values, err := mdb.GetExisting([][]byte{[]byte("key1"), []byte("missing"), []byte("key2")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"key1": []byte("value1"), "key2": []byte("value2")}, values)
+}
+
+func Test_Merge(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil other should error", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		assert.Equal(t, common.ErrNilPersister, mdb.Merge(nil, nil))
+	})
+	t.Run("merging into itself should do nothing", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		_ = mdb.Put([]byte("key"), []byte("value"))
+
+		err := mdb.Merge(mdb, func(_ []byte, _, _ []byte) []byte {
+			assert.Fail(t, "should not resolve conflicts")
+			return nil
+		})
+		assert.Nil(t, err)
+		value, _ := mdb.Get([]byte("key"))
+		assert.Equal(t, []byte("value"), value)
+	})
+	t.Run("conflicts should be resolved by the handler", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		_ = mdb.Put([]byte("key1"), []byte("existing1"))
+		_ = mdb.Put([]byte("key2"), []byte("existing2"))
+		other := memorydb.New()
+		_ = other.Put([]byte("key2"), []byte("incoming2"))
+		_ = other.Put([]byte("key3"), []byte("incoming3"))
+
+		err := mdb.Merge(other, func(key []byte, existing, incoming []byte) []byte {
+			assert.Equal(t, []byte("key2"), key)
+			return append(append(existing, '+'), incoming...)
+		})
+		assert.Nil(t, err)
+
+		values, _ := mdb.GetExisting([][]byte{[]byte("key1"), []byte("key2"), []byte("key3")})
+		expected := map[string][]byte{
+			"key1": []byte("existing1"),
+			"key2": []byte("existing2+incoming2"),
+			"key3": []byte("incoming3"),
+		}
+		assert.Equal(t, expected, values)
+		count, _ := other.CountPrefix(nil)
+		assert.Equal(t, uint64(2), count)
+	})
+	t.Run("nil handler should keep the incoming values", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		_ = mdb.Put([]byte("key"), []byte("existing"))
+		other := memorydb.New()
+		_ = other.Put([]byte("key"), []byte("incoming"))
+
+		err := mdb.Merge(other, nil)
+		assert.Nil(t, err)
+		value, _ := mdb.Get([]byte("key"))
+		assert.Equal(t, []byte("incoming"), value)
+	})
+}
+
+func TestNewWithShards(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid number of shards should error", func(t *testing.T) {
+		t.Parallel()
+
+		mdb, err := memorydb.NewWithShards(0, true)
+		assert.Nil(t, mdb)
+		assert.Equal(t, common.ErrInvalidNumShards, err)
+	})
+	for _, consistentRange := range []bool{true, false} {
+		consistentRange := consistentRange
+		t.Run(fmt.Sprintf("consistent range %v should visit all keys", consistentRange), func(t *testing.T) {
+			t.Parallel()
+
+			mdb, err := memorydb.NewWithShards(4, consistentRange)
+			assert.Nil(t, err)
+
+			numKeys := 100
+			for i := 0; i < numKeys; i++ {
+				_ = mdb.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+			}
+
+			visited := make(map[string]struct{})
+			mdb.RangeKeys(func(key []byte, _ []byte) bool {
+				visited[string(key)] = struct{}{}
+				return true
+			})
+			assert.Equal(t, numKeys, len(visited))
+
+			count, _ := mdb.CountPrefix([]byte("key1"))
+			assert.Equal(t, uint64(11), count)
+
+			numVisited := 0
+			mdb.RangeKeys(func(_ []byte, _ []byte) bool {
+				numVisited++
+				return numVisited < 10
+			})
+			assert.Equal(t, 10, numVisited)
+		})
+	}
+}
+
+func Test_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	mdb, _ := memorydb.NewWithShards(8, false)
+	numGoroutines := 16
+	numKeys := 100
+	wg := sync.WaitGroup{}
+	wg.Add(numGoroutines)
+	for g := 0; g < numGoroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < numKeys; i++ {
+				key := []byte(fmt.Sprintf("key%d_%d", g, i))
+				_ = mdb.Put(key, key)
+				_ = mdb.ApplyBatch([]types.Operation{
+					{Type: types.PutOperation, Key: key, Value: key},
+					{Type: types.RemoveOperation, Key: []byte(fmt.Sprintf("key%d_%d", (g+1)%numGoroutines, i))},
+				})
+				mdb.RangePrefix([]byte("key0_"), func(_ []byte, _ []byte) bool {
+					return true
+				})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for g := 0; g < numGoroutines; g++ {
+		for i := 0; i < numKeys; i++ {
+			key := []byte(fmt.Sprintf("key%d_%d", g, i))
+			value, err := mdb.Get(key)
+			if err == nil {
+				assert.Equal(t, key, value)
+			}
+		}
+	}
+}
+
+// BenchmarkDB_ConcurrentPutAndGet measures the throughput of concurrent operations on distinct keys, with the data
+// held in a single shard, as a single mutex, and striped over the default number of shards
+func BenchmarkDB_ConcurrentPutAndGet(b *testing.B) {
+	for _, numShards := range []int{1, 16} {
+		b.Run(fmt.Sprintf("%d shards", numShards), func(b *testing.B) {
+			mdb, _ := memorydb.NewWithShards(numShards, true)
+			value := []byte("value")
+			keyIndex := uint32(0)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				key := []byte(fmt.Sprintf("key%d", atomic.AddUint32(&keyIndex, 1)))
+				for pb.Next() {
+					_ = mdb.Put(key, value)
+					_, _ = mdb.Get(key)
+				}
+			})
+		})
+	}
+}
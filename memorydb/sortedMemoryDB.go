@@ -0,0 +1,210 @@
+package memorydb
+
+import (
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var _ types.Persister = (*sortedDB)(nil)
+var _ types.BatchApplier = (*sortedDB)(nil)
+var _ types.PrefixCounter = (*sortedDB)(nil)
+var _ types.PrefixRanger = (*sortedDB)(nil)
+var _ types.Truncater = (*sortedDB)(nil)
+var _ types.Snapshotter = (*sortedDB)(nil)
+
+const sortedBackendName = "sortedMemoryDB"
+
+// sortedDB is a memory database keeping the keys sorted in the leveldb memtable skip list, with the same bytewise
+// ordering as the leveldb persisters. RangeKeys and RangePrefix visit the keys in ascending order in O(log n + k),
+// without sorting them on each call, making it a better test double than DB for the range heavy code.
+// As the leveldb memtable, the skip list does not reclaim the memory of the overwritten and removed entries until
+// the database is destroyed or truncated
+type sortedDB struct {
+	db   *memdb.DB
+	mutx sync.RWMutex
+}
+
+// NewSortedMemoryDB creates a new sorted memory database
+func NewSortedMemoryDB() *sortedDB {
+	return &sortedDB{
+		db: memdb.New(comparer.DefaultComparer, 0),
+	}
+}
+
+// Put adds the value to the (key, val) storage medium
+func (s *sortedDB) Put(key, val []byte) error {
+	s.mutx.Lock()
+	defer s.mutx.Unlock()
+
+	return s.db.Put(key, val)
+}
+
+// ApplyBatch atomically applies the provided Put and Remove operations
+func (s *sortedDB) ApplyBatch(ops []types.Operation) error {
+	for _, op := range ops {
+		if op.Type != types.PutOperation && op.Type != types.RemoveOperation {
+			return common.ErrInvalidOperationType
+		}
+	}
+
+	s.mutx.Lock()
+	defer s.mutx.Unlock()
+
+	for _, op := range ops {
+		if op.Type == types.PutOperation {
+			_ = s.db.Put(op.Key, op.Value)
+			continue
+		}
+
+		_ = s.db.Delete(op.Key)
+	}
+
+	return nil
+}
+
+// Get gets a copy of the value associated to the key, or reports an error
+func (s *sortedDB) Get(key []byte) ([]byte, error) {
+	s.mutx.RLock()
+	defer s.mutx.RUnlock()
+
+	val, err := s.db.Get(key)
+	if err != nil {
+		return nil, common.NewStorageError(common.OpGet, key, sortedBackendName, common.ErrKeyNotFound)
+	}
+
+	return cloneBytes(val), nil
+}
+
+// Has returns nil if the given key is present in the persistence medium
+func (s *sortedDB) Has(key []byte) error {
+	s.mutx.RLock()
+	defer s.mutx.RUnlock()
+
+	if !s.db.Contains(key) {
+		return common.NewStorageError(common.OpHas, key, sortedBackendName, common.ErrKeyNotFound)
+	}
+
+	return nil
+}
+
+// Close closes the files/resources associated to the storage medium
+func (s *sortedDB) Close() error {
+	// nothing to do
+	return nil
+}
+
+// Remove removes the data associated to the given key
+func (s *sortedDB) Remove(key []byte) error {
+	s.mutx.Lock()
+	defer s.mutx.Unlock()
+
+	_ = s.db.Delete(key)
+
+	return nil
+}
+
+// Destroy removes the storage medium stored data
+func (s *sortedDB) Destroy() error {
+	s.mutx.Lock()
+	defer s.mutx.Unlock()
+
+	s.db.Reset()
+
+	return nil
+}
+
+// RangeKeys will iterate over all contained (key, value) pairs in ascending key order, calling the provided handler
+// with copies of them
+func (s *sortedDB) RangeKeys(handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	s.rangeSlice(nil, handler)
+}
+
+// RangePrefix will iterate in ascending key order over the contained (key, value) pairs whose key starts with the
+// provided prefix, seeking the first of them instead of visiting all the keys
+func (s *sortedDB) RangePrefix(prefix []byte, handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	s.rangeSlice(util.BytesPrefix(prefix), handler)
+}
+
+func (s *sortedDB) rangeSlice(slice *util.Range, handler func(key []byte, value []byte) bool) {
+	s.mutx.RLock()
+	defer s.mutx.RUnlock()
+
+	iterator := s.db.NewIterator(slice)
+	defer iterator.Release()
+
+	for iterator.Next() {
+		shouldContinue := handler(cloneBytes(iterator.Key()), cloneBytes(iterator.Value()))
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+// CountPrefix returns the number of contained keys starting with the provided prefix
+func (s *sortedDB) CountPrefix(prefix []byte) (uint64, error) {
+	s.mutx.RLock()
+	defer s.mutx.RUnlock()
+
+	iterator := s.db.NewIterator(util.BytesPrefix(prefix))
+	defer iterator.Release()
+
+	count := uint64(0)
+	for iterator.Next() {
+		count++
+	}
+
+	return count, nil
+}
+
+// Truncate removes all the contained keys
+func (s *sortedDB) Truncate() error {
+	return s.Destroy()
+}
+
+// Snapshot returns a read-only view of the contained data, backed by a copy of the current keys
+func (s *sortedDB) Snapshot() (types.Snapshot, error) {
+	s.mutx.RLock()
+	defer s.mutx.RUnlock()
+
+	snapshot := NewSortedMemoryDB()
+	iterator := s.db.NewIterator(nil)
+	defer iterator.Release()
+
+	for iterator.Next() {
+		_ = snapshot.db.Put(iterator.Key(), iterator.Value())
+	}
+
+	return &sortedMemorySnapshot{
+		db: snapshot,
+	}, nil
+}
+
+// DestroyClosed removes the storage medium stored data
+func (s *sortedDB) DestroyClosed() error {
+	return s.Destroy()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (s *sortedDB) IsInterfaceNil() bool {
+	return s == nil
+}
+
+func cloneBytes(buff []byte) []byte {
+	cloned := make([]byte, len(buff))
+	copy(cloned, buff)
+
+	return cloned
+}
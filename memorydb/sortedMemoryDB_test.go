@@ -0,0 +1,165 @@
+package memorydb_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rangeAllKeys(ranger interface {
+	RangeKeys(handler func(key []byte, value []byte) bool)
+}) []string {
+	keys := make([]string, 0)
+	ranger.RangeKeys(func(key []byte, _ []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+
+	return keys
+}
+
+func TestSortedMemoryDB_PutGetHasRemove(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.NewSortedMemoryDB()
+	assert.False(t, mdb.IsInterfaceNil())
+	key, val := []byte("key"), []byte("value")
+
+	_, err := mdb.Get(key)
+	assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	assert.True(t, errors.Is(mdb.Has(key), common.ErrKeyNotFound))
+
+	assert.Nil(t, mdb.Put(key, val))
+	assert.Nil(t, mdb.Has(key))
+	recovered, err := mdb.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, val, recovered)
+
+	recovered[0] = 'x'
+	recovered, _ = mdb.Get(key)
+	assert.Equal(t, val, recovered, "the returned value should be a copy")
+
+	assert.Nil(t, mdb.Put(key, []byte("other value")))
+	recovered, _ = mdb.Get(key)
+	assert.Equal(t, []byte("other value"), recovered)
+
+	assert.Nil(t, mdb.Remove(key))
+	assert.True(t, errors.Is(mdb.Has(key), common.ErrKeyNotFound))
+	assert.Nil(t, mdb.Remove(key))
+	assert.Nil(t, mdb.Close())
+}
+
+func TestSortedMemoryDB_RangeKeysShouldMatchTheLevelDBOrder(t *testing.T) {
+	t.Parallel()
+
+	ldb, err := leveldb.NewDB(t.TempDir(), 1, 1, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	mdb := memorydb.NewSortedMemoryDB()
+	keys := [][]byte{{}, {0}, {0, 0}, {0, 1}, {1}, {0xff}, {0xff, 0}, []byte("a"), []byte("ab"), []byte("b")}
+	for i := 0; i < 100; i++ {
+		key := make([]byte, 1+i%5)
+		_, _ = rand.Read(key)
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		require.Nil(t, ldb.Put(key, key))
+		require.Nil(t, mdb.Put(key, key))
+	}
+	require.Nil(t, ldb.Remove([]byte("ab")))
+	require.Nil(t, mdb.Remove([]byte("ab")))
+
+	expectedKeys := rangeAllKeys(ldb)
+	assert.Equal(t, expectedKeys, rangeAllKeys(mdb))
+
+	numHandled := 0
+	mdb.RangeKeys(func(key []byte, value []byte) bool {
+		assert.Equal(t, expectedKeys[numHandled], string(key))
+		assert.Equal(t, key, value)
+		numHandled++
+		return numHandled < 3
+	})
+	assert.Equal(t, 3, numHandled)
+}
+
+func TestSortedMemoryDB_RangePrefixAndCountPrefix(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.NewSortedMemoryDB()
+	mdb.RangePrefix(nil, nil)
+	for _, key := range []string{"b2", "a", "b1", "b", "c", "ba"} {
+		_ = mdb.Put([]byte(key), []byte("value"))
+	}
+
+	keys := make([]string, 0)
+	mdb.RangePrefix([]byte("b"), func(key []byte, _ []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	assert.Equal(t, []string{"b", "b1", "b2", "ba"}, keys)
+
+	count, err := mdb.CountPrefix([]byte("b"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), count)
+	count, _ = mdb.CountPrefix(nil)
+	assert.Equal(t, uint64(6), count)
+}
+
+func TestSortedMemoryDB_ApplyBatch(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.NewSortedMemoryDB()
+	_ = mdb.Put([]byte("removed"), []byte("value"))
+
+	err := mdb.ApplyBatch([]types.Operation{
+		{Type: types.PutOperation, Key: []byte("key"), Value: []byte("value")},
+		{Type: types.OperationType(100), Key: []byte("invalid")},
+	})
+	assert.Equal(t, common.ErrInvalidOperationType, err)
+	assert.Equal(t, []string{"removed"}, rangeAllKeys(mdb))
+
+	err = mdb.ApplyBatch([]types.Operation{
+		{Type: types.PutOperation, Key: []byte("key"), Value: []byte("value")},
+		{Type: types.RemoveOperation, Key: []byte("removed")},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"key"}, rangeAllKeys(mdb))
+}
+
+func TestSortedMemoryDB_SnapshotAndTruncate(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.NewSortedMemoryDB()
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("key2"), []byte("value2"))
+
+	snapshot, err := mdb.Snapshot()
+	assert.Nil(t, err)
+	assert.False(t, snapshot.IsInterfaceNil())
+
+	assert.Nil(t, mdb.Truncate())
+	assert.Empty(t, rangeAllKeys(mdb))
+
+	val, err := snapshot.Get([]byte("key2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value2"), val)
+	assert.Equal(t, []string{"key1", "key2"}, rangeAllKeys(snapshot))
+	_, isWritable := snapshot.(interface {
+		Put(key, val []byte) error
+	})
+	assert.False(t, isWritable)
+
+	snapshot.Release()
+	assert.Empty(t, rangeAllKeys(snapshot))
+	assert.Nil(t, mdb.DestroyClosed())
+}
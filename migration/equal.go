@@ -0,0 +1,109 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// ErrNilStorer signals that a nil storer was provided
+var ErrNilStorer = errors.New("nil storer")
+
+// ErrUnsortedRange signals that a storer does not iterate over its keys in ascending order
+var ErrUnsortedRange = errors.New("storer does not range over the keys in ascending order")
+
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+// Equal compares the persisted (key, value) pairs of the two storers and returns whether they hold the same pairs.
+// If they diverge, the smallest key present in only one of them or having different values is returned as well.
+// Both storers are iterated at once and merged, so no more than a pair of each is held in memory. The merge
+// requires both iterations to be in ascending key order, as the leveldb persisters and the sorted memory database
+// provide. ErrUnsortedRange is returned if a key out of order is met, but as the merge stops at the first
+// difference, the keys out of order might be reported as differences instead
+func Equal(a, b types.Storer) (bool, []byte, error) {
+	if check.IfNil(a) || check.IfNil(b) {
+		return false, nil, ErrNilStorer
+	}
+
+	chPairsB := make(chan keyValue)
+	chDone := make(chan struct{})
+	chErrB := make(chan error, 1)
+	go func() {
+		defer close(chPairsB)
+		chErrB <- streamSorted(b, chPairsB, chDone)
+	}()
+
+	nextB, hasB := <-chPairsB
+	var diffKey []byte
+	var lastKeyA []byte
+	var errA error
+	a.RangeKeys(func(key []byte, value []byte) bool {
+		if lastKeyA != nil && bytes.Compare(lastKeyA, key) >= 0 {
+			errA = ErrUnsortedRange
+			return false
+		}
+		lastKeyA = key
+
+		if hasB && bytes.Compare(nextB.key, key) < 0 {
+			diffKey = nextB.key
+			return false
+		}
+		if !hasB || !bytes.Equal(nextB.key, key) || !bytes.Equal(nextB.value, value) {
+			diffKey = key
+			return false
+		}
+
+		nextB, hasB = <-chPairsB
+
+		return true
+	})
+	if diffKey == nil && hasB {
+		diffKey = nextB.key
+	}
+
+	// stop the iteration of b, draining it so it is not left blocked on a send
+	close(chDone)
+	for range chPairsB {
+	}
+
+	errB := <-chErrB
+	if errA != nil {
+		return false, nil, errA
+	}
+	if errB != nil {
+		return false, nil, errB
+	}
+	if diffKey != nil {
+		return false, diffKey, nil
+	}
+
+	return true, nil, nil
+}
+
+// streamSorted sends the pairs of the storer, in its iteration order, until the done channel is closed. It returns
+// ErrUnsortedRange if the keys are not in ascending order
+func streamSorted(storer types.Storer, chPairs chan<- keyValue, chDone <-chan struct{}) error {
+	var lastKey []byte
+	var err error
+	storer.RangeKeys(func(key []byte, value []byte) bool {
+		if lastKey != nil && bytes.Compare(lastKey, key) >= 0 {
+			err = ErrUnsortedRange
+			return false
+		}
+		lastKey = key
+
+		select {
+		case chPairs <- keyValue{key: key, value: value}:
+			return true
+		case <-chDone:
+			return false
+		}
+	})
+
+	return err
+}
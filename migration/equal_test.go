@@ -0,0 +1,107 @@
+package migration_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/migration"
+	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func createSortedUnit(t *testing.T, numPairs int) *storageUnit.Unit {
+	cache, _ := lrucache.NewCache(10)
+	unit, err := storageUnit.NewStorageUnit(cache, memorydb.NewSortedMemoryDB())
+	require.Nil(t, err)
+
+	for i := 0; i < numPairs; i++ {
+		_ = unit.Put([]byte(fmt.Sprintf("key%03d", i)), []byte(fmt.Sprintf("value%03d", i)))
+	}
+
+	return unit
+}
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil storer should error", func(t *testing.T) {
+		t.Parallel()
+
+		equal, diffKey, err := migration.Equal(nil, createSortedUnit(t, 1))
+		require.False(t, equal)
+		require.Nil(t, diffKey)
+		require.Equal(t, migration.ErrNilStorer, err)
+
+		_, _, err = migration.Equal(createSortedUnit(t, 1), nil)
+		require.Equal(t, migration.ErrNilStorer, err)
+	})
+	t.Run("unsorted storer should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		unsorted, _ := storageUnit.NewStorageUnit(cache, &testscommon.PersisterStub{
+			RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+				for _, idx := range []int{0, 1, 0} {
+					if !handler([]byte(fmt.Sprintf("key%03d", idx)), []byte(fmt.Sprintf("value%03d", idx))) {
+						return
+					}
+				}
+			},
+		})
+
+		_, _, err := migration.Equal(unsorted, createSortedUnit(t, 3))
+		require.Equal(t, migration.ErrUnsortedRange, err)
+		_, _, err = migration.Equal(createSortedUnit(t, 3), unsorted)
+		require.Equal(t, migration.ErrUnsortedRange, err)
+	})
+	t.Run("same pairs should be equal", func(t *testing.T) {
+		t.Parallel()
+
+		for _, numPairs := range []int{0, 1, 100} {
+			equal, diffKey, err := migration.Equal(createSortedUnit(t, numPairs), createSortedUnit(t, numPairs))
+			require.Nil(t, err)
+			require.True(t, equal)
+			require.Nil(t, diffKey)
+		}
+	})
+	t.Run("should return the first differing key", func(t *testing.T) {
+		t.Parallel()
+
+		testDiff := func(alter func(a, b types.Storer), expectedDiffKey string) {
+			a, b := createSortedUnit(t, 100), createSortedUnit(t, 100)
+			alter(a, b)
+
+			equal, diffKey, err := migration.Equal(a, b)
+			require.Nil(t, err)
+			require.False(t, equal)
+			require.Equal(t, expectedDiffKey, string(diffKey))
+
+			equal, diffKey, err = migration.Equal(b, a)
+			require.Nil(t, err)
+			require.False(t, equal)
+			require.Equal(t, expectedDiffKey, string(diffKey))
+		}
+
+		testDiff(func(a, b types.Storer) {
+			_ = b.Put([]byte("key050"), []byte("other value"))
+			_ = b.Put([]byte("key070"), []byte("other value"))
+		}, "key050")
+		testDiff(func(a, b types.Storer) {
+			_ = a.Remove([]byte("key042"))
+			_ = b.Remove([]byte("key080"))
+		}, "key042")
+		testDiff(func(a, b types.Storer) {
+			_ = b.Put([]byte("key042a"), []byte("extra"))
+		}, "key042a")
+		testDiff(func(a, b types.Storer) {
+			_ = a.Remove([]byte("key099"))
+		}, "key099")
+		testDiff(func(a, b types.Storer) {
+			_ = b.Put([]byte("zzz"), []byte("extra"))
+		}, "zzz")
+	})
+}
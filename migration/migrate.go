@@ -0,0 +1,182 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var log = logger.GetOrCreate("storage/migration")
+
+// ErrInvalidBatchSize signals that an invalid batch size was provided
+var ErrInvalidBatchSize = errors.New("invalid batch size")
+
+// ErrInvalidParallelism signals that an invalid parallelism value was provided
+var ErrInvalidParallelism = errors.New("invalid parallelism")
+
+// MigrateOptions holds the settings of a migration between two persisters
+type MigrateOptions struct {
+	// BatchSize is the maximum number of pairs written in the destination at once
+	BatchSize int
+	// Parallelism is the number of batches that can be written concurrently in the destination
+	Parallelism int
+	// ProgressHandler is optional and is called after each written batch with the total number of copied pairs and
+	// the last key of the written batch. Batches are written in the source iteration order only if Parallelism is 1
+	ProgressHandler func(copied uint64, lastKey []byte)
+	// ResumeFromKey is optional and, when provided, only the keys greater or equal to it are copied
+	ResumeFromKey []byte
+	// Prefix is optional and, when provided, only the keys starting with it are copied. If the source is a
+	// types.PrefixRanger only the prefixed range is iterated, otherwise all the keys are iterated and filtered
+	Prefix []byte
+}
+
+type migrator struct {
+	dst     types.Persister
+	opts    MigrateOptions
+	mut     sync.Mutex
+	copied  uint64
+	err     error
+	batches chan []types.Operation
+}
+
+// Migrate streams all the (key, value) pairs from the source persister to the destination persister in batches.
+// The batches are atomically applied if the destination is a types.BatchApplier, otherwise each pair is put separately.
+// It returns the number of pairs contained in the successfully written batches, also when an error is returned
+func Migrate(src, dst types.Persister, opts MigrateOptions) (uint64, error) {
+	if check.IfNil(src) || check.IfNil(dst) {
+		return 0, common.ErrNilPersister
+	}
+	if opts.BatchSize < 1 {
+		return 0, ErrInvalidBatchSize
+	}
+	if opts.Parallelism < 1 {
+		return 0, ErrInvalidParallelism
+	}
+
+	m := &migrator{
+		dst:     dst,
+		opts:    opts,
+		batches: make(chan []types.Operation, opts.Parallelism),
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(opts.Parallelism)
+	for i := 0; i < opts.Parallelism; i++ {
+		go func() {
+			defer wg.Done()
+			m.processBatches()
+		}()
+	}
+
+	m.readSource(src)
+	close(m.batches)
+	wg.Wait()
+
+	log.Debug("migration finished", "copied", m.copied, "error", m.err)
+
+	return m.copied, m.err
+}
+
+func (m *migrator) rangeSource(src types.Persister, handler func(key []byte, value []byte) bool) {
+	if len(m.opts.Prefix) == 0 {
+		src.RangeKeys(handler)
+		return
+	}
+
+	prefixRanger, ok := src.(types.PrefixRanger)
+	if ok {
+		prefixRanger.RangePrefix(m.opts.Prefix, handler)
+		return
+	}
+
+	src.RangeKeys(func(key []byte, value []byte) bool {
+		if !bytes.HasPrefix(key, m.opts.Prefix) {
+			return true
+		}
+
+		return handler(key, value)
+	})
+}
+
+func (m *migrator) readSource(src types.Persister) {
+	ops := make([]types.Operation, 0, m.opts.BatchSize)
+	m.rangeSource(src, func(key []byte, value []byte) bool {
+		if len(m.opts.ResumeFromKey) > 0 && bytes.Compare(key, m.opts.ResumeFromKey) < 0 {
+			return true
+		}
+
+		ops = append(ops, types.Operation{
+			Type:  types.PutOperation,
+			Key:   key,
+			Value: value,
+		})
+		if len(ops) < m.opts.BatchSize {
+			return true
+		}
+
+		m.batches <- ops
+		ops = make([]types.Operation, 0, m.opts.BatchSize)
+
+		return !m.hasFailed()
+	})
+
+	if len(ops) > 0 && !m.hasFailed() {
+		m.batches <- ops
+	}
+}
+
+func (m *migrator) processBatches() {
+	for ops := range m.batches {
+		if m.hasFailed() {
+			continue
+		}
+
+		err := m.writeBatch(ops)
+		m.onBatchProcessed(ops, err)
+	}
+}
+
+func (m *migrator) writeBatch(ops []types.Operation) error {
+	batchApplier, ok := m.dst.(types.BatchApplier)
+	if ok {
+		return batchApplier.ApplyBatch(ops)
+	}
+
+	for _, op := range ops {
+		err := m.dst.Put(op.Key, op.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *migrator) onBatchProcessed(ops []types.Operation, err error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if err != nil {
+		if m.err == nil {
+			m.err = err
+		}
+		return
+	}
+
+	m.copied += uint64(len(ops))
+	if m.opts.ProgressHandler != nil {
+		m.opts.ProgressHandler(m.copied, ops[len(ops)-1].Key)
+	}
+}
+
+func (m *migrator) hasFailed() bool {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	return m.err != nil
+}
@@ -0,0 +1,172 @@
+package migration_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/migration"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/stretchr/testify/require"
+)
+
+const numPairs = 100
+
+func createPopulatedMemoryDB() *memorydb.DB {
+	mdb := memorydb.New()
+	for i := 0; i < numPairs; i++ {
+		_ = mdb.Put([]byte(fmt.Sprintf("key%03d", i)), []byte(fmt.Sprintf("value%03d", i)))
+	}
+
+	return mdb
+}
+
+func TestMigrate_InvalidArgumentsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	opts := migration.MigrateOptions{
+		BatchSize:   10,
+		Parallelism: 1,
+	}
+
+	t.Run("nil source should error", func(t *testing.T) {
+		t.Parallel()
+
+		copied, err := migration.Migrate(nil, memorydb.New(), opts)
+		require.Equal(t, uint64(0), copied)
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("nil destination should error", func(t *testing.T) {
+		t.Parallel()
+
+		copied, err := migration.Migrate(memorydb.New(), nil, opts)
+		require.Equal(t, uint64(0), copied)
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("invalid batch size should error", func(t *testing.T) {
+		t.Parallel()
+
+		invalidOpts := opts
+		invalidOpts.BatchSize = 0
+		_, err := migration.Migrate(memorydb.New(), memorydb.New(), invalidOpts)
+		require.Equal(t, migration.ErrInvalidBatchSize, err)
+	})
+	t.Run("invalid parallelism should error", func(t *testing.T) {
+		t.Parallel()
+
+		invalidOpts := opts
+		invalidOpts.Parallelism = 0
+		_, err := migration.Migrate(memorydb.New(), memorydb.New(), invalidOpts)
+		require.Equal(t, migration.ErrInvalidParallelism, err)
+	})
+}
+
+func TestMigrate_MemoryDBToLevelDB(t *testing.T) {
+	t.Parallel()
+
+	src := createPopulatedMemoryDB()
+	dst, err := leveldb.NewDB(t.TempDir(), 10, 10, 10)
+	require.Nil(t, err)
+	defer func() {
+		_ = dst.Close()
+	}()
+
+	mutProgress := sync.Mutex{}
+	numProgressCalls := 0
+	lastCopied := uint64(0)
+	opts := migration.MigrateOptions{
+		BatchSize:   7,
+		Parallelism: 4,
+		ProgressHandler: func(copied uint64, lastKey []byte) {
+			mutProgress.Lock()
+			numProgressCalls++
+			lastCopied = copied
+			mutProgress.Unlock()
+		},
+	}
+
+	copied, err := migration.Migrate(src, dst, opts)
+	require.Nil(t, err)
+	require.Equal(t, uint64(numPairs), copied)
+	require.Equal(t, 15, numProgressCalls)
+	require.Equal(t, uint64(numPairs), lastCopied)
+
+	for i := 0; i < numPairs; i++ {
+		val, errGet := dst.Get([]byte(fmt.Sprintf("key%03d", i)))
+		require.Nil(t, errGet)
+		require.Equal(t, []byte(fmt.Sprintf("value%03d", i)), val)
+	}
+}
+
+func TestMigrate_ResumeFromKeyShouldCopyRemainingKeys(t *testing.T) {
+	t.Parallel()
+
+	src := createPopulatedMemoryDB()
+	dst := memorydb.New()
+	opts := migration.MigrateOptions{
+		BatchSize:     10,
+		Parallelism:   1,
+		ResumeFromKey: []byte("key060"),
+	}
+
+	copied, err := migration.Migrate(src, dst, opts)
+	require.Nil(t, err)
+	require.Equal(t, uint64(40), copied)
+	require.NotNil(t, dst.Has([]byte("key059")))
+	require.Nil(t, dst.Has([]byte("key060")))
+	require.Nil(t, dst.Has([]byte("key099")))
+}
+
+func TestMigrate_DestinationErrorShouldStopMigration(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+	numPuts := 0
+	dst := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			numPuts++
+			if numPuts > 15 {
+				return expectedErr
+			}
+
+			return nil
+		},
+	}
+	opts := migration.MigrateOptions{
+		BatchSize:   10,
+		Parallelism: 1,
+	}
+
+	copied, err := migration.Migrate(createPopulatedMemoryDB(), dst, opts)
+	require.Equal(t, expectedErr, err)
+	require.Equal(t, uint64(10), copied)
+	require.Less(t, numPuts, numPairs)
+}
+
+func TestMigrate_PrefixShouldCopyOnlyThePrefixedKeys(t *testing.T) {
+	t.Parallel()
+
+	src := createPopulatedMemoryDB()
+	dst := memorydb.New()
+
+	copied, err := migration.Migrate(src, dst, migration.MigrateOptions{
+		BatchSize:   3,
+		Parallelism: 1,
+		Prefix:      []byte("key05"),
+	})
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), copied)
+
+	count, _ := dst.CountPrefix(nil)
+	require.Equal(t, uint64(10), count)
+	count, _ = dst.CountPrefix([]byte("key05"))
+	require.Equal(t, uint64(10), count)
+
+	value, err := dst.Get([]byte("key057"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value057"), value)
+}
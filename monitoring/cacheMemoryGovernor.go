@@ -0,0 +1,184 @@
+package monitoring
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+
+	"github.com/DharitriOne/drt-chain-core-go/core"
+)
+
+// numChecksPerLimit is the number of limit checks done while the caches grow by the whole limit, bounding how
+// much the registered caches can exceed the limit between two checks
+const numChecksPerLimit = 64
+
+// maxEvictionRounds bounds the eviction rounds of one check, the caches growing concurrently with the evictions
+const maxEvictionRounds = 3
+
+// CacheMemoryConsumer defines a cache whose contained bytes count against the global cache memory limit
+type CacheMemoryConsumer interface {
+	SizeInBytesContained() uint64
+	EvictBytes(numBytes uint64) uint64
+	IsInterfaceNil() bool
+}
+
+var globalCacheMemoryLimit int64
+var cacheGrowthSinceCheck int64
+
+var mutCacheConsumers sync.RWMutex
+var cacheConsumers = make(map[CacheMemoryConsumer]struct{})
+
+// mutEnforce makes the concurrent callers skip the check while another one is evicting
+var mutEnforce sync.Mutex
+
+// SetGlobalCacheMemoryLimit sets the maximum number of bytes held by all the registered caches together, a limit
+// lower than 1 disabling it. When the total exceeds the limit, each cache is asked to evict its share of the
+// excess, proportional to its contained size. The total is checked right away and then each time the caches grew
+// by about 1/64 of the limit, so the total can exceed the limit by this much between two checks
+func SetGlobalCacheMemoryLimit(bytes int64) {
+	atomic.StoreInt64(&globalCacheMemoryLimit, bytes)
+	log.Debug("SetGlobalCacheMemoryLimit", "limit", core.ConvertBytes(uint64(max64(bytes, 0))),
+		"cumulated capacity", core.ConvertBytes(cumulatedSizeInBytes.GetUint64()))
+	if bytes > 0 && cumulatedSizeInBytes.Get() > bytes {
+		log.Warn("the cumulated capacity of the caches exceeds the global cache memory limit, the caches will be evicted",
+			"limit", core.ConvertBytes(uint64(bytes)), "cumulated capacity", core.ConvertBytes(cumulatedSizeInBytes.GetUint64()))
+	}
+
+	EnforceGlobalCacheMemoryLimit()
+}
+
+// GlobalCacheMemoryLimit returns the maximum number of bytes held by all the registered caches, 0 or less if
+// there is no limit
+func GlobalCacheMemoryLimit() int64 {
+	return atomic.LoadInt64(&globalCacheMemoryLimit)
+}
+
+// RegisterCacheMemoryConsumer makes the bytes contained by the cache count against the global cache memory limit
+func RegisterCacheMemoryConsumer(consumer CacheMemoryConsumer) {
+	if consumer == nil || consumer.IsInterfaceNil() {
+		return
+	}
+
+	mutCacheConsumers.Lock()
+	cacheConsumers[consumer] = struct{}{}
+	mutCacheConsumers.Unlock()
+}
+
+// UnregisterCacheMemoryConsumer stops counting the bytes contained by the cache against the global limit
+func UnregisterCacheMemoryConsumer(consumer CacheMemoryConsumer) {
+	mutCacheConsumers.Lock()
+	delete(cacheConsumers, consumer)
+	mutCacheConsumers.Unlock()
+}
+
+// IsCacheMemoryConsumerRegistered returns true if the bytes contained by the cache count against the global limit
+func IsCacheMemoryConsumerRegistered(consumer CacheMemoryConsumer) bool {
+	mutCacheConsumers.RLock()
+	defer mutCacheConsumers.RUnlock()
+
+	_, ok := cacheConsumers[consumer]
+
+	return ok
+}
+
+// TotalCachedBytes returns the number of bytes contained by all the registered caches
+func TotalCachedBytes() uint64 {
+	total := uint64(0)
+	for _, consumer := range registeredConsumers() {
+		total += consumer.SizeInBytesContained()
+	}
+
+	return total
+}
+
+// MonitorCacheGrowth records the bytes added in a registered cache, checking the global cache memory limit once
+// the caches grew enough since the last check
+func MonitorCacheGrowth(sizeInBytes int) {
+	limit := GlobalCacheMemoryLimit()
+	if limit < 1 || sizeInBytes <= 0 {
+		return
+	}
+
+	growth := atomic.AddInt64(&cacheGrowthSinceCheck, int64(sizeInBytes))
+	if growth < max64(limit/numChecksPerLimit, 1) {
+		return
+	}
+
+	EnforceGlobalCacheMemoryLimit()
+}
+
+// EnforceGlobalCacheMemoryLimit makes the registered caches evict, proportionally to their contained sizes, the
+// bytes exceeding the global cache memory limit. It does nothing if another check is in progress
+func EnforceGlobalCacheMemoryLimit() {
+	limit := GlobalCacheMemoryLimit()
+	if limit < 1 {
+		return
+	}
+	if !mutEnforce.TryLock() {
+		return
+	}
+	defer mutEnforce.Unlock()
+
+	atomic.StoreInt64(&cacheGrowthSinceCheck, 0)
+
+	consumers := registeredConsumers()
+	for round := 0; round < maxEvictionRounds; round++ {
+		sizes := make([]uint64, len(consumers))
+		total := uint64(0)
+		for i, consumer := range consumers {
+			sizes[i] = consumer.SizeInBytesContained()
+			total += sizes[i]
+		}
+		if total <= uint64(limit) {
+			return
+		}
+
+		excess := total - uint64(limit)
+		evicted := uint64(0)
+		for i, consumer := range consumers {
+			if sizes[i] == 0 {
+				continue
+			}
+
+			evicted += consumer.EvictBytes(proportionalShare(excess, sizes[i], total))
+		}
+
+		log.Debug("EnforceGlobalCacheMemoryLimit", "total", core.ConvertBytes(total),
+			"limit", core.ConvertBytes(uint64(limit)), "evicted", core.ConvertBytes(evicted))
+		if evicted == 0 {
+			return
+		}
+	}
+}
+
+// proportionalShare returns ceil(excess * size / total), computed on 128 bits. As excess < total, the high word
+// of the product is lower than total, so the division does not overflow
+func proportionalShare(excess uint64, size uint64, total uint64) uint64 {
+	hi, lo := bits.Mul64(excess, size)
+	share, rest := bits.Div64(hi, lo, total)
+	if rest > 0 {
+		share++
+	}
+
+	return share
+}
+
+func registeredConsumers() []CacheMemoryConsumer {
+	mutCacheConsumers.RLock()
+	defer mutCacheConsumers.RUnlock()
+
+	consumers := make([]CacheMemoryConsumer, 0, len(cacheConsumers))
+	for consumer := range cacheConsumers {
+		consumers = append(consumers, consumer)
+	}
+
+	return consumers
+}
+
+func max64(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,133 @@
+package monitoring_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheMemoryConsumerStub struct {
+	mut       sync.Mutex
+	size      uint64
+	requested []uint64
+}
+
+func (stub *cacheMemoryConsumerStub) SizeInBytesContained() uint64 {
+	stub.mut.Lock()
+	defer stub.mut.Unlock()
+
+	return stub.size
+}
+
+func (stub *cacheMemoryConsumerStub) EvictBytes(numBytes uint64) uint64 {
+	stub.mut.Lock()
+	defer stub.mut.Unlock()
+
+	stub.requested = append(stub.requested, numBytes)
+	if numBytes > stub.size {
+		numBytes = stub.size
+	}
+	stub.size -= numBytes
+
+	return numBytes
+}
+
+func (stub *cacheMemoryConsumerStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+// the tests below change the global limit, so they do not run in parallel
+
+func TestSetGlobalCacheMemoryLimit_ShouldEvictProportionally(t *testing.T) {
+	defer monitoring.SetGlobalCacheMemoryLimit(0)
+
+	small := &cacheMemoryConsumerStub{size: 100}
+	large := &cacheMemoryConsumerStub{size: 300}
+	monitoring.RegisterCacheMemoryConsumer(small)
+	monitoring.RegisterCacheMemoryConsumer(large)
+	defer monitoring.UnregisterCacheMemoryConsumer(small)
+	defer monitoring.UnregisterCacheMemoryConsumer(large)
+
+	assert.Equal(t, uint64(400), monitoring.TotalCachedBytes())
+
+	monitoring.SetGlobalCacheMemoryLimit(200)
+	assert.Equal(t, int64(200), monitoring.GlobalCacheMemoryLimit())
+	assert.Equal(t, []uint64{50}, small.requested)
+	assert.Equal(t, []uint64{150}, large.requested)
+	assert.Equal(t, uint64(200), monitoring.TotalCachedBytes())
+}
+
+func TestSetGlobalCacheMemoryLimit_UnderTheLimitShouldNotEvict(t *testing.T) {
+	defer monitoring.SetGlobalCacheMemoryLimit(0)
+
+	consumer := &cacheMemoryConsumerStub{size: 100}
+	monitoring.RegisterCacheMemoryConsumer(consumer)
+	defer monitoring.UnregisterCacheMemoryConsumer(consumer)
+
+	monitoring.SetGlobalCacheMemoryLimit(100)
+	assert.Empty(t, consumer.requested)
+
+	monitoring.SetGlobalCacheMemoryLimit(0)
+	monitoring.MonitorCacheGrowth(1000)
+	assert.Empty(t, consumer.requested)
+}
+
+func TestSetGlobalCacheMemoryLimit_SizedCachesShouldStayUnderTheLimit(t *testing.T) {
+	defer monitoring.SetGlobalCacheMemoryLimit(0)
+
+	cache1, _ := lrucache.NewCacheWithSizeInBytes(10000, 1000000)
+	cache2, _ := lrucache.NewShardedCacheWithSizeInBytes(10000, 1000000, 4)
+	monitoring.RegisterCacheMemoryConsumer(cache1)
+	monitoring.RegisterCacheMemoryConsumer(cache2)
+	defer func() {
+		_ = cache1.Close()
+		_ = cache2.Close()
+	}()
+
+	limit := int64(64 * 1000)
+	monitoring.SetGlobalCacheMemoryLimit(limit)
+	for i := 0; i < 1000; i++ {
+		cache1.Put([]byte(fmt.Sprintf("key%d", i)), i, 100)
+		cache2.Put([]byte(fmt.Sprintf("key%d", i)), i, 100)
+	}
+
+	// the limit is checked each time the caches grew by 1/64 of the limit
+	total := cache1.SizeInBytesContained() + cache2.SizeInBytesContained()
+	assert.LessOrEqual(t, total, uint64(limit+limit/64))
+	assert.Greater(t, cache1.Len(), 0)
+	assert.Greater(t, cache2.Len(), 0)
+
+	_, found := cache1.Get([]byte("key999"))
+	assert.True(t, found)
+	_, found = cache1.Get([]byte("key0"))
+	assert.False(t, found)
+}
+
+func TestCloseShouldUnregisterTheSizedCache(t *testing.T) {
+	defer monitoring.SetGlobalCacheMemoryLimit(0)
+
+	cache, _ := lrucache.NewCacheWithSizeInBytes(100, 100000)
+	monitoring.RegisterCacheMemoryConsumer(cache)
+	cache.Put([]byte("key"), "value", 1000)
+	assert.True(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+	_ = cache.Close()
+	assert.False(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+
+	monitoring.SetGlobalCacheMemoryLimit(10)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestSizedCacheShouldNotBeRegisteredByItsConstruction(t *testing.T) {
+	defer monitoring.SetGlobalCacheMemoryLimit(0)
+
+	cache, _ := lrucache.NewCacheWithSizeInBytes(100, 100000)
+	cache.Put([]byte("key"), "value", 1000)
+	assert.False(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+
+	monitoring.SetGlobalCacheMemoryLimit(10)
+	assert.Equal(t, 1, cache.Len())
+}
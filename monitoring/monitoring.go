@@ -1,6 +1,8 @@
 package monitoring
 
 import (
+	"sync"
+
 	"github.com/DharitriOne/drt-chain-core-go/core"
 	"github.com/DharitriOne/drt-chain-core-go/core/atomic"
 	logger "github.com/DharitriOne/drt-chain-logger-go"
@@ -10,8 +12,47 @@ var log = logger.GetOrCreate("storage")
 
 var cumulatedSizeInBytes atomic.Counter
 
+var mutPersistersSizes sync.RWMutex
+var persistersSizes = make(map[string]uint64)
+
 // MonitorNewCache adds the size in the global cumulated size variable
 func MonitorNewCache(tag string, sizeInBytes uint64) {
 	cumulatedSizeInBytes.Add(int64(sizeInBytes))
 	log.Debug("MonitorNewCache", "name", tag, "capacity", core.ConvertBytes(sizeInBytes), "cumulated", core.ConvertBytes(cumulatedSizeInBytes.GetUint64()))
 }
+
+// MonitorPersisterSize records the on-disk size of the persister identified by the provided tag,
+// replacing the previously reported value. A zero size stops tracking the persister
+func MonitorPersisterSize(tag string, sizeInBytes uint64) {
+	mutPersistersSizes.Lock()
+	if sizeInBytes == 0 {
+		delete(persistersSizes, tag)
+	} else {
+		persistersSizes[tag] = sizeInBytes
+	}
+	mutPersistersSizes.Unlock()
+
+	log.Trace("MonitorPersisterSize", "name", tag, "size", core.ConvertBytes(sizeInBytes), "total persisted", core.ConvertBytes(TotalPersistedBytes()))
+}
+
+// PersisterSize returns the last on-disk size reported by the persister identified by the provided tag, 0 if the
+// persister is not monitored
+func PersisterSize(tag string) uint64 {
+	mutPersistersSizes.RLock()
+	defer mutPersistersSizes.RUnlock()
+
+	return persistersSizes[tag]
+}
+
+// TotalPersistedBytes returns the sum of the last on-disk sizes reported by the persisters
+func TotalPersistedBytes() uint64 {
+	mutPersistersSizes.RLock()
+	defer mutPersistersSizes.RUnlock()
+
+	total := uint64(0)
+	for _, size := range persistersSizes {
+		total += size
+	}
+
+	return total
+}
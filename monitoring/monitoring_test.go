@@ -0,0 +1,23 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorPersisterSize(t *testing.T) {
+	initialTotal := TotalPersistedBytes()
+
+	MonitorPersisterSize("persister1", 100)
+	MonitorPersisterSize("persister2", 50)
+	assert.Equal(t, initialTotal+150, TotalPersistedBytes())
+	assert.Equal(t, uint64(100), PersisterSize("persister1"))
+
+	MonitorPersisterSize("persister1", 30)
+	assert.Equal(t, initialTotal+80, TotalPersistedBytes())
+
+	MonitorPersisterSize("persister1", 0)
+	MonitorPersisterSize("persister2", 0)
+	assert.Equal(t, initialTotal, TotalPersistedBytes())
+}
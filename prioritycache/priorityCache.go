@@ -0,0 +1,351 @@
+package prioritycache
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*priorityCache)(nil)
+
+var log = logger.GetOrCreate("storage/prioritycache")
+
+// ErrNilScoreFunc signals that a nil score function was provided
+var ErrNilScoreFunc = errors.New("nil score function")
+
+// EntryMeta holds the usage of a cache entry, provided to the score function
+type EntryMeta struct {
+	InsertionTime  time.Time
+	LastAccessTime time.Time
+	NumHits        uint64
+}
+
+type entry struct {
+	key   string
+	value interface{}
+	size  int
+	meta  EntryMeta
+	score float64
+	index int
+}
+
+// entryHeap is a min heap of the entries ordered by their score
+type entryHeap []*entry
+
+// Len returns the number of entries in the heap
+func (h entryHeap) Len() int {
+	return len(h)
+}
+
+// Less returns true if the entry at index i has a lower score than the one at index j
+func (h entryHeap) Less(i, j int) bool {
+	return h[i].score < h[j].score
+}
+
+// Swap swaps the entries at the provided indexes
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+// Push adds an entry at the end of the heap
+func (h *entryHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+// Pop removes the last entry of the heap
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return e
+}
+
+// priorityCache implements a cache evicting the entry with the lowest score, as computed by a user defined function
+// from the entry key, value and usage. The score of an entry is computed when it is inserted, updated by Put or hit
+// by Get, so the scores depending on the elapsed time are evaluated at those moments only. The score function is
+// called while holding the cache lock, so it must not access the cache.
+// Get hits update the entry usage, while Has, Peek and HasOrAdd (when the key is present) do not
+type priorityCache struct {
+	mut         sync.Mutex
+	capacity    int
+	score       func(key []byte, value interface{}, meta EntryMeta) float64
+	entries     map[string]*entry
+	heap        entryHeap
+	sizeInBytes uint64
+
+	mutAddedDataHandlers sync.RWMutex
+	mapDataHandlers      map[string]func(key []byte, value interface{})
+}
+
+// NewPriorityCache creates a new cache holding at most the provided number of entries and evicting the ones with
+// the lowest score first
+func NewPriorityCache(
+	capacity int,
+	score func(key []byte, value interface{}, meta EntryMeta) float64,
+) (*priorityCache, error) {
+	if capacity < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+	if score == nil {
+		return nil, ErrNilScoreFunc
+	}
+
+	return &priorityCache{
+		capacity:        capacity,
+		score:           score,
+		entries:         make(map[string]*entry, capacity),
+		heap:            make(entryHeap, 0, capacity),
+		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
+	}, nil
+}
+
+// Clear is used to completely clear the cache.
+func (c *priorityCache) Clear() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.entries = make(map[string]*entry, c.capacity)
+	c.heap = make(entryHeap, 0, c.capacity)
+	c.sizeInBytes = 0
+}
+
+// Put adds a value to the cache, the lowest scoring entry being evicted if the capacity is exceeded, even if it is
+// the added one. Returns true if an eviction occurred.
+func (c *priorityCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	if sizeInBytes < 0 {
+		log.Error("priority cache put error",
+			"key", key,
+			"error", common.ErrNegativeSizeInBytes,
+		)
+
+		return false
+	}
+
+	c.mut.Lock()
+	evicted = c.put(string(key), value, sizeInBytes)
+	c.mut.Unlock()
+
+	c.callAddedDataHandlers(key, value)
+
+	return evicted
+}
+
+func (c *priorityCache) put(key string, value interface{}, sizeInBytes int) bool {
+	now := time.Now()
+	e, ok := c.entries[key]
+	if ok {
+		c.sizeInBytes -= uint64(e.size)
+		c.sizeInBytes += uint64(sizeInBytes)
+		e.value = value
+		e.size = sizeInBytes
+		e.meta.LastAccessTime = now
+		c.rescore(e)
+
+		return false
+	}
+
+	e = &entry{
+		key:   key,
+		value: value,
+		size:  sizeInBytes,
+		meta: EntryMeta{
+			InsertionTime:  now,
+			LastAccessTime: now,
+		},
+	}
+	e.score = c.score([]byte(key), value, e.meta)
+	c.entries[key] = e
+	heap.Push(&c.heap, e)
+	c.sizeInBytes += uint64(sizeInBytes)
+
+	evicted := false
+	for len(c.heap) > c.capacity {
+		c.removeEntry(c.heap[0])
+		evicted = true
+	}
+
+	return evicted
+}
+
+func (c *priorityCache) rescore(e *entry) {
+	e.score = c.score([]byte(e.key), e.value, e.meta)
+	heap.Fix(&c.heap, e.index)
+}
+
+func (c *priorityCache) removeEntry(e *entry) {
+	heap.Remove(&c.heap, e.index)
+	delete(c.entries, e.key)
+	c.sizeInBytes -= uint64(e.size)
+}
+
+// Get looks up a key's value from the cache, counting the hit in the entry usage and updating its score.
+func (c *priorityCache) Get(key []byte) (value interface{}, ok bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	e, ok := c.entries[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	e.meta.NumHits++
+	e.meta.LastAccessTime = time.Now()
+	c.rescore(e)
+
+	return e.value, true
+}
+
+// Has checks if a key is in the cache, without updating its usage.
+func (c *priorityCache) Has(key []byte) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	_, ok := c.entries[string(key)]
+
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found) without updating its usage.
+func (c *priorityCache) Peek(key []byte) (value interface{}, ok bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	e, ok := c.entries[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+// HasOrAdd checks if a key is in the cache without updating its usage, and if not, adds the value as Put does.
+// Returns whether found and whether the value was added.
+func (c *priorityCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	if sizeInBytes < 0 {
+		return c.Has(key), false
+	}
+
+	c.mut.Lock()
+	_, has = c.entries[string(key)]
+	if has {
+		c.mut.Unlock()
+		return true, false
+	}
+
+	c.put(string(key), value, sizeInBytes)
+	c.mut.Unlock()
+
+	c.callAddedDataHandlers(key, value)
+
+	return false, true
+}
+
+// Remove removes the provided key from the cache.
+func (c *priorityCache) Remove(key []byte) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	e, ok := c.entries[string(key)]
+	if !ok {
+		return
+	}
+
+	c.removeEntry(e)
+}
+
+// Keys returns a slice of the keys in the cache, in eviction order, from the lowest score to the highest.
+func (c *priorityCache) Keys() [][]byte {
+	type scoredKey struct {
+		key   string
+		score float64
+	}
+
+	c.mut.Lock()
+	sorted := make([]scoredKey, 0, len(c.heap))
+	for _, e := range c.heap {
+		sorted = append(sorted, scoredKey{key: e.key, score: e.score})
+	}
+	c.mut.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].score < sorted[j].score
+	})
+
+	keys := make([][]byte, 0, len(sorted))
+	for _, sk := range sorted {
+		keys = append(keys, []byte(sk.key))
+	}
+
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *priorityCache) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return len(c.entries)
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (c *priorityCache) SizeInBytesContained() uint64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.sizeInBytes
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (c *priorityCache) MaxSize() int {
+	return c.capacity
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (c *priorityCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	c.mutAddedDataHandlers.Lock()
+	c.mapDataHandlers[id] = handler
+	c.mutAddedDataHandlers.Unlock()
+}
+
+// UnRegisterHandler removes the handler from the list
+func (c *priorityCache) UnRegisterHandler(id string) {
+	c.mutAddedDataHandlers.Lock()
+	delete(c.mapDataHandlers, id)
+	c.mutAddedDataHandlers.Unlock()
+}
+
+func (c *priorityCache) callAddedDataHandlers(key []byte, value interface{}) {
+	c.mutAddedDataHandlers.RLock()
+	for _, handler := range c.mapDataHandlers {
+		go handler(key, value)
+	}
+	c.mutAddedDataHandlers.RUnlock()
+}
+
+// Close does nothing for this cacher implementation
+func (c *priorityCache) Close() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *priorityCache) IsInterfaceNil() bool {
+	return c == nil
+}
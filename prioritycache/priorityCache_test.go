@@ -0,0 +1,197 @@
+package prioritycache_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/prioritycache"
+	"github.com/stretchr/testify/assert"
+)
+
+func valueScore(_ []byte, value interface{}, _ prioritycache.EntryMeta) float64 {
+	return float64(value.(int))
+}
+
+func keysAsStrings(keys [][]byte) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, string(key))
+	}
+
+	return result
+}
+
+func TestNewPriorityCache(t *testing.T) {
+	t.Parallel()
+
+	c, err := prioritycache.NewPriorityCache(0, valueScore)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	c, err = prioritycache.NewPriorityCache(10, nil)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, prioritycache.ErrNilScoreFunc, err)
+
+	c, err = prioritycache.NewPriorityCache(10, valueScore)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, c.MaxSize())
+	assert.Nil(t, c.Close())
+}
+
+func TestPriorityCache_PutGetRemove(t *testing.T) {
+	t.Parallel()
+
+	c, _ := prioritycache.NewPriorityCache(10, valueScore)
+	assert.False(t, c.Put([]byte("key"), 1, 5))
+	assert.False(t, c.Put([]byte("key"), 2, 3))
+
+	value, ok := c.Get([]byte("key"))
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, uint64(3), c.SizeInBytesContained())
+
+	has, added := c.HasOrAdd([]byte("key"), 3, 1)
+	assert.True(t, has)
+	assert.False(t, added)
+	has, added = c.HasOrAdd([]byte("key2"), 3, 1)
+	assert.False(t, has)
+	assert.True(t, added)
+	assert.Equal(t, 2, c.Len())
+
+	c.Remove([]byte("key"))
+	assert.False(t, c.Has([]byte("key")))
+	_, ok = c.Peek([]byte("key"))
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), c.SizeInBytesContained())
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+}
+
+func TestPriorityCache_ShouldEvictTheLowestScore(t *testing.T) {
+	t.Parallel()
+
+	c, _ := prioritycache.NewPriorityCache(3, valueScore)
+	c.Put([]byte("a"), 5, 0)
+	c.Put([]byte("b"), 1, 0)
+	c.Put([]byte("c"), 3, 0)
+	assert.Equal(t, []string{"b", "c", "a"}, keysAsStrings(c.Keys()))
+
+	evicted := c.Put([]byte("d"), 4, 0)
+	assert.True(t, evicted)
+	assert.Equal(t, []string{"c", "d", "a"}, keysAsStrings(c.Keys()))
+
+	// the added entry is evicted if it has the lowest score
+	evicted = c.Put([]byte("e"), 0, 0)
+	assert.True(t, evicted)
+	assert.False(t, c.Has([]byte("e")))
+
+	// updating an entry recomputes its score
+	c.Put([]byte("a"), 2, 0)
+	assert.Equal(t, []string{"a", "c", "d"}, keysAsStrings(c.Keys()))
+}
+
+func TestPriorityCache_ScoreShouldReceiveTheEntryUsage(t *testing.T) {
+	t.Parallel()
+
+	// the keys prefixed with "vip" are always kept, the others are ranked by their number of hits
+	score := func(key []byte, _ interface{}, meta prioritycache.EntryMeta) float64 {
+		if strings.HasPrefix(string(key), "vip") {
+			return 1000
+		}
+
+		return float64(meta.NumHits)
+	}
+
+	before := time.Now()
+	c, _ := prioritycache.NewPriorityCache(3, score)
+	c.Put([]byte("vip1"), "value", 0)
+	c.Put([]byte("a"), "value", 0)
+	c.Put([]byte("b"), "value", 0)
+	for i := 0; i < 3; i++ {
+		_, _ = c.Get([]byte("a"))
+	}
+	_, _ = c.Get([]byte("b"))
+
+	// Has and Peek do not count as hits
+	for i := 0; i < 5; i++ {
+		_ = c.Has([]byte("b"))
+		_, _ = c.Peek([]byte("b"))
+	}
+
+	c.Put([]byte("c"), "value", 0)
+	assert.False(t, c.Has([]byte("c")))
+	_, _ = c.Get([]byte("c"))
+	c.Put([]byte("c"), "value", 0)
+	assert.False(t, c.Has([]byte("c")))
+	assert.Equal(t, []string{"b", "a", "vip1"}, keysAsStrings(c.Keys()))
+
+	var recordedMeta prioritycache.EntryMeta
+	c2, _ := prioritycache.NewPriorityCache(1, func(_ []byte, _ interface{}, meta prioritycache.EntryMeta) float64 {
+		recordedMeta = meta
+		return 0
+	})
+	c2.Put([]byte("key"), "value", 0)
+	_, _ = c2.Get([]byte("key"))
+	assert.Equal(t, uint64(1), recordedMeta.NumHits)
+	assert.False(t, recordedMeta.InsertionTime.Before(before))
+	assert.False(t, recordedMeta.LastAccessTime.Before(recordedMeta.InsertionTime))
+}
+
+func TestPriorityCache_RegisterHandlerShouldBeCalledOnAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := prioritycache.NewPriorityCache(2, valueScore)
+	chCalled := make(chan struct{}, 1)
+	c.RegisterHandler(nil, "nil")
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		chCalled <- struct{}{}
+	}, "id")
+
+	c.Put([]byte("key"), 1, 0)
+	select {
+	case <-chCalled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "handler was not called")
+	}
+
+	c.UnRegisterHandler("id")
+}
+
+func TestPriorityCache_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := prioritycache.NewPriorityCache(20, valueScore)
+	wg := sync.WaitGroup{}
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key%d", idx%30))
+			switch idx % 5 {
+			case 0:
+				c.Put(key, idx, 1)
+			case 1:
+				_, _ = c.Get(key)
+			case 2:
+				c.HasOrAdd(key, idx, 1)
+			case 3:
+				_ = c.Keys()
+			default:
+				c.Remove(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Len(), 20)
+	assert.Equal(t, uint64(c.Len()), c.SizeInBytesContained())
+}
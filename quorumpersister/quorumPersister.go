@@ -0,0 +1,254 @@
+package quorumpersister
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*quorumPersister)(nil)
+
+var log = logger.GetOrCreate("storage/quorumpersister")
+
+// ErrEmptyPersisters signals that an empty list of persisters was provided
+var ErrEmptyPersisters = errors.New("empty persisters list")
+
+// ErrInvalidWriteQuorum signals that an invalid write quorum was provided
+var ErrInvalidWriteQuorum = errors.New("invalid write quorum")
+
+// ErrInvalidReadQuorum signals that an invalid read quorum was provided
+var ErrInvalidReadQuorum = errors.New("invalid read quorum")
+
+// ErrWriteQuorumNotReached signals that not enough persisters acknowledged a write
+var ErrWriteQuorumNotReached = errors.New("write quorum not reached")
+
+// ErrReadQuorumNotReached signals that not enough persisters answered a read
+var ErrReadQuorumNotReached = errors.New("read quorum not reached")
+
+// ErrQuorumConflict signals that the persisters answering a read returned conflicting values with no majority
+var ErrQuorumConflict = errors.New("quorum conflict")
+
+type readResult struct {
+	value []byte
+	err   error
+}
+
+// quorumPersister replicates the data over multiple persisters.
+//
+// Consistency model: writes (Put and Remove) are sent concurrently to all persisters and succeed as soon as
+// writeQuorum of them acknowledge; the remaining writes continue in the background and are not rolled back on failure.
+// Reads query all persisters concurrently and decide on the first readQuorum answers (a missing key is an answer),
+// returning the value given by most of them or ErrQuorumConflict on a tie. Choosing writeQuorum + readQuorum greater
+// than the number of persisters guarantees that a read observes the last acknowledged write. There is no read repair,
+// so lagging persisters are only made consistent by later writes.
+type quorumPersister struct {
+	persisters  []types.Persister
+	writeQuorum int
+	readQuorum  int
+}
+
+// NewQuorumPersister creates a persister that writes on all the provided persisters and reads from them using the
+// provided write and read quorums
+func NewQuorumPersister(persisters []types.Persister, writeQuorum, readQuorum int) (*quorumPersister, error) {
+	if len(persisters) == 0 {
+		return nil, ErrEmptyPersisters
+	}
+	for idx, persister := range persisters {
+		if check.IfNil(persister) {
+			return nil, fmt.Errorf("%w at index %d", common.ErrNilPersister, idx)
+		}
+	}
+	if writeQuorum < 1 || writeQuorum > len(persisters) {
+		return nil, ErrInvalidWriteQuorum
+	}
+	if readQuorum < 1 || readQuorum > len(persisters) {
+		return nil, ErrInvalidReadQuorum
+	}
+
+	return &quorumPersister{
+		persisters:  persisters,
+		writeQuorum: writeQuorum,
+		readQuorum:  readQuorum,
+	}, nil
+}
+
+func (qp *quorumPersister) write(operation string, handler func(persister types.Persister) error) error {
+	results := make(chan error, len(qp.persisters))
+	for _, persister := range qp.persisters {
+		go func(p types.Persister) {
+			results <- handler(p)
+		}(persister)
+	}
+
+	numAcks := 0
+	numFailures := 0
+	var lastErr error
+	for range qp.persisters {
+		err := <-results
+		if err == nil {
+			numAcks++
+			if numAcks == qp.writeQuorum {
+				return nil
+			}
+			continue
+		}
+
+		log.Debug("quorum persister write failed", "operation", operation, "error", err)
+		lastErr = err
+		numFailures++
+		if numFailures > len(qp.persisters)-qp.writeQuorum {
+			break
+		}
+	}
+
+	return fmt.Errorf("%w for %s, acknowledged %d/%d, last error: %v", ErrWriteQuorumNotReached, operation, numAcks, qp.writeQuorum, lastErr)
+}
+
+func (qp *quorumPersister) read(key []byte) ([]byte, error) {
+	results := make(chan readResult, len(qp.persisters))
+	for _, persister := range qp.persisters {
+		go func(p types.Persister) {
+			value, err := p.Get(key)
+			results <- readResult{value: value, err: err}
+		}(persister)
+	}
+
+	answers := make([]readResult, 0, qp.readQuorum)
+	numFailures := 0
+	var lastErr error
+	for range qp.persisters {
+		result := <-results
+		if result.err != nil && !isNotFound(result.err) {
+			lastErr = result.err
+			numFailures++
+			if numFailures > len(qp.persisters)-qp.readQuorum {
+				break
+			}
+			continue
+		}
+
+		answers = append(answers, result)
+		if len(answers) == qp.readQuorum {
+			return decide(answers)
+		}
+	}
+
+	return nil, fmt.Errorf("%w, answered %d/%d, last error: %v", ErrReadQuorumNotReached, len(answers), qp.readQuorum, lastErr)
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, common.ErrKeyNotFound)
+}
+
+// decide returns the answer given by most of the persisters, a missing key being treated as an answer as well
+func decide(answers []readResult) ([]byte, error) {
+	counts := make(map[string]int)
+	values := make(map[string]readResult)
+	for _, answer := range answers {
+		// the prefix separates the missing key answer from the stored values
+		id := "v" + string(answer.value)
+		if answer.err != nil {
+			id = "n"
+		}
+		counts[id]++
+		values[id] = answer
+	}
+
+	bestID := ""
+	bestCount := 0
+	isTie := false
+	for id, count := range counts {
+		if count > bestCount {
+			bestID = id
+			bestCount = count
+			isTie = false
+			continue
+		}
+		if count == bestCount {
+			isTie = true
+		}
+	}
+	if isTie {
+		return nil, ErrQuorumConflict
+	}
+
+	best := values[bestID]
+	if best.err != nil {
+		return nil, common.ErrKeyNotFound
+	}
+
+	return best.value, nil
+}
+
+// Put writes the value on all persisters, succeeding when the write quorum is reached
+func (qp *quorumPersister) Put(key, val []byte) error {
+	return qp.write("Put", func(persister types.Persister) error {
+		return persister.Put(key, val)
+	})
+}
+
+// Get returns the value agreed by the read quorum
+func (qp *quorumPersister) Get(key []byte) ([]byte, error) {
+	return qp.read(key)
+}
+
+// Has returns nil if the read quorum agrees the key is present
+func (qp *quorumPersister) Has(key []byte) error {
+	_, err := qp.read(key)
+
+	return err
+}
+
+// Remove removes the key from all persisters, succeeding when the write quorum is reached
+func (qp *quorumPersister) Remove(key []byte) error {
+	return qp.write("Remove", func(persister types.Persister) error {
+		return persister.Remove(key)
+	})
+}
+
+// Close closes all persisters, returning the last encountered error
+func (qp *quorumPersister) Close() error {
+	return qp.applyOnAll(func(persister types.Persister) error {
+		return persister.Close()
+	})
+}
+
+// Destroy removes the stored data of all persisters, returning the last encountered error
+func (qp *quorumPersister) Destroy() error {
+	return qp.applyOnAll(func(persister types.Persister) error {
+		return persister.Destroy()
+	})
+}
+
+// DestroyClosed removes the stored data of all already closed persisters, returning the last encountered error
+func (qp *quorumPersister) DestroyClosed() error {
+	return qp.applyOnAll(func(persister types.Persister) error {
+		return persister.DestroyClosed()
+	})
+}
+
+func (qp *quorumPersister) applyOnAll(handler func(persister types.Persister) error) error {
+	var lastErr error
+	for _, persister := range qp.persisters {
+		err := handler(persister)
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// RangeKeys iterates over the pairs of the first persister only, as the quorum can not be applied on iterations
+func (qp *quorumPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	qp.persisters[0].RangeKeys(handler)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (qp *quorumPersister) IsInterfaceNil() bool {
+	return qp == nil
+}
@@ -0,0 +1,190 @@
+package quorumpersister_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/quorumpersister"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackend = errors.New("backend error")
+
+func createFailingPersister() *testscommon.PersisterStub {
+	return &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			return errBackend
+		},
+		GetCalled: func(key []byte) ([]byte, error) {
+			return nil, errBackend
+		},
+		RemoveCalled: func(key []byte) error {
+			return errBackend
+		},
+	}
+}
+
+func createValuePersister(value []byte) *testscommon.PersisterStub {
+	return &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			return value, nil
+		},
+	}
+}
+
+func TestNewQuorumPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty persisters should error", func(t *testing.T) {
+		t.Parallel()
+
+		qp, err := quorumpersister.NewQuorumPersister(nil, 1, 1)
+		require.True(t, check.IfNil(qp))
+		require.Equal(t, quorumpersister.ErrEmptyPersisters, err)
+	})
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		qp, err := quorumpersister.NewQuorumPersister([]types.Persister{memorydb.New(), nil}, 1, 1)
+		require.True(t, check.IfNil(qp))
+		require.True(t, errors.Is(err, common.ErrNilPersister))
+	})
+	t.Run("invalid write quorum should error", func(t *testing.T) {
+		t.Parallel()
+
+		qp, err := quorumpersister.NewQuorumPersister([]types.Persister{memorydb.New()}, 0, 1)
+		require.True(t, check.IfNil(qp))
+		require.Equal(t, quorumpersister.ErrInvalidWriteQuorum, err)
+
+		qp, err = quorumpersister.NewQuorumPersister([]types.Persister{memorydb.New()}, 2, 1)
+		require.True(t, check.IfNil(qp))
+		require.Equal(t, quorumpersister.ErrInvalidWriteQuorum, err)
+	})
+	t.Run("invalid read quorum should error", func(t *testing.T) {
+		t.Parallel()
+
+		qp, err := quorumpersister.NewQuorumPersister([]types.Persister{memorydb.New()}, 1, 0)
+		require.True(t, check.IfNil(qp))
+		require.Equal(t, quorumpersister.ErrInvalidReadQuorum, err)
+
+		qp, err = quorumpersister.NewQuorumPersister([]types.Persister{memorydb.New()}, 1, 2)
+		require.True(t, check.IfNil(qp))
+		require.Equal(t, quorumpersister.ErrInvalidReadQuorum, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		qp, err := quorumpersister.NewQuorumPersister([]types.Persister{memorydb.New(), memorydb.New()}, 2, 1)
+		require.False(t, check.IfNil(qp))
+		require.Nil(t, err)
+	})
+}
+
+func TestQuorumPersister_OneFailingBackendShouldKeepQuorum(t *testing.T) {
+	t.Parallel()
+
+	first := memorydb.New()
+	second := memorydb.New()
+	qp, _ := quorumpersister.NewQuorumPersister([]types.Persister{first, createFailingPersister(), second}, 2, 2)
+
+	key, value := []byte("key"), []byte("value")
+	require.Nil(t, qp.Put(key, value))
+
+	recovered, err := qp.Get(key)
+	require.Nil(t, err)
+	require.Equal(t, value, recovered)
+	require.Nil(t, qp.Has(key))
+
+	require.Nil(t, qp.Remove(key))
+	_, err = qp.Get(key)
+	require.Equal(t, common.ErrKeyNotFound, err)
+	require.Equal(t, common.ErrKeyNotFound, qp.Has(key))
+}
+
+func TestQuorumPersister_WriteQuorumNotReachedShouldError(t *testing.T) {
+	t.Parallel()
+
+	qp, _ := quorumpersister.NewQuorumPersister(
+		[]types.Persister{memorydb.New(), createFailingPersister(), createFailingPersister()},
+		2,
+		1,
+	)
+
+	err := qp.Put([]byte("key"), []byte("value"))
+	require.True(t, errors.Is(err, quorumpersister.ErrWriteQuorumNotReached))
+
+	err = qp.Remove([]byte("key"))
+	require.True(t, errors.Is(err, quorumpersister.ErrWriteQuorumNotReached))
+}
+
+func TestQuorumPersister_ReadQuorumNotReachedShouldError(t *testing.T) {
+	t.Parallel()
+
+	qp, _ := quorumpersister.NewQuorumPersister(
+		[]types.Persister{createValuePersister([]byte("value")), createFailingPersister(), createFailingPersister()},
+		1,
+		2,
+	)
+
+	_, err := qp.Get([]byte("key"))
+	require.True(t, errors.Is(err, quorumpersister.ErrReadQuorumNotReached))
+}
+
+func TestQuorumPersister_GetShouldReturnMostCommonValue(t *testing.T) {
+	t.Parallel()
+
+	qp, _ := quorumpersister.NewQuorumPersister(
+		[]types.Persister{
+			createValuePersister([]byte("stale")),
+			createValuePersister([]byte("value")),
+			createValuePersister([]byte("value")),
+		},
+		1,
+		3,
+	)
+
+	recovered, err := qp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), recovered)
+}
+
+func TestQuorumPersister_GetWithConflictShouldError(t *testing.T) {
+	t.Parallel()
+
+	missing := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			return nil, common.ErrKeyNotFound
+		},
+	}
+	qp, _ := quorumpersister.NewQuorumPersister([]types.Persister{createValuePersister([]byte("value")), missing}, 1, 2)
+
+	_, err := qp.Get([]byte("key"))
+	require.Equal(t, quorumpersister.ErrQuorumConflict, err)
+}
+
+func TestQuorumPersister_CloseShouldCloseAllPersisters(t *testing.T) {
+	t.Parallel()
+
+	numClosed := 0
+	persister := &testscommon.PersisterStub{
+		CloseCalled: func() error {
+			numClosed++
+			return nil
+		},
+	}
+	failing := &testscommon.PersisterStub{
+		CloseCalled: func() error {
+			numClosed++
+			return errBackend
+		},
+	}
+	qp, _ := quorumpersister.NewQuorumPersister([]types.Persister{failing, persister}, 1, 1)
+
+	require.Equal(t, errBackend, qp.Close())
+	require.Equal(t, 2, numClosed)
+}
@@ -0,0 +1,257 @@
+package randomcache
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*randomCache)(nil)
+
+var log = logger.GetOrCreate("storage/randomcache")
+
+type entry struct {
+	key   string
+	value interface{}
+	size  int
+}
+
+// randomCache implements a cache that evicts uniformly chosen elements when its maximum size is reached.
+// It is meant as a comparison baseline for the other eviction policies
+type randomCache struct {
+	mut         sync.Mutex
+	maxSize     int
+	sizeInBytes uint64
+	entries     []*entry
+	indexes     map[string]int
+	randomizer  *rand.Rand
+
+	mutAddedDataHandlers sync.RWMutex
+	mapDataHandlers      map[string]func(key []byte, value interface{})
+}
+
+// NewCache creates a new random eviction cache holding at most the provided number of elements
+func NewCache(size int) (*randomCache, error) {
+	if size < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+
+	return &randomCache{
+		maxSize:         size,
+		entries:         make([]*entry, 0, size),
+		indexes:         make(map[string]int, size),
+		randomizer:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
+	}, nil
+}
+
+// Clear is used to completely clear the cache.
+func (c *randomCache) Clear() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.entries = make([]*entry, 0, c.maxSize)
+	c.indexes = make(map[string]int, c.maxSize)
+	c.sizeInBytes = 0
+}
+
+// Put adds a value to the cache. Returns true if an eviction occurred.
+func (c *randomCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	c.mut.Lock()
+	evicted = c.put(string(key), value, sizeInBytes)
+	c.mut.Unlock()
+
+	c.callAddedDataHandlers(key, value)
+
+	return evicted
+}
+
+func (c *randomCache) put(key string, value interface{}, sizeInBytes int) bool {
+	if sizeInBytes < 0 {
+		log.Error("random cache put error",
+			"key", []byte(key),
+			"error", common.ErrNegativeSizeInBytes,
+		)
+
+		return false
+	}
+
+	idx, ok := c.indexes[key]
+	if ok {
+		existing := c.entries[idx]
+		c.sizeInBytes -= uint64(existing.size)
+		c.sizeInBytes += uint64(sizeInBytes)
+		existing.value = value
+		existing.size = sizeInBytes
+
+		return false
+	}
+
+	evicted := false
+	if len(c.entries) >= c.maxSize {
+		c.removeAt(c.randomizer.Intn(len(c.entries)))
+		evicted = true
+	}
+
+	c.indexes[key] = len(c.entries)
+	c.entries = append(c.entries, &entry{
+		key:   key,
+		value: value,
+		size:  sizeInBytes,
+	})
+	c.sizeInBytes += uint64(sizeInBytes)
+
+	return evicted
+}
+
+// removeAt removes the entry from the provided position by moving the last entry in its place
+func (c *randomCache) removeAt(idx int) {
+	removed := c.entries[idx]
+	lastIdx := len(c.entries) - 1
+	last := c.entries[lastIdx]
+
+	c.entries[idx] = last
+	c.indexes[last.key] = idx
+	c.entries[lastIdx] = nil
+	c.entries = c.entries[:lastIdx]
+
+	delete(c.indexes, removed.key)
+	c.sizeInBytes -= uint64(removed.size)
+}
+
+// Get looks up a key's value from the cache.
+func (c *randomCache) Get(key []byte) (value interface{}, ok bool) {
+	return c.Peek(key)
+}
+
+// Has checks if a key is in the cache.
+func (c *randomCache) Has(key []byte) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	_, ok := c.indexes[string(key)]
+
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found). It behaves as Get since the cache does not track usage.
+func (c *randomCache) Peek(key []byte) (value interface{}, ok bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	idx, ok := c.indexes[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	return c.entries[idx].value, true
+}
+
+// HasOrAdd checks if a key is in the cache and if not, adds the value.
+// Returns whether found and whether the value was added.
+func (c *randomCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	c.mut.Lock()
+	_, has = c.indexes[string(key)]
+	if has {
+		c.mut.Unlock()
+		return true, false
+	}
+
+	c.put(string(key), value, sizeInBytes)
+	_, added = c.indexes[string(key)]
+	c.mut.Unlock()
+
+	if added {
+		c.callAddedDataHandlers(key, value)
+	}
+
+	return false, added
+}
+
+// Remove removes the provided key from the cache.
+func (c *randomCache) Remove(key []byte) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	idx, ok := c.indexes[string(key)]
+	if !ok {
+		return
+	}
+
+	c.removeAt(idx)
+}
+
+// Keys returns a slice of the keys in the cache. The keys are not ordered.
+func (c *randomCache) Keys() [][]byte {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	keys := make([][]byte, 0, len(c.entries))
+	for _, e := range c.entries {
+		keys = append(keys, []byte(e.key))
+	}
+
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *randomCache) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return len(c.entries)
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (c *randomCache) SizeInBytesContained() uint64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.sizeInBytes
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (c *randomCache) MaxSize() int {
+	return c.maxSize
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (c *randomCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	c.mutAddedDataHandlers.Lock()
+	c.mapDataHandlers[id] = handler
+	c.mutAddedDataHandlers.Unlock()
+}
+
+// UnRegisterHandler removes the handler from the list
+func (c *randomCache) UnRegisterHandler(id string) {
+	c.mutAddedDataHandlers.Lock()
+	delete(c.mapDataHandlers, id)
+	c.mutAddedDataHandlers.Unlock()
+}
+
+func (c *randomCache) callAddedDataHandlers(key []byte, value interface{}) {
+	c.mutAddedDataHandlers.RLock()
+	for _, handler := range c.mapDataHandlers {
+		go handler(key, value)
+	}
+	c.mutAddedDataHandlers.RUnlock()
+}
+
+// Close does nothing for this cacher implementation
+func (c *randomCache) Close() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *randomCache) IsInterfaceNil() bool {
+	return c == nil
+}
@@ -0,0 +1,155 @@
+package randomcache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/randomcache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCache(t *testing.T) {
+	t.Parallel()
+
+	c, err := randomcache.NewCache(0)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	c, err = randomcache.NewCache(10)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, c.MaxSize())
+}
+
+func TestRandomCache_PutGetRemove(t *testing.T) {
+	t.Parallel()
+
+	c, _ := randomcache.NewCache(10)
+	key := []byte("key")
+
+	evicted := c.Put(key, "value", 5)
+	assert.False(t, evicted)
+	assert.True(t, c.Has(key))
+	val, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, "value", val)
+	assert.Equal(t, uint64(5), c.SizeInBytesContained())
+
+	c.Put(key, "new value", 9)
+	val, _ = c.Peek(key)
+	assert.Equal(t, "new value", val)
+	assert.Equal(t, uint64(9), c.SizeInBytesContained())
+	assert.Equal(t, 1, c.Len())
+
+	has, added := c.HasOrAdd(key, "other", 1)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	c.Remove(key)
+	assert.False(t, c.Has(key))
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+	_, ok = c.Get(key)
+	assert.False(t, ok)
+}
+
+func TestRandomCache_PutShouldEvictWhenFull(t *testing.T) {
+	t.Parallel()
+
+	size := 10
+	c, _ := randomcache.NewCache(size)
+	for i := 0; i < size; i++ {
+		assert.False(t, c.Put([]byte(fmt.Sprintf("key%d", i)), i, 1))
+	}
+
+	for i := size; i < 5*size; i++ {
+		key := []byte(fmt.Sprintf("key%d", i))
+		assert.True(t, c.Put(key, i, 1))
+		assert.True(t, c.Has(key))
+		assert.Equal(t, size, c.Len())
+		assert.Equal(t, uint64(size), c.SizeInBytesContained())
+	}
+
+	keys := c.Keys()
+	assert.Equal(t, size, len(keys))
+	for _, key := range keys {
+		assert.True(t, c.Has(key))
+	}
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+}
+
+func TestRandomCache_EvictionShouldBeSpreadAcrossKeys(t *testing.T) {
+	t.Parallel()
+
+	size := 10
+	numRounds := 1000
+	numEvictions := make(map[int]int)
+	for round := 0; round < numRounds; round++ {
+		c, _ := randomcache.NewCache(size)
+		for i := 0; i <= size; i++ {
+			c.Put([]byte(fmt.Sprintf("key%d", i)), i, 1)
+		}
+
+		for i := 0; i < size; i++ {
+			if !c.Has([]byte(fmt.Sprintf("key%d", i))) {
+				numEvictions[i]++
+			}
+		}
+	}
+
+	// each of the initial keys has a 1/size probability to be evicted in a round
+	for i := 0; i < size; i++ {
+		assert.Greater(t, numEvictions[i], 0)
+	}
+}
+
+func TestRandomCache_RegisterHandlerShouldBeCalledOnAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := randomcache.NewCache(10)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		assert.Equal(t, []byte("key"), key)
+		wg.Done()
+	}, "id")
+
+	_, added := c.HasOrAdd([]byte("key"), "value", 1)
+	assert.True(t, added)
+	wg.Wait()
+
+	c.UnRegisterHandler("id")
+	c.Put([]byte("key"), "value", 1)
+}
+
+func TestRandomCache_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := randomcache.NewCache(50)
+	numGoroutines := 10
+	wg := sync.WaitGroup{}
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				key := []byte(fmt.Sprintf("key%d_%d", idx, j))
+				c.Put(key, j, 1)
+				_, _ = c.Get(key)
+				if j%3 == 0 {
+					c.Remove(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Len(), 50)
+	assert.Equal(t, uint64(c.Len()), c.SizeInBytesContained())
+}
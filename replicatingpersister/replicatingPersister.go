@@ -0,0 +1,306 @@
+package replicatingpersister
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*replicatingPersister)(nil)
+
+var log = logger.GetOrCreate("storage/replicatingpersister")
+
+// maxReplicationBackoff bounds the time between two attempts of replicating the same operation
+const maxReplicationBackoff = time.Minute
+
+// ErrNilLocalPersister signals that a nil local persister was provided
+var ErrNilLocalPersister = errors.New("nil local persister")
+
+// ErrNilRemotePersister signals that a nil remote persister was provided
+var ErrNilRemotePersister = errors.New("nil remote persister")
+
+// ErrInvalidQueueSize signals that a non-positive replication queue size was provided
+var ErrInvalidQueueSize = errors.New("invalid replication queue size")
+
+// ErrInvalidBackoff signals that a non-positive retry backoff was provided
+var ErrInvalidBackoff = errors.New("invalid backoff")
+
+type replicationOp struct {
+	opType types.OperationType
+	key    []byte
+	value  []byte
+}
+
+// replicatingPersister applies the writes to the local persister, which is authoritative and serves all the reads,
+// and replicates them asynchronously to the remote persister. The replicated operations are applied on the remote
+// one at a time, in the order they were applied locally, each one being retried with an exponential backoff until
+// it succeeds, so a failing remote delays the replication without reordering or dropping writes. When the
+// replication queue is full the writes wait for room in the queue
+type replicatingPersister struct {
+	local        types.Persister
+	remote       types.Persister
+	retryBackoff time.Duration
+	chOps        chan replicationOp
+	ctx          context.Context
+	cancel       context.CancelFunc
+	chStopped    chan struct{}
+
+	// mutWrite is held across the local write and its enqueueing, so the writes are queued in the order they
+	// were applied locally
+	mutWrite sync.Mutex
+
+	mutPending sync.Mutex
+	condDrain  *sync.Cond
+	numPending int
+	stopped    bool
+}
+
+// NewReplicatingPersister creates a persister writing to the local persister and replicating the writes to the
+// remote persister in the background. At most queueSize writes wait for being replicated, the retries of a failed
+// replication starting from the provided backoff
+func NewReplicatingPersister(
+	local types.Persister,
+	remote types.Persister,
+	queueSize int,
+	retryBackoff time.Duration,
+) (*replicatingPersister, error) {
+	if check.IfNil(local) {
+		return nil, ErrNilLocalPersister
+	}
+	if check.IfNil(remote) {
+		return nil, ErrNilRemotePersister
+	}
+	if queueSize < 1 {
+		return nil, ErrInvalidQueueSize
+	}
+	if retryBackoff <= 0 {
+		return nil, ErrInvalidBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rp := &replicatingPersister{
+		local:        local,
+		remote:       remote,
+		retryBackoff: retryBackoff,
+		chOps:        make(chan replicationOp, queueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+		chStopped:    make(chan struct{}),
+	}
+	rp.condDrain = sync.NewCond(&rp.mutPending)
+
+	go rp.replicate()
+
+	return rp, nil
+}
+
+func (rp *replicatingPersister) replicate() {
+	defer close(rp.chStopped)
+
+	for {
+		select {
+		case op := <-rp.chOps:
+			if !rp.applyOnRemote(op) {
+				return
+			}
+			rp.markReplicated()
+		case <-rp.ctx.Done():
+			return
+		}
+	}
+}
+
+// applyOnRemote applies the operation on the remote persister until it succeeds, returning false if the
+// replication was stopped meanwhile
+func (rp *replicatingPersister) applyOnRemote(op replicationOp) bool {
+	backoff := rp.retryBackoff
+	for {
+		var err error
+		if op.opType == types.PutOperation {
+			err = rp.remote.Put(op.key, op.value)
+		} else {
+			err = rp.remote.Remove(op.key)
+		}
+		if err == nil {
+			return true
+		}
+
+		log.Debug("replicatingPersister: cannot replicate operation, will retry",
+			"key", op.key,
+			"backoff", backoff,
+			"error", err,
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-rp.ctx.Done():
+			timer.Stop()
+			return false
+		}
+
+		backoff *= 2
+		if backoff > maxReplicationBackoff {
+			backoff = maxReplicationBackoff
+		}
+	}
+}
+
+func (rp *replicatingPersister) markReplicated() {
+	rp.mutPending.Lock()
+	rp.numPending--
+	if rp.numPending == 0 {
+		rp.condDrain.Broadcast()
+	}
+	rp.mutPending.Unlock()
+}
+
+func (rp *replicatingPersister) enqueue(op replicationOp) {
+	rp.mutPending.Lock()
+	rp.numPending++
+	rp.mutPending.Unlock()
+
+	select {
+	case rp.chOps <- op:
+	case <-rp.ctx.Done():
+		log.Warn("replicatingPersister: replication stopped, operation not replicated", "key", op.key)
+	}
+}
+
+// Put adds the value to the local persister and queues it for replication
+func (rp *replicatingPersister) Put(key, val []byte) error {
+	rp.mutWrite.Lock()
+	defer rp.mutWrite.Unlock()
+
+	err := rp.local.Put(key, val)
+	if err != nil {
+		return err
+	}
+
+	rp.enqueue(replicationOp{
+		opType: types.PutOperation,
+		key:    copyBytes(key),
+		value:  copyBytes(val),
+	})
+
+	return nil
+}
+
+// Get gets the value associated to the key from the local persister
+func (rp *replicatingPersister) Get(key []byte) ([]byte, error) {
+	return rp.local.Get(key)
+}
+
+// Has returns nil if the given key is present in the local persister
+func (rp *replicatingPersister) Has(key []byte) error {
+	return rp.local.Has(key)
+}
+
+// Remove removes the key from the local persister and queues the removal for replication
+func (rp *replicatingPersister) Remove(key []byte) error {
+	rp.mutWrite.Lock()
+	defer rp.mutWrite.Unlock()
+
+	err := rp.local.Remove(key)
+	if err != nil {
+		return err
+	}
+
+	rp.enqueue(replicationOp{
+		opType: types.RemoveOperation,
+		key:    copyBytes(key),
+	})
+
+	return nil
+}
+
+// RangeKeys iterates over the pairs of the local persister
+func (rp *replicatingPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	rp.local.RangeKeys(handler)
+}
+
+// ReplicationLag returns the number of writes applied locally and not yet replicated
+func (rp *replicatingPersister) ReplicationLag() int {
+	rp.mutPending.Lock()
+	defer rp.mutPending.Unlock()
+
+	return rp.numPending
+}
+
+// DrainReplication blocks until all the writes applied locally so far are replicated, or until the replication
+// is stopped by Close or Destroy. It should be called before Close for a clean shutdown, keeping in mind that it
+// waits for as long as the remote persister fails
+func (rp *replicatingPersister) DrainReplication() {
+	rp.mutPending.Lock()
+	defer rp.mutPending.Unlock()
+
+	for rp.numPending > 0 && !rp.stopped {
+		rp.condDrain.Wait()
+	}
+}
+
+func (rp *replicatingPersister) stopReplication() {
+	rp.cancel()
+	<-rp.chStopped
+
+	rp.mutPending.Lock()
+	rp.stopped = true
+	if rp.numPending > 0 {
+		log.Warn("replicatingPersister: replication stopped with pending operations", "pending", rp.numPending)
+	}
+	rp.condDrain.Broadcast()
+	rp.mutPending.Unlock()
+}
+
+// Close stops the replication, dropping the writes not yet replicated, and closes both persisters
+func (rp *replicatingPersister) Close() error {
+	rp.stopReplication()
+
+	errLocal := rp.local.Close()
+	errRemote := rp.remote.Close()
+	if errLocal != nil {
+		return errLocal
+	}
+
+	return errRemote
+}
+
+// Destroy stops the replication and removes the local persister data. The remote persister is only closed, so
+// the backup survives the destruction of the local data
+func (rp *replicatingPersister) Destroy() error {
+	rp.stopReplication()
+
+	errLocal := rp.local.Destroy()
+	errRemote := rp.remote.Close()
+	if errLocal != nil {
+		return errLocal
+	}
+
+	return errRemote
+}
+
+// DestroyClosed removes the already closed local persister data
+func (rp *replicatingPersister) DestroyClosed() error {
+	return rp.local.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (rp *replicatingPersister) IsInterfaceNil() bool {
+	return rp == nil
+}
+
+func copyBytes(buff []byte) []byte {
+	if buff == nil {
+		return nil
+	}
+
+	buffCopy := make([]byte, len(buff))
+	copy(buffCopy, buff)
+
+	return buffCopy
+}
@@ -0,0 +1,208 @@
+package replicatingpersister_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/replicatingpersister"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReplicatingPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil local persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := replicatingpersister.NewReplicatingPersister(nil, memorydb.New(), 10, time.Millisecond)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, replicatingpersister.ErrNilLocalPersister, err)
+	})
+	t.Run("nil remote persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := replicatingpersister.NewReplicatingPersister(memorydb.New(), nil, 10, time.Millisecond)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, replicatingpersister.ErrNilRemotePersister, err)
+	})
+	t.Run("invalid queue size should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := replicatingpersister.NewReplicatingPersister(memorydb.New(), memorydb.New(), 0, time.Millisecond)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, replicatingpersister.ErrInvalidQueueSize, err)
+	})
+	t.Run("invalid backoff should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := replicatingpersister.NewReplicatingPersister(memorydb.New(), memorydb.New(), 10, 0)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, replicatingpersister.ErrInvalidBackoff, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := replicatingpersister.NewReplicatingPersister(memorydb.New(), memorydb.New(), 10, time.Millisecond)
+		require.False(t, check.IfNil(rp))
+		require.Nil(t, err)
+		require.Nil(t, rp.Close())
+	})
+}
+
+func TestReplicatingPersister_ShouldReplicateTheWrites(t *testing.T) {
+	t.Parallel()
+
+	local := memorydb.New()
+	remote := memorydb.New()
+	rp, _ := replicatingpersister.NewReplicatingPersister(local, remote, 10, time.Millisecond)
+
+	require.Nil(t, rp.Put([]byte("key1"), []byte("value1")))
+	require.Nil(t, rp.Put([]byte("key2"), []byte("value2")))
+	require.Nil(t, rp.Remove([]byte("key1")))
+
+	val, err := rp.Get([]byte("key2"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value2"), val)
+	require.NotNil(t, rp.Has([]byte("key1")))
+
+	rp.DrainReplication()
+	require.Equal(t, 0, rp.ReplicationLag())
+	require.NotNil(t, remote.Has([]byte("key1")))
+	val, err = remote.Get([]byte("key2"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value2"), val)
+
+	require.Nil(t, rp.Close())
+}
+
+func TestReplicatingPersister_ConcurrentWritesOfAKeyShouldBeReplicatedInOrder(t *testing.T) {
+	t.Parallel()
+
+	local := memorydb.New()
+	remote := memorydb.New()
+	chFirstWritten := make(chan struct{})
+	chSecondDone := make(chan struct{})
+	// the first write pauses after being applied locally, giving the second write the chance to be applied and
+	// queued in between if the local write and its enqueueing were not atomic
+	pausingLocal := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			err := local.Put(key, val)
+			if string(val) == "first" {
+				close(chFirstWritten)
+				select {
+				case <-chSecondDone:
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+			return err
+		},
+	}
+	rp, _ := replicatingpersister.NewReplicatingPersister(pausingLocal, remote, 10, time.Millisecond)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_ = rp.Put([]byte("key"), []byte("first"))
+	}()
+	go func() {
+		defer wg.Done()
+		<-chFirstWritten
+		_ = rp.Put([]byte("key"), []byte("second"))
+		close(chSecondDone)
+	}()
+	wg.Wait()
+
+	rp.DrainReplication()
+	localVal, _ := local.Get([]byte("key"))
+	remoteVal, _ := remote.Get([]byte("key"))
+	require.Equal(t, []byte("second"), localVal)
+	require.Equal(t, localVal, remoteVal)
+
+	require.Nil(t, rp.Close())
+}
+
+func TestReplicatingPersister_ShouldRetryTheFailedReplications(t *testing.T) {
+	t.Parallel()
+
+	numFailures := int32(3)
+	remote := memorydb.New()
+	remoteStub := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			if atomic.AddInt32(&numFailures, -1) >= 0 {
+				return errors.New("remote unavailable")
+			}
+
+			return remote.Put(key, val)
+		},
+	}
+	rp, _ := replicatingpersister.NewReplicatingPersister(memorydb.New(), remoteStub, 10, time.Millisecond)
+
+	require.Nil(t, rp.Put([]byte("key"), []byte("value")))
+	rp.DrainReplication()
+	require.Nil(t, remote.Has([]byte("key")))
+
+	require.Nil(t, rp.Close())
+}
+
+func TestReplicatingPersister_FailingRemoteShouldNotBlockTheLocalWrites(t *testing.T) {
+	t.Parallel()
+
+	remoteStub := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			return errors.New("remote unavailable")
+		},
+	}
+	local := memorydb.New()
+	rp, _ := replicatingpersister.NewReplicatingPersister(local, remoteStub, 10, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		require.Nil(t, rp.Put([]byte{byte(i)}, []byte("value")))
+	}
+	require.Nil(t, local.Has([]byte{4}))
+	require.Equal(t, 5, rp.ReplicationLag())
+
+	chDrained := make(chan struct{})
+	go func() {
+		rp.DrainReplication()
+		close(chDrained)
+	}()
+
+	require.Nil(t, rp.Close())
+	select {
+	case <-chDrained:
+	case <-time.After(time.Second):
+		require.Fail(t, "drain should return once the replication is stopped")
+	}
+}
+
+func TestReplicatingPersister_DestroyShouldKeepTheRemoteData(t *testing.T) {
+	t.Parallel()
+
+	remoteClosed := false
+	remote := memorydb.New()
+	remoteStub := &testscommon.PersisterStub{
+		PutCalled: remote.Put,
+		CloseCalled: func() error {
+			remoteClosed = true
+			return nil
+		},
+		DestroyCalled: func() error {
+			require.Fail(t, "the remote persister should not be destroyed")
+			return nil
+		},
+	}
+	rp, _ := replicatingpersister.NewReplicatingPersister(memorydb.New(), remoteStub, 10, time.Millisecond)
+
+	require.Nil(t, rp.Put([]byte("key"), []byte("value")))
+	rp.DrainReplication()
+	require.Nil(t, rp.Destroy())
+	require.True(t, remoteClosed)
+	require.Nil(t, remote.Has([]byte("key")))
+}
@@ -0,0 +1,162 @@
+package retrypersister
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*retryPersister)(nil)
+
+var log = logger.GetOrCreate("storage/retrypersister")
+
+// ErrInvalidMaxRetries signals that an invalid maximum number of retries was provided
+var ErrInvalidMaxRetries = errors.New("invalid max retries")
+
+// ErrInvalidBackoff signals that an invalid backoff duration was provided
+var ErrInvalidBackoff = errors.New("invalid backoff")
+
+// ErrNilIsRetryableHandler signals that a nil is-retryable handler was provided
+var ErrNilIsRetryableHandler = errors.New("nil is retryable handler")
+
+type retryPersister struct {
+	persister   types.Persister
+	maxRetries  int
+	backoff     time.Duration
+	isRetryable func(err error) bool
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// NewRetryPersister creates a persister wrapper that retries the Put, Get, Has and Remove operations
+// for as long as the inner persister returns errors considered retryable by the provided handler.
+// The time between retries doubles after each attempt, starting from the provided backoff value.
+func NewRetryPersister(
+	inner types.Persister,
+	maxRetries int,
+	backoff time.Duration,
+	isRetryable func(err error) bool,
+) (*retryPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	if maxRetries < 0 {
+		return nil, ErrInvalidMaxRetries
+	}
+	if backoff < 0 {
+		return nil, ErrInvalidBackoff
+	}
+	if isRetryable == nil {
+		return nil, ErrNilIsRetryableHandler
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &retryPersister{
+		persister:   inner,
+		maxRetries:  maxRetries,
+		backoff:     backoff,
+		isRetryable: isRetryable,
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+func (rp *retryPersister) doWithRetries(operation string, handler func() error) error {
+	backoff := rp.backoff
+	err := handler()
+	for retry := 1; retry <= rp.maxRetries; retry++ {
+		if err == nil || !rp.isRetryable(err) {
+			return err
+		}
+
+		log.Trace("retrying persister operation",
+			"operation", operation,
+			"retry", retry,
+			"error", err,
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-rp.ctx.Done():
+			timer.Stop()
+			return err
+		}
+
+		backoff *= 2
+		err = handler()
+	}
+
+	return err
+}
+
+// Put adds the value to the (key, val) persistence medium, retrying on transient errors
+func (rp *retryPersister) Put(key, val []byte) error {
+	return rp.doWithRetries("Put", func() error {
+		return rp.persister.Put(key, val)
+	})
+}
+
+// Get gets the value associated to the key, retrying on transient errors
+func (rp *retryPersister) Get(key []byte) ([]byte, error) {
+	var val []byte
+	err := rp.doWithRetries("Get", func() error {
+		var errGet error
+		val, errGet = rp.persister.Get(key)
+		return errGet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the given key is present in the persistence medium, retrying on transient errors
+func (rp *retryPersister) Has(key []byte) error {
+	return rp.doWithRetries("Has", func() error {
+		return rp.persister.Has(key)
+	})
+}
+
+// Remove removes the data associated to the given key, retrying on transient errors
+func (rp *retryPersister) Remove(key []byte) error {
+	return rp.doWithRetries("Remove", func() error {
+		return rp.persister.Remove(key)
+	})
+}
+
+// Close stops any pending retries and closes the inner persister
+func (rp *retryPersister) Close() error {
+	rp.cancel()
+
+	return rp.persister.Close()
+}
+
+// Destroy stops any pending retries and removes the inner persister stored data
+func (rp *retryPersister) Destroy() error {
+	rp.cancel()
+
+	return rp.persister.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister stored data
+func (rp *retryPersister) DestroyClosed() error {
+	return rp.persister.DestroyClosed()
+}
+
+// RangeKeys calls the inner persister's RangeKeys method
+func (rp *retryPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	rp.persister.RangeKeys(handler)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (rp *retryPersister) IsInterfaceNil() bool {
+	return rp == nil
+}
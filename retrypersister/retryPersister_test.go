@@ -0,0 +1,201 @@
+package retrypersister_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/retrypersister"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = errors.New("transient error")
+var errPermanent = errors.New("permanent error")
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func TestNewRetryPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := retrypersister.NewRetryPersister(nil, 1, time.Millisecond, isTransient)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("negative max retries should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := retrypersister.NewRetryPersister(&testscommon.PersisterStub{}, -1, time.Millisecond, isTransient)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, retrypersister.ErrInvalidMaxRetries, err)
+	})
+	t.Run("negative backoff should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := retrypersister.NewRetryPersister(&testscommon.PersisterStub{}, 1, -time.Millisecond, isTransient)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, retrypersister.ErrInvalidBackoff, err)
+	})
+	t.Run("nil is retryable handler should error", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := retrypersister.NewRetryPersister(&testscommon.PersisterStub{}, 1, time.Millisecond, nil)
+		require.True(t, check.IfNil(rp))
+		require.Equal(t, retrypersister.ErrNilIsRetryableHandler, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		rp, err := retrypersister.NewRetryPersister(&testscommon.PersisterStub{}, 1, time.Millisecond, isTransient)
+		require.False(t, check.IfNil(rp))
+		require.Nil(t, err)
+	})
+}
+
+func TestRetryPersister_Put(t *testing.T) {
+	t.Parallel()
+
+	t.Run("transient errors should be retried until success", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		inner := &testscommon.PersisterStub{
+			PutCalled: func(key, val []byte) error {
+				numCalls++
+				if numCalls < 3 {
+					return errTransient
+				}
+
+				return nil
+			},
+		}
+		rp, _ := retrypersister.NewRetryPersister(inner, 5, time.Millisecond, isTransient)
+
+		err := rp.Put([]byte("key"), []byte("value"))
+		require.Nil(t, err)
+		require.Equal(t, 3, numCalls)
+	})
+	t.Run("non retryable error should pass through immediately", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		inner := &testscommon.PersisterStub{
+			PutCalled: func(key, val []byte) error {
+				numCalls++
+				return errPermanent
+			},
+		}
+		rp, _ := retrypersister.NewRetryPersister(inner, 5, time.Millisecond, isTransient)
+
+		err := rp.Put([]byte("key"), []byte("value"))
+		require.Equal(t, errPermanent, err)
+		require.Equal(t, 1, numCalls)
+	})
+	t.Run("retries exhausted should return the last error", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		inner := &testscommon.PersisterStub{
+			PutCalled: func(key, val []byte) error {
+				numCalls++
+				return errTransient
+			},
+		}
+		rp, _ := retrypersister.NewRetryPersister(inner, 3, time.Millisecond, isTransient)
+
+		err := rp.Put([]byte("key"), []byte("value"))
+		require.Equal(t, errTransient, err)
+		require.Equal(t, 4, numCalls)
+	})
+}
+
+func TestRetryPersister_Get(t *testing.T) {
+	t.Parallel()
+
+	numCalls := 0
+	expectedValue := []byte("value")
+	inner := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			numCalls++
+			if numCalls == 1 {
+				return nil, errTransient
+			}
+
+			return expectedValue, nil
+		},
+	}
+	rp, _ := retrypersister.NewRetryPersister(inner, 2, time.Millisecond, isTransient)
+
+	val, err := rp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, expectedValue, val)
+	require.Equal(t, 2, numCalls)
+}
+
+func TestRetryPersister_HasAndRemove(t *testing.T) {
+	t.Parallel()
+
+	numHasCalls := 0
+	numRemoveCalls := 0
+	inner := &testscommon.PersisterStub{
+		HasCalled: func(key []byte) error {
+			numHasCalls++
+			if numHasCalls == 1 {
+				return errTransient
+			}
+
+			return common.ErrKeyNotFound
+		},
+		RemoveCalled: func(key []byte) error {
+			numRemoveCalls++
+			if numRemoveCalls == 1 {
+				return errTransient
+			}
+
+			return nil
+		},
+	}
+	rp, _ := retrypersister.NewRetryPersister(inner, 2, time.Millisecond, isTransient)
+
+	err := rp.Has([]byte("key"))
+	require.Equal(t, common.ErrKeyNotFound, err)
+	require.Equal(t, 2, numHasCalls)
+
+	err = rp.Remove([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, 2, numRemoveCalls)
+}
+
+func TestRetryPersister_CloseShouldStopPendingRetries(t *testing.T) {
+	t.Parallel()
+
+	inner := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			return errTransient
+		},
+	}
+	rp, _ := retrypersister.NewRetryPersister(inner, 10, time.Hour, isTransient)
+
+	chDone := make(chan error)
+	go func() {
+		chDone <- rp.Put([]byte("key"), []byte("value"))
+	}()
+
+	time.Sleep(time.Millisecond * 10)
+	err := rp.Close()
+	require.Nil(t, err)
+
+	select {
+	case err = <-chDone:
+		require.Equal(t, errTransient, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "put should have returned after close")
+	}
+}
@@ -0,0 +1,114 @@
+package safepersister
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*safePersister)(nil)
+
+var log = logger.GetOrCreate("storage/safepersister")
+
+// ErrPersisterPanic signals that the inner persister panicked while executing an operation
+var ErrPersisterPanic = errors.New("persister panic")
+
+type safePersister struct {
+	persister types.Persister
+}
+
+// NewSafePersister creates a persister wrapper that recovers the panics of the inner persister,
+// converting them into errors wrapping ErrPersisterPanic that contain the recovered value and the stack trace
+func NewSafePersister(inner types.Persister) (*safePersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+
+	return &safePersister{
+		persister: inner,
+	}, nil
+}
+
+func (sp *safePersister) doSafe(operation string, handler func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		err = fmt.Errorf("%w in %s: %v, stack: %s", ErrPersisterPanic, operation, r, debug.Stack())
+		log.Error("recovered persister panic", "operation", operation, "error", err)
+	}()
+
+	return handler()
+}
+
+// Put adds the value to the (key, val) persistence medium
+func (sp *safePersister) Put(key, val []byte) error {
+	return sp.doSafe("Put", func() error {
+		return sp.persister.Put(key, val)
+	})
+}
+
+// Get gets the value associated to the key
+func (sp *safePersister) Get(key []byte) ([]byte, error) {
+	var val []byte
+	err := sp.doSafe("Get", func() error {
+		var errGet error
+		val, errGet = sp.persister.Get(key)
+		return errGet
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the given key is present in the persistence medium
+func (sp *safePersister) Has(key []byte) error {
+	return sp.doSafe("Has", func() error {
+		return sp.persister.Has(key)
+	})
+}
+
+// Remove removes the data associated to the given key
+func (sp *safePersister) Remove(key []byte) error {
+	return sp.doSafe("Remove", func() error {
+		return sp.persister.Remove(key)
+	})
+}
+
+// Close closes the inner persister
+func (sp *safePersister) Close() error {
+	return sp.doSafe("Close", sp.persister.Close)
+}
+
+// Destroy removes the inner persister stored data
+func (sp *safePersister) Destroy() error {
+	return sp.doSafe("Destroy", sp.persister.Destroy)
+}
+
+// DestroyClosed removes the already closed inner persister stored data
+func (sp *safePersister) DestroyClosed() error {
+	return sp.doSafe("DestroyClosed", sp.persister.DestroyClosed)
+}
+
+// RangeKeys calls the inner persister's RangeKeys method. As no error can be returned, a recovered panic
+// is only logged and the iteration stops
+func (sp *safePersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	_ = sp.doSafe("RangeKeys", func() error {
+		sp.persister.RangeKeys(handler)
+		return nil
+	})
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sp *safePersister) IsInterfaceNil() bool {
+	return sp == nil
+}
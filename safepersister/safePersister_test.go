@@ -0,0 +1,113 @@
+package safepersister_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/safepersister"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/stretchr/testify/require"
+)
+
+func createPanickingPersister() *testscommon.PersisterStub {
+	return &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			panic("put panic")
+		},
+		GetCalled: func(key []byte) ([]byte, error) {
+			panic("get panic")
+		},
+		HasCalled: func(key []byte) error {
+			panic("has panic")
+		},
+		RemoveCalled: func(key []byte) error {
+			panic("remove panic")
+		},
+		CloseCalled: func() error {
+			panic("close panic")
+		},
+		DestroyCalled: func() error {
+			panic("destroy panic")
+		},
+		DestroyClosedCalled: func() error {
+			panic("destroy closed panic")
+		},
+		RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+			panic("range keys panic")
+		},
+	}
+}
+
+func requirePanicError(t *testing.T, err error, recoveredValue string) {
+	require.True(t, errors.Is(err, safepersister.ErrPersisterPanic))
+	require.True(t, strings.Contains(err.Error(), recoveredValue))
+	require.True(t, strings.Contains(err.Error(), "stack"))
+}
+
+func TestNewSafePersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		sp, err := safepersister.NewSafePersister(nil)
+		require.True(t, check.IfNil(sp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		sp, err := safepersister.NewSafePersister(&testscommon.PersisterStub{})
+		require.False(t, check.IfNil(sp))
+		require.Nil(t, err)
+	})
+}
+
+func TestSafePersister_PanicsShouldBeConvertedToErrors(t *testing.T) {
+	t.Parallel()
+
+	sp, _ := safepersister.NewSafePersister(createPanickingPersister())
+
+	requirePanicError(t, sp.Put([]byte("key"), []byte("value")), "put panic")
+	val, err := sp.Get([]byte("key"))
+	require.Nil(t, val)
+	requirePanicError(t, err, "get panic")
+	requirePanicError(t, sp.Has([]byte("key")), "has panic")
+	requirePanicError(t, sp.Remove([]byte("key")), "remove panic")
+	requirePanicError(t, sp.Close(), "close panic")
+	requirePanicError(t, sp.Destroy(), "destroy panic")
+	requirePanicError(t, sp.DestroyClosed(), "destroy closed panic")
+
+	require.NotPanics(t, func() {
+		sp.RangeKeys(func(key []byte, val []byte) bool {
+			return true
+		})
+	})
+}
+
+func TestSafePersister_ShouldForwardToInnerPersister(t *testing.T) {
+	t.Parallel()
+
+	sp, _ := safepersister.NewSafePersister(memorydb.New())
+
+	require.Nil(t, sp.Put([]byte("key"), []byte("value")))
+	val, err := sp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), val)
+	require.Nil(t, sp.Has([]byte("key")))
+
+	numKeys := 0
+	sp.RangeKeys(func(key []byte, val []byte) bool {
+		numKeys++
+		return true
+	})
+	require.Equal(t, 1, numKeys)
+
+	require.Nil(t, sp.Remove([]byte("key")))
+	require.ErrorIs(t, sp.Has([]byte("key")), common.ErrKeyNotFound)
+	require.Nil(t, sp.Close())
+}
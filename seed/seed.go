@@ -0,0 +1,128 @@
+package seed
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
+)
+
+// ErrNilReader signals that a nil reader has been provided
+var ErrNilReader = errors.New("nil reader")
+
+// ErrMalformedEntry signals that an entry of the seed file could not be decoded
+var ErrMalformedEntry = errors.New("malformed seed entry")
+
+// ErrMalformedJSON signals that the seed content is not a JSON array
+var ErrMalformedJSON = errors.New("malformed seed JSON")
+
+type jsonPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SeedFromJSON puts into the unit all the pairs read from a JSON array of {"key": ..., "value": ...} objects,
+// both fields being base64 encoded. It returns the number of stored pairs. Malformed entries are reported with
+// their zero based index in the array, the pairs before them remaining stored
+func SeedFromJSON(u *storageUnit.Unit, r io.Reader) (int, error) {
+	err := checkArgs(u, r)
+	if err != nil {
+		return 0, err
+	}
+
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrMalformedJSON, err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return 0, fmt.Errorf("%w: expected array, got %v", ErrMalformedJSON, token)
+	}
+
+	numStored := 0
+	for index := 0; decoder.More(); index++ {
+		pair := jsonPair{}
+		err = decoder.Decode(&pair)
+		if err != nil {
+			return numStored, fmt.Errorf("%w at index %d: %v", ErrMalformedEntry, index, err)
+		}
+
+		err = putEncodedPair(u, pair.Key, pair.Value)
+		if err != nil {
+			return numStored, fmt.Errorf("%w at index %d", err, index)
+		}
+		numStored++
+	}
+
+	_, err = decoder.Token()
+	if err != nil {
+		return numStored, fmt.Errorf("%w: %v", ErrMalformedJSON, err)
+	}
+
+	return numStored, nil
+}
+
+// SeedFromCSV puts into the unit all the pairs read from a CSV content having the base64 encoded key on the first
+// column and the base64 encoded value on the second one. It returns the number of stored pairs. Malformed records
+// are reported with their line number, the pairs before them remaining stored
+func SeedFromCSV(u *storageUnit.Unit, r io.Reader) (int, error) {
+	err := checkArgs(u, r)
+	if err != nil {
+		return 0, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	numStored := 0
+	for {
+		record, errRead := reader.Read()
+		if errRead == io.EOF {
+			return numStored, nil
+		}
+		if errRead != nil {
+			// csv.ParseError already contains the line and column
+			return numStored, fmt.Errorf("%w: %v", ErrMalformedEntry, errRead)
+		}
+
+		line, _ := reader.FieldPos(0)
+		err = putEncodedPair(u, record[0], record[1])
+		if err != nil {
+			return numStored, fmt.Errorf("%w at line %d", err, line)
+		}
+		numStored++
+	}
+}
+
+func checkArgs(u *storageUnit.Unit, r io.Reader) error {
+	if check.IfNil(u) {
+		return storageUnit.ErrNilStorageUnit
+	}
+	if r == nil {
+		return ErrNilReader
+	}
+
+	return nil
+}
+
+func putEncodedPair(u *storageUnit.Unit, encodedKey string, encodedValue string) error {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return fmt.Errorf("%w, invalid key: %v,", ErrMalformedEntry, err)
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("%w, empty key,", ErrMalformedEntry)
+	}
+	value, err := base64.StdEncoding.DecodeString(encodedValue)
+	if err != nil {
+		return fmt.Errorf("%w, invalid value: %v,", ErrMalformedEntry, err)
+	}
+
+	return u.Put(key, value)
+}
@@ -0,0 +1,126 @@
+package seed_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/seed"
+	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
+	"github.com/stretchr/testify/require"
+)
+
+func createUnit(t *testing.T) *storageUnit.Unit {
+	cache, _ := lrucache.NewCache(10)
+	u, err := storageUnit.NewStorageUnit(cache, memorydb.New())
+	require.Nil(t, err)
+
+	return u
+}
+
+func encode(data string) string {
+	return base64.StdEncoding.EncodeToString([]byte(data))
+}
+
+func TestSeedFromJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		num, err := seed.SeedFromJSON(nil, strings.NewReader("[]"))
+		require.Equal(t, 0, num)
+		require.Equal(t, storageUnit.ErrNilStorageUnit, err)
+	})
+	t.Run("nil reader should error", func(t *testing.T) {
+		t.Parallel()
+
+		num, err := seed.SeedFromJSON(createUnit(t), nil)
+		require.Equal(t, 0, num)
+		require.Equal(t, seed.ErrNilReader, err)
+	})
+	t.Run("not an array should error", func(t *testing.T) {
+		t.Parallel()
+
+		num, err := seed.SeedFromJSON(createUnit(t), strings.NewReader(`{"key": "a"}`))
+		require.Equal(t, 0, num)
+		require.True(t, errors.Is(err, seed.ErrMalformedJSON))
+	})
+	t.Run("malformed entry should report its index", func(t *testing.T) {
+		t.Parallel()
+
+		u := createUnit(t)
+		content := fmt.Sprintf(`[{"key": "%s", "value": "%s"}, {"key": "not base64!", "value": ""}]`, encode("k1"), encode("v1"))
+		num, err := seed.SeedFromJSON(u, strings.NewReader(content))
+		require.Equal(t, 1, num)
+		require.True(t, errors.Is(err, seed.ErrMalformedEntry))
+		require.Contains(t, err.Error(), "index 1")
+		require.Nil(t, u.Has([]byte("k1")))
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		u := createUnit(t)
+		content := fmt.Sprintf(`[{"key": "%s", "value": "%s"}, {"key": "%s", "value": "%s"}]`,
+			encode("k1"), encode("v1"), encode("k2"), encode("v2"))
+		num, err := seed.SeedFromJSON(u, strings.NewReader(content))
+		require.Nil(t, err)
+		require.Equal(t, 2, num)
+
+		val, err := u.Get([]byte("k1"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("v1"), val)
+		val, err = u.Get([]byte("k2"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("v2"), val)
+	})
+}
+
+func TestSeedFromCSV(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		num, err := seed.SeedFromCSV(nil, strings.NewReader(""))
+		require.Equal(t, 0, num)
+		require.Equal(t, storageUnit.ErrNilStorageUnit, err)
+	})
+	t.Run("wrong number of fields should error", func(t *testing.T) {
+		t.Parallel()
+
+		u := createUnit(t)
+		content := encode("k1") + "," + encode("v1") + "\n" + encode("k2") + "\n"
+		num, err := seed.SeedFromCSV(u, strings.NewReader(content))
+		require.Equal(t, 1, num)
+		require.True(t, errors.Is(err, seed.ErrMalformedEntry))
+		require.Contains(t, err.Error(), "line 2")
+	})
+	t.Run("invalid encoding should report its line", func(t *testing.T) {
+		t.Parallel()
+
+		u := createUnit(t)
+		content := encode("k1") + "," + encode("v1") + "\n" + encode("k2") + ",not base64!\n"
+		num, err := seed.SeedFromCSV(u, strings.NewReader(content))
+		require.Equal(t, 1, num)
+		require.True(t, errors.Is(err, seed.ErrMalformedEntry))
+		require.Contains(t, err.Error(), "line 2")
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		u := createUnit(t)
+		content := encode("k1") + "," + encode("v1") + "\n" + encode("k2") + ", " + encode("v2") + "\n"
+		num, err := seed.SeedFromCSV(u, strings.NewReader(content))
+		require.Nil(t, err)
+		require.Equal(t, 2, num)
+
+		val, err := u.Get([]byte("k2"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("v2"), val)
+	})
+}
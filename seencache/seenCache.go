@@ -0,0 +1,91 @@
+package seencache
+
+import (
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.SeenCacher = (*seenCache)(nil)
+
+// seenCache records the keys that were seen, without storing any value, and is meant for deduplication.
+// The keys are kept in a ring buffer, so when the capacity is reached the oldest added key is forgotten.
+// Checking or re-adding a key does not refresh it
+type seenCache struct {
+	mut      sync.RWMutex
+	capacity int
+	keys     []string
+	next     int
+	indexes  map[string]struct{}
+}
+
+// NewSeenCache creates a new seen cache remembering at most the provided number of keys
+func NewSeenCache(capacity int) (*seenCache, error) {
+	if capacity < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+
+	return &seenCache{
+		capacity: capacity,
+		keys:     make([]string, 0, capacity),
+		indexes:  make(map[string]struct{}, capacity),
+	}, nil
+}
+
+// Add records the key and returns true if it was not already contained
+func (sc *seenCache) Add(key []byte) bool {
+	sc.mut.Lock()
+	defer sc.mut.Unlock()
+
+	_, found := sc.indexes[string(key)]
+	if found {
+		return false
+	}
+
+	k := string(key)
+	sc.indexes[k] = struct{}{}
+	if len(sc.keys) < sc.capacity {
+		sc.keys = append(sc.keys, k)
+		return true
+	}
+
+	delete(sc.indexes, sc.keys[sc.next])
+	sc.keys[sc.next] = k
+	sc.next = (sc.next + 1) % sc.capacity
+
+	return true
+}
+
+// Contains returns true if the key was added and not yet forgotten
+func (sc *seenCache) Contains(key []byte) bool {
+	sc.mut.RLock()
+	defer sc.mut.RUnlock()
+
+	_, found := sc.indexes[string(key)]
+
+	return found
+}
+
+// Len returns the number of contained keys
+func (sc *seenCache) Len() int {
+	sc.mut.RLock()
+	defer sc.mut.RUnlock()
+
+	return len(sc.keys)
+}
+
+// Clear forgets all the contained keys
+func (sc *seenCache) Clear() {
+	sc.mut.Lock()
+	defer sc.mut.Unlock()
+
+	sc.keys = make([]string, 0, sc.capacity)
+	sc.next = 0
+	sc.indexes = make(map[string]struct{}, sc.capacity)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sc *seenCache) IsInterfaceNil() bool {
+	return sc == nil
+}
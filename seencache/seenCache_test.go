@@ -0,0 +1,99 @@
+package seencache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/seencache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSeenCache(t *testing.T) {
+	t.Parallel()
+
+	sc, err := seencache.NewSeenCache(0)
+	assert.True(t, check.IfNil(sc))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	sc, err = seencache.NewSeenCache(10)
+	assert.False(t, check.IfNil(sc))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, sc.Len())
+}
+
+func TestSeenCache_AddContains(t *testing.T) {
+	t.Parallel()
+
+	sc, _ := seencache.NewSeenCache(10)
+	key := []byte("key")
+
+	assert.False(t, sc.Contains(key))
+	assert.True(t, sc.Add(key))
+	assert.True(t, sc.Contains(key))
+	assert.False(t, sc.Add(key))
+	assert.Equal(t, 1, sc.Len())
+
+	sc.Clear()
+	assert.False(t, sc.Contains(key))
+	assert.Equal(t, 0, sc.Len())
+	assert.True(t, sc.Add(key))
+}
+
+func TestSeenCache_AddShouldForgetOldestKeys(t *testing.T) {
+	t.Parallel()
+
+	capacity := 3
+	sc, _ := seencache.NewSeenCache(capacity)
+	for i := 0; i < 5; i++ {
+		assert.True(t, sc.Add([]byte(fmt.Sprintf("key%d", i))))
+	}
+
+	assert.Equal(t, capacity, sc.Len())
+	assert.False(t, sc.Contains([]byte("key0")))
+	assert.False(t, sc.Contains([]byte("key1")))
+	for i := 2; i < 5; i++ {
+		assert.True(t, sc.Contains([]byte(fmt.Sprintf("key%d", i))))
+	}
+
+	// re-adding an existing key does not refresh it
+	assert.False(t, sc.Add([]byte("key2")))
+	assert.True(t, sc.Add([]byte("key5")))
+	assert.False(t, sc.Contains([]byte("key2")))
+	assert.True(t, sc.Contains([]byte("key3")))
+}
+
+func TestSeenCache_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	sc, _ := seencache.NewSeenCache(100)
+
+	numOperations := 1000
+	numAdded := 0
+	mutAdded := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key%d", idx%50))
+			if idx%2 == 0 {
+				_ = sc.Contains(key)
+				return
+			}
+
+			if sc.Add(key) {
+				mutAdded.Lock()
+				numAdded++
+				mutAdded.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 25, numAdded)
+	assert.Equal(t, 25, sc.Len())
+}
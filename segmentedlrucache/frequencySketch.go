@@ -0,0 +1,95 @@
+package segmentedlrucache
+
+import (
+	"hash/fnv"
+)
+
+const (
+	sketchDepth      = 4
+	maxSketchCounter = 15
+	minSketchWidth   = 64
+)
+
+// frequencySketch is a count-min sketch estimating the access frequency of the keys with small saturating
+// counters. Every agingPeriod recorded accesses all the counters are halved, so the keys which stopped being
+// accessed slowly lose their estimated frequency. Not concurrent safe, the cache lock protects it
+type frequencySketch struct {
+	counters     [sketchDepth][]uint8
+	mask         uint64
+	agingPeriod  int
+	numIncrement int
+}
+
+func newFrequencySketch(numEntries int, agingPeriod int) *frequencySketch {
+	width := minSketchWidth
+	for width < numEntries {
+		width <<= 1
+	}
+
+	fs := &frequencySketch{
+		mask:        uint64(width - 1),
+		agingPeriod: agingPeriod,
+	}
+	for row := range fs.counters {
+		fs.counters[row] = make([]uint8, width)
+	}
+
+	return fs
+}
+
+// indexes derives the counter index of each row from the two halves of the key hash
+func (fs *frequencySketch) indexes(key string) [sketchDepth]uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(key))
+	h := hasher.Sum64()
+	h1, h2 := h&0xffffffff, h>>32
+
+	var indexes [sketchDepth]uint64
+	for row := range indexes {
+		indexes[row] = (h1 + uint64(row)*h2) & fs.mask
+	}
+
+	return indexes
+}
+
+func (fs *frequencySketch) record(key string) {
+	for row, idx := range fs.indexes(key) {
+		if fs.counters[row][idx] < maxSketchCounter {
+			fs.counters[row][idx]++
+		}
+	}
+
+	fs.numIncrement++
+	if fs.numIncrement >= fs.agingPeriod {
+		fs.age()
+	}
+}
+
+func (fs *frequencySketch) estimate(key string) uint8 {
+	estimate := uint8(maxSketchCounter)
+	for row, idx := range fs.indexes(key) {
+		if fs.counters[row][idx] < estimate {
+			estimate = fs.counters[row][idx]
+		}
+	}
+
+	return estimate
+}
+
+func (fs *frequencySketch) age() {
+	for row := range fs.counters {
+		for idx := range fs.counters[row] {
+			fs.counters[row][idx] >>= 1
+		}
+	}
+	fs.numIncrement = 0
+}
+
+func (fs *frequencySketch) reset() {
+	for row := range fs.counters {
+		for idx := range fs.counters[row] {
+			fs.counters[row][idx] = 0
+		}
+	}
+	fs.numIncrement = 0
+}
@@ -0,0 +1,400 @@
+package segmentedlrucache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*segmentedLRU)(nil)
+
+var log = logger.GetOrCreate("storage/segmentedlrucache")
+
+// ErrInvalidFrequencyAgingPeriod signals that the frequency aging period of the admission is not positive
+var ErrInvalidFrequencyAgingPeriod = errors.New("invalid frequency aging period")
+
+// AdmissionConfig holds the configuration of the frequency based admission of the segmented LRU cache
+type AdmissionConfig struct {
+	// WindowCapacity is the number of entries of the admission window, where the new entries land before
+	// competing for a place in the probationary segment
+	WindowCapacity int
+	// FrequencyAgingPeriod is the number of recorded accesses after which all the estimated frequencies are
+	// halved, so the keys which stopped being accessed lose their admission advantage
+	FrequencyAgingPeriod int
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	size      int
+	protected bool
+	inWindow  bool
+}
+
+// segmentedLRU implements a scan resistant LRU cache split in two segments. The new entries land in the
+// probationary segment and a hit on a probationary entry promotes it to the protected segment. When the protected
+// segment is full, its least recently used entry is demoted to the probationary segment instead of being evicted,
+// and the evictions only happen from the probationary segment. A one-shot scan therefore only cycles through the
+// probationary segment, leaving the hot entries of the protected segment cached.
+// Get hits promote and refresh the entries, Put on an existing key refreshes it in its segment, while Has, Peek and
+// HasOrAdd do not change the order.
+// With the frequency based admission, the new entries land in an admission window instead and the entry leaving
+// the full window only enters the full probationary segment if its estimated access frequency is higher than the
+// one of the probationary entry it would evict, otherwise it is the one evicted
+type segmentedLRU struct {
+	mut          sync.Mutex
+	window       *list.List
+	probationary *list.List
+	protected    *list.List
+	windowCap    int
+	probationCap int
+	protectedCap int
+	sketch       *frequencySketch
+	items        map[string]*list.Element
+	sizeInBytes  uint64
+
+	mutAddedDataHandlers sync.RWMutex
+	mapDataHandlers      map[string]func(key []byte, value interface{})
+}
+
+// NewSegmentedLRU creates a new segmented LRU cache with the provided capacities of the probationary and of the
+// protected segments
+func NewSegmentedLRU(probationaryCap int, protectedCap int) (*segmentedLRU, error) {
+	if probationaryCap < 1 || protectedCap < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+
+	return &segmentedLRU{
+		window:          list.New(),
+		probationary:    list.New(),
+		protected:       list.New(),
+		probationCap:    probationaryCap,
+		protectedCap:    protectedCap,
+		items:           make(map[string]*list.Element, probationaryCap+protectedCap),
+		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
+	}, nil
+}
+
+// NewSegmentedLRUWithAdmission creates a new segmented LRU cache whose new entries go through an admission window
+// and are only admitted in the probationary segment if they are accessed more often than the entries they evict
+func NewSegmentedLRUWithAdmission(probationaryCap int, protectedCap int, config AdmissionConfig) (*segmentedLRU, error) {
+	if config.WindowCapacity < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+	if config.FrequencyAgingPeriod < 1 {
+		return nil, ErrInvalidFrequencyAgingPeriod
+	}
+
+	c, err := NewSegmentedLRU(probationaryCap, protectedCap)
+	if err != nil {
+		return nil, err
+	}
+
+	c.windowCap = config.WindowCapacity
+	c.sketch = newFrequencySketch(c.MaxSize(), config.FrequencyAgingPeriod)
+
+	return c, nil
+}
+
+// Clear is used to completely clear the cache.
+func (c *segmentedLRU) Clear() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.window.Init()
+	c.probationary.Init()
+	c.protected.Init()
+	c.items = make(map[string]*list.Element, c.probationCap+c.protectedCap)
+	c.sizeInBytes = 0
+	if c.sketch != nil {
+		c.sketch.reset()
+	}
+}
+
+// Put adds a value to the cache. Returns true if an eviction occurred.
+func (c *segmentedLRU) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	if sizeInBytes < 0 {
+		log.Error("segmented LRU cache put error",
+			"key", key,
+			"error", common.ErrNegativeSizeInBytes,
+		)
+
+		return false
+	}
+
+	c.mut.Lock()
+	evicted = c.put(string(key), value, sizeInBytes)
+	c.mut.Unlock()
+
+	c.callAddedDataHandlers(key, value)
+
+	return evicted
+}
+
+func (c *segmentedLRU) put(key string, value interface{}, sizeInBytes int) bool {
+	element, ok := c.items[key]
+	if ok {
+		e := element.Value.(*entry)
+		c.sizeInBytes -= uint64(e.size)
+		c.sizeInBytes += uint64(sizeInBytes)
+		e.value = value
+		e.size = sizeInBytes
+		c.segmentOf(e).MoveToFront(element)
+
+		return false
+	}
+
+	e := &entry{
+		key:   key,
+		value: value,
+		size:  sizeInBytes,
+	}
+	c.sizeInBytes += uint64(sizeInBytes)
+	if c.sketch == nil {
+		c.items[key] = c.probationary.PushFront(e)
+
+		return c.evictIfNeeded()
+	}
+
+	c.sketch.record(key)
+	e.inWindow = true
+	c.items[key] = c.window.PushFront(e)
+
+	return c.admitFromWindow()
+}
+
+// admitFromWindow moves the entries overflowing the admission window to the probationary segment. When the
+// probationary segment is full, the entry with the lower estimated frequency between the window candidate and the
+// probationary victim is evicted, the victim winning the ties
+func (c *segmentedLRU) admitFromWindow() bool {
+	evicted := false
+	for c.window.Len() > c.windowCap {
+		candidate := c.window.Remove(c.window.Back()).(*entry)
+		candidate.inWindow = false
+
+		if c.probationary.Len() >= c.probationCap {
+			evicted = true
+			victim := c.probationary.Back()
+			if c.sketch.estimate(candidate.key) <= c.sketch.estimate(victim.Value.(*entry).key) {
+				delete(c.items, candidate.key)
+				c.sizeInBytes -= uint64(candidate.size)
+				continue
+			}
+
+			c.removeElement(victim)
+		}
+
+		c.items[candidate.key] = c.probationary.PushFront(candidate)
+	}
+
+	return c.evictIfNeeded() || evicted
+}
+
+func (c *segmentedLRU) segmentOf(e *entry) *list.List {
+	if e.inWindow {
+		return c.window
+	}
+	if e.protected {
+		return c.protected
+	}
+
+	return c.probationary
+}
+
+// promote moves a probationary entry to the protected segment, demoting the least recently used protected entry
+// if the protected segment overflows
+func (c *segmentedLRU) promote(element *list.Element) {
+	e := element.Value.(*entry)
+	c.probationary.Remove(element)
+	e.protected = true
+	c.items[e.key] = c.protected.PushFront(e)
+
+	if c.protected.Len() <= c.protectedCap {
+		return
+	}
+
+	demoted := c.protected.Remove(c.protected.Back()).(*entry)
+	demoted.protected = false
+	c.items[demoted.key] = c.probationary.PushFront(demoted)
+}
+
+func (c *segmentedLRU) evictIfNeeded() bool {
+	evicted := false
+	for c.probationary.Len() > c.probationCap {
+		c.removeElement(c.probationary.Back())
+		evicted = true
+	}
+
+	return evicted
+}
+
+func (c *segmentedLRU) removeElement(element *list.Element) {
+	e := element.Value.(*entry)
+	c.segmentOf(e).Remove(element)
+	delete(c.items, e.key)
+	c.sizeInBytes -= uint64(e.size)
+}
+
+// Get looks up a key's value from the cache, promoting a probationary entry to the protected segment.
+// With the frequency based admission, both the hits and the misses are recorded as accesses.
+func (c *segmentedLRU) Get(key []byte) (value interface{}, ok bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.sketch != nil {
+		c.sketch.record(string(key))
+	}
+
+	element, ok := c.items[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	switch {
+	case e.inWindow:
+		c.window.MoveToFront(element)
+	case e.protected:
+		c.protected.MoveToFront(element)
+	default:
+		c.promote(element)
+	}
+
+	return e.value, true
+}
+
+// Has checks if a key is in the cache, without changing its segment or recent-ness.
+func (c *segmentedLRU) Has(key []byte) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	_, ok := c.items[string(key)]
+
+	return ok
+}
+
+// Peek returns the key value (or undefined if not found) without changing its segment or recent-ness.
+func (c *segmentedLRU) Peek(key []byte) (value interface{}, ok bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	element, ok := c.items[string(key)]
+	if !ok {
+		return nil, false
+	}
+
+	return element.Value.(*entry).value, true
+}
+
+// HasOrAdd checks if a key is in the cache and if not, adds the value as Put does.
+// Returns whether found and whether the value was added.
+func (c *segmentedLRU) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	if sizeInBytes < 0 {
+		return c.Has(key), false
+	}
+
+	c.mut.Lock()
+	_, has = c.items[string(key)]
+	if has {
+		c.mut.Unlock()
+		return true, false
+	}
+
+	c.put(string(key), value, sizeInBytes)
+	c.mut.Unlock()
+
+	c.callAddedDataHandlers(key, value)
+
+	return false, true
+}
+
+// Remove removes the provided key from the cache.
+func (c *segmentedLRU) Remove(key []byte) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	element, ok := c.items[string(key)]
+	if !ok {
+		return
+	}
+
+	c.removeElement(element)
+}
+
+// Keys returns a slice of the keys in the cache, the probationary ones first, then the protected ones and the
+// admission window ones, each segment from oldest to newest.
+func (c *segmentedLRU) Keys() [][]byte {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	keys := make([][]byte, 0, len(c.items))
+	for _, segment := range []*list.List{c.probationary, c.protected, c.window} {
+		for element := segment.Back(); element != nil; element = element.Prev() {
+			keys = append(keys, []byte(element.Value.(*entry).key))
+		}
+	}
+
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *segmentedLRU) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return len(c.items)
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (c *segmentedLRU) SizeInBytesContained() uint64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.sizeInBytes
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache, the sum of the segment capacities.
+func (c *segmentedLRU) MaxSize() int {
+	return c.windowCap + c.probationCap + c.protectedCap
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (c *segmentedLRU) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	c.mutAddedDataHandlers.Lock()
+	c.mapDataHandlers[id] = handler
+	c.mutAddedDataHandlers.Unlock()
+}
+
+// UnRegisterHandler removes the handler from the list
+func (c *segmentedLRU) UnRegisterHandler(id string) {
+	c.mutAddedDataHandlers.Lock()
+	delete(c.mapDataHandlers, id)
+	c.mutAddedDataHandlers.Unlock()
+}
+
+func (c *segmentedLRU) callAddedDataHandlers(key []byte, value interface{}) {
+	c.mutAddedDataHandlers.RLock()
+	for _, handler := range c.mapDataHandlers {
+		go handler(key, value)
+	}
+	c.mutAddedDataHandlers.RUnlock()
+}
+
+// Close does nothing for this cacher implementation
+func (c *segmentedLRU) Close() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *segmentedLRU) IsInterfaceNil() bool {
+	return c == nil
+}
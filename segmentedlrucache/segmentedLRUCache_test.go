@@ -0,0 +1,282 @@
+package segmentedlrucache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/segmentedlrucache"
+	"github.com/stretchr/testify/assert"
+)
+
+func keysAsStrings(keys [][]byte) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, string(key))
+	}
+
+	return result
+}
+
+func TestNewSegmentedLRU(t *testing.T) {
+	t.Parallel()
+
+	c, err := segmentedlrucache.NewSegmentedLRU(0, 10)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	c, err = segmentedlrucache.NewSegmentedLRU(10, 0)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	c, err = segmentedlrucache.NewSegmentedLRU(2, 3)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, c.MaxSize())
+	assert.Nil(t, c.Close())
+}
+
+func TestSegmentedLRU_PutGetRemove(t *testing.T) {
+	t.Parallel()
+
+	c, _ := segmentedlrucache.NewSegmentedLRU(2, 2)
+	assert.False(t, c.Put([]byte("key"), "value", 5))
+	assert.False(t, c.Put([]byte("key"), "new value", 3))
+
+	value, ok := c.Peek([]byte("key"))
+	assert.True(t, ok)
+	assert.Equal(t, "new value", value)
+	assert.Equal(t, uint64(3), c.SizeInBytesContained())
+
+	has, added := c.HasOrAdd([]byte("key"), "other", 1)
+	assert.True(t, has)
+	assert.False(t, added)
+	has, added = c.HasOrAdd([]byte("key2"), "value2", 1)
+	assert.False(t, has)
+	assert.True(t, added)
+	assert.Equal(t, 2, c.Len())
+
+	c.Remove([]byte("key"))
+	assert.False(t, c.Has([]byte("key")))
+	_, ok = c.Get([]byte("key"))
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), c.SizeInBytesContained())
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+}
+
+func TestSegmentedLRU_HitsShouldPromoteAndOverflowShouldDemote(t *testing.T) {
+	t.Parallel()
+
+	c, _ := segmentedlrucache.NewSegmentedLRU(2, 2)
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	c.Put([]byte("c"), "c", 0)
+	// the probationary segment is full, so the oldest entry was evicted
+	assert.Equal(t, []string{"b", "c"}, keysAsStrings(c.Keys()))
+
+	_, _ = c.Get([]byte("b"))
+	_, _ = c.Get([]byte("c"))
+	assert.Equal(t, []string{"b", "c"}, keysAsStrings(c.Keys()))
+
+	c.Put([]byte("d"), "d", 0)
+	c.Put([]byte("e"), "e", 0)
+	assert.Equal(t, []string{"d", "e", "b", "c"}, keysAsStrings(c.Keys()))
+
+	// promoting d overflows the protected segment, demoting its least recently used entry b
+	_, _ = c.Get([]byte("d"))
+	assert.Equal(t, []string{"e", "b", "c", "d"}, keysAsStrings(c.Keys()))
+
+	evicted := c.Put([]byte("f"), "f", 0)
+	assert.True(t, evicted)
+	assert.Equal(t, []string{"b", "f", "c", "d"}, keysAsStrings(c.Keys()))
+}
+
+func TestSegmentedLRU_HotSetShouldSurviveLargeScan(t *testing.T) {
+	t.Parallel()
+
+	numHot := 80
+	hotKeys := make([][]byte, 0, numHot)
+	for i := 0; i < numHot; i++ {
+		hotKeys = append(hotKeys, []byte(fmt.Sprintf("hot%d", i)))
+	}
+
+	slru, _ := segmentedlrucache.NewSegmentedLRU(20, 80)
+	lru, _ := lrucache.NewCache(100)
+	for _, c := range []interface {
+		Put(key []byte, value interface{}, sizeInBytes int) bool
+		Get(key []byte) (interface{}, bool)
+	}{slru, lru} {
+		for _, key := range hotKeys {
+			c.Put(key, "value", 0)
+			_, _ = c.Get(key)
+		}
+		for i := 0; i < 10000; i++ {
+			c.Put([]byte(fmt.Sprintf("scan%d", i)), "value", 0)
+		}
+	}
+
+	numHotInSLRU := 0
+	numHotInLRU := 0
+	for _, key := range hotKeys {
+		if slru.Has(key) {
+			numHotInSLRU++
+		}
+		if lru.Has(key) {
+			numHotInLRU++
+		}
+	}
+
+	assert.Equal(t, numHot, numHotInSLRU)
+	assert.Equal(t, 0, numHotInLRU)
+	assert.Equal(t, 100, slru.Len())
+}
+
+func TestNewSegmentedLRUWithAdmission(t *testing.T) {
+	t.Parallel()
+
+	c, err := segmentedlrucache.NewSegmentedLRUWithAdmission(2, 3, segmentedlrucache.AdmissionConfig{
+		WindowCapacity:       0,
+		FrequencyAgingPeriod: 10,
+	})
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	c, err = segmentedlrucache.NewSegmentedLRUWithAdmission(2, 3, segmentedlrucache.AdmissionConfig{
+		WindowCapacity:       1,
+		FrequencyAgingPeriod: 0,
+	})
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, segmentedlrucache.ErrInvalidFrequencyAgingPeriod, err)
+
+	c, err = segmentedlrucache.NewSegmentedLRUWithAdmission(2, 3, segmentedlrucache.AdmissionConfig{
+		WindowCapacity:       1,
+		FrequencyAgingPeriod: 10,
+	})
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+	assert.Equal(t, 6, c.MaxSize())
+}
+
+func TestSegmentedLRUWithAdmission_FrequentCandidateShouldReplaceVictim(t *testing.T) {
+	t.Parallel()
+
+	c, _ := segmentedlrucache.NewSegmentedLRUWithAdmission(1, 1, segmentedlrucache.AdmissionConfig{
+		WindowCapacity:       1,
+		FrequencyAgingPeriod: 1000,
+	})
+	for i := 0; i < 5; i++ {
+		_, _ = c.Get([]byte("hot"))
+	}
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	assert.Equal(t, []string{"a", "b"}, keysAsStrings(c.Keys()))
+
+	// b leaving the window is not more frequent than the probationary a, so b is evicted
+	evicted := c.Put([]byte("hot"), "hot", 0)
+	assert.True(t, evicted)
+	assert.Equal(t, []string{"a", "hot"}, keysAsStrings(c.Keys()))
+
+	// hot leaving the window is more frequent than a, so a is evicted
+	evicted = c.Put([]byte("c"), "c", 0)
+	assert.True(t, evicted)
+	assert.Equal(t, []string{"hot", "c"}, keysAsStrings(c.Keys()))
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestSegmentedLRUWithAdmission_FormerlyHotKeyShouldLoseItsAdvantageWithAging(t *testing.T) {
+	t.Parallel()
+
+	runScenario := func(agingPeriod int) (hasFormerlyHot bool, hasCandidate bool) {
+		c, _ := segmentedlrucache.NewSegmentedLRUWithAdmission(1, 1, segmentedlrucache.AdmissionConfig{
+			WindowCapacity:       1,
+			FrequencyAgingPeriod: agingPeriod,
+		})
+
+		// the key is hot at startup, then lands in the probationary segment
+		for i := 0; i < 20; i++ {
+			_, _ = c.Get([]byte("formerly hot"))
+		}
+		c.Put([]byte("formerly hot"), "value", 0)
+		c.Put([]byte("filler"), "value", 0)
+		c.Remove([]byte("filler"))
+
+		// the key is no longer accessed while the other keys are
+		for i := 0; i < 500; i++ {
+			_, _ = c.Get([]byte(fmt.Sprintf("other%d", i)))
+		}
+		for i := 0; i < 4; i++ {
+			_, _ = c.Get([]byte("candidate"))
+		}
+
+		// the candidate leaving the window competes with the formerly hot key
+		c.Put([]byte("candidate"), "value", 0)
+		c.Put([]byte("newest"), "value", 0)
+
+		return c.Has([]byte("formerly hot")), c.Has([]byte("candidate"))
+	}
+
+	hasFormerlyHot, hasCandidate := runScenario(1000000)
+	assert.True(t, hasFormerlyHot)
+	assert.False(t, hasCandidate)
+
+	hasFormerlyHot, hasCandidate = runScenario(20)
+	assert.False(t, hasFormerlyHot)
+	assert.True(t, hasCandidate)
+}
+
+func TestSegmentedLRU_RegisterHandlerShouldBeCalledOnAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := segmentedlrucache.NewSegmentedLRU(2, 2)
+	chCalled := make(chan struct{}, 1)
+	c.RegisterHandler(nil, "nil")
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		chCalled <- struct{}{}
+	}, "id")
+
+	c.Put([]byte("key"), "value", 0)
+	select {
+	case <-chCalled:
+	case <-time.After(time.Second):
+		assert.Fail(t, "handler was not called")
+	}
+
+	c.UnRegisterHandler("id")
+}
+
+func TestSegmentedLRU_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := segmentedlrucache.NewSegmentedLRU(10, 10)
+	wg := sync.WaitGroup{}
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key%d", idx%30))
+			switch idx % 4 {
+			case 0:
+				c.Put(key, idx, 1)
+			case 1:
+				_, _ = c.Get(key)
+			case 2:
+				c.HasOrAdd(key, idx, 1)
+			default:
+				c.Remove(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Len(), 20)
+	assert.Equal(t, uint64(c.Len()), c.SizeInBytesContained())
+}
@@ -0,0 +1,62 @@
+package sharded
+
+import (
+	"math/bits"
+)
+
+// binarySplitShardIDProvider assigns the keys to shards by the low bits of their hash, as in linear hashing. The
+// shard index is read from the minimum number of low bits able to address all the shards and, when it points past
+// the last shard, its highest bit is cleared. Consequently, adding the shard n only moves to it part of the keys of
+// the shard n - 2^(b-1), b being the number of bits addressing n+1 shards.
+// As opposed to shardIDProvider, which reads the last bytes of the key, the keys do not need to be uniformly
+// distributed themselves
+type binarySplitShardIDProvider struct {
+	numOfShards uint32
+	mask        uint64
+	splitBit    uint64
+}
+
+// NewBinarySplitShardIDProvider creates a binary split shard ID provider over the hash of the keys
+func NewBinarySplitShardIDProvider(numShards uint32) (*binarySplitShardIDProvider, error) {
+	if numShards < minNumOfShards {
+		return nil, ErrInvalidNumberOfShards
+	}
+
+	bitsNeeded := bits.Len32(numShards - 1)
+
+	return &binarySplitShardIDProvider{
+		numOfShards: numShards,
+		mask:        1<<bitsNeeded - 1,
+		splitBit:    1 << (bitsNeeded - 1),
+	}, nil
+}
+
+// ComputeId computes the shard id for a given key
+func (bsp *binarySplitShardIDProvider) ComputeId(key []byte) uint32 {
+	shardIndex := hashBytes(key) & bsp.mask
+	if shardIndex >= uint64(bsp.numOfShards) {
+		shardIndex -= bsp.splitBit
+	}
+
+	return uint32(shardIndex)
+}
+
+// NumberOfShards returns the number of shards
+func (bsp *binarySplitShardIDProvider) NumberOfShards() uint32 {
+	return bsp.numOfShards
+}
+
+// GetShardIDs will return a list of all shard ids
+func (bsp *binarySplitShardIDProvider) GetShardIDs() []uint32 {
+	shardIDs := make([]uint32, bsp.numOfShards)
+	for i := uint32(0); i < bsp.numOfShards; i++ {
+		shardIDs[i] = i
+	}
+
+	return shardIDs
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (bsp *binarySplitShardIDProvider) IsInterfaceNil() bool {
+	return bsp == nil
+}
@@ -0,0 +1,96 @@
+package sharded_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/sharded"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBinarySplitShardIDProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid number of shards should error", func(t *testing.T) {
+		t.Parallel()
+
+		bsp, err := sharded.NewBinarySplitShardIDProvider(1)
+		require.Nil(t, bsp)
+		require.Equal(t, sharded.ErrInvalidNumberOfShards, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		bsp, err := sharded.NewBinarySplitShardIDProvider(5)
+		require.Nil(t, err)
+		require.False(t, bsp.IsInterfaceNil())
+		require.Equal(t, uint32(5), bsp.NumberOfShards())
+		require.Equal(t, []uint32{0, 1, 2, 3, 4}, bsp.GetShardIDs())
+	})
+}
+
+func TestBinarySplitShardIDProvider_ComputeId(t *testing.T) {
+	t.Parallel()
+
+	keys := generateRandomKeys(10000)
+	for _, numShards := range []uint32{2, 3, 4, 5, 8, 9, 16} {
+		numShards := numShards
+		t.Run(fmt.Sprintf("%d shards", numShards), func(t *testing.T) {
+			t.Parallel()
+
+			bsp, _ := sharded.NewBinarySplitShardIDProvider(numShards)
+			counts := make(map[uint32]int)
+			for _, key := range keys {
+				shardID := bsp.ComputeId(key)
+				require.Less(t, shardID, numShards)
+				require.Equal(t, shardID, bsp.ComputeId(key))
+				counts[shardID]++
+			}
+
+			require.Equal(t, int(numShards), len(counts))
+		})
+	}
+}
+
+func TestBinarySplitShardIDProvider_AddingShardShouldOnlySplitOneShard(t *testing.T) {
+	t.Parallel()
+
+	numKeys := 40000
+	keys := generateRandomKeys(numKeys)
+	testCases := []struct {
+		numShards   uint32
+		sourceShard uint32
+		movedShare  float64
+	}{
+		{numShards: 4, sourceShard: 0, movedShare: 1.0 / 8},
+		{numShards: 5, sourceShard: 1, movedShare: 1.0 / 8},
+		{numShards: 8, sourceShard: 0, movedShare: 1.0 / 16},
+		{numShards: 9, sourceShard: 1, movedShare: 1.0 / 16},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d to %d shards", tc.numShards, tc.numShards+1), func(t *testing.T) {
+			t.Parallel()
+
+			before, _ := sharded.NewBinarySplitShardIDProvider(tc.numShards)
+			after, _ := sharded.NewBinarySplitShardIDProvider(tc.numShards + 1)
+
+			shardIDsBefore := computeShardIDs(before, keys)
+			shardIDsAfter := computeShardIDs(after, keys)
+			sourceShards := make(map[uint32]int)
+			numMoved := 0
+			for i := range keys {
+				if shardIDsBefore[i] == shardIDsAfter[i] {
+					continue
+				}
+
+				require.Equal(t, tc.numShards, shardIDsAfter[i])
+				sourceShards[shardIDsBefore[i]]++
+				numMoved++
+			}
+
+			require.Equal(t, map[uint32]int{tc.sourceShard: numMoved}, sourceShards)
+			require.InDelta(t, tc.movedShare*float64(numKeys), numMoved, 0.2*tc.movedShare*float64(numKeys))
+		})
+	}
+}
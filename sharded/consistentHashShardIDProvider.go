@@ -0,0 +1,183 @@
+package sharded
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// ErrInvalidNumberOfVirtualNodes signals that an invalid number of virtual nodes was provided
+var ErrInvalidNumberOfVirtualNodes = errors.New("the number of virtual nodes must be greater than zero")
+
+// ErrShardAlreadyExists signals that the added shard already exists
+var ErrShardAlreadyExists = errors.New("shard already exists")
+
+// ErrShardNotFound signals that the removed shard does not exist
+var ErrShardNotFound = errors.New("shard not found")
+
+// ErrCannotRemoveLastShard signals that the only remaining shard can not be removed
+var ErrCannotRemoveLastShard = errors.New("the last shard can not be removed")
+
+type ringNode struct {
+	hash    uint64
+	shardID uint32
+}
+
+// consistentHashShardIDProvider assigns the keys to shards using a consistent hashing ring. Each shard is placed
+// on the ring as several virtual nodes and a key belongs to the shard owning the first virtual node following the
+// key hash. Adding or removing a shard only moves the keys of the ring ranges covered by its virtual nodes, about
+// 1/numOfShards of the keys, the data of the moved keys having to be migrated by the caller
+type consistentHashShardIDProvider struct {
+	mut          sync.RWMutex
+	virtualNodes int
+	ring         []ringNode
+	shardIDs     map[uint32]struct{}
+}
+
+// NewConsistentHashShardIDProvider creates a consistent hashing shard ID provider with the shards
+// 0 to numOfShards-1, each one placed on the ring as the provided number of virtual nodes
+func NewConsistentHashShardIDProvider(numOfShards int32, virtualNodes int) (*consistentHashShardIDProvider, error) {
+	if numOfShards < minNumOfShards {
+		return nil, ErrInvalidNumberOfShards
+	}
+	if virtualNodes < 1 {
+		return nil, ErrInvalidNumberOfVirtualNodes
+	}
+
+	chp := &consistentHashShardIDProvider{
+		virtualNodes: virtualNodes,
+		ring:         make([]ringNode, 0, int(numOfShards)*virtualNodes),
+		shardIDs:     make(map[uint32]struct{}, numOfShards),
+	}
+	for shardID := uint32(0); shardID < uint32(numOfShards); shardID++ {
+		chp.addShardNodes(shardID)
+	}
+	chp.sortRing()
+
+	return chp, nil
+}
+
+// ComputeId computes the shard id for a given key
+func (chp *consistentHashShardIDProvider) ComputeId(key []byte) uint32 {
+	keyHash := hashBytes(key)
+
+	chp.mut.RLock()
+	defer chp.mut.RUnlock()
+
+	idx := sort.Search(len(chp.ring), func(i int) bool {
+		return chp.ring[i].hash >= keyHash
+	})
+	if idx == len(chp.ring) {
+		idx = 0
+	}
+
+	return chp.ring[idx].shardID
+}
+
+// AddShard places a new shard on the ring, taking over the keys of the ring ranges covered by its virtual nodes
+func (chp *consistentHashShardIDProvider) AddShard(shardID uint32) error {
+	chp.mut.Lock()
+	defer chp.mut.Unlock()
+
+	_, exists := chp.shardIDs[shardID]
+	if exists {
+		return ErrShardAlreadyExists
+	}
+
+	chp.addShardNodes(shardID)
+	chp.sortRing()
+
+	return nil
+}
+
+// RemoveShard removes a shard from the ring, its keys being taken over by the shards following its virtual nodes
+func (chp *consistentHashShardIDProvider) RemoveShard(shardID uint32) error {
+	chp.mut.Lock()
+	defer chp.mut.Unlock()
+
+	_, exists := chp.shardIDs[shardID]
+	if !exists {
+		return ErrShardNotFound
+	}
+	if len(chp.shardIDs) == 1 {
+		return ErrCannotRemoveLastShard
+	}
+
+	ring := make([]ringNode, 0, len(chp.ring)-chp.virtualNodes)
+	for _, node := range chp.ring {
+		if node.shardID != shardID {
+			ring = append(ring, node)
+		}
+	}
+	chp.ring = ring
+	delete(chp.shardIDs, shardID)
+
+	return nil
+}
+
+// NumberOfShards returns the number of shards
+func (chp *consistentHashShardIDProvider) NumberOfShards() uint32 {
+	chp.mut.RLock()
+	defer chp.mut.RUnlock()
+
+	return uint32(len(chp.shardIDs))
+}
+
+// GetShardIDs will return a sorted list of all shard ids
+func (chp *consistentHashShardIDProvider) GetShardIDs() []uint32 {
+	chp.mut.RLock()
+	defer chp.mut.RUnlock()
+
+	shardIDs := make([]uint32, 0, len(chp.shardIDs))
+	for shardID := range chp.shardIDs {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool {
+		return shardIDs[i] < shardIDs[j]
+	})
+
+	return shardIDs
+}
+
+func (chp *consistentHashShardIDProvider) addShardNodes(shardID uint32) {
+	buff := make([]byte, 8)
+	binary.BigEndian.PutUint32(buff, shardID)
+	for i := 0; i < chp.virtualNodes; i++ {
+		binary.BigEndian.PutUint32(buff[4:], uint32(i))
+		chp.ring = append(chp.ring, ringNode{
+			hash:    hashBytes(buff),
+			shardID: shardID,
+		})
+	}
+
+	chp.shardIDs[shardID] = struct{}{}
+}
+
+func (chp *consistentHashShardIDProvider) sortRing() {
+	sort.Slice(chp.ring, func(i, j int) bool {
+		return chp.ring[i].hash < chp.ring[j].hash
+	})
+}
+
+// hashBytes computes the fnv hash of the provided bytes, followed by a finalizer spreading the hashes of
+// similar inputs over the whole ring
+func hashBytes(buff []byte) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write(buff)
+	h := hasher.Sum64()
+
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (chp *consistentHashShardIDProvider) IsInterfaceNil() bool {
+	return chp == nil
+}
@@ -0,0 +1,144 @@
+package sharded_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/sharded"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func generateRandomKeys(numKeys int) [][]byte {
+	keys := make([][]byte, 0, numKeys)
+	for i := 0; i < numKeys; i++ {
+		key := make([]byte, 32)
+		_, _ = rand.Read(key)
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func computeShardIDs(provider types.ShardIDProvider, keys [][]byte) []uint32 {
+	shardIDs := make([]uint32, 0, len(keys))
+	for _, key := range keys {
+		shardIDs = append(shardIDs, provider.ComputeId(key))
+	}
+
+	return shardIDs
+}
+
+func countMoved(before []uint32, after []uint32) int {
+	numMoved := 0
+	for i := range before {
+		if before[i] != after[i] {
+			numMoved++
+		}
+	}
+
+	return numMoved
+}
+
+func TestNewConsistentHashShardIDProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid number of shards", func(t *testing.T) {
+		t.Parallel()
+
+		ip, err := sharded.NewConsistentHashShardIDProvider(1, 10)
+		require.Nil(t, ip)
+		require.Equal(t, sharded.ErrInvalidNumberOfShards, err)
+	})
+	t.Run("invalid number of virtual nodes", func(t *testing.T) {
+		t.Parallel()
+
+		ip, err := sharded.NewConsistentHashShardIDProvider(4, 0)
+		require.Nil(t, ip)
+		require.Equal(t, sharded.ErrInvalidNumberOfVirtualNodes, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		ip, err := sharded.NewConsistentHashShardIDProvider(4, 10)
+		require.Nil(t, err)
+		require.Equal(t, uint32(4), ip.NumberOfShards())
+		require.Equal(t, []uint32{0, 1, 2, 3}, ip.GetShardIDs())
+	})
+}
+
+func TestConsistentHashShardIDProvider_ComputeIdShouldSpreadKeys(t *testing.T) {
+	t.Parallel()
+
+	numShards := 4
+	ip, _ := sharded.NewConsistentHashShardIDProvider(int32(numShards), 100)
+	keys := generateRandomKeys(10000)
+
+	counts := make(map[uint32]int)
+	for _, shardID := range computeShardIDs(ip, keys) {
+		counts[shardID]++
+	}
+
+	require.Equal(t, numShards, len(counts))
+	for _, count := range counts {
+		require.Greater(t, count, len(keys)/numShards/2)
+	}
+	require.Equal(t, ip.ComputeId(keys[0]), ip.ComputeId(keys[0]))
+}
+
+func TestConsistentHashShardIDProvider_AddRemoveShard(t *testing.T) {
+	t.Parallel()
+
+	ip, _ := sharded.NewConsistentHashShardIDProvider(2, 10)
+
+	require.Equal(t, sharded.ErrShardAlreadyExists, ip.AddShard(1))
+	require.Nil(t, ip.AddShard(5))
+	require.Equal(t, []uint32{0, 1, 5}, ip.GetShardIDs())
+
+	require.Equal(t, sharded.ErrShardNotFound, ip.RemoveShard(3))
+	require.Nil(t, ip.RemoveShard(0))
+	require.Nil(t, ip.RemoveShard(5))
+	require.Equal(t, sharded.ErrCannotRemoveLastShard, ip.RemoveShard(1))
+	require.Equal(t, uint32(1), ip.NumberOfShards())
+	require.Equal(t, uint32(1), ip.ComputeId([]byte("key")))
+}
+
+func TestConsistentHashShardIDProvider_KeyMovementOnShardCountChange(t *testing.T) {
+	t.Parallel()
+
+	keys := generateRandomKeys(10000)
+
+	binarySplitBefore, _ := sharded.NewShardIDProvider(8)
+	binarySplitAfter, _ := sharded.NewShardIDProvider(9)
+	binarySplitMoved := countMoved(computeShardIDs(binarySplitBefore, keys), computeShardIDs(binarySplitAfter, keys))
+
+	consistentHash, _ := sharded.NewConsistentHashShardIDProvider(8, 100)
+	shardIDsBefore := computeShardIDs(consistentHash, keys)
+	require.Nil(t, consistentHash.AddShard(8))
+	shardIDsAfterAdd := computeShardIDs(consistentHash, keys)
+	consistentHashMoved := countMoved(shardIDsBefore, shardIDsAfterAdd)
+
+	t.Logf("keys moved from 8 to 9 shards: binary split %d, consistent hashing %d, ideal %d, out of %d",
+		binarySplitMoved, consistentHashMoved, len(keys)/9, len(keys))
+
+	// only the keys taken over by the new shard move, close to the ideal 1/9 of the keys
+	for i := range keys {
+		if shardIDsBefore[i] != shardIDsAfterAdd[i] {
+			require.Equal(t, uint32(8), shardIDsAfterAdd[i])
+		}
+	}
+	require.Less(t, consistentHashMoved, len(keys)/9*3/2)
+
+	// removing any shard, not only the last one, moves only the keys of the removed shard
+	require.Nil(t, consistentHash.RemoveShard(3))
+	shardIDsAfterRemove := computeShardIDs(consistentHash, keys)
+	for i := range keys {
+		if shardIDsAfterAdd[i] != shardIDsAfterRemove[i] {
+			require.Equal(t, uint32(3), shardIDsAfterAdd[i])
+		}
+	}
+
+	require.Nil(t, consistentHash.AddShard(3))
+	require.Nil(t, consistentHash.RemoveShard(8))
+	require.Equal(t, shardIDsBefore, computeShardIDs(consistentHash, keys))
+}
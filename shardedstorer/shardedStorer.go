@@ -0,0 +1,249 @@
+package shardedstorer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-core-go/data"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Storer = (*shardedStorer)(nil)
+
+// ErrNilStorer signals that a nil storer was provided
+var ErrNilStorer = errors.New("nil storer")
+
+// ErrNilShardFunc signals that a nil shard function was provided
+var ErrNilShardFunc = errors.New("nil shard function")
+
+// ErrInvalidShardIndex signals that the shard function returned an index out of the storers range
+var ErrInvalidShardIndex = errors.New("invalid shard index")
+
+// shardedStorer routes each key to the storer owning it, as computed by the shard function, so each shard keeps its
+// own cache, persister and lock. The shard function must always return the same index for a given key, otherwise
+// the keys written before are no longer found
+type shardedStorer struct {
+	storers   []types.Storer
+	shardFunc func(key []byte) int
+}
+
+// NewShardedStorer creates a storer spreading the keys over the provided storers, the shard function returning
+// the index of the storer owning a key
+func NewShardedStorer(storers []types.Storer, shardFunc func(key []byte) int) (*shardedStorer, error) {
+	if len(storers) == 0 {
+		return nil, common.ErrInvalidNumShards
+	}
+	for idx, storer := range storers {
+		if check.IfNil(storer) {
+			return nil, fmt.Errorf("%w at index %d", ErrNilStorer, idx)
+		}
+	}
+	if shardFunc == nil {
+		return nil, ErrNilShardFunc
+	}
+
+	return &shardedStorer{
+		storers:   append([]types.Storer(nil), storers...),
+		shardFunc: shardFunc,
+	}, nil
+}
+
+func (ss *shardedStorer) shardIndex(key []byte) (int, error) {
+	idx := ss.shardFunc(key)
+	if idx < 0 || idx >= len(ss.storers) {
+		return 0, fmt.Errorf("%w: %d for %d shards", ErrInvalidShardIndex, idx, len(ss.storers))
+	}
+
+	return idx, nil
+}
+
+func (ss *shardedStorer) storerFor(key []byte) (types.Storer, error) {
+	idx, err := ss.shardIndex(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.storers[idx], nil
+}
+
+// Put writes the data in the storer owning the key
+func (ss *shardedStorer) Put(key, data []byte) error {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return err
+	}
+
+	return storer.Put(key, data)
+}
+
+// PutInEpoch writes the data in the provided epoch of the storer owning the key
+func (ss *shardedStorer) PutInEpoch(key, data []byte, epoch uint32) error {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return err
+	}
+
+	return storer.PutInEpoch(key, data, epoch)
+}
+
+// Get returns the value of the key from the storer owning it
+func (ss *shardedStorer) Get(key []byte) ([]byte, error) {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return storer.Get(key)
+}
+
+// Has returns nil if the key is present in the storer owning it
+func (ss *shardedStorer) Has(key []byte) error {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return err
+	}
+
+	return storer.Has(key)
+}
+
+// SearchFirst returns the first value found for the key in the storer owning it
+func (ss *shardedStorer) SearchFirst(key []byte) ([]byte, error) {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return storer.SearchFirst(key)
+}
+
+// RemoveFromCurrentEpoch removes the key from the current epoch of the storer owning it
+func (ss *shardedStorer) RemoveFromCurrentEpoch(key []byte) error {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return err
+	}
+
+	return storer.RemoveFromCurrentEpoch(key)
+}
+
+// Remove removes the key from the storer owning it
+func (ss *shardedStorer) Remove(key []byte) error {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return err
+	}
+
+	return storer.Remove(key)
+}
+
+// GetFromEpoch returns the value of the key from the provided epoch of the storer owning it
+func (ss *shardedStorer) GetFromEpoch(key []byte, epoch uint32) ([]byte, error) {
+	storer, err := ss.storerFor(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return storer.GetFromEpoch(key, epoch)
+}
+
+// GetBulkFromEpoch groups the keys by the storer owning them and returns the values found in the provided epoch
+// of each storer, in shard order
+func (ss *shardedStorer) GetBulkFromEpoch(keys [][]byte, epoch uint32) ([]data.KeyValuePair, error) {
+	keysByShard := make([][][]byte, len(ss.storers))
+	for _, key := range keys {
+		idx, err := ss.shardIndex(key)
+		if err != nil {
+			return nil, err
+		}
+
+		keysByShard[idx] = append(keysByShard[idx], key)
+	}
+
+	results := make([]data.KeyValuePair, 0, len(keys))
+	for idx, shardKeys := range keysByShard {
+		if len(shardKeys) == 0 {
+			continue
+		}
+
+		shardResults, err := ss.storers[idx].GetBulkFromEpoch(shardKeys, epoch)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, shardResults...)
+	}
+
+	return results, nil
+}
+
+// GetOldestEpoch returns the oldest epoch among all the storers
+func (ss *shardedStorer) GetOldestEpoch() (uint32, error) {
+	oldestEpoch := uint32(0)
+	for idx, storer := range ss.storers {
+		epoch, err := storer.GetOldestEpoch()
+		if err != nil {
+			return 0, err
+		}
+
+		if idx == 0 || epoch < oldestEpoch {
+			oldestEpoch = epoch
+		}
+	}
+
+	return oldestEpoch, nil
+}
+
+// RangeKeys iterates over the (key, value) pairs of all the storers, one storer after the other, so the handler is
+// never called concurrently. If the handler returns true, the iteration will continue, otherwise will stop
+func (ss *shardedStorer) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	for _, storer := range ss.storers {
+		shouldContinue := true
+		storer.RangeKeys(func(key []byte, val []byte) bool {
+			shouldContinue = handler(key, val)
+			return shouldContinue
+		})
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
+// ClearCache clears the caches of all the storers
+func (ss *shardedStorer) ClearCache() {
+	for _, storer := range ss.storers {
+		storer.ClearCache()
+	}
+}
+
+// DestroyUnit destroys all the storers, returning the first error met after trying all of them
+func (ss *shardedStorer) DestroyUnit() error {
+	return ss.applyOnAll(types.Storer.DestroyUnit)
+}
+
+// Close closes all the storers, returning the first error met after trying all of them
+func (ss *shardedStorer) Close() error {
+	return ss.applyOnAll(types.Storer.Close)
+}
+
+func (ss *shardedStorer) applyOnAll(handler func(storer types.Storer) error) error {
+	var firstErr error
+	for _, storer := range ss.storers {
+		err := handler(storer)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ss *shardedStorer) IsInterfaceNil() bool {
+	return ss == nil
+}
@@ -0,0 +1,186 @@
+package shardedstorer_test
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/shardedstorer"
+	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func createUnit(t *testing.T) *storageUnit.Unit {
+	cache, err := lrucache.NewCache(10)
+	require.Nil(t, err)
+	unit, err := storageUnit.NewStorageUnit(cache, memorydb.New())
+	require.Nil(t, err)
+
+	return unit
+}
+
+func createUnits(t *testing.T, numUnits int) []types.Storer {
+	units := make([]types.Storer, 0, numUnits)
+	for i := 0; i < numUnits; i++ {
+		units = append(units, createUnit(t))
+	}
+
+	return units
+}
+
+// firstByteShardFunc routes the keys by their first byte, so the tests know the owning unit
+func firstByteShardFunc(numShards int) func(key []byte) int {
+	return func(key []byte) int {
+		return int(key[0]) % numShards
+	}
+}
+
+func TestNewShardedStorer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no storers should error", func(t *testing.T) {
+		t.Parallel()
+
+		ss, err := shardedstorer.NewShardedStorer(nil, firstByteShardFunc(1))
+		require.True(t, check.IfNil(ss))
+		require.Equal(t, common.ErrInvalidNumShards, err)
+	})
+	t.Run("nil storer should error", func(t *testing.T) {
+		t.Parallel()
+
+		ss, err := shardedstorer.NewShardedStorer([]types.Storer{createUnit(t), nil}, firstByteShardFunc(2))
+		require.True(t, check.IfNil(ss))
+		require.True(t, errors.Is(err, shardedstorer.ErrNilStorer))
+	})
+	t.Run("nil shard func should error", func(t *testing.T) {
+		t.Parallel()
+
+		ss, err := shardedstorer.NewShardedStorer(createUnits(t, 2), nil)
+		require.True(t, check.IfNil(ss))
+		require.Equal(t, shardedstorer.ErrNilShardFunc, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		ss, err := shardedstorer.NewShardedStorer(createUnits(t, 2), firstByteShardFunc(2))
+		require.False(t, check.IfNil(ss))
+		require.Nil(t, err)
+	})
+}
+
+func TestShardedStorer_KeyOperationsShouldBeRoutedToTheOwningStorer(t *testing.T) {
+	t.Parallel()
+
+	units := createUnits(t, 2)
+	ss, _ := shardedstorer.NewShardedStorer(units, firstByteShardFunc(2))
+
+	evenKey, oddKey := []byte{0, 'a'}, []byte{1, 'b'}
+	require.Nil(t, ss.Put(evenKey, []byte("even")))
+	require.Nil(t, ss.Put(oddKey, []byte("odd")))
+
+	require.Nil(t, units[0].Has(evenKey))
+	require.NotNil(t, units[0].Has(oddKey))
+	require.Nil(t, units[1].Has(oddKey))
+	require.NotNil(t, units[1].Has(evenKey))
+
+	val, err := ss.Get(evenKey)
+	require.Nil(t, err)
+	require.Equal(t, []byte("even"), val)
+	val, err = ss.SearchFirst(oddKey)
+	require.Nil(t, err)
+	require.Equal(t, []byte("odd"), val)
+	val, err = ss.GetFromEpoch(oddKey, 0)
+	require.Nil(t, err)
+	require.Equal(t, []byte("odd"), val)
+
+	require.Nil(t, ss.Remove(evenKey))
+	require.NotNil(t, ss.Has(evenKey))
+	require.NotNil(t, units[0].Has(evenKey))
+	require.Nil(t, ss.Has(oddKey))
+}
+
+func TestShardedStorer_InvalidShardIndexShouldError(t *testing.T) {
+	t.Parallel()
+
+	ss, _ := shardedstorer.NewShardedStorer(createUnits(t, 2), func(key []byte) int {
+		return 2
+	})
+
+	err := ss.Put([]byte("key"), []byte("val"))
+	require.True(t, errors.Is(err, shardedstorer.ErrInvalidShardIndex))
+	_, err = ss.Get([]byte("key"))
+	require.True(t, errors.Is(err, shardedstorer.ErrInvalidShardIndex))
+	_, err = ss.GetBulkFromEpoch([][]byte{[]byte("key")}, 0)
+	require.True(t, errors.Is(err, shardedstorer.ErrInvalidShardIndex))
+}
+
+func TestShardedStorer_GetBulkFromEpochShouldMergeTheShardsResults(t *testing.T) {
+	t.Parallel()
+
+	ss, _ := shardedstorer.NewShardedStorer(createUnits(t, 3), firstByteShardFunc(3))
+
+	keys := make([][]byte, 0, 6)
+	for i := byte(0); i < 6; i++ {
+		key := []byte{i, 'k'}
+		keys = append(keys, key)
+		require.Nil(t, ss.Put(key, []byte(fmt.Sprintf("val%d", i))))
+	}
+
+	results, err := ss.GetBulkFromEpoch(append(keys, []byte{7, 'm'}), 0)
+	require.Nil(t, err)
+	require.Len(t, results, len(keys))
+	for _, result := range results {
+		require.Equal(t, []byte(fmt.Sprintf("val%d", result.Key[0])), result.Value)
+	}
+}
+
+func TestShardedStorer_RangeKeys(t *testing.T) {
+	t.Parallel()
+
+	ss, _ := shardedstorer.NewShardedStorer(createUnits(t, 3), firstByteShardFunc(3))
+	for i := byte(0); i < 9; i++ {
+		require.Nil(t, ss.Put([]byte{i}, []byte{i}))
+	}
+
+	t.Run("should visit the keys of all the storers", func(t *testing.T) {
+		visited := make([]int, 0, 9)
+		ss.RangeKeys(func(key []byte, val []byte) bool {
+			require.Equal(t, key, val)
+			visited = append(visited, int(key[0]))
+			return true
+		})
+		sort.Ints(visited)
+		require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8}, visited)
+	})
+	t.Run("should stop when the handler returns false", func(t *testing.T) {
+		numVisited := 0
+		ss.RangeKeys(func(key []byte, val []byte) bool {
+			numVisited++
+			return false
+		})
+		require.Equal(t, 1, numVisited)
+	})
+	t.Run("nil handler should not panic", func(t *testing.T) {
+		require.NotPanics(t, func() {
+			ss.RangeKeys(nil)
+		})
+	})
+}
+
+func TestShardedStorer_CloseShouldCloseAllTheStorers(t *testing.T) {
+	t.Parallel()
+
+	units := createUnits(t, 2)
+	ss, _ := shardedstorer.NewShardedStorer(units, firstByteShardFunc(2))
+
+	require.Nil(t, ss.Close())
+	for _, unit := range units {
+		require.Equal(t, common.ErrUnitClosed, unit.Put([]byte("key"), []byte("val")))
+	}
+}
@@ -0,0 +1,179 @@
+package sizetieredcache
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*sizeTieredCache)(nil)
+
+// ErrInvalidSmallThreshold signals that a negative small value threshold was provided
+var ErrInvalidSmallThreshold = errors.New("invalid small value threshold")
+
+// ErrSameCacheForBothTiers signals that the same cache instance was provided for both tiers
+var ErrSameCacheForBothTiers = errors.New("the same cache was provided for both tiers")
+
+// sizeTieredCache routes the values to one of two inner caches by their size, so the small values can be held in a
+// count bounded cache and the large ones in a byte bounded cache, each tier evicting on its own. A key lives in at
+// most one tier: writing it in one tier removes it from the other. The writes are serialized to keep this invariant,
+// while the reads go straight to the inner caches
+type sizeTieredCache struct {
+	mutWrite       sync.Mutex
+	smallThreshold int
+	smallCache     types.Cacher
+	largeCache     types.Cacher
+}
+
+// NewSizeTieredCache creates a cache storing the values of at most smallThreshold bytes in the small cache and the
+// larger ones in the large cache. The inner caches should not be used directly afterwards
+func NewSizeTieredCache(smallThreshold int, smallCache types.Cacher, largeCache types.Cacher) (*sizeTieredCache, error) {
+	if smallThreshold < 0 {
+		return nil, ErrInvalidSmallThreshold
+	}
+	if check.IfNil(smallCache) || check.IfNil(largeCache) {
+		return nil, common.ErrNilCacher
+	}
+	if smallCache == largeCache {
+		return nil, ErrSameCacheForBothTiers
+	}
+
+	return &sizeTieredCache{
+		smallThreshold: smallThreshold,
+		smallCache:     smallCache,
+		largeCache:     largeCache,
+	}, nil
+}
+
+// tiers returns the tier the size belongs to, followed by the other tier
+func (c *sizeTieredCache) tiers(sizeInBytes int) (types.Cacher, types.Cacher) {
+	if sizeInBytes <= c.smallThreshold {
+		return c.smallCache, c.largeCache
+	}
+
+	return c.largeCache, c.smallCache
+}
+
+// Clear is used to completely clear both tiers.
+func (c *sizeTieredCache) Clear() {
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	c.smallCache.Clear()
+	c.largeCache.Clear()
+}
+
+// Put adds a value to the tier matching its size, removing the key from the other tier. Returns true if an
+// eviction occurred.
+func (c *sizeTieredCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	tier, otherTier := c.tiers(sizeInBytes)
+	otherTier.Remove(key)
+
+	return tier.Put(key, value, sizeInBytes)
+}
+
+// Get looks up a key's value in the small tier, then in the large tier.
+func (c *sizeTieredCache) Get(key []byte) (value interface{}, ok bool) {
+	value, ok = c.smallCache.Get(key)
+	if ok {
+		return value, true
+	}
+
+	return c.largeCache.Get(key)
+}
+
+// Has checks if a key is in any of the tiers, without updating the
+// recent-ness or deleting it for being stale.
+func (c *sizeTieredCache) Has(key []byte) bool {
+	return c.smallCache.Has(key) || c.largeCache.Has(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (c *sizeTieredCache) Peek(key []byte) (value interface{}, ok bool) {
+	value, ok = c.smallCache.Peek(key)
+	if ok {
+		return value, true
+	}
+
+	return c.largeCache.Peek(key)
+}
+
+// HasOrAdd checks if a key is in any of the tiers and if not, adds the value to the tier matching its size.
+// Returns whether the item existed before and whether it has been added.
+func (c *sizeTieredCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	if c.smallCache.Has(key) || c.largeCache.Has(key) {
+		return true, false
+	}
+
+	tier, _ := c.tiers(sizeInBytes)
+
+	return tier.HasOrAdd(key, value, sizeInBytes)
+}
+
+// Remove removes the provided key from both tiers.
+func (c *sizeTieredCache) Remove(key []byte) {
+	c.mutWrite.Lock()
+	defer c.mutWrite.Unlock()
+
+	c.smallCache.Remove(key)
+	c.largeCache.Remove(key)
+}
+
+// Keys returns the keys of the small tier followed by the keys of the large tier, each tier being ordered
+// from oldest to newest.
+func (c *sizeTieredCache) Keys() [][]byte {
+	return append(c.smallCache.Keys(), c.largeCache.Keys()...)
+}
+
+// Len returns the number of items in both tiers.
+func (c *sizeTieredCache) Len() int {
+	return c.smallCache.Len() + c.largeCache.Len()
+}
+
+// SizeInBytesContained returns the size in bytes of all the elements of both tiers
+func (c *sizeTieredCache) SizeInBytesContained() uint64 {
+	return c.smallCache.SizeInBytesContained() + c.largeCache.SizeInBytesContained()
+}
+
+// MaxSize returns the sum of the maximum number of items of the tiers.
+func (c *sizeTieredCache) MaxSize() int {
+	return c.smallCache.MaxSize() + c.largeCache.MaxSize()
+}
+
+// RegisterHandler registers the handler on both tiers, so it is called whichever tier the data is added to
+func (c *sizeTieredCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	c.smallCache.RegisterHandler(handler, id)
+	c.largeCache.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandler removes the handler from both tiers
+func (c *sizeTieredCache) UnRegisterHandler(id string) {
+	c.smallCache.UnRegisterHandler(id)
+	c.largeCache.UnRegisterHandler(id)
+}
+
+// Close closes both tiers
+func (c *sizeTieredCache) Close() error {
+	errSmall := c.smallCache.Close()
+	errLarge := c.largeCache.Close()
+	if errSmall != nil {
+		return errSmall
+	}
+
+	return errLarge
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *sizeTieredCache) IsInterfaceNil() bool {
+	return c == nil
+}
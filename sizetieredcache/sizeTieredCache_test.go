@@ -0,0 +1,202 @@
+package sizetieredcache_test
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/sizetieredcache"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+const smallThreshold = 64
+
+func createTiers(t *testing.T) (types.Cacher, types.Cacher) {
+	smallCache, err := lrucache.NewCache(10)
+	require.Nil(t, err)
+	largeCache, err := lrucache.NewCacheWithSizeInBytes(100, 4096)
+	require.Nil(t, err)
+
+	return smallCache, largeCache
+}
+
+func TestNewSizeTieredCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("negative threshold should error", func(t *testing.T) {
+		t.Parallel()
+
+		smallCache, largeCache := createTiers(t)
+		c, err := sizetieredcache.NewSizeTieredCache(-1, smallCache, largeCache)
+		require.True(t, check.IfNil(c))
+		require.Equal(t, sizetieredcache.ErrInvalidSmallThreshold, err)
+	})
+	t.Run("nil caches should error", func(t *testing.T) {
+		t.Parallel()
+
+		smallCache, largeCache := createTiers(t)
+		c, err := sizetieredcache.NewSizeTieredCache(smallThreshold, nil, largeCache)
+		require.True(t, check.IfNil(c))
+		require.Equal(t, common.ErrNilCacher, err)
+
+		c, err = sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, nil)
+		require.True(t, check.IfNil(c))
+		require.Equal(t, common.ErrNilCacher, err)
+	})
+	t.Run("same cache for both tiers should error", func(t *testing.T) {
+		t.Parallel()
+
+		smallCache, _ := createTiers(t)
+		c, err := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, smallCache)
+		require.True(t, check.IfNil(c))
+		require.Equal(t, sizetieredcache.ErrSameCacheForBothTiers, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		smallCache, largeCache := createTiers(t)
+		c, err := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, largeCache)
+		require.False(t, check.IfNil(c))
+		require.Nil(t, err)
+		require.Equal(t, 110, c.MaxSize())
+	})
+}
+
+func TestSizeTieredCache_PutShouldRouteBySize(t *testing.T) {
+	t.Parallel()
+
+	smallCache, largeCache := createTiers(t)
+	c, _ := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, largeCache)
+
+	c.Put([]byte("small"), "small value", smallThreshold)
+	c.Put([]byte("large"), "large value", smallThreshold+1)
+
+	require.True(t, smallCache.Has([]byte("small")))
+	require.False(t, largeCache.Has([]byte("small")))
+	require.True(t, largeCache.Has([]byte("large")))
+	require.False(t, smallCache.Has([]byte("large")))
+
+	value, ok := c.Get([]byte("small"))
+	require.True(t, ok)
+	require.Equal(t, "small value", value)
+	value, ok = c.Peek([]byte("large"))
+	require.True(t, ok)
+	require.Equal(t, "large value", value)
+	require.Equal(t, 2, c.Len())
+	require.Equal(t, uint64(smallThreshold+1), c.SizeInBytesContained())
+
+	keys := make([]string, 0)
+	for _, key := range c.Keys() {
+		keys = append(keys, string(key))
+	}
+	require.Equal(t, []string{"small", "large"}, keys)
+}
+
+func TestSizeTieredCache_PutShouldMoveKeyBetweenTiers(t *testing.T) {
+	t.Parallel()
+
+	smallCache, largeCache := createTiers(t)
+	c, _ := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, largeCache)
+
+	c.Put([]byte("key"), "small value", 1)
+	c.Put([]byte("key"), "large value", 1000)
+	require.False(t, smallCache.Has([]byte("key")))
+	require.Equal(t, 1, c.Len())
+
+	value, ok := c.Get([]byte("key"))
+	require.True(t, ok)
+	require.Equal(t, "large value", value)
+
+	c.Put([]byte("key"), "small again", 1)
+	require.False(t, largeCache.Has([]byte("key")))
+	require.Equal(t, 1, c.Len())
+}
+
+func TestSizeTieredCache_HasOrAddShouldCheckBothTiers(t *testing.T) {
+	t.Parallel()
+
+	smallCache, largeCache := createTiers(t)
+	c, _ := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, largeCache)
+
+	has, added := c.HasOrAdd([]byte("key"), "large value", 1000)
+	require.False(t, has)
+	require.True(t, added)
+
+	has, added = c.HasOrAdd([]byte("key"), "small value", 1)
+	require.True(t, has)
+	require.False(t, added)
+	require.False(t, smallCache.Has([]byte("key")))
+}
+
+func TestSizeTieredCache_RemoveAndClearShouldAffectBothTiers(t *testing.T) {
+	t.Parallel()
+
+	smallCache, largeCache := createTiers(t)
+	c, _ := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, largeCache)
+
+	c.Put([]byte("small"), "small value", 1)
+	c.Put([]byte("large"), "large value", 1000)
+
+	c.Remove([]byte("large"))
+	require.False(t, c.Has([]byte("large")))
+	require.True(t, c.Has([]byte("small")))
+
+	c.Put([]byte("large"), "large value", 1000)
+	c.Clear()
+	require.Equal(t, 0, c.Len())
+	require.Equal(t, 0, smallCache.Len())
+	require.Equal(t, 0, largeCache.Len())
+}
+
+func TestSizeTieredCache_TiersShouldEvictIndependently(t *testing.T) {
+	t.Parallel()
+
+	smallCache, largeCache := createTiers(t)
+	c, _ := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, largeCache)
+
+	c.Put([]byte("large"), "large value", 1000)
+	for i := 0; i < 100; i++ {
+		c.Put([]byte(fmt.Sprintf("small%d", i)), i, 1)
+	}
+
+	require.Equal(t, 10, smallCache.Len())
+	require.True(t, c.Has([]byte("large")))
+}
+
+func TestSizeTieredCache_RegisterHandlerShouldBeCalledForBothTiers(t *testing.T) {
+	t.Parallel()
+
+	smallCache, largeCache := createTiers(t)
+	c, _ := sizetieredcache.NewSizeTieredCache(smallThreshold, smallCache, largeCache)
+
+	mut := sync.Mutex{}
+	addedKeys := make([]string, 0)
+	chDone := make(chan struct{}, 2)
+	c.RegisterHandler(func(key []byte, _ interface{}) {
+		mut.Lock()
+		addedKeys = append(addedKeys, string(key))
+		mut.Unlock()
+		chDone <- struct{}{}
+	}, "id")
+
+	c.Put([]byte("small"), "small value", 1)
+	c.Put([]byte("large"), "large value", 1000)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-chDone:
+		case <-time.After(time.Second):
+			require.Fail(t, "timeout waiting for the handlers")
+		}
+	}
+
+	mut.Lock()
+	sort.Strings(addedKeys)
+	require.Equal(t, []string{"large", "small"}, addedKeys)
+	mut.Unlock()
+}
@@ -0,0 +1,196 @@
+package softdeletepersister
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*softDeletePersister)(nil)
+
+var log = logger.GetOrCreate("storage/softdeletepersister")
+
+// ErrInvalidStoredValue signals that the value stored in the inner persister could not be decoded
+var ErrInvalidStoredValue = errors.New("invalid stored value")
+
+// softDeletePersister replaces the removal of a key with a tombstone recording the removal time and the removed
+// value. Tombstoned keys are hidden from Get, Has and RangeKeys and are physically deleted by GarbageCollect.
+// All the values stored in the inner persister are encoded by this wrapper, so the inner persister should not be
+// shared with other writers.
+type softDeletePersister struct {
+	mutWrite          sync.Mutex
+	inner             types.Persister
+	softRemoveDefault bool
+}
+
+// NewSoftDeletePersister creates a persister wrapper supporting soft removals. If softRemoveDefault is set,
+// Remove behaves as SoftRemove, otherwise it physically deletes the key
+func NewSoftDeletePersister(inner types.Persister, softRemoveDefault bool) (*softDeletePersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+
+	return &softDeletePersister{
+		inner:             inner,
+		softRemoveDefault: softRemoveDefault,
+	}, nil
+}
+
+// Put adds the value, replacing any existing tombstone of the key
+func (sdp *softDeletePersister) Put(key, val []byte) error {
+	sdp.mutWrite.Lock()
+	defer sdp.mutWrite.Unlock()
+
+	return sdp.inner.Put(key, encodeLiveValue(val))
+}
+
+// Get returns the value of the key, or common.ErrKeyNotFound if the key is missing or soft removed
+func (sdp *softDeletePersister) Get(key []byte) ([]byte, error) {
+	storedValue, err := sdp.inner.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	val, isTombstone, _, err := decodeStoredValue(storedValue)
+	if err != nil {
+		return nil, err
+	}
+	if isTombstone {
+		return nil, common.ErrKeyNotFound
+	}
+
+	return val, nil
+}
+
+// Has returns nil if the key is present and was not soft removed
+func (sdp *softDeletePersister) Has(key []byte) error {
+	_, err := sdp.Get(key)
+
+	return err
+}
+
+// Remove soft removes the key if the wrapper was created with softRemoveDefault, otherwise deletes it
+func (sdp *softDeletePersister) Remove(key []byte) error {
+	if sdp.softRemoveDefault {
+		return sdp.SoftRemove(key)
+	}
+
+	sdp.mutWrite.Lock()
+	defer sdp.mutWrite.Unlock()
+
+	return sdp.inner.Remove(key)
+}
+
+// SoftRemove replaces the value of the key with a tombstone holding the current time and the removed value.
+// Removing an already soft removed key keeps the original tombstone
+func (sdp *softDeletePersister) SoftRemove(key []byte) error {
+	sdp.mutWrite.Lock()
+	defer sdp.mutWrite.Unlock()
+
+	var removedValue []byte
+	storedValue, err := sdp.inner.Get(key)
+	switch {
+	case err == nil:
+		val, isTombstone, _, errDecode := decodeStoredValue(storedValue)
+		if errDecode != nil {
+			return errDecode
+		}
+		if isTombstone {
+			return nil
+		}
+		removedValue = val
+	case errors.Is(err, common.ErrKeyNotFound):
+	default:
+		return err
+	}
+
+	return sdp.inner.Put(key, encodeTombstone(time.Now().UnixNano(), removedValue))
+}
+
+// GarbageCollect physically deletes the tombstones created before olderThan, returning their number
+func (sdp *softDeletePersister) GarbageCollect(olderThan time.Time) (int, error) {
+	sdp.mutWrite.Lock()
+	defer sdp.mutWrite.Unlock()
+
+	limit := olderThan.UnixNano()
+	expiredKeys := make([][]byte, 0)
+	sdp.inner.RangeKeys(func(key []byte, storedValue []byte) bool {
+		_, isTombstone, removedAt, err := decodeStoredValue(storedValue)
+		if err != nil {
+			log.Warn("softDeletePersister.GarbageCollect: invalid stored value", "key", key, "error", err)
+			return true
+		}
+		if isTombstone && removedAt < limit {
+			expiredKeys = append(expiredKeys, append([]byte{}, key...))
+		}
+
+		return true
+	})
+
+	for idx, key := range expiredKeys {
+		err := sdp.inner.Remove(key)
+		if err != nil {
+			return idx, err
+		}
+	}
+
+	return len(expiredKeys), nil
+}
+
+// RangeKeys iterates over the pairs that were not soft removed
+func (sdp *softDeletePersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	sdp.RangeKeysIncludingDeleted(func(key []byte, val []byte, deleted bool) bool {
+		if deleted {
+			return true
+		}
+
+		return handler(key, val)
+	})
+}
+
+// RangeKeysIncludingDeleted iterates over all the pairs, flagging the soft removed ones. For those, the provided
+// value is the one that was removed
+func (sdp *softDeletePersister) RangeKeysIncludingDeleted(handler func(key []byte, val []byte, deleted bool) bool) {
+	if handler == nil {
+		return
+	}
+
+	sdp.inner.RangeKeys(func(key []byte, storedValue []byte) bool {
+		val, isTombstone, _, err := decodeStoredValue(storedValue)
+		if err != nil {
+			log.Warn("softDeletePersister.RangeKeys: invalid stored value", "key", key, "error", err)
+			return true
+		}
+
+		return handler(key, val, isTombstone)
+	})
+}
+
+// Close closes the inner persister
+func (sdp *softDeletePersister) Close() error {
+	return sdp.inner.Close()
+}
+
+// Destroy removes the inner persister data
+func (sdp *softDeletePersister) Destroy() error {
+	return sdp.inner.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister data
+func (sdp *softDeletePersister) DestroyClosed() error {
+	return sdp.inner.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sdp *softDeletePersister) IsInterfaceNil() bool {
+	return sdp == nil
+}
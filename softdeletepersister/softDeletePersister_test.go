@@ -0,0 +1,147 @@
+package softdeletepersister_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/softdeletepersister"
+	"github.com/stretchr/testify/require"
+)
+
+type rangedPair struct {
+	value   string
+	deleted bool
+}
+
+func TestNewSoftDeletePersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		sdp, err := softdeletepersister.NewSoftDeletePersister(nil, true)
+		require.True(t, check.IfNil(sdp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		sdp, err := softdeletepersister.NewSoftDeletePersister(memorydb.New(), true)
+		require.False(t, check.IfNil(sdp))
+		require.Nil(t, err)
+	})
+}
+
+func TestSoftDeletePersister_SoftRemoveShouldHideTheKey(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	sdp, _ := softdeletepersister.NewSoftDeletePersister(inner, false)
+
+	require.Nil(t, sdp.Put([]byte("removed"), []byte("value1")))
+	require.Nil(t, sdp.Put([]byte("live"), []byte("value2")))
+	require.Nil(t, sdp.SoftRemove([]byte("removed")))
+	require.Nil(t, sdp.SoftRemove([]byte("missing")))
+
+	_, err := sdp.Get([]byte("removed"))
+	require.True(t, errors.Is(err, common.ErrKeyNotFound))
+	require.True(t, errors.Is(sdp.Has([]byte("removed")), common.ErrKeyNotFound))
+	require.Nil(t, inner.Has([]byte("removed")))
+
+	val, err := sdp.Get([]byte("live"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value2"), val)
+
+	visible := make(map[string]string)
+	sdp.RangeKeys(func(key []byte, val []byte) bool {
+		visible[string(key)] = string(val)
+		return true
+	})
+	require.Equal(t, map[string]string{"live": "value2"}, visible)
+
+	all := make(map[string]rangedPair)
+	sdp.RangeKeysIncludingDeleted(func(key []byte, val []byte, deleted bool) bool {
+		all[string(key)] = rangedPair{value: string(val), deleted: deleted}
+		return true
+	})
+	expected := map[string]rangedPair{
+		"live":    {value: "value2", deleted: false},
+		"removed": {value: "value1", deleted: true},
+		"missing": {value: "", deleted: true},
+	}
+	require.Equal(t, expected, all)
+
+	require.Nil(t, sdp.Put([]byte("removed"), []byte("value3")))
+	val, err = sdp.Get([]byte("removed"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value3"), val)
+}
+
+func TestSoftDeletePersister_Remove(t *testing.T) {
+	t.Parallel()
+
+	t.Run("soft remove by default should keep a tombstone", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		sdp, _ := softdeletepersister.NewSoftDeletePersister(inner, true)
+		require.Nil(t, sdp.Put([]byte("key"), []byte("value")))
+		require.Nil(t, sdp.Remove([]byte("key")))
+
+		require.True(t, errors.Is(sdp.Has([]byte("key")), common.ErrKeyNotFound))
+		require.Nil(t, inner.Has([]byte("key")))
+	})
+	t.Run("hard remove should delete the key", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		sdp, _ := softdeletepersister.NewSoftDeletePersister(inner, false)
+		require.Nil(t, sdp.Put([]byte("key"), []byte("value")))
+		require.Nil(t, sdp.Remove([]byte("key")))
+
+		require.True(t, errors.Is(sdp.Has([]byte("key")), common.ErrKeyNotFound))
+		require.True(t, errors.Is(inner.Has([]byte("key")), common.ErrKeyNotFound))
+	})
+}
+
+func TestSoftDeletePersister_GarbageCollect(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	sdp, _ := softdeletepersister.NewSoftDeletePersister(inner, true)
+	require.Nil(t, sdp.Put([]byte("key1"), []byte("value1")))
+	require.Nil(t, sdp.Put([]byte("key2"), []byte("value2")))
+	require.Nil(t, sdp.Put([]byte("live"), []byte("value3")))
+	require.Nil(t, sdp.Remove([]byte("key1")))
+	require.Nil(t, sdp.Remove([]byte("key2")))
+
+	numCollected, err := sdp.GarbageCollect(time.Now().Add(-time.Hour))
+	require.Nil(t, err)
+	require.Equal(t, 0, numCollected)
+	require.Nil(t, inner.Has([]byte("key1")))
+
+	numCollected, err = sdp.GarbageCollect(time.Now().Add(time.Hour))
+	require.Nil(t, err)
+	require.Equal(t, 2, numCollected)
+	require.True(t, errors.Is(inner.Has([]byte("key1")), common.ErrKeyNotFound))
+	require.True(t, errors.Is(inner.Has([]byte("key2")), common.ErrKeyNotFound))
+
+	val, err := sdp.Get([]byte("live"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value3"), val)
+}
+
+func TestSoftDeletePersister_GetWithInvalidStoredValueShouldError(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	_ = inner.Put([]byte("key"), []byte{})
+	sdp, _ := softdeletepersister.NewSoftDeletePersister(inner, true)
+
+	_, err := sdp.Get([]byte("key"))
+	require.Equal(t, softdeletepersister.ErrInvalidStoredValue, err)
+}
@@ -0,0 +1,50 @@
+package softdeletepersister
+
+import (
+	"encoding/binary"
+)
+
+const liveValueMarker = byte(0)
+const tombstoneMarker = byte(1)
+
+// marker + removal timestamp in unix nanoseconds
+const tombstoneHeaderLength = 1 + 8
+
+func encodeLiveValue(val []byte) []byte {
+	buff := make([]byte, 1+len(val))
+	buff[0] = liveValueMarker
+	copy(buff[1:], val)
+
+	return buff
+}
+
+// encodeTombstone keeps the removed value after the header so it can still be inspected until garbage collected
+func encodeTombstone(removedAt int64, removedValue []byte) []byte {
+	buff := make([]byte, tombstoneHeaderLength+len(removedValue))
+	buff[0] = tombstoneMarker
+	binary.BigEndian.PutUint64(buff[1:tombstoneHeaderLength], uint64(removedAt))
+	copy(buff[tombstoneHeaderLength:], removedValue)
+
+	return buff
+}
+
+// decodeStoredValue returns the contained value, whether it is a tombstone and, if so, its removal timestamp
+func decodeStoredValue(storedValue []byte) ([]byte, bool, int64, error) {
+	if len(storedValue) == 0 {
+		return nil, false, 0, ErrInvalidStoredValue
+	}
+
+	switch storedValue[0] {
+	case liveValueMarker:
+		return storedValue[1:], false, 0, nil
+	case tombstoneMarker:
+		if len(storedValue) < tombstoneHeaderLength {
+			return nil, false, 0, ErrInvalidStoredValue
+		}
+
+		removedAt := int64(binary.BigEndian.Uint64(storedValue[1:tombstoneHeaderLength]))
+		return storedValue[tombstoneHeaderLength:], true, removedAt, nil
+	default:
+		return nil, false, 0, ErrInvalidStoredValue
+	}
+}
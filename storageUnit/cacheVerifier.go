@@ -0,0 +1,78 @@
+package storageUnit
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// CacheMismatchHandler is called when a sampled cache hit holds a value different from the persisted one, with
+// the cached value, the persisted value and whether the key exists in the persister at all. Returning true
+// corrects the cache from the persister and serves the persisted value, returning false keeps serving the cached one
+type CacheMismatchHandler func(key []byte, cachedValue []byte, persistedValue []byte, existsInPersister bool) bool
+
+// cacheVerifier selects one in every samplingInterval cache hits for being cross-checked against the persister
+type cacheVerifier struct {
+	samplingInterval uint64
+	numHits          uint64
+	onMismatch       CacheMismatchHandler
+}
+
+func (cv *cacheVerifier) shouldVerify() bool {
+	if cv == nil {
+		return false
+	}
+
+	return atomic.AddUint64(&cv.numHits, 1)%cv.samplingInterval == 0
+}
+
+// WithCacheVerification makes the storage unit cross-check one in every samplingInterval cache hits of Get against
+// the persister, as a diagnostic for the deployments where the database might be modified out of band, leaving
+// stale values in the cache. The mismatches are logged and passed to the optional handler, which decides whether
+// the cache is corrected. A zero interval leaves the verification disabled
+func WithCacheVerification(samplingInterval uint64, onMismatch CacheMismatchHandler) UnitOption {
+	return func(u *Unit) {
+		if samplingInterval == 0 {
+			log.Warn("cache verification not enabled", "error", common.ErrInvalidSamplingInterval)
+			return
+		}
+
+		u.cacheVerifier = &cacheVerifier{
+			samplingInterval: samplingInterval,
+			onMismatch:       onMismatch,
+		}
+	}
+}
+
+// verifyCachedValue compares the cached value with the persisted one, returning the value to be served. A value
+// which can not be read from the persister is not verified
+func (u *Unit) verifyCachedValue(persister types.Persister, key []byte, cached []byte) ([]byte, error) {
+	persisted, err := persister.Get(key)
+	existsInPersister := err == nil
+	if err != nil && !errors.Is(err, common.ErrKeyNotFound) {
+		u.log.Debug("cannot verify cached value", "key", key, "error", err)
+		return cached, nil
+	}
+	if existsInPersister && bytes.Equal(cached, persisted) {
+		return cached, nil
+	}
+
+	u.log.Warn("cached value differs from the persisted one", "key", key, "exists in persister", existsInPersister)
+
+	onMismatch := u.cacheVerifier.onMismatch
+	if onMismatch == nil || !onMismatch(key, cached, persisted, existsInPersister) {
+		return cached, nil
+	}
+
+	if !existsInPersister {
+		u.cacher.Remove(key)
+		return nil, err
+	}
+
+	u.cacher.Put(key, persisted, len(persisted))
+
+	return persisted, nil
+}
@@ -0,0 +1,402 @@
+package storageUnit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// changeLogHeadKey stores the first retained and the next sequence numbers of the change log. Its length differs
+// from the one of the record keys, so it never collides with them
+var changeLogHeadKey = []byte("changeLogHead")
+
+// changeLogAckPrefix prefixes the keys storing the sequence acknowledged by each consumer
+var changeLogAckPrefix = []byte("changeLogAck")
+
+const changeLogSeqLength = 8
+
+// ChangeRecord is a mutation of the storage unit, as replayed by ConsumeChanges. The value is empty for removals
+type ChangeRecord struct {
+	Seq   uint64
+	Type  types.OperationType
+	Key   []byte
+	Value []byte
+}
+
+// changeLog is the sequenced log of the mutations done through the storage unit. Each record is stored under its
+// big endian sequence number, while the acknowledged sequences of the consumers are stored under their ids, so the
+// consumers can resume after a restart. The records below the minimum acknowledged sequence are removed
+type changeLog struct {
+	mut       sync.Mutex
+	persister types.Persister
+	firstSeq  uint64
+	nextSeq   uint64
+	acks      map[string]uint64
+}
+
+// WithChangeLog makes the storage unit append a sequenced record to the provided persister for each Put and
+// Remove, the records being replayed with ConsumeChanges. The persister should be dedicated to the change log and
+// is closed and destroyed together with the unit. A record is appended only after the data was written and cached,
+// so a crash in between loses the record of the last mutation, and a failed append makes the mutation return
+// ErrChangeLogAppendFailed although it was applied. A nil persister or one whose stored state can not be loaded
+// fails the unit creation
+func WithChangeLog(persister types.Persister) UnitOption {
+	return func(u *Unit) {
+		if check.IfNil(persister) {
+			u.optionsErr = fmt.Errorf("change log: %w", common.ErrNilPersister)
+			return
+		}
+
+		cl, err := loadChangeLog(persister)
+		if err != nil {
+			u.optionsErr = fmt.Errorf("change log: %w", err)
+			return
+		}
+
+		u.changeLog = cl
+	}
+}
+
+func loadChangeLog(persister types.Persister) (*changeLog, error) {
+	cl := &changeLog{
+		persister: persister,
+		acks:      make(map[string]uint64),
+	}
+
+	head, err := persister.Get(changeLogHeadKey)
+	if err == nil {
+		if len(head) != 2*changeLogSeqLength {
+			return nil, fmt.Errorf("%w: invalid head length %d", common.ErrInvalidChangeRecord, len(head))
+		}
+
+		cl.firstSeq = binary.BigEndian.Uint64(head[:changeLogSeqLength])
+		cl.nextSeq = binary.BigEndian.Uint64(head[changeLogSeqLength:])
+	}
+	if err != nil && !errors.Is(err, common.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	var errAck error
+	rangeAcks := func(key []byte, value []byte) bool {
+		if !bytes.HasPrefix(key, changeLogAckPrefix) {
+			return true
+		}
+		if len(value) != changeLogSeqLength {
+			errAck = fmt.Errorf("%w: invalid acknowledged sequence length %d", common.ErrInvalidChangeRecord, len(value))
+			return false
+		}
+
+		cl.acks[string(key[len(changeLogAckPrefix):])] = binary.BigEndian.Uint64(value)
+		return true
+	}
+
+	prefixRanger, ok := persister.(types.PrefixRanger)
+	if ok {
+		prefixRanger.RangePrefix(changeLogAckPrefix, rangeAcks)
+	} else {
+		persister.RangeKeys(rangeAcks)
+	}
+
+	return cl, errAck
+}
+
+// append stores the records of the provided operations, in order, with consecutive sequence numbers
+func (cl *changeLog) append(ops []types.Operation) error {
+	if cl == nil || len(ops) == 0 {
+		return nil
+	}
+
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	batch := make([]types.Operation, 0, len(ops)+1)
+	for i, op := range ops {
+		batch = append(batch, types.Operation{
+			Type:  types.PutOperation,
+			Key:   seqToKey(cl.nextSeq + uint64(i)),
+			Value: encodeChangeRecord(op),
+		})
+	}
+	batch = append(batch, types.Operation{
+		Type:  types.PutOperation,
+		Key:   changeLogHeadKey,
+		Value: encodeChangeLogHead(cl.firstSeq, cl.nextSeq+uint64(len(ops))),
+	})
+
+	err := cl.write(batch)
+	if err != nil {
+		return err
+	}
+
+	cl.nextSeq += uint64(len(ops))
+
+	return nil
+}
+
+// write applies the operations atomically if the persister allows it, otherwise one by one, the head being last
+func (cl *changeLog) write(ops []types.Operation) error {
+	batchApplier, ok := cl.persister.(types.BatchApplier)
+	if ok {
+		return batchApplier.ApplyBatch(ops)
+	}
+
+	for _, op := range ops {
+		var err error
+		if op.Type == types.PutOperation {
+			err = cl.persister.Put(op.Key, op.Value)
+		} else {
+			err = cl.persister.Remove(op.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cl *changeLog) bounds() (uint64, uint64) {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	return cl.firstSeq, cl.nextSeq
+}
+
+func (cl *changeLog) get(seq uint64) (ChangeRecord, error) {
+	buff, err := cl.persister.Get(seqToKey(seq))
+	if err != nil {
+		return ChangeRecord{}, err
+	}
+
+	return decodeChangeRecord(seq, buff)
+}
+
+// ack records the sequence acknowledged by the consumer and removes the records below the minimum acknowledged
+// sequence of all the consumers
+func (cl *changeLog) ack(consumerID string, seq uint64) error {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	if seq > cl.nextSeq {
+		return fmt.Errorf("%w: sequence %d is past the next sequence %d", common.ErrInvalidChangeSequence, seq, cl.nextSeq)
+	}
+	if seq < cl.acks[consumerID] {
+		return fmt.Errorf("%w: sequence %d is below the acknowledged sequence %d",
+			common.ErrInvalidChangeSequence, seq, cl.acks[consumerID])
+	}
+
+	acks := make(map[string]uint64, len(cl.acks)+1)
+	minAck := seq
+	for id, acked := range cl.acks {
+		acks[id] = acked
+		if id != consumerID && acked < minAck {
+			minAck = acked
+		}
+	}
+	acks[consumerID] = seq
+
+	ackValue := make([]byte, changeLogSeqLength)
+	binary.BigEndian.PutUint64(ackValue, seq)
+	ops := []types.Operation{{
+		Type:  types.PutOperation,
+		Key:   ackKey(consumerID),
+		Value: ackValue,
+	}}
+
+	firstSeq := cl.firstSeq
+	if minAck > firstSeq {
+		for s := firstSeq; s < minAck; s++ {
+			ops = append(ops, types.Operation{
+				Type: types.RemoveOperation,
+				Key:  seqToKey(s),
+			})
+		}
+		firstSeq = minAck
+	}
+	ops = append(ops, types.Operation{
+		Type:  types.PutOperation,
+		Key:   changeLogHeadKey,
+		Value: encodeChangeLogHead(firstSeq, cl.nextSeq),
+	})
+
+	err := cl.write(ops)
+	if err != nil {
+		return err
+	}
+
+	cl.acks = acks
+	cl.firstSeq = firstSeq
+
+	return nil
+}
+
+func (cl *changeLog) acked(consumerID string) uint64 {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	return cl.acks[consumerID]
+}
+
+func (cl *changeLog) close() error {
+	if cl == nil {
+		return nil
+	}
+
+	return cl.persister.Close()
+}
+
+func (cl *changeLog) destroy(closed bool) error {
+	if cl == nil {
+		return nil
+	}
+	if closed {
+		return cl.persister.DestroyClosed()
+	}
+
+	return cl.persister.Destroy()
+}
+
+func seqToKey(seq uint64) []byte {
+	key := make([]byte, changeLogSeqLength)
+	binary.BigEndian.PutUint64(key, seq)
+
+	return key
+}
+
+func ackKey(consumerID string) []byte {
+	key := make([]byte, 0, len(changeLogAckPrefix)+len(consumerID))
+	key = append(key, changeLogAckPrefix...)
+
+	return append(key, consumerID...)
+}
+
+func encodeChangeLogHead(firstSeq uint64, nextSeq uint64) []byte {
+	head := make([]byte, 2*changeLogSeqLength)
+	binary.BigEndian.PutUint64(head, firstSeq)
+	binary.BigEndian.PutUint64(head[changeLogSeqLength:], nextSeq)
+
+	return head
+}
+
+// encodeChangeRecord encodes the operation as its type, the varint encoded key length, the key and the value
+func encodeChangeRecord(op types.Operation) []byte {
+	buff := make([]byte, 1+binary.MaxVarintLen64, 1+binary.MaxVarintLen64+len(op.Key)+len(op.Value))
+	buff[0] = byte(op.Type)
+	n := binary.PutUvarint(buff[1:], uint64(len(op.Key)))
+	buff = buff[:1+n]
+	buff = append(buff, op.Key...)
+
+	return append(buff, op.Value...)
+}
+
+func decodeChangeRecord(seq uint64, buff []byte) (ChangeRecord, error) {
+	if len(buff) == 0 {
+		return ChangeRecord{}, fmt.Errorf("%w: empty record %d", common.ErrInvalidChangeRecord, seq)
+	}
+
+	opType := types.OperationType(buff[0])
+	if opType != types.PutOperation && opType != types.RemoveOperation {
+		return ChangeRecord{}, fmt.Errorf("%w: unknown operation type %d in record %d", common.ErrInvalidChangeRecord, opType, seq)
+	}
+
+	keyLen, n := binary.Uvarint(buff[1:])
+	if n <= 0 || keyLen > uint64(len(buff)-1-n) {
+		return ChangeRecord{}, fmt.Errorf("%w: invalid key length in record %d", common.ErrInvalidChangeRecord, seq)
+	}
+
+	keyStart := 1 + n
+	keyEnd := keyStart + int(keyLen)
+
+	return ChangeRecord{
+		Seq:   seq,
+		Type:  opType,
+		Key:   buff[keyStart:keyEnd],
+		Value: buff[keyEnd:],
+	}, nil
+}
+
+// ConsumeChanges calls the handler, in order, with the change records starting from the provided sequence up to the
+// last record appended when the call started. The iteration stops at the first handler error, which is returned.
+// The handler can call the unit, as the records are read without holding the unit lock. A consumer should
+// acknowledge the processed records with AckChanges and resume from AckedChangeSequence after a restart.
+// It returns ErrChangeLogNotEnabled if the unit was not created with a change log and ErrChangesTruncated if the
+// records starting from the provided sequence were already removed
+func (u *Unit) ConsumeChanges(fromSeq uint64, handler func(ChangeRecord) error) error {
+	if u.changeLog == nil {
+		return common.ErrChangeLogNotEnabled
+	}
+	if handler == nil {
+		return common.ErrNilChangeHandler
+	}
+
+	firstSeq, nextSeq := u.changeLog.bounds()
+	if fromSeq < firstSeq {
+		return fmt.Errorf("%w: requested sequence %d, first retained sequence %d", common.ErrChangesTruncated, fromSeq, firstSeq)
+	}
+
+	for seq := fromSeq; seq < nextSeq; seq++ {
+		record, err := u.readChangeRecord(seq)
+		if err != nil {
+			return err
+		}
+
+		err = handler(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *Unit) readChangeRecord(seq uint64) (ChangeRecord, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return ChangeRecord{}, common.ErrUnitClosed
+	}
+
+	record, err := u.changeLog.get(seq)
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return ChangeRecord{}, fmt.Errorf("%w: record %d was removed", common.ErrChangesTruncated, seq)
+	}
+
+	return record, err
+}
+
+// AckChanges records that the consumer processed all the change records below the provided sequence. The records
+// below the minimum sequence acknowledged by all the known consumers are removed from the change log, so a consumer
+// should acknowledge its starting sequence before the others truncate the records it needs. A consumer can not move
+// its acknowledged sequence backwards, nor past the next sequence to be appended.
+// It returns ErrChangeLogNotEnabled if the unit was not created with a change log
+func (u *Unit) AckChanges(consumerID string, seq uint64) error {
+	if u.changeLog == nil {
+		return common.ErrChangeLogNotEnabled
+	}
+
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	return u.changeLog.ack(consumerID, seq)
+}
+
+// AckedChangeSequence returns the sequence acknowledged by the consumer, from which it should resume consuming,
+// 0 for an unknown consumer. It returns ErrChangeLogNotEnabled if the unit was not created with a change log
+func (u *Unit) AckedChangeSequence(consumerID string) (uint64, error) {
+	if u.changeLog == nil {
+		return 0, common.ErrChangeLogNotEnabled
+	}
+
+	return u.changeLog.acked(consumerID), nil
+}
@@ -0,0 +1,118 @@
+package storageUnit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DharitriOne/drt-chain-storage-go/boundedpersister"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+)
+
+// Validate checks the cache and database configs, including their cross-field constraints, without creating any
+// component. All the violations are returned in a single error, each one prefixed by the name of the offending
+// field and wrapping the error the component creation would return, so errors.Is can still be used on the result.
+// The types registered with RegisterCacheType and RegisterDBType are validated by their own factories
+func (config UnitConfig) Validate() error {
+	errs := make([]error, 0)
+	addError := func(field string, err error) {
+		errs = append(errs, fmt.Errorf("%s: %w", field, err))
+	}
+
+	if config.DBConf.MaxBatchSize > int(config.CacheConf.Capacity) {
+		addError("DBConf.MaxBatchSize", fmt.Errorf("%w: max batch size %d, cache capacity %d",
+			common.ErrCacheSizeIsLowerThanBatchSize, config.DBConf.MaxBatchSize, config.CacheConf.Capacity))
+	}
+
+	validateCacheConfig(config.CacheConf, addError)
+	validateDBConfig(config.DBConf, addError)
+
+	return errors.Join(errs...)
+}
+
+func validateCacheConfig(config CacheConfig, addError func(field string, err error)) {
+	_, isRegistered := getCacheFactory(config.Type)
+	if isRegistered {
+		return
+	}
+
+	switch config.Type {
+	case LRUCache:
+		if config.SizeInBytes != 0 {
+			addError("CacheConf.SizeInBytes", common.ErrLRUCacheWithProvidedSize)
+		}
+	case SizeLRUCache:
+		validateSizeLRU(config, addError)
+	case ShardedSizeLRUCache:
+		validateShards(config, addError)
+		validateSizeLRU(config, addError)
+	case FIFOShardedCache:
+		validateShards(config, addError)
+	default:
+		addError("CacheConf.Type", fmt.Errorf("%w: %q", common.ErrNotSupportedCacheType, config.Type))
+		return
+	}
+
+	if config.Capacity < 1 {
+		addError("CacheConf.Capacity", common.ErrCacheSizeInvalid)
+	}
+
+	err := checkEvictionPolicy(config.Type, config.EvictionPolicy)
+	if err != nil {
+		addError("CacheConf.EvictionPolicy", err)
+	}
+}
+
+func validateSizeLRU(config CacheConfig, addError func(field string, err error)) {
+	if config.SizeInBytes < minimumSizeForLRUCache {
+		addError("CacheConf.SizeInBytes", fmt.Errorf("%w, provided %d, minimum %d",
+			common.ErrLRUCacheInvalidSize, config.SizeInBytes, minimumSizeForLRUCache))
+	}
+	if config.PerEntryOverheadBytes < 0 {
+		addError("CacheConf.PerEntryOverheadBytes", common.ErrInvalidPerEntryOverhead)
+	}
+}
+
+func validateShards(config CacheConfig, addError func(field string, err error)) {
+	if config.Shards < 1 {
+		addError("CacheConf.Shards", common.ErrCacheShardsInvalid)
+	}
+}
+
+func validateDBConfig(config DBConfig, addError func(field string, err error)) {
+	// these settings are applied by the unit to every database type, the registered ones included
+	if config.MaxConcurrentOps < 0 {
+		addError("DBConf.MaxConcurrentOps", boundedpersister.ErrInvalidMaxConcurrentOps)
+	}
+	if config.SyncEveryInterval < 0 {
+		addError("DBConf.SyncEveryInterval", common.ErrInvalidSyncInterval)
+	}
+	if config.OpenTimeout < 0 {
+		addError("DBConf.OpenTimeout", common.ErrInvalidOpenTimeout)
+	}
+
+	_, isRegistered := getDBFactory(config.Type)
+	if isRegistered {
+		return
+	}
+
+	switch config.Type {
+	case LvlDB, LvlDBSerial:
+		if len(config.FilePath) == 0 {
+			addError("DBConf.FilePath", common.ErrEmptyDBPath)
+		}
+		if config.AdaptiveBatching &&
+			(config.MinBatchDelayMilliseconds <= 0 || config.MaxBatchDelayMilliseconds < config.MinBatchDelayMilliseconds) {
+			addError("DBConf.MinBatchDelayMilliseconds", fmt.Errorf("%w: min %d ms, max %d ms",
+				common.ErrInvalidBatchDelayBounds, config.MinBatchDelayMilliseconds, config.MaxBatchDelayMilliseconds))
+		}
+
+		_, err := leveldb.ParseCompactionSchedule(config.CompactionSchedule)
+		if err != nil {
+			addError("DBConf.CompactionSchedule", err)
+		}
+	case MemoryDB:
+	default:
+		addError("DBConf.Type", fmt.Errorf("%w: %q", common.ErrNotSupportedDBType, config.Type))
+	}
+}
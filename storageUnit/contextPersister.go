@@ -0,0 +1,82 @@
+package storageUnit
+
+import (
+	"context"
+
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// contextPersister binds a context to the single key operations of a persister. If the persister implements
+// types.ContextPersister the context is forwarded to it, otherwise the context is only checked before calling the
+// persister, so an already expired or cancelled context is honored but a running operation is not interrupted
+type contextPersister struct {
+	types.Persister
+	ctx context.Context
+}
+
+func newContextPersister(ctx context.Context, persister types.Persister) *contextPersister {
+	return &contextPersister{
+		Persister: persister,
+		ctx:       ctx,
+	}
+}
+
+// Put adds the value to the persister, honoring the bound context
+func (cp *contextPersister) Put(key, val []byte) error {
+	ctxPersister, ok := cp.Persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.PutCtx(cp.ctx, key, val)
+	}
+
+	err := cp.ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return cp.Persister.Put(key, val)
+}
+
+// Get returns the value from the persister, honoring the bound context
+func (cp *contextPersister) Get(key []byte) ([]byte, error) {
+	ctxPersister, ok := cp.Persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.GetCtx(cp.ctx, key)
+	}
+
+	err := cp.ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.Persister.Get(key)
+}
+
+// Has checks the key in the persister, honoring the bound context
+func (cp *contextPersister) Has(key []byte) error {
+	ctxPersister, ok := cp.Persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.HasCtx(cp.ctx, key)
+	}
+
+	err := cp.ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return cp.Persister.Has(key)
+}
+
+// Remove removes the key from the persister, honoring the bound context
+func (cp *contextPersister) Remove(key []byte) error {
+	ctxPersister, ok := cp.Persister.(types.ContextPersister)
+	if ok {
+		return ctxPersister.RemoveCtx(cp.ctx, key)
+	}
+
+	err := cp.ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return cp.Persister.Remove(key)
+}
@@ -0,0 +1,100 @@
+package storageUnit
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-core-go/hashing"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+// DupStats holds the duplicate persisted values found by DuplicateReport. The values are sampled by their hash,
+// so all the copies of a value are either sampled or not, and the estimations extrapolate the sampled counts to
+// the whole store
+type DupStats struct {
+	NumScanned uint64
+	NumSampled uint64
+	// NumDuplicateGroups is the number of sampled distinct values stored under more than one key
+	NumDuplicateGroups uint64
+	// NumDuplicateValues is the number of sampled values which are copies of another one, the first value of
+	// each group not being counted
+	NumDuplicateValues uint64
+	// ReclaimableBytes is the size of the sampled duplicate values, which a deduplication would not store
+	ReclaimableBytes          uint64
+	EstimatedDuplicateGroups  uint64
+	EstimatedReclaimableBytes uint64
+}
+
+type duplicateGroup struct {
+	numValues uint64
+	size      uint64
+}
+
+// DuplicateReport scans the persisted values, hashes them with the provided hasher and reports how many values are
+// stored under several keys and how many bytes storing them once would reclaim. Only the values whose hash falls
+// in the provided sample rate, in the (0, 1] interval, are tracked, bounding the memory used over large stores;
+// all the values are still read and hashed. The unit writes are blocked during the scan
+func (u *Unit) DuplicateReport(hasher hashing.Hasher, sampleRate float64) (DupStats, error) {
+	if check.IfNil(hasher) {
+		return DupStats{}, common.ErrNilHasher
+	}
+	if !(sampleRate > 0 && sampleRate <= 1) {
+		return DupStats{}, common.ErrInvalidSampleRate
+	}
+
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return DupStats{}, common.ErrUnitClosed
+	}
+
+	sampleThreshold := uint64(math.MaxUint64)
+	if sampleRate < 1 {
+		sampleThreshold = uint64(sampleRate * math.MaxUint64)
+	}
+
+	stats := DupStats{}
+	groups := make(map[string]*duplicateGroup)
+	u.persister.RangeKeys(func(_ []byte, value []byte) bool {
+		stats.NumScanned++
+
+		valueHash := hasher.Compute(string(value))
+		if sampleHash(valueHash) > sampleThreshold {
+			return true
+		}
+		stats.NumSampled++
+
+		group, found := groups[string(valueHash)]
+		if !found {
+			groups[string(valueHash)] = &duplicateGroup{
+				numValues: 1,
+				size:      uint64(len(value)),
+			}
+			return true
+		}
+
+		group.numValues++
+		if group.numValues == 2 {
+			stats.NumDuplicateGroups++
+		}
+		stats.NumDuplicateValues++
+		stats.ReclaimableBytes += group.size
+
+		return true
+	})
+
+	stats.EstimatedDuplicateGroups = uint64(float64(stats.NumDuplicateGroups) / sampleRate)
+	stats.EstimatedReclaimableBytes = uint64(float64(stats.ReclaimableBytes) / sampleRate)
+
+	return stats, nil
+}
+
+// sampleHash spreads the value hash over the uint64 range, whatever the hasher output size
+func sampleHash(valueHash []byte) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write(valueHash)
+
+	return hasher.Sum64()
+}
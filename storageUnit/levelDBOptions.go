@@ -0,0 +1,72 @@
+package storageUnit
+
+import (
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// LevelDBPersisterFactoryHandler defines a persister factory able to create its leveldb persisters with additional
+// leveldb options. NewStorageUnitFromConf uses it to apply the leveldb settings of the database config, which the
+// persister factories lacking it ignore
+type LevelDBPersisterFactoryHandler interface {
+	CreateWithOptions(path string, options ...leveldb.Option) (types.Persister, error)
+}
+
+// createLevelDBOptions returns the leveldb options matching the leveldb settings of the database config
+func createLevelDBOptions(config DBConfig) ([]leveldb.Option, error) {
+	options := make([]leveldb.Option, 0)
+	if config.AdaptiveBatching {
+		options = append(options, leveldb.WithAdaptiveBatching(
+			time.Duration(config.MinBatchDelayMilliseconds)*time.Millisecond,
+			time.Duration(config.MaxBatchDelayMilliseconds)*time.Millisecond,
+		))
+	}
+	if len(config.CompactionSchedule) > 0 {
+		windows, err := leveldb.ParseCompactionSchedule(config.CompactionSchedule)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, leveldb.WithCompactionSchedule(windows))
+	}
+	if config.SyncEveryInterval > 0 {
+		options = append(options, leveldb.WithSyncEveryInterval(config.SyncEveryInterval))
+	}
+
+	return options, nil
+}
+
+// newDBFromConf creates the database of the config with the persister factory, within the open timeout of the
+// config, passing it the leveldb options of the config if the factory is able to apply them
+func newDBFromConf(persisterFactory PersisterFactoryHandler, config DBConfig) (types.Persister, error) {
+	if check.IfNil(persisterFactory) {
+		return nil, ErrNilPersisterFactory
+	}
+
+	options, err := createLevelDBOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) == 0 {
+		return NewDBWithTimeout(persisterFactory, config.FilePath, config.OpenTimeout)
+	}
+
+	levelDBFactory, ok := persisterFactory.(LevelDBPersisterFactoryHandler)
+	if !ok {
+		log.Warn("the persister factory can not apply the leveldb settings of the config, they are ignored",
+			"path", config.FilePath)
+		return NewDBWithTimeout(persisterFactory, config.FilePath, config.OpenTimeout)
+	}
+
+	if config.OpenTimeout > 0 {
+		// also bounds a hung opening, which the retries can not interrupt
+		options = append(options, leveldb.WithOpenTimeout(config.OpenTimeout))
+	}
+
+	return createDBWithRetries(func() (types.Persister, error) {
+		return levelDBFactory.CreateWithOptions(config.FilePath, options...)
+	}, config.OpenTimeout)
+}
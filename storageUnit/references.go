@@ -0,0 +1,51 @@
+package storageUnit
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+// ErrNilStorageUnit signals that a nil storage unit has been provided
+var ErrNilStorageUnit = errors.New("nil storage unit")
+
+// ErrNilReferenceExtractor signals that a nil reference extractor function has been provided
+var ErrNilReferenceExtractor = errors.New("nil reference extractor")
+
+// VerifyReferences scans all the persisted entries whose keys start with the index prefix and checks that the data key
+// built from the data prefix and the reference extracted from each index value exists in the unit.
+// It returns the first dangling data key or nil if all the references are consistent. It is meant as a
+// diagnostic tool, as the whole unit is iterated
+func VerifyReferences(u *Unit, indexPrefix, dataPrefix []byte, extractRef func(indexValue []byte) []byte) ([]byte, error) {
+	if check.IfNil(u) {
+		return nil, ErrNilStorageUnit
+	}
+	if extractRef == nil {
+		return nil, ErrNilReferenceExtractor
+	}
+
+	var danglingKey []byte
+	var err error
+	u.RangeKeys(func(key []byte, value []byte) bool {
+		if !bytes.HasPrefix(key, indexPrefix) {
+			return true
+		}
+
+		dataKey := append(append(make([]byte, 0, len(dataPrefix)), dataPrefix...), extractRef(value)...)
+		errHas := u.Has(dataKey)
+		if errHas == nil {
+			return true
+		}
+		if errors.Is(errHas, common.ErrKeyNotFound) {
+			danglingKey = dataKey
+			return false
+		}
+
+		err = errHas
+		return false
+	})
+
+	return danglingKey, err
+}
@@ -0,0 +1,100 @@
+package storageUnit
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// ErrNilDBFactory signals that a nil custom database factory has been provided
+var ErrNilDBFactory = errors.New("nil db factory")
+
+// ErrNilCacheFactory signals that a nil custom cache factory has been provided
+var ErrNilCacheFactory = errors.New("nil cache factory")
+
+// ErrEmptyTypeName signals that a custom type was registered with an empty name
+var ErrEmptyTypeName = errors.New("empty type name")
+
+// DBFactory creates a persister from the database arguments
+type DBFactory func(args ArgDB) (types.Persister, error)
+
+// CacheFactory creates a cacher from the cache config
+type CacheFactory func(config CacheConfig) (types.Cacher, error)
+
+var (
+	mutRegistry    sync.RWMutex
+	dbFactories    = make(map[DBType]DBFactory)
+	cacheFactories = make(map[CacheType]CacheFactory)
+)
+
+// RegisterDBType registers the factory creating the persisters of a custom database type. NewStorageUnitFromConf
+// uses it, instead of the provided persister factory, for the configs of that type. Registering a name again
+// replaces the previous factory
+func RegisterDBType(name DBType, factory func(ArgDB) (types.Persister, error)) error {
+	if len(name) == 0 {
+		return ErrEmptyTypeName
+	}
+	if factory == nil {
+		return ErrNilDBFactory
+	}
+
+	mutRegistry.Lock()
+	dbFactories[name] = factory
+	mutRegistry.Unlock()
+
+	return nil
+}
+
+// RegisterCacheType registers the factory creating the cachers of a custom cache type. NewCache consults it before
+// the built-in types, so a built-in type can be overridden as well. The custom factory is responsible for validating
+// the whole config, including the eviction policy. Registering a name again replaces the previous factory
+func RegisterCacheType(name CacheType, factory func(CacheConfig) (types.Cacher, error)) error {
+	if len(name) == 0 {
+		return ErrEmptyTypeName
+	}
+	if factory == nil {
+		return ErrNilCacheFactory
+	}
+
+	mutRegistry.Lock()
+	cacheFactories[name] = factory
+	mutRegistry.Unlock()
+
+	return nil
+}
+
+func getDBFactory(name DBType) (DBFactory, bool) {
+	mutRegistry.RLock()
+	defer mutRegistry.RUnlock()
+
+	factory, ok := dbFactories[name]
+
+	return factory, ok
+}
+
+func getCacheFactory(name CacheType) (CacheFactory, bool) {
+	mutRegistry.RLock()
+	defer mutRegistry.RUnlock()
+
+	factory, ok := cacheFactories[name]
+
+	return factory, ok
+}
+
+func newArgDB(config DBConfig) ArgDB {
+	return ArgDB{
+		DBType:                    config.Type,
+		Path:                      config.FilePath,
+		BatchDelaySeconds:         config.BatchDelaySeconds,
+		MaxBatchSize:              config.MaxBatchSize,
+		MaxBatchSizeInBytes:       config.MaxBatchSizeInBytes,
+		MaxOpenFiles:              config.MaxOpenFiles,
+		AdaptiveBatching:          config.AdaptiveBatching,
+		MinBatchDelayMilliseconds: config.MinBatchDelayMilliseconds,
+		MaxBatchDelayMilliseconds: config.MaxBatchDelayMilliseconds,
+		CompactionSchedule:        config.CompactionSchedule,
+		SyncEveryInterval:         config.SyncEveryInterval,
+		OpenTimeout:               config.OpenTimeout,
+	}
+}
@@ -1,11 +1,17 @@
 package storageUnit
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/DharitriOne/drt-chain-core-go/core/check"
@@ -14,11 +20,17 @@ import (
 	"github.com/DharitriOne/drt-chain-core-go/hashing/blake2b"
 	"github.com/DharitriOne/drt-chain-core-go/hashing/fnv"
 	"github.com/DharitriOne/drt-chain-core-go/hashing/keccak"
+	"github.com/DharitriOne/drt-chain-core-go/marshal"
 	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/boundedpersister"
 	"github.com/DharitriOne/drt-chain-storage-go/common"
 	"github.com/DharitriOne/drt-chain-storage-go/fifocache"
 	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	capacityCache "github.com/DharitriOne/drt-chain-storage-go/lrucache/capacity"
+	"github.com/DharitriOne/drt-chain-storage-go/migration"
 	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
+	"github.com/DharitriOne/drt-chain-storage-go/randomcache"
+	"github.com/DharitriOne/drt-chain-storage-go/sharded"
 	"github.com/DharitriOne/drt-chain-storage-go/types"
 )
 
@@ -33,15 +45,42 @@ type DBType string
 // HasherType represents the type of the supported hash functions
 type HasherType string
 
+// EvictionPolicy represents the policy used by a cache to choose the evicted elements
+type EvictionPolicy string
+
 // Cache types that are currently supported
 const (
-	LRUCache         CacheType = "LRU"
-	SizeLRUCache     CacheType = "SizeLRU"
-	FIFOShardedCache CacheType = "FIFOSharded"
+	LRUCache            CacheType = "LRU"
+	SizeLRUCache        CacheType = "SizeLRU"
+	ShardedSizeLRUCache CacheType = "ShardedSizeLRU"
+	FIFOShardedCache    CacheType = "FIFOSharded"
+)
+
+// Eviction policies that can be configured for a cache. An empty policy selects the default policy of the cache type
+const (
+	LRUPolicy    EvictionPolicy = "LRU"
+	LFUPolicy    EvictionPolicy = "LFU"
+	FIFOPolicy   EvictionPolicy = "FIFO"
+	RandomPolicy EvictionPolicy = "Random"
+	TTLPolicy    EvictionPolicy = "TTL"
 )
 
+// compatibleEvictionPolicies holds, for each cache type, the eviction policies it can be configured with
+var compatibleEvictionPolicies = map[CacheType][]EvictionPolicy{
+	LRUCache:            {LRUPolicy, RandomPolicy},
+	SizeLRUCache:        {LRUPolicy},
+	ShardedSizeLRUCache: {LRUPolicy},
+	FIFOShardedCache:    {FIFOPolicy},
+}
+
 var log = logger.GetOrCreate("storage/storageUnit")
 
+// versionLength is the length of the version counters written by CommitIfVersion
+const versionLength = 8
+
+// prefixExportBatchSize is the maximum number of pairs written or removed at once by ExportPrefix and MovePrefix
+const prefixExportBatchSize = 1000
+
 // DB types that are currently supported
 const (
 	LvlDB       DBType = "LvlDB"
@@ -54,7 +93,8 @@ type ShardIDProviderType string
 
 // Shard id provider types that are currently supported
 const (
-	BinarySplit ShardIDProviderType = "BinarySplit"
+	BinarySplit    ShardIDProviderType = "BinarySplit"
+	ConsistentHash ShardIDProviderType = "ConsistentHash"
 )
 
 const (
@@ -81,6 +121,11 @@ var ErrNilPersisterFactory = errors.New("nil persister factory")
 type UnitConfig struct {
 	CacheConf CacheConfig
 	DBConf    DBConfig
+	// Logger is optional, the package logger is used if not provided
+	Logger logger.Logger
+	// DisableReadCaching stops the reads from adding the values read from the persister in the cache, for the
+	// scan like access patterns not benefiting from it
+	DisableReadCaching bool
 }
 
 // CacheConfig holds the configurable elements of a cache
@@ -92,6 +137,13 @@ type CacheConfig struct {
 	Capacity             uint32
 	SizePerSender        uint32
 	Shards               uint32
+	EvictionPolicy       EvictionPolicy
+	// PerEntryOverheadBytes makes the size LRU caches account the key length plus this value for each entry
+	// in their byte budget. 0 disables the overhead accounting
+	PerEntryOverheadBytes int
+	// MaxEntrySizeInBytes makes the size LRU caches skip the values larger than this size, which are then always
+	// read from the persister. 0 disables the limit
+	MaxEntrySizeInBytes int64
 }
 
 // String returns a readable representation of the object
@@ -101,99 +153,968 @@ func (config *CacheConfig) String() string {
 		log.Error("CacheConfig.String()", "err", err)
 	}
 
-	return string(bytes)
+	return string(bytes)
+}
+
+// DBConfig holds the configurable elements of a database
+type DBConfig struct {
+	FilePath            string
+	Type                DBType
+	BatchDelaySeconds   int
+	MaxBatchSize        int
+	MaxBatchSizeInBytes int
+	MaxOpenFiles        int
+	// AdaptiveBatching replaces the fixed BatchDelaySeconds with a delay adapted to the recent write rate,
+	// bounded by MinBatchDelayMilliseconds and MaxBatchDelayMilliseconds. Like the other leveldb settings, it is
+	// applied by the persister factories implementing LevelDBPersisterFactoryHandler
+	AdaptiveBatching          bool
+	MinBatchDelayMilliseconds int
+	MaxBatchDelayMilliseconds int
+	// MaxConcurrentOps bounds the simultaneous persister operations, 0 meaning unbounded
+	MaxConcurrentOps int
+	// FailFastOnMaxConcurrentOps makes the operations exceeding MaxConcurrentOps return
+	// common.ErrTooManyConcurrentOps instead of waiting for a free slot
+	FailFastOnMaxConcurrentOps bool
+	// CompactionSchedule lists the daily windows, written as "HH:MM-HH:MM" in local time, in which the leveldb
+	// persisters run a full compaction, the automatic compactions being deferred outside them. Empty means no schedule
+	CompactionSchedule []string
+	// SyncEveryInterval leaves the leveldb writes unsynced, the journal being synced in the background at this
+	// interval and on close, so a machine crash loses at most the writes of the last interval. 0 syncs each write
+	SyncEveryInterval time.Duration
+	// OpenTimeout bounds the whole opening of the persister, retries included, 0 meaning unbounded
+	OpenTimeout time.Duration
+}
+
+// Unit represents a storer's data bank
+// holding the cache and persistence unit
+// After Close, the operations reaching the persister return ErrUnitClosed, RangeKeys does nothing and DestroyUnit
+// destroys the closed persister
+type Unit struct {
+	lock             sync.RWMutex
+	persister        types.Persister
+	cacher           types.Cacher
+	marshalizer      marshal.Marshalizer
+	log              logger.Logger
+	sizeSampler      *valueSizeSampler
+	cacheVerifier    *cacheVerifier
+	changeLog        *changeLog
+	closed           uint32
+	cacherClosed     uint32
+	closeGracePeriod time.Duration
+	// optionsErr is the error of the options failing the unit creation
+	optionsErr error
+	// disableReadCaching is set at construction only, so it is read without holding the lock
+	disableReadCaching bool
+}
+
+// UnitOption defines an optional setting that can be applied on the storage unit at construction time
+type UnitOption func(u *Unit)
+
+// WithLogger sets the logger used by the storage unit instead of the package logger.
+// A nil logger is ignored
+func WithLogger(unitLogger logger.Logger) UnitOption {
+	return func(u *Unit) {
+		if check.IfNil(unitLogger) {
+			return
+		}
+
+		u.log = unitLogger
+	}
+}
+
+// WithMarshalizer sets the codec used by the PutObject and GetObject methods
+func WithMarshalizer(marshalizer marshal.Marshalizer) UnitOption {
+	return func(u *Unit) {
+		u.marshalizer = marshalizer
+	}
+}
+
+// WithValueSizeSampling makes the storage unit record a histogram of the put value sizes, read with
+// ValueSizeHistogram. The bucket bounds split the sizes in the [0, b1), [b1, b2), ..., [bn, math.MaxUint64]
+// buckets, zero and duplicated bounds being ignored. Only one in every samplingInterval puts is recorded, so the
+// counts should be multiplied by the interval to estimate the totals. A zero interval leaves the sampling disabled
+func WithValueSizeSampling(bucketBounds []uint64, samplingInterval uint64) UnitOption {
+	return func(u *Unit) {
+		if samplingInterval == 0 {
+			log.Warn("value size sampling not enabled", "error", common.ErrInvalidSamplingInterval)
+			return
+		}
+
+		u.sizeSampler = newValueSizeSampler(bucketBounds, samplingInterval)
+	}
+}
+
+// WithCloseGracePeriod makes Close wait up to the provided duration for the operations already in flight to
+// complete before closing the persister. The operations started after Close return ErrUnitClosed regardless of
+// the grace period. A zero grace period closes the persister without waiting
+func WithCloseGracePeriod(gracePeriod time.Duration) UnitOption {
+	return func(u *Unit) {
+		u.closeGracePeriod = gracePeriod
+	}
+}
+
+// WithReadCachingDisabled stops all the reads of the unit from adding the values read from the persister in the
+// cache, the writes still updating it. Get then behaves like GetNoCacheFill
+func WithReadCachingDisabled() UnitOption {
+	return func(u *Unit) {
+		u.disableReadCaching = true
+	}
+}
+
+// Put adds data to both cache and persistence medium
+func (u *Unit) Put(key, data []byte) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	return u.putUnprotected(u.persister, key, data)
+}
+
+// PutIf writes the new value only if the provided condition, called with the current value and whether the key
+// exists, returns true. The current value is read and the new one written under the unit write lock, so no other
+// write done through this unit can happen in between. It returns whether the new value was written
+func (u *Unit) PutIf(key, newValue []byte, cond func(oldValue []byte, exists bool) bool) (bool, error) {
+	if cond == nil {
+		return false, common.ErrNilCondition
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return false, common.ErrUnitClosed
+	}
+
+	oldValue, err := u.getUnprotected(u.persister, key)
+	exists := err == nil
+	if err != nil && !errors.Is(err, common.ErrKeyNotFound) {
+		return false, err
+	}
+
+	if !cond(oldValue, exists) {
+		return false, nil
+	}
+
+	err = u.putUnprotected(u.persister, key, newValue)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// PutReportingChange writes the data only if it differs from the currently stored value, skipping the cache and
+// persister writes of an identical overwrite. It returns whether the stored value changed
+func (u *Unit) PutReportingChange(key, data []byte) (bool, error) {
+	return u.PutIf(key, data, func(oldValue []byte, exists bool) bool {
+		return !exists || !bytes.Equal(oldValue, data)
+	})
+}
+
+func (u *Unit) putUnprotected(persister types.Persister, key, data []byte) error {
+	u.cacher.Put(key, data, len(data))
+
+	err := persister.Put(key, data)
+	if err != nil {
+		u.cacher.Remove(key)
+		return err
+	}
+
+	u.sizeSampler.record(len(data))
+
+	return u.appendChanges([]types.Operation{{Type: types.PutOperation, Key: key, Value: data}})
+}
+
+// appendChanges appends the records of the operations already applied to the change log, if enabled. The error
+// wraps ErrChangeLogAppendFailed, as the operations can not be rolled back
+func (u *Unit) appendChanges(ops []types.Operation) error {
+	err := u.changeLog.append(ops)
+	if err != nil {
+		u.log.Error("cannot append to the change log", "error", err)
+		return fmt.Errorf("%w: %w", common.ErrChangeLogAppendFailed, err)
+	}
+
+	return nil
+}
+
+// ApplyBatch atomically applies the provided mixed Put and Remove operations in the persister.
+// The cache is updated only after the persister successfully applied the whole batch.
+// It returns ErrBatchNotSupported if the persister can not apply batches atomically
+func (u *Unit) ApplyBatch(ops []types.Operation) error {
+	batchApplier, ok := u.persister.(types.BatchApplier)
+	if !ok {
+		return common.ErrBatchNotSupported
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	return u.applyBatchUnprotected(batchApplier, ops)
+}
+
+// CommitIfVersion atomically applies the provided operations only if the version key still holds the expected
+// version, a missing version key matching an empty expected version. The version is an 8 bytes big endian
+// counter, bumped in the same persister batch as the operations, so concurrent writers using the same version key
+// get serializable multi-key updates. It returns whether the operations were applied and ErrBatchNotSupported if
+// the persister can not apply batches atomically
+func (u *Unit) CommitIfVersion(versionKey, expectedVersion []byte, ops []types.Operation) (bool, error) {
+	batchApplier, ok := u.persister.(types.BatchApplier)
+	if !ok {
+		return false, common.ErrBatchNotSupported
+	}
+	for _, op := range ops {
+		if bytes.Equal(op.Key, versionKey) {
+			return false, common.ErrVersionKeyInBatch
+		}
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return false, common.ErrUnitClosed
+	}
+
+	currentVersion, err := u.getUnprotected(u.persister, versionKey)
+	if err != nil && !errors.Is(err, common.ErrKeyNotFound) {
+		return false, err
+	}
+	if !bytes.Equal(currentVersion, expectedVersion) {
+		return false, nil
+	}
+
+	nextVersion, err := bumpVersion(currentVersion)
+	if err != nil {
+		return false, err
+	}
+
+	batch := make([]types.Operation, 0, len(ops)+1)
+	batch = append(batch, ops...)
+	batch = append(batch, types.Operation{
+		Type:  types.PutOperation,
+		Key:   versionKey,
+		Value: nextVersion,
+	})
+
+	err = u.applyBatchUnprotected(batchApplier, batch)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func bumpVersion(version []byte) ([]byte, error) {
+	if len(version) != 0 && len(version) != versionLength {
+		return nil, common.ErrInvalidVersion
+	}
+
+	counter := uint64(0)
+	if len(version) == versionLength {
+		counter = binary.BigEndian.Uint64(version)
+	}
+
+	nextVersion := make([]byte, versionLength)
+	binary.BigEndian.PutUint64(nextVersion, counter+1)
+
+	return nextVersion, nil
+}
+
+func (u *Unit) applyBatchUnprotected(batchApplier types.BatchApplier, ops []types.Operation) error {
+	err := batchApplier.ApplyBatch(ops)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		if op.Type == types.PutOperation {
+			u.cacher.Put(op.Key, op.Value, len(op.Value))
+			u.sizeSampler.record(len(op.Value))
+			continue
+		}
+
+		u.cacher.Remove(op.Key)
+	}
+
+	return u.appendChanges(ops)
+}
+
+// CountPrefix returns the number of persisted keys starting with the provided prefix.
+// It returns ErrPrefixCountNotSupported if the persister can not count keys by prefix
+func (u *Unit) CountPrefix(prefix []byte) (uint64, error) {
+	prefixCounter, ok := u.persister.(types.PrefixCounter)
+	if !ok {
+		return 0, common.ErrPrefixCountNotSupported
+	}
+
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return 0, common.ErrUnitClosed
+	}
+
+	return prefixCounter.CountPrefix(prefix)
+}
+
+// PrefixDiskSize returns the approximate size on disk of the persisted keys starting with the provided prefix,
+// storage overhead and compression included, so it can differ from the sum of the value lengths.
+// It returns ErrPrefixDiskSizeNotSupported if the persister can not estimate it
+func (u *Unit) PrefixDiskSize(prefix []byte) (uint64, error) {
+	prefixDiskSizer, ok := u.persister.(types.PrefixDiskSizer)
+	if !ok {
+		return 0, common.ErrPrefixDiskSizeNotSupported
+	}
+
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return 0, common.ErrUnitClosed
+	}
+
+	return prefixDiskSizer.PrefixDiskSize(prefix)
+}
+
+// EntryStats returns the number of live persisted keys and the estimated number of obsolete entries a compaction
+// would drop, so a high deleted to live ratio signals that compacting the persister pays off.
+// It returns ErrEntryStatsNotSupported if the persister can not report them
+func (u *Unit) EntryStats() (uint64, uint64, error) {
+	entryStatsProvider, ok := u.persister.(types.EntryStatsProvider)
+	if !ok {
+		return 0, 0, common.ErrEntryStatsNotSupported
+	}
+
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return 0, 0, common.ErrUnitClosed
+	}
+
+	return entryStatsProvider.EntryStats()
+}
+
+// WarmPrefix loads the persisted (key, value) pairs whose key starts with the provided prefix in the cache, in a
+// single iteration over the prefixed range. At most limit pairs are loaded, a non-positive limit meaning that only
+// the cache capacity bounds the loaded pairs. The keys already cached are skipped, so their values are not
+// replaced. It returns the number of loaded pairs.
+// It returns ErrPrefixRangeNotSupported if the persister can not iterate over a prefixed range
+func (u *Unit) WarmPrefix(prefix []byte, limit int) (int, error) {
+	prefixRanger, ok := u.persister.(types.PrefixRanger)
+	if !ok {
+		return 0, common.ErrPrefixRangeNotSupported
+	}
+
+	maxLoaded := u.cacher.MaxSize()
+	if limit > 0 && limit < maxLoaded {
+		maxLoaded = limit
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return 0, common.ErrUnitClosed
+	}
+
+	batchApplier, ok := u.persister.(types.BatchApplier)
+	if ok {
+		// applying an empty batch writes the pending operations of the batching persisters, otherwise the
+		// iteration could return values older than the ones pending
+		err := batchApplier.ApplyBatch(nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	loaded := 0
+	prefixRanger.RangePrefix(prefix, func(key []byte, value []byte) bool {
+		if loaded >= maxLoaded {
+			return false
+		}
+		if u.cacher.Has(key) {
+			return true
+		}
+
+		u.cacher.Put(key, value, len(value))
+		loaded++
+
+		return true
+	})
+
+	return loaded, nil
+}
+
+// ExportPrefix copies the persisted (key, value) pairs whose key starts with the provided prefix to the destination
+// persister, in batches, and returns the number of copied pairs, also when an error is returned. The unit writes
+// are blocked during the export, so the destination receives a consistent view of the prefixed keys
+func (u *Unit) ExportPrefix(prefix []byte, dst types.Persister) (uint64, error) {
+	if check.IfNil(dst) {
+		return 0, common.ErrNilPersister
+	}
+
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return 0, common.ErrUnitClosed
+	}
+
+	return u.exportPrefixUnprotected(prefix, dst)
+}
+
+// MovePrefix copies the persisted (key, value) pairs whose key starts with the provided prefix to the destination
+// persister, as ExportPrefix does, then removes them from the unit. The unit is locked during the whole move, so no
+// write can happen between the copy and the removal. The removal starts only after all the pairs were copied, so a
+// failure can leave pairs in both persisters, but never in none of them
+func (u *Unit) MovePrefix(prefix []byte, dst types.Persister) (uint64, error) {
+	if check.IfNil(dst) {
+		return 0, common.ErrNilPersister
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return 0, common.ErrUnitClosed
+	}
+
+	copied, err := u.exportPrefixUnprotected(prefix, dst)
+	if err != nil {
+		return copied, err
+	}
+
+	return copied, u.removePrefixUnprotected(prefix)
+}
+
+func (u *Unit) exportPrefixUnprotected(prefix []byte, dst types.Persister) (uint64, error) {
+	batchApplier, ok := u.persister.(types.BatchApplier)
+	if ok {
+		// applying an empty batch writes the pending operations of the batching persisters, otherwise the
+		// iteration could miss them
+		err := batchApplier.ApplyBatch(nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return migration.Migrate(u.persister, dst, migration.MigrateOptions{
+		BatchSize:   prefixExportBatchSize,
+		Parallelism: 1,
+		Prefix:      prefix,
+	})
+}
+
+func (u *Unit) removePrefixUnprotected(prefix []byte) error {
+	keys := make([][]byte, 0)
+	rangeHandler := func(key []byte, _ []byte) bool {
+		keys = append(keys, key)
+		return true
+	}
+
+	prefixRanger, ok := u.persister.(types.PrefixRanger)
+	if ok {
+		prefixRanger.RangePrefix(prefix, rangeHandler)
+	} else {
+		u.persister.RangeKeys(func(key []byte, value []byte) bool {
+			if !bytes.HasPrefix(key, prefix) {
+				return true
+			}
+
+			return rangeHandler(key, value)
+		})
+	}
+
+	for start := 0; start < len(keys); start += prefixExportBatchSize {
+		end := start + prefixExportBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		err := u.removeKeysUnprotected(keys[start:end])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *Unit) removeKeysUnprotected(keys [][]byte) error {
+	batchApplier, ok := u.persister.(types.BatchApplier)
+	if ok {
+		ops := make([]types.Operation, 0, len(keys))
+		for _, key := range keys {
+			ops = append(ops, types.Operation{
+				Type: types.RemoveOperation,
+				Key:  key,
+			})
+		}
+
+		// the wrappers forwarding the batches report the inner persisters not able to apply them
+		err := u.applyBatchUnprotected(batchApplier, ops)
+		if !errors.Is(err, common.ErrBatchNotSupported) {
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		err := u.removeUnprotected(u.persister, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Snapshot returns a consistent read-only view of the persister, as of the snapshot creation. The cache is not
+// involved in the snapshot reads. The returned snapshot should be released after use.
+// It returns ErrSnapshotNotSupported if the persister can not create snapshots
+func (u *Unit) Snapshot() (types.Snapshot, error) {
+	snapshotter, ok := u.persister.(types.Snapshotter)
+	if !ok {
+		return nil, common.ErrSnapshotNotSupported
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return nil, common.ErrUnitClosed
+	}
+
+	return snapshotter.Snapshot()
+}
+
+// Diff compares the current persister contents against the provided older snapshot. It returns the keys that are
+// missing from the old snapshot or whose value changed since, as added, and the keys that are no longer present, as
+// removed. Both lists are sorted. As the persisters do not expose sequence numbers, the key sets of the two states are
+// fully compared, so the cost is proportional to the number of persisted keys. The old snapshot is not released.
+// It returns ErrSnapshotNotSupported if the persister can not create snapshots
+func (u *Unit) Diff(old types.Snapshot) ([][]byte, [][]byte, error) {
+	if check.IfNil(old) {
+		return nil, nil, common.ErrNilSnapshot
+	}
+
+	current, err := u.Snapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer current.Release()
+
+	added := make([][]byte, 0)
+	var errIteration error
+	current.RangeKeys(func(key []byte, value []byte) bool {
+		oldValue, errGet := old.Get(key)
+		if errGet == nil {
+			if !bytes.Equal(oldValue, value) {
+				added = append(added, key)
+			}
+			return true
+		}
+		if !errors.Is(errGet, common.ErrKeyNotFound) {
+			errIteration = errGet
+			return false
+		}
+
+		added = append(added, key)
+		return true
+	})
+	if errIteration != nil {
+		return nil, nil, errIteration
+	}
+
+	removed := make([][]byte, 0)
+	old.RangeKeys(func(key []byte, _ []byte) bool {
+		errHas := current.Has(key)
+		if errHas == nil {
+			return true
+		}
+		if !errors.Is(errHas, common.ErrKeyNotFound) {
+			errIteration = errHas
+			return false
+		}
+
+		removed = append(removed, key)
+		return true
+	})
+	if errIteration != nil {
+		return nil, nil, errIteration
+	}
+
+	sortKeys(added)
+	sortKeys(removed)
+
+	return added, removed, nil
+}
+
+func sortKeys(keys [][]byte) {
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+}
+
+// ValueSizeHistogram returns the sampled value sizes histogram, or nil if the sampling was not enabled with
+// WithValueSizeSampling
+func (u *Unit) ValueSizeHistogram() map[SizeBucket]uint64 {
+	return u.sizeSampler.histogram()
+}
+
+// PutInEpoch will call the Put method as this storer doesn't handle epochs
+func (u *Unit) PutInEpoch(key, data []byte, _ uint32) error {
+	return u.Put(key, data)
+}
+
+// GetOldestEpoch will return an error that signals that the oldest epoch fetching is not available
+func (u *Unit) GetOldestEpoch() (uint32, error) {
+	return 0, common.ErrOldestEpochNotAvailable
+}
+
+// Close will close unit. The operations started afterwards return ErrUnitClosed, while the ones in flight are
+// waited for up to the configured close grace period. Closing an already closed unit does nothing. The persister,
+// the change log and the cacher are all closed even if some of them fail, the errors being joined
+func (u *Unit) Close() error {
+	if !atomic.CompareAndSwapUint32(&u.closed, 0, 1) {
+		return nil
+	}
+
+	u.waitInFlightOperations()
+	u.cacher.Clear()
+
+	errPersister := u.persister.Close()
+	if errPersister != nil {
+		u.log.Error("cannot close storage unit persister", "error", errPersister)
+	}
+
+	errChangeLog := u.changeLog.close()
+	if errChangeLog != nil {
+		u.log.Error("cannot close storage unit change log", "error", errChangeLog)
+	}
+
+	errCacher := u.closeCacher()
+
+	return errors.Join(errPersister, errChangeLog, errCacher)
+}
+
+// closeCacher unregisters the cacher from the global cache memory limit and closes it once, as both Close and
+// DestroyUnit release it
+func (u *Unit) closeCacher() error {
+	if !atomic.CompareAndSwapUint32(&u.cacherClosed, 0, 1) {
+		return nil
+	}
+
+	cacheMemoryConsumer, ok := u.cacher.(monitoring.CacheMemoryConsumer)
+	if ok {
+		monitoring.UnregisterCacheMemoryConsumer(cacheMemoryConsumer)
+	}
+
+	err := u.cacher.Close()
+	if err != nil {
+		u.log.Error("cannot close storage unit cacher", "error", err)
+	}
+
+	return err
+}
+
+// waitInFlightOperations waits up to the close grace period for the operations holding the unit lock. The
+// operations acquiring the lock after the unit was marked as closed return right away
+func (u *Unit) waitInFlightOperations() {
+	if u.closeGracePeriod <= 0 {
+		return
+	}
+
+	chDone := make(chan struct{})
+	go func() {
+		u.lock.Lock()
+		u.lock.Unlock()
+		close(chDone)
+	}()
+
+	select {
+	case <-chDone:
+	case <-time.After(u.closeGracePeriod):
+		u.log.Warn("closing the storage unit persister with operations in flight",
+			"grace period", u.closeGracePeriod)
+	}
+}
+
+func (u *Unit) isClosed() bool {
+	return atomic.LoadUint32(&u.closed) == 1
+}
+
+// RangeKeys can iterate over the persisted (key, value) pairs calling the provided handler
+func (u *Unit) RangeKeys(handler func(key []byte, value []byte) bool) {
+	if u.isClosed() {
+		return
+	}
+
+	u.persister.RangeKeys(handler)
+}
+
+// Get searches the key in the cache. In case it is not found,
+// it further searches it in the associated database.
+// In case it is found in the database, the cache is updated with the value as well, unless the read caching is
+// disabled.
+func (u *Unit) Get(key []byte) ([]byte, error) {
+	if u.disableReadCaching {
+		return u.GetNoCacheFill(key)
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return nil, common.ErrUnitClosed
+	}
+
+	return u.getUnprotected(u.persister, key)
+}
+
+// GetNoCacheFill searches the key in the cache, then in the persistence medium, without adding the value read from
+// the persister in the cache. As the cache is not filled, it only takes the unit lock in shared mode, so the
+// concurrent reads do not contend with each other
+func (u *Unit) GetNoCacheFill(key []byte) ([]byte, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return nil, common.ErrUnitClosed
+	}
+
+	return u.getWithoutCacheFill(u.persister, key)
+}
+
+func (u *Unit) getWithoutCacheFill(persister types.Persister, key []byte) ([]byte, error) {
+	v, ok := u.cacher.Get(key)
+	cached, isRawValue := v.([]byte)
+	if ok && isRawValue {
+		if u.cacheVerifier.shouldVerify() {
+			return u.verifyCachedValue(persister, key, cached)
+		}
+
+		return cached, nil
+	}
+
+	// the key is missing from the cache or the cache holds a decoded object stored by PutObject or GetObject
+	return persister.Get(key)
+}
+
+func (u *Unit) getUnprotected(persister types.Persister, key []byte) ([]byte, error) {
+	if u.disableReadCaching {
+		return u.getWithoutCacheFill(persister, key)
+	}
+
+	v, ok := u.cacher.Get(key)
+	var err error
+
+	cached, isRawValue := v.([]byte)
+	if ok && isRawValue && u.cacheVerifier.shouldVerify() {
+		return u.verifyCachedValue(persister, key, cached)
+	}
+
+	if !ok {
+		// not found in cache
+		// search it in second persistence medium
+
+		v, err = persister.Get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		buff, okAssertion := v.([]byte)
+		if !okAssertion {
+			return nil, fmt.Errorf("key: %s is not a byte slice", base64.StdEncoding.EncodeToString(key))
+		}
+
+		// if found in persistence unit, add it in cache
+		u.cacher.Put(key, v, len(buff))
+	}
+
+	buff, ok := v.([]byte)
+	if !ok {
+		// the cache holds a decoded object stored by PutObject or GetObject, serve the raw value from the persister
+		buff, err = persister.Get(key)
+		if err != nil {
+			return nil, err
+		}
+
+		u.cacher.Put(key, buff, len(buff))
+	}
+
+	return buff, nil
+}
+
+// GetConsistent returns the values of the provided keys, in order, read under the unit lock so no write can
+// interleave between the reads and the values are consistent with each other. The keys are searched in the cache,
+// then in the persister, like in Get. An error is returned if any of the keys is missing. This is meant for reading
+// a few keys atomically, as the writes are blocked during the whole read
+func (u *Unit) GetConsistent(keys [][]byte) ([][]byte, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return nil, common.ErrUnitClosed
+	}
+
+	values := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		value, err := u.getUnprotected(u.persister, key)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
 }
 
-// DBConfig holds the configurable elements of a database
-type DBConfig struct {
-	FilePath          string
-	Type              DBType
-	BatchDelaySeconds int
-	MaxBatchSize      int
-	MaxOpenFiles      int
-}
+// PutObject encodes the provided object using the configured marshalizer and stores it in the persistence medium.
+// The cache holds the decoded object so subsequent GetObject calls do not need to unmarshal it again, therefore
+// the object should not be modified after this call
+func (u *Unit) PutObject(key []byte, obj interface{}) error {
+	if check.IfNil(u.marshalizer) {
+		return common.ErrNilMarshalizer
+	}
 
-// Unit represents a storer's data bank
-// holding the cache and persistence unit
-type Unit struct {
-	lock      sync.RWMutex
-	persister types.Persister
-	cacher    types.Cacher
-}
+	buff, err := u.marshalizer.Marshal(obj)
+	if err != nil {
+		return err
+	}
 
-// Put adds data to both cache and persistence medium
-func (u *Unit) Put(key, data []byte) error {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
-	u.cacher.Put(key, data, len(data))
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
 
-	err := u.persister.Put(key, data)
+	err = u.persister.Put(key, buff)
 	if err != nil {
 		u.cacher.Remove(key)
 		return err
 	}
 
-	return err
-}
+	u.cacher.Put(key, obj, len(buff))
+	u.sizeSampler.record(len(buff))
 
-// PutInEpoch will call the Put method as this storer doesn't handle epochs
-func (u *Unit) PutInEpoch(key, data []byte, _ uint32) error {
-	return u.Put(key, data)
+	return u.appendChanges([]types.Operation{{Type: types.PutOperation, Key: key, Value: buff}})
 }
 
-// GetOldestEpoch will return an error that signals that the oldest epoch fetching is not available
-func (u *Unit) GetOldestEpoch() (uint32, error) {
-	return 0, common.ErrOldestEpochNotAvailable
-}
+// GetObject searches the key in the cache and in the persistence medium and writes the decoded value
+// in the provided destination, which should be a non-nil pointer. A decoded object found in the cache is
+// shallow copied in the destination, while values read from the persister are decoded using the configured
+// marshalizer and a copy of the decoded object is cached
+func (u *Unit) GetObject(key []byte, dst interface{}) error {
+	if check.IfNil(u.marshalizer) {
+		return common.ErrNilMarshalizer
+	}
 
-// Close will close unit
-func (u *Unit) Close() error {
-	u.cacher.Clear()
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() {
+		return common.ErrInvalidObjectDestination
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	v, ok := u.cacher.Get(key)
+	if ok && v != nil {
+		cachedValue := reflect.ValueOf(v)
+		if cachedValue.Type() == dstValue.Type() && !cachedValue.IsNil() {
+			dstValue.Elem().Set(cachedValue.Elem())
+			return nil
+		}
+	}
 
-	err := u.persister.Close()
+	buff, err := u.persister.Get(key)
 	if err != nil {
-		log.Error("cannot close storage unit persister", "error", err)
 		return err
 	}
 
+	err = u.marshalizer.Unmarshal(dst, buff)
+	if err != nil {
+		return err
+	}
+
+	if u.disableReadCaching {
+		return nil
+	}
+
+	cachedObject := reflect.New(dstValue.Elem().Type())
+	cachedObject.Elem().Set(dstValue.Elem())
+	u.cacher.Put(key, cachedObject.Interface(), len(buff))
+
 	return nil
 }
 
-// RangeKeys can iterate over the persisted (key, value) pairs calling the provided handler
-func (u *Unit) RangeKeys(handler func(key []byte, value []byte) bool) {
-	u.persister.RangeKeys(handler)
+// GetOrDefault searches the key in the same way Get does, returning a copy of the provided default value
+// if the key can not be retrieved. Backend errors are only logged, use GetOrDefaultE to handle them
+func (u *Unit) GetOrDefault(key []byte, def []byte) []byte {
+	v, err := u.GetOrDefaultE(key, def)
+	if err != nil {
+		u.log.Warn("cannot get key from unit, returning the default value",
+			"key", key,
+			"error", err.Error(),
+		)
+
+		return cloneBytes(def)
+	}
+
+	return v
 }
 
-// Get searches the key in the cache. In case it is not found,
-// it further searches it in the associated database.
-// In case it is found in the database, the cache is updated with the value as well.
-func (u *Unit) Get(key []byte) ([]byte, error) {
-	u.lock.Lock()
-	defer u.lock.Unlock()
+// GetOrDefaultE searches the key in the same way Get does, returning a copy of the provided default value
+// if the key is not found. Any other error is propagated
+func (u *Unit) GetOrDefaultE(key []byte, def []byte) ([]byte, error) {
+	v, err := u.Get(key)
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return cloneBytes(def), nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	v, ok := u.cacher.Get(key)
-	var err error
+	return v, nil
+}
 
-	if !ok {
-		// not found in cache
-		// search it in second persistence medium
+func cloneBytes(buff []byte) []byte {
+	if buff == nil {
+		return nil
+	}
 
-		v, err = u.persister.Get(key)
-		if err != nil {
-			return nil, err
-		}
+	clone := make([]byte, len(buff))
+	copy(clone, buff)
 
-		buff, okAssertion := v.([]byte)
-		if !okAssertion {
-			return nil, fmt.Errorf("key: %s is not a byte slice", base64.StdEncoding.EncodeToString(key))
-		}
+	return clone
+}
 
-		// if found in persistence unit, add it in cache
-		u.cacher.Put(key, v, len(buff))
+// GetInto searches the key in the same way Get does and copies the found value in the provided buffer,
+// returning the number of copied bytes. If the buffer is too small, ErrBufferTooSmall is returned along
+// with the length the buffer should have, so the caller can retry with a larger (pooled) buffer.
+func (u *Unit) GetInto(key []byte, dst []byte) (int, error) {
+	v, err := u.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) > len(dst) {
+		return len(v), common.ErrBufferTooSmall
 	}
 
-	return v.([]byte), nil
+	return copy(dst, v), nil
 }
 
 // GetFromEpoch will call the Get method as this storer doesn't handle epochs
@@ -207,7 +1128,7 @@ func (u *Unit) GetBulkFromEpoch(keys [][]byte, _ uint32) ([]storageCore.KeyValue
 	for _, key := range keys {
 		value, err := u.Get(key)
 		if err != nil {
-			log.Warn("cannot get key from unit",
+			u.log.Warn("cannot get key from unit",
 				"key", key,
 				"error", err.Error(),
 			)
@@ -219,12 +1140,87 @@ func (u *Unit) GetBulkFromEpoch(keys [][]byte, _ uint32) ([]storageCore.KeyValue
 	return results, nil
 }
 
+// GetExisting returns the values of the provided keys which are present, omitting the missing ones, so the
+// callers do not need to check the keys with Has before getting them. The keys are first searched in the cache and
+// the remaining ones are read from the persister in a single pass, batched if the persister implements
+// types.MultiGetter. The values read from the persister are added in the cache
+func (u *Unit) GetExisting(keys [][]byte) (map[string][]byte, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return nil, common.ErrUnitClosed
+	}
+
+	values := make(map[string][]byte, len(keys))
+	missingKeys := make([][]byte, 0)
+	for _, key := range keys {
+		v, ok := u.cacher.Get(key)
+		if ok {
+			// the cache might hold a decoded object stored by PutObject or GetObject, then the raw value is read
+			// from the persister
+			buff, isBuff := v.([]byte)
+			if isBuff {
+				values[string(key)] = buff
+				continue
+			}
+		}
+
+		missingKeys = append(missingKeys, key)
+	}
+	if len(missingKeys) == 0 {
+		return values, nil
+	}
+
+	persisted, err := u.getExistingFromPersister(missingKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, buff := range persisted {
+		values[key] = buff
+		if !u.disableReadCaching {
+			u.cacher.Put([]byte(key), buff, len(buff))
+		}
+	}
+
+	return values, nil
+}
+
+func (u *Unit) getExistingFromPersister(keys [][]byte) (map[string][]byte, error) {
+	multiGetter, ok := u.persister.(types.MultiGetter)
+	if ok {
+		return multiGetter.GetExisting(keys)
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		buff, err := u.persister.Get(key)
+		if errors.Is(err, common.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values[string(key)] = buff
+	}
+
+	return values, nil
+}
+
 // Has checks if the key is in the Unit.
-// It first checks the cache. If it is not found, it checks the db
+// It first checks the cache. If it is not found, it checks the db.
+// The cache is never mutated: a key found only in the db is not promoted into the cache and the cache
+// recent-ness of the key is not updated
 func (u *Unit) Has(key []byte) error {
 	u.lock.RLock()
 	defer u.lock.RUnlock()
 
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
 	has := u.cacher.Has(key)
 	if has {
 		return nil
@@ -233,6 +1229,44 @@ func (u *Unit) Has(key []byte) error {
 	return u.persister.Has(key)
 }
 
+// GetAndRemoveFromCache atomically returns the cached value of the key and removes it from the cache, so when
+// several callers pop the same key only one of them gets the value. The persister is not involved.
+// It returns ErrGetAndRemoveNotSupported if the cache can not atomically get and remove a key
+func (u *Unit) GetAndRemoveFromCache(key []byte) (interface{}, bool, error) {
+	getAndRemover, ok := u.cacher.(types.GetAndRemover)
+	if !ok {
+		return nil, false, common.ErrGetAndRemoveNotSupported
+	}
+
+	value, ok := getAndRemover.GetAndRemove(key)
+
+	return value, ok, nil
+}
+
+// HasInPersister checks if the key is in the persistence medium, ignoring the cache contents
+func (u *Unit) HasInPersister(key []byte) error {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	return u.persister.Has(key)
+}
+
+// GetFromPersister reads the key from the persistence medium, ignoring and leaving unchanged the cache contents
+func (u *Unit) GetFromPersister(key []byte) ([]byte, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return nil, common.ErrUnitClosed
+	}
+
+	return u.persister.Get(key)
+}
+
 // SearchFirst will call the Get method as this storer doesn't handle epochs
 func (u *Unit) SearchFirst(key []byte) ([]byte, error) {
 	return u.Get(key)
@@ -248,10 +1282,84 @@ func (u *Unit) Remove(key []byte) error {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	return u.removeUnprotected(u.persister, key)
+}
+
+func (u *Unit) removeUnprotected(persister types.Persister, key []byte) error {
 	u.cacher.Remove(key)
-	err := u.persister.Remove(key)
+	err := persister.Remove(key)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return u.appendChanges([]types.Operation{{Type: types.RemoveOperation, Key: key}})
+}
+
+// PutCtx adds data to both cache and persistence medium, the persister call honoring the context deadline and
+// cancellation if the persister implements types.ContextPersister. On a context error the data is not cached and
+// the outcome of the write is undefined: the persister might still complete it afterwards, once the unit lock was
+// released, so it can land after a later write or removal of the same key and leave the persister disagreeing with
+// the cache. The unit lock is not held until then, as a hung persister would block all the other operations. The
+// key should be written again, or removed, to get a defined value
+func (u *Unit) PutCtx(ctx context.Context, key, data []byte) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	return u.putUnprotected(newContextPersister(ctx, u.persister), key, data)
+}
+
+// GetCtx searches the key in the cache, then in the persistence medium, the persister call honoring the context
+// deadline and cancellation if the persister implements types.ContextPersister
+func (u *Unit) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return nil, common.ErrUnitClosed
+	}
+
+	return u.getUnprotected(newContextPersister(ctx, u.persister), key)
+}
+
+// HasCtx checks if the key is in the cache, then in the persistence medium, the persister call honoring the context
+// deadline and cancellation if the persister implements types.ContextPersister
+func (u *Unit) HasCtx(ctx context.Context, key []byte) error {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	has := u.cacher.Has(key)
+	if has {
+		return nil
+	}
+
+	return newContextPersister(ctx, u.persister).Has(key)
+}
+
+// RemoveCtx removes the data associated to the given key from both cache and persistence medium, the persister call
+// honoring the context deadline and cancellation if the persister implements types.ContextPersister. On a context
+// error the outcome of the removal is undefined, as for PutCtx: the persister might still complete it afterwards,
+// even after a later write of the same key
+func (u *Unit) RemoveCtx(ctx context.Context, key []byte) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	return u.removeUnprotected(newContextPersister(ctx, u.persister), key)
 }
 
 // ClearCache cleans up the entire cache
@@ -259,13 +1367,48 @@ func (u *Unit) ClearCache() {
 	u.cacher.Clear()
 }
 
-// DestroyUnit cleans up the cache, and the db
+// DestroyUnit cleans up the cache, the db and the change log, if any. The unit is closed, so the later operations
+// return ErrUnitClosed
 func (u *Unit) DestroyUnit() error {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
 	u.cacher.Clear()
-	return u.persister.Destroy()
+	_ = u.closeCacher()
+
+	wasClosed := !atomic.CompareAndSwapUint32(&u.closed, 0, 1)
+	var errPersister error
+	if wasClosed {
+		errPersister = u.persister.DestroyClosed()
+	} else {
+		errPersister = u.persister.Destroy()
+	}
+
+	errChangeLog := u.changeLog.destroy(wasClosed)
+	if errChangeLog != nil {
+		u.log.Error("cannot destroy storage unit change log", "error", errChangeLog)
+	}
+
+	return errors.Join(errPersister, errChangeLog)
+}
+
+// TruncateUnit cleans up the cache and deletes all the keys from the db, without closing it or removing its directory.
+// It returns ErrTruncateNotSupported if the persister can not be truncated
+func (u *Unit) TruncateUnit() error {
+	truncater, ok := u.persister.(types.Truncater)
+	if !ok {
+		return common.ErrTruncateNotSupported
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.isClosed() {
+		return common.ErrUnitClosed
+	}
+
+	u.cacher.Clear()
+	return truncater.Truncate()
 }
 
 // IsInterfaceNil returns true if there is no value under the interface
@@ -275,7 +1418,7 @@ func (u *Unit) IsInterfaceNil() bool {
 
 // NewStorageUnit is the constructor for the storage unit, creating a new storage unit
 // from the given cacher and persister.
-func NewStorageUnit(c types.Cacher, p types.Persister) (*Unit, error) {
+func NewStorageUnit(c types.Cacher, p types.Persister, options ...UnitOption) (*Unit, error) {
 	if check.IfNil(p) {
 		return nil, common.ErrNilPersister
 	}
@@ -286,6 +1429,20 @@ func NewStorageUnit(c types.Cacher, p types.Persister) (*Unit, error) {
 	sUnit := &Unit{
 		persister: p,
 		cacher:    c,
+		log:       log,
+	}
+
+	for _, option := range options {
+		option(sUnit)
+	}
+	if sUnit.optionsErr != nil {
+		return nil, sUnit.optionsErr
+	}
+
+	// the unit owns the registration of its cacher against the global cache memory limit, released by closeCacher
+	cacheMemoryConsumer, ok := c.(monitoring.CacheMemoryConsumer)
+	if ok {
+		monitoring.RegisterCacheMemoryConsumer(cacheMemoryConsumer)
 	}
 
 	return sUnit, nil
@@ -297,8 +1454,15 @@ type PersisterFactoryHandler interface {
 	IsInterfaceNil() bool
 }
 
-// NewStorageUnitFromConf creates a new storage unit from a storage unit config
-func NewStorageUnitFromConf(cacheConf CacheConfig, dbConf DBConfig, persisterFactory PersisterFactoryHandler) (*Unit, error) {
+// NewStorageUnitFromConf creates a new storage unit from a storage unit config. The database types registered with
+// RegisterDBType are created by their registered factory instead of the provided persister factory. The leveldb
+// settings of the config are passed to the persister factories implementing LevelDBPersisterFactoryHandler
+func NewStorageUnitFromConf(
+	cacheConf CacheConfig,
+	dbConf DBConfig,
+	persisterFactory PersisterFactoryHandler,
+	options ...UnitOption,
+) (*Unit, error) {
 	var cache types.Cacher
 	var db types.Persister
 	var err error
@@ -315,25 +1479,58 @@ func NewStorageUnitFromConf(cacheConf CacheConfig, dbConf DBConfig, persisterFac
 		return nil, err
 	}
 
-	db, err = NewDB(persisterFactory, dbConf.FilePath)
+	dbFactory, ok := getDBFactory(dbConf.Type)
+	if ok {
+		db, err = dbFactory(newArgDB(dbConf))
+	} else {
+		db, err = newDBFromConf(persisterFactory, dbConf)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return NewStorageUnit(cache, db)
+	if dbConf.MaxConcurrentOps > 0 {
+		db, err = boundedpersister.NewBoundedPersister(db, dbConf.MaxConcurrentOps, dbConf.FailFastOnMaxConcurrentOps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewStorageUnit(cache, db, options...)
+}
+
+// NewStorageUnitFromUnitConf creates a new storage unit from a unit config, using the configured logger if provided
+func NewStorageUnitFromUnitConf(config UnitConfig, persisterFactory PersisterFactoryHandler, options ...UnitOption) (*Unit, error) {
+	options = append([]UnitOption{WithLogger(config.Logger)}, options...)
+	if config.DisableReadCaching {
+		options = append(options, WithReadCachingDisabled())
+	}
+
+	return NewStorageUnitFromConf(config.CacheConf, config.DBConf, persisterFactory, options...)
 }
 
-// NewCache creates a new cache from a cache config
+// NewCache creates a new cache from a cache config. The cache types registered with RegisterCacheType take
+// precedence over the built-in ones
 func NewCache(config CacheConfig) (types.Cacher, error) {
 	monitoring.MonitorNewCache(config.Name, config.SizeInBytes)
 
+	cacheFactory, ok := getCacheFactory(config.Type)
+	if ok {
+		return cacheFactory(config)
+	}
+
 	cacheType := config.Type
 	capacity := config.Capacity
 	shards := config.Shards
 	sizeInBytes := config.SizeInBytes
 
+	err := checkEvictionPolicy(cacheType, config.EvictionPolicy)
+	if err != nil {
+		return nil, err
+	}
+
 	var cacher types.Cacher
-	var err error
+	capacityOptions := []capacityCache.Option{capacityCache.WithMaxEntrySizeInBytes(config.MaxEntrySizeInBytes)}
 
 	switch cacheType {
 	case LRUCache:
@@ -341,6 +1538,11 @@ func NewCache(config CacheConfig) (types.Cacher, error) {
 			return nil, common.ErrLRUCacheWithProvidedSize
 		}
 
+		if config.EvictionPolicy == RandomPolicy {
+			cacher, err = randomcache.NewCache(int(capacity))
+			break
+		}
+
 		cacher, err = lrucache.NewCache(int(capacity))
 	case SizeLRUCache:
 		if sizeInBytes < minimumSizeForLRUCache {
@@ -351,7 +1553,47 @@ func NewCache(config CacheConfig) (types.Cacher, error) {
 			)
 		}
 
-		cacher, err = lrucache.NewCacheWithSizeInBytes(int(capacity), int64(sizeInBytes))
+		if config.PerEntryOverheadBytes != 0 {
+			cacher, err = lrucache.NewCacheWithSizeInBytesAndOverhead(
+				int(capacity),
+				int64(sizeInBytes),
+				config.PerEntryOverheadBytes,
+				capacityOptions...,
+			)
+			break
+		}
+
+		cacher, err = lrucache.NewCacheWithSizeInBytes(int(capacity), int64(sizeInBytes), capacityOptions...)
+	case ShardedSizeLRUCache:
+		if shards < 1 {
+			return nil, common.ErrCacheShardsInvalid
+		}
+		if sizeInBytes < minimumSizeForLRUCache {
+			return nil, fmt.Errorf("%w, provided %d, minimum %d",
+				common.ErrLRUCacheInvalidSize,
+				sizeInBytes,
+				minimumSizeForLRUCache,
+			)
+		}
+
+		// capacity and size in bytes are configured as totals and are evenly split between the shards
+		if config.PerEntryOverheadBytes != 0 {
+			cacher, err = lrucache.NewShardedCacheWithSizeInBytesAndOverhead(
+				int(capacity/shards),
+				int64(sizeInBytes/uint64(shards)),
+				int(shards),
+				config.PerEntryOverheadBytes,
+				capacityOptions...,
+			)
+			break
+		}
+
+		cacher, err = lrucache.NewShardedCacheWithSizeInBytes(
+			int(capacity/shards),
+			int64(sizeInBytes/uint64(shards)),
+			int(shards),
+			capacityOptions...,
+		)
 	case FIFOShardedCache:
 		cacher, err = fifocache.NewShardedCache(int(capacity), int(shards))
 		if err != nil {
@@ -369,33 +1611,81 @@ func NewCache(config CacheConfig) (types.Cacher, error) {
 	return cacher, nil
 }
 
+func checkEvictionPolicy(cacheType CacheType, policy EvictionPolicy) error {
+	switch policy {
+	case "":
+		return nil
+	case LRUPolicy, LFUPolicy, FIFOPolicy, RandomPolicy, TTLPolicy:
+	default:
+		return fmt.Errorf("%w: %s", common.ErrNotSupportedEvictionPolicy, policy)
+	}
+
+	for _, compatiblePolicy := range compatibleEvictionPolicies[cacheType] {
+		if compatiblePolicy == policy {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: cache type %s, eviction policy %s", common.ErrIncompatibleEvictionPolicy, cacheType, policy)
+}
+
 // ArgDB is a structure that is used to create a new storage.Persister implementation
 type ArgDB struct {
-	DBType            DBType
-	Path              string
-	BatchDelaySeconds int
-	MaxBatchSize      int
-	MaxOpenFiles      int
+	DBType                    DBType
+	Path                      string
+	BatchDelaySeconds         int
+	MaxBatchSize              int
+	MaxBatchSizeInBytes       int
+	MaxOpenFiles              int
+	AdaptiveBatching          bool
+	MinBatchDelayMilliseconds int
+	MaxBatchDelayMilliseconds int
+	CompactionSchedule        []string
+	SyncEveryInterval         time.Duration
+	OpenTimeout               time.Duration
 }
 
 // NewDB creates a new database from database config
 // TODO: refactor to integrate retries loop into persister factory; maybe implement persister
 // factory separatelly in storage repo
 func NewDB(persisterFactory PersisterFactoryHandler, path string) (types.Persister, error) {
+	return NewDBWithTimeout(persisterFactory, path, 0)
+}
+
+// NewDBWithTimeout creates a new database as NewDB does, the retries that would start after the provided open
+// timeout being skipped. It then returns ErrOpenTimeout, wrapping the last creation error as well. A zero timeout
+// keeps all the retries. A single hung creation is not interrupted
+func NewDBWithTimeout(
+	persisterFactory PersisterFactoryHandler,
+	path string,
+	openTimeout time.Duration,
+) (types.Persister, error) {
 	if check.IfNil(persisterFactory) {
 		return nil, ErrNilPersisterFactory
 	}
 
+	return createDBWithRetries(func() (types.Persister, error) {
+		return persisterFactory.Create(path)
+	}, openTimeout)
+}
+
+func createDBWithRetries(create func() (types.Persister, error), openTimeout time.Duration) (types.Persister, error) {
+	deadline := time.Now().Add(openTimeout)
+
 	var db types.Persister
 	var err error
 
 	for i := 0; i < MaxRetriesToCreateDB; i++ {
-		db, err = persisterFactory.Create(path)
+		db, err = create()
 
 		if err == nil {
 			return db, nil
 		}
 
+		if openTimeout > 0 && time.Until(deadline) < SleepTimeBetweenCreateDBRetries {
+			return nil, fmt.Errorf("%w: %w", common.ErrOpenTimeout, err)
+		}
+
 		// TODO: extract this in a parameter and inject it
 		time.Sleep(SleepTimeBetweenCreateDBRetries)
 	}
@@ -419,3 +1709,19 @@ func (h HasherType) NewHasher() (hashing.Hasher, error) {
 		return nil, common.ErrNotSupportedHashType
 	}
 }
+
+// NewShardIDProvider will return a shard id provider implementation from the string ShardIDProviderType. The number
+// of virtual nodes is only used by the consistent hashing provider
+func (s ShardIDProviderType) NewShardIDProvider(numOfShards int32, virtualNodes int) (types.ShardIDProvider, error) {
+	switch s {
+	case BinarySplit:
+		if numOfShards < 0 {
+			return nil, sharded.ErrInvalidNumberOfShards
+		}
+		return sharded.NewBinarySplitShardIDProvider(uint32(numOfShards))
+	case ConsistentHash:
+		return sharded.NewConsistentHashShardIDProvider(numOfShards, virtualNodes)
+	default:
+		return nil, common.ErrNotSupportedShardIDProviderType
+	}
+}
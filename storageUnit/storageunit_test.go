@@ -1,16 +1,30 @@
 package storageUnit_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-core-go/hashing/blake2b"
+	"github.com/DharitriOne/drt-chain-storage-go/boundedpersister"
 	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
 	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
 	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/monitoring"
+	"github.com/DharitriOne/drt-chain-storage-go/randomcache"
 	"github.com/DharitriOne/drt-chain-storage-go/storageUnit"
 	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -136,13 +150,104 @@ func TestGetPresent(t *testing.T) {
 	assert.Equal(t, val, v, "expected %s but got %s", val, v)
 }
 
+func TestGetIntoNotPresent(t *testing.T) {
+	key := []byte("key5a")
+	s := initStorageUnit(t, 10)
+	buff := make([]byte, 10)
+	n, err := s.GetInto(key, buff)
+
+	assert.NotNil(t, err)
+	assert.Zero(t, n)
+}
+
+func TestGetIntoPresent(t *testing.T) {
+	key, val := []byte("key5b"), []byte("value5b")
+	s := initStorageUnit(t, 10)
+	err := s.Put(key, val)
+	assert.Nil(t, err)
+
+	buff := make([]byte, 10)
+	n, err := s.GetInto(key, buff)
+	assert.Nil(t, err)
+	assert.Equal(t, len(val), n)
+	assert.Equal(t, val, buff[:n])
+
+	s.ClearCache()
+
+	buff = make([]byte, len(val))
+	n, err = s.GetInto(key, buff)
+	assert.Nil(t, err)
+	assert.Equal(t, len(val), n)
+	assert.Equal(t, val, buff)
+}
+
+func TestGetIntoBufferTooSmall(t *testing.T) {
+	key, val := []byte("key5c"), []byte("value5c")
+	s := initStorageUnit(t, 10)
+	err := s.Put(key, val)
+	assert.Nil(t, err)
+
+	buff := make([]byte, 2)
+	n, err := s.GetInto(key, buff)
+	assert.Equal(t, common.ErrBufferTooSmall, err)
+	assert.Equal(t, len(val), n)
+	assert.Equal(t, make([]byte, 2), buff)
+}
+
+func TestGetOrDefaultPresentShouldReturnValue(t *testing.T) {
+	s := initStorageUnit(t, 10)
+	key, val := []byte("key"), []byte("value")
+	_ = s.Put(key, val)
+
+	assert.Equal(t, val, s.GetOrDefault(key, []byte("default")))
+
+	recovered, err := s.GetOrDefaultE(key, []byte("default"))
+	assert.Nil(t, err)
+	assert.Equal(t, val, recovered)
+}
+
+func TestGetOrDefaultNotPresentShouldReturnDefaultCopy(t *testing.T) {
+	s := initStorageUnit(t, 10)
+	def := []byte("default")
+
+	recovered := s.GetOrDefault([]byte("missing"), def)
+	assert.Equal(t, def, recovered)
+	recovered[0] = 'D'
+	assert.Equal(t, []byte("default"), def)
+
+	recovered, err := s.GetOrDefaultE([]byte("missing"), def)
+	assert.Nil(t, err)
+	assert.Equal(t, def, recovered)
+
+	recovered, err = s.GetOrDefaultE([]byte("missing"), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, recovered)
+}
+
+func TestGetOrDefaultBackendErrorShouldPropagateOnlyOnGetOrDefaultE(t *testing.T) {
+	expectedErr := errors.New("expected error")
+	persister := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			return nil, expectedErr
+		},
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	recovered, err := s.GetOrDefaultE([]byte("key"), []byte("default"))
+	assert.Nil(t, recovered)
+	assert.Equal(t, expectedErr, err)
+
+	assert.Equal(t, []byte("default"), s.GetOrDefault([]byte("key"), []byte("default")))
+}
+
 func TestHasNotPresent(t *testing.T) {
 	key := []byte("key6")
 	s := initStorageUnit(t, 10)
 	err := s.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestHasNotPresentCache(t *testing.T) {
@@ -171,6 +276,73 @@ func TestHasPresent(t *testing.T) {
 	assert.Nil(t, err, "expected no error, but got %s", err)
 }
 
+func TestHasShouldNotMutateTheCache(t *testing.T) {
+	key, val := []byte("key"), []byte("value")
+	cache, _ := lrucache.NewCache(2)
+	persister := memorydb.New()
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	_ = persister.Put(key, val)
+	_ = s.Put([]byte("hot1"), val)
+	_ = s.Put([]byte("hot2"), val)
+	keysBefore := cache.Keys()
+
+	assert.Nil(t, s.Has(key))
+	assert.Nil(t, s.Has([]byte("hot1")))
+	assert.NotNil(t, s.Has([]byte("missing")))
+
+	assert.False(t, cache.Has(key))
+	assert.Equal(t, keysBefore, cache.Keys())
+}
+
+func TestGetAndRemoveFromCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not supported cache should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := randomcache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		value, ok, err := s.GetAndRemoveFromCache([]byte("key"))
+		assert.Nil(t, value)
+		assert.False(t, ok)
+		assert.Equal(t, common.ErrGetAndRemoveNotSupported, err)
+	})
+	t.Run("should pop the cached value without touching the persister", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		key := []byte("key")
+		_ = s.Put(key, []byte("value"))
+
+		value, ok, err := s.GetAndRemoveFromCache(key)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("value"), value)
+
+		_, ok, err = s.GetAndRemoveFromCache(key)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+		assert.Nil(t, s.HasInPersister(key))
+	})
+}
+
+func TestHasInPersister(t *testing.T) {
+	key, val := []byte("key"), []byte("value")
+	cache, _ := lrucache.NewCache(10)
+	persister := memorydb.New()
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	cache.Put(key, val, len(val))
+	assert.Nil(t, s.Has(key))
+	assert.ErrorIs(t, s.HasInPersister(key), common.ErrKeyNotFound)
+
+	_ = persister.Put(key, val)
+	cache.Clear()
+	assert.Nil(t, s.HasInPersister(key))
+	assert.False(t, cache.Has(key))
+}
+
 func TestDeleteNotPresent(t *testing.T) {
 	key := []byte("key12")
 	s := initStorageUnit(t, 10)
@@ -238,6 +410,1293 @@ func TestDestroyUnitNoError(t *testing.T) {
 	assert.Nil(t, err, "no error expected, but got %s", err)
 }
 
+func TestClosedUnitShouldReturnErrUnitClosed(t *testing.T) {
+	s := initStorageUnit(t, 10)
+	_ = s.Put([]byte("key"), []byte("value"))
+
+	err := s.Close()
+	assert.Nil(t, err)
+
+	_, err = s.Get([]byte("key"))
+	assert.Equal(t, common.ErrUnitClosed, err)
+	assert.Equal(t, common.ErrUnitClosed, s.Has([]byte("key")))
+	assert.Equal(t, common.ErrUnitClosed, s.Put([]byte("key"), []byte("value")))
+	assert.Equal(t, common.ErrUnitClosed, s.Remove([]byte("key")))
+
+	numCalls := 0
+	s.RangeKeys(func(_ []byte, _ []byte) bool {
+		numCalls++
+		return true
+	})
+	assert.Equal(t, 0, numCalls)
+	assert.Nil(t, s.DestroyUnit())
+}
+
+func TestCloseShouldBeIdempotent(t *testing.T) {
+	numCloseCalls := 0
+	persister := &testscommon.PersisterStub{
+		CloseCalled: func() error {
+			numCloseCalls++
+			return nil
+		},
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	assert.Nil(t, s.Close())
+	assert.Nil(t, s.Close())
+	assert.Equal(t, 1, numCloseCalls)
+}
+
+func TestDestroyUnitShouldCloseTheUnit(t *testing.T) {
+	s := initStorageUnit(t, 10)
+	assert.Nil(t, s.DestroyUnit())
+
+	assert.Equal(t, common.ErrUnitClosed, s.Put([]byte("key"), []byte("value")))
+	_, err := s.Get([]byte("key"))
+	assert.Equal(t, common.ErrUnitClosed, err)
+	assert.Nil(t, s.Close())
+}
+
+func TestCloseShouldCloseEverythingEvenIfThePersisterFails(t *testing.T) {
+	errPersister := errors.New("persister close error")
+	persister := &testscommon.PersisterStub{
+		CloseCalled: func() error {
+			return errPersister
+		},
+	}
+	changeLogClosed := false
+	changeLogPersister := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			return nil, common.ErrKeyNotFound
+		},
+		CloseCalled: func() error {
+			changeLogClosed = true
+			return nil
+		},
+	}
+	cache, _ := lrucache.NewCacheWithSizeInBytes(10, 1000)
+	s, _ := storageUnit.NewStorageUnit(cache, persister, storageUnit.WithChangeLog(changeLogPersister))
+
+	err := s.Close()
+	assert.True(t, errors.Is(err, errPersister))
+	assert.True(t, changeLogClosed)
+	assert.False(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+}
+
+func TestCloseAndDestroyShouldUnregisterTheSizedCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("close", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCacheWithSizeInBytes(10, 1000)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		assert.True(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+
+		assert.Nil(t, s.Close())
+		assert.False(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+		assert.Nil(t, s.DestroyUnit())
+	})
+	t.Run("destroy", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCacheWithSizeInBytes(10, 1000)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		assert.True(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+
+		assert.Nil(t, s.DestroyUnit())
+		assert.False(t, monitoring.IsCacheMemoryConsumerRegistered(cache))
+	})
+}
+
+func TestCloseGracePeriodShouldWaitInFlightOperations(t *testing.T) {
+	chGetStarted := make(chan struct{})
+	chReleaseGet := make(chan struct{})
+	persisterClosed := uint32(0)
+	persister := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			close(chGetStarted)
+			<-chReleaseGet
+			if atomic.LoadUint32(&persisterClosed) == 1 {
+				return nil, errors.New("persister closed during get")
+			}
+
+			return []byte("value"), nil
+		},
+		CloseCalled: func() error {
+			atomic.StoreUint32(&persisterClosed, 1)
+			return nil
+		},
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister, storageUnit.WithCloseGracePeriod(time.Minute))
+
+	chGetDone := make(chan error)
+	go func() {
+		_, err := s.Get([]byte("key"))
+		chGetDone <- err
+	}()
+	<-chGetStarted
+
+	chCloseDone := make(chan error)
+	go func() {
+		chCloseDone <- s.Close()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, uint32(0), atomic.LoadUint32(&persisterClosed))
+
+	close(chReleaseGet)
+	assert.Nil(t, <-chGetDone)
+	assert.Nil(t, <-chCloseDone)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&persisterClosed))
+
+	_, err := s.Get([]byte("key"))
+	assert.Equal(t, common.ErrUnitClosed, err)
+}
+
+func TestCloseGracePeriodShouldNotWaitLongerThanTheGracePeriod(t *testing.T) {
+	chGetStarted := make(chan struct{})
+	chReleaseGet := make(chan struct{})
+	persister := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			close(chGetStarted)
+			<-chReleaseGet
+			return nil, errors.New("persister closed during get")
+		},
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister, storageUnit.WithCloseGracePeriod(50*time.Millisecond))
+	defer close(chReleaseGet)
+
+	go func() {
+		_, _ = s.Get([]byte("key"))
+	}()
+	<-chGetStarted
+
+	start := time.Now()
+	err := s.Close()
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+// hangingContextPersister blocks the context aware operations until their context is done
+type hangingContextPersister struct {
+	*testscommon.PersisterStub
+}
+
+func (hcp *hangingContextPersister) PutCtx(ctx context.Context, _, _ []byte) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (hcp *hangingContextPersister) GetCtx(ctx context.Context, _ []byte) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hcp *hangingContextPersister) HasCtx(ctx context.Context, _ []byte) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (hcp *hangingContextPersister) RemoveCtx(ctx context.Context, _ []byte) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestContextOperationsShouldHonorTheDeadline(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, &hangingContextPersister{&testscommon.PersisterStub{}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, s.PutCtx(ctx, []byte("key"), []byte("value")))
+	assert.False(t, cache.Has([]byte("key")))
+	_, err := s.GetCtx(ctx, []byte("key"))
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, context.DeadlineExceeded, s.HasCtx(ctx, []byte("key")))
+	assert.Equal(t, context.DeadlineExceeded, s.RemoveCtx(ctx, []byte("key")))
+}
+
+func TestContextOperationsWithoutContextPersister(t *testing.T) {
+	s := initStorageUnit(t, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	assert.Nil(t, s.PutCtx(ctx, []byte("key"), []byte("value")))
+	s.ClearCache()
+	value, err := s.GetCtx(ctx, []byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+	assert.Nil(t, s.HasCtx(ctx, []byte("key")))
+
+	cancel()
+	s.ClearCache()
+	_, err = s.GetCtx(ctx, []byte("key"))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, s.HasCtx(ctx, []byte("key")))
+	assert.Equal(t, context.Canceled, s.RemoveCtx(ctx, []byte("key")))
+	assert.Nil(t, s.HasInPersister([]byte("key")))
+}
+
+func TestDuplicateReport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil hasher should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		_, err := s.DuplicateReport(nil, 1)
+		assert.Equal(t, common.ErrNilHasher, err)
+	})
+	t.Run("invalid sample rate should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		for _, sampleRate := range []float64{0, -0.5, 1.5, math.NaN()} {
+			_, err := s.DuplicateReport(blake2b.NewBlake2b(), sampleRate)
+			assert.Equal(t, common.ErrInvalidSampleRate, err)
+		}
+	})
+	t.Run("closed unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		_ = s.Close()
+		_, err := s.DuplicateReport(blake2b.NewBlake2b(), 1)
+		assert.Equal(t, common.ErrUnitClosed, err)
+	})
+	t.Run("full scan should report the duplicates", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		_ = s.Put([]byte("key1"), []byte("value a"))
+		_ = s.Put([]byte("key2"), []byte("value a"))
+		_ = s.Put([]byte("key3"), []byte("value a"))
+		_ = s.Put([]byte("key4"), []byte("value bb"))
+		_ = s.Put([]byte("key5"), []byte("value bb"))
+		_ = s.Put([]byte("key6"), []byte("unique"))
+
+		stats, err := s.DuplicateReport(blake2b.NewBlake2b(), 1)
+		assert.Nil(t, err)
+		assert.Equal(t, storageUnit.DupStats{
+			NumScanned:                6,
+			NumSampled:                6,
+			NumDuplicateGroups:        2,
+			NumDuplicateValues:        3,
+			ReclaimableBytes:          2*7 + 8,
+			EstimatedDuplicateGroups:  2,
+			EstimatedReclaimableBytes: 2*7 + 8,
+		}, stats)
+	})
+	t.Run("sampling should keep the copies of a value together", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		numValues := 1000
+		for i := 0; i < numValues; i++ {
+			value := []byte(fmt.Sprintf("value%04d", i))
+			_ = s.Put([]byte(fmt.Sprintf("first%d", i)), value)
+			_ = s.Put([]byte(fmt.Sprintf("second%d", i)), value)
+		}
+
+		stats, err := s.DuplicateReport(blake2b.NewBlake2b(), 0.5)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2*numValues), stats.NumScanned)
+		assert.Equal(t, 2*stats.NumDuplicateGroups, stats.NumSampled)
+		assert.Equal(t, stats.NumDuplicateGroups, stats.NumDuplicateValues)
+		assert.InDelta(t, numValues, stats.EstimatedDuplicateGroups, float64(numValues)/10)
+		assert.InDelta(t, numValues*9, stats.EstimatedReclaimableBytes, float64(numValues*9)/10)
+	})
+}
+
+func TestCacheVerification(t *testing.T) {
+	t.Parallel()
+
+	createUnit := func(samplingInterval uint64, onMismatch storageUnit.CacheMismatchHandler) (*storageUnit.Unit, types.Persister) {
+		mdb := memorydb.New()
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, mdb, storageUnit.WithCacheVerification(samplingInterval, onMismatch))
+		_ = s.Put([]byte("key"), []byte("value"))
+
+		return s, mdb
+	}
+
+	t.Run("matching values should not call the handler", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := createUnit(1, func(_ []byte, _ []byte, _ []byte, _ bool) bool {
+			assert.Fail(t, "should not have been called")
+			return false
+		})
+
+		value, err := s.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+	})
+	t.Run("mismatch should be corrected if the handler requests it", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		s, mdb := createUnit(1, func(key []byte, cachedValue []byte, persistedValue []byte, exists bool) bool {
+			numCalls++
+			assert.Equal(t, []byte("key"), key)
+			assert.Equal(t, []byte("value"), cachedValue)
+			assert.Equal(t, []byte("modified"), persistedValue)
+			assert.True(t, exists)
+			return true
+		})
+		_ = mdb.Put([]byte("key"), []byte("modified"))
+
+		for i := 0; i < 2; i++ {
+			value, err := s.Get([]byte("key"))
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("modified"), value)
+		}
+		assert.Equal(t, 1, numCalls)
+	})
+	t.Run("mismatch should be kept if the handler does not request the correction", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		s, mdb := createUnit(1, func(_ []byte, _ []byte, _ []byte, _ bool) bool {
+			numCalls++
+			return false
+		})
+		_ = mdb.Put([]byte("key"), []byte("modified"))
+
+		value, err := s.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+		assert.Equal(t, 1, numCalls)
+	})
+	t.Run("key removed from the persister should be removed from the cache", func(t *testing.T) {
+		t.Parallel()
+
+		s, mdb := createUnit(1, func(_ []byte, _ []byte, persistedValue []byte, exists bool) bool {
+			assert.Nil(t, persistedValue)
+			assert.False(t, exists)
+			return true
+		})
+		_ = mdb.Remove([]byte("key"))
+
+		_, err := s.Get([]byte("key"))
+		assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+		assert.NotNil(t, s.Has([]byte("key")))
+	})
+	t.Run("only the sampled hits should be verified", func(t *testing.T) {
+		t.Parallel()
+
+		numCalls := 0
+		s, mdb := createUnit(3, func(_ []byte, _ []byte, _ []byte, _ bool) bool {
+			numCalls++
+			return false
+		})
+		_ = mdb.Put([]byte("key"), []byte("modified"))
+
+		for i := 0; i < 9; i++ {
+			_, _ = s.Get([]byte("key"))
+		}
+		assert.Equal(t, 3, numCalls)
+	})
+}
+
+func TestCountPrefixNotSupported(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, testscommon.NewMemDbMock())
+
+	count, err := s.CountPrefix([]byte("prefix"))
+	assert.Equal(t, uint64(0), count)
+	assert.Equal(t, common.ErrPrefixCountNotSupported, err)
+}
+
+func TestCountPrefixShouldCountPersistedKeys(t *testing.T) {
+	s := initStorageUnit(t, 10)
+	_ = s.Put([]byte("prefix_1"), []byte("value"))
+	_ = s.Put([]byte("prefix_2"), []byte("value"))
+	_ = s.Put([]byte("other"), []byte("value"))
+
+	count, err := s.CountPrefix([]byte("prefix_"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), count)
+}
+
+func TestEntryStatsNotSupported(t *testing.T) {
+	s := initStorageUnit(t, 10)
+
+	live, deleted, err := s.EntryStats()
+	assert.Equal(t, uint64(0), live)
+	assert.Equal(t, uint64(0), deleted)
+	assert.Equal(t, common.ErrEntryStatsNotSupported, err)
+}
+
+func TestEntryStatsShouldReportThePersisterStats(t *testing.T) {
+	ldb, err := leveldb.NewDB(t.TempDir(), 10, 1, 10)
+	assert.Nil(t, err)
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, ldb)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	_ = s.Put([]byte("key1"), []byte("value"))
+	_ = s.Put([]byte("key2"), []byte("value"))
+
+	live, deleted, err := s.EntryStats()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), live)
+	assert.Equal(t, uint64(0), deleted)
+}
+
+func TestPrefixDiskSizeNotSupported(t *testing.T) {
+	s := initStorageUnit(t, 10)
+
+	size, err := s.PrefixDiskSize([]byte("prefix"))
+	assert.Equal(t, uint64(0), size)
+	assert.Equal(t, common.ErrPrefixDiskSizeNotSupported, err)
+}
+
+func TestPrefixDiskSizeShouldReportThePersisterEstimation(t *testing.T) {
+	ldb, err := leveldb.NewDB(t.TempDir(), 10, 1, 10)
+	assert.Nil(t, err)
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, ldb)
+
+	_ = s.Put([]byte("key1"), []byte("value"))
+
+	size, err := s.PrefixDiskSize([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), size)
+
+	_ = s.Close()
+	_, err = s.PrefixDiskSize([]byte("key"))
+	assert.Equal(t, common.ErrUnitClosed, err)
+}
+
+func TestWarmPrefixNotSupported(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, testscommon.NewMemDbMock())
+
+	loaded, err := s.WarmPrefix([]byte("prefix"), 10)
+	assert.Equal(t, 0, loaded)
+	assert.Equal(t, common.ErrPrefixRangeNotSupported, err)
+}
+
+func TestWarmPrefixShouldLoadThePrefixedKeysInCache(t *testing.T) {
+	ldb, err := leveldb.NewDB(t.TempDir(), 10, 100, 10)
+	assert.Nil(t, err)
+	for i := 0; i < 5; i++ {
+		_ = ldb.Put([]byte(fmt.Sprintf("prefix_%d", i)), []byte("value"))
+	}
+	_ = ldb.Put([]byte("other"), []byte("value"))
+
+	cache, _ := lrucache.NewCache(3)
+	s, _ := storageUnit.NewStorageUnit(cache, ldb)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	loaded, err := s.WarmPrefix([]byte("prefix_"), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, loaded)
+	assert.True(t, cache.Has([]byte("prefix_0")))
+	assert.True(t, cache.Has([]byte("prefix_1")))
+
+	// the already cached keys are skipped and the cache capacity bounds the loaded keys
+	loaded, err = s.WarmPrefix([]byte("prefix_"), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, loaded)
+	assert.False(t, cache.Has([]byte("other")))
+	assert.Equal(t, 3, cache.Len())
+	assert.True(t, cache.Has([]byte("prefix_4")))
+}
+
+func TestTruncateUnitNotSupported(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, testscommon.NewMemDbMock())
+
+	err := s.TruncateUnit()
+	assert.Equal(t, common.ErrTruncateNotSupported, err)
+}
+
+func TestTruncateUnitShouldKeepUnitUsable(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	ldb, err := leveldb.NewDB(t.TempDir(), 10, 1, 10)
+	assert.Nil(t, err)
+	s, _ := storageUnit.NewStorageUnit(cache, ldb)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	_ = s.Put([]byte("key1"), []byte("value1"))
+	_ = s.Put([]byte("key2"), []byte("value2"))
+
+	err = s.TruncateUnit()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cache.Len())
+	assert.NotNil(t, s.Has([]byte("key1")))
+	assert.NotNil(t, s.Has([]byte("key2")))
+
+	err = s.Put([]byte("key1"), []byte("new value"))
+	assert.Nil(t, err)
+	s.ClearCache()
+	val, err := s.Get([]byte("key1"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("new value"), val)
+}
+
+func TestStorageUnitWithLoggerShouldUseInjectedLogger(t *testing.T) {
+	expectedErr := errors.New("expected error")
+	numWarnings := 0
+	unitLogger := &testscommon.LoggerStub{
+		WarnCalled: func(message string, args ...interface{}) {
+			numWarnings++
+		},
+	}
+	persister := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			return nil, expectedErr
+		},
+	}
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister, storageUnit.WithLogger(unitLogger))
+
+	results, err := s.GetBulkFromEpoch([][]byte{[]byte("key1"), []byte("key2")}, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 2, numWarnings)
+}
+
+func TestStorageUnitWithNilLoggerShouldUseDefaultLogger(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithLogger(nil))
+
+	results, err := s.GetBulkFromEpoch([][]byte{[]byte("key")}, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSnapshotNotSupported(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, testscommon.NewMemDbMock())
+
+	snapshot, err := s.Snapshot()
+	assert.Nil(t, snapshot)
+	assert.Equal(t, common.ErrSnapshotNotSupported, err)
+}
+
+func TestSnapshotShouldNotSeeLaterWrites(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	ldb, err := leveldb.NewDB(t.TempDir(), 10, 10, 10)
+	assert.Nil(t, err)
+	s, _ := storageUnit.NewStorageUnit(cache, ldb)
+	defer func() {
+		_ = s.Close()
+	}()
+
+	_ = s.Put([]byte("key"), []byte("value"))
+	snapshot, err := s.Snapshot()
+	assert.Nil(t, err)
+	defer snapshot.Release()
+
+	_ = s.Put([]byte("key"), []byte("changed"))
+
+	val, err := snapshot.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), val)
+
+	val, err = s.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("changed"), val)
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("nil snapshot should error", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+
+		added, removed, err := s.Diff(nil)
+		assert.Nil(t, added)
+		assert.Nil(t, removed)
+		assert.Equal(t, common.ErrNilSnapshot, err)
+	})
+	t.Run("snapshot not supported should error", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, testscommon.NewMemDbMock())
+
+		old, _ := memorydb.New().Snapshot()
+		added, removed, err := s.Diff(old)
+		assert.Nil(t, added)
+		assert.Nil(t, removed)
+		assert.Equal(t, common.ErrSnapshotNotSupported, err)
+	})
+	t.Run("no changes should return empty lists", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		_ = s.Put([]byte("key"), []byte("value"))
+
+		old, _ := s.Snapshot()
+		defer old.Release()
+
+		added, removed, err := s.Diff(old)
+		assert.Nil(t, err)
+		assert.Empty(t, added)
+		assert.Empty(t, removed)
+	})
+	t.Run("should return added, changed and removed keys", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		ldb, err := leveldb.NewDB(t.TempDir(), 10, 10, 10)
+		assert.Nil(t, err)
+		s, _ := storageUnit.NewStorageUnit(cache, ldb)
+		defer func() {
+			_ = s.Close()
+		}()
+
+		_ = s.Put([]byte("unchanged"), []byte("value"))
+		_ = s.Put([]byte("changed"), []byte("value"))
+		_ = s.Put([]byte("removed"), []byte("value"))
+		old, err := s.Snapshot()
+		assert.Nil(t, err)
+		defer old.Release()
+
+		_ = s.Put([]byte("changed"), []byte("new value"))
+		_ = s.Remove([]byte("removed"))
+		_ = s.Put([]byte("added2"), []byte("value"))
+		_ = s.Put([]byte("added1"), []byte("value"))
+
+		added, removed, err := s.Diff(old)
+		assert.Nil(t, err)
+		assert.Equal(t, [][]byte{[]byte("added1"), []byte("added2"), []byte("changed")}, added)
+		assert.Equal(t, [][]byte{[]byte("removed")}, removed)
+
+		val, err := old.Get([]byte("removed"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), val)
+	})
+}
+
+func TestValueSizeHistogram(t *testing.T) {
+	t.Run("disabled sampling should return nil", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		_ = s.Put([]byte("key"), []byte("value"))
+
+		assert.Nil(t, s.ValueSizeHistogram())
+	})
+	t.Run("zero sampling interval should leave the sampling disabled", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithValueSizeSampling([]uint64{10}, 0))
+		_ = s.Put([]byte("key"), []byte("value"))
+
+		assert.Nil(t, s.ValueSizeHistogram())
+	})
+	t.Run("should record the value sizes in buckets", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		bounds := []uint64{100, 10, 0, 10}
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithValueSizeSampling(bounds, 1))
+
+		_ = s.Put([]byte("key1"), make([]byte, 0))
+		_ = s.Put([]byte("key2"), make([]byte, 9))
+		_ = s.Put([]byte("key3"), make([]byte, 10))
+		_ = s.Put([]byte("key4"), make([]byte, 1000))
+
+		expected := map[storageUnit.SizeBucket]uint64{
+			{LowerBound: 0, UpperBound: 10}:               2,
+			{LowerBound: 10, UpperBound: 100}:             1,
+			{LowerBound: 100, UpperBound: math.MaxUint64}: 1,
+		}
+		assert.Equal(t, expected, s.ValueSizeHistogram())
+	})
+	t.Run("should only record the sampled puts", func(t *testing.T) {
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithValueSizeSampling(nil, 4))
+
+		for i := 0; i < 100; i++ {
+			_ = s.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		}
+
+		expected := map[storageUnit.SizeBucket]uint64{
+			{LowerBound: 0, UpperBound: math.MaxUint64}: 25,
+		}
+		assert.Equal(t, expected, s.ValueSizeHistogram())
+	})
+}
+
+func TestPutIf(t *testing.T) {
+	t.Run("nil condition should error", func(t *testing.T) {
+		s := initStorageUnit(t, 10)
+
+		written, err := s.PutIf([]byte("key"), []byte("value"), nil)
+		assert.False(t, written)
+		assert.Equal(t, common.ErrNilCondition, err)
+	})
+	t.Run("persister error should be returned", func(t *testing.T) {
+		expectedErr := errors.New("expected error")
+		cache, _ := lrucache.NewCache(10)
+		persister := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				return nil, expectedErr
+			},
+		}
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+		written, err := s.PutIf([]byte("key"), []byte("value"), func(_ []byte, _ bool) bool {
+			assert.Fail(t, "condition should not be called")
+			return true
+		})
+		assert.False(t, written)
+		assert.Equal(t, expectedErr, err)
+	})
+	t.Run("should write only if the condition holds", func(t *testing.T) {
+		s := initStorageUnit(t, 10)
+		key := []byte("key")
+		isNewerVersion := func(newValue []byte) func(oldValue []byte, exists bool) bool {
+			return func(oldValue []byte, exists bool) bool {
+				return !exists || bytes.Compare(oldValue, newValue) < 0
+			}
+		}
+
+		written, err := s.PutIf(key, []byte("v2"), isNewerVersion([]byte("v2")))
+		assert.Nil(t, err)
+		assert.True(t, written)
+
+		written, err = s.PutIf(key, []byte("v1"), isNewerVersion([]byte("v1")))
+		assert.Nil(t, err)
+		assert.False(t, written)
+		val, _ := s.Get(key)
+		assert.Equal(t, []byte("v2"), val)
+
+		written, err = s.PutIf(key, []byte("v3"), isNewerVersion([]byte("v3")))
+		assert.Nil(t, err)
+		assert.True(t, written)
+		val, _ = s.Get(key)
+		assert.Equal(t, []byte("v3"), val)
+	})
+	t.Run("concurrent conditional increments should not be lost", func(t *testing.T) {
+		s := initStorageUnit(t, 10)
+		key := []byte("counter")
+		_ = s.Put(key, []byte("0"))
+
+		numIncrements := 50
+		wg := sync.WaitGroup{}
+		wg.Add(numIncrements)
+		for i := 0; i < numIncrements; i++ {
+			go func() {
+				defer wg.Done()
+
+				for {
+					current, _ := s.Get(key)
+					counter, _ := strconv.Atoi(string(current))
+					next := []byte(strconv.Itoa(counter + 1))
+					written, err := s.PutIf(key, next, func(oldValue []byte, _ bool) bool {
+						return bytes.Equal(oldValue, current)
+					})
+					assert.Nil(t, err)
+					if written {
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		val, _ := s.Get(key)
+		assert.Equal(t, []byte(strconv.Itoa(numIncrements)), val)
+	})
+}
+
+func TestPutReportingChange(t *testing.T) {
+	t.Parallel()
+
+	numPuts := 0
+	cache, _ := lrucache.NewCache(10)
+	persister := &testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			numPuts++
+			return nil
+		},
+		GetCalled: func(key []byte) ([]byte, error) {
+			return nil, common.ErrKeyNotFound
+		},
+	}
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+	key := []byte("key")
+
+	changed, err := s.PutReportingChange(key, []byte("value"))
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 1, numPuts)
+
+	changed, err = s.PutReportingChange(key, []byte("value"))
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 1, numPuts)
+
+	changed, err = s.PutReportingChange(key, []byte("other value"))
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, numPuts)
+	val, _ := s.Get(key)
+	assert.Equal(t, []byte("other value"), val)
+
+	changed, err = s.PutReportingChange([]byte("empty"), []byte{})
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 3, numPuts)
+}
+
+func TestGetExisting(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should read the cache then the persister in a single batch", func(t *testing.T) {
+		t.Parallel()
+
+		persister := memorydb.New()
+		_ = persister.Put([]byte("persisted"), []byte("persisted value"))
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+		_ = s.Put([]byte("cached"), []byte("cached value"))
+
+		values, err := s.GetExisting([][]byte{[]byte("cached"), []byte("persisted"), []byte("missing")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{
+			"cached":    []byte("cached value"),
+			"persisted": []byte("persisted value"),
+		}, values)
+		assert.True(t, cache.Has([]byte("persisted")))
+		assert.False(t, cache.Has([]byte("missing")))
+	})
+	t.Run("persister without batched reads should be read key by key", func(t *testing.T) {
+		t.Parallel()
+
+		readKeys := make([]string, 0)
+		persister := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				readKeys = append(readKeys, string(key))
+				if string(key) == "persisted" {
+					return []byte("persisted value"), nil
+				}
+				return nil, common.NewStorageError(common.OpGet, key, "stub", common.ErrKeyNotFound)
+			},
+			PutCalled: func(key, val []byte) error {
+				return nil
+			},
+		}
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+		_ = s.Put([]byte("cached"), []byte("cached value"))
+
+		values, err := s.GetExisting([][]byte{[]byte("cached"), []byte("persisted"), []byte("missing")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{
+			"cached":    []byte("cached value"),
+			"persisted": []byte("persisted value"),
+		}, values)
+		assert.Equal(t, []string{"persisted", "missing"}, readKeys)
+	})
+	t.Run("persister errors should be returned", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		persister := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				return nil, expectedErr
+			},
+		}
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+		values, err := s.GetExisting([][]byte{[]byte("key")})
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, values)
+	})
+}
+
+func TestGetConsistent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should return the values in order", func(t *testing.T) {
+		t.Parallel()
+
+		persister := memorydb.New()
+		_ = persister.Put([]byte("persisted"), []byte("persisted value"))
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+		_ = s.Put([]byte("cached"), []byte("cached value"))
+
+		values, err := s.GetConsistent([][]byte{[]byte("persisted"), []byte("cached")})
+		assert.Nil(t, err)
+		assert.Equal(t, [][]byte{[]byte("persisted value"), []byte("cached value")}, values)
+		assert.True(t, cache.Has([]byte("persisted")))
+	})
+	t.Run("missing key should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		_ = s.Put([]byte("key"), []byte("value"))
+
+		values, err := s.GetConsistent([][]byte{[]byte("key"), []byte("missing")})
+		assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+		assert.Nil(t, values)
+	})
+	t.Run("closed unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		_ = s.Close()
+
+		values, err := s.GetConsistent([][]byte{[]byte("key")})
+		assert.Equal(t, common.ErrUnitClosed, err)
+		assert.Nil(t, values)
+	})
+	t.Run("writes should not interleave with the reads", func(t *testing.T) {
+		t.Parallel()
+
+		db := memorydb.New()
+		_ = db.Put([]byte("a"), []byte("a0"))
+		_ = db.Put([]byte("b"), []byte("b0"))
+		readStarted := make(chan struct{})
+		releaseRead := make(chan struct{})
+		persister := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				if string(key) == "a" {
+					close(readStarted)
+					<-releaseRead
+				}
+				return db.Get(key)
+			},
+			PutCalled: db.Put,
+		}
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+		chValues := make(chan [][]byte, 1)
+		go func() {
+			values, _ := s.GetConsistent([][]byte{[]byte("a"), []byte("b")})
+			chValues <- values
+		}()
+		<-readStarted
+
+		chPutDone := make(chan struct{})
+		go func() {
+			_ = s.Put([]byte("b"), []byte("b1"))
+			close(chPutDone)
+		}()
+
+		select {
+		case <-chPutDone:
+			assert.Fail(t, "put should wait for the consistent read")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(releaseRead)
+		assert.Equal(t, [][]byte{[]byte("a0"), []byte("b0")}, <-chValues)
+		<-chPutDone
+		value, _ := s.Get([]byte("b"))
+		assert.Equal(t, []byte("b1"), value)
+	})
+}
+
+func TestGetNoCacheFill(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should not fill the cache", func(t *testing.T) {
+		t.Parallel()
+
+		persister := memorydb.New()
+		_ = persister.Put([]byte("persisted"), []byte("persisted value"))
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+		_ = s.Put([]byte("cached"), []byte("cached value"))
+
+		value, err := s.GetNoCacheFill([]byte("persisted"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("persisted value"), value)
+		assert.False(t, cache.Has([]byte("persisted")))
+
+		value, err = s.GetNoCacheFill([]byte("cached"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("cached value"), value)
+
+		_, err = s.GetNoCacheFill([]byte("missing"))
+		assert.True(t, errors.Is(err, common.ErrKeyNotFound))
+	})
+	t.Run("cached object should be read from the persister", func(t *testing.T) {
+		t.Parallel()
+
+		marshalizer := &testscommon.MarshalizerMock{}
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithMarshalizer(marshalizer))
+		obj := &testObject{Name: "name", Value: 37}
+		_ = s.PutObject([]byte("key"), obj)
+
+		value, err := s.GetNoCacheFill([]byte("key"))
+		assert.Nil(t, err)
+		expectedBuff, _ := marshalizer.Marshal(obj)
+		assert.Equal(t, expectedBuff, value)
+		cached, _ := cache.Peek([]byte("key"))
+		assert.Equal(t, obj, cached)
+	})
+	t.Run("closed unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+		_ = s.Close()
+
+		_, err := s.GetNoCacheFill([]byte("key"))
+		assert.Equal(t, common.ErrUnitClosed, err)
+	})
+}
+
+func TestWithReadCachingDisabled(t *testing.T) {
+	t.Parallel()
+
+	persister := memorydb.New()
+	marshalizer := &testscommon.MarshalizerMock{}
+	buff, _ := marshalizer.Marshal(&testObject{Name: "name", Value: 37})
+	_ = persister.Put([]byte("a"), []byte("a value"))
+	_ = persister.Put([]byte("b"), []byte("b value"))
+	_ = persister.Put([]byte("object"), buff)
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister,
+		storageUnit.WithMarshalizer(marshalizer),
+		storageUnit.WithReadCachingDisabled(),
+	)
+
+	value, err := s.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("a value"), value)
+
+	values, err := s.GetExisting([][]byte{[]byte("b")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"b": []byte("b value")}, values)
+
+	consistent, err := s.GetConsistent([][]byte{[]byte("a"), []byte("b")})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("a value"), []byte("b value")}, consistent)
+
+	recovered := &testObject{}
+	err = s.GetObject([]byte("object"), recovered)
+	assert.Nil(t, err)
+	assert.Equal(t, &testObject{Name: "name", Value: 37}, recovered)
+
+	assert.Equal(t, 0, cache.Len())
+
+	_ = s.Put([]byte("written"), []byte("written value"))
+	assert.True(t, cache.Has([]byte("written")))
+}
+
+func TestVerifyReferences(t *testing.T) {
+	extractRef := func(indexValue []byte) []byte {
+		return indexValue
+	}
+
+	t.Run("nil unit should error", func(t *testing.T) {
+		danglingKey, err := storageUnit.VerifyReferences(nil, []byte("idx_"), []byte("data_"), extractRef)
+		assert.Nil(t, danglingKey)
+		assert.Equal(t, storageUnit.ErrNilStorageUnit, err)
+	})
+	t.Run("nil extractor should error", func(t *testing.T) {
+		danglingKey, err := storageUnit.VerifyReferences(initStorageUnit(t, 10), []byte("idx_"), []byte("data_"), nil)
+		assert.Nil(t, danglingKey)
+		assert.Equal(t, storageUnit.ErrNilReferenceExtractor, err)
+	})
+	t.Run("consistent references should return nil", func(t *testing.T) {
+		s := initStorageUnit(t, 10)
+		_ = s.Put([]byte("idx_1"), []byte("a"))
+		_ = s.Put([]byte("idx_2"), []byte("b"))
+		_ = s.Put([]byte("data_a"), []byte("value a"))
+		_ = s.Put([]byte("data_b"), []byte("value b"))
+		_ = s.Put([]byte("other"), []byte("c"))
+
+		danglingKey, err := storageUnit.VerifyReferences(s, []byte("idx_"), []byte("data_"), extractRef)
+		assert.Nil(t, err)
+		assert.Nil(t, danglingKey)
+	})
+	t.Run("dangling reference should be returned", func(t *testing.T) {
+		s := initStorageUnit(t, 10)
+		_ = s.Put([]byte("idx_1"), []byte("a"))
+		_ = s.Put([]byte("idx_2"), []byte("missing"))
+		_ = s.Put([]byte("data_a"), []byte("value a"))
+
+		danglingKey, err := storageUnit.VerifyReferences(s, []byte("idx_"), []byte("data_"), extractRef)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("data_missing"), danglingKey)
+	})
+	t.Run("backend error should be returned", func(t *testing.T) {
+		expectedErr := errors.New("expected error")
+		persister := &testscommon.PersisterStub{
+			RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+				handler([]byte("idx_1"), []byte("a"))
+			},
+			HasCalled: func(key []byte) error {
+				return expectedErr
+			},
+		}
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+		danglingKey, err := storageUnit.VerifyReferences(s, []byte("idx_"), []byte("data_"), extractRef)
+		assert.Nil(t, danglingKey)
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestApplyBatchNotSupported(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, testscommon.NewMemDbMock())
+
+	err := s.ApplyBatch([]types.Operation{{Type: types.PutOperation, Key: []byte("key"), Value: []byte("value")}})
+	assert.Equal(t, common.ErrBatchNotSupported, err)
+}
+
+func TestApplyBatchShouldUpdateCacheAfterPersister(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	mdb := memorydb.New()
+	s, _ := storageUnit.NewStorageUnit(cache, mdb)
+
+	err := s.Put([]byte("removed"), []byte("removed value"))
+	assert.Nil(t, err)
+
+	ops := []types.Operation{
+		{Type: types.PutOperation, Key: []byte("key"), Value: []byte("value")},
+		{Type: types.RemoveOperation, Key: []byte("removed")},
+	}
+	err = s.ApplyBatch(ops)
+	assert.Nil(t, err)
+
+	assert.True(t, cache.Has([]byte("key")))
+	assert.False(t, cache.Has([]byte("removed")))
+	assert.Nil(t, mdb.Has([]byte("key")))
+	assert.NotNil(t, mdb.Has([]byte("removed")))
+}
+
+func TestApplyBatchFailureShouldNotTouchCache(t *testing.T) {
+	expectedErr := errors.New("expected error")
+	cache, _ := lrucache.NewCache(10)
+	persister := &testscommon.BatchPersisterStub{
+		ApplyBatchCalled: func(ops []types.Operation) error {
+			return expectedErr
+		},
+	}
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+	cache.Put([]byte("removed"), []byte("removed value"), 13)
+
+	ops := []types.Operation{
+		{Type: types.PutOperation, Key: []byte("key"), Value: []byte("value")},
+		{Type: types.RemoveOperation, Key: []byte("removed")},
+	}
+	err := s.ApplyBatch(ops)
+	assert.Equal(t, expectedErr, err)
+	assert.False(t, cache.Has([]byte("key")))
+	assert.True(t, cache.Has([]byte("removed")))
+}
+
+type testObject struct {
+	Name  string
+	Value int
+}
+
+func TestPutObjectGetObjectWithoutMarshalizerShouldErr(t *testing.T) {
+	s := initStorageUnit(t, 10)
+
+	err := s.PutObject([]byte("key"), &testObject{})
+	assert.Equal(t, common.ErrNilMarshalizer, err)
+
+	err = s.GetObject([]byte("key"), &testObject{})
+	assert.Equal(t, common.ErrNilMarshalizer, err)
+}
+
+func TestGetObjectInvalidDestinationShouldErr(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithMarshalizer(&testscommon.MarshalizerMock{}))
+
+	err := s.GetObject([]byte("key"), testObject{})
+	assert.Equal(t, common.ErrInvalidObjectDestination, err)
+
+	var nilDestination *testObject
+	err = s.GetObject([]byte("key"), nilDestination)
+	assert.Equal(t, common.ErrInvalidObjectDestination, err)
+}
+
+func TestPutObjectGetObjectShouldServeDecodedObjectFromCache(t *testing.T) {
+	marshalizer := &testscommon.MarshalizerMock{}
+	cache, _ := lrucache.NewCache(10)
+	mdb := memorydb.New()
+	s, _ := storageUnit.NewStorageUnit(cache, mdb, storageUnit.WithMarshalizer(marshalizer))
+
+	key := []byte("key")
+	obj := &testObject{Name: "name", Value: 37}
+	err := s.PutObject(key, obj)
+	assert.Nil(t, err)
+
+	cached, ok := cache.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, obj, cached)
+
+	persisted, err := mdb.Get(key)
+	assert.Nil(t, err)
+	expectedBuff, _ := marshalizer.Marshal(obj)
+	assert.Equal(t, expectedBuff, persisted)
+
+	// a failing marshalizer proves the object is served from the cache without unmarshalling
+	marshalizer.Fail = true
+	recovered := &testObject{}
+	err = s.GetObject(key, recovered)
+	assert.Nil(t, err)
+	assert.Equal(t, obj, recovered)
+	marshalizer.Fail = false
+
+	// plain Get should still return the encoded value
+	val, err := s.Get(key)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedBuff, val)
+}
+
+func TestGetObjectNotInCacheShouldDecodeAndCache(t *testing.T) {
+	marshalizer := &testscommon.MarshalizerMock{}
+	cache, _ := lrucache.NewCache(10)
+	mdb := memorydb.New()
+	s, _ := storageUnit.NewStorageUnit(cache, mdb, storageUnit.WithMarshalizer(marshalizer))
+
+	key := []byte("key")
+	obj := &testObject{Name: "name", Value: 37}
+	buff, _ := marshalizer.Marshal(obj)
+	_ = mdb.Put(key, buff)
+
+	recovered := &testObject{}
+	err := s.GetObject(key, recovered)
+	assert.Nil(t, err)
+	assert.Equal(t, obj, recovered)
+
+	cached, ok := cache.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, obj, cached)
+
+	// the cached object is a copy, changing the destination should not alter it
+	recovered.Value = 0
+	cached, _ = cache.Get(key)
+	assert.Equal(t, obj, cached)
+
+	err = s.GetObject([]byte("missing"), &testObject{})
+	assert.NotNil(t, err)
+}
+
+func TestPutObjectMarshalFailureShouldErr(t *testing.T) {
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithMarshalizer(&testscommon.MarshalizerMock{Fail: true}))
+
+	err := s.PutObject([]byte("key"), &testObject{})
+	assert.NotNil(t, err)
+	assert.False(t, cache.Has([]byte("key")))
+}
+
 func TestCreateCacheFromConfWrongType(t *testing.T) {
 
 	cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: "NotLRU", Capacity: 100, Shards: 1, SizeInBytes: 0})
@@ -254,6 +1713,96 @@ func TestCreateCacheFromConfOK(t *testing.T) {
 	assert.NotNil(t, cacher, "valid cacher expected but got nil")
 }
 
+func TestCreateCacheFromConfShardedSizeLRU(t *testing.T) {
+	cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.ShardedSizeLRUCache, Capacity: 100, Shards: 0, SizeInBytes: 4096})
+	assert.Equal(t, common.ErrCacheShardsInvalid, err)
+	assert.Nil(t, cacher)
+
+	cacher, err = storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.ShardedSizeLRUCache, Capacity: 100, Shards: 4, SizeInBytes: 100})
+	assert.ErrorIs(t, err, common.ErrLRUCacheInvalidSize)
+	assert.Nil(t, cacher)
+
+	cacher, err = storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.ShardedSizeLRUCache, Capacity: 100, Shards: 4, SizeInBytes: 4096})
+	assert.Nil(t, err)
+	assert.NotNil(t, cacher)
+	assert.Equal(t, 100, cacher.MaxSize())
+}
+
+func TestCreateCacheFromConfWithPerEntryOverhead(t *testing.T) {
+	cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.SizeLRUCache, Capacity: 100, SizeInBytes: 4096, PerEntryOverheadBytes: -1})
+	assert.Equal(t, common.ErrInvalidPerEntryOverhead, err)
+	assert.Nil(t, cacher)
+
+	cacher, err = storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.SizeLRUCache, Capacity: 100, SizeInBytes: 4096, PerEntryOverheadBytes: 16})
+	assert.Nil(t, err)
+	cacher.Put([]byte("key"), []byte("value"), 5)
+	assert.Equal(t, uint64(3+16+5), cacher.SizeInBytesContained())
+
+	cacher, err = storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.ShardedSizeLRUCache, Capacity: 100, Shards: 4, SizeInBytes: 4096, PerEntryOverheadBytes: 16})
+	assert.Nil(t, err)
+	cacher.Put([]byte("key"), []byte("value"), 5)
+	assert.Equal(t, uint64(3+16+5), cacher.SizeInBytesContained())
+}
+
+func TestStorageUnit_OversizedValueShouldNotEvictHotEntries(t *testing.T) {
+	cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{
+		Type:                storageUnit.SizeLRUCache,
+		Capacity:            100,
+		SizeInBytes:         4096,
+		MaxEntrySizeInBytes: 1024,
+	})
+	assert.Nil(t, err)
+
+	persister := memorydb.New()
+	s, _ := storageUnit.NewStorageUnit(cacher, persister)
+	hotKeys := [][]byte{[]byte("hot1"), []byte("hot2"), []byte("hot3")}
+	for _, key := range hotKeys {
+		assert.Nil(t, s.Put(key, make([]byte, 512)))
+	}
+
+	largeKey := []byte("large")
+	largeValue := make([]byte, 4000)
+	assert.Nil(t, s.Put(largeKey, largeValue))
+
+	for _, key := range hotKeys {
+		assert.True(t, cacher.Has(key))
+	}
+	assert.False(t, cacher.Has(largeKey))
+	assert.Nil(t, persister.Has(largeKey))
+
+	recovered, err := s.Get(largeKey)
+	assert.Nil(t, err)
+	assert.Equal(t, largeValue, recovered)
+	assert.False(t, cacher.Has(largeKey))
+}
+
+func TestCreateCacheFromConfWithEvictionPolicy(t *testing.T) {
+	t.Run("unknown policy should error", func(t *testing.T) {
+		cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.LRUCache, Capacity: 10, EvictionPolicy: "unknown"})
+		assert.Nil(t, cacher)
+		assert.True(t, errors.Is(err, common.ErrNotSupportedEvictionPolicy))
+	})
+	t.Run("incompatible policy should error", func(t *testing.T) {
+		cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.FIFOShardedCache, Capacity: 10, Shards: 2, EvictionPolicy: storageUnit.RandomPolicy})
+		assert.Nil(t, cacher)
+		assert.True(t, errors.Is(err, common.ErrIncompatibleEvictionPolicy))
+
+		cacher, err = storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.LRUCache, Capacity: 10, EvictionPolicy: storageUnit.TTLPolicy})
+		assert.Nil(t, cacher)
+		assert.True(t, errors.Is(err, common.ErrIncompatibleEvictionPolicy))
+	})
+	t.Run("random policy should work", func(t *testing.T) {
+		cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.LRUCache, Capacity: 10, EvictionPolicy: storageUnit.RandomPolicy})
+		assert.Nil(t, err)
+		assert.Equal(t, "*randomcache.randomCache", fmt.Sprintf("%T", cacher))
+	})
+	t.Run("default policy of the cache type should work", func(t *testing.T) {
+		cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.LRUCache, Capacity: 10, EvictionPolicy: storageUnit.LRUPolicy})
+		assert.Nil(t, err)
+		assert.Equal(t, "*lrucache.lruCache", fmt.Sprintf("%T", cacher))
+	})
+}
+
 func TestCreateDBFromConfWrongType(t *testing.T) {
 	persisterFactory := testscommon.NewPersisterFactoryHandlerMock(
 		"NotLvlDB",
@@ -302,6 +1851,35 @@ func TestCreateDBFromConfLvlDBOk(t *testing.T) {
 	assert.Nil(t, err, "no error expected destroying the persister")
 }
 
+func TestNewDBWithTimeout_AlwaysFailingFactoryShouldStopAtTheTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NewDBWithTimeout", func(t *testing.T) {
+		t.Parallel()
+
+		persisterFactory := testscommon.NewPersisterFactoryHandlerMock("NotLvlDB", 0, 0, 0)
+		start := time.Now()
+		persister, err := storageUnit.NewDBWithTimeout(persisterFactory, t.TempDir(), time.Second)
+		assert.Nil(t, persister)
+		assert.True(t, errors.Is(err, common.ErrOpenTimeout))
+		assert.True(t, errors.Is(err, common.ErrNotSupportedDBType))
+		assert.Less(t, time.Since(start), storageUnit.SleepTimeBetweenCreateDBRetries)
+	})
+	t.Run("NewStorageUnitFromConf", func(t *testing.T) {
+		t.Parallel()
+
+		start := time.Now()
+		storer, err := storageUnit.NewStorageUnitFromConf(
+			storageUnit.CacheConfig{Capacity: 10, Type: storageUnit.LRUCache},
+			storageUnit.DBConfig{FilePath: t.TempDir(), Type: "NotLvlDB", OpenTimeout: time.Second},
+			testscommon.NewPersisterFactoryHandlerMock("NotLvlDB", 0, 0, 0),
+		)
+		assert.Nil(t, storer)
+		assert.True(t, errors.Is(err, common.ErrOpenTimeout))
+		assert.Less(t, time.Since(start), storageUnit.SleepTimeBetweenCreateDBRetries)
+	})
+}
+
 func TestNewStorageUnit_FromConfWrongCacheSizeVsBatchSize(t *testing.T) {
 
 	storer, err := storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
@@ -375,6 +1953,421 @@ func TestNewStorageUnit_FromConfLvlDBOk(t *testing.T) {
 	assert.Nil(t, err, "no error expected destroying the persister")
 }
 
+func TestNewStorageUnit_FromConfWithMaxConcurrentOpsOk(t *testing.T) {
+	storer, err := storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
+		Capacity: 10,
+		Type:     storageUnit.LRUCache,
+	}, storageUnit.DBConfig{
+		FilePath:                   "Blocks",
+		Type:                       storageUnit.LvlDB,
+		MaxBatchSize:               1,
+		BatchDelaySeconds:          1,
+		MaxOpenFiles:               10,
+		MaxConcurrentOps:           2,
+		FailFastOnMaxConcurrentOps: true,
+	},
+		testscommon.NewPersisterFactoryHandlerMock(storageUnit.LvlDB, 1, 1, 10),
+	)
+
+	assert.Nil(t, err, "no error expected but got %s", err)
+	assert.NotNil(t, storer, "valid storer expected but got nil")
+	assert.Nil(t, storer.Put([]byte("key"), []byte("value")))
+	err = storer.DestroyUnit()
+	assert.Nil(t, err, "no error expected destroying the persister")
+}
+
+func TestNewStorageUnit_FromConfWithMaxConcurrentOpsShouldKeepTheOptionalOperations(t *testing.T) {
+	storer, err := storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
+		Capacity: 10,
+		Type:     storageUnit.LRUCache,
+	}, storageUnit.DBConfig{
+		FilePath:          "Blocks",
+		Type:              storageUnit.LvlDB,
+		MaxBatchSize:      1,
+		BatchDelaySeconds: 1,
+		MaxOpenFiles:      10,
+		MaxConcurrentOps:  2,
+	},
+		testscommon.NewPersisterFactoryHandlerMock(storageUnit.LvlDB, 1, 1, 10),
+	)
+	assert.Nil(t, err)
+
+	err = storer.ApplyBatch([]types.Operation{
+		{Type: types.PutOperation, Key: []byte("key1"), Value: []byte("value1")},
+		{Type: types.PutOperation, Key: []byte("key2"), Value: []byte("value2")},
+	})
+	assert.Nil(t, err)
+	value, err := storer.Get([]byte("key2"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value2"), value)
+
+	assert.Nil(t, storer.TruncateUnit())
+	assert.NotNil(t, storer.Has([]byte("key1")))
+	assert.Nil(t, storer.DestroyUnit())
+}
+
+type levelDBPersisterFactoryStub struct {
+	numCreateCalls int
+	createdDB      *leveldb.DB
+}
+
+func (stub *levelDBPersisterFactoryStub) Create(path string) (types.Persister, error) {
+	stub.numCreateCalls++
+	return memorydb.New(), nil
+}
+
+func (stub *levelDBPersisterFactoryStub) CreateWithOptions(path string, options ...leveldb.Option) (types.Persister, error) {
+	db, err := leveldb.NewDB(path, 100, 100, 10, options...)
+	stub.createdDB = db
+
+	return db, err
+}
+
+func (stub *levelDBPersisterFactoryStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func TestNewStorageUnit_FromConfShouldApplyTheLevelDBSettings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no leveldb settings should use Create", func(t *testing.T) {
+		t.Parallel()
+
+		factory := &levelDBPersisterFactoryStub{}
+		storer, err := storageUnit.NewStorageUnitFromConf(
+			storageUnit.CacheConfig{Capacity: 10, Type: storageUnit.LRUCache},
+			storageUnit.DBConfig{FilePath: t.TempDir(), Type: storageUnit.LvlDB},
+			factory,
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, factory.numCreateCalls)
+		assert.Nil(t, factory.createdDB)
+		assert.Nil(t, storer.Close())
+	})
+	t.Run("adaptive batching should write the batch after the minimum delay", func(t *testing.T) {
+		t.Parallel()
+
+		factory := &levelDBPersisterFactoryStub{}
+		storer, err := storageUnit.NewStorageUnitFromConf(
+			storageUnit.CacheConfig{Capacity: 10, Type: storageUnit.LRUCache},
+			storageUnit.DBConfig{
+				FilePath:                  t.TempDir(),
+				Type:                      storageUnit.LvlDB,
+				AdaptiveBatching:          true,
+				MinBatchDelayMilliseconds: 10,
+				MaxBatchDelayMilliseconds: 1000,
+			},
+			factory,
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, factory.numCreateCalls)
+
+		assert.Nil(t, storer.Put([]byte("key"), []byte("value")))
+		assert.Eventually(t, func() bool {
+			return factory.createdDB.PendingBatchLen() == 0
+		}, time.Second, time.Millisecond*5)
+		assert.Nil(t, storer.Close())
+	})
+	t.Run("compaction schedule should be passed to the factory", func(t *testing.T) {
+		t.Parallel()
+
+		factory := &levelDBPersisterFactoryStub{}
+		storer, err := storageUnit.NewStorageUnitFromConf(
+			storageUnit.CacheConfig{Capacity: 10, Type: storageUnit.LRUCache},
+			storageUnit.DBConfig{
+				FilePath:           t.TempDir(),
+				Type:               storageUnit.LvlDB,
+				CompactionSchedule: []string{"03:00-05:00"},
+			},
+			factory,
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, factory.numCreateCalls)
+		assert.NotNil(t, factory.createdDB)
+		assert.Nil(t, storer.Close())
+	})
+	t.Run("sync interval should be passed to the factory", func(t *testing.T) {
+		t.Parallel()
+
+		factory := &levelDBPersisterFactoryStub{}
+		storer, err := storageUnit.NewStorageUnitFromConf(
+			storageUnit.CacheConfig{Capacity: 10, Type: storageUnit.LRUCache},
+			storageUnit.DBConfig{
+				FilePath:          t.TempDir(),
+				Type:              storageUnit.LvlDB,
+				SyncEveryInterval: time.Millisecond * 10,
+			},
+			factory,
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, factory.numCreateCalls)
+		assert.NotNil(t, factory.createdDB)
+
+		assert.Nil(t, storer.Put([]byte("key"), []byte("value")))
+		assert.Nil(t, storer.Close())
+	})
+	t.Run("invalid compaction schedule should error", func(t *testing.T) {
+		t.Parallel()
+
+		factory := &levelDBPersisterFactoryStub{}
+		storer, err := storageUnit.NewStorageUnitFromConf(
+			storageUnit.CacheConfig{Capacity: 10, Type: storageUnit.LRUCache},
+			storageUnit.DBConfig{
+				FilePath:           t.TempDir(),
+				Type:               storageUnit.LvlDB,
+				CompactionSchedule: []string{"25:00-05:00"},
+			},
+			factory,
+		)
+		assert.Nil(t, storer)
+		assert.True(t, errors.Is(err, common.ErrInvalidCompactionWindow))
+		assert.Equal(t, 0, factory.numCreateCalls)
+		assert.Nil(t, factory.createdDB)
+	})
+}
+
+func TestRegisterCustomTypes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid registrations should error", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, storageUnit.ErrEmptyTypeName, storageUnit.RegisterDBType("", func(_ storageUnit.ArgDB) (types.Persister, error) {
+			return memorydb.New(), nil
+		}))
+		assert.Equal(t, storageUnit.ErrNilDBFactory, storageUnit.RegisterDBType("CustomNilDB", nil))
+		assert.Equal(t, storageUnit.ErrEmptyTypeName, storageUnit.RegisterCacheType("", func(_ storageUnit.CacheConfig) (types.Cacher, error) {
+			return lrucache.NewCache(1)
+		}))
+		assert.Equal(t, storageUnit.ErrNilCacheFactory, storageUnit.RegisterCacheType("CustomNilCache", nil))
+	})
+	t.Run("custom in-memory types should be used by NewStorageUnitFromConf", func(t *testing.T) {
+		t.Parallel()
+
+		var receivedArgs storageUnit.ArgDB
+		err := storageUnit.RegisterDBType("CustomMemoryDB", func(args storageUnit.ArgDB) (types.Persister, error) {
+			receivedArgs = args
+			return memorydb.New(), nil
+		})
+		assert.Nil(t, err)
+
+		var receivedConfig storageUnit.CacheConfig
+		err = storageUnit.RegisterCacheType("CustomCache", func(config storageUnit.CacheConfig) (types.Cacher, error) {
+			receivedConfig = config
+			return lrucache.NewCache(int(config.Capacity))
+		})
+		assert.Nil(t, err)
+
+		cacheConfig := storageUnit.CacheConfig{
+			Capacity:       10,
+			Type:           "CustomCache",
+			EvictionPolicy: "CustomPolicy",
+		}
+		dbConfig := storageUnit.DBConfig{
+			FilePath:     "Custom",
+			Type:         "CustomMemoryDB",
+			MaxBatchSize: 5,
+		}
+
+		// the persister factory does not know the custom type, so it is not used
+		storer, err := storageUnit.NewStorageUnitFromConf(cacheConfig, dbConfig,
+			testscommon.NewPersisterFactoryHandlerMock("CustomMemoryDB", 0, 0, 0),
+		)
+		assert.Nil(t, err)
+		assert.False(t, check.IfNil(storer))
+		assert.Equal(t, cacheConfig, receivedConfig)
+		assert.Equal(t, storageUnit.DBType("CustomMemoryDB"), receivedArgs.DBType)
+		assert.Equal(t, "Custom", receivedArgs.Path)
+		assert.Equal(t, 5, receivedArgs.MaxBatchSize)
+
+		assert.Nil(t, storer.Put([]byte("key"), []byte("value")))
+		recovered, err := storer.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), recovered)
+		assert.Nil(t, storer.Close())
+	})
+	t.Run("factory errors should be returned", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		_ = storageUnit.RegisterCacheType("CustomFailingCache", func(_ storageUnit.CacheConfig) (types.Cacher, error) {
+			return nil, expectedErr
+		})
+
+		cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: "CustomFailingCache"})
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, cacher)
+	})
+	t.Run("concurrent registrations should not panic", func(t *testing.T) {
+		t.Parallel()
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+
+				name := storageUnit.CacheType(fmt.Sprintf("CustomConcurrentCache%d", idx%5))
+				_ = storageUnit.RegisterCacheType(name, func(config storageUnit.CacheConfig) (types.Cacher, error) {
+					return lrucache.NewCache(int(config.Capacity))
+				})
+				_, _ = storageUnit.NewCache(storageUnit.CacheConfig{Type: name, Capacity: 1})
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func TestUnitConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	validConfig := func() storageUnit.UnitConfig {
+		return storageUnit.UnitConfig{
+			CacheConf: storageUnit.CacheConfig{
+				Type:        storageUnit.ShardedSizeLRUCache,
+				Capacity:    100,
+				Shards:      4,
+				SizeInBytes: 4096,
+			},
+			DBConf: storageUnit.DBConfig{
+				FilePath:           "Blocks",
+				Type:               storageUnit.LvlDBSerial,
+				MaxBatchSize:       10,
+				CompactionSchedule: []string{"02:00-04:00"},
+			},
+		}
+	}
+
+	t.Run("valid config should work", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, validConfig().Validate())
+
+		config := validConfig()
+		config.CacheConf = storageUnit.CacheConfig{Type: storageUnit.LRUCache, Capacity: 10, EvictionPolicy: storageUnit.RandomPolicy}
+		config.DBConf = storageUnit.DBConfig{Type: storageUnit.MemoryDB, MaxBatchSize: 10}
+		assert.Nil(t, config.Validate())
+	})
+	t.Run("single violation should be attributed to its field", func(t *testing.T) {
+		t.Parallel()
+
+		config := validConfig()
+		config.DBConf.FilePath = ""
+
+		err := config.Validate()
+		assert.True(t, errors.Is(err, common.ErrEmptyDBPath))
+		assert.Contains(t, err.Error(), "DBConf.FilePath")
+	})
+	t.Run("all violations should be aggregated", func(t *testing.T) {
+		t.Parallel()
+
+		config := validConfig()
+		config.CacheConf.Shards = 0
+		config.CacheConf.SizeInBytes = 10
+		config.CacheConf.EvictionPolicy = storageUnit.FIFOPolicy
+		config.DBConf.MaxBatchSize = 1000
+		config.DBConf.FilePath = ""
+		config.DBConf.CompactionSchedule = []string{"25:00-26:00"}
+
+		err := config.Validate()
+		expectedErrors := map[string]error{
+			"CacheConf.Shards":          common.ErrCacheShardsInvalid,
+			"CacheConf.SizeInBytes":     common.ErrLRUCacheInvalidSize,
+			"CacheConf.EvictionPolicy":  common.ErrIncompatibleEvictionPolicy,
+			"DBConf.MaxBatchSize":       common.ErrCacheSizeIsLowerThanBatchSize,
+			"DBConf.FilePath":           common.ErrEmptyDBPath,
+			"DBConf.CompactionSchedule": common.ErrInvalidCompactionWindow,
+		}
+		for field, expectedErr := range expectedErrors {
+			assert.True(t, errors.Is(err, expectedErr), "expected %v", expectedErr)
+			assert.Contains(t, err.Error(), field)
+		}
+	})
+	t.Run("negative database settings should error", func(t *testing.T) {
+		t.Parallel()
+
+		config := validConfig()
+		config.DBConf.MaxConcurrentOps = -1
+		config.DBConf.SyncEveryInterval = -time.Second
+		config.DBConf.OpenTimeout = -time.Second
+
+		err := config.Validate()
+		expectedErrors := map[string]error{
+			"DBConf.MaxConcurrentOps":  boundedpersister.ErrInvalidMaxConcurrentOps,
+			"DBConf.SyncEveryInterval": common.ErrInvalidSyncInterval,
+			"DBConf.OpenTimeout":       common.ErrInvalidOpenTimeout,
+		}
+		for field, expectedErr := range expectedErrors {
+			assert.True(t, errors.Is(err, expectedErr), "expected %v", expectedErr)
+			assert.Contains(t, err.Error(), field)
+		}
+	})
+	t.Run("unsupported types should error", func(t *testing.T) {
+		t.Parallel()
+
+		config := validConfig()
+		config.CacheConf.Type = "NotACache"
+		config.DBConf.Type = "NotADB"
+
+		err := config.Validate()
+		assert.True(t, errors.Is(err, common.ErrNotSupportedCacheType))
+		assert.True(t, errors.Is(err, common.ErrNotSupportedDBType))
+		assert.Contains(t, err.Error(), "CacheConf.Type")
+		assert.Contains(t, err.Error(), "DBConf.Type")
+	})
+	t.Run("LRU with size and zero capacity should error", func(t *testing.T) {
+		t.Parallel()
+
+		config := validConfig()
+		config.CacheConf = storageUnit.CacheConfig{Type: storageUnit.LRUCache, SizeInBytes: 4096}
+		config.DBConf.MaxBatchSize = 0
+
+		err := config.Validate()
+		assert.True(t, errors.Is(err, common.ErrLRUCacheWithProvidedSize))
+		assert.True(t, errors.Is(err, common.ErrCacheSizeInvalid))
+	})
+	t.Run("registered types should be accepted", func(t *testing.T) {
+		t.Parallel()
+
+		_ = storageUnit.RegisterCacheType("ValidatedCustomCache", func(config storageUnit.CacheConfig) (types.Cacher, error) {
+			return lrucache.NewCache(int(config.Capacity))
+		})
+		_ = storageUnit.RegisterDBType("ValidatedCustomDB", func(_ storageUnit.ArgDB) (types.Persister, error) {
+			return memorydb.New(), nil
+		})
+
+		config := validConfig()
+		config.CacheConf.Type = "ValidatedCustomCache"
+		config.DBConf.Type = "ValidatedCustomDB"
+		config.DBConf.FilePath = ""
+		assert.Nil(t, config.Validate())
+	})
+}
+
+func TestNewStorageUnit_FromUnitConfWithLoggerOk(t *testing.T) {
+	storer, err := storageUnit.NewStorageUnitFromUnitConf(storageUnit.UnitConfig{
+		CacheConf: storageUnit.CacheConfig{
+			Capacity: 10,
+			Type:     storageUnit.LRUCache,
+		},
+		DBConf: storageUnit.DBConfig{
+			FilePath:          "Blocks",
+			Type:              storageUnit.LvlDB,
+			MaxBatchSize:      1,
+			BatchDelaySeconds: 1,
+			MaxOpenFiles:      10,
+		},
+		Logger: &testscommon.LoggerStub{},
+	},
+		testscommon.NewPersisterFactoryHandlerMock(storageUnit.LvlDB, 1, 1, 10),
+	)
+
+	assert.Nil(t, err, "no error expected but got %s", err)
+	assert.NotNil(t, storer, "valid storer expected but got nil")
+	err = storer.DestroyUnit()
+	assert.Nil(t, err, "no error expected destroying the persister")
+}
+
 func TestNewStorageUnit_ShouldWorkLvlDB(t *testing.T) {
 	storer, err := storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
 		Capacity: 10,
@@ -463,3 +2456,475 @@ func BenchmarkStorageUnit_GetWithDataNotBeingPresent(b *testing.B) {
 		logError(err)
 	}
 }
+
+func BenchmarkStorageUnit_GetIntoWithDataBeingPresent(b *testing.B) {
+	b.StopTimer()
+	s := initStorageUnit(b, 1)
+	defer func() {
+		err := s.DestroyUnit()
+		logError(err)
+	}()
+	for i := 0; i < valuesInDb; i++ {
+		err := s.Put([]byte(strconv.Itoa(i)), []byte(strconv.Itoa(i)))
+		logError(err)
+	}
+	buff := make([]byte, 32)
+	b.StartTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		nr := rand.Intn(valuesInDb)
+		b.StartTimer()
+
+		_, err := s.GetInto([]byte(strconv.Itoa(nr)), buff)
+		logError(err)
+	}
+}
+
+func TestShardIDProviderType_NewShardIDProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unknown type should error", func(t *testing.T) {
+		t.Parallel()
+
+		provider, err := storageUnit.ShardIDProviderType("unknown").NewShardIDProvider(4, 10)
+		assert.Nil(t, provider)
+		assert.Equal(t, common.ErrNotSupportedShardIDProviderType, err)
+	})
+	t.Run("binary split should work", func(t *testing.T) {
+		t.Parallel()
+
+		provider, err := storageUnit.BinarySplit.NewShardIDProvider(4, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(4), provider.NumberOfShards())
+	})
+	t.Run("consistent hash should work", func(t *testing.T) {
+		t.Parallel()
+
+		provider, err := storageUnit.ConsistentHash.NewShardIDProvider(4, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(4), provider.NumberOfShards())
+	})
+	t.Run("consistent hash with invalid virtual nodes should error", func(t *testing.T) {
+		t.Parallel()
+
+		provider, err := storageUnit.ConsistentHash.NewShardIDProvider(4, 0)
+		assert.True(t, check.IfNil(provider))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCommitIfVersion(t *testing.T) {
+	t.Parallel()
+
+	versionKey := []byte("version")
+	ops := []types.Operation{
+		{Type: types.PutOperation, Key: []byte("key1"), Value: []byte("value1")},
+		{Type: types.PutOperation, Key: []byte("key2"), Value: []byte("value2")},
+	}
+
+	t.Run("persister without batches should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, testscommon.NewMemDbMock())
+
+		committed, err := s.CommitIfVersion(versionKey, nil, ops)
+		assert.False(t, committed)
+		assert.Equal(t, common.ErrBatchNotSupported, err)
+	})
+	t.Run("batch writing the version key should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+
+		committed, err := s.CommitIfVersion(versionKey, nil, []types.Operation{
+			{Type: types.RemoveOperation, Key: versionKey},
+		})
+		assert.False(t, committed)
+		assert.Equal(t, common.ErrVersionKeyInBatch, err)
+	})
+	t.Run("invalid stored version should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		_ = s.Put(versionKey, []byte("v1"))
+
+		committed, err := s.CommitIfVersion(versionKey, []byte("v1"), ops)
+		assert.False(t, committed)
+		assert.Equal(t, common.ErrInvalidVersion, err)
+		assert.NotNil(t, s.Has([]byte("key1")))
+	})
+	t.Run("matching versions should commit and bump the version", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+
+		committed, err := s.CommitIfVersion(versionKey, nil, ops)
+		assert.Nil(t, err)
+		assert.True(t, committed)
+
+		version, err := s.Get(versionKey)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1}, version)
+		value, _ := s.Get([]byte("key2"))
+		assert.Equal(t, []byte("value2"), value)
+
+		committed, err = s.CommitIfVersion(versionKey, version, []types.Operation{
+			{Type: types.RemoveOperation, Key: []byte("key1")},
+		})
+		assert.Nil(t, err)
+		assert.True(t, committed)
+
+		version, _ = s.Get(versionKey)
+		assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 2}, version)
+		assert.NotNil(t, s.Has([]byte("key1")))
+	})
+	t.Run("stale version should not commit", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		_, _ = s.CommitIfVersion(versionKey, nil, nil)
+
+		committed, err := s.CommitIfVersion(versionKey, nil, ops)
+		assert.Nil(t, err)
+		assert.False(t, committed)
+		assert.NotNil(t, s.Has([]byte("key1")))
+
+		version, _ := s.Get(versionKey)
+		assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1}, version)
+	})
+	t.Run("concurrent commits on the same version should apply only one", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		numCommits := 10
+		numCommitted := uint32(0)
+		wg := sync.WaitGroup{}
+		wg.Add(numCommits)
+		for i := 0; i < numCommits; i++ {
+			go func(idx int) {
+				defer wg.Done()
+
+				committed, err := s.CommitIfVersion(versionKey, nil, []types.Operation{
+					{Type: types.PutOperation, Key: []byte("key"), Value: []byte(fmt.Sprintf("value%d", idx))},
+				})
+				assert.Nil(t, err)
+				if committed {
+					atomic.AddUint32(&numCommitted, 1)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, uint32(1), atomic.LoadUint32(&numCommitted))
+	})
+}
+
+func TestExportPrefixAndMovePrefix(t *testing.T) {
+	t.Parallel()
+
+	createPopulatedUnit := func(t *testing.T) *storageUnit.Unit {
+		s := initStorageUnit(t, 10)
+		for i := 0; i < 30; i++ {
+			_ = s.Put([]byte(fmt.Sprintf("a_%02d", i)), []byte(fmt.Sprintf("value%02d", i)))
+			_ = s.Put([]byte(fmt.Sprintf("b_%02d", i)), []byte(fmt.Sprintf("value%02d", i)))
+		}
+
+		return s
+	}
+
+	t.Run("nil destination should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		copied, err := s.ExportPrefix([]byte("a_"), nil)
+		assert.Equal(t, uint64(0), copied)
+		assert.Equal(t, common.ErrNilPersister, err)
+
+		copied, err = s.MovePrefix([]byte("a_"), nil)
+		assert.Equal(t, uint64(0), copied)
+		assert.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("export should copy the prefixed keys", func(t *testing.T) {
+		t.Parallel()
+
+		s := createPopulatedUnit(t)
+		dst := memorydb.New()
+
+		copied, err := s.ExportPrefix([]byte("a_"), dst)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(30), copied)
+
+		count, _ := dst.CountPrefix(nil)
+		assert.Equal(t, uint64(30), count)
+		value, _ := dst.Get([]byte("a_07"))
+		assert.Equal(t, []byte("value07"), value)
+		count, _ = s.CountPrefix([]byte("a_"))
+		assert.Equal(t, uint64(30), count)
+	})
+	t.Run("move should copy then remove the prefixed keys", func(t *testing.T) {
+		t.Parallel()
+
+		s := createPopulatedUnit(t)
+		dst := memorydb.New()
+		_, _ = s.Get([]byte("a_29"))
+
+		copied, err := s.MovePrefix([]byte("a_"), dst)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(30), copied)
+
+		count, _ := dst.CountPrefix([]byte("a_"))
+		assert.Equal(t, uint64(30), count)
+		count, _ = s.CountPrefix([]byte("a_"))
+		assert.Equal(t, uint64(0), count)
+		count, _ = s.CountPrefix([]byte("b_"))
+		assert.Equal(t, uint64(30), count)
+		assert.NotNil(t, s.Has([]byte("a_29")))
+		_, err = s.Get([]byte("a_29"))
+		assert.NotNil(t, err)
+	})
+	t.Run("failed copy should not remove the keys", func(t *testing.T) {
+		t.Parallel()
+
+		s := createPopulatedUnit(t)
+		expectedErr := errors.New("expected error")
+		dst := &testscommon.BatchPersisterStub{
+			ApplyBatchCalled: func(ops []types.Operation) error {
+				return expectedErr
+			},
+		}
+
+		copied, err := s.MovePrefix([]byte("a_"), dst)
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, uint64(0), copied)
+		count, _ := s.CountPrefix([]byte("a_"))
+		assert.Equal(t, uint64(30), count)
+	})
+}
+
+func TestChangeLog(t *testing.T) {
+	t.Parallel()
+
+	createUnit := func(changeLog types.Persister) *storageUnit.Unit {
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithChangeLog(changeLog))
+
+		return s
+	}
+	consumeAll := func(s *storageUnit.Unit, fromSeq uint64) ([]storageUnit.ChangeRecord, error) {
+		records := make([]storageUnit.ChangeRecord, 0)
+		err := s.ConsumeChanges(fromSeq, func(record storageUnit.ChangeRecord) error {
+			records = append(records, record)
+			return nil
+		})
+
+		return records, err
+	}
+
+	t.Run("unit without change log should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+
+		err := s.ConsumeChanges(0, func(storageUnit.ChangeRecord) error { return nil })
+		assert.Equal(t, common.ErrChangeLogNotEnabled, err)
+		assert.Equal(t, common.ErrChangeLogNotEnabled, s.AckChanges("consumer", 0))
+		_, err = s.AckedChangeSequence("consumer")
+		assert.Equal(t, common.ErrChangeLogNotEnabled, err)
+	})
+	t.Run("nil handler should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := createUnit(memorydb.New())
+		assert.Equal(t, common.ErrNilChangeHandler, s.ConsumeChanges(0, nil))
+	})
+	t.Run("mutations should be replayed in order", func(t *testing.T) {
+		t.Parallel()
+
+		s := createUnit(memorydb.New())
+		_ = s.Put([]byte("a"), []byte("1"))
+		_ = s.Remove([]byte("a"))
+		_ = s.ApplyBatch([]types.Operation{
+			{Type: types.PutOperation, Key: []byte("b"), Value: []byte("2")},
+			{Type: types.RemoveOperation, Key: []byte("c")},
+		})
+		_ = s.PutCtx(context.Background(), []byte("d"), []byte("3"))
+
+		records, err := consumeAll(s, 0)
+		assert.Nil(t, err)
+		expected := []storageUnit.ChangeRecord{
+			{Seq: 0, Type: types.PutOperation, Key: []byte("a"), Value: []byte("1")},
+			{Seq: 1, Type: types.RemoveOperation, Key: []byte("a"), Value: []byte{}},
+			{Seq: 2, Type: types.PutOperation, Key: []byte("b"), Value: []byte("2")},
+			{Seq: 3, Type: types.RemoveOperation, Key: []byte("c"), Value: []byte{}},
+			{Seq: 4, Type: types.PutOperation, Key: []byte("d"), Value: []byte("3")},
+		}
+		assert.Equal(t, expected, records)
+
+		records, err = consumeAll(s, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, expected[3:], records)
+	})
+	t.Run("handler error should stop the replay", func(t *testing.T) {
+		t.Parallel()
+
+		s := createUnit(memorydb.New())
+		_ = s.Put([]byte("a"), []byte("1"))
+		_ = s.Put([]byte("b"), []byte("2"))
+
+		expectedErr := errors.New("expected error")
+		numCalls := 0
+		err := s.ConsumeChanges(0, func(storageUnit.ChangeRecord) error {
+			numCalls++
+			return expectedErr
+		})
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, 1, numCalls)
+	})
+	t.Run("failed write should not append a record", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		expectedErr := errors.New("expected error")
+		persister := &testscommon.PersisterStub{
+			PutCalled: func(key, data []byte) error {
+				return expectedErr
+			},
+		}
+		s, _ := storageUnit.NewStorageUnit(cache, persister, storageUnit.WithChangeLog(memorydb.New()))
+
+		assert.Equal(t, expectedErr, s.Put([]byte("a"), []byte("1")))
+		records, err := consumeAll(s, 0)
+		assert.Nil(t, err)
+		assert.Empty(t, records)
+	})
+	t.Run("invalid change log should fail the unit creation", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		s, err := storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithChangeLog(nil))
+		assert.Nil(t, s)
+		assert.True(t, errors.Is(err, common.ErrNilPersister))
+
+		changeLog := memorydb.New()
+		_ = changeLog.Put([]byte("changeLogHead"), []byte("invalid"))
+		s, err = storageUnit.NewStorageUnit(cache, memorydb.New(), storageUnit.WithChangeLog(changeLog))
+		assert.Nil(t, s)
+		assert.True(t, errors.Is(err, common.ErrInvalidChangeRecord))
+	})
+	t.Run("failed append should be told apart from a failed write", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		changeLog := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				return nil, common.ErrKeyNotFound
+			},
+			PutCalled: func(key, val []byte) error {
+				return expectedErr
+			},
+		}
+		s := createUnit(changeLog)
+
+		err := s.Put([]byte("a"), []byte("1"))
+		assert.True(t, errors.Is(err, common.ErrChangeLogAppendFailed))
+		assert.True(t, errors.Is(err, expectedErr))
+		value, err := s.Get([]byte("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("1"), value)
+	})
+	t.Run("destroying the unit should destroy the change log", func(t *testing.T) {
+		t.Parallel()
+
+		numDestroyCalls, numDestroyClosedCalls := 0, 0
+		changeLog := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				return nil, common.ErrKeyNotFound
+			},
+			DestroyCalled: func() error {
+				numDestroyCalls++
+				return nil
+			},
+			DestroyClosedCalled: func() error {
+				numDestroyClosedCalls++
+				return nil
+			},
+		}
+
+		s := createUnit(changeLog)
+		assert.Nil(t, s.DestroyUnit())
+		assert.Equal(t, 1, numDestroyCalls)
+
+		s = createUnit(changeLog)
+		assert.Nil(t, s.Close())
+		assert.Nil(t, s.DestroyUnit())
+		assert.Equal(t, 1, numDestroyClosedCalls)
+	})
+	t.Run("acknowledged records should be truncated", func(t *testing.T) {
+		t.Parallel()
+
+		s := createUnit(memorydb.New())
+		assert.Nil(t, s.AckChanges("slow", 0))
+		for i := 0; i < 5; i++ {
+			_ = s.Put([]byte(fmt.Sprintf("key%d", i)), []byte("value"))
+		}
+
+		assert.Nil(t, s.AckChanges("fast", 4))
+		assert.Nil(t, s.AckChanges("slow", 2))
+
+		_, err := consumeAll(s, 1)
+		assert.True(t, errors.Is(err, common.ErrChangesTruncated))
+		records, err := consumeAll(s, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(records))
+
+		err = s.AckChanges("slow", 1)
+		assert.True(t, errors.Is(err, common.ErrInvalidChangeSequence))
+		err = s.AckChanges("slow", 6)
+		assert.True(t, errors.Is(err, common.ErrInvalidChangeSequence))
+
+		assert.Nil(t, s.AckChanges("slow", 5))
+		_, err = consumeAll(s, 3)
+		assert.True(t, errors.Is(err, common.ErrChangesTruncated))
+		records, err = consumeAll(s, 4)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(records))
+	})
+	t.Run("consumer should resume after reopening", func(t *testing.T) {
+		t.Parallel()
+
+		changeLog := memorydb.New()
+		s := createUnit(changeLog)
+		_ = s.Put([]byte("a"), []byte("1"))
+		_ = s.Put([]byte("b"), []byte("2"))
+		assert.Nil(t, s.AckChanges("consumer", 1))
+		_ = s.Close()
+
+		s = createUnit(changeLog)
+		_ = s.Put([]byte("c"), []byte("3"))
+
+		fromSeq, err := s.AckedChangeSequence("consumer")
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(1), fromSeq)
+
+		records, err := consumeAll(s, fromSeq)
+		assert.Nil(t, err)
+		assert.Equal(t, []storageUnit.ChangeRecord{
+			{Seq: 1, Type: types.PutOperation, Key: []byte("b"), Value: []byte("2")},
+			{Seq: 2, Type: types.PutOperation, Key: []byte("c"), Value: []byte("3")},
+		}, records)
+	})
+	t.Run("closed unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := createUnit(memorydb.New())
+		_ = s.Put([]byte("a"), []byte("1"))
+		_ = s.Close()
+
+		_, err := consumeAll(s, 0)
+		assert.Equal(t, common.ErrUnitClosed, err)
+		assert.Equal(t, common.ErrUnitClosed, s.AckChanges("consumer", 1))
+	})
+}
@@ -0,0 +1,91 @@
+package storageUnit
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// SizeBucket is a value size histogram bucket, holding the sizes in the [LowerBound, UpperBound) interval.
+// The UpperBound of the last bucket is math.MaxUint64
+type SizeBucket struct {
+	LowerBound uint64
+	UpperBound uint64
+}
+
+// valueSizeSampler records the size of one in every samplingInterval put values in a histogram
+type valueSizeSampler struct {
+	upperBounds      []uint64
+	buckets          []SizeBucket
+	counts           []uint64
+	samplingInterval uint64
+	numPuts          uint64
+}
+
+func newValueSizeSampler(bucketBounds []uint64, samplingInterval uint64) *valueSizeSampler {
+	upperBounds := make([]uint64, 0, len(bucketBounds)+1)
+	sortedBounds := append([]uint64(nil), bucketBounds...)
+	sort.Slice(sortedBounds, func(i, j int) bool {
+		return sortedBounds[i] < sortedBounds[j]
+	})
+	for _, bound := range sortedBounds {
+		isDuplicate := len(upperBounds) > 0 && upperBounds[len(upperBounds)-1] == bound
+		if bound == 0 || bound == math.MaxUint64 || isDuplicate {
+			continue
+		}
+
+		upperBounds = append(upperBounds, bound)
+	}
+	upperBounds = append(upperBounds, math.MaxUint64)
+
+	buckets := make([]SizeBucket, 0, len(upperBounds))
+	lowerBound := uint64(0)
+	for _, upperBound := range upperBounds {
+		buckets = append(buckets, SizeBucket{
+			LowerBound: lowerBound,
+			UpperBound: upperBound,
+		})
+		lowerBound = upperBound
+	}
+
+	return &valueSizeSampler{
+		upperBounds:      upperBounds,
+		buckets:          buckets,
+		counts:           make([]uint64, len(buckets)),
+		samplingInterval: samplingInterval,
+	}
+}
+
+// record counts the provided size if the put is sampled. It does nothing on a nil sampler
+func (vss *valueSizeSampler) record(size int) {
+	if vss == nil {
+		return
+	}
+
+	numPuts := atomic.AddUint64(&vss.numPuts, 1)
+	if numPuts%vss.samplingInterval != 0 {
+		return
+	}
+
+	idx := sort.Search(len(vss.upperBounds), func(i int) bool {
+		return uint64(size) < vss.upperBounds[i]
+	})
+	if idx == len(vss.upperBounds) {
+		idx--
+	}
+
+	atomic.AddUint64(&vss.counts[idx], 1)
+}
+
+func (vss *valueSizeSampler) histogram() map[SizeBucket]uint64 {
+	if vss == nil {
+		return nil
+	}
+
+	histogram := make(map[SizeBucket]uint64, len(vss.buckets))
+	for i, bucket := range vss.buckets {
+		histogram[bucket] = atomic.LoadUint64(&vss.counts[i])
+	}
+
+	return histogram
+}
@@ -0,0 +1,23 @@
+package testscommon
+
+import "github.com/DharitriOne/drt-chain-storage-go/types"
+
+// BatchPersisterStub -
+type BatchPersisterStub struct {
+	PersisterStub
+	ApplyBatchCalled func(ops []types.Operation) error
+}
+
+// ApplyBatch -
+func (stub *BatchPersisterStub) ApplyBatch(ops []types.Operation) error {
+	if stub.ApplyBatchCalled != nil {
+		return stub.ApplyBatchCalled(ops)
+	}
+
+	return nil
+}
+
+// IsInterfaceNil -
+func (stub *BatchPersisterStub) IsInterfaceNil() bool {
+	return stub == nil
+}
@@ -27,11 +27,16 @@ func NewPersisterFactoryHandlerMock(dbType storageUnit.DBType, batchDelaySeconds
 
 // Create -
 func (mock *persisterFactoryHandlerMock) Create(path string) (types.Persister, error) {
+	return mock.CreateWithOptions(path)
+}
+
+// CreateWithOptions -
+func (mock *persisterFactoryHandlerMock) CreateWithOptions(path string, options ...leveldb.Option) (types.Persister, error) {
 	switch mock.dbType {
 	case storageUnit.LvlDB:
-		return leveldb.NewDB(path, mock.batchDelaySeconds, mock.maxBatchSize, mock.maxOpenFiles)
+		return leveldb.NewDB(path, mock.batchDelaySeconds, mock.maxBatchSize, mock.maxOpenFiles, options...)
 	case storageUnit.LvlDBSerial:
-		return leveldb.NewSerialDB(path, mock.batchDelaySeconds, mock.maxBatchSize, mock.maxOpenFiles)
+		return leveldb.NewSerialDB(path, mock.batchDelaySeconds, mock.maxBatchSize, mock.maxOpenFiles, options...)
 	case storageUnit.MemoryDB:
 		return memorydb.New(), nil
 	default:
@@ -0,0 +1,169 @@
+package throttlepersister
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*throttlePersister)(nil)
+
+// ErrInvalidRate signals that a non positive write rate was provided
+var ErrInvalidRate = errors.New("invalid write rate")
+
+// throttlePersister bounds the bytes written per second in the inner persister with a token bucket refilled at the
+// configured rate and holding at most one second of writes. Each write takes as many tokens as its key and value
+// lengths: a write finding not enough tokens still reserves them, driving the bucket below zero, and waits until
+// its reservation is covered, so the writes larger than the bucket pass too. Reads are not throttled
+type throttlePersister struct {
+	persister types.Persister
+
+	mut        sync.Mutex
+	rate       int64
+	tokens     float64
+	lastRefill time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewThrottlePersister creates a persister wrapper limiting the writes of the inner persister to the provided
+// number of bytes per second
+func NewThrottlePersister(inner types.Persister, bytesPerSec int64) (*throttlePersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	if bytesPerSec < 1 {
+		return nil, ErrInvalidRate
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &throttlePersister{
+		persister:  inner,
+		rate:       bytesPerSec,
+		tokens:     float64(bytesPerSec),
+		lastRefill: time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
+	}, nil
+}
+
+// SetRate changes the number of bytes written per second. The writes already waiting keep their wait time
+func (tp *throttlePersister) SetRate(bytesPerSec int64) error {
+	if bytesPerSec < 1 {
+		return ErrInvalidRate
+	}
+
+	tp.mut.Lock()
+	defer tp.mut.Unlock()
+
+	tp.refill(time.Now())
+	tp.rate = bytesPerSec
+	if tp.tokens > float64(bytesPerSec) {
+		tp.tokens = float64(bytesPerSec)
+	}
+
+	return nil
+}
+
+func (tp *throttlePersister) refill(now time.Time) {
+	elapsed := now.Sub(tp.lastRefill)
+	tp.lastRefill = now
+	if elapsed <= 0 {
+		return
+	}
+
+	tp.tokens += elapsed.Seconds() * float64(tp.rate)
+	if tp.tokens > float64(tp.rate) {
+		tp.tokens = float64(tp.rate)
+	}
+}
+
+// reserve takes the tokens of a write and returns how long the write has to wait for them
+func (tp *throttlePersister) reserve(numBytes int) time.Duration {
+	tp.mut.Lock()
+	defer tp.mut.Unlock()
+
+	tp.refill(time.Now())
+	tp.tokens -= float64(numBytes)
+	if tp.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-tp.tokens / float64(tp.rate) * float64(time.Second))
+}
+
+// wait blocks until the tokens of a write are available or the persister is closed
+func (tp *throttlePersister) wait(numBytes int) {
+	waitTime := tp.reserve(numBytes)
+	if waitTime <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(waitTime)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-tp.ctx.Done():
+	}
+}
+
+// Put adds the value to the (key, val) persistence medium, after waiting for the write rate to allow it
+func (tp *throttlePersister) Put(key, val []byte) error {
+	tp.wait(len(key) + len(val))
+
+	return tp.persister.Put(key, val)
+}
+
+// Get gets the value associated to the key, without throttling
+func (tp *throttlePersister) Get(key []byte) ([]byte, error) {
+	return tp.persister.Get(key)
+}
+
+// Has returns nil if the given key is present in the persistence medium, without throttling
+func (tp *throttlePersister) Has(key []byte) error {
+	return tp.persister.Has(key)
+}
+
+// Remove removes the data associated to the given key, after waiting for the write rate to allow it
+func (tp *throttlePersister) Remove(key []byte) error {
+	tp.wait(len(key))
+
+	return tp.persister.Remove(key)
+}
+
+// Close releases the waiting writes and closes the inner persister
+func (tp *throttlePersister) Close() error {
+	tp.cancel()
+
+	return tp.persister.Close()
+}
+
+// Destroy releases the waiting writes and removes the inner persister stored data
+func (tp *throttlePersister) Destroy() error {
+	tp.cancel()
+
+	return tp.persister.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister stored data
+func (tp *throttlePersister) DestroyClosed() error {
+	return tp.persister.DestroyClosed()
+}
+
+// RangeKeys calls the inner persister's RangeKeys method
+func (tp *throttlePersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	tp.persister.RangeKeys(handler)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tp *throttlePersister) IsInterfaceNil() bool {
+	return tp == nil
+}
@@ -0,0 +1,113 @@
+package throttlepersister_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/throttlepersister"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewThrottlePersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := throttlepersister.NewThrottlePersister(nil, 100)
+		require.True(t, check.IfNil(tp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("invalid rate should error", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := throttlepersister.NewThrottlePersister(memorydb.New(), 0)
+		require.True(t, check.IfNil(tp))
+		require.Equal(t, throttlepersister.ErrInvalidRate, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := throttlepersister.NewThrottlePersister(memorydb.New(), 100)
+		require.False(t, check.IfNil(tp))
+		require.Nil(t, err)
+	})
+}
+
+func TestThrottlePersister_WritesShouldWaitForTheRate(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := throttlepersister.NewThrottlePersister(memorydb.New(), 1000)
+
+	// the bucket initially holds one second of writes
+	start := time.Now()
+	require.Nil(t, tp.Put([]byte("key1"), make([]byte, 996)))
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+
+	start = time.Now()
+	require.Nil(t, tp.Put([]byte("key2"), make([]byte, 296)))
+	require.GreaterOrEqual(t, time.Since(start), 250*time.Millisecond)
+
+	start = time.Now()
+	longKey := make([]byte, 50)
+	for i := 0; i < 3; i++ {
+		require.Nil(t, tp.Remove(longKey))
+	}
+	require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// the reads are not throttled
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		_, err := tp.Get([]byte("key2"))
+		require.Nil(t, err)
+		require.Nil(t, tp.Has([]byte("key2")))
+	}
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestThrottlePersister_SetRate(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := throttlepersister.NewThrottlePersister(memorydb.New(), 100)
+	require.Equal(t, throttlepersister.ErrInvalidRate, tp.SetRate(-1))
+
+	require.Nil(t, tp.SetRate(1000000))
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		require.Nil(t, tp.Put([]byte("key"), make([]byte, 10000)))
+	}
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// lowering the rate also lowers the bucket to one second of writes
+	require.Nil(t, tp.SetRate(100))
+	start = time.Now()
+	require.Nil(t, tp.Put([]byte("key"), make([]byte, 97)))
+	require.Nil(t, tp.Put([]byte("key"), make([]byte, 17)))
+	require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestThrottlePersister_CloseShouldReleaseTheWaitingWrites(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := throttlepersister.NewThrottlePersister(memorydb.New(), 10)
+	require.Nil(t, tp.Put([]byte("key"), make([]byte, 7)))
+
+	chDone := make(chan struct{})
+	go func() {
+		// would wait for about 100 seconds
+		_ = tp.Put([]byte("key"), make([]byte, 1000))
+		close(chDone)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	require.Nil(t, tp.Close())
+
+	select {
+	case <-chDone:
+	case <-time.After(time.Second):
+		require.Fail(t, "the waiting write was not released")
+	}
+}
@@ -1,6 +1,7 @@
 package timecache
 
 import (
+	"io"
 	"time"
 
 	"github.com/DharitriOne/drt-chain-core-go/core"
@@ -41,6 +42,28 @@ func (ptc *peerTimeCache) Has(pid core.PeerID) bool {
 	return ptc.timeCache.Has(string(pid))
 }
 
+// SaveTo saves the inner time cache, so the banned peers survive a restart. Returns
+// common.ErrTimeCachePersistenceNotSupported if the inner time cache can not be saved
+func (ptc *peerTimeCache) SaveTo(w io.Writer) error {
+	persistentTimeCache, ok := ptc.timeCache.(types.PersistentTimeCacher)
+	if !ok {
+		return common.ErrTimeCachePersistenceNotSupported
+	}
+
+	return persistentTimeCache.SaveTo(w)
+}
+
+// LoadFrom loads the peers saved with SaveTo in the inner time cache, skipping the expired ones. Returns
+// common.ErrTimeCachePersistenceNotSupported if the inner time cache can not be loaded
+func (ptc *peerTimeCache) LoadFrom(r io.Reader) error {
+	persistentTimeCache, ok := ptc.timeCache.(types.PersistentTimeCacher)
+	if !ok {
+		return common.ErrTimeCachePersistenceNotSupported
+	}
+
+	return persistentTimeCache.LoadFrom(r)
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (ptc *peerTimeCache) IsInterfaceNil() bool {
 	return ptc == nil
@@ -1,6 +1,7 @@
 package timecache
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 	"time"
@@ -68,3 +69,33 @@ func TestPeerTimeCache_Methods(t *testing.T) {
 	assert.True(t, hasWasCalled)
 	assert.True(t, sweepWasCalled)
 }
+
+func TestPeerTimeCache_SaveToLoadFrom(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not persistent time cache should error", func(t *testing.T) {
+		t.Parallel()
+
+		ptc, _ := NewPeerTimeCache(&testscommon.TimeCacheStub{})
+		assert.Equal(t, common.ErrTimeCachePersistenceNotSupported, ptc.SaveTo(&bytes.Buffer{}))
+		assert.Equal(t, common.ErrTimeCachePersistenceNotSupported, ptc.LoadFrom(&bytes.Buffer{}))
+	})
+	t.Run("banned peers should survive a restart", func(t *testing.T) {
+		t.Parallel()
+
+		pid := core.PeerID("banned peer")
+		ptc, _ := NewPeerTimeCache(NewTimeCache(time.Hour))
+		err := ptc.Upsert(pid, time.Minute)
+		assert.Nil(t, err)
+
+		buff := &bytes.Buffer{}
+		err = ptc.SaveTo(buff)
+		assert.Nil(t, err)
+
+		restarted, _ := NewPeerTimeCache(NewTimeCache(time.Hour))
+		assert.False(t, restarted.Has(pid))
+		err = restarted.LoadFrom(buff)
+		assert.Nil(t, err)
+		assert.True(t, restarted.Has(pid))
+	})
+}
@@ -0,0 +1,154 @@
+package timecache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+)
+
+const timeCacheSnapshotVersion = byte(1)
+
+// maxSavedKeyLength bounds the key length read back, so a corrupted length does not trigger a huge allocation
+const maxSavedKeyLength = 1 << 16
+
+type savedEntry struct {
+	key    string
+	expiry time.Time
+}
+
+// saveTo writes the keys with their expiry time. The format is a version byte and the number of entries, followed
+// by the length, the bytes and the expiry as unix nanoseconds of each key, all big endian. The values are not saved.
+// The entries are copied under the lock, the writer being called after releasing it
+func (tcc *timeCacheCore) saveTo(w io.Writer) error {
+	now := time.Now()
+
+	tcc.RLock()
+	entries := make([]savedEntry, 0, len(tcc.data))
+	for key, element := range tcc.data {
+		expiry := element.timestamp.Add(element.span)
+		if !expiry.After(now) {
+			continue
+		}
+
+		entries = append(entries, savedEntry{
+			key:    key,
+			expiry: expiry,
+		})
+	}
+	tcc.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 9)
+	header[0] = timeCacheSnapshotVersion
+	binary.BigEndian.PutUint64(header[1:], uint64(len(entries)))
+	_, err := bw.Write(header)
+	if err != nil {
+		return err
+	}
+
+	buff := make([]byte, 8)
+	for _, e := range entries {
+		binary.BigEndian.PutUint32(buff[:4], uint32(len(e.key)))
+		_, err = bw.Write(buff[:4])
+		if err != nil {
+			return err
+		}
+		_, err = bw.WriteString(e.key)
+		if err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint64(buff, uint64(e.expiry.UnixNano()))
+		_, err = bw.Write(buff)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// loadFrom reads the entries written by saveTo, skipping the ones which expired in the meantime. A loaded key
+// already present is kept with the later of the two expiries. Nothing is loaded if the data can not be entirely
+// decoded
+func (tcc *timeCacheCore) loadFrom(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, 9)
+	_, err := io.ReadFull(br, header)
+	if err != nil {
+		return fmt.Errorf("%w: %v", common.ErrInvalidTimeCacheSnapshot, err)
+	}
+	if header[0] != timeCacheSnapshotVersion {
+		return fmt.Errorf("%w: unknown version %d", common.ErrInvalidTimeCacheSnapshot, header[0])
+	}
+
+	numEntries := binary.BigEndian.Uint64(header[1:])
+	entries := make([]savedEntry, 0)
+	buff := make([]byte, 8)
+	for i := uint64(0); i < numEntries; i++ {
+		_, err = io.ReadFull(br, buff[:4])
+		if err != nil {
+			return fmt.Errorf("%w: %v", common.ErrInvalidTimeCacheSnapshot, err)
+		}
+		keyLength := binary.BigEndian.Uint32(buff[:4])
+		if keyLength == 0 || keyLength > maxSavedKeyLength {
+			return fmt.Errorf("%w: invalid key length %d", common.ErrInvalidTimeCacheSnapshot, keyLength)
+		}
+
+		key := make([]byte, keyLength)
+		_, err = io.ReadFull(br, key)
+		if err != nil {
+			return fmt.Errorf("%w: %v", common.ErrInvalidTimeCacheSnapshot, err)
+		}
+		_, err = io.ReadFull(br, buff)
+		if err != nil {
+			return fmt.Errorf("%w: %v", common.ErrInvalidTimeCacheSnapshot, err)
+		}
+
+		entries = append(entries, savedEntry{
+			key:    string(key),
+			expiry: time.Unix(0, int64(binary.BigEndian.Uint64(buff))),
+		})
+	}
+
+	now := time.Now()
+
+	tcc.Lock()
+	defer tcc.Unlock()
+
+	for _, e := range entries {
+		remaining := e.expiry.Sub(now)
+		if remaining <= 0 {
+			continue
+		}
+
+		existing, found := tcc.data[e.key]
+		if found && existing.timestamp.Add(existing.span).After(e.expiry) {
+			continue
+		}
+
+		tcc.data[e.key] = &entry{
+			timestamp: now,
+			span:      remaining,
+		}
+	}
+
+	return nil
+}
+
+// SaveTo writes the keys which are not yet expired with their expiry time, so they can be loaded back with
+// LoadFrom after a restart
+func (tc *TimeCache) SaveTo(w io.Writer) error {
+	return tc.timeCache.saveTo(w)
+}
+
+// LoadFrom reads the keys written by SaveTo, each one keeping its saved expiry time. The keys which expired
+// between the save and the load are skipped. The expiries are wall clock times, so the clock of the loading node
+// should be in sync with the one of the saving node
+func (tc *TimeCache) LoadFrom(r io.Reader) error {
+	return tc.timeCache.loadFrom(r)
+}
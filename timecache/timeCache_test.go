@@ -1,6 +1,8 @@
 package timecache
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -245,3 +247,93 @@ func TestTimeCache_IsInterfaceNil(t *testing.T) {
 
 	assert.True(t, check.IfNil(tc))
 }
+
+// ------- SaveTo and LoadFrom
+
+func TestTimeCache_SaveToLoadFromShouldKeepExpiries(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Hour)
+	_ = tc.Add("default span")
+	_ = tc.AddWithSpan("short span", time.Minute)
+	_ = tc.AddWithSpan("expired", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	buff := &bytes.Buffer{}
+	err := tc.SaveTo(buff)
+	require.Nil(t, err)
+
+	loaded := NewTimeCache(time.Second)
+	err = loaded.LoadFrom(buff)
+	require.Nil(t, err)
+	assert.Equal(t, 2, loaded.Len())
+	assert.False(t, loaded.Has("expired"))
+
+	original, _ := tc.Value("default span")
+	recovered, _ := loaded.Value("default span")
+	assert.Equal(t, original.timestamp.Add(original.span).UnixNano(), recovered.timestamp.Add(recovered.span).UnixNano())
+
+	recovered, _ = loaded.Value("short span")
+	assert.True(t, recovered.span <= time.Minute)
+	assert.True(t, recovered.span > time.Minute-time.Second)
+}
+
+func TestTimeCache_LoadFromShouldSkipEntriesExpiredSinceSave(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Hour)
+	_ = tc.AddWithSpan("soon expired", time.Millisecond*20)
+	_ = tc.Add("kept")
+
+	buff := &bytes.Buffer{}
+	err := tc.SaveTo(buff)
+	require.Nil(t, err)
+
+	time.Sleep(time.Millisecond * 100)
+
+	loaded := NewTimeCache(time.Hour)
+	err = loaded.LoadFrom(buff)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"kept"}, loaded.Keys())
+}
+
+func TestTimeCache_LoadFromShouldKeepLaterExpiry(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Hour)
+	_ = tc.AddWithSpan("key", time.Minute)
+	buff := &bytes.Buffer{}
+	_ = tc.SaveTo(buff)
+
+	loaded := NewTimeCache(time.Hour)
+	_ = loaded.AddWithSpan("key", time.Hour)
+	err := loaded.LoadFrom(buff)
+	require.Nil(t, err)
+
+	recovered, _ := loaded.Value("key")
+	assert.Equal(t, time.Hour, recovered.span)
+}
+
+func TestTimeCache_LoadFromInvalidDataShouldErr(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Hour)
+	_ = tc.Add("key1")
+	_ = tc.Add("key2")
+	buff := &bytes.Buffer{}
+	_ = tc.SaveTo(buff)
+	saved := buff.Bytes()
+
+	loaded := NewTimeCache(time.Hour)
+	err := loaded.LoadFrom(bytes.NewReader(nil))
+	assert.True(t, errors.Is(err, common.ErrInvalidTimeCacheSnapshot))
+
+	wrongVersion := append([]byte{}, saved...)
+	wrongVersion[0] = 0xFF
+	err = loaded.LoadFrom(bytes.NewReader(wrongVersion))
+	assert.True(t, errors.Is(err, common.ErrInvalidTimeCacheSnapshot))
+
+	err = loaded.LoadFrom(bytes.NewReader(saved[:len(saved)-1]))
+	assert.True(t, errors.Is(err, common.ErrInvalidTimeCacheSnapshot))
+	assert.Equal(t, 0, loaded.Len())
+}
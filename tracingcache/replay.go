@@ -0,0 +1,74 @@
+package tracingcache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+// ReplayTrace feeds the accesses recorded by a tracing cache through the provided cache and returns the hits and
+// the misses of the replayed Get operations. The recorded key hashes are used as keys, a replayed Put storing an
+// empty value with the recorded size, so the size aware caches are evicting as they would on the real workload.
+// The provided cache should be empty and dedicated to the replay
+func ReplayTrace(traceFile string, cache types.Cacher) (common.CacheStats, error) {
+	if check.IfNil(cache) {
+		return common.CacheStats{}, common.ErrNilCacher
+	}
+
+	file, err := os.Open(traceFile)
+	if err != nil {
+		return common.CacheStats{}, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := bufio.NewReader(file)
+	version, err := reader.ReadByte()
+	if err != nil {
+		return common.CacheStats{}, fmt.Errorf("%w: %v", common.ErrInvalidTrace, err)
+	}
+	if version != traceVersion {
+		return common.CacheStats{}, fmt.Errorf("%w: unknown version %d", common.ErrInvalidTrace, version)
+	}
+
+	stats := common.CacheStats{}
+	record := make([]byte, traceRecordLength)
+	for {
+		_, err = io.ReadFull(reader, record)
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, fmt.Errorf("%w: %v", common.ErrInvalidTrace, err)
+		}
+
+		key := record[1:9]
+		switch record[0] {
+		case opGet:
+			_, ok := cache.Get(key)
+			if ok {
+				stats.Hits++
+			} else {
+				stats.Misses++
+			}
+		case opPut:
+			cache.Put(copyKey(key), struct{}{}, int(binary.BigEndian.Uint32(record[9:])))
+		default:
+			return stats, fmt.Errorf("%w: unknown operation %d", common.ErrInvalidTrace, record[0])
+		}
+	}
+}
+
+func copyKey(key []byte) []byte {
+	keyCopy := make([]byte, len(key))
+	copy(keyCopy, key)
+
+	return keyCopy
+}
@@ -0,0 +1,93 @@
+package tracingcache_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/tracingcache"
+	"github.com/stretchr/testify/require"
+)
+
+// recordTrace plays a workload over a few hot keys and many cold ones, reading each key before writing it on miss
+func recordTrace(t *testing.T, traceFile string) common.CacheStats {
+	inner, _ := lrucache.NewCache(100)
+	c, _ := tracingcache.NewTracingCache(inner, traceFile)
+
+	stats := common.CacheStats{}
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("hot_%d", i%10))
+		if i%2 == 1 {
+			key = []byte(fmt.Sprintf("cold_%d", i))
+		}
+
+		_, ok := c.Get(key)
+		if ok {
+			stats.Hits++
+			continue
+		}
+
+		stats.Misses++
+		c.Put(key, "value", 10)
+	}
+	require.Nil(t, c.Close())
+
+	return stats
+}
+
+func TestReplayTrace(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil cache should error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := tracingcache.ReplayTrace(filepath.Join(t.TempDir(), "trace"), nil)
+		require.Equal(t, common.ErrNilCacher, err)
+	})
+	t.Run("missing trace file should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		_, err := tracingcache.ReplayTrace(filepath.Join(t.TempDir(), "trace"), cache)
+		require.NotNil(t, err)
+	})
+	t.Run("corrupted trace should error", func(t *testing.T) {
+		t.Parallel()
+
+		traceFile := filepath.Join(t.TempDir(), "trace")
+		_ = recordTrace(t, traceFile)
+		content, _ := os.ReadFile(traceFile)
+		_ = os.WriteFile(traceFile, content[:len(content)-1], 0600)
+
+		cache, _ := lrucache.NewCache(10)
+		_, err := tracingcache.ReplayTrace(traceFile, cache)
+		require.True(t, errors.Is(err, common.ErrInvalidTrace))
+	})
+	t.Run("same cache config should reproduce the recorded stats", func(t *testing.T) {
+		t.Parallel()
+
+		traceFile := filepath.Join(t.TempDir(), "trace")
+		recorded := recordTrace(t, traceFile)
+
+		cache, _ := lrucache.NewCache(100)
+		replayed, err := tracingcache.ReplayTrace(traceFile, cache)
+		require.Nil(t, err)
+		require.Equal(t, recorded, replayed)
+	})
+	t.Run("smaller cache should lower the hit ratio", func(t *testing.T) {
+		t.Parallel()
+
+		traceFile := filepath.Join(t.TempDir(), "trace")
+		recorded := recordTrace(t, traceFile)
+
+		cache, _ := lrucache.NewCache(2)
+		replayed, err := tracingcache.ReplayTrace(traceFile, cache)
+		require.Nil(t, err)
+		require.Equal(t, recorded.Hits+recorded.Misses, replayed.Hits+replayed.Misses)
+		require.Less(t, replayed.HitRatio(), recorded.HitRatio())
+	})
+}
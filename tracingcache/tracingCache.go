@@ -0,0 +1,215 @@
+package tracingcache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/fnv"
+	"os"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*tracingCache)(nil)
+
+var log = logger.GetOrCreate("storage/tracingcache")
+
+const traceVersion = byte(1)
+
+const rwOwner = 0600
+
+const (
+	opGet = byte(1)
+	opPut = byte(2)
+)
+
+// operation + key hash + size in bytes
+const traceRecordLength = 1 + 8 + 4
+
+// tracingCache records the sequence of the keys read and written through it in a trace file, so the real access
+// pattern can be replayed offline against other cache configurations by ReplayTrace. The keys are recorded as
+// their 64 bits fnv hash, the values are not recorded. A failing trace write is logged once and stops the recording,
+// the cache operations being served by the inner cache regardless
+type tracingCache struct {
+	inner types.Cacher
+
+	mutTrace sync.Mutex
+	file     *os.File
+	writer   *bufio.Writer
+	errTrace error
+}
+
+// NewTracingCache creates a cache wrapper recording the Get and Put keys in the provided trace file, which is
+// truncated if it already exists. The trace is flushed to the file on Close
+func NewTracingCache(inner types.Cacher, traceFile string) (*tracingCache, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilCacher
+	}
+
+	file, err := os.OpenFile(traceFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, rwOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &tracingCache{
+		inner:  inner,
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}
+
+	err = tc.writer.WriteByte(traceVersion)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return tc, nil
+}
+
+func (tc *tracingCache) record(op byte, key []byte, sizeInBytes int) {
+	record := make([]byte, traceRecordLength)
+	record[0] = op
+	binary.BigEndian.PutUint64(record[1:9], hashKey(key))
+	binary.BigEndian.PutUint32(record[9:], uint32(sizeInBytes))
+
+	tc.mutTrace.Lock()
+	defer tc.mutTrace.Unlock()
+
+	if tc.errTrace != nil {
+		return
+	}
+
+	_, tc.errTrace = tc.writer.Write(record)
+	if tc.errTrace != nil {
+		log.Warn("tracing cache stopped recording", "error", tc.errTrace)
+	}
+}
+
+func hashKey(key []byte) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write(key)
+
+	return hasher.Sum64()
+}
+
+// Clear is used to completely clear the cache.
+func (tc *tracingCache) Clear() {
+	tc.inner.Clear()
+}
+
+// Put adds a value to the cache, recording the key.  Returns true if an eviction occurred.
+func (tc *tracingCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	tc.record(opPut, key, sizeInBytes)
+
+	return tc.inner.Put(key, value, sizeInBytes)
+}
+
+// Get looks up a key's value from the cache, recording the key.
+func (tc *tracingCache) Get(key []byte) (value interface{}, ok bool) {
+	tc.record(opGet, key, 0)
+
+	return tc.inner.Get(key)
+}
+
+// Has checks if a key is in the cache, without updating the
+// recent-ness or deleting it for being stale.
+func (tc *tracingCache) Has(key []byte) bool {
+	return tc.inner.Has(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the "recently used"-ness of the key.
+func (tc *tracingCache) Peek(key []byte) (value interface{}, ok bool) {
+	return tc.inner.Peek(key)
+}
+
+// HasOrAdd checks if a key is in the cache without updating the
+// recent-ness or deleting it for being stale, and if not adds the value.
+// An added value is recorded as a Put.
+func (tc *tracingCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	has, added = tc.inner.HasOrAdd(key, value, sizeInBytes)
+	if added {
+		tc.record(opPut, key, sizeInBytes)
+	}
+
+	return has, added
+}
+
+// Remove removes the provided key from the cache.
+func (tc *tracingCache) Remove(key []byte) {
+	tc.inner.Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (tc *tracingCache) Keys() [][]byte {
+	return tc.inner.Keys()
+}
+
+// Len returns the number of items in the cache.
+func (tc *tracingCache) Len() int {
+	return tc.inner.Len()
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (tc *tracingCache) SizeInBytesContained() uint64 {
+	return tc.inner.SizeInBytesContained()
+}
+
+// MaxSize returns the maximum number of items which can be stored in cache.
+func (tc *tracingCache) MaxSize() int {
+	return tc.inner.MaxSize()
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added
+func (tc *tracingCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	tc.inner.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandler removes the handler from the list
+func (tc *tracingCache) UnRegisterHandler(id string) {
+	tc.inner.UnRegisterHandler(id)
+}
+
+// Close flushes and closes the trace file, then closes the inner cache. The accesses done afterwards are no
+// longer recorded
+func (tc *tracingCache) Close() error {
+	tc.mutTrace.Lock()
+	errTrace := tc.closeTrace()
+	tc.mutTrace.Unlock()
+
+	err := tc.inner.Close()
+	if errTrace != nil {
+		return errTrace
+	}
+
+	return err
+}
+
+func (tc *tracingCache) closeTrace() error {
+	if tc.file == nil {
+		return nil
+	}
+
+	err := tc.errTrace
+	if err == nil {
+		err = tc.writer.Flush()
+	}
+
+	errClose := tc.file.Close()
+	if err == nil {
+		err = errClose
+	}
+
+	tc.file = nil
+	tc.errTrace = common.ErrTraceClosed
+
+	return err
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tc *tracingCache) IsInterfaceNil() bool {
+	return tc == nil
+}
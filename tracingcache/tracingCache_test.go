@@ -0,0 +1,89 @@
+package tracingcache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/lrucache"
+	"github.com/DharitriOne/drt-chain-storage-go/tracingcache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTracingCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil inner cache should error", func(t *testing.T) {
+		t.Parallel()
+
+		c, err := tracingcache.NewTracingCache(nil, filepath.Join(t.TempDir(), "trace"))
+		require.True(t, check.IfNil(c))
+		require.Equal(t, common.ErrNilCacher, err)
+	})
+	t.Run("invalid trace file should error", func(t *testing.T) {
+		t.Parallel()
+
+		inner, _ := lrucache.NewCache(10)
+		c, err := tracingcache.NewTracingCache(inner, filepath.Join(t.TempDir(), "missing", "trace"))
+		require.True(t, check.IfNil(c))
+		require.NotNil(t, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		inner, _ := lrucache.NewCache(10)
+		c, err := tracingcache.NewTracingCache(inner, filepath.Join(t.TempDir(), "trace"))
+		require.False(t, check.IfNil(c))
+		require.Nil(t, err)
+		require.Nil(t, c.Close())
+	})
+}
+
+func TestTracingCache_ShouldForwardToTheInnerCache(t *testing.T) {
+	t.Parallel()
+
+	inner, _ := lrucache.NewCache(10)
+	c, _ := tracingcache.NewTracingCache(inner, filepath.Join(t.TempDir(), "trace"))
+	defer func() {
+		_ = c.Close()
+	}()
+
+	c.Put([]byte("key"), "value", 5)
+	require.True(t, inner.Has([]byte("key")))
+	value, ok := c.Get([]byte("key"))
+	require.True(t, ok)
+	require.Equal(t, "value", value)
+
+	has, added := c.HasOrAdd([]byte("other"), "value", 5)
+	require.False(t, has)
+	require.True(t, added)
+	require.Equal(t, 2, c.Len())
+
+	c.Remove([]byte("key"))
+	require.False(t, inner.Has([]byte("key")))
+}
+
+func TestTracingCache_CloseShouldFlushTheTrace(t *testing.T) {
+	t.Parallel()
+
+	traceFile := filepath.Join(t.TempDir(), "trace")
+	inner, _ := lrucache.NewCache(10)
+	c, _ := tracingcache.NewTracingCache(inner, traceFile)
+
+	c.Put([]byte("key"), "value", 5)
+	_, _ = c.Get([]byte("key"))
+	_, _ = c.Get([]byte("missing"))
+	_ = c.Has([]byte("key"))
+	require.Nil(t, c.Close())
+
+	info, err := os.Stat(traceFile)
+	require.Nil(t, err)
+	require.Equal(t, int64(1+3*13), info.Size())
+
+	require.Nil(t, c.Close())
+	c.Put([]byte("key"), "value", 5)
+	info, _ = os.Stat(traceFile)
+	require.Equal(t, int64(1+3*13), info.Size())
+}
@@ -0,0 +1,177 @@
+package tracingpersister
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*tracingPersister)(nil)
+var _ types.ContextPersister = (*tracingPersister)(nil)
+
+const (
+	// OpPut is the operation name reported for Put calls
+	OpPut = "Put"
+	// OpGet is the operation name reported for Get calls
+	OpGet = "Get"
+	// OpHas is the operation name reported for Has calls
+	OpHas = "Has"
+	// OpRemove is the operation name reported for Remove calls
+	OpRemove = "Remove"
+	// OpRangeKeys is the operation name reported for RangeKeys calls
+	OpRangeKeys = "RangeKeys"
+	// OpClose is the operation name reported for Close calls
+	OpClose = "Close"
+	// OpDestroy is the operation name reported for Destroy calls
+	OpDestroy = "Destroy"
+	// OpDestroyClosed is the operation name reported for DestroyClosed calls
+	OpDestroyClosed = "DestroyClosed"
+)
+
+// ErrNilOnOpHandler signals that a nil operation hook was provided
+var ErrNilOnOpHandler = errors.New("nil on operation handler")
+
+// OnOpHandler is called after each persister operation with the operation context, name, key (nil for the
+// operations not targeting a key), duration and resulting error
+type OnOpHandler func(ctx context.Context, op string, key []byte, dur time.Duration, err error)
+
+type tracingPersister struct {
+	persister    types.Persister
+	ctxPersister types.ContextPersister
+	onOp         OnOpHandler
+}
+
+// NewTracingPersister creates a persister wrapper that reports every operation, with its timing and error, to the
+// provided hook. The context aware variants pass their context to the hook, the other methods pass
+// context.Background(). If the inner persister implements types.ContextPersister, the context is forwarded to it
+func NewTracingPersister(inner types.Persister, onOp OnOpHandler) (*tracingPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	if onOp == nil {
+		return nil, ErrNilOnOpHandler
+	}
+
+	ctxPersister, _ := inner.(types.ContextPersister)
+
+	return &tracingPersister{
+		persister:    inner,
+		ctxPersister: ctxPersister,
+		onOp:         onOp,
+	}, nil
+}
+
+func (tp *tracingPersister) trace(ctx context.Context, op string, key []byte, handler func() error) error {
+	start := time.Now()
+	err := handler()
+	tp.onOp(ctx, op, key, time.Since(start), err)
+
+	return err
+}
+
+// PutCtx adds the value to the inner persister, reporting the operation with the provided context
+func (tp *tracingPersister) PutCtx(ctx context.Context, key, val []byte) error {
+	return tp.trace(ctx, OpPut, key, func() error {
+		if tp.ctxPersister != nil {
+			return tp.ctxPersister.PutCtx(ctx, key, val)
+		}
+
+		return tp.persister.Put(key, val)
+	})
+}
+
+// GetCtx returns the value from the inner persister, reporting the operation with the provided context
+func (tp *tracingPersister) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	var val []byte
+	err := tp.trace(ctx, OpGet, key, func() error {
+		var errGet error
+		if tp.ctxPersister != nil {
+			val, errGet = tp.ctxPersister.GetCtx(ctx, key)
+			return errGet
+		}
+
+		val, errGet = tp.persister.Get(key)
+		return errGet
+	})
+
+	return val, err
+}
+
+// HasCtx checks the key in the inner persister, reporting the operation with the provided context
+func (tp *tracingPersister) HasCtx(ctx context.Context, key []byte) error {
+	return tp.trace(ctx, OpHas, key, func() error {
+		if tp.ctxPersister != nil {
+			return tp.ctxPersister.HasCtx(ctx, key)
+		}
+
+		return tp.persister.Has(key)
+	})
+}
+
+// RemoveCtx removes the key from the inner persister, reporting the operation with the provided context
+func (tp *tracingPersister) RemoveCtx(ctx context.Context, key []byte) error {
+	return tp.trace(ctx, OpRemove, key, func() error {
+		if tp.ctxPersister != nil {
+			return tp.ctxPersister.RemoveCtx(ctx, key)
+		}
+
+		return tp.persister.Remove(key)
+	})
+}
+
+// RangeKeysCtx iterates over the inner persister pairs, reporting the whole iteration with the provided context
+func (tp *tracingPersister) RangeKeysCtx(ctx context.Context, handler func(key []byte, val []byte) bool) {
+	_ = tp.trace(ctx, OpRangeKeys, nil, func() error {
+		tp.persister.RangeKeys(handler)
+		return nil
+	})
+}
+
+// Put adds the value to the inner persister
+func (tp *tracingPersister) Put(key, val []byte) error {
+	return tp.PutCtx(context.Background(), key, val)
+}
+
+// Get returns the value from the inner persister
+func (tp *tracingPersister) Get(key []byte) ([]byte, error) {
+	return tp.GetCtx(context.Background(), key)
+}
+
+// Has returns nil if the key is found in the inner persister
+func (tp *tracingPersister) Has(key []byte) error {
+	return tp.HasCtx(context.Background(), key)
+}
+
+// Remove removes the key from the inner persister
+func (tp *tracingPersister) Remove(key []byte) error {
+	return tp.RemoveCtx(context.Background(), key)
+}
+
+// RangeKeys iterates over the inner persister pairs
+func (tp *tracingPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	tp.RangeKeysCtx(context.Background(), handler)
+}
+
+// Close closes the inner persister
+func (tp *tracingPersister) Close() error {
+	return tp.trace(context.Background(), OpClose, nil, tp.persister.Close)
+}
+
+// Destroy removes the inner persister data
+func (tp *tracingPersister) Destroy() error {
+	return tp.trace(context.Background(), OpDestroy, nil, tp.persister.Destroy)
+}
+
+// DestroyClosed removes the already closed inner persister data
+func (tp *tracingPersister) DestroyClosed() error {
+	return tp.trace(context.Background(), OpDestroyClosed, nil, tp.persister.DestroyClosed)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tp *tracingPersister) IsInterfaceNil() bool {
+	return tp == nil
+}
@@ -0,0 +1,148 @@
+package tracingpersister_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/leveldb"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/tracingpersister"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey string
+
+type tracedOp struct {
+	ctx context.Context
+	op  string
+	key []byte
+	dur time.Duration
+	err error
+}
+
+func createRecordingHook(ops *[]tracedOp) tracingpersister.OnOpHandler {
+	return func(ctx context.Context, op string, key []byte, dur time.Duration, err error) {
+		*ops = append(*ops, tracedOp{ctx: ctx, op: op, key: key, dur: dur, err: err})
+	}
+}
+
+func TestNewTracingPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := tracingpersister.NewTracingPersister(nil, createRecordingHook(&[]tracedOp{}))
+		require.True(t, check.IfNil(tp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("nil hook should error", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := tracingpersister.NewTracingPersister(memorydb.New(), nil)
+		require.True(t, check.IfNil(tp))
+		require.Equal(t, tracingpersister.ErrNilOnOpHandler, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := tracingpersister.NewTracingPersister(memorydb.New(), createRecordingHook(&[]tracedOp{}))
+		require.False(t, check.IfNil(tp))
+		require.Nil(t, err)
+	})
+}
+
+func TestTracingPersister_ContextMethodsShouldPassTheContext(t *testing.T) {
+	t.Parallel()
+
+	ops := make([]tracedOp, 0)
+	tp, _ := tracingpersister.NewTracingPersister(memorydb.New(), createRecordingHook(&ops))
+	ctx := context.WithValue(context.Background(), ctxKey("span"), "span-id")
+
+	require.Nil(t, tp.PutCtx(ctx, []byte("key"), []byte("value")))
+	val, err := tp.GetCtx(ctx, []byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), val)
+	require.Nil(t, tp.HasCtx(ctx, []byte("key")))
+	tp.RangeKeysCtx(ctx, func(key []byte, val []byte) bool {
+		return true
+	})
+	require.Nil(t, tp.RemoveCtx(ctx, []byte("key")))
+
+	expectedOps := []string{
+		tracingpersister.OpPut,
+		tracingpersister.OpGet,
+		tracingpersister.OpHas,
+		tracingpersister.OpRangeKeys,
+		tracingpersister.OpRemove,
+	}
+	require.Equal(t, len(expectedOps), len(ops))
+	for idx, traced := range ops {
+		require.Equal(t, expectedOps[idx], traced.op)
+		require.Equal(t, "span-id", traced.ctx.Value(ctxKey("span")))
+		require.Nil(t, traced.err)
+	}
+	require.Equal(t, []byte("key"), ops[0].key)
+	require.Nil(t, ops[3].key)
+}
+
+func TestTracingPersister_ContextMethodsShouldForwardTheContext(t *testing.T) {
+	t.Parallel()
+
+	inner, _ := leveldb.NewSerialDB(t.TempDir(), 10, 1, 10)
+	defer func() {
+		_ = inner.Close()
+	}()
+	ops := make([]tracedOp, 0)
+	tp, _ := tracingpersister.NewTracingPersister(inner, createRecordingHook(&ops))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.Equal(t, context.Canceled, tp.PutCtx(ctx, []byte("key"), []byte("value")))
+	require.Equal(t, 1, len(ops))
+	require.Equal(t, context.Canceled, ops[0].err)
+	require.NotNil(t, inner.Has([]byte("key")))
+}
+
+func TestTracingPersister_ShouldReportErrorsAndDuration(t *testing.T) {
+	t.Parallel()
+
+	expectedErr := errors.New("expected error")
+	inner := &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			time.Sleep(time.Millisecond * 10)
+			return nil, expectedErr
+		},
+	}
+	ops := make([]tracedOp, 0)
+	tp, _ := tracingpersister.NewTracingPersister(inner, createRecordingHook(&ops))
+
+	_, err := tp.Get([]byte("key"))
+	require.Equal(t, expectedErr, err)
+	require.Equal(t, 1, len(ops))
+	require.Equal(t, tracingpersister.OpGet, ops[0].op)
+	require.Equal(t, expectedErr, ops[0].err)
+	require.Equal(t, context.Background(), ops[0].ctx)
+	require.GreaterOrEqual(t, ops[0].dur, time.Millisecond*10)
+}
+
+func TestTracingPersister_LifecycleMethodsShouldBeTraced(t *testing.T) {
+	t.Parallel()
+
+	ops := make([]tracedOp, 0)
+	tp, _ := tracingpersister.NewTracingPersister(&testscommon.PersisterStub{}, createRecordingHook(&ops))
+
+	require.Nil(t, tp.Close())
+	require.Nil(t, tp.DestroyClosed())
+	require.Nil(t, tp.Destroy())
+
+	require.Equal(t, 3, len(ops))
+	require.Equal(t, tracingpersister.OpClose, ops[0].op)
+	require.Equal(t, tracingpersister.OpDestroyClosed, ops[1].op)
+	require.Equal(t, tracingpersister.OpDestroy, ops[2].op)
+}
@@ -0,0 +1,8 @@
+package ttlpersister
+
+import "time"
+
+// SetNowFunc sets the function providing the current time
+func (tp *ttlPersister) SetNowFunc(nowFunc func() time.Time) {
+	tp.nowFunc = nowFunc
+}
@@ -0,0 +1,383 @@
+package ttlpersister
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*ttlPersister)(nil)
+
+var log = logger.GetOrCreate("storage/ttlpersister")
+
+const (
+	dataPrefix      = byte('d')
+	metadataPrefix  = byte('m')
+	writeTimePrefix = byte('w')
+	timeLength      = 8
+)
+
+// ErrInvalidTTL signals that a non-positive time to live was provided
+var ErrInvalidTTL = errors.New("invalid time to live")
+
+// ErrInvalidExpiryMetadata signals that the stored expiry metadata could not be decoded
+var ErrInvalidExpiryMetadata = errors.New("invalid expiry metadata")
+
+// ErrInvalidWriteTimeMetadata signals that the stored write time metadata could not be decoded
+var ErrInvalidWriteTimeMetadata = errors.New("invalid write time metadata")
+
+// ErrWriteTimesNotTracked signals that the write time of a key was requested while the write times are not tracked
+var ErrWriteTimesNotTracked = errors.New("write times are not tracked")
+
+// ErrWriteTimeNotFound signals that the key exists but has no recorded write time, being written while the write
+// times were not tracked
+var ErrWriteTimeNotFound = errors.New("write time not found")
+
+// ttlPersister stores an optional expiry time for each key in a parallel metadata key space of the inner persister,
+// instead of prepending it to the value, so the stored value bytes are exactly the provided ones and the content
+// hashes computed over them stay valid. The expired keys are hidden from Get, Has and RangeKeys and are physically
+// deleted by PurgeExpired. The keys of the inner persister are prefixed by this wrapper, so the inner persister
+// should not be shared with other writers.
+// Optionally, the time of the last Put or PutWithTTL of each key is recorded in another parallel key space and
+// returned by LastModified, allowing age based policies. A key written while the write times were not tracked has
+// no write time.
+// If the inner persister implements types.BatchApplier, a value, its expiry and its write time are written atomically
+type ttlPersister struct {
+	mutWrite        sync.Mutex
+	inner           types.Persister
+	trackWriteTimes bool
+	nowFunc         func() time.Time
+}
+
+// Option configures the ttl persister
+type Option func(tp *ttlPersister)
+
+// WithWriteTimeTracking records the time of the last Put or PutWithTTL of each key, returned by LastModified
+func WithWriteTimeTracking() Option {
+	return func(tp *ttlPersister) {
+		tp.trackWriteTimes = true
+	}
+}
+
+// NewTTLPersister creates a persister wrapper supporting expiring keys
+func NewTTLPersister(inner types.Persister, options ...Option) (*ttlPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+
+	tp := &ttlPersister{
+		inner:   inner,
+		nowFunc: time.Now,
+	}
+	for _, option := range options {
+		option(tp)
+	}
+
+	return tp, nil
+}
+
+// Put adds the value without expiry, dropping the expiry previously set on the key, if any
+func (tp *ttlPersister) Put(key, val []byte) error {
+	tp.mutWrite.Lock()
+	defer tp.mutWrite.Unlock()
+
+	return tp.write(tp.withWriteTime(key, []types.Operation{
+		{Type: types.PutOperation, Key: dataKey(key), Value: val},
+		{Type: types.RemoveOperation, Key: metadataKey(key)},
+	}))
+}
+
+// PutWithTTL adds the value, expiring it after the provided time to live
+func (tp *ttlPersister) PutWithTTL(key, val []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	tp.mutWrite.Lock()
+	defer tp.mutWrite.Unlock()
+
+	return tp.write(tp.withWriteTime(key, []types.Operation{
+		{Type: types.PutOperation, Key: dataKey(key), Value: val},
+		{Type: types.PutOperation, Key: metadataKey(key), Value: encodeTime(tp.nowFunc().Add(ttl))},
+	}))
+}
+
+// withWriteTime records the write time of the key or, if the write times are not tracked, drops the one recorded
+// while they were, so it does not outlive the write
+func (tp *ttlPersister) withWriteTime(key []byte, ops []types.Operation) []types.Operation {
+	if !tp.trackWriteTimes {
+		return tp.withoutWriteTime(key, ops)
+	}
+
+	return append(ops, types.Operation{
+		Type:  types.PutOperation,
+		Key:   writeTimeKey(key),
+		Value: encodeTime(tp.nowFunc()),
+	})
+}
+
+func (tp *ttlPersister) withoutWriteTime(key []byte, ops []types.Operation) []types.Operation {
+	return append(ops, types.Operation{
+		Type: types.RemoveOperation,
+		Key:  writeTimeKey(key),
+	})
+}
+
+// SetExpiry sets the expiry time of an existing key, without rewriting its value
+func (tp *ttlPersister) SetExpiry(key []byte, expireAt time.Time) error {
+	tp.mutWrite.Lock()
+	defer tp.mutWrite.Unlock()
+
+	err := tp.Has(key)
+	if err != nil {
+		return err
+	}
+
+	return tp.inner.Put(metadataKey(key), encodeTime(expireAt))
+}
+
+// Expiry returns the expiry time of the key and whether one is set
+func (tp *ttlPersister) Expiry(key []byte) (time.Time, bool, error) {
+	expireAt, hasExpiry, err := tp.getExpiry(key)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if !hasExpiry {
+		return time.Time{}, false, nil
+	}
+
+	return time.Unix(0, expireAt), true, nil
+}
+
+// LastModified returns the time of the last Put or PutWithTTL of the key, common.ErrKeyNotFound if the key is
+// missing or expired and ErrWriteTimeNotFound if the key was written while the write times were not tracked
+func (tp *ttlPersister) LastModified(key []byte) (time.Time, error) {
+	if !tp.trackWriteTimes {
+		return time.Time{}, ErrWriteTimesNotTracked
+	}
+
+	err := tp.Has(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	metadata, err := tp.inner.Get(writeTimeKey(key))
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return time.Time{}, ErrWriteTimeNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	writeTime, ok := decodeTime(metadata)
+	if !ok {
+		return time.Time{}, ErrInvalidWriteTimeMetadata
+	}
+
+	return time.Unix(0, writeTime), nil
+}
+
+func (tp *ttlPersister) getExpiry(key []byte) (int64, bool, error) {
+	metadata, err := tp.inner.Get(metadataKey(key))
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	expireAt, ok := decodeTime(metadata)
+	if !ok {
+		return 0, false, ErrInvalidExpiryMetadata
+	}
+
+	return expireAt, true, nil
+}
+
+// Get returns the value of the key, or common.ErrKeyNotFound if the key is missing or expired
+func (tp *ttlPersister) Get(key []byte) ([]byte, error) {
+	expireAt, hasExpiry, err := tp.getExpiry(key)
+	if err != nil {
+		return nil, err
+	}
+	if hasExpiry && tp.isExpired(expireAt) {
+		return nil, common.ErrKeyNotFound
+	}
+
+	return tp.inner.Get(dataKey(key))
+}
+
+// Has returns nil if the key is present and not expired
+func (tp *ttlPersister) Has(key []byte) error {
+	expireAt, hasExpiry, err := tp.getExpiry(key)
+	if err != nil {
+		return err
+	}
+	if hasExpiry && tp.isExpired(expireAt) {
+		return common.ErrKeyNotFound
+	}
+
+	return tp.inner.Has(dataKey(key))
+}
+
+// Remove deletes the value, the expiry and the write time of the key
+func (tp *ttlPersister) Remove(key []byte) error {
+	tp.mutWrite.Lock()
+	defer tp.mutWrite.Unlock()
+
+	return tp.write(tp.withoutWriteTime(key, []types.Operation{
+		{Type: types.RemoveOperation, Key: dataKey(key)},
+		{Type: types.RemoveOperation, Key: metadataKey(key)},
+	}))
+}
+
+// PurgeExpired physically deletes the expired keys, returning their number
+func (tp *ttlPersister) PurgeExpired() (int, error) {
+	tp.mutWrite.Lock()
+	defer tp.mutWrite.Unlock()
+
+	expiredKeys := tp.expiredKeys()
+	for idx, key := range expiredKeys {
+		err := tp.write(tp.withoutWriteTime([]byte(key), []types.Operation{
+			{Type: types.RemoveOperation, Key: dataKey([]byte(key))},
+			{Type: types.RemoveOperation, Key: metadataKey([]byte(key))},
+		}))
+		if err != nil {
+			return idx, err
+		}
+	}
+
+	return len(expiredKeys), nil
+}
+
+func (tp *ttlPersister) expiredKeys() []string {
+	expiredKeys := make([]string, 0)
+	tp.inner.RangeKeys(func(key []byte, val []byte) bool {
+		if len(key) == 0 || key[0] != metadataPrefix {
+			return true
+		}
+
+		expireAt, ok := decodeTime(val)
+		if !ok {
+			log.Warn("ttlPersister: invalid expiry metadata", "key", key[1:], "error", ErrInvalidExpiryMetadata)
+			return true
+		}
+		if tp.isExpired(expireAt) {
+			expiredKeys = append(expiredKeys, string(key[1:]))
+		}
+
+		return true
+	})
+
+	return expiredKeys
+}
+
+// RangeKeys iterates over the pairs that are not expired
+func (tp *ttlPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	expiredKeys := make(map[string]struct{})
+	for _, key := range tp.expiredKeys() {
+		expiredKeys[key] = struct{}{}
+	}
+
+	tp.inner.RangeKeys(func(key []byte, val []byte) bool {
+		if len(key) == 0 || key[0] != dataPrefix {
+			return true
+		}
+
+		_, isExpired := expiredKeys[string(key[1:])]
+		if isExpired {
+			return true
+		}
+
+		return handler(key[1:], val)
+	})
+}
+
+func (tp *ttlPersister) write(ops []types.Operation) error {
+	batchApplier, ok := tp.inner.(types.BatchApplier)
+	if ok {
+		return batchApplier.ApplyBatch(ops)
+	}
+
+	for _, op := range ops {
+		var err error
+		if op.Type == types.PutOperation {
+			err = tp.inner.Put(op.Key, op.Value)
+		} else {
+			err = tp.inner.Remove(op.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tp *ttlPersister) isExpired(expireAt int64) bool {
+	return tp.nowFunc().UnixNano() >= expireAt
+}
+
+// Close closes the inner persister
+func (tp *ttlPersister) Close() error {
+	return tp.inner.Close()
+}
+
+// Destroy removes the inner persister data
+func (tp *ttlPersister) Destroy() error {
+	return tp.inner.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister data
+func (tp *ttlPersister) DestroyClosed() error {
+	return tp.inner.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (tp *ttlPersister) IsInterfaceNil() bool {
+	return tp == nil
+}
+
+func dataKey(key []byte) []byte {
+	return prefixedKey(dataPrefix, key)
+}
+
+func metadataKey(key []byte) []byte {
+	return prefixedKey(metadataPrefix, key)
+}
+
+func writeTimeKey(key []byte) []byte {
+	return prefixedKey(writeTimePrefix, key)
+}
+
+func prefixedKey(prefix byte, key []byte) []byte {
+	prefixed := make([]byte, 0, 1+len(key))
+	prefixed = append(prefixed, prefix)
+
+	return append(prefixed, key...)
+}
+
+func encodeTime(t time.Time) []byte {
+	buff := make([]byte, timeLength)
+	binary.BigEndian.PutUint64(buff, uint64(t.UnixNano()))
+
+	return buff
+}
+
+func decodeTime(metadata []byte) (int64, bool) {
+	if len(metadata) != timeLength {
+		return 0, false
+	}
+
+	return int64(binary.BigEndian.Uint64(metadata)), true
+}
@@ -0,0 +1,254 @@
+package ttlpersister_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/ttlpersister"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func rangedPairs(ranger interface {
+	RangeKeys(handler func(key []byte, val []byte) bool)
+}) map[string]string {
+	pairs := make(map[string]string)
+	ranger.RangeKeys(func(key []byte, val []byte) bool {
+		pairs[string(key)] = string(val)
+		return true
+	})
+
+	return pairs
+}
+
+func TestNewTTLPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := ttlpersister.NewTTLPersister(nil)
+		require.True(t, check.IfNil(tp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		tp, err := ttlpersister.NewTTLPersister(memorydb.New())
+		require.False(t, check.IfNil(tp))
+		require.Nil(t, err)
+	})
+}
+
+func TestTTLPersister_ExpiredKeysShouldBeHidden(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Unix(1000, 0)}
+	tp, _ := ttlpersister.NewTTLPersister(memorydb.New())
+	tp.SetNowFunc(c.Now)
+
+	require.Equal(t, ttlpersister.ErrInvalidTTL, tp.PutWithTTL([]byte("key"), []byte("value"), 0))
+	require.Nil(t, tp.PutWithTTL([]byte("expiring"), []byte("value1"), time.Minute))
+	require.Nil(t, tp.Put([]byte("permanent"), []byte("value2")))
+
+	expireAt, hasExpiry, err := tp.Expiry([]byte("expiring"))
+	require.Nil(t, err)
+	require.True(t, hasExpiry)
+	require.Equal(t, c.now.Add(time.Minute), expireAt)
+	_, hasExpiry, err = tp.Expiry([]byte("permanent"))
+	require.Nil(t, err)
+	require.False(t, hasExpiry)
+
+	val, err := tp.Get([]byte("expiring"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value1"), val)
+	require.Equal(t, map[string]string{"expiring": "value1", "permanent": "value2"}, rangedPairs(tp))
+
+	c.now = c.now.Add(time.Minute)
+	_, err = tp.Get([]byte("expiring"))
+	require.True(t, errors.Is(err, common.ErrKeyNotFound))
+	require.True(t, errors.Is(tp.Has([]byte("expiring")), common.ErrKeyNotFound))
+	require.Nil(t, tp.Has([]byte("permanent")))
+	require.Equal(t, map[string]string{"permanent": "value2"}, rangedPairs(tp))
+}
+
+func TestTTLPersister_ValuesShouldBeStoredUnchanged(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	tp, _ := ttlpersister.NewTTLPersister(inner)
+	value := []byte("content addressed value")
+	require.Nil(t, tp.PutWithTTL([]byte("key"), value, time.Hour))
+
+	numValues := 0
+	inner.RangeKeys(func(key []byte, val []byte) bool {
+		if string(key[1:]) == "key" && len(val) == len(value) {
+			require.Equal(t, value, val)
+			numValues++
+		}
+		return true
+	})
+	require.Equal(t, 1, numValues)
+}
+
+func TestTTLPersister_SetExpiryAndPut(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Unix(1000, 0)}
+	tp, _ := ttlpersister.NewTTLPersister(memorydb.New())
+	tp.SetNowFunc(c.Now)
+
+	err := tp.SetExpiry([]byte("missing"), c.now)
+	require.True(t, errors.Is(err, common.ErrKeyNotFound))
+
+	require.Nil(t, tp.Put([]byte("key"), []byte("value")))
+	require.Nil(t, tp.SetExpiry([]byte("key"), c.now))
+	require.True(t, errors.Is(tp.Has([]byte("key")), common.ErrKeyNotFound))
+
+	// a put without ttl drops the expiry
+	require.Nil(t, tp.Put([]byte("key"), []byte("value")))
+	require.Nil(t, tp.Has([]byte("key")))
+
+	require.Nil(t, tp.Remove([]byte("key")))
+	require.True(t, errors.Is(tp.Has([]byte("key")), common.ErrKeyNotFound))
+}
+
+func TestTTLPersister_PurgeExpired(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Unix(1000, 0)}
+	inner := memorydb.New()
+	tp, _ := ttlpersister.NewTTLPersister(inner)
+	tp.SetNowFunc(c.Now)
+
+	_ = tp.PutWithTTL([]byte("key1"), []byte("value"), time.Second)
+	_ = tp.PutWithTTL([]byte("key2"), []byte("value"), time.Hour)
+	_ = tp.Put([]byte("key3"), []byte("value"))
+
+	c.now = c.now.Add(time.Minute)
+	numPurged, err := tp.PurgeExpired()
+	require.Nil(t, err)
+	require.Equal(t, 1, numPurged)
+	require.Equal(t, 3, len(rangedPairs(inner)))
+	require.Equal(t, map[string]string{"key2": "value", "key3": "value"}, rangedPairs(tp))
+}
+
+func TestTTLPersister_WithoutBatchApplierShouldWork(t *testing.T) {
+	t.Parallel()
+
+	tp, _ := ttlpersister.NewTTLPersister(testscommon.NewMemDbMock())
+	require.Nil(t, tp.PutWithTTL([]byte("key"), []byte("value"), time.Hour))
+
+	val, err := tp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), val)
+}
+
+func TestTTLPersister_LastModified(t *testing.T) {
+	t.Parallel()
+
+	t.Run("untracked write times should error", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		tp, _ := ttlpersister.NewTTLPersister(inner)
+		_ = tp.Put([]byte("key"), []byte("value"))
+
+		_, err := tp.LastModified([]byte("key"))
+		require.Equal(t, ttlpersister.ErrWriteTimesNotTracked, err)
+		require.Equal(t, 1, len(rangedPairs(inner)))
+	})
+	t.Run("should return the time of the last write", func(t *testing.T) {
+		t.Parallel()
+
+		c := &clock{now: time.Unix(1000, 0)}
+		tp, _ := ttlpersister.NewTTLPersister(memorydb.New(), ttlpersister.WithWriteTimeTracking())
+		tp.SetNowFunc(c.Now)
+
+		_ = tp.Put([]byte("key"), []byte("value"))
+		writeTime, err := tp.LastModified([]byte("key"))
+		require.Nil(t, err)
+		require.True(t, writeTime.Equal(time.Unix(1000, 0)))
+
+		c.now = c.now.Add(time.Minute)
+		_ = tp.PutWithTTL([]byte("key"), []byte("value"), time.Hour)
+		writeTime, _ = tp.LastModified([]byte("key"))
+		require.True(t, writeTime.Equal(time.Unix(1060, 0)))
+
+		c.now = c.now.Add(time.Minute)
+		_ = tp.SetExpiry([]byte("key"), c.now.Add(time.Hour))
+		writeTime, _ = tp.LastModified([]byte("key"))
+		require.True(t, writeTime.Equal(time.Unix(1060, 0)))
+
+		require.Equal(t, map[string]string{"key": "value"}, rangedPairs(tp))
+	})
+	t.Run("missing, removed or expired key should error", func(t *testing.T) {
+		t.Parallel()
+
+		c := &clock{now: time.Unix(1000, 0)}
+		inner := memorydb.New()
+		tp, _ := ttlpersister.NewTTLPersister(inner, ttlpersister.WithWriteTimeTracking())
+		tp.SetNowFunc(c.Now)
+
+		_, err := tp.LastModified([]byte("missing"))
+		require.True(t, errors.Is(err, common.ErrKeyNotFound))
+
+		_ = tp.Put([]byte("removed"), []byte("value"))
+		_ = tp.Remove([]byte("removed"))
+		_, err = tp.LastModified([]byte("removed"))
+		require.True(t, errors.Is(err, common.ErrKeyNotFound))
+
+		_ = tp.PutWithTTL([]byte("expired"), []byte("value"), time.Second)
+		c.now = c.now.Add(time.Minute)
+		_, err = tp.LastModified([]byte("expired"))
+		require.True(t, errors.Is(err, common.ErrKeyNotFound))
+
+		_, _ = tp.PurgeExpired()
+		require.Equal(t, 0, len(rangedPairs(inner)))
+	})
+	t.Run("key written while untracked should error", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		untracked, _ := ttlpersister.NewTTLPersister(inner)
+		_ = untracked.Put([]byte("key"), []byte("value"))
+
+		tp, _ := ttlpersister.NewTTLPersister(inner, ttlpersister.WithWriteTimeTracking())
+		_, err := tp.LastModified([]byte("key"))
+		require.Equal(t, ttlpersister.ErrWriteTimeNotFound, err)
+	})
+	t.Run("reopening with the tracking toggled should not return stale write times", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		tracked, _ := ttlpersister.NewTTLPersister(inner, ttlpersister.WithWriteTimeTracking())
+		_ = tracked.Put([]byte("rewritten"), []byte("value"))
+		_ = tracked.Put([]byte("removed"), []byte("value"))
+		_ = tracked.Put([]byte("kept"), []byte("value"))
+
+		untracked, _ := ttlpersister.NewTTLPersister(inner)
+		_ = untracked.Put([]byte("rewritten"), []byte("new value"))
+		_ = untracked.Remove([]byte("removed"))
+		_ = untracked.Put([]byte("removed"), []byte("new value"))
+
+		reopened, _ := ttlpersister.NewTTLPersister(inner, ttlpersister.WithWriteTimeTracking())
+		_, err := reopened.LastModified([]byte("rewritten"))
+		require.Equal(t, ttlpersister.ErrWriteTimeNotFound, err)
+		_, err = reopened.LastModified([]byte("removed"))
+		require.Equal(t, ttlpersister.ErrWriteTimeNotFound, err)
+		_, err = reopened.LastModified([]byte("kept"))
+		require.Nil(t, err)
+	})
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/DharitriOne/drt-chain-core-go/data"
@@ -101,6 +103,131 @@ type Storer interface {
 	IsInterfaceNil() bool
 }
 
+// OperationType defines the type of an operation contained in a batch
+type OperationType uint8
+
+const (
+	// PutOperation stores the value under the key
+	PutOperation OperationType = iota
+	// RemoveOperation removes the key
+	RemoveOperation
+)
+
+// Operation defines a Put or Remove operation that can be applied as part of a batch
+type Operation struct {
+	Type  OperationType
+	Key   []byte
+	Value []byte
+}
+
+// BatchApplier defines a persister able to atomically apply a batch of mixed Put and Remove operations
+type BatchApplier interface {
+	ApplyBatch(ops []Operation) error
+}
+
+// PrefixCounter defines a persister able to count the keys starting with a given prefix
+type PrefixCounter interface {
+	CountPrefix(prefix []byte) (uint64, error)
+}
+
+// PrefixDiskSizer defines a persister able to estimate the size on disk of the keys starting with a given prefix
+type PrefixDiskSizer interface {
+	PrefixDiskSize(prefix []byte) (uint64, error)
+}
+
+// EntryStatsProvider defines a persister able to report its live keys and estimate its obsolete entries
+type EntryStatsProvider interface {
+	EntryStats() (live uint64, estimatedDeleted uint64, err error)
+}
+
+// PrefixRanger defines a persister able to iterate only over the keys starting with a given prefix
+type PrefixRanger interface {
+	RangePrefix(prefix []byte, handler func(key []byte, value []byte) bool)
+}
+
+// Truncater defines a persister able to delete all its keys while remaining opened
+type Truncater interface {
+	Truncate() error
+}
+
+// Snapshot defines a read-only view of a persister as of the snapshot creation
+type Snapshot interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) error
+	RangeKeys(handler func(key []byte, value []byte) bool)
+	Release()
+	IsInterfaceNil() bool
+}
+
+// Snapshotter defines a persister able to create consistent read-only snapshots
+type Snapshotter interface {
+	Snapshot() (Snapshot, error)
+}
+
+// Checkpointer defines a persister able to create a consistent copy of its files in another directory
+type Checkpointer interface {
+	Checkpoint(destDir string) error
+}
+
+// MultiGetter defines a persister able to read several keys in a single pass
+type MultiGetter interface {
+	GetExisting(keys [][]byte) (map[string][]byte, error)
+}
+
+// CacheRanger defines a cache able to iterate over its entries without changing their eviction order
+type CacheRanger interface {
+	Range(handler func(key []byte, value interface{}) bool)
+}
+
+// CacheEntry defines a (key, value) pair to be added in a cache, along with its size in bytes
+type CacheEntry struct {
+	Key         []byte
+	Value       interface{}
+	SizeInBytes int
+}
+
+// CacheBatchPutter defines a cache able to add several entries at once, evicting only after the last one
+type CacheBatchPutter interface {
+	PutBatch(entries []CacheEntry) (evicted bool)
+}
+
+// CacheBatchGetter defines a cache able to look up several keys at once, returning only the found ones
+type CacheBatchGetter interface {
+	GetMulti(keys [][]byte) map[string]interface{}
+}
+
+// FillRateProvider defines a cache able to report how fast it fills, as the growth of its number of entries in
+// entries per second over a recent window, and the predicted time left until its capacity is reached
+type FillRateProvider interface {
+	FillRate() (entriesPerSec float64, timeToFull time.Duration)
+}
+
+// ContextPersister defines a persister whose single key operations honor the deadline and the cancellation of the
+// provided context, returning the context error when the context is done before the operation completes
+type ContextPersister interface {
+	PutCtx(ctx context.Context, key, val []byte) error
+	GetCtx(ctx context.Context, key []byte) ([]byte, error)
+	HasCtx(ctx context.Context, key []byte) error
+	RemoveCtx(ctx context.Context, key []byte) error
+}
+
+// PersistentTimeCacher defines a time cache able to save its keys with their expiry and to load them back
+type PersistentTimeCacher interface {
+	SaveTo(w io.Writer) error
+	LoadFrom(r io.Reader) error
+}
+
+// GetAndRemover defines a cache able to atomically return the value of a key and remove it
+type GetAndRemover interface {
+	GetAndRemove(key []byte) (value interface{}, ok bool)
+}
+
+// CacheStatsExporter defines a cache whose lifetime hit and miss counters can be exported and imported back
+type CacheStatsExporter interface {
+	ExportStats() []byte
+	ImportStats(exported []byte) error
+}
+
 // StorerWithPutInEpoch is an extended storer with the ability to set the epoch which will be used for put operations
 type StorerWithPutInEpoch interface {
 	Storer
@@ -162,6 +289,7 @@ type LRUCacheHandler interface {
 	Keys() []interface{}
 	Len() int
 	Purge()
+	Resize(size int) (evicted int)
 }
 
 // SizedLRUCacheHandler is the interface for size capable LRU cache.
@@ -187,6 +315,15 @@ type TimeCacher interface {
 	IsInterfaceNil() bool
 }
 
+// SeenCacher defines a cache that only records the presence of keys, without storing any value
+type SeenCacher interface {
+	Add(key []byte) bool
+	Contains(key []byte) bool
+	Len() int
+	Clear()
+	IsInterfaceNil() bool
+}
+
 // EvictionHandler defines a component which can be registered on TimeCacher
 type EvictionHandler interface {
 	Evicted(key []byte)
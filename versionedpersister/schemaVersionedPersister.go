@@ -0,0 +1,224 @@
+package versionedpersister
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	logger "github.com/DharitriOne/drt-chain-logger-go"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*schemaVersionedPersister)(nil)
+
+var log = logger.GetOrCreate("storage/versionedpersister")
+
+// ErrNilMigrator signals that a nil migrator was provided
+var ErrNilMigrator = errors.New("nil migrator")
+
+// ErrInvalidMigrationVersion signals that a migration was registered from a version which is not older than the
+// current schema version
+var ErrInvalidMigrationVersion = errors.New("invalid migration version")
+
+// ErrMissingMigration signals that a stored value can not be upgraded as a migration step is not registered
+var ErrMissingMigration = errors.New("missing migration")
+
+// ErrUnknownSchemaVersion signals that a stored value was written with a schema version newer than the current one
+var ErrUnknownSchemaVersion = errors.New("unknown schema version")
+
+// ErrInvalidVersionedValue signals that a stored value does not hold the schema version byte
+var ErrInvalidVersionedValue = errors.New("invalid versioned value")
+
+// Migrator upgrades a value stored with the provided schema version to the next schema version
+type Migrator func(version byte, data []byte) ([]byte, error)
+
+// schemaVersionedPersister prepends the current schema version byte to the written values and upgrades the values
+// written with older schema versions when they are read, by chaining the registered migrations one version at a
+// time. If enabled, the upgraded values are written back, so each old value is migrated only once. All the values
+// of the inner persister should be written through this wrapper, as the first byte of a value is always decoded as
+// its schema version
+type schemaVersionedPersister struct {
+	mutWrite       sync.Mutex
+	inner          types.Persister
+	currentVersion byte
+	rewriteOnRead  bool
+
+	mutMigrations sync.RWMutex
+	migrations    map[byte]Migrator
+}
+
+// NewSchemaVersionedPersister creates a persister wrapper tagging the values with the current schema version. If
+// rewriteOnRead is set, the values upgraded by Get are written back with the current schema version
+func NewSchemaVersionedPersister(
+	inner types.Persister,
+	currentVersion byte,
+	rewriteOnRead bool,
+) (*schemaVersionedPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+
+	return &schemaVersionedPersister{
+		inner:          inner,
+		currentVersion: currentVersion,
+		rewriteOnRead:  rewriteOnRead,
+		migrations:     make(map[byte]Migrator),
+	}, nil
+}
+
+// RegisterMigration registers the migrator upgrading the values from the provided schema version to the next one,
+// replacing the migrator previously registered for the same version, if any
+func (vp *schemaVersionedPersister) RegisterMigration(fromVersion byte, fn Migrator) error {
+	if fn == nil {
+		return ErrNilMigrator
+	}
+	if fromVersion >= vp.currentVersion {
+		return fmt.Errorf("%w: %d, current version %d", ErrInvalidMigrationVersion, fromVersion, vp.currentVersion)
+	}
+
+	vp.mutMigrations.Lock()
+	vp.migrations[fromVersion] = fn
+	vp.mutMigrations.Unlock()
+
+	return nil
+}
+
+// Put adds the value tagged with the current schema version
+func (vp *schemaVersionedPersister) Put(key, val []byte) error {
+	vp.mutWrite.Lock()
+	defer vp.mutWrite.Unlock()
+
+	return vp.inner.Put(key, vp.encode(val))
+}
+
+func (vp *schemaVersionedPersister) encode(val []byte) []byte {
+	tagged := make([]byte, 0, len(val)+1)
+	tagged = append(tagged, vp.currentVersion)
+
+	return append(tagged, val...)
+}
+
+// Get returns the value of the key, upgraded to the current schema version
+func (vp *schemaVersionedPersister) Get(key []byte) ([]byte, error) {
+	stored, err := vp.inner.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	val, migrated, err := vp.decode(stored)
+	if err != nil {
+		return nil, err
+	}
+	if migrated && vp.rewriteOnRead {
+		vp.rewrite(key, stored, val)
+	}
+
+	return val, nil
+}
+
+// decode strips the schema version of the stored value and runs the migrations needed to bring it to the current
+// schema version, returning whether any migration ran
+func (vp *schemaVersionedPersister) decode(stored []byte) ([]byte, bool, error) {
+	if len(stored) == 0 {
+		return nil, false, ErrInvalidVersionedValue
+	}
+
+	version := stored[0]
+	if version > vp.currentVersion {
+		return nil, false, fmt.Errorf("%w: %d, current version %d", ErrUnknownSchemaVersion, version, vp.currentVersion)
+	}
+
+	val := stored[1:]
+	if version == vp.currentVersion {
+		return val, false, nil
+	}
+
+	vp.mutMigrations.RLock()
+	defer vp.mutMigrations.RUnlock()
+
+	for ; version < vp.currentVersion; version++ {
+		migrator, ok := vp.migrations[version]
+		if !ok {
+			return nil, false, fmt.Errorf("%w: from version %d", ErrMissingMigration, version)
+		}
+
+		var err error
+		val, err = migrator(version, val)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return val, true, nil
+}
+
+// rewrite writes back the upgraded value, unless the stored value was changed since it was read. A failed rewrite
+// is only logged, as the upgraded value can still be served
+func (vp *schemaVersionedPersister) rewrite(key []byte, stored []byte, upgraded []byte) {
+	vp.mutWrite.Lock()
+	defer vp.mutWrite.Unlock()
+
+	current, err := vp.inner.Get(key)
+	if err != nil || !bytes.Equal(current, stored) {
+		return
+	}
+
+	err = vp.inner.Put(key, vp.encode(upgraded))
+	if err != nil {
+		log.Warn("schemaVersionedPersister: cannot rewrite the migrated value", "key", key, "error", err)
+	}
+}
+
+// Has returns nil if the given key is present in the persistence medium
+func (vp *schemaVersionedPersister) Has(key []byte) error {
+	return vp.inner.Has(key)
+}
+
+// Remove removes the data associated to the given key
+func (vp *schemaVersionedPersister) Remove(key []byte) error {
+	vp.mutWrite.Lock()
+	defer vp.mutWrite.Unlock()
+
+	return vp.inner.Remove(key)
+}
+
+// RangeKeys iterates over the pairs, the values being upgraded to the current schema version without being
+// written back. The values which can not be upgraded are skipped
+func (vp *schemaVersionedPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	vp.inner.RangeKeys(func(key []byte, stored []byte) bool {
+		val, _, err := vp.decode(stored)
+		if err != nil {
+			log.Warn("schemaVersionedPersister: cannot decode value", "key", key, "error", err)
+			return true
+		}
+
+		return handler(key, val)
+	})
+}
+
+// Close closes the inner persister
+func (vp *schemaVersionedPersister) Close() error {
+	return vp.inner.Close()
+}
+
+// Destroy removes the inner persister data
+func (vp *schemaVersionedPersister) Destroy() error {
+	return vp.inner.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister data
+func (vp *schemaVersionedPersister) DestroyClosed() error {
+	return vp.inner.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (vp *schemaVersionedPersister) IsInterfaceNil() bool {
+	return vp == nil
+}
@@ -0,0 +1,157 @@
+package versionedpersister_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/versionedpersister"
+	"github.com/stretchr/testify/require"
+)
+
+// appendMigrator upgrades a value by appending the version it was upgraded from
+func appendMigrator(version byte, data []byte) ([]byte, error) {
+	return append(append([]byte(nil), data...), '0'+version), nil
+}
+
+func TestNewSchemaVersionedPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		vp, err := versionedpersister.NewSchemaVersionedPersister(nil, 1, false)
+		require.True(t, check.IfNil(vp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		vp, err := versionedpersister.NewSchemaVersionedPersister(memorydb.New(), 1, false)
+		require.False(t, check.IfNil(vp))
+		require.Nil(t, err)
+	})
+}
+
+func TestSchemaVersionedPersister_RegisterMigration(t *testing.T) {
+	t.Parallel()
+
+	vp, _ := versionedpersister.NewSchemaVersionedPersister(memorydb.New(), 2, false)
+	require.Equal(t, versionedpersister.ErrNilMigrator, vp.RegisterMigration(0, nil))
+	err := vp.RegisterMigration(2, appendMigrator)
+	require.True(t, errors.Is(err, versionedpersister.ErrInvalidMigrationVersion))
+	require.Nil(t, vp.RegisterMigration(1, appendMigrator))
+}
+
+func TestSchemaVersionedPersister_PutShouldTagTheCurrentVersion(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	vp, _ := versionedpersister.NewSchemaVersionedPersister(inner, 3, false)
+
+	require.Nil(t, vp.Put([]byte("key"), []byte("value")))
+	stored, _ := inner.Get([]byte("key"))
+	require.Equal(t, append([]byte{3}, "value"...), stored)
+
+	val, err := vp.Get([]byte("key"))
+	require.Nil(t, err)
+	require.Equal(t, []byte("value"), val)
+}
+
+func TestSchemaVersionedPersister_GetShouldChainTheMigrations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without rewrite", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		_ = inner.Put([]byte("key"), append([]byte{0}, "value"...))
+		vp, _ := versionedpersister.NewSchemaVersionedPersister(inner, 2, false)
+		_ = vp.RegisterMigration(0, appendMigrator)
+		_ = vp.RegisterMigration(1, appendMigrator)
+
+		val, err := vp.Get([]byte("key"))
+		require.Nil(t, err)
+		require.Equal(t, []byte("value01"), val)
+
+		stored, _ := inner.Get([]byte("key"))
+		require.Equal(t, append([]byte{0}, "value"...), stored)
+	})
+	t.Run("with rewrite", func(t *testing.T) {
+		t.Parallel()
+
+		inner := memorydb.New()
+		_ = inner.Put([]byte("key"), append([]byte{1}, "value"...))
+		vp, _ := versionedpersister.NewSchemaVersionedPersister(inner, 2, true)
+		numCalls := 0
+		_ = vp.RegisterMigration(1, func(version byte, data []byte) ([]byte, error) {
+			numCalls++
+			return appendMigrator(version, data)
+		})
+
+		for i := 0; i < 2; i++ {
+			val, err := vp.Get([]byte("key"))
+			require.Nil(t, err)
+			require.Equal(t, []byte("value1"), val)
+		}
+		require.Equal(t, 1, numCalls)
+
+		stored, _ := inner.Get([]byte("key"))
+		require.Equal(t, append([]byte{2}, "value1"...), stored)
+	})
+}
+
+func TestSchemaVersionedPersister_GetErrors(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	vp, _ := versionedpersister.NewSchemaVersionedPersister(inner, 2, true)
+	expectedErr := errors.New("expected error")
+	_ = vp.RegisterMigration(1, func(version byte, data []byte) ([]byte, error) {
+		return nil, expectedErr
+	})
+
+	_ = inner.Put([]byte("empty"), []byte{})
+	_, err := vp.Get([]byte("empty"))
+	require.Equal(t, versionedpersister.ErrInvalidVersionedValue, err)
+
+	_ = inner.Put([]byte("newer"), []byte{3, 'v'})
+	_, err = vp.Get([]byte("newer"))
+	require.True(t, errors.Is(err, versionedpersister.ErrUnknownSchemaVersion))
+
+	_ = inner.Put([]byte("unregistered"), []byte{0, 'v'})
+	_, err = vp.Get([]byte("unregistered"))
+	require.True(t, errors.Is(err, versionedpersister.ErrMissingMigration))
+
+	_ = inner.Put([]byte("failing"), []byte{1, 'v'})
+	_, err = vp.Get([]byte("failing"))
+	require.Equal(t, expectedErr, err)
+	stored, _ := inner.Get([]byte("failing"))
+	require.Equal(t, []byte{1, 'v'}, stored)
+
+	_, err = vp.Get([]byte("missing"))
+	require.True(t, errors.Is(err, common.ErrKeyNotFound))
+}
+
+func TestSchemaVersionedPersister_RangeKeysShouldUpgradeTheValues(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	vp, _ := versionedpersister.NewSchemaVersionedPersister(inner, 1, true)
+	_ = vp.RegisterMigration(0, appendMigrator)
+	_ = inner.Put([]byte("old"), append([]byte{0}, "value"...))
+	_ = inner.Put([]byte("invalid"), []byte{5})
+	_ = vp.Put([]byte("new"), []byte("value"))
+
+	pairs := make(map[string]string)
+	vp.RangeKeys(func(key []byte, val []byte) bool {
+		pairs[string(key)] = string(val)
+		return true
+	})
+	require.Equal(t, map[string]string{"old": "value0", "new": "value"}, pairs)
+
+	stored, _ := inner.Get([]byte("old"))
+	require.Equal(t, append([]byte{0}, "value"...), stored)
+}
@@ -0,0 +1,397 @@
+package versionedpersister
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/types"
+)
+
+var _ types.Persister = (*versionHistoryPersister)(nil)
+
+// ErrInvalidKeepVersions signals that a number of kept versions lower than 1 was provided
+var ErrInvalidKeepVersions = errors.New("invalid number of kept versions")
+
+// ErrInvalidVersionBack signals that a negative number of updates back was provided
+var ErrInvalidVersionBack = errors.New("invalid number of updates back")
+
+// ErrVersionNotFound signals that the requested version of a key is not retained
+var ErrVersionNotFound = errors.New("version not found")
+
+// ErrNoPreviousVersion signals that a key can not be rolled back as no previous version is retained
+var ErrNoPreviousVersion = errors.New("no previous version")
+
+// ErrInvalidVersionRecord signals that a stored version record could not be decoded
+var ErrInvalidVersionRecord = errors.New("invalid version record")
+
+const (
+	currentPrefix = byte(0)
+	historyPrefix = byte(1)
+
+	versionSeqLength = 8
+
+	flagValue     = byte(0)
+	flagTombstone = byte(1)
+)
+
+// versionHistoryPersister keeps, for each key, the current version and up to keepVersions-1 previous versions,
+// so the last updates of a key can be read back and undone. The current version is stored under the key prefixed
+// by a marker byte, together with its version number, while each previous version is stored under a history key
+// made of another marker byte, the varint encoded key length, the key and the big endian version number.
+// Remove writes a tombstone as the new current version, so a removal can be rolled back as well. The versions
+// older than the kept ones are pruned when a new version is written. The inner persister should be dedicated to
+// this wrapper. If it implements types.BatchApplier, each update is written atomically
+type versionHistoryPersister struct {
+	mutWrite     sync.Mutex
+	inner        types.Persister
+	keepVersions int
+}
+
+// NewVersionedPersister creates a persister wrapper keeping the most recent keepVersions versions of each
+// key, the current one included. Keeping a single version disables the history
+func NewVersionedPersister(inner types.Persister, keepVersions int) (*versionHistoryPersister, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilPersister
+	}
+	if keepVersions < 1 {
+		return nil, ErrInvalidKeepVersions
+	}
+
+	return &versionHistoryPersister{
+		inner:        inner,
+		keepVersions: keepVersions,
+	}, nil
+}
+
+type currentVersion struct {
+	seq       uint64
+	tombstone bool
+	value     []byte
+}
+
+func currentKey(key []byte) []byte {
+	prefixed := make([]byte, 0, len(key)+1)
+	prefixed = append(prefixed, currentPrefix)
+
+	return append(prefixed, key...)
+}
+
+func historyKey(key []byte, seq uint64) []byte {
+	prefixed := make([]byte, 1+binary.MaxVarintLen64, 1+binary.MaxVarintLen64+len(key)+versionSeqLength)
+	prefixed[0] = historyPrefix
+	n := binary.PutUvarint(prefixed[1:], uint64(len(key)))
+	prefixed = prefixed[:1+n]
+	prefixed = append(prefixed, key...)
+
+	return binary.BigEndian.AppendUint64(prefixed, seq)
+}
+
+func encodeVersion(tombstone bool, value []byte) []byte {
+	flag := flagValue
+	if tombstone {
+		flag = flagTombstone
+	}
+
+	encoded := make([]byte, 0, len(value)+1)
+	encoded = append(encoded, flag)
+
+	return append(encoded, value...)
+}
+
+func decodeVersion(encoded []byte) (bool, []byte, error) {
+	if len(encoded) == 0 || encoded[0] > flagTombstone {
+		return false, nil, ErrInvalidVersionRecord
+	}
+
+	return encoded[0] == flagTombstone, encoded[1:], nil
+}
+
+func encodeCurrent(cv currentVersion) []byte {
+	encoded := make([]byte, versionSeqLength, versionSeqLength+len(cv.value)+1)
+	binary.BigEndian.PutUint64(encoded, cv.seq)
+
+	return append(encoded, encodeVersion(cv.tombstone, cv.value)...)
+}
+
+func decodeCurrent(encoded []byte) (currentVersion, error) {
+	if len(encoded) < versionSeqLength {
+		return currentVersion{}, ErrInvalidVersionRecord
+	}
+
+	tombstone, value, err := decodeVersion(encoded[versionSeqLength:])
+	if err != nil {
+		return currentVersion{}, err
+	}
+
+	return currentVersion{
+		seq:       binary.BigEndian.Uint64(encoded[:versionSeqLength]),
+		tombstone: tombstone,
+		value:     value,
+	}, nil
+}
+
+// getCurrent returns the current version of the key and whether it exists, a tombstone counting as existing
+func (vhp *versionHistoryPersister) getCurrent(key []byte) (currentVersion, bool, error) {
+	encoded, err := vhp.inner.Get(currentKey(key))
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return currentVersion{}, false, nil
+	}
+	if err != nil {
+		return currentVersion{}, false, err
+	}
+
+	cv, err := decodeCurrent(encoded)
+	if err != nil {
+		return currentVersion{}, false, fmt.Errorf("%w for key %x", err, key)
+	}
+
+	return cv, true, nil
+}
+
+// Put writes the value as the new current version of the key, moving the previous one in the history
+func (vhp *versionHistoryPersister) Put(key, val []byte) error {
+	return vhp.writeVersion(key, false, val)
+}
+
+// Remove writes a tombstone as the new current version of the key, moving the previous one in the history. Removing
+// a missing or already removed key does nothing. Without a history, the key is removed right away
+func (vhp *versionHistoryPersister) Remove(key []byte) error {
+	if vhp.keepVersions == 1 {
+		vhp.mutWrite.Lock()
+		defer vhp.mutWrite.Unlock()
+
+		return vhp.inner.Remove(currentKey(key))
+	}
+
+	return vhp.writeVersion(key, true, nil)
+}
+
+func (vhp *versionHistoryPersister) writeVersion(key []byte, tombstone bool, val []byte) error {
+	vhp.mutWrite.Lock()
+	defer vhp.mutWrite.Unlock()
+
+	cv, exists, err := vhp.getCurrent(key)
+	if err != nil {
+		return err
+	}
+	if tombstone && (!exists || cv.tombstone) {
+		return nil
+	}
+	if !exists {
+		return vhp.write([]types.Operation{{
+			Type:  types.PutOperation,
+			Key:   currentKey(key),
+			Value: encodeCurrent(currentVersion{tombstone: tombstone, value: val}),
+		}})
+	}
+
+	// the history holds the versions newSeq-keepVersions+1 to newSeq-1, so the version newSeq-keepVersions is pruned
+	newSeq := cv.seq + 1
+	ops := make([]types.Operation, 0, 3)
+	if vhp.keepVersions > 1 {
+		ops = append(ops, types.Operation{
+			Type:  types.PutOperation,
+			Key:   historyKey(key, cv.seq),
+			Value: encodeVersion(cv.tombstone, cv.value),
+		})
+		if newSeq >= uint64(vhp.keepVersions) {
+			ops = append(ops, types.Operation{
+				Type: types.RemoveOperation,
+				Key:  historyKey(key, newSeq-uint64(vhp.keepVersions)),
+			})
+		}
+	}
+
+	// the current version is written last, so without atomic batches a failed write leaves at most an extra
+	// history entry, overwritten by the next update
+	ops = append(ops, types.Operation{
+		Type:  types.PutOperation,
+		Key:   currentKey(key),
+		Value: encodeCurrent(currentVersion{seq: newSeq, tombstone: tombstone, value: val}),
+	})
+
+	return vhp.write(ops)
+}
+
+func (vhp *versionHistoryPersister) write(ops []types.Operation) error {
+	batchApplier, ok := vhp.inner.(types.BatchApplier)
+	if ok {
+		return batchApplier.ApplyBatch(ops)
+	}
+
+	for _, op := range ops {
+		var err error
+		if op.Type == types.PutOperation {
+			err = vhp.inner.Put(op.Key, op.Value)
+		} else {
+			err = vhp.inner.Remove(op.Key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the current version of the key
+func (vhp *versionHistoryPersister) Get(key []byte) ([]byte, error) {
+	return vhp.GetVersion(key, 0)
+}
+
+// GetVersion returns the version of the key written the provided number of updates ago, 0 returning the current
+// version. A removal counts as an update, the versions removed being reported as ErrKeyNotFound. It returns
+// ErrVersionNotFound if the requested version is not retained
+func (vhp *versionHistoryPersister) GetVersion(key []byte, back int) ([]byte, error) {
+	if back < 0 {
+		return nil, ErrInvalidVersionBack
+	}
+
+	cv, exists, err := vhp.getCurrent(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, common.ErrKeyNotFound
+	}
+	if back == 0 {
+		if cv.tombstone {
+			return nil, common.ErrKeyNotFound
+		}
+
+		return cv.value, nil
+	}
+	if back >= vhp.keepVersions || uint64(back) > cv.seq {
+		return nil, fmt.Errorf("%w: %d updates back for key %x", ErrVersionNotFound, back, key)
+	}
+
+	encoded, err := vhp.inner.Get(historyKey(key, cv.seq-uint64(back)))
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: %d updates back for key %x", ErrVersionNotFound, back, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tombstone, value, err := decodeVersion(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w for key %x", err, key)
+	}
+	if tombstone {
+		return nil, common.ErrKeyNotFound
+	}
+
+	return value, nil
+}
+
+// Rollback discards the current version of the key and restores the previous one. It returns ErrNoPreviousVersion
+// if no previous version is retained
+func (vhp *versionHistoryPersister) Rollback(key []byte) error {
+	vhp.mutWrite.Lock()
+	defer vhp.mutWrite.Unlock()
+
+	cv, exists, err := vhp.getCurrent(key)
+	if err != nil {
+		return err
+	}
+	if !exists || cv.seq == 0 || vhp.keepVersions == 1 {
+		return ErrNoPreviousVersion
+	}
+
+	previousKey := historyKey(key, cv.seq-1)
+	encoded, err := vhp.inner.Get(previousKey)
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return ErrNoPreviousVersion
+	}
+	if err != nil {
+		return err
+	}
+
+	tombstone, value, err := decodeVersion(encoded)
+	if err != nil {
+		return fmt.Errorf("%w for key %x", err, key)
+	}
+
+	return vhp.write([]types.Operation{
+		{
+			Type:  types.PutOperation,
+			Key:   currentKey(key),
+			Value: encodeCurrent(currentVersion{seq: cv.seq - 1, tombstone: tombstone, value: value}),
+		},
+		{
+			Type: types.RemoveOperation,
+			Key:  previousKey,
+		},
+	})
+}
+
+// Has returns nil if the current version of the key is present and is not a removal
+func (vhp *versionHistoryPersister) Has(key []byte) error {
+	cv, exists, err := vhp.getCurrent(key)
+	if err != nil {
+		return err
+	}
+	if !exists || cv.tombstone {
+		return common.ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// RangeKeys iterates over the current versions of the keys, skipping the removed ones
+func (vhp *versionHistoryPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	prefix := []byte{currentPrefix}
+	rangeHandler := func(key []byte, encoded []byte) bool {
+		if !bytes.HasPrefix(key, prefix) {
+			return true
+		}
+
+		cv, err := decodeCurrent(encoded)
+		if err != nil {
+			log.Warn("versionHistoryPersister: cannot decode value", "key", key[1:], "error", err)
+			return true
+		}
+		if cv.tombstone {
+			return true
+		}
+
+		return handler(key[1:], cv.value)
+	}
+
+	prefixRanger, ok := vhp.inner.(types.PrefixRanger)
+	if ok {
+		prefixRanger.RangePrefix(prefix, rangeHandler)
+		return
+	}
+
+	vhp.inner.RangeKeys(rangeHandler)
+}
+
+// Close closes the inner persister
+func (vhp *versionHistoryPersister) Close() error {
+	return vhp.inner.Close()
+}
+
+// Destroy removes the inner persister data
+func (vhp *versionHistoryPersister) Destroy() error {
+	return vhp.inner.Destroy()
+}
+
+// DestroyClosed removes the already closed inner persister data
+func (vhp *versionHistoryPersister) DestroyClosed() error {
+	return vhp.inner.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (vhp *versionHistoryPersister) IsInterfaceNil() bool {
+	return vhp == nil
+}
@@ -0,0 +1,191 @@
+package versionedpersister_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DharitriOne/drt-chain-core-go/core/check"
+	"github.com/DharitriOne/drt-chain-storage-go/common"
+	"github.com/DharitriOne/drt-chain-storage-go/memorydb"
+	"github.com/DharitriOne/drt-chain-storage-go/testscommon"
+	"github.com/DharitriOne/drt-chain-storage-go/versionedpersister"
+	"github.com/stretchr/testify/require"
+)
+
+func countKeys(db *memorydb.DB) int {
+	numKeys := 0
+	db.RangeKeys(func(_ []byte, _ []byte) bool {
+		numKeys++
+		return true
+	})
+
+	return numKeys
+}
+
+func TestNewVersionedPersister(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil persister should error", func(t *testing.T) {
+		t.Parallel()
+
+		vhp, err := versionedpersister.NewVersionedPersister(nil, 2)
+		require.True(t, check.IfNil(vhp))
+		require.Equal(t, common.ErrNilPersister, err)
+	})
+	t.Run("invalid keep versions should error", func(t *testing.T) {
+		t.Parallel()
+
+		vhp, err := versionedpersister.NewVersionedPersister(memorydb.New(), 0)
+		require.True(t, check.IfNil(vhp))
+		require.Equal(t, versionedpersister.ErrInvalidKeepVersions, err)
+	})
+	t.Run("should work", func(t *testing.T) {
+		t.Parallel()
+
+		vhp, err := versionedpersister.NewVersionedPersister(memorydb.New(), 2)
+		require.False(t, check.IfNil(vhp))
+		require.Nil(t, err)
+	})
+}
+
+func TestVersionHistoryPersister_GetVersion(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	vhp, _ := versionedpersister.NewVersionedPersister(inner, 3)
+	key := []byte("key")
+	for i := 0; i < 5; i++ {
+		require.Nil(t, vhp.Put(key, []byte(fmt.Sprintf("v%d", i))))
+	}
+
+	val, err := vhp.Get(key)
+	require.Nil(t, err)
+	require.Equal(t, []byte("v4"), val)
+	for back := 0; back < 3; back++ {
+		val, err = vhp.GetVersion(key, back)
+		require.Nil(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("v%d", 4-back)), val)
+	}
+
+	_, err = vhp.GetVersion(key, 3)
+	require.True(t, errors.Is(err, versionedpersister.ErrVersionNotFound))
+	_, err = vhp.GetVersion(key, -1)
+	require.Equal(t, versionedpersister.ErrInvalidVersionBack, err)
+	_, err = vhp.GetVersion([]byte("missing"), 0)
+	require.Equal(t, common.ErrKeyNotFound, err)
+
+	// the current version and the 2 kept previous versions
+	require.Equal(t, 3, countKeys(inner))
+}
+
+func TestVersionHistoryPersister_Rollback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should restore the previous versions", func(t *testing.T) {
+		t.Parallel()
+
+		vhp, _ := versionedpersister.NewVersionedPersister(memorydb.New(), 3)
+		key := []byte("key")
+		for i := 0; i < 4; i++ {
+			_ = vhp.Put(key, []byte(fmt.Sprintf("v%d", i)))
+		}
+
+		require.Nil(t, vhp.Rollback(key))
+		val, _ := vhp.Get(key)
+		require.Equal(t, []byte("v2"), val)
+
+		require.Nil(t, vhp.Rollback(key))
+		val, _ = vhp.Get(key)
+		require.Equal(t, []byte("v1"), val)
+
+		require.Equal(t, versionedpersister.ErrNoPreviousVersion, vhp.Rollback(key))
+
+		_ = vhp.Put(key, []byte("v5"))
+		val, _ = vhp.GetVersion(key, 1)
+		require.Equal(t, []byte("v1"), val)
+	})
+	t.Run("first version can not be rolled back", func(t *testing.T) {
+		t.Parallel()
+
+		vhp, _ := versionedpersister.NewVersionedPersister(memorydb.New(), 3)
+		require.Equal(t, versionedpersister.ErrNoPreviousVersion, vhp.Rollback([]byte("key")))
+
+		_ = vhp.Put([]byte("key"), []byte("value"))
+		require.Equal(t, versionedpersister.ErrNoPreviousVersion, vhp.Rollback([]byte("key")))
+	})
+	t.Run("removal should be rolled back", func(t *testing.T) {
+		t.Parallel()
+
+		vhp, _ := versionedpersister.NewVersionedPersister(memorydb.New(), 2)
+		key := []byte("key")
+		_ = vhp.Put(key, []byte("value"))
+		require.Nil(t, vhp.Remove(key))
+
+		_, err := vhp.Get(key)
+		require.Equal(t, common.ErrKeyNotFound, err)
+		require.Equal(t, common.ErrKeyNotFound, vhp.Has(key))
+		val, err := vhp.GetVersion(key, 1)
+		require.Nil(t, err)
+		require.Equal(t, []byte("value"), val)
+
+		require.Nil(t, vhp.Rollback(key))
+		require.Nil(t, vhp.Has(key))
+		val, _ = vhp.Get(key)
+		require.Equal(t, []byte("value"), val)
+	})
+}
+
+func TestVersionHistoryPersister_SingleVersionShouldNotKeepHistory(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	vhp, _ := versionedpersister.NewVersionedPersister(inner, 1)
+	key := []byte("key")
+	_ = vhp.Put(key, []byte("v0"))
+	_ = vhp.Put(key, []byte("v1"))
+
+	require.Equal(t, 1, countKeys(inner))
+	_, err := vhp.GetVersion(key, 1)
+	require.True(t, errors.Is(err, versionedpersister.ErrVersionNotFound))
+	require.Equal(t, versionedpersister.ErrNoPreviousVersion, vhp.Rollback(key))
+
+	require.Nil(t, vhp.Remove(key))
+	require.Equal(t, 0, countKeys(inner))
+}
+
+func TestVersionHistoryPersister_RangeKeysShouldIterateTheCurrentVersions(t *testing.T) {
+	t.Parallel()
+
+	vhp, _ := versionedpersister.NewVersionedPersister(memorydb.New(), 3)
+	_ = vhp.Put([]byte("a"), []byte("a0"))
+	_ = vhp.Put([]byte("a"), []byte("a1"))
+	_ = vhp.Put([]byte("b"), []byte("b0"))
+	_ = vhp.Put([]byte("c"), []byte("c0"))
+	_ = vhp.Remove([]byte("c"))
+
+	pairs := make(map[string]string)
+	vhp.RangeKeys(func(key []byte, val []byte) bool {
+		pairs[string(key)] = string(val)
+		return true
+	})
+	require.Equal(t, map[string]string{"a": "a1", "b": "b0"}, pairs)
+}
+
+func TestVersionHistoryPersister_FailedWriteShouldKeepTheCurrentVersion(t *testing.T) {
+	t.Parallel()
+
+	inner := memorydb.New()
+	expectedErr := errors.New("expected error")
+	failing := &testscommon.PersisterStub{
+		GetCalled: inner.Get,
+		PutCalled: func(key, val []byte) error {
+			return expectedErr
+		},
+	}
+	vhp, _ := versionedpersister.NewVersionedPersister(failing, 2)
+
+	require.Equal(t, expectedErr, vhp.Put([]byte("key"), []byte("value")))
+	_, err := vhp.Get([]byte("key"))
+	require.Equal(t, common.ErrKeyNotFound, err)
+}